@@ -0,0 +1,189 @@
+// Package cannect runs the orders of the CA assets like the cannect command,
+// for the Go programs embedding cannect instead of running the command. The
+// catalogs and the orders are built with the catalog and the order packages,
+// and the catalog shared by the orders is wrapped with order.NewSharedCatalog
+// so it is fetched once. The config of the command is built into the Runner
+// with LoadConfig and BuildOrders.
+package cannect
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultConcurrency is the default limit of the concurrent orders, the same
+// as the default of the -con-limit option of the command.
+const DefaultConcurrency = 5
+
+// ErrHook is matched by the Error of the hook failed after the order.
+var ErrHook = errors.New("hook failed")
+
+// Error is the failure of the order run by the Runner, so the programs tell
+// the stages apart with errors.Is instead of the messages. It matches
+// catalog.ErrFetch if the source is missing or unreachable, catalog.ErrContent
+// if the content is invalid, order.ErrWrite if the destination is unwritable,
+// and ErrHook if the hook fails. The failure of the order, other than the one
+// of fetching or checking the contents, or the cancellation, is the one of
+// writing them. The URI parse failures are uri.ErrInvalidURI, before the
+// orders are built.
+type Error struct {
+	// Name is the name of the order.
+	Name  string
+	Err   error
+	stage error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.stage != nil && target == e.stage
+}
+
+// newError returns the Error of the order failed at the stage, which is
+// found from the err if it is nil. The order running its own hook, like the
+// post-write command of the CLI, returns the error matching ErrHook.
+func newError(ctx context.Context, name string, err, stage error) *Error {
+	if stage == nil && errors.Is(err, ErrHook) {
+		stage = ErrHook
+	}
+	if stage == nil && !errors.Is(err, catalogapi.ErrFetch) && !errors.Is(err, catalogapi.ErrContent) &&
+		!errors.Is(err, orderapi.ErrWrite) && ctx.Err() == nil {
+		stage = orderapi.ErrWrite
+	}
+
+	return &Error{Name: name, Err: err, stage: stage}
+}
+
+// Hook is called after each order succeeds, with the name of the order. The
+// error of the hook fails the order.
+type Hook func(ctx context.Context, name string) error
+
+// Runner runs the orders concurrently, like the cannect command.
+type Runner struct {
+	orders    []namedOrder
+	conLimit  int
+	logger    *log.Logger
+	hooks     []Hook
+	onFailure func(name string, err error)
+}
+
+type namedOrder struct {
+	name  string
+	order orderapi.Order
+}
+
+func NewRunner() *Runner {
+	runner := &Runner{
+		conLimit: DefaultConcurrency,
+	}
+
+	return runner
+}
+
+// Add adds the order. The name identifies the order in the logs and the hooks,
+// like the URI of the destination.
+func (r *Runner) Add(name string, order orderapi.Order) *Runner {
+	r.orders = append(r.orders, namedOrder{name: name, order: order})
+	return r
+}
+
+// WithConcurrency sets the limit of the concurrent orders. The orders are not
+// limited if it is not positive.
+func (r *Runner) WithConcurrency(n int) *Runner {
+	r.conLimit = n
+	return r
+}
+
+// WithLogger makes the Runner log the result of each order.
+func (r *Runner) WithLogger(l *log.Logger) *Runner {
+	r.logger = l
+	return r
+}
+
+// WithHooks adds the hooks called after each order succeeds, in the order they
+// are added.
+func (r *Runner) WithHooks(hooks ...Hook) *Runner {
+	r.hooks = append(r.hooks, hooks...)
+	return r
+}
+
+// WithKeepGoing makes the Runner let the other orders complete when an order
+// fails. The failures are handed to the onFailure, which is called
+// concurrently, instead of being returned.
+func (r *Runner) WithKeepGoing(onFailure func(name string, err error)) *Runner {
+	r.onFailure = onFailure
+	return r
+}
+
+// Run runs the orders, and the hooks after each of them. The first failure
+// cancels the other orders and is returned, unless the Runner keeps going.
+func (r *Runner) Run(ctx context.Context) error {
+	g, gCtx := errgroup.WithContext(ctx)
+	if r.onFailure != nil {
+		// The failure of an order does not cancel the others.
+		g, gCtx = new(errgroup.Group), ctx
+	}
+
+	var limit chan struct{}
+	if r.conLimit > 0 {
+		limit = make(chan struct{}, r.conLimit)
+	}
+
+	for _, o := range r.orders {
+		o := o
+		g.Go(func() error {
+			if limit != nil {
+				limit <- struct{}{}
+				defer func() { <-limit }()
+			}
+
+			err := r.order(gCtx, o)
+			if err != nil && r.onFailure != nil {
+				r.onFailure(o.name, err)
+				return nil
+			}
+
+			return err
+		})
+	}
+
+	return g.Wait()
+}
+
+// order runs the order and the hooks, and logs the result. The failure is
+// returned as the Error.
+func (r *Runner) order(ctx context.Context, o namedOrder) error {
+	var err error
+	if oErr := o.order.Order(ctx); oErr != nil {
+		err = newError(ctx, o.name, oErr, nil)
+	} else {
+		for _, hook := range r.hooks {
+			hErr := hook(ctx, o.name)
+			if hErr != nil {
+				err = newError(ctx, o.name, hErr, ErrHook)
+				break
+			}
+		}
+	}
+
+	if r.logger != nil {
+		if err != nil {
+			r.logger.Printf("%s: %v", o.name, err)
+		} else {
+			r.logger.Printf("%s: ordered", o.name)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,200 @@
+package cannect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+var errTestOrder = errors.New("test order failed")
+
+// testCounter counts the concurrent orders.
+type testCounter struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+// testOrder waits until the context is done if wait is true, and fails with
+// the err.
+type testOrder struct {
+	counter *testCounter
+	wait    bool
+	err     error
+}
+
+func (o testOrder) Order(ctx context.Context) error {
+	if o.counter != nil {
+		o.counter.mu.Lock()
+		o.counter.current++
+		if o.counter.current > o.counter.max {
+			o.counter.max = o.counter.current
+		}
+		o.counter.mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		o.counter.mu.Lock()
+		o.counter.current--
+		o.counter.mu.Unlock()
+	}
+
+	if o.wait {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return o.err
+}
+
+func TestRunner_Run(t *testing.T) {
+	t.Parallel()
+
+	counter := &testCounter{}
+	var mu sync.Mutex
+	var hooked []string
+
+	runner := NewRunner().WithConcurrency(2).WithHooks(func(ctx context.Context, name string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		hooked = append(hooked, name)
+		return nil
+	})
+	for _, name := range []string{"file://a.crt", "file://b.crt", "file://c.crt", "file://d.crt", "file://e.crt"} {
+		runner = runner.Add(name, testOrder{counter: counter})
+	}
+
+	err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter.max > 2 {
+		t.Errorf("Expected at most 2 concurrent orders but got: %d", counter.max)
+	}
+
+	sort.Strings(hooked)
+	want := []string{"file://a.crt", "file://b.crt", "file://c.crt", "file://d.crt", "file://e.crt"}
+	if diff := cmp.Diff(want, hooked); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRunner_Run_Failure(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase  string
+		keepGoing bool
+		// want
+		err      error
+		failures []string
+	}{
+		{"Cancel", false, errTestOrder, nil},
+		{"Keep Going", true, nil, []string{"file://failed.crt"}},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var failures []string
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			hook := func(ctx context.Context, name string) error {
+				t.Errorf("Unexpected hook of %s", name)
+				return nil
+			}
+			runner := NewRunner().WithHooks(hook).
+				Add("file://failed.crt", testOrder{err: errTestOrder})
+			if d.keepGoing {
+				runner = runner.WithKeepGoing(func(name string, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					if errors.Is(err, errTestOrder) {
+						failures = append(failures, name)
+					}
+				})
+			} else {
+				// The waiting order is canceled by the failure.
+				runner = runner.Add("file://waiting.crt", testOrder{wait: true})
+			}
+
+			err := runner.Run(ctx)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if ctx.Err() != nil {
+				t.Errorf("Expected the waiting order is canceled but got: %v", ctx.Err())
+			}
+			if diff := cmp.Diff(d.failures, failures); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRunner_Run_Error(t *testing.T) {
+	t.Parallel()
+
+	errTestHook := errors.New("test hook failed")
+	fetchErr := catalogapi.NewFetchError("file://root-ca.crt", "root", errTestOrder)
+	contentErr := catalogapi.NewContentError("file://root-ca.crt", "root", errTestOrder)
+
+	data := []struct {
+		testCase string
+		order    testOrder
+		hookErr  error
+		// want
+		stage error
+	}{
+		{"Fetch", testOrder{err: fetchErr}, nil, catalogapi.ErrFetch},
+		{"Content", testOrder{err: contentErr}, nil, catalogapi.ErrContent},
+		{"Write", testOrder{err: errTestOrder}, nil, orderapi.ErrWrite},
+		{"Hook", testOrder{}, errTestHook, ErrHook},
+		{"Order Hook", testOrder{err: fmt.Errorf("nginx -s reload: %w", ErrHook)}, nil, ErrHook},
+	}
+
+	stages := []error{catalogapi.ErrFetch, catalogapi.ErrContent, orderapi.ErrWrite, ErrHook}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			runner := NewRunner().Add("file://ca-bundle.crt", d.order)
+			if d.hookErr != nil {
+				runner = runner.WithHooks(func(ctx context.Context, name string) error {
+					return d.hookErr
+				})
+			}
+
+			err := runner.Run(context.Background())
+			var rErr *Error
+			if !errors.As(err, &rErr) {
+				t.Fatalf("Expected Error but got: %#v", err)
+			}
+			if rErr.Name != "file://ca-bundle.crt" {
+				t.Errorf("Expected the name of the order but got: %s", rErr.Name)
+			}
+			for _, stage := range stages {
+				if errors.Is(err, stage) != (stage == d.stage) {
+					t.Errorf("Expected %v only but got: %v", d.stage, err)
+				}
+			}
+		})
+	}
+}
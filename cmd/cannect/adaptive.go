@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+)
+
+// The retry of the writes refused by the throttling of the destinations.
+const (
+	throttleAttempts  = 5
+	throttleBaseDelay = time.Second
+)
+
+// throttleSchemes are the schemes of the network destinations, whose writes
+// are limited adaptively.
+var throttleSchemes = schemeSet("s3", "gcs", "azblob", "https", "k8s")
+
+// adaptiveLimit limits the writes to a backend in parallel. The limit is
+// halved when a write is throttled, and recovers by one for each limit of the
+// successful writes, so that the large fan-out does not keep hammering the
+// backend.
+type adaptiveLimit struct {
+	mu      sync.Mutex
+	limit   float64
+	max     int
+	active  int
+	changed chan struct{}
+}
+
+func newAdaptiveLimit(max int) *adaptiveLimit {
+	if max < 1 {
+		max = 1
+	}
+
+	return &adaptiveLimit{limit: float64(max), max: max, changed: make(chan struct{})}
+}
+
+// acquire waits until the write can be started within the limit.
+func (a *adaptiveLimit) acquire(ctx context.Context) error {
+	for {
+		a.mu.Lock()
+		if a.active < int(a.limit) {
+			a.active++
+			a.mu.Unlock()
+			return nil
+		}
+		changed := a.changed
+		a.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// release ends the write, and adjusts the limit by whether it is throttled.
+func (a *adaptiveLimit) release(throttled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.active--
+	if throttled {
+		a.limit /= 2
+		if a.limit < 1 {
+			a.limit = 1
+		}
+	} else {
+		a.limit += 1 / a.limit
+		if a.limit > float64(a.max) {
+			a.limit = float64(a.max)
+		}
+	}
+
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+// current returns the number of the writes allowed in parallel.
+func (a *adaptiveLimit) current() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return int(a.limit)
+}
+
+// throttleSet holds the adaptiveLimit of each scheme in a run, since the
+// destinations of a scheme are usually served by the same backend.
+type throttleSet struct {
+	mu        sync.Mutex
+	limits    map[string]*adaptiveLimit
+	max       int
+	baseDelay time.Duration
+}
+
+func newThrottleSet(max int) *throttleSet {
+	return &throttleSet{limits: make(map[string]*adaptiveLimit), max: max, baseDelay: throttleBaseDelay}
+}
+
+func (t *throttleSet) limitOf(scheme string) *adaptiveLimit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limit, ok := t.limits[scheme]
+	if !ok {
+		limit = newAdaptiveLimit(t.max)
+		t.limits[scheme] = limit
+	}
+
+	return limit
+}
+
+// throttledOrder writes to the network destination within the adaptiveLimit
+// of its scheme, and retries the write refused by the throttling after the
+// backoff, instead of failing the order.
+type throttledOrder struct {
+	uriText   string
+	order     Order
+	limit     *adaptiveLimit
+	baseDelay time.Duration
+	l         *log.Logger
+}
+
+// newThrottledOrder returns the order limited adaptively if the URI is of the
+// network destination, or the order as it is.
+func newThrottledOrder(uriText string, order Order, set *throttleSet, l *log.Logger) Order {
+	scheme := schemeapi.Of(uriText)
+	if _, ok := throttleSchemes[scheme]; !ok {
+		return order
+	}
+
+	return &throttledOrder{
+		uriText:   uriText,
+		order:     order,
+		limit:     set.limitOf(scheme),
+		baseDelay: set.baseDelay,
+		l:         l,
+	}
+}
+
+func (t *throttledOrder) Order(ctx context.Context) error {
+	delay := t.baseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := t.limit.acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = t.order.Order(ctx)
+		throttled := orderapi.Throttled(err)
+		t.limit.release(throttled)
+		if !throttled || attempt >= throttleAttempts {
+			return err
+		}
+
+		t.l.Print(msgs.Sprintf(msgThrottled, t.uriText, delay, t.limit.current()))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
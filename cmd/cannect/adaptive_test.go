@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testThrottledError is the error of the destination refusing the write.
+type testThrottledError struct{}
+
+func (testThrottledError) Error() string   { return "429 Too Many Requests" }
+func (testThrottledError) Throttled() bool { return true }
+
+// testThrottledOrder fails with the throttled error for the times, and then
+// succeeds.
+type testThrottledOrder struct {
+	times int
+	calls int
+}
+
+func (o *testThrottledOrder) Order(context.Context) error {
+	o.calls++
+	if o.calls <= o.times {
+		return testThrottledError{}
+	}
+	return nil
+}
+
+func TestAdaptiveLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := newAdaptiveLimit(8)
+	ctx := context.Background()
+
+	for i := 0; i < 8; i++ {
+		if err := limit.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The limit is exceeded.
+	tCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := limit.acquire(tCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %#v error but got: %#v", context.DeadlineExceeded, err)
+	}
+
+	limit.release(true)
+	if got := limit.current(); got != 4 {
+		t.Errorf("Expected the limit 4 but got: %d", got)
+	}
+	for i := 0; i < 7; i++ {
+		limit.release(true)
+	}
+	if got := limit.current(); got != 1 {
+		t.Errorf("Expected the limit 1 but got: %d", got)
+	}
+
+	// The limit recovers with the successful writes, up to the max.
+	for i := 0; i < 100; i++ {
+		if err := limit.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+		limit.release(false)
+	}
+	if got := limit.current(); got != 8 {
+		t.Errorf("Expected the limit 8 but got: %d", got)
+	}
+}
+
+func TestNewThrottledOrder(t *testing.T) {
+	t.Parallel()
+
+	set := newThrottleSet(4)
+	order := &testThrottledOrder{}
+
+	if got := newThrottledOrder("file://out.crt", order, set, log.New(&bytes.Buffer{}, "", 0)); got != order {
+		t.Errorf("Expected the order as it is but got: %#v", got)
+	}
+
+	s3Order, ok := newThrottledOrder("s3://bucket/out.crt", order, set, nil).(*throttledOrder)
+	if !ok {
+		t.Fatal("Expected the throttled order of the s3 scheme")
+	}
+	other, ok := newThrottledOrder("s3://bucket/other.crt", order, set, nil).(*throttledOrder)
+	if !ok || other.limit != s3Order.limit {
+		t.Error("Expected the limit shared by the s3 scheme")
+	}
+}
+
+func TestThrottledOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		times    int
+		// want
+		calls     int
+		throttled bool
+	}{
+		{"OK:Not Throttled", 0, 1, false},
+		{"OK:Recovered", 2, 3, false},
+		{"NG:Given Up", throttleAttempts, throttleAttempts, true},
+	}
+
+	for _, d := range data {
+		var buf bytes.Buffer
+		set := newThrottleSet(4)
+		set.baseDelay = time.Millisecond
+		order := &testThrottledOrder{times: d.times}
+
+		err := newThrottledOrder("https://example.com/bundle", order, set, log.New(&buf, "", 0)).Order(context.Background())
+		if (err != nil) != d.throttled {
+			t.Errorf("%s: Expected throttled %t but got: %#v", d.testCase, d.throttled, err)
+		}
+		if order.calls != d.calls {
+			t.Errorf("%s: Expected %d calls but got: %d", d.testCase, d.calls, order.calls)
+		}
+		if got := strings.Count(buf.String(), "Throttled by https://example.com/bundle"); got != d.calls-1 {
+			t.Errorf("%s: Expected %d retries logged but got: %s", d.testCase, d.calls-1, buf.String())
+		}
+	}
+}
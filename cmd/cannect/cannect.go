@@ -1,50 +1,566 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/yuxki/cannect"
 	"github.com/yuxki/cannect/pkg/asset"
 	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	metricsapi "github.com/yuxki/cannect/pkg/metrics"
 	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	stateapi "github.com/yuxki/cannect/pkg/state"
+	traceapi "github.com/yuxki/cannect/pkg/trace"
+	"github.com/yuxki/cannect/pkg/transform"
 	uriapi "github.com/yuxki/cannect/pkg/uri"
-	"golang.org/x/sync/errgroup"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
 )
 
 type CatalogJSON struct {
-	Alias    string `json:"alias"`
-	URI      string `json:"uri"`
-	Category string `json:"category"`
+	Alias                string         `json:"alias"`
+	URI                  string         `json:"uri"`
+	Category             string         `json:"category,omitempty"`
+	Categories           []string       `json:"categories,omitempty"`
+	CAPolicy             *CAPolicyJSON  `json:"caPolicy,omitempty"`
+	KeyPolicy            *KeyPolicyJSON `json:"keyPolicy,omitempty"`
+	MinRemainingValidity *int           `json:"minRemainingValidity,omitempty"`
+	WarnBefore           *int           `json:"warnBefore,omitempty"`
+	Filter               *FilterJSON    `json:"filter,omitempty"`
+	Range                *RangeJSON     `json:"range,omitempty"`
+	Retry                *RetryJSON     `json:"retry,omitempty"`
+	S3                   *S3JSON        `json:"s3,omitempty"`
+	Timeout              int64          `json:"timeout,omitempty"`
+	Warn                 []string       `json:"warn,omitempty"`
+	Description          string         `json:"description,omitempty"`
+	Owner                string         `json:"owner,omitempty"`
+}
+
+// categories returns the categories of the catalog, that are the Categories,
+// or the Category if the Categories is empty.
+func (c CatalogJSON) categories() []string {
+	if len(c.Categories) > 0 {
+		return c.Categories
+	}
+
+	return []string{c.Category}
+}
+
+// hasCategory reports whether the catalog is of any of the categories.
+func (c CatalogJSON) hasCategory(categories ...string) bool {
+	for _, own := range c.categories() {
+		for _, category := range categories {
+			if own == category {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checker returns the Checker of the category of the catalog. The blocks of
+// the content must be of any of the Categories if they are specified.
+func (c CatalogJSON) checker(custom map[string]asset.Checker) (asset.Checker, error) {
+	if len(c.Categories) == 0 {
+		return cannect.CategoryChecker(c.Category, custom)
+	}
+
+	checkers := make([]asset.Checker, 0, len(c.Categories))
+	for _, category := range c.Categories {
+		checker, err := cannect.CategoryChecker(category, custom)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+
+	return asset.NewMulti(strings.Join(c.Categories, ","), checkers...), nil
+}
+
+// RangeJSON configures the part of the source fetched by the catalog. The
+// source is read to the end if the Length is 0.
+type RangeJSON struct {
+	Offset     int64 `json:"offset,omitempty"`
+	Length     int64 `json:"length,omitempty"`
+	FirstBlock bool  `json:"firstBlock,omitempty"`
+}
+
+func (r *RangeJSON) rng() catalogapi.Range {
+	if r == nil {
+		return catalogapi.Range{}
+	}
+
+	return catalogapi.Range{Offset: r.Offset, Length: r.Length, FirstBlock: r.FirstBlock}
+}
+
+// S3JSON configures the client of the s3 scheme for the S3-compatible object
+// stores, like MinIO. The empty values are of the run, or of the AWS SDK.
+// The role is assumed only by the catalogs.
+type S3JSON struct {
+	Endpoint   string `json:"endpoint,omitempty"`
+	Region     string `json:"region,omitempty"`
+	PathStyle  bool   `json:"pathStyle,omitempty"`
+	RoleARN    string `json:"roleArn,omitempty"`
+	ExternalID string `json:"externalId,omitempty"`
+}
+
+// options returns the options of the AWS SDK, with the S3JSON of the run as the
+// defaults. It returns nil if nothing is configured.
+func (s *S3JSON) options(run S3JSON) []func(*s3.Options) {
+	if s != nil {
+		if s.Endpoint != "" {
+			run.Endpoint = s.Endpoint
+		}
+		if s.Region != "" {
+			run.Region = s.Region
+		}
+		run.PathStyle = run.PathStyle || s.PathStyle
+	}
+	if run == (S3JSON{}) {
+		return nil
+	}
+
+	return []func(*s3.Options){func(o *s3.Options) {
+		if run.Endpoint != "" {
+			o.BaseEndpoint = aws.String(run.Endpoint)
+		}
+		if run.Region != "" {
+			o.Region = run.Region
+		}
+		o.UsePathStyle = o.UsePathStyle || run.PathStyle
+	}}
+}
+
+// assumeRole returns the role the catalog assumes.
+func (s *S3JSON) assumeRole() catalogapi.AssumeRole {
+	if s == nil {
+		return catalogapi.AssumeRole{}
+	}
+
+	return catalogapi.AssumeRole{RoleARN: s.RoleARN, ExternalID: s.ExternalID}
+}
+
+// check reports whether the endpoint is the URL of HTTP or HTTPS, and the role
+// is the ARN of an IAM role.
+func (s *S3JSON) check() error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Endpoint != "" {
+		u, err := url.Parse(s.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s: %w", s.Endpoint, errInvalidS3Endpoint)
+		}
+	}
+
+	if s.ExternalID != "" && s.RoleARN == "" {
+		return errNoRoleARN
+	}
+	if s.RoleARN != "" && !roleARNRegexp.MatchString(s.RoleARN) {
+		return fmt.Errorf("%s: %w", s.RoleARN, errInvalidRoleARN)
+	}
+
+	return nil
+}
+
+// roleARNRegexp matches the ARN of an IAM role, in any partition.
+var roleARNRegexp = regexp.MustCompile(`^arn:[-a-z]+:iam::[0-9]{12}:role/.+$`)
+
+// RetryJSON configures the retry of the remote catalog. The delays are the
+// strings parsed by time.ParseDuration, like "500ms".
+type RetryJSON struct {
+	Attempts   int     `json:"attempts,omitempty"`
+	BaseDelay  string  `json:"baseDelay,omitempty"`
+	Jitter     float64 `json:"jitter,omitempty"`
+	MaxElapsed string  `json:"maxElapsed,omitempty"`
+}
+
+func (r *RetryJSON) retry() (catalogapi.Retry, error) {
+	if r == nil {
+		return catalogapi.Retry{}, nil
+	}
+
+	if r.Attempts < 0 {
+		return catalogapi.Retry{}, errInvalidAttempts
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return catalogapi.Retry{}, errInvalidJitter
+	}
+
+	retry := catalogapi.Retry{Attempts: r.Attempts, Jitter: r.Jitter}
+	for _, d := range []struct {
+		text string
+		dst  *time.Duration
+	}{
+		{r.BaseDelay, &retry.BaseDelay},
+		{r.MaxElapsed, &retry.MaxElapsed},
+	} {
+		if d.text == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(d.text)
+		if err != nil || duration < 0 {
+			return catalogapi.Retry{}, fmt.Errorf("%s: %w", d.text, errInvalidRetryDelay)
+		}
+		*d.dst = duration
+	}
+
+	return retry, nil
+}
+
+// FilterJSON configures the filter of the PEM blocks in the fetched content.
+// The Subject is a regular expression matched to the subject of certificates.
+type FilterJSON struct {
+	Types          []string `json:"types,omitempty"`
+	ExcludeExpired bool     `json:"excludeExpired,omitempty"`
+	Subject        string   `json:"subject,omitempty"`
+}
+
+// filter returns the PEMFilter of the configuration.
+func (f FilterJSON) filter() (transform.PEMFilter, error) {
+	filter := transform.NewPEMFilter()
+
+	if len(f.Types) > 0 {
+		filter = filter.WithTypes(f.Types...)
+	}
+	if f.ExcludeExpired {
+		filter = filter.WithExcludeExpired()
+	}
+	if f.Subject != "" {
+		reg, err := regexp.Compile(f.Subject)
+		if err != nil {
+			return filter, fmt.Errorf("%s: %w", f.Subject, errInvalidSubject)
+		}
+		filter = filter.WithSubject(reg)
+	}
+
+	return filter, nil
+}
+
+// KeyPolicyJSON configures the strength of the keys and the algorithms of the
+// signatures in the content of the catalog.
+type KeyPolicyJSON struct {
+	MinRSABits                int      `json:"minRSABits,omitempty"`
+	AllowedCurves             []string `json:"allowedCurves,omitempty"`
+	BannedSignatureAlgorithms []string `json:"bannedSignatureAlgorithms,omitempty"`
+}
+
+// keyCurves are the names of the curves supported in the allowedCurves.
+var keyCurves = []string{"P-224", "P-256", "P-384", "P-521", "Ed25519"}
+
+// keyPolicy returns the KeyPolicy, or the error if the curves or the signature
+// algorithms are undefined.
+func (k KeyPolicyJSON) keyPolicy() (asset.KeyPolicy, error) {
+	if k.MinRSABits < 0 {
+		return asset.KeyPolicy{}, fmt.Errorf("%d: %w", k.MinRSABits, errInvalidMinRSABits)
+	}
+
+	curves := make(map[string]struct{}, len(keyCurves))
+	for _, curve := range keyCurves {
+		curves[curve] = struct{}{}
+	}
+	for _, curve := range k.AllowedCurves {
+		if _, ok := curves[curve]; !ok {
+			return asset.KeyPolicy{}, fmt.Errorf("%s: %w", curve, errUndefinedCurve)
+		}
+	}
+
+	algos := make(map[string]struct{})
+	for algo := x509.MD2WithRSA; algo <= x509.PureEd25519; algo++ {
+		algos[algo.String()] = struct{}{}
+	}
+	for _, name := range k.BannedSignatureAlgorithms {
+		if _, ok := algos[name]; !ok {
+			return asset.KeyPolicy{}, fmt.Errorf("%s: %w", name, errUndefinedSignatureAlgo)
+		}
+	}
+
+	return asset.KeyPolicy{
+		MinRSABits:                k.MinRSABits,
+		AllowedCurves:             k.AllowedCurves,
+		BannedSignatureAlgorithms: k.BannedSignatureAlgorithms,
+	}, nil
+}
+
+type CAPolicyJSON struct {
+	RequiredPolicies       []string `json:"requiredPolicies,omitempty"`
+	ForbiddenPolicies      []string `json:"forbiddenPolicies,omitempty"`
+	MaxPathLen             *int     `json:"maxPathLen,omitempty"`
+	RequireNameConstraints bool     `json:"requireNameConstraints,omitempty"`
+	PermittedDNSDomains    []string `json:"permittedDNSDomains,omitempty"`
 }
 
 type OrderJSON struct {
-	CatalogAliases []string `json:"aliases"`
-	URI            string   `json:"uri"`
+	CatalogAliases []string        `json:"aliases"`
+	URI            string          `json:"uri,omitempty"`
+	URIs           []string        `json:"uris,omitempty"`
+	Fallbacks      []string        `json:"fallbacks,omitempty"`
+	Mirror         string          `json:"mirror,omitempty"`
+	Merge          bool            `json:"merge,omitempty"`
+	Seal           string          `json:"seal,omitempty"`
+	Verify         string          `json:"verify,omitempty"`
+	MatchKey       bool            `json:"matchKey,omitempty"`
+	MergeCRL       bool            `json:"mergeCRL,omitempty"`
+	UnwrapPKCS7    bool            `json:"unwrapPKCS7,omitempty"`
+	Format         string          `json:"format,omitempty"`
+	Normalize      *NormalizeJSON  `json:"normalize,omitempty"`
+	Edge           *EdgeJSON       `json:"edge,omitempty"`
+	Join           *JoinJSON       `json:"join,omitempty"`
+	Webhook        *WebhookJSON    `json:"webhook,omitempty"`
+	GitHub         *GitHubJSON     `json:"github,omitempty"`
+	Invalidate     *InvalidateJSON `json:"invalidate,omitempty"`
+	S3             *S3JSON         `json:"s3,omitempty"`
+	Template       string          `json:"template,omitempty"`
+	DNS            *DNSJSON        `json:"dns,omitempty"`
+	Command        *CommandJSON    `json:"command,omitempty"`
+	Publish        *PublishJSON    `json:"publish,omitempty"`
+	Hook           []string        `json:"hook,omitempty"`
+	Timeout        int64           `json:"timeout,omitempty"`
+	Description    string          `json:"description,omitempty"`
+	Owner          string          `json:"owner,omitempty"`
+}
+
+// uris returns the destinations of the order. The URIs is used when the
+// contents are fanned out to several destinations.
+func (o OrderJSON) uris() []string {
+	if o.URI == "" {
+		return o.URIs
+	}
+
+	return append([]string{o.URI}, o.URIs...)
+}
+
+// destinations returns the destinations of the order including the fallbacks.
+func (o OrderJSON) destinations() []string {
+	return append(o.uris(), o.Fallbacks...)
+}
+
+// urisWith returns the destinations starting with the prefix, like "file://".
+func (o OrderJSON) urisWith(prefix string) []string {
+	var uris []string
+	for _, uri := range o.destinations() {
+		if strings.HasPrefix(uri, prefix) {
+			uris = append(uris, uri)
+		}
+	}
+
+	return uris
+}
+
+type NormalizeJSON struct {
+	StripHeaders bool `json:"stripHeaders,omitempty"`
+}
+
+// EdgeJSON configures the minimization of the content for the constrained
+// devices. The Budget is the maximum size in bytes, and is not limited if it is
+// 0.
+type EdgeJSON struct {
+	DER      bool `json:"der,omitempty"`
+	RootOnly bool `json:"rootOnly,omitempty"`
+	Budget   int  `json:"budget,omitempty"`
+}
+
+// JoinJSON configures the concatenation of the contents of the catalogs. The
+// FinalNewline is "keep", "ensure" or "strip".
+type JoinJSON struct {
+	Separator    string `json:"separator,omitempty"`
+	AssetNewline bool   `json:"assetNewline,omitempty"`
+	FinalNewline string `json:"finalNewline,omitempty"`
+}
+
+// WebhookJSON configures the request of the https scheme. The Headers maps
+// header names to the names of environment variables holding their values.
+type WebhookJSON struct {
+	Method      string            `json:"method,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// GitHubJSON configures the commit of the github scheme. The pull request is
+// opened to PullRequestBase from the branch of the "ref" query if it is set.
+type GitHubJSON struct {
+	Message         string `json:"message,omitempty"`
+	PullRequestBase string `json:"pullRequestBase,omitempty"`
+}
+
+// DNSJSON configures the records of the dns scheme. The TLSA is the certificate
+// usage, the selector and the matching type like "3 1 1".
+type DNSJSON struct {
+	TLSA string `json:"tlsa,omitempty"`
+	TTL  uint32 `json:"ttl,omitempty"`
+}
+
+// tlsa returns the parameters of the TLSA records.
+func (d DNSJSON) tlsa() (usage, selector, matchingType uint8, err error) {
+	var extra string
+	n, _ := fmt.Sscanf(d.TLSA+" -", "%d %d %d %s", &usage, &selector, &matchingType, &extra)
+	if n != 4 || extra != "-" || usage > 3 || selector > 1 || matchingType > 2 {
+		return 0, 0, 0, fmt.Errorf("%s: %w", d.TLSA, errInvalidTLSA)
+	}
+
+	return usage, selector, matchingType, nil
+}
+
+// CommandJSON configures the command of the cmd scheme, which reads the
+// content from its standard input. The Timeout is in seconds, and the command
+// is only limited by the -timeout option if it is 0.
+type CommandJSON struct {
+	Args    []string `json:"args"`
+	Timeout int64    `json:"timeout,omitempty"`
+}
+
+// PublishJSON configures the publication of the mqtt, mqtts and nats scheme.
+// The notification of the digest, the size and the URL is published instead of
+// the content if the Notify is set. The QoS and the Retain are only for MQTT.
+type PublishJSON struct {
+	Notify bool   `json:"notify,omitempty"`
+	URL    string `json:"url,omitempty"`
+	QoS    uint8  `json:"qos,omitempty"`
+	Retain bool   `json:"retain,omitempty"`
+}
+
+// InvalidateJSON configures the CDN cache invalidation after writing to the s3
+// or gcs scheme. The Target is the distribution ID of CloudFront, or
+// "<project>/<url map>" of Cloud CDN. The object path is invalidated if the
+// Paths is not set.
+type InvalidateJSON struct {
+	Provider string   `json:"provider"`
+	Target   string   `json:"target"`
+	Paths    []string `json:"paths,omitempty"`
 }
 
+// CategoryJSON defines the category of the CA-adjacent assets that cannect
+// does not support. The content must match the Pattern, or is not validated if
+// the Pattern is "none".
+type CategoryJSON struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// The pattern of the category whose content is not validated.
+const noneCategoryPattern = cannect.NoneCategoryPattern
+
+// builtinCategories are the categories supported by cannect.
+var builtinCategories = cannect.BuiltinCategories
+
+// multiCategories are the PEM categories that can be combined in the
+// categories of the catalog.
+var multiCategories = []string{
+	asset.CertCategory, asset.PrivKeyCategory, asset.EncPrivKeyCategory, asset.CRLCategory,
+	asset.PubKeyCategory, asset.SSHPrivKeyCategory, asset.PKCS7Category,
+}
+
+// customCheckers returns the Checkers of the categories by their names.
+func customCheckers(cJSONs []CategoryJSON) (map[string]asset.Checker, error) {
+	categories := make([]cannect.CategoryConfig, 0, len(cJSONs))
+	for _, cJSON := range cJSONs {
+		categories = append(categories, cannect.CategoryConfig(cJSON))
+	}
+
+	return cannect.CategoryCheckers(categories)
+}
+
+// CatalogsJSON is the catalog file. The Schema is the URI of the JSON Schema
+// for the editors, which is ignored by cannect.
 type CatalogsJSON struct {
-	Catalogs []CatalogJSON `json:"catalogs"`
+	Schema     string         `json:"$schema,omitempty"`
+	Categories []CategoryJSON `json:"categories,omitempty"`
+	Catalogs   []CatalogJSON  `json:"catalogs"`
 }
 
 type OrdersJSON struct {
+	Schema string      `json:"$schema,omitempty"`
 	Orders []OrderJSON `json:"orders"`
+	Hook   []string    `json:"hook,omitempty"`
 }
 
+// CAnnectJSON is the config. The Hook is the command run after each order
+// that does not have its own hook.
 type CAnnectJSON struct {
-	Catalogs []CatalogJSON `json:"catalogs"`
-	Orders   []OrderJSON   `json:"orders"`
+	Schema     string         `json:"$schema,omitempty"`
+	Categories []CategoryJSON `json:"categories,omitempty"`
+	Catalogs   []CatalogJSON  `json:"catalogs"`
+	Orders     []OrderJSON    `json:"orders"`
+	Hook       []string       `json:"hook,omitempty"`
 }
 
 type runConfig struct {
-	EnvOut   string
-	ConLimit int
+	EnvOut    string
+	EnvFormat orderapi.EnvFormat
+	EnvBase64 bool
+	ConLimit  int
+	Stdout    io.Writer
+	FIPS      bool
+	NoWrite   bool
+	// Report collects the results of the orders if it is not nil.
+	Report *runReport
+	// Drifts collects the destinations differing from the contents instead of
+	// writing them if it is not nil. NoWrite must be also set.
+	Drifts *driftSet
+	// ModTime is the modification time of the archive entries.
+	ModTime time.Time
+	// Digests skips the orders whose contents are the same as the ones written
+	// in the previous run if it is not nil.
+	Digests *digestCache
+	// FSGuard restricts the files of the file scheme catalogs.
+	FSGuard catalogapi.FSGuard
+	// Root is the directory the paths of the file scheme are in, if it is not
+	// empty.
+	Root string
+	// Log writes the structured log records with the fields, instead of the
+	// free-form lines, if it is not nil.
+	Log *slog.Logger
+	// KeepGoing lets the other orders complete when an order fails, and
+	// returns all failures at the end.
+	KeepGoing bool
+	// FetchTimeout limits each fetch of the catalogs without their own
+	// timeout if it is not 0.
+	FetchTimeout time.Duration
+	// Usage counts the fetches and the writes, and enforces its quota, if it
+	// is not nil.
+	Usage *usageMeter
+	// SkipUnchanged skips the orders whose destinations have the contents
+	// already.
+	SkipUnchanged bool
+	// Warnings collects the warnings of the checks in the warn of the
+	// catalogs if it is not nil.
+	Warnings *warningSet
+	// GitHubLimiter throttles the requests of the github scheme catalogs with
+	// the rate limit of the GitHub API if it is not nil. It is shared by the
+	// runs, since the limit is of the token.
+	GitHubLimiter *catalogapi.RateLimiter
+	// HTTPClient calls the APIs of the github and s3 scheme catalogs through
+	// the endpoint overrides and the resolvers if it is not nil.
+	HTTPClient *http.Client
+	// S3 is the default of the s3 elements of the catalogs and the orders.
+	S3 S3JSON
+	// Metrics receives the events of the fetches and the orders if it is not
+	// nil.
+	Metrics metricsapi.Instrument
+	// Tracer emits the spans of the fetches and the orders if it is not nil.
+	Tracer trace.TracerProvider
 }
 
 // Order is a struct that retrieves data from its own catalog and writes the
@@ -53,251 +569,1668 @@ type Order interface {
 	Order(context.Context) error
 }
 
-func newRunConfig(envOut string, conLimit int) runConfig {
-	return runConfig{
-		EnvOut:   envOut,
-		ConLimit: conLimit,
-	}
-}
+func newRunConfig(envOut string, conLimit int, fips bool) runConfig {
+	return runConfig{
+		EnvOut:        envOut,
+		EnvFormat:     orderapi.ExportEnvFormat,
+		ConLimit:      conLimit,
+		Stdout:        os.Stdout,
+		FIPS:          fips || fipsBuild,
+		ModTime:       sourceDateEpoch(),
+		GitHubLimiter: catalogapi.NewRateLimiter(),
+	}
+}
+
+// newFSGuard returns the FSGuard of the -fs-root and -fs-strict options. The
+// directory of the -root option is used if the -fs-root option is not set, so
+// the symlinks cannot escape from it.
+func newFSGuard(fsRoot, root string, strict bool) catalogapi.FSGuard {
+	if fsRoot == "" {
+		fsRoot = root
+	}
+
+	return catalogapi.FSGuard{
+		Root:          fsRoot,
+		RegularOnly:   strict,
+		CaseSensitive: strict,
+	}
+}
+
+// newFSURI returns the FSURI of the file scheme, whose path is in the root
+// directory if it is not empty.
+func newFSURI(uriText, root string) (uriapi.FSURI, error) {
+	uri, err := uriapi.NewFSURI(uriText)
+	if err != nil || root == "" {
+		return uri, err
+	}
+
+	return uri.Under(root)
+}
+
+// sourceDateEpoch returns the time of the SOURCE_DATE_EPOCH, or the fixed
+// orderapi.ArchiveModTime if it is not set or not a number of seconds.
+func sourceDateEpoch() time.Time {
+	sec, err := strconv.ParseInt(os.Getenv(sourceDateEpochEnv), 10, 64)
+	if err != nil {
+		return orderapi.ArchiveModTime
+	}
+
+	return time.Unix(sec, 0).UTC()
+}
+
+var (
+	errAliasNotFound           = cannect.ErrAliasNotFound
+	errUndefinedAlias          = errors.New("undefined alias")
+	errUndefinedCategory       = cannect.ErrUndefinedCategory
+	errEmptyCategoryName       = cannect.ErrEmptyCategoryName
+	errCategoryDuplicated      = cannect.ErrCategoryDuplicated
+	errInvalidCategoryPattern  = cannect.ErrInvalidCategoryPattern
+	errCategoryExclusive       = errors.New("either of category or categories must be specified")
+	errMultiCategoryNotAllowed = errors.New("only the PEM categories can be combined in categories")
+	errUndefinedSrcScheme      = errors.New("undefined source scheme")
+	errUndefinedDstScheme      = errors.New("undefined destination scheme")
+	errOrderURIDuplicated      = errors.New("order URI must not be duplicated")
+	errAliasDuplicated         = errors.New("alias must not be duplicated")
+	errUndefinedSeal           = errors.New("undefined seal")
+	errSealNotAllowed          = errors.New("seal is supported only in file scheme")
+	errURIsExclusive           = errors.New("uri and uris must not be specified together")
+	errFallbacksNotAllowed     = errors.New("fallbacks is supported only with uri")
+	errNoOrderURI              = errors.New("uri or uris must be specified")
+	errInvalidSubject          = errors.New("invalid subject pattern")
+	errFilterNotAllowed        = errors.New("filter is not supported in custom scheme")
+	errRangeNotAllowed         = errors.New("range is supported only in file and s3 scheme")
+	errInvalidRange            = errors.New("offset and length of range must not be negative")
+	errRetryNotAllowed         = errors.New("retry is supported only in github and s3 scheme")
+	errS3NotAllowed            = errors.New("s3 is supported only in s3 scheme")
+	errInvalidS3Endpoint       = errors.New("s3 endpoint must be the URL of http or https")
+	errInvalidRoleARN          = errors.New("s3 roleArn must be the ARN of an IAM role")
+	errNoRoleARN               = errors.New("s3 externalId requires roleArn")
+	errRoleNotAllowed          = errors.New("s3 roleArn is supported only in catalogs")
+	errVersionNotAllowed       = errors.New("versionId is supported only in catalogs")
+	errInvalidAttempts         = errors.New("attempts of retry must not be negative")
+	errInvalidJitter           = errors.New("jitter of retry must be from 0 to 1")
+	errInvalidRetryDelay       = errors.New("invalid delay of retry")
+	errCAPolicyNotAllowed      = errors.New("caPolicy is supported only in certificate category")
+	errExpiryNotAllowed        = errors.New("minRemainingValidity and warnBefore are supported only in certificate, pkcs7 and derCertificate category")
+	errInvalidValidity         = errors.New("minRemainingValidity and warnBefore must not be negative")
+	errUndefinedVerify         = errors.New("undefined verify")
+	errInvalidMinRSABits       = errors.New("minRSABits of keyPolicy must not be negative")
+	errUndefinedCurve          = errors.New("undefined curve")
+	errUndefinedSignatureAlgo  = errors.New("undefined signature algorithm")
+	errUndefinedMethod         = errors.New("undefined webhook method")
+	errWebhookNotAllowed       = errors.New("webhook is supported only in https scheme")
+	errGitHubNotAllowed        = errors.New("github is supported only in github scheme")
+	errPullRequestNoRef        = errors.New("pullRequestBase requires ref query in uri")
+	errUndefinedProvider       = errors.New("undefined invalidate provider")
+	errInvalidateNotAllowed    = errors.New("invalidate provider does not support the scheme")
+	errInvalidCDNTarget        = errors.New("invalid invalidate target")
+	errUndefinedFinalNewline   = errors.New("undefined finalNewline")
+	errInvalidBudget           = errors.New("budget must not be negative")
+	errEdgeDERNotAllowed       = errors.New("der of edge is not supported with verify and matchKey")
+	errUndefinedOrderFormat    = errors.New("undefined format of order")
+	errFormatDERNotAllowed     = errors.New("der format is not supported with verify and matchKey")
+	errNoKeyPairAliases        = errors.New("matchKey requires certificate and privateKey aliases")
+	errDERFilterNotAllowed     = errors.New("filter is not supported in der categories")
+	errUndefinedEnvFormat      = errors.New("undefined env format")
+	errDNSNotAllowed           = errors.New("dns is supported only in dns scheme")
+	errInvalidTLSA             = errors.New("invalid tlsa")
+	errCommandNotAllowed       = errors.New("command is supported only in cmd scheme")
+	errNoCommand               = errors.New("cmd scheme requires command")
+	errInvalidTimeout          = errors.New("timeout must not be negative")
+	errPublishNotAllowed       = errors.New("publish is supported only in mqtt, mqtts and nats scheme")
+	errInvalidQoS              = errors.New("qos must be 0 or 1")
+	errURLWithoutNotify        = errors.New("url of publish requires notify")
+	errCERTNotAllowed          = errors.New("CERT record is not supported by route53")
+	errMergeCRLNotAllowed      = errors.New("mergeCRL is not supported with template, and in zip, tar, helm and kustomize scheme")
+	errTemplateNotAllowed      = errors.New("template is not supported in zip, tar, helm and kustomize scheme")
+)
+
+const (
+	tpm2Seal          = "tpm2"
+	crossSignedVerify = "crossSigned"
+	chainVerify       = "chain"
+	pemFormat         = "pem"
+	derFormat         = "der"
+)
+
+var finalNewlines = map[string]orderapi.FinalNewline{
+	"":       orderapi.KeepFinalNewline,
+	"keep":   orderapi.KeepFinalNewline,
+	"ensure": orderapi.EnsureFinalNewline,
+	"strip":  orderapi.StripFinalNewline,
+}
+
+var envFormats = map[string]orderapi.EnvFormat{
+	"export":     orderapi.ExportEnvFormat,
+	"dotenv":     orderapi.DotenvEnvFormat,
+	"json":       orderapi.JSONEnvFormat,
+	"yaml":       orderapi.YAMLEnvFormat,
+	"powershell": orderapi.PowerShellEnvFormat,
+}
+
+const (
+	cloudFrontProvider = "cloudfront"
+	cloudCDNProvider   = "cloudcdn"
+)
+
+// createCatalogSets creates the catalogs of each order. The catalog of the
+// same source and options is shared by the orders, even if their aliases
+// differ, so each source is fetched only once in the run. The catalogs of an order are fetched concurrently, and the fetches
+// in the run are limited by the ConLimit.
+func createCatalogSets(cntJSON CAnnectJSON, cfg runConfig, logger *log.Logger) ([][]orderapi.Catalog, error) {
+	catalogSets := make([][]orderapi.Catalog, 0, len(cntJSON.Orders))
+	sources := cannect.NewSources()
+	custom, err := customCheckers(cntJSON.Categories)
+	if err != nil {
+		return nil, err
+	}
+	var limit chan struct{}
+	if cfg.ConLimit > 0 {
+		limit = make(chan struct{}, cfg.ConLimit)
+	}
+
+	orderJSONs := cntJSON.Orders
+	for idx := range orderJSONs {
+		catalogSet := make([]orderapi.Catalog, 0, len(orderJSONs[idx].CatalogAliases))
+		aliases := orderJSONs[idx].CatalogAliases
+		for aliasIdx := range aliases {
+			var cJSON CatalogJSON
+			var ok bool
+
+			for _, jsn := range cntJSON.Catalogs {
+				if jsn.Alias == aliases[aliasIdx] {
+					cJSON = jsn
+					ok = true
+					break
+				}
+			}
+
+			if !ok {
+				return nil, fmt.Errorf("%s: %w", aliases[aliasIdx], errAliasNotFound)
+			}
+
+			key, err := cJSON.sharedKey()
+			if err != nil {
+				return nil, err
+			}
+
+			catalog, err := sources.Catalog(key, len(orderJSONs[idx].destinations()), func() (orderapi.Catalog, error) {
+				return createCatalog(cJSON, custom, limit, cfg, logger)
+			})
+			if err != nil {
+				return nil, err
+			}
+			catalogSet = append(catalogSet, catalog)
+		}
+		catalogSets = append(catalogSets, catalogSet)
+	}
+	sources.Stream()
+
+	return catalogSets, nil
+}
+
+// createCatalog returns the catalog of the config wrapped with the timeout,
+// the logs, the metrics, the traces, the usage and the limit of the fetches.
+func createCatalog(
+	cJSON CatalogJSON, custom map[string]asset.Checker, limit chan struct{}, cfg runConfig, logger *log.Logger,
+) (orderapi.Catalog, error) {
+	cLogger := plainLog(logger, cfg.Log)
+
+	checker, err := cJSON.checker(custom)
+	if err != nil {
+		return nil, err
+	}
+
+	if cJSON.CAPolicy != nil {
+		policy := asset.CAPolicy{
+			RequiredPolicies:       cJSON.CAPolicy.RequiredPolicies,
+			ForbiddenPolicies:      cJSON.CAPolicy.ForbiddenPolicies,
+			MaxPathLen:             cJSON.CAPolicy.MaxPathLen,
+			RequireNameConstraints: cJSON.CAPolicy.RequireNameConstraints,
+			PermittedDNSDomains:    cJSON.CAPolicy.PermittedDNSDomains,
+		}
+		checker = warnedCheck(checker, caPolicyCheck, func(c asset.Checker) asset.Checker {
+			return asset.NewCAPolicyCheck(c, policy)
+		}, cJSON, cfg, logger)
+	}
+
+	if cJSON.KeyPolicy != nil {
+		policy, err := cJSON.KeyPolicy.keyPolicy()
+		if err != nil {
+			return nil, err
+		}
+		checker = warnedCheck(checker, keyPolicyCheck, func(c asset.Checker) asset.Checker {
+			return asset.NewKeyPolicyCheck(c, policy)
+		}, cJSON, cfg, logger)
+	}
+
+	if days := cJSON.MinRemainingValidity; days != nil {
+		checker = asset.NewExpiryCheck(checker, time.Duration(*days)*24*time.Hour)
+	}
+
+	if days := cJSON.WarnBefore; days != nil {
+		checker = asset.NewWarn(
+			asset.NewExpiryCheck(passCheck{}, time.Duration(*days)*24*time.Hour), checker,
+			checkWarner(expiryCheck, cJSON, cfg, logger),
+		)
+	}
+
+	if cfg.FIPS {
+		checker = warnedCheck(checker, fipsCheck, func(c asset.Checker) asset.Checker {
+			return asset.NewFIPS(c)
+		}, cJSON, cfg, logger)
+	}
+
+	var filter catalogapi.Filter
+	if cJSON.Filter != nil {
+		pemFilter, err := cJSON.Filter.filter()
+		if err != nil {
+			return nil, err
+		}
+		filter = pemFilter
+	}
+
+	var catalog orderapi.Catalog
+	scheme := schemeapi.Of(cJSON.URI)
+
+	switch scheme {
+	case "file":
+		uri, err := newFSURI(cJSON.URI, cfg.Root)
+		if err != nil {
+			return nil, err
+		}
+		catalog = catalogapi.NewFSCatalog(uri, cJSON.Alias, checker).WithLogger(cLogger).WithFilter(filter).
+			WithRange(cJSON.Range.rng()).WithGuard(cfg.FSGuard)
+	case "github":
+		uri, err := uriapi.NewGitHubURI(cJSON.URI)
+		if err != nil {
+			return nil, err
+		}
+		retry, err := cJSON.Retry.retry()
+		if err != nil {
+			return nil, err
+		}
+		catalog = catalogapi.NewGitHubCatalog(uri, cJSON.Alias, checker).WithLogger(cLogger).WithFilter(filter).
+			WithRetry(retry).WithRateLimiter(cfg.GitHubLimiter).WithHTTPClient(cfg.HTTPClient)
+	case "s3":
+		uri, err := uriapi.NewS3URI(cJSON.URI)
+		if err != nil {
+			return nil, err
+		}
+		retry, err := cJSON.Retry.retry()
+		if err != nil {
+			return nil, err
+		}
+		catalog = catalogapi.NewS3Catalog(uri, cJSON.Alias, checker).WithLogger(cLogger).WithFilter(filter).
+			WithRange(cJSON.Range.rng()).WithRetry(retry).WithHTTPClient(cfg.HTTPClient).
+			WithOptions(cJSON.S3.options(cfg.S3)...).WithAssumeRole(cJSON.S3.assumeRole())
+	case "workload":
+		uri, err := uriapi.NewWorkloadURI(cJSON.URI)
+		if err != nil {
+			return nil, err
+		}
+		catalog = catalogapi.NewWorkloadCatalog(uri, cJSON.Alias, checker).WithLogger(cLogger).WithFilter(filter)
+	case "plugin":
+		uri, err := uriapi.NewPluginURI(cJSON.URI)
+		if err != nil {
+			return nil, err
+		}
+		catalog = catalogapi.NewPluginCatalog(uri, cJSON.Alias, checker).WithLogger(cLogger).WithFilter(filter).
+			WithStderr(logger.Writer())
+	default:
+		s, uri, err := customScheme(cJSON.URI, true)
+		if err != nil {
+			return nil, err
+		}
+
+		catalog, err = s.NewCatalog(uri, cJSON.Alias, checker)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	catalog = newTimeoutCatalog(catalog, cJSON, cfg.FetchTimeout)
+
+	if cfg.Log != nil {
+		catalog = newLoggedCatalog(catalog, cJSON, cfg.Log)
+	}
+
+	if cfg.Metrics != nil {
+		catalog = metricsapi.NewCatalog(catalog, cJSON.Alias, cJSON.URI, cfg.Metrics)
+	}
+
+	if cfg.Tracer != nil {
+		catalog = traceapi.NewCatalog(catalog, cJSON.Alias, cJSON.URI, cfg.Tracer)
+	}
+
+	if cfg.Usage != nil {
+		catalog = newMeteredCatalog(catalog, cJSON, cfg.Usage)
+	}
+
+	if limit != nil {
+		catalog = &limitedCatalog{catalog: catalog, limit: limit}
+	}
+
+	return catalog, nil
+}
+
+// sharedKey returns the key of the catalog shared by the orders. It is the
+// normalized URI and the options changing the result of the fetch, so the
+// catalogs of the same source with the different checks or filters are not
+// shared. The alias and the descriptions do not change the result, and the
+// shared catalog logs with the alias of the first catalog.
+func (c CatalogJSON) sharedKey() (string, error) {
+	c.URI = cannect.NormalizeURI(c.URI)
+	c.Alias, c.Description, c.Owner = "", "", ""
+
+	key, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return string(key), nil
+}
+
+// limitedCatalog limits the concurrent fetches of the catalogs sharing the
+// limit. It is wrapped by the shared catalog, so the orders waiting for the
+// fetch of the other order do not take the limit.
+type limitedCatalog struct {
+	catalog orderapi.Catalog
+	limit   chan struct{}
+}
+
+func (c *limitedCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	select {
+	case c.limit <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-c.limit }()
+
+	return c.catalog.Fetch(ctx)
+}
+
+// Open takes the limit until the reader is closed, since the content is
+// fetched while it is read.
+func (c *limitedCatalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	select {
+	case c.limit <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	r, err := orderapi.Open(ctx, c.catalog)
+	if err != nil {
+		<-c.limit
+		return nil, err
+	}
+
+	return &limitedReader{ReadCloser: r, limit: c.limit}, nil
+}
+
+func (c *limitedCatalog) Streams() bool {
+	return orderapi.Streams(c.catalog)
+}
+
+// limitedReader releases the limit when it is closed.
+type limitedReader struct {
+	io.ReadCloser
+	limit  chan struct{}
+	closed bool
+}
+
+func (l *limitedReader) Close() error {
+	if !l.closed {
+		l.closed = true
+		<-l.limit
+	}
+
+	return l.ReadCloser.Close()
+}
+
+// bundleCatalogs returns the catalogs wrapped in a bundle, if the order
+// joins, transforms or verifies the concatenated contents.
+func bundleCatalogs(oJSON OrderJSON, catalogs []orderapi.Catalog) []orderapi.Catalog {
+	return orderapi.NewOptions(orderOptions(oJSON, catalogs)...).Bundled()
+}
+
+// orderOptions converts the order element to the options of the order
+// package, so the orders in the config have the same features as the ones
+// composed with orderapi.New.
+func orderOptions(oJSON OrderJSON, catalogs []orderapi.Catalog) []orderapi.Option {
+	opts := []orderapi.Option{orderapi.WithCatalogs(catalogs...)}
+
+	if jJSON := oJSON.Join; jJSON != nil {
+		opts = append(opts, orderapi.WithJoin(jJSON.Separator, jJSON.AssetNewline, finalNewlines[jJSON.FinalNewline]))
+	}
+
+	if oJSON.Format == pemFormat {
+		opts = append(opts, orderapi.WithTransforms(transform.NewToPEM()))
+	}
+
+	if oJSON.UnwrapPKCS7 {
+		opts = append(opts, orderapi.WithTransforms(transform.NewUnwrapPKCS7()))
+	}
+
+	if oJSON.MergeCRL {
+		opts = append(opts, orderapi.WithTransforms(transform.NewMergeCRL()))
+	}
+
+	if oJSON.Normalize != nil {
+		normalize := transform.NewNormalize()
+		if oJSON.Normalize.StripHeaders {
+			normalize = normalize.WithStripHeaders()
+		}
+		opts = append(opts, orderapi.WithTransforms(normalize))
+	}
+
+	if eJSON := oJSON.Edge; eJSON != nil {
+		edge := transform.NewEdge().WithBudget(eJSON.Budget)
+		if eJSON.DER {
+			edge = edge.WithDER()
+		}
+		if eJSON.RootOnly {
+			edge = edge.WithRootOnly()
+		}
+		opts = append(opts, orderapi.WithTransforms(edge))
+	}
+
+	if oJSON.Format == derFormat {
+		opts = append(opts, orderapi.WithTransforms(transform.NewToDER()))
+	}
+
+	switch oJSON.Verify {
+	case crossSignedVerify:
+		opts = append(opts, orderapi.WithChecks(asset.NewCrossSigned()))
+	case chainVerify:
+		opts = append(opts, orderapi.WithChecks(asset.NewChain()))
+	}
+
+	if oJSON.MatchKey {
+		opts = append(opts, orderapi.WithChecks(asset.NewKeyMatch()))
+	}
+
+	return opts
+}
+
+// templateCatalogs returns the catalogs rendered through the template of the
+// order. Each catalog is transformed and verified separately as an asset named
+// by its alias.
+func templateCatalogs(oJSON OrderJSON, catalogs []orderapi.Catalog) ([]orderapi.Catalog, error) {
+	tmpl, err := orderapi.NewTemplate(oJSON.Template, entryCatalogs(oJSON, catalogs), oJSON.CatalogAliases)
+	if err != nil {
+		return nil, err
+	}
+
+	return []orderapi.Catalog{tmpl}, nil
+}
+
+// entryCatalogs bundles each catalog separately, for the orders writing each
+// catalog as an entry named by its alias.
+func entryCatalogs(oJSON OrderJSON, catalogs []orderapi.Catalog) []orderapi.Catalog {
+	entries := make([]orderapi.Catalog, 0, len(catalogs))
+	for _, catalog := range catalogs {
+		entries = append(entries, bundleCatalogs(oJSON, []orderapi.Catalog{catalog})...)
+	}
+
+	return entries
+}
+
+// srcSchemes and dstSchemes are the schemes built in the CLI for the catalogs
+// and the orders. The other schemes are looked up in the registered custom
+// schemes.
+var (
+	srcSchemes = schemeSet("file", "github", "s3", "workload", "plugin")
+	dstSchemes = schemeSet(
+		"file", "env", "vault", "stdout", "github", "secretsmanager", "ssm", "s3", "gcs", "azblob",
+		"zip", "tar", "https", "k8s", "docker", "helm", "kustomize", "cas", "dns",
+		"unix", "cmd", "mqtt", "mqtts", "nats", "plugin",
+	)
+)
+
+func schemeSet(names ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+
+	return set
+}
+
+// customScheme returns the registered custom scheme of the URI and the parsed
+// URI. The scheme must support the catalogs if catalog is true, or the orders.
+func customScheme(uriText string, catalog bool) (schemeapi.Scheme, schemeapi.URI, error) {
+	name := schemeapi.Of(uriText)
+
+	s, ok := schemeapi.Lookup(name)
+	if catalog && (!ok || s.NewCatalog == nil) {
+		return s, nil, fmt.Errorf("%s: %w", name, errUndefinedSrcScheme)
+	}
+	if !catalog && (!ok || s.NewOrder == nil) {
+		return s, nil, fmt.Errorf("%s: %w", name, errUndefinedDstScheme)
+	}
+
+	uri, err := s.ParseURI(uriText)
+	if err != nil {
+		return s, nil, err
+	}
+
+	return s, uri, nil
+}
+
+// orderCatalogs returns the catalogs of the contents written by the order
+// element, that are transformed, verified or rendered through the template.
+func orderCatalogs(oJSON OrderJSON, sources []orderapi.Catalog) ([]orderapi.Catalog, error) {
+	if oJSON.Template != "" {
+		return templateCatalogs(oJSON, sources)
+	}
+
+	return bundleCatalogs(oJSON, sources), nil
+}
+
+// newOrder creates the Order to the destination of the URI. The sources are the
+// catalogs of the aliases of the order element. The envWriter returns the writer
+// of the env scheme output.
+func newOrder(
+	uriText string, oJSON OrderJSON, sources []orderapi.Catalog, cfg runConfig,
+	envWriter func() (*orderapi.EnvWriter, error), logger *log.Logger,
+) (Order, error) {
+	var order Order
+	oLog := plainLog(logger, cfg.Log).With("aliases", strings.Join(oJSON.CatalogAliases, ","))
+	scheme := schemeapi.Of(uriText)
+
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scheme {
+	case "file":
+		uri, err := newFSURI(uriText, cfg.Root)
+		if err != nil {
+			return nil, err
+		}
+
+		fsOrder := orderapi.NewFSOrder(uri, catalogs).WithLogger(oLog)
+		if oJSON.Seal == tpm2Seal {
+			fsOrder = fsOrder.WithSealer(orderapi.NewTPMSealer(path.Base(uri.Path())))
+		}
+
+		order = fsOrder
+	case "env":
+		uri, err := uriapi.NewEnvURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		w, err := envWriter()
+		if err != nil {
+			return nil, err
+		}
+
+		envOrder := orderapi.NewEnvOrder(uri, catalogs, nil).WithEnvWriter(w).WithLogger(oLog)
+		if cfg.EnvBase64 {
+			envOrder = envOrder.WithBase64()
+		}
+
+		order = envOrder
+	case "github":
+		uri, err := uriapi.NewGitHubURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		githubOrder := orderapi.NewGitHubOrder(uri, catalogs).WithLogger(oLog)
+		if gJSON := oJSON.GitHub; gJSON != nil {
+			if gJSON.Message != "" {
+				githubOrder = githubOrder.WithMessage(gJSON.Message)
+			}
+			if gJSON.PullRequestBase != "" {
+				githubOrder = githubOrder.WithPullRequest(gJSON.PullRequestBase)
+			}
+		}
+
+		order = githubOrder
+	case "stdout":
+		uri, err := uriapi.NewStdoutURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewStdoutOrder(uri, catalogs, cfg.Stdout).WithLogger(oLog)
+	case "vault":
+		uri, err := uriapi.NewVaultURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewVaultOrder(uri, catalogs).WithLogger(oLog)
+	case "secretsmanager":
+		uri, err := uriapi.NewSecretsManagerURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewSecretsManagerOrder(uri, catalogs).WithLogger(oLog)
+	case "ssm":
+		uri, err := uriapi.NewSSMURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewSSMOrder(uri, catalogs).WithLogger(oLog)
+	case "s3":
+		uri, err := uriapi.NewS3URI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		s3Order := orderapi.NewS3Order(uri, catalogs).WithLogger(oLog).WithOptions(oJSON.S3.options(cfg.S3)...)
+		if iJSON := oJSON.Invalidate; iJSON != nil {
+			s3Order = s3Order.WithInvalidator(orderapi.NewCloudFrontInvalidator(iJSON.Target), iJSON.Paths...)
+		}
+
+		order = s3Order
+	case "gcs":
+		uri, err := uriapi.NewGCSURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		gcsOrder := orderapi.NewGCSOrder(uri, catalogs).WithLogger(oLog)
+		if iJSON := oJSON.Invalidate; iJSON != nil {
+			project, urlMap, _ := strings.Cut(iJSON.Target, "/")
+			gcsOrder = gcsOrder.WithInvalidator(orderapi.NewCloudCDNInvalidator(project, urlMap), iJSON.Paths...)
+		}
+
+		order = gcsOrder
+	case "azblob":
+		uri, err := uriapi.NewAzBlobURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewAzBlobOrder(uri, catalogs).WithLogger(oLog)
+	case "zip", "tar":
+		uri, err := uriapi.NewArchiveURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewArchiveOrder(uri, entryCatalogs(oJSON, sources), oJSON.CatalogAliases).
+			WithModTime(cfg.ModTime).WithLogger(oLog)
+	case "https":
+		uri, err := uriapi.NewWebhookURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		webhookOrder := orderapi.NewWebhookOrder(uri, catalogs).WithLogger(oLog)
+		if wJSON := oJSON.Webhook; wJSON != nil {
+			if wJSON.Method != "" {
+				webhookOrder = webhookOrder.WithMethod(wJSON.Method)
+			}
+			if wJSON.ContentType != "" {
+				webhookOrder = webhookOrder.WithContentType(wJSON.ContentType)
+			}
+			for name, env := range wJSON.Headers {
+				webhookOrder = webhookOrder.WithHeaderEnv(name, env)
+			}
+		}
+
+		order = webhookOrder
+	case "k8s":
+		uri, err := uriapi.NewKubernetesURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewKubernetesOrder(uri, catalogs).WithLogger(oLog)
+	case "docker":
+		uri, err := uriapi.NewDockerURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewDockerOrder(uri, catalogs).WithLogger(oLog)
+	case "cas":
+		uri, err := uriapi.NewCASURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewCASOrder(uri, catalogs).WithLogger(oLog)
+	case "unix":
+		uri, err := uriapi.NewUnixURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewUnixOrder(uri, catalogs).WithLogger(oLog)
+	case "cmd":
+		uri, err := uriapi.NewCommandURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		var args []string
+		var timeout time.Duration
+		if cJSON := oJSON.Command; cJSON != nil {
+			args = cJSON.Args
+			timeout = time.Second * time.Duration(cJSON.Timeout)
+		}
+
+		order = orderapi.NewCommandOrder(uri, catalogs, args).WithTimeout(timeout).WithOutput(logger.Writer()).
+			WithLogger(oLog)
+	case "plugin":
+		uri, err := uriapi.NewPluginURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewPluginOrder(uri, catalogs).WithStderr(logger.Writer()).WithLogger(oLog)
+	case "mqtt", "mqtts":
+		uri, err := uriapi.NewMQTTURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		mqttOrder := orderapi.NewMQTTOrder(uri, catalogs).WithLogger(oLog)
+		if pJSON := oJSON.Publish; pJSON != nil {
+			mqttOrder = mqttOrder.WithQoS(pJSON.QoS).WithRetain(pJSON.Retain)
+			if pJSON.Notify {
+				mqttOrder = mqttOrder.WithNotification(pJSON.URL)
+			}
+		}
+
+		order = mqttOrder
+	case "nats":
+		uri, err := uriapi.NewNATSURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		natsOrder := orderapi.NewNATSOrder(uri, catalogs).WithLogger(oLog)
+		if pJSON := oJSON.Publish; pJSON != nil && pJSON.Notify {
+			natsOrder = natsOrder.WithNotification(pJSON.URL)
+		}
+
+		order = natsOrder
+	case "dns":
+		uri, err := uriapi.NewDNSURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		dnsOrder := orderapi.NewDNSOrder(uri, catalogs).WithLogger(oLog)
+		if dJSON := oJSON.DNS; dJSON != nil {
+			if dJSON.TLSA != "" {
+				usage, selector, matchingType, err := dJSON.tlsa()
+				if err != nil {
+					return nil, err
+				}
+				dnsOrder = dnsOrder.WithTLSA(usage, selector, matchingType)
+			}
+			if dJSON.TTL != 0 {
+				dnsOrder = dnsOrder.WithTTL(dJSON.TTL)
+			}
+		}
+
+		order = dnsOrder
+	case "helm":
+		uri, err := uriapi.NewHelmURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewHelmOrder(uri, entryCatalogs(oJSON, sources), oJSON.CatalogAliases).
+			WithLogger(oLog)
+	case "kustomize":
+		uri, err := uriapi.NewKustomizeURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order = orderapi.NewKustomizeOrder(uri, entryCatalogs(oJSON, sources), oJSON.CatalogAliases).
+			WithLogger(oLog)
+	default:
+		s, uri, err := customScheme(uriText, false)
+		if err != nil {
+			return nil, err
+		}
+
+		order, err = s.NewOrder(uri, catalogs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+func run(ctx context.Context, cntJSON CAnnectJSON, cfg runConfig, logger *log.Logger) (err error) {
+	cntJSON.Orders, err = mergeOrders(cntJSON.Orders)
+	if err != nil {
+		return err
+	}
+
+	catalogSets, err := createCatalogSets(cntJSON, cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	// Order to destinations
+	var envFile *os.File
+	var envBuf *bytes.Buffer
+	var envWriter *orderapi.EnvWriter
+	defer func() {
+		if envBuf != nil {
+			flushErr := envWriter.Flush()
+			if err == nil {
+				err = flushErr
+			}
+			if err == nil {
+				err = writeChanged(cfg.EnvOut, envBuf.Bytes(), logger)
+			}
+			return
+		}
+
+		if envFile == nil {
+			return
+		}
+
+		flushErr := envWriter.Flush()
+		if err == nil {
+			err = flushErr
+		}
+
+		closeErr := envFile.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	openEnvWriter := func() (*orderapi.EnvWriter, error) {
+		if cfg.NoWrite {
+			return orderapi.NewEnvWriter(io.Discard, cfg.EnvFormat).WithSorted(), nil
+		}
+
+		if cfg.Digests != nil || cfg.SkipUnchanged {
+			// The env file is written at the end, only if it is changed.
+			if envBuf == nil {
+				envBuf = new(bytes.Buffer)
+				envWriter = orderapi.NewEnvWriter(envBuf, cfg.EnvFormat).WithSorted()
+			}
+			return envWriter, nil
+		}
+
+		if envFile == nil {
+			file, err := os.Create(cfg.EnvOut)
+			if err != nil {
+				return nil, err
+			}
+			envFile = file
+			envWriter = orderapi.NewEnvWriter(file, cfg.EnvFormat).WithSorted()
+		}
+
+		return envWriter, nil
+	}
+
+	mirrors := newMirrorSet()
+	throttles := newThrottleSet(cfg.ConLimit)
+
+	runner := cannect.NewRunner().WithConcurrency(cfg.ConLimit)
+	failures := new(joinedError)
+	if cfg.KeepGoing {
+		runner = runner.WithKeepGoing(func(_ string, err error) { failures.add(err) })
+	}
+
+	for idx, oJSON := range cntJSON.Orders {
+		uris := oJSON.uris()
+
+		hook := hookOf(cntJSON, oJSON)
+
+		// The catalogs are shared, so they are fetched once for all destinations.
+		sources := catalogSets[idx]
+
+		// meter counts the writes to the destination, after limiting them by
+		// the throttling of the network destinations.
+		meter := func(uriText string, order Order) (Order, error) {
+			if cfg.NoWrite {
+				return order, nil
+			}
+			order = newThrottledOrder(uriText, order, throttles, logger)
+			if cfg.Usage == nil {
+				return order, nil
+			}
+			return newMeteredOrder(uriText, oJSON, sources, order, cfg.Usage)
+		}
+
+		for _, uriText := range uris {
+			order, err := newOrder(uriText, oJSON, sources, cfg, openEnvWriter, logger)
+			if err != nil {
+				return err
+			}
+			order, err = meter(uriText, order)
+			if err != nil {
+				return err
+			}
+
+			if cfg.NoWrite {
+				// Replace the order after checking the URI, so nothing is written.
+				if cfg.Drifts != nil {
+					order, err = newCheckOrder(uriText, oJSON, sources, cfg, logger)
+				} else {
+					order, err = newNoWriteOrder(uriText, oJSON, sources, logger)
+				}
+				if err != nil {
+					return err
+				}
+			} else if len(oJSON.Fallbacks) > 0 {
+				fOrder := newFailoverOrder(logger).withReport(cfg.Report).add(uriText, order)
+				for _, fallback := range oJSON.Fallbacks {
+					fbOrder, err := newOrder(fallback, oJSON, sources, cfg, openEnvWriter, logger)
+					if err != nil {
+						return err
+					}
+					fbOrder, err = meter(fallback, fbOrder)
+					if err != nil {
+						return err
+					}
+					fOrder = fOrder.add(fallback, fbOrder)
+				}
+				order = fOrder
+			}
+
+			if oJSON.Mirror != "" && !cfg.NoWrite {
+				order, err = newMirrorOrder(uriText, oJSON, sources, order, mirrors, logger)
+				if err != nil {
+					return err
+				}
+			}
+
+			if cfg.Digests != nil && !cfg.NoWrite && schemeapi.Of(uriText) != "env" {
+				order, err = newUnchangedOrder(uriText, oJSON, sources, order, cfg.Digests, logger)
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(hook) > 0 && !cfg.NoWrite {
+				order, err = newHookOrder(uriText, oJSON, hook, sources, order, cfg, logger)
+				if err != nil {
+					return err
+				}
+			}
+
+			if cfg.SkipUnchanged && !cfg.NoWrite {
+				order, err = newSkipOrder(uriText, oJSON, sources, order, cfg, logger)
+				if err != nil {
+					return err
+				}
+			}
+
+			order = newTimeoutOrder(uriText, oJSON, order)
+
+			if cfg.Metrics != nil {
+				order = metricsapi.NewOrder(order, uriText, cfg.Metrics)
+			}
+
+			if cfg.Tracer != nil {
+				order = traceapi.NewOrder(order, uriText, oJSON.CatalogAliases, cfg.Tracer)
+			}
+
+			if cfg.Log != nil {
+				order, err = newLoggedOrder(uriText, oJSON, sources, order, cfg.Log)
+				if err != nil {
+					return err
+				}
+			}
+
+			if cfg.Report != nil {
+				order, err = newReportOrder(uriText, oJSON, sources, order, cfg.Report)
+				if err != nil {
+					return err
+				}
+			}
+
+			runner = runner.Add(uriText, order)
+		}
+	}
+
+	err = runner.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	return failures.errOrNil()
+}
+
+func unmarshal(file *os.File) (CAnnectJSON, error) {
+	var jsn CAnnectJSON
+	err := decodeConfig(file, &jsn)
+	if err != nil {
+		return jsn, err
+	}
+
+	return jsn, nil
+}
+
+// unmarshalAll merges the catalogs and orders of the files in order.
+func unmarshalAll(files []*os.File) (CAnnectJSON, error) {
+	var jsn CAnnectJSON
+	for _, file := range files {
+		fJSON, err := unmarshal(file)
+		if err != nil {
+			return jsn, err
+		}
+
+		jsn.Categories = append(jsn.Categories, fJSON.Categories...)
+		jsn.Catalogs = append(jsn.Catalogs, fJSON.Catalogs...)
+		jsn.Orders = append(jsn.Orders, withDefaultHook(fJSON.Orders, fJSON.Hook)...)
+	}
+
+	return jsn, nil
+}
+
+// unmarshalBoth merges the catalogs of the catalog files and the orders of the
+// order files in order.
+func unmarshalBoth(cFiles, oFiles []*os.File) (CAnnectJSON, error) {
+	var jsn CAnnectJSON
+
+	for _, cFile := range cFiles {
+		var cJSON CatalogsJSON
+		err := decodeConfig(cFile, &cJSON)
+		if err != nil {
+			return jsn, err
+		}
+		jsn.Categories = append(jsn.Categories, cJSON.Categories...)
+		jsn.Catalogs = append(jsn.Catalogs, cJSON.Catalogs...)
+	}
+
+	for _, oFile := range oFiles {
+		var oJSON OrdersJSON
+		err := decodeConfig(oFile, &oJSON)
+		if err != nil {
+			return jsn, err
+		}
+		jsn.Orders = append(jsn.Orders, withDefaultHook(oJSON.Orders, oJSON.Hook)...)
+	}
+
+	return jsn, nil
+}
+
+// hasStdoutOrder reports whether any order writes to the standard output.
+func hasStdoutOrder(jsn CAnnectJSON) bool {
+	for idx := range jsn.Orders {
+		if len(jsn.Orders[idx].urisWith("stdout:")) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validate(jsn CAnnectJSON) error {
+	_, err := customCheckers(jsn.Categories)
+	if err != nil {
+		// Check the categories are valid and not duplicated
+		return err
+	}
+
+	// alsSet holds the catalog of the alias.
+	alsSet := make(map[string]CatalogJSON)
+	for i := range jsn.Catalogs {
+		if _, ok := alsSet[jsn.Catalogs[i].Alias]; ok {
+			// Check No Duplicated alias
+			return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errAliasDuplicated)
+		}
+		alsSet[jsn.Catalogs[i].Alias] = jsn.Catalogs[i]
+
+		if (jsn.Catalogs[i].Category == "") == (len(jsn.Catalogs[i].Categories) == 0) {
+			// Check either of category or categories is specified
+			return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errCategoryExclusive)
+		}
+
+		for _, category := range jsn.Catalogs[i].Categories {
+			found := false
+			for _, multi := range multiCategories {
+				if category == multi {
+					found = true
+				}
+			}
+			if !found {
+				// Check only the PEM categories are combined
+				return fmt.Errorf("%s: %w", category, errMultiCategoryNotAllowed)
+			}
+		}
+
+		if jsn.Catalogs[i].CAPolicy != nil && !jsn.Catalogs[i].hasCategory(asset.CertCategory) {
+			// Check CA policy is only for certificates
+			return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errCAPolicyNotAllowed)
+		}
+
+		switch jsn.Catalogs[i].Category {
+		case asset.DERCertCategory, asset.DERCRLCategory:
+			if jsn.Catalogs[i].Filter != nil {
+				// Check filter is only for PEM
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errDERFilterNotAllowed)
+			}
+		}
+
+		if jsn.Catalogs[i].MinRemainingValidity != nil || jsn.Catalogs[i].WarnBefore != nil {
+			if !jsn.Catalogs[i].hasCategory(
+				asset.CertCategory, asset.PKCS7Category, asset.DERCertCategory, asset.BundleCategory,
+			) {
+				// Check expiry policy is only for certificates
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errExpiryNotAllowed)
+			}
+
+			for _, days := range []*int{jsn.Catalogs[i].MinRemainingValidity, jsn.Catalogs[i].WarnBefore} {
+				if days != nil && *days < 0 {
+					// Check the days are not negative
+					return fmt.Errorf("%d: %w", *days, errInvalidValidity)
+				}
+			}
+		}
+
+		if _, ok := srcSchemes[schemeapi.Of(jsn.Catalogs[i].URI)]; !ok {
+			_, _, err := customScheme(jsn.Catalogs[i].URI, true)
+			if err != nil {
+				// Check the custom scheme is registered
+				return err
+			}
+
+			if jsn.Catalogs[i].Filter != nil {
+				// Check filter is only for built-in schemes
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errFilterNotAllowed)
+			}
+		}
+
+		if rJSON := jsn.Catalogs[i].Range; rJSON != nil {
+			switch schemeapi.Of(jsn.Catalogs[i].URI) {
+			case "file", "s3":
+			default:
+				// Check range is only for file and s3 scheme
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errRangeNotAllowed)
+			}
+
+			if rJSON.Offset < 0 || rJSON.Length < 0 {
+				// Check range is not negative
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errInvalidRange)
+			}
+		}
+
+		if sJSON := jsn.Catalogs[i].S3; sJSON != nil {
+			if schemeapi.Of(jsn.Catalogs[i].URI) != "s3" {
+				// Check s3 is only for s3 scheme
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errS3NotAllowed)
+			}
+
+			err := sJSON.check()
+			if err != nil {
+				// Check the endpoint is the URL
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, err)
+			}
+		}
+
+		if jsn.Catalogs[i].Timeout < 0 {
+			// Check the timeout is not negative
+			return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errInvalidTimeout)
+		}
+
+		if rJSON := jsn.Catalogs[i].Retry; rJSON != nil {
+			switch schemeapi.Of(jsn.Catalogs[i].URI) {
+			case "github", "s3":
+			default:
+				// Check retry is only for github and s3 scheme
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, errRetryNotAllowed)
+			}
+
+			_, err := rJSON.retry()
+			if err != nil {
+				// Check attempts, jitter and delays of retry are valid
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, err)
+			}
+		}
+
+		if kJSON := jsn.Catalogs[i].KeyPolicy; kJSON != nil {
+			_, err := kJSON.keyPolicy()
+			if err != nil {
+				// Check the curves and the signature algorithms are defined
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, err)
+			}
+		}
+
+		if fJSON := jsn.Catalogs[i].Filter; fJSON != nil {
+			_, err := fJSON.filter()
+			if err != nil {
+				// Check the subject pattern can be compiled
+				return err
+			}
+		}
+
+		for _, name := range jsn.Catalogs[i].Warn {
+			err := checkWarn(name)
+			if err != nil {
+				// Check the warned checks are defined
+				return fmt.Errorf("%s: %w", jsn.Catalogs[i].Alias, err)
+			}
+		}
+	}
+
+	// dupSet holds whether the order to the destination is merged.
+	dupSet := make(map[string]bool)
+	oJSONs := jsn.Orders
+	for idx := range oJSONs {
+		aliases := oJSONs[idx].CatalogAliases
+		for _, als := range aliases {
+			_, ok := alsSet[als]
+			if !ok {
+				// Check no undefined alias
+				return fmt.Errorf("%s: %w", als, errUndefinedAlias)
+			}
+		}
+
+		switch oJSONs[idx].Verify {
+		case "", crossSignedVerify, chainVerify:
+		default:
+			// Check no undefined verify
+			return fmt.Errorf("%s: %w", oJSONs[idx].Verify, errUndefinedVerify)
+		}
+
+		if oJSONs[idx].MatchKey {
+			hasCert, hasKey := false, false
+			for _, als := range aliases {
+				hasCert = hasCert || alsSet[als].hasCategory(asset.CertCategory, asset.BundleCategory)
+				hasKey = hasKey || alsSet[als].hasCategory(asset.PrivKeyCategory, asset.BundleCategory)
+			}
+			if !hasCert || !hasKey {
+				// Check the order has both of a certificate and a private key
+				return fmt.Errorf("%s: %w", strings.Join(aliases, ","), errNoKeyPairAliases)
+			}
+		}
 
-type catalogLogger struct {
-	l *log.Logger
-}
+		if hook := oJSONs[idx].Hook; len(hook) > 0 && hook[0] == "" {
+			// Check the hook has the command
+			return fmt.Errorf("%s: %w", strings.Join(aliases, ","), errEmptyHook)
+		}
 
-func (c *catalogLogger) Log(uriText string) {
-	c.l.Printf("Fetching: %s", uriText)
-}
+		switch oJSONs[idx].Format {
+		case "", pemFormat:
+		case derFormat:
+			if oJSONs[idx].Verify != "" || oJSONs[idx].MatchKey {
+				// Check the verified content is PEM
+				return fmt.Errorf("%s: %w", strings.Join(aliases, ","), errFormatDERNotAllowed)
+			}
+		default:
+			// Check no undefined format
+			return fmt.Errorf("%s: %w", oJSONs[idx].Format, errUndefinedOrderFormat)
+		}
 
-var (
-	errAliasNotFound      = errors.New("alias in destination not found in sources")
-	errUndefinedAlias     = errors.New("undefined alias")
-	errUndefinedCategory  = errors.New("undefined category")
-	errUndefinedSrcScheme = errors.New("undefined source scheme")
-	errUndefinedDstScheme = errors.New("undefined destination scheme")
-	errOrderURIDuplicated = errors.New("order URI must not be duplicated")
-)
+		if eJSON := oJSONs[idx].Edge; eJSON != nil {
+			if eJSON.Budget < 0 {
+				// Check the budget is not negative
+				return fmt.Errorf("%d: %w", eJSON.Budget, errInvalidBudget)
+			}
+			if eJSON.DER && (oJSONs[idx].Verify != "" || oJSONs[idx].MatchKey) {
+				// Check the verified content is PEM
+				return fmt.Errorf("%s: %w", strings.Join(aliases, ","), errEdgeDERNotAllowed)
+			}
+		}
 
-func createCatalogSets(cntJSON CAnnectJSON, logger *log.Logger) ([][]orderapi.Catalog, error) {
-	catalogSets := make([][]orderapi.Catalog, 0, len(cntJSON.Orders))
+		if jJSON := oJSONs[idx].Join; jJSON != nil {
+			if _, ok := finalNewlines[jJSON.FinalNewline]; !ok {
+				// Check no undefined final newline
+				return fmt.Errorf("%s: %w", jJSON.FinalNewline, errUndefinedFinalNewline)
+			}
+		}
 
-	srcSchemeReg := regexp.MustCompile("^(file|github|s3)")
-	cLogger := catalogLogger{l: logger}
+		uris := oJSONs[idx].uris()
+		if oJSONs[idx].URI != "" && len(oJSONs[idx].URIs) > 0 {
+			// Check uri and uris are exclusive
+			return fmt.Errorf("%s: %w", oJSONs[idx].URI, errURIsExclusive)
+		}
+		if len(uris) == 0 {
+			// Check any destination is specified
+			return fmt.Errorf("%s: %w", strings.Join(aliases, ","), errNoOrderURI)
+		}
+		if len(oJSONs[idx].Fallbacks) > 0 && oJSONs[idx].URI == "" {
+			// Check fallbacks are for the single destination
+			return fmt.Errorf("%s: %w", strings.Join(oJSONs[idx].Fallbacks, ","), errFallbacksNotAllowed)
+		}
+		if oJSONs[idx].Merge && (oJSONs[idx].URI == "" || len(oJSONs[idx].Fallbacks) > 0) {
+			// Check merge is for the single destination without fallbacks
+			return fmt.Errorf("%s: %w", strings.Join(uris, ","), errMergeNotAllowed)
+		}
 
-	orderJSONs := cntJSON.Orders
-	for idx := range orderJSONs {
-		catalogSet := make([]orderapi.Catalog, 0, len(orderJSONs[idx].CatalogAliases))
-		aliases := orderJSONs[idx].CatalogAliases
-		for aliasIdx := range aliases {
-			var cJSON CatalogJSON
-			var ok bool
+		if sJSON := oJSONs[idx].S3; sJSON != nil {
+			if len(oJSONs[idx].urisWith("s3://")) == 0 {
+				// Check s3 is only for s3 scheme
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errS3NotAllowed)
+			}
 
-			for _, jsn := range cntJSON.Catalogs {
-				if jsn.Alias == aliases[aliasIdx] {
-					cJSON = jsn
-					ok = true
-					break
-				}
+			err := sJSON.check()
+			if err != nil {
+				// Check the endpoint is the URL
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), err)
 			}
 
-			if !ok {
-				return nil, fmt.Errorf("%s: %w", aliases[aliasIdx], errAliasNotFound)
+			if sJSON.RoleARN != "" || sJSON.ExternalID != "" {
+				// Check the role is only for catalogs
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errRoleNotAllowed)
+			}
+		}
+
+		for _, u := range oJSONs[idx].urisWith("s3://") {
+			uri, err := uriapi.NewS3URI(u)
+			if err == nil && uri.VersionID() != "" {
+				// Check the version is only for catalogs
+				return fmt.Errorf("%s: %w", u, errVersionNotAllowed)
 			}
+		}
 
-			var checker catalogapi.AssetChecker
+		if wJSON := oJSONs[idx].Webhook; wJSON != nil {
+			if len(oJSONs[idx].urisWith("https://")) == 0 {
+				// Check webhook is only for https scheme
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errWebhookNotAllowed)
+			}
 
-			switch cJSON.Category {
-			case asset.CertCategory:
-				checker = asset.NewCertiricate()
-			case asset.PrivKeyCategory:
-				checker = asset.NewPrivateKey()
-			case asset.EncPrivKeyCategory:
-				checker = asset.NewEncryptedPrivateKey()
-			case asset.CRLCategory:
-				checker = asset.NewCRL()
+			switch wJSON.Method {
+			case "", http.MethodPost, http.MethodPut, http.MethodPatch:
 			default:
-				return nil, fmt.Errorf("%s: %w", cJSON.Category, errUndefinedCategory)
+				// Check no undefined method
+				return fmt.Errorf("%s: %w", wJSON.Method, errUndefinedMethod)
 			}
+		}
 
-			var catalog orderapi.Catalog
-			scheme := srcSchemeReg.FindString(cJSON.URI)
+		if gJSON := oJSONs[idx].GitHub; gJSON != nil {
+			ghURIs := oJSONs[idx].urisWith("github://")
+			if len(ghURIs) == 0 {
+				// Check github is only for github scheme
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errGitHubNotAllowed)
+			}
 
-			switch scheme {
-			case "file":
-				uri, err := uriapi.NewFSURI(cJSON.URI)
-				if err != nil {
-					return nil, err
+			for _, uri := range ghURIs {
+				if gJSON.PullRequestBase != "" && !strings.Contains(uri, "?ref=") {
+					// Check the branch of the pull request is specified
+					return fmt.Errorf("%s: %w", uri, errPullRequestNoRef)
 				}
-				catalog = catalogapi.NewFSCatalog(uri, cJSON.Alias, checker).WithLogger(&cLogger)
-			case "github":
-				uri, err := uriapi.NewGitHubURI(cJSON.URI)
-				if err != nil {
-					return nil, err
-				}
-				catalog = catalogapi.NewGitHubCatalog(uri, cJSON.Alias, checker).WithLogger(&cLogger)
-			case "s3":
-				uri, err := uriapi.NewS3URI(cJSON.URI)
-				if err != nil {
-					return nil, err
+			}
+		}
+
+		if iJSON := oJSONs[idx].Invalidate; iJSON != nil {
+			var prefix string
+			switch iJSON.Provider {
+			case cloudFrontProvider:
+				prefix = "s3://"
+			case cloudCDNProvider:
+				prefix = "gcs://"
+				if project, urlMap, ok := strings.Cut(iJSON.Target, "/"); !ok || project == "" || urlMap == "" {
+					// Check the target is "<project>/<url map>"
+					return fmt.Errorf("%s: %w", iJSON.Target, errInvalidCDNTarget)
 				}
-				catalog = catalogapi.NewS3Catalog(uri, cJSON.Alias, checker).WithLogger(&cLogger)
 			default:
-				return nil, fmt.Errorf("%s: %w", scheme, errUndefinedSrcScheme)
+				// Check no undefined provider
+				return fmt.Errorf("%s: %w", iJSON.Provider, errUndefinedProvider)
 			}
 
-			catalogSet = append(catalogSet, catalog)
+			if iJSON.Target == "" {
+				// Check the target is specified
+				return fmt.Errorf("%s: %w", iJSON.Provider, errInvalidCDNTarget)
+			}
+			if len(oJSONs[idx].urisWith(prefix)) == 0 {
+				// Check invalidate is only for the scheme of the provider
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errInvalidateNotAllowed)
+			}
 		}
-		catalogSets = append(catalogSets, catalogSet)
-	}
-
-	return catalogSets, nil
-}
-
-type orderLogger struct {
-	l *log.Logger
-}
-
-func (o *orderLogger) Log(uriText string) {
-	o.l.Printf("Ordering: %s", uriText)
-}
 
-func run(ctx context.Context, cntJSON CAnnectJSON, cfg runConfig, logger *log.Logger) (err error) {
-	catalogSets, err := createCatalogSets(cntJSON, logger)
-	if err != nil {
-		return err
-	}
+		if dJSON := oJSONs[idx].DNS; dJSON != nil {
+			if len(oJSONs[idx].urisWith("dns://")) == 0 {
+				// Check dns is only for dns scheme
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errDNSNotAllowed)
+			}
 
-	// Order to destinations
-	var envFile *os.File
-	limit := make(chan struct{}, cfg.ConLimit)
+			if dJSON.TLSA != "" {
+				_, _, _, err := dJSON.tlsa()
+				if err != nil {
+					// Check the TLSA parameters are defined
+					return err
+				}
+			}
+		}
 
-	dstSchemeReg := regexp.MustCompile("^(file|env)")
+		if cJSON := oJSONs[idx].Command; cJSON != nil {
+			if len(oJSONs[idx].urisWith("cmd://")) == 0 {
+				// Check command is only for cmd scheme
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errCommandNotAllowed)
+			}
+			if cJSON.Timeout < 0 {
+				// Check the timeout is not negative
+				return fmt.Errorf("%d: %w", cJSON.Timeout, errInvalidTimeout)
+			}
+		}
+		if pJSON := oJSONs[idx].Publish; pJSON != nil {
+			var pubURIs []string
+			for _, prefix := range []string{"mqtt://", "mqtts://", "nats://"} {
+				pubURIs = append(pubURIs, oJSONs[idx].urisWith(prefix)...)
+			}
+			if len(pubURIs) == 0 {
+				// Check publish is only for mqtt, mqtts and nats scheme
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errPublishNotAllowed)
+			}
+			if pJSON.QoS > 1 {
+				// Check the QoS is supported
+				return fmt.Errorf("%d: %w", pJSON.QoS, errInvalidQoS)
+			}
+			if pJSON.URL != "" && !pJSON.Notify {
+				// Check the URL is for the notification
+				return fmt.Errorf("%s: %w", pJSON.URL, errURLWithoutNotify)
+			}
+		}
+		if oJSONs[idx].Timeout < 0 {
+			// Check the timeout is not negative
+			return fmt.Errorf("%d: %w", oJSONs[idx].Timeout, errInvalidTimeout)
+		}
+		if cmdURIs := oJSONs[idx].urisWith("cmd://"); len(cmdURIs) > 0 {
+			if oJSONs[idx].Command == nil || len(oJSONs[idx].Command.Args) == 0 {
+				// Check the command of cmd scheme is specified
+				return fmt.Errorf("%s: %w", strings.Join(cmdURIs, ","), errNoCommand)
+			}
+		}
 
-	oLog := orderLogger{l: logger}
+		for _, uri := range oJSONs[idx].urisWith("dns://route53/") {
+			if strings.HasSuffix(uri, "?type=CERT") {
+				// Check CERT record is not for route53
+				return fmt.Errorf("%s: %w", uri, errCERTNotAllowed)
+			}
+		}
 
-	g, ctx := errgroup.WithContext(ctx)
-	for idx, oJSON := range cntJSON.Orders {
-		idx := idx
+		if oJSONs[idx].MergeCRL {
+			entries := oJSONs[idx].Template != ""
+			for _, prefix := range []string{"zip://", "tar://", "helm://", "kustomize://"} {
+				entries = entries || len(oJSONs[idx].urisWith(prefix)) > 0
+			}
+			if entries {
+				// Check the CRLs are merged in the concatenated contents
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errMergeCRLNotAllowed)
+			}
+		}
 
-		var order Order
-		scheme := dstSchemeReg.FindString(oJSON.URI)
+		if oJSONs[idx].Template != "" {
+			for _, prefix := range []string{"zip://", "tar://", "helm://", "kustomize://"} {
+				if len(oJSONs[idx].urisWith(prefix)) > 0 {
+					// Check template is not for the schemes writing each catalog
+					return fmt.Errorf("%s: %w", strings.Join(uris, ","), errTemplateNotAllowed)
+				}
+			}
 
-		switch scheme {
-		case "file":
-			uri, err := uriapi.NewFSURI(oJSON.URI)
+			_, err := orderapi.NewTemplate(oJSONs[idx].Template, nil, nil)
 			if err != nil {
-				return err
+				// Check the template can be parsed
+				return fmt.Errorf("%s: %w", strings.Join(aliases, ","), err)
 			}
+		}
 
-			order = orderapi.NewFSOrder(uri, catalogSets[idx]).WithLogger(&oLog)
-		case "env":
-			uri, err := uriapi.NewEnvURI(oJSON.URI)
-			if err != nil {
-				return err
+		switch oJSONs[idx].Seal {
+		case "":
+		case tpm2Seal:
+			if len(oJSONs[idx].urisWith("file://")) == 0 {
+				// Check seal is only for file scheme
+				return fmt.Errorf("%s: %w", strings.Join(uris, ","), errSealNotAllowed)
 			}
+		default:
+			// Check no undefined seal
+			return fmt.Errorf("%s: %w", oJSONs[idx].Seal, errUndefinedSeal)
+		}
 
-			if envFile == nil {
-				envFile, err = os.Create(cfg.EnvOut)
+		for _, uri := range oJSONs[idx].destinations() {
+			if _, ok := dstSchemes[schemeapi.Of(uri)]; !ok {
+				_, _, err := customScheme(uri, false)
 				if err != nil {
+					// Check the custom scheme is registered
 					return err
 				}
-				defer func() {
-					closeErr := envFile.Close()
-					if err == nil {
-						err = closeErr
-					}
-				}()
 			}
 
-			order = orderapi.NewEnvOrder(uri, catalogSets[idx], envFile).WithLogger(&oLog)
-		default:
-			return fmt.Errorf("%s: %w", scheme, errUndefinedDstScheme)
+			if merge, ok := dupSet[uri]; ok && !(merge && oJSONs[idx].Merge) {
+				// Check No Duplicated destination unless merged
+				return fmt.Errorf("%s: %w", uri, errOrderURIDuplicated)
+			}
+			dupSet[uri] = oJSONs[idx].Merge
 		}
+	}
 
-		g.Go(func() error {
-			limit <- struct{}{}
-			err := order.Order(ctx)
-			if err != nil {
-				return err
-			}
+	_, err = mergeOrders(oJSONs)
+	return err
+}
 
-			<-limit
-			return nil
-		})
-	}
+const (
+	defaultTimeout       = 30
+	defaultConfigTimeout = 10
+	defaultEnvOut        = "./cannect.env"
+	defaultEnvFormat     = "export"
+	defaultConLimit      = 5
+	// The time of the reproducible outputs, in seconds since the Unix epoch.
+	sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+)
 
-	err = g.Wait()
-	if err != nil {
-		return err
-	}
+const (
+	configPhase    = "config load"
+	executionPhase = "execution"
+)
 
-	return nil
+// timeoutError is used to report which phase timed out, so that a slow config
+// load is not mistaken for a slow execution.
+type timeoutError struct {
+	phase string
+	err   error
 }
 
-func unmarshal(file *os.File) (CAnnectJSON, error) {
-	var jsn CAnnectJSON
-	err := json.NewDecoder(file).Decode(&jsn)
-	if err != nil {
-		return jsn, err
-	}
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("%s timed out: %v", e.phase, e.err)
+}
 
-	return jsn, nil
+func (e timeoutError) Unwrap() error {
+	return e.err
 }
 
-func unmarshalBoth(cFile, oFile *os.File) (CAnnectJSON, error) {
-	var jsn CAnnectJSON
+// joinedError holds the failures of the orders in the keep-going mode, like
+// errors.Join that is not available in the older versions of Go. It is safe
+// to add the errors concurrently.
+type joinedError struct {
+	mu   sync.Mutex
+	errs []error
+}
 
-	var cJSON CatalogsJSON
-	err := json.NewDecoder(cFile).Decode(&cJSON)
-	if err != nil {
-		return jsn, err
-	}
+func (e *joinedError) add(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	var oJSON OrdersJSON
-	err = json.NewDecoder(oFile).Decode(&oJSON)
-	if err != nil {
-		return jsn, err
+	e.errs = append(e.errs, err)
+}
+
+func (e *joinedError) errOrNil() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.errs) == 0 {
+		return nil
 	}
 
-	return CAnnectJSON{
-		Catalogs: cJSON.Catalogs,
-		Orders:   oJSON.Orders,
-	}, nil
+	return e
 }
 
-func validate(jsn CAnnectJSON) error {
-	alsSet := make(map[string]struct{})
-	for i := range jsn.Catalogs {
-		alsSet[jsn.Catalogs[i].Alias] = struct{}{}
+func (e *joinedError) Error() string {
+	lines := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		lines = append(lines, err.Error())
 	}
 
-	dupSet := make(map[string]struct{})
-	oJSONs := jsn.Orders
-	for idx := range oJSONs {
-		aliases := oJSONs[idx].CatalogAliases
-		for _, als := range aliases {
-			_, ok := alsSet[als]
-			if !ok {
-				// Check no undefined alias
-				return fmt.Errorf("%s: %w", als, errUndefinedAlias)
-			}
+	return fmt.Sprintf("orders failed (%d):\n%s", len(e.errs), strings.Join(lines, "\n"))
+}
+
+func (e *joinedError) Unwrap() []error {
+	return e.errs
+}
+
+// Is and As make errors.Is and errors.As find the failures before Go 1.20,
+// which does not know Unwrap() []error.
+func (e *joinedError) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
 		}
+	}
 
-		if _, ok := dupSet[oJSONs[idx].URI]; !ok {
-			dupSet[oJSONs[idx].URI] = struct{}{}
-			continue
+	return false
+}
+
+func (e *joinedError) As(target interface{}) bool {
+	for _, err := range e.errs {
+		if errors.As(err, target) {
+			return true
 		}
-		// Check No Duplicated destination
-		return fmt.Errorf("%s: %w", oJSONs[idx].URI, errOrderURIDuplicated)
 	}
 
-	return nil
+	return false
 }
 
-const (
-	defaultTimeout  = 30
-	defaultEnvOut   = "./cannect.env"
-	defaultConLimit = 5
-)
+type loadResult struct {
+	cntJSON CAnnectJSON
+	err     error
+}
+
+// loadConfig creates and validates the CAnnectJSON within the deadline of the
+// context.
+func loadConfig(ctx context.Context, catalog, order, catalogOrder string, flgs int) (CAnnectJSON, error) {
+	if err := ctx.Err(); err != nil {
+		return CAnnectJSON{}, timeoutError{phase: configPhase, err: err}
+	}
+
+	done := make(chan loadResult, 1)
+	go func() {
+		cntJSON, err := CreateCannectJSON(catalog, order, catalogOrder, flgs)
+		done <- loadResult{cntJSON: cntJSON, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return CAnnectJSON{}, timeoutError{phase: configPhase, err: ctx.Err()}
+	case res := <-done:
+		return res.cntJSON, res.err
+	}
+}
+
+// execute runs the orders within the deadline of the context, and reports the
+// execution phase if the deadline is exceeded.
+func execute(ctx context.Context, cntJSON CAnnectJSON, cfg runConfig, logger *log.Logger) error {
+	err := run(ctx, cntJSON, cfg, logger)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return timeoutError{phase: executionPhase, err: err}
+	}
+
+	return err
+}
 
 const (
 	catalogFlg      = 0x01
@@ -334,42 +2267,30 @@ func CreateCannectJSON(catalog, order, catalogOrder string, flgs int) (CAnnectJS
 	var cntJSON CAnnectJSON
 	switch flgs {
 	case catalogFlg | orderFlg:
-		cFile, err := os.Open(catalog)
+		cFiles, closeCFiles, err := openConfigFiles(catalog)
 		if err != nil {
 			return cntJSON, err
 		}
-		defer func() {
-			if err := cFile.Close(); err != nil {
-				log.Printf("failed to close file: %v\n", err)
-			}
-		}()
+		defer closeCFiles()
 
-		oFile, err := os.Open(order)
+		oFiles, closeOFiles, err := openConfigFiles(order)
 		if err != nil {
 			return cntJSON, err
 		}
-		defer func() {
-			if err := oFile.Close(); err != nil {
-				log.Printf("failed to close file: %v\n", err)
-			}
-		}()
+		defer closeOFiles()
 
-		cntJSON, err = unmarshalBoth(cFile, oFile)
+		cntJSON, err = unmarshalBoth(cFiles, oFiles)
 		if err != nil {
 			return cntJSON, err
 		}
 	case catalogOrderFlg:
-		file, err := os.Open(catalogOrder)
+		files, closeFiles, err := openConfigFiles(catalogOrder)
 		if err != nil {
 			return cntJSON, err
 		}
-		defer func() {
-			if err := file.Close(); err != nil {
-				log.Printf("failed to close file: %v\n", err)
-			}
-		}()
+		defer closeFiles()
 
-		cntJSON, err = unmarshal(file)
+		cntJSON, err = unmarshalAll(files)
 		if err != nil {
 			return cntJSON, err
 		}
@@ -383,43 +2304,271 @@ func CreateCannectJSON(catalog, order, catalogOrder string, flgs int) (CAnnectJS
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "inspect" {
+		os.Exit(inspectMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "operator" {
+		os.Exit(operatorMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lambda" {
+		os.Exit(lambdaMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validateMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		os.Exit(watchMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		os.Exit(schemaMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "gen-fixtures" {
+		os.Exit(genFixturesMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit(selftestMain(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "probe" {
+		os.Exit(probeMain(os.Args[2:]))
+	}
+
 	logger := log.New(os.Stdout, "", log.LstdFlags)
 
-	catalog := flag.String("catalog", "", "The path of JSON format file contains catalogs.")
-	order := flag.String("order", "", "The path of JSON format file contains orders.")
-	catalogOrder := flag.String("catalog-order", "", "The path of JSON format file contains catalogs and orders.")
-	envOut := flag.String("env-out", defaultEnvOut, "'env' scheme output file.")
-	conLimit := flag.Int("con-limit", defaultConLimit, "The limit of concurrency..")
-	timeout := flag.Int64("timeout", defaultTimeout, "Timeout (seconds).")
+	msgs = newPrinter(langFromArgs(os.Args[1:]))
+
+	catalog := new(listFlag)
+	flag.Var(catalog, "catalog", msgs.Sprintf(msgFlagCatalog))
+	order := new(listFlag)
+	flag.Var(order, "order", msgs.Sprintf(msgFlagOrder))
+	catalogOrder := new(listFlag)
+	flag.Var(catalogOrder, "catalog-order", msgs.Sprintf(msgFlagCatalogOrder))
+	envOut := flag.String("env-out", defaultEnvOut, msgs.Sprintf(msgFlagEnvOut))
+	envFormat := flag.String("env-format", defaultEnvFormat, msgs.Sprintf(msgFlagEnvFormat))
+	envBase64 := flag.Bool("env-base64", false, msgs.Sprintf(msgFlagEnvBase64))
+	noWrite := flag.Bool("no-write", false, msgs.Sprintf(msgFlagNoWrite))
+	dryRun := flag.Bool("dry-run", false, msgs.Sprintf(msgFlagDryRun))
+	check := flag.Bool("check", false, msgs.Sprintf(msgFlagCheck))
+	policy := flag.String("policy", "", msgs.Sprintf(msgFlagPolicy))
+	conLimit := flag.Int("con-limit", defaultConLimit, msgs.Sprintf(msgFlagConLimit))
+	timeout := flag.Int64("timeout", defaultTimeout, msgs.Sprintf(msgFlagTimeout))
+	configTimeout := flag.Int64("config-timeout", defaultConfigTimeout, msgs.Sprintf(msgFlagConfigTimeout))
+	fetchTimeout := flag.Int64("fetch-timeout", 0, msgs.Sprintf(msgFlagFetchTimeout))
+	fips := flag.Bool("fips", false, msgs.Sprintf(msgFlagFIPS))
+	root := flag.String("root", "", msgs.Sprintf(msgFlagRoot))
+	fsRoot := flag.String("fs-root", "", msgs.Sprintf(msgFlagFSRoot))
+	fsStrict := flag.Bool("fs-strict", false, msgs.Sprintf(msgFlagFSStrict))
+	logFormat := flag.String("log-format", defaultLogFormat, msgs.Sprintf(msgFlagLogFormat))
+	logLevel := flag.String("log-level", defaultLogLevel, msgs.Sprintf(msgFlagLogLevel))
+	keepGoing := flag.Bool("keep-going", false, msgs.Sprintf(msgFlagKeepGoing))
+	output := flag.String("output", textOutput, msgs.Sprintf(msgFlagOutput))
+	summary := flag.String("summary", "", msgs.Sprintf(msgFlagSummary))
+	quotaSpec := flag.String("quota", "", msgs.Sprintf(msgFlagQuota))
+	skipUnchanged := flag.Bool("skip-unchanged", false, msgs.Sprintf(msgFlagSkipUnchanged))
+	stateURI := flag.String("state", "", msgs.Sprintf(msgFlagState))
+	strict := flag.Bool("strict", false, msgs.Sprintf(msgFlagStrict))
+	metricsTextfile := flag.String("metrics-textfile", "", msgs.Sprintf(msgFlagMetricsTextfile))
+	netFlags := addNetworkFlags(flag.CommandLine)
+	flag.StringVar(&configFormat, "format", "", msgs.Sprintf(msgFlagFormat))
+	flag.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
 	flag.Parse()
 
-	flgs, ok := checkExclusive(*catalog, *order, *catalogOrder)
+	flgs, ok := checkExclusive(catalog.String(), order.String(), catalogOrder.String())
 	if !ok {
-		// nolint lll
-		logger.Fatalln(
-			`
-Usage: cannect <OPTIONS>
-  OPTIONS
-    -catalog <file-path> The path of catalog file. (required: Exclusive to -catalog-order)
-    -order <file-path> The path of order file. (required: Exclusive to -catalog-order)
-    -catalog-order <file-path> The path of file contains both orders and catalogs. (required: Exclusive to -catalog and -order)
-    -env-out <file-path> The path of env scheme output. (default: ./cannect.env)
-    -con-limit <number> The limit of concurrency. (default: 5)
-    -timeout <number> The number of seconds for timeout. (default: 30)`,
-		)
+		logger.Fatalln(usage())
+	}
+
+	switch configFormat {
+	case "", jsonFormat, yamlFormat, tomlFormat:
+	default:
+		log.Fatalf("%s: %v", configFormat, errUndefinedFormat)
 	}
 
-	cntJSON, err := CreateCannectJSON(*catalog, *order, *catalogOrder, flgs)
+	format, ok := envFormats[*envFormat]
+	if !ok {
+		log.Fatalf("%s: %v", *envFormat, errUndefinedEnvFormat)
+	}
+
+	switch *output {
+	case textOutput, githubOutput:
+	default:
+		log.Fatalf("%s: %v", *output, errUndefinedOutput)
+	}
+
+	quota, err := parseQuota(*quotaSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	httpClient, err := netFlags.httpClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	s3JSON, err := netFlags.s3()
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	var annotations io.Writer = os.Stdout
+	fatal := func(err error) {
+		if *output == githubOutput {
+			_ = reportGitHub(annotations, "cannect", nil, nil, err)
+		}
+		log.Fatal(err)
+	}
+
+	configCtx, configCancel := context.WithTimeout(context.Background(), time.Second*time.Duration(*configTimeout))
+	cntJSON, err := loadConfig(configCtx, catalog.String(), order.String(), catalogOrder.String(), flgs)
+	configCancel()
+	if err != nil {
+		fatal(err)
+	}
+	if *policy != "" {
+		pJSON, err := loadPolicy(*policy)
+		if err != nil {
+			fatal(err)
+		}
+
+		err = pJSON.check(cntJSON)
+		if err != nil {
+			fatal(err)
+		}
+	}
+	if hasStdoutOrder(cntJSON) {
+		// Keep the standard output for the contents.
+		logger.SetOutput(os.Stderr)
+		annotations = os.Stderr
+	}
+
 	ctx := context.Background()
 	ctx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(*timeout))
 	defer cancel()
 
-	cfg := newRunConfig(*envOut, *conLimit)
-	err = run(ctx, cntJSON, cfg, logger)
+	cfg := newRunConfig(*envOut, *conLimit, *fips)
+	cfg.HTTPClient = httpClient
+	cfg.S3 = s3JSON
+	cfg.EnvFormat = format
+	cfg.EnvBase64 = *envBase64
+	cfg.FSGuard = newFSGuard(*fsRoot, *root, *fsStrict)
+	cfg.Root = *root
+	cfg.NoWrite = *noWrite || *dryRun || *check
+	cfg.KeepGoing = *keepGoing
+	cfg.FetchTimeout = time.Second * time.Duration(*fetchTimeout)
+	cfg.Usage = newUsageMeter(quota)
+	cfg.SkipUnchanged = *skipUnchanged
+	cfg.Warnings = newWarningSet()
+	var registry *metricsapi.Registry
+	if *metricsTextfile != "" {
+		registry = metricsapi.NewRegistry()
+		cfg.Metrics = registry
+	}
+	if *check {
+		cfg.Drifts = newDriftSet()
+	}
+	if *output == githubOutput || *dryRun || *summary != "" {
+		cfg.Report = newRunReport()
+	}
+	if *summary != "" {
+		digests, err := readSummaryDigests(*summary)
+		if err != nil {
+			fatal(err)
+		}
+		cfg.Report.withPrevious(digests)
+	}
+	var store stateapi.Store
+	if *stateURI != "" {
+		store, err = newStateStore(*stateURI, cfg)
+		if err != nil {
+			fatal(err)
+		}
+		cfg.Digests, err = loadDigests(ctx, store)
+		if err != nil {
+			fatal(err)
+		}
+	}
+	logger, err = newLogger(logger.Writer(), *logFormat, *logLevel, &cfg)
+	if err != nil {
+		fatal(err)
+	}
+	if cfg.Log != nil {
+		// Write the errors in the format too.
+		log.SetFlags(0)
+		errLog, _ := newStructuredLog(log.Writer(), *logFormat, *logLevel)
+		log.SetOutput(levelWriter{l: errLog, level: slog.LevelError})
+	}
+	startedAt := time.Now()
+	tracer, err := newTracerProvider(ctx)
+	if err != nil {
+		fatal(err)
+	}
+	if tracer != nil {
+		cfg.Tracer = tracer
+		ctx = traceapi.ContextFromEnv(ctx)
+	}
+	err = traced(ctx, tracer, "cannect.run", logger, func(ctx context.Context) error {
+		return execute(ctx, cntJSON, cfg, logger)
+	})
+	if registry != nil {
+		mErr := registry.WriteTextfile(*metricsTextfile)
+		if mErr != nil {
+			log.Println(mErr)
+		}
+	}
+	if store != nil && !cfg.NoWrite {
+		sErr := saveDigests(store, cfg.Digests)
+		if sErr != nil {
+			log.Println(sErr)
+		}
+	}
+	if *summary != "" {
+		rSummary := newRunSummary(cfg.Report.Results(), startedAt, time.Now(), cfg.NoWrite, err)
+		usage := cfg.Usage.Usage()
+		rSummary.Usage = &usage
+		rSummary.Warnings = cfg.Warnings.Warnings()
+		sErr := writeSummary(*summary, rSummary)
+		if sErr != nil {
+			log.Println(sErr)
+		}
+	}
+	if *dryRun {
+		// Nothing is written to the standard output by the orders.
+		rErr := writeReport(os.Stdout, cfg.Report.Results())
+		if rErr != nil {
+			log.Println(rErr)
+		}
+	}
+	if *output == githubOutput {
+		rErr := reportGitHub(annotations, "cannect", cfg.Report.Results(), cfg.Report.Warnings(), err)
+		if rErr != nil {
+			log.Println(rErr)
+		}
+	}
 	if err != nil {
 		log.Println(err)
 	}
+	if err != nil && *keepGoing {
+		// All orders are completed, and the failures are reported above.
+		cancel()
+		os.Exit(1)
+	}
+
+	if *check {
+		cancel()
+		if err != nil {
+			os.Exit(1)
+		}
+
+		drifts := cfg.Drifts.Drifts()
+		if len(drifts) > 0 {
+			if err := writeDrifts(os.Stdout, drifts); err != nil {
+				log.Println(err)
+			}
+			os.Exit(exitDrifted)
+		}
+	}
+
+	if *strict && len(cfg.Warnings.Warnings()) > 0 {
+		// The warnings are logged by the checks.
+		cancel()
+		os.Exit(exitWarned)
+	}
 }
@@ -1,13 +1,34 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/internal/testca"
+	"github.com/yuxki/cannect/pkg/asset"
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	metricsapi "github.com/yuxki/cannect/pkg/metrics"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	"github.com/yuxki/cannect/pkg/transform"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
 )
 
 func TestUnmarshal(t *testing.T) {
@@ -124,7 +145,7 @@ func TestUnmarshalBoth(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	jsn, err := unmarshalBoth(catalogFile, orderFile)
+	jsn, err := unmarshalBoth([]*os.File{catalogFile}, []*os.File{orderFile})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -213,114 +234,2440 @@ func TestValidate(t *testing.T) {
 			},
 			errUndefinedAlias,
 		},
+		{
+			"NG:CA Policy Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.key",
+						URI:      "file://testdata/root-ca.key",
+						Category: "privateKey",
+						CAPolicy: &CAPolicyJSON{RequireNameConstraints: true},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.key",
+						},
+						URI: "file://testdata/test-root-ca.key",
+					},
+				},
+			},
+			errCAPolicyNotAllowed,
+		},
+		{
+			"NG:No Key Pair Aliases",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:      "file://testdata/test-root-ca.crt",
+						MatchKey: true,
+					},
+				},
+			},
+			errNoKeyPairAliases,
+		},
+		{
+			"NG:Undefined Curve",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:     "root-ca.crt",
+						URI:       "file://testdata/root-ca.crt",
+						Category:  "certificate",
+						KeyPolicy: &KeyPolicyJSON{AllowedCurves: []string{"secp256k1"}},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			errUndefinedCurve,
+		},
+		{
+			"NG:Undefined Signature Algorithm",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:     "root-ca.crt",
+						URI:       "file://testdata/root-ca.crt",
+						Category:  "certificate",
+						KeyPolicy: &KeyPolicyJSON{BannedSignatureAlgorithms: []string{"SHA1"}},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			errUndefinedSignatureAlgo,
+		},
+		{
+			"NG:Category Duplicated",
+			CAnnectJSON{
+				Categories: []CategoryJSON{
+					{Name: "certificate", Pattern: noneCategoryPattern},
+				},
+			},
+			errCategoryDuplicated,
+		},
+		{
+			"NG:Invalid Category Pattern",
+			CAnnectJSON{
+				Categories: []CategoryJSON{
+					{Name: "dhParams", Pattern: "("},
+				},
+			},
+			errInvalidCategoryPattern,
+		},
+		{
+			"NG:Category Exclusive",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:      "server.pem",
+						URI:        "file://testdata/server.pem",
+						Category:   "certificate",
+						Categories: []string{"certificate", "privateKey"},
+					},
+				},
+			},
+			errCategoryExclusive,
+		},
+		{
+			"NG:Multi Category Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:      "server.pem",
+						URI:        "file://testdata/server.pem",
+						Categories: []string{"certificate", "derCertificate"},
+					},
+				},
+			},
+			errMultiCategoryNotAllowed,
+		},
+		{
+			"NG:Expiry Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:      "root-ca.key",
+						URI:        "file://testdata/root-ca.key",
+						Category:   "privateKey",
+						WarnBefore: &[]int{30}[0],
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.key",
+						},
+						URI: "file://testdata/test-root-ca.key",
+					},
+				},
+			},
+			errExpiryNotAllowed,
+		},
+		{
+			"NG:Invalid Validity",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:                "root-ca.crt",
+						URI:                  "file://testdata/root-ca.crt",
+						Category:             "certificate",
+						MinRemainingValidity: &[]int{-1}[0],
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			errInvalidValidity,
+		},
+		{
+			"NG:Invalid Subject",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+						Filter:   &FilterJSON{Subject: "CN=(Root"},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			errInvalidSubject,
+		},
+		{
+			"NG:Multiple Stdout Orders",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "stdout://",
+					},
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "stdout://",
+					},
+				},
+			},
+			errOrderURIDuplicated,
+		},
+		{
+			"NG:Undefined Seal",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:  "file://testdata/test-root-ca.crt.crt",
+						Seal: "tpm1",
+					},
+				},
+			},
+			errUndefinedSeal,
+		},
+		{
+			"NG:Webhook Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "file://testdata/test-root-ca.crt.crt",
+						Webhook: &WebhookJSON{Method: "PUT"},
+					},
+				},
+			},
+			errWebhookNotAllowed,
+		},
+		{
+			"NG:Command Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "file://testdata/test-root-ca.crt.crt",
+						Command: &CommandJSON{Args: []string{"cat"}},
+					},
+				},
+			},
+			errCommandNotAllowed,
+		},
+		{
+			"NG:No Command",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "cmd://vault",
+					},
+				},
+			},
+			errNoCommand,
+		},
+		{
+			"NG:Publish Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "https://ca.example.com/root-ca.crt",
+						Publish: &PublishJSON{Notify: true},
+					},
+				},
+			},
+			errPublishNotAllowed,
+		},
+		{
+			"NG:Invalid QoS",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "mqtt://broker.example.com/fleet/ca",
+						Publish: &PublishJSON{QoS: 2},
+					},
+				},
+			},
+			errInvalidQoS,
+		},
+		{
+			"NG:URL Without Notify",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "nats://nats.example.com/fleet.ca",
+						Publish: &PublishJSON{URL: "https://ca.example.com/root-ca.crt"},
+					},
+				},
+			},
+			errURLWithoutNotify,
+		},
+		{
+			"NG:Invalid Budget",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:  "file://testdata/edge.der",
+						Edge: &EdgeJSON{Budget: -1},
+					},
+				},
+			},
+			errInvalidBudget,
+		},
+		{
+			"NG:Edge DER With Verify",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:    "file://testdata/edge.der",
+						Verify: crossSignedVerify,
+						Edge:   &EdgeJSON{DER: true},
+					},
+				},
+			},
+			errEdgeDERNotAllowed,
+		},
+		{
+			"NG:Undefined Format",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:    "file://testdata/root-ca.p12",
+						Format: "pkcs12",
+					},
+				},
+			},
+			errUndefinedOrderFormat,
+		},
+		{
+			"NG:DER Format With Verify",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:    "file://testdata/root-ca.cer",
+						Verify: crossSignedVerify,
+						Format: derFormat,
+					},
+				},
+			},
+			errFormatDERNotAllowed,
+		},
+		{
+			"NG:DER Category With Filter",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.cer",
+						URI:      "file://testdata/root-ca.cer",
+						Category: "derCertificate",
+						Filter:   &FilterJSON{Types: []string{"CERTIFICATE"}},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.cer",
+						},
+						URI: "file://testdata/test-root-ca.cer",
+					},
+				},
+			},
+			errDERFilterNotAllowed,
+		},
+		{
+			"NG:Undefined Webhook Method",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "https://inventory.example.com/api/v1/bundles",
+						Webhook: &WebhookJSON{Method: "DELETE"},
+					},
+				},
+			},
+			errUndefinedMethod,
+		},
+		{
+			"NG:Pull Request Without Ref",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:    "github:///repos/yuxki/pki/contents/dist/root-ca.crt",
+						GitHub: &GitHubJSON{PullRequestBase: "main"},
+					},
+				},
+			},
+			errPullRequestNoRef,
+		},
+		{
+			"NG:Undefined Invalidate Provider",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:        "s3://foo-bucket/root-ca.crt",
+						Invalidate: &InvalidateJSON{Provider: "fastly", Target: "E2EXAMPLE"},
+					},
+				},
+			},
+			errUndefinedProvider,
+		},
+		{
+			"NG:Invalidate Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:        "gcs://foo-bucket/root-ca.crt",
+						Invalidate: &InvalidateJSON{Provider: "cloudfront", Target: "E2EXAMPLE"},
+					},
+				},
+			},
+			errInvalidateNotAllowed,
+		},
+		{
+			"NG:Invalid CDN Target",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:        "gcs://foo-bucket/root-ca.crt",
+						Invalidate: &InvalidateJSON{Provider: "cloudcdn", Target: "foo-project"},
+					},
+				},
+			},
+			errInvalidCDNTarget,
+		},
+		{
+			"NG:Template Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:      "zip://testdata/bundle.zip",
+						Template: "{{ .Content }}",
+					},
+				},
+			},
+			errTemplateNotAllowed,
+		},
+		{
+			"NG:Undefined Final Newline",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:  "file://testdata/test-root-ca.crt.crt",
+						Join: &JoinJSON{FinalNewline: "always"},
+					},
+				},
+			},
+			errUndefinedFinalNewline,
+		},
+		{
+			"NG:DNS Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+						DNS: &DNSJSON{TTL: 60},
+					},
+				},
+			},
+			errDNSNotAllowed,
+		},
+		{
+			"NG:Invalid TLSA",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "dns://route53/Z0123456789ABC/_443._tcp.example.com",
+						DNS: &DNSJSON{TLSA: "3 1 3"},
+					},
+				},
+			},
+			errInvalidTLSA,
+		},
+		{
+			"NG:CERT Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "dns://route53/Z0123456789ABC/ca.example.com?type=CERT",
+					},
+				},
+			},
+			errCERTNotAllowed,
+		},
+		{
+			"NG:Merge CRL Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:      "tar://testdata/bundle.tar",
+						MergeCRL: true,
+					},
+				},
+			},
+			errMergeCRLNotAllowed,
+		},
+		{
+			"NG:Undefined Custom Scheme",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "undefined://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errUndefinedDstScheme,
+		},
+		{
+			"NG:Catalog Not Supported In Custom Scheme",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "testorder://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "testscheme://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errUndefinedSrcScheme,
+		},
+		{
+			"NG:Filter Not Allowed In Custom Scheme",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "testscheme://testdata/root-ca.crt",
+						Category: "certificate",
+						Filter:   &FilterJSON{ExcludeExpired: true},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "testscheme://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errFilterNotAllowed,
+		},
+		{
+			"NG:Fallbacks Without URI",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URIs:      []string{"file://testdata/test-root-ca.crt.crt"},
+						Fallbacks: []string{"file://testdata/test-root-ca-fallback.crt"},
+					},
+				},
+			},
+			errFallbacksNotAllowed,
+		},
+		{
+			"NG:Duplicated Fallbacks",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:       "file://testdata/test-root-ca.crt.crt",
+						Fallbacks: []string{"file://testdata/test-root-ca.crt.crt"},
+					},
+				},
+			},
+			errOrderURIDuplicated,
+		},
+		{
+			"NG:Range Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "github:///repos/yuxki/cannect/contents/examples/store/root-ca.crt",
+						Category: "certificate",
+						Range:    &RangeJSON{FirstBlock: true},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errRangeNotAllowed,
+		},
+		{
+			"NG:Invalid Range",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+						Range:    &RangeJSON{Offset: -1},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errInvalidRange,
+		},
+		{
+			"OK:Retry",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "github:///repos/yuxki/cannect/contents/examples/store/root-ca.crt",
+						Category: "certificate",
+						Retry:    &RetryJSON{Attempts: 3, BaseDelay: "500ms", Jitter: 0.5, MaxElapsed: "1m"},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"NG:Retry Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+						Retry:    &RetryJSON{Attempts: 3},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errRetryNotAllowed,
+		},
+		{
+			"NG:Invalid Attempts",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "github:///repos/yuxki/cannect/contents/examples/store/root-ca.crt",
+						Category: "certificate",
+						Retry:    &RetryJSON{Attempts: -1},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errInvalidAttempts,
+		},
+		{
+			"NG:Invalid Jitter",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "github:///repos/yuxki/cannect/contents/examples/store/root-ca.crt",
+						Category: "certificate",
+						Retry:    &RetryJSON{Jitter: 1.5},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errInvalidJitter,
+		},
+		{
+			"NG:Invalid Retry Delay",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "s3://bucket/root-ca.crt",
+						Category: "certificate",
+						Retry:    &RetryJSON{BaseDelay: "soon"},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errInvalidRetryDelay,
+		},
+		{
+			"OK:Workload Scheme",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "spire-bundle",
+						URI:      "workload:///run/spire/sockets/agent.sock?asset=bundle",
+						Category: "certificate",
+						Filter:   &FilterJSON{ExcludeExpired: true},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"spire-bundle",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"OK:Timeout",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+						Timeout:  10,
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "file://testdata/test-root-ca.crt.crt",
+						Timeout: 30,
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"NG:Negative Catalog Timeout",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+						Timeout:  -1,
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "file://testdata/test-root-ca.crt.crt",
+						Timeout: 0,
+					},
+				},
+			},
+			errInvalidTimeout,
+		},
+		{
+			"NG:Negative Order Timeout",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+						Timeout:  0,
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:     "file://testdata/test-root-ca.crt.crt",
+						Timeout: -1,
+					},
+				},
+			},
+			errInvalidTimeout,
+		},
+		{
+			"NG:Duplicated Aliases",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/sub-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+				},
+			},
+			errAliasDuplicated,
+		},
+		{
+			"NG:Duplicated URIs",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt.crt",
+					},
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URIs: []string{
+							"env://ROOT_CA",
+							"file://testdata/test-root-ca.crt.crt",
+						},
+					},
+				},
+			},
+			errOrderURIDuplicated,
+		},
+		{
+			"NG:URI And URIs",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI:  "file://testdata/test-root-ca.crt.crt",
+						URIs: []string{"env://ROOT_CA"},
+					},
+				},
+			},
+			errURIsExclusive,
+		},
+		{
+			"OK:S3",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "s3://cannect/root-ca.crt",
+						Category: "certificate",
+						S3:       &S3JSON{Endpoint: "https://minio.lab:9000", Region: "us-east-1", PathStyle: true},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "s3://cannect-out/root-ca.crt",
+						S3:  &S3JSON{Endpoint: "http://minio.lab:9000", PathStyle: true},
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"NG:S3 Not Allowed Catalog",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+						S3:       &S3JSON{PathStyle: true},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			errS3NotAllowed,
+		},
+		{
+			"NG:S3 Not Allowed Order",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+						S3:  &S3JSON{Region: "us-east-1"},
+					},
+				},
+			},
+			errS3NotAllowed,
+		},
+		{
+			"NG:Invalid S3 Endpoint",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "s3://cannect/root-ca.crt",
+						Category: "certificate",
+						S3:       &S3JSON{Endpoint: "minio.lab:9000"},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			errInvalidS3Endpoint,
+		},
+		{
+			"OK:S3 Assume Role With Version",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "s3://cannect/root-ca.crt?versionId=3HL4kqtJlcpXroDTDmJ.rmSpXd3dIbrHY",
+						Category: "certificate",
+						S3:       &S3JSON{RoleARN: "arn:aws:iam::123456789012:role/cannect-reader", ExternalID: "lab"},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"NG:Invalid Role ARN",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "s3://cannect/root-ca.crt",
+						Category: "certificate",
+						S3:       &S3JSON{RoleARN: "cannect-reader"},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			errInvalidRoleARN,
+		},
+		{
+			"NG:No Role ARN",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "s3://cannect/root-ca.crt",
+						Category: "certificate",
+						S3:       &S3JSON{ExternalID: "lab"},
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "file://testdata/test-root-ca.crt",
+					},
+				},
+			},
+			errNoRoleARN,
+		},
+		{
+			"NG:Role Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "s3://cannect-out/root-ca.crt",
+						S3:  &S3JSON{RoleARN: "arn:aws:iam::123456789012:role/cannect-reader", ExternalID: "lab"},
+					},
+				},
+			},
+			errRoleNotAllowed,
+		},
+		{
+			"NG:Version Not Allowed",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{
+						Alias:    "root-ca.crt",
+						URI:      "file://testdata/root-ca.crt",
+						Category: "certificate",
+					},
+				},
+				Orders: []OrderJSON{
+					{
+						CatalogAliases: []string{
+							"root-ca.crt",
+						},
+						URI: "s3://cannect-out/root-ca.crt?versionId=3HL4kqtJlcpXroDTDmJ.rmSpXd3dIbrHY",
+					},
+				},
+			},
+			errVersionNotAllowed,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testdata, func(t *testing.T) {
+			t.Parallel()
+
+			err := validate(d.jsn)
+
+			if d.err == nil {
+				if err != nil {
+					t.Fatalf("Expected no error but got: %s", err.Error())
+				}
+				return
+			}
+
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+			{
+				Alias:    "sub-ca.crt",
+				URI:      "file://testdata/sub-ca.crt",
+				Category: "certificate",
+			},
+			{
+				Alias:    "server.crt",
+				URI:      "file://testdata/server.crt",
+				Category: "certificate",
+			},
+			{
+				Alias:    "chain-head.crt",
+				URI:      "file://testdata/chain.crt",
+				Category: "certificate",
+				Range:    &RangeJSON{FirstBlock: true},
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URI: "file://testdata/test-root-ca.out",
+			},
+			{
+				CatalogAliases: []string{
+					"chain-head.crt",
+				},
+				URI: "file://testdata/test-chain-head.out",
+			},
+			{
+				CatalogAliases: []string{
+					"sub-ca.crt",
+				},
+				URI: "file://testdata/test-sub-ca.out",
+			},
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+					"sub-ca.crt",
+					"server.crt",
+				},
+				URI: "file://testdata/test-chain.out",
+			},
+			{
+				CatalogAliases: []string{
+					"server.crt",
+				},
+				URIs: []string{
+					"file://testdata/test-server-1.out",
+					"file://testdata/test-server-2.out",
+				},
+			},
+			{
+				CatalogAliases: []string{
+					"server.crt",
+				},
+				URI:      "file://testdata/test-server-b64.out",
+				Template: `{{ .Asset "server.crt" | base64 }}`,
+			},
+		},
+	}
+
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5}
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+	err := run(context.TODO(), jsn, cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootWant, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootResult, err := os.ReadFile("testdata/test-root-ca.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rootResult, rootWant); diff != "" {
+		t.Error(diff)
+	}
+
+	headResult, err := os.ReadFile("testdata/test-chain-head.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(headResult, rootWant); diff != "" {
+		t.Error(diff)
+	}
+
+	subWant, err := os.ReadFile("testdata/sub-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subResult, err := os.ReadFile("testdata/test-sub-ca.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(subResult, subWant); diff != "" {
+		t.Error(diff)
+	}
+
+	chainWant, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainResult, err := os.ReadFile("testdata/test-chain.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(chainResult, chainWant); diff != "" {
+		t.Error(diff)
+	}
+
+	serverWant, err := os.ReadFile("testdata/server.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"testdata/test-server-1.out", "testdata/test-server-2.out"} {
+		serverResult, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(serverResult, serverWant); diff != "" {
+			t.Error(diff)
+		}
+	}
+
+	b64Result, err := os.ReadFile("testdata/test-server-b64.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(b64Result), base64.StdEncoding.EncodeToString(serverWant)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRun_EnvFormat(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URIs: []string{"env://ROOT_CA", "env://ROOT_CA_COPY"},
+			},
+		},
+	}
+
+	envOut := "testdata/test-env-format.out"
+	t.Cleanup(func() { os.Remove(envOut) })
+
+	cfg := runConfig{EnvOut: envOut, EnvFormat: envFormats["json"], EnvBase64: true, ConLimit: 5}
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+	err := run(context.TODO(), jsn, cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootCA, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := os.ReadFile(envOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]string
+	err = json.Unmarshal(buf, &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"ROOT_CA":      base64.StdEncoding.EncodeToString(rootCA),
+		"ROOT_CA_COPY": base64.StdEncoding.EncodeToString(rootCA),
+	}
+	if diff := cmp.Diff(result, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+type testSchemeURI struct {
+	text string
+}
+
+func (t testSchemeURI) Text() string   { return t.text }
+func (t testSchemeURI) Scheme() string { return schemeapi.Of(t.text) }
+func (t testSchemeURI) Path() string   { return strings.TrimPrefix(t.text, t.Scheme()+"://") }
+
+type testSchemeCatalog struct {
+	path    string
+	checker catalogapi.AssetChecker
+}
+
+func (t testSchemeCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	buf, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, t.checker.CheckContent(buf)
+}
+
+type testSchemeOrder struct {
+	path     string
+	catalogs []orderapi.Catalog
+}
+
+func (t testSchemeOrder) Order(ctx context.Context) error {
+	buf, err := orderapi.NewBundle(t.catalogs).Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(t.path, buf, 0o644)
+}
+
+func testParseSchemeURI(uri string) (schemeapi.URI, error) {
+	return testSchemeURI{text: uri}, nil
+}
+
+func init() {
+	testSchemes := []schemeapi.Scheme{
+		{
+			Name:     "testscheme",
+			Usage:    "Read and write the local file for testing.",
+			ParseURI: testParseSchemeURI,
+			NewCatalog: func(uri schemeapi.URI, alias string, checker catalogapi.AssetChecker) (orderapi.Catalog, error) {
+				return testSchemeCatalog{path: uri.Path(), checker: checker}, nil
+			},
+			NewOrder: func(uri schemeapi.URI, catalogs []orderapi.Catalog) (schemeapi.Order, error) {
+				return testSchemeOrder{path: uri.Path(), catalogs: catalogs}, nil
+			},
+		},
+		{
+			Name:     "testorder",
+			Usage:    "Write the local file for testing.",
+			ParseURI: testParseSchemeURI,
+			NewOrder: func(uri schemeapi.URI, catalogs []orderapi.Catalog) (schemeapi.Order, error) {
+				return testSchemeOrder{path: uri.Path(), catalogs: catalogs}, nil
+			},
+		},
+	}
+
+	for _, s := range testSchemes {
+		err := schemeapi.Register(s)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestRun_CustomScheme(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "testscheme://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URI: "testorder://testdata/test-custom-root-ca.out",
+			},
+		},
+	}
+
+	err := validate(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5}
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+	err = run(context.TODO(), jsn, cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := os.ReadFile("testdata/test-custom-root-ca.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(result, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	text := usage()
+	for _, want := range []string{
+		"testorder Write the local file for testing.",
+		"testscheme Read and write the local file for testing.",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected usage contains %q but got: %s", want, text)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	cntJSON, err := loadConfig(context.Background(), "", "", "testdata/test_catalog_order.json", catalogOrderFlg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cntJSON.Orders) == 0 {
+		t.Fatal("Expected orders are loaded but got nothing")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	_, err = loadConfig(ctx, "", "", "testdata/test_catalog_order.json", catalogOrderFlg)
+
+	var tErr timeoutError
+	if !errors.As(err, &tErr) || tErr.phase != configPhase {
+		t.Fatalf("Expected %s phase timeout but got: %v", configPhase, err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %v but got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRun_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_Deterministic"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	outputs := func(idx int) []string {
+		return []string{
+			fmt.Sprintf("%s/%d.env", dir, idx),
+			fmt.Sprintf("%s/%d.zip", dir, idx),
+			fmt.Sprintf("%s/%d.tar.gz", dir, idx),
+		}
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		out := outputs(idx)
+		jsn := CAnnectJSON{
+			Catalogs: []CatalogJSON{
+				{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+				{Alias: "sub-ca.crt", URI: "file://testdata/sub-ca.crt", Category: "certificate"},
+				{Alias: "server.crt", URI: "file://testdata/server.crt", Category: "certificate"},
+			},
+			Orders: []OrderJSON{
+				{CatalogAliases: []string{"root-ca.crt"}, URI: "env://ROOT_CA"},
+				{CatalogAliases: []string{"sub-ca.crt"}, URI: "env://SUB_CA"},
+				{CatalogAliases: []string{"server.crt"}, URI: "env://SERVER"},
+				{
+					CatalogAliases: []string{"root-ca.crt", "sub-ca.crt", "server.crt"},
+					URIs:           []string{"zip://" + out[1], "tar://" + out[2]},
+				},
+			},
+		}
+
+		cfg := newRunConfig(out[0], 5, false)
+		cfg.EnvFormat = orderapi.DotenvEnvFormat
+		err := run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for idx, p := range outputs(0) {
+		first, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := os.ReadFile(outputs(1)[idx])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(first, second) {
+			t.Errorf("%s: Expected the same contents in every run", p)
+		}
+	}
+
+	env, err := os.ReadFile(outputs(0)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := regexp.MustCompile(`(?m)^[A-Z_]+`).FindAllString(string(env), -1)
+	if diff := cmp.Diff(keys, []string{"ROOT_CA", "SERVER", "SUB_CA"}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRun_Root(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_Root"
+	err := os.MkdirAll(path.Join(dir, "ca"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	want, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path.Join(dir, "ca", "root-ca.crt"), want, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase string
+		catalog  string
+		order    string
+		err      error
+	}{
+		{"OK:in root", "file://ca/root-ca.crt", "file:///ca/out.crt", nil},
+		{"NG:catalog outside root", "file://ca/../../root-ca.crt", "file://ca/catalog.crt", uriapi.ErrOutsideRoot},
+		{"NG:order outside root", "file://ca/root-ca.crt", "file://ca/../../order.crt", uriapi.ErrOutsideRoot},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			jsn := CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "ca", URI: d.catalog, Category: "certificate"}},
+				Orders:   []OrderJSON{{CatalogAliases: []string{"ca"}, URI: d.order}},
+			}
+
+			cfg := newRunConfig(path.Join(dir, "cannect.env"), 5, false)
+			cfg.Root = dir
+			cfg.FSGuard = newFSGuard("", cfg.Root, false)
+			err := run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			got, err := os.ReadFile(path.Join(dir, "ca", "out.crt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(got), string(want)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRun_KeepGoing(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_KeepGoing"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	want, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase  string
+		keepGoing bool
+	}{
+		{"OK:keep going", true},
+		{"OK:stop", false},
+	}
+
+	for idx, d := range data {
+		d := d
+		out := fmt.Sprintf("%s/%d", dir, idx)
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			jsn := CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{Alias: "missing.crt", URI: "file://testdata/missing.crt", Category: "certificate"},
+					{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+				},
+				Orders: []OrderJSON{
+					{CatalogAliases: []string{"missing.crt"}, URI: "file://" + out + "-missing.crt"},
+					{CatalogAliases: []string{"missing.crt"}, URI: "file://" + out + "-missing2.crt"},
+					{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + out + "-root-ca.crt"},
+				},
+			}
+
+			// The slot of the concurrency must be released by the failed orders.
+			cfg := newRunConfig(out+".env", 1, false)
+			cfg.KeepGoing = d.keepGoing
+			err := run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("Expected %#v error but got: %#v", fs.ErrNotExist, err)
+			}
+			if !d.keepGoing {
+				return
+			}
+
+			var jErr *joinedError
+			if !errors.As(err, &jErr) || len(jErr.errs) != 2 {
+				t.Fatalf("Expected the failures of 2 orders but got: %v", err)
+			}
+
+			got, err := os.ReadFile(out + "-root-ca.crt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(got), string(want)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestJoinedError(t *testing.T) {
+	t.Parallel()
+
+	tErr := timeoutError{phase: executionPhase, err: context.DeadlineExceeded}
+	jErr := new(joinedError)
+	jErr.add(errors.New("not found"))
+	jErr.add(fmt.Errorf("file://ca.crt: %w", tErr))
+
+	if !errors.Is(jErr, context.DeadlineExceeded) {
+		t.Errorf("Expected %#v error in: %v", context.DeadlineExceeded, jErr)
+	}
+	if errors.Is(jErr, context.Canceled) {
+		t.Errorf("Unexpected %#v error in: %v", context.Canceled, jErr)
+	}
+
+	var got timeoutError
+	if !errors.As(jErr, &got) || got.phase != executionPhase {
+		t.Errorf("Expected timeoutError in: %v", jErr)
+	}
+
+	want := "orders failed (2):\nnot found\nfile://ca.crt: execution timed out: context deadline exceeded"
+	if diff := cmp.Diff(jErr.Error(), want); diff != "" {
+		t.Error(diff)
 	}
 
-	for _, d := range data {
-		d := d
-		t.Run(d.testdata, func(t *testing.T) {
-			t.Parallel()
+	if err := new(joinedError).errOrNil(); err != nil {
+		t.Errorf("Expected nil but got: %v", err)
+	}
+}
 
-			err := validate(d.jsn)
+func TestRun_CustomCategory(t *testing.T) {
+	t.Parallel()
 
-			if d.err == nil {
-				if err != nil {
-					t.Fatalf("Expected no error but got: %s", err.Error())
-				}
-				return
-			}
+	dir := "testdata/TestRun_CustomCategory"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
 
-			if !errors.Is(err, d.err) {
-				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
-			}
-		})
+	dhPEM := []byte("-----BEGIN DH PARAMETERS-----\nMAYCAQACAQI=\n-----END DH PARAMETERS-----\n")
+	err = os.WriteFile(path.Join(dir, "dhparams.pem"), dhPEM, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsn := CAnnectJSON{
+		Categories: []CategoryJSON{
+			{Name: "dhParams", Pattern: "^-----BEGIN DH PARAMETERS-----"},
+			{Name: "misc", Pattern: noneCategoryPattern},
+		},
+		Catalogs: []CatalogJSON{
+			{Alias: "dhparams.pem", URI: "file://" + dir + "/dhparams.pem", Category: "dhParams"},
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "misc"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"dhparams.pem"}, URI: "file://" + dir + "/dhparams.out"},
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + dir + "/root-ca.out"},
+		},
+	}
+	err = validate(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newRunConfig(path.Join(dir, "cannect.env"), 5, false)
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path.Join(dir, "dhparams.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, dhPEM) {
+		t.Errorf("Expected the DH parameters but got: %s", got)
+	}
+
+	jsn.Catalogs[1].Category = "dhParams"
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if !errors.Is(err, asset.ErrUnexpectedCAAsset) {
+		t.Fatalf("Expected %#v error but got: %#v", asset.ErrUnexpectedCAAsset, err)
 	}
 }
 
-func TestRun(t *testing.T) {
+func TestRun_Edge(t *testing.T) {
 	t.Parallel()
 
+	dir := "testdata/TestRun_Edge"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	root, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(root)
+	if block == nil {
+		t.Fatal("Expected the PEM block of root-ca.crt")
+	}
+
 	jsn := CAnnectJSON{
 		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+			{Alias: "sub-ca.crt", URI: "file://testdata/sub-ca.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
 			{
-				Alias:    "root-ca.crt",
-				URI:      "file://testdata/root-ca.crt",
-				Category: "certificate",
-			},
-			{
-				Alias:    "sub-ca.crt",
-				URI:      "file://testdata/sub-ca.crt",
-				Category: "certificate",
+				CatalogAliases: []string{"sub-ca.crt", "root-ca.crt"},
+				URI:            "file://" + dir + "/root-ca.der",
+				Edge:           &EdgeJSON{DER: true, RootOnly: true, Budget: len(block.Bytes)},
 			},
+		},
+	}
+
+	cfg := newRunConfig(path.Join(dir, "cannect.env"), 5, false)
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path.Join(dir, "root-ca.der"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, block.Bytes) {
+		t.Errorf("Expected the DER of root-ca.crt but got %d bytes", len(got))
+	}
+
+	jsn.Orders[0].Edge.Budget = len(block.Bytes) - 1
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if !errors.Is(err, transform.ErrOverBudget) {
+		t.Fatalf("Expected %#v error but got: %#v", transform.ErrOverBudget, err)
+	}
+}
+
+func TestRun_VerifyChain(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_VerifyChain"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+			{Alias: "sub-ca.crt", URI: "file://testdata/sub-ca.crt", Category: "certificate"},
+			{Alias: "server.crt", URI: "file://testdata/server.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
 			{
-				Alias:    "server.crt",
-				URI:      "file://testdata/server.crt",
-				Category: "certificate",
+				CatalogAliases: []string{"server.crt", "sub-ca.crt", "root-ca.crt"},
+				URI:            "file://" + dir + "/chain.crt",
+				Verify:         chainVerify,
 			},
 		},
+	}
+	err = validate(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newRunConfig(path.Join(dir, "cannect.env"), 5, false)
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsn.Orders[0].CatalogAliases = []string{"sub-ca.crt", "server.crt", "root-ca.crt"}
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if !errors.Is(err, asset.ErrChainOrder) {
+		t.Fatalf("Expected %#v error but got: %#v", asset.ErrChainOrder, err)
+	}
+}
+
+func TestRun_MatchKey(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_MatchKey"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	ca, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := ca.Leaf.KeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootKey, err := ca.Root.KeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string][]byte{
+		"server.crt": ca.Leaf.CertPEM(), "server.key": leafKey, "root-ca.key": rootKey,
+	} {
+		err := os.WriteFile(path.Join(dir, name), content, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "server.crt", URI: "file://" + dir + "/server.crt", Category: "certificate"},
+			{Alias: "server.key", URI: "file://" + dir + "/server.key", Category: "privateKey"},
+			{Alias: "root-ca.key", URI: "file://" + dir + "/root-ca.key", Category: "privateKey"},
+		},
 		Orders: []OrderJSON{
 			{
-				CatalogAliases: []string{
-					"root-ca.crt",
-				},
-				URI: "file://testdata/test-root-ca.out",
+				CatalogAliases: []string{"server.crt", "server.key"},
+				URI:            "file://" + dir + "/server.pem",
+				MatchKey:       true,
 			},
+		},
+	}
+	err = validate(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newRunConfig(path.Join(dir, "cannect.env"), 5, false)
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsn.Orders[0].CatalogAliases = []string{"server.crt", "root-ca.key"}
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if !errors.Is(err, asset.ErrKeyMismatch) {
+		t.Fatalf("Expected %#v error but got: %#v", asset.ErrKeyMismatch, err)
+	}
+}
+
+func TestRun_FetchOnce(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
 			{
-				CatalogAliases: []string{
-					"sub-ca.crt",
-				},
-				URI: "file://testdata/test-sub-ca.out",
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
 			},
 			{
-				CatalogAliases: []string{
-					"root-ca.crt",
-					"sub-ca.crt",
-					"server.crt",
-				},
-				URI: "file://testdata/test-chain.out",
+				Alias:    "sub-ca.crt",
+				URI:      "file://testdata/sub-ca.crt",
+				Category: "certificate",
 			},
 		},
 	}
+	for i := 0; i < 3; i++ {
+		out := fmt.Sprintf("testdata/test-fetch-once-%d.out", i)
+		t.Cleanup(func() { os.Remove(out) })
 
-	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5}
-	logger := log.New(os.Stdout, "", log.LstdFlags)
-	err := run(context.TODO(), jsn, cfg, logger)
+		jsn.Orders = append(jsn.Orders, OrderJSON{
+			CatalogAliases: []string{"sub-ca.crt", "root-ca.crt"},
+			URI:            "file://" + out,
+		})
+	}
+
+	err := validate(jsn)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	rootWant, err := os.ReadFile("testdata/root-ca.crt")
+	var buf bytes.Buffer
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 3}
+	err = run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
 	if err != nil {
 		t.Fatal(err)
 	}
-	rootResult, err := os.ReadFile("testdata/test-root-ca.out")
+
+	for _, uri := range []string{"file://testdata/root-ca.crt", "file://testdata/sub-ca.crt"} {
+		if got := strings.Count(buf.String(), msgs.Sprintf(msgFetching, uri)+"\n"); got != 1 {
+			t.Errorf("Expected %s is fetched once but got: %d\n%s", uri, got, buf.String())
+		}
+	}
+}
+
+func TestCreateCatalogSets_Shared(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+			{Alias: "root.crt", URI: "FILE://testdata/./root-ca.crt", Category: "certificate", Owner: "pki"},
+			{
+				Alias: "filtered-root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate",
+				Filter: &FilterJSON{ExcludeExpired: true},
+			},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "stdout://"},
+			{CatalogAliases: []string{"root.crt"}, URI: "stdout://"},
+			{CatalogAliases: []string{"filtered-root-ca.crt"}, URI: "stdout://"},
+		},
+	}
+
+	sets, err := createCatalogSets(jsn, runConfig{}, log.New(io.Discard, "", 0))
 	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(rootResult, rootWant); diff != "" {
-		t.Error(diff)
+
+	if sets[0][0] != sets[1][0] {
+		t.Error("Expected the catalogs of the same source are shared")
 	}
+	if sets[0][0] == sets[2][0] {
+		t.Error("Expected the catalogs of the different filters are not shared")
+	}
+}
 
-	subWant, err := os.ReadFile("testdata/sub-ca.crt")
+func TestCreateCatalogSets_Stream(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+			{Alias: "sub-ca.crt", URI: "file://testdata/sub-ca.crt", Category: "certificate"},
+			{Alias: "server.crt", URI: "file://testdata/server.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "stdout://"},
+			{CatalogAliases: []string{"sub-ca.crt"}, URI: "stdout://"},
+			{CatalogAliases: []string{"sub-ca.crt"}, URI: "stdout://"},
+			{CatalogAliases: []string{"server.crt"}, URIs: []string{"file://server-1.out", "file://server-2.out"}},
+		},
+	}
+
+	cfg := runConfig{
+		ConLimit:     1,
+		FetchTimeout: time.Minute,
+		Usage:        newUsageMeter(QuotaJSON{}),
+		Log:          slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Metrics:      metricsapi.NewRegistry(),
+	}
+	sets, err := createCatalogSets(jsn, cfg, log.New(io.Discard, "", 0))
 	if err != nil {
 		t.Fatal(err)
 	}
-	subResult, err := os.ReadFile("testdata/test-sub-ca.out")
+
+	if !orderapi.Streams(sets[0][0]) {
+		t.Error("Expected the catalog of a single destination is streamed through the wrappers")
+	}
+	if orderapi.Streams(sets[1][0]) {
+		t.Error("Expected the catalog shared by the orders is not streamed")
+	}
+	if orderapi.Streams(sets[3][0]) {
+		t.Error("Expected the catalog fanned out to the destinations is not streamed")
+	}
+
+	r, err := orderapi.Open(context.TODO(), sets[0][0])
 	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(subResult, subWant); diff != "" {
-		t.Error(diff)
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
 	}
+	r.Close()
 
-	chainWant, err := os.ReadFile("testdata/chain.crt")
+	want, err := os.ReadFile("testdata/root-ca.crt")
 	if err != nil {
 		t.Fatal(err)
 	}
-	chainResult, err := os.ReadFile("testdata/test-chain.out")
+	if !bytes.Equal(buf, want) {
+		t.Errorf("Expected %q but got: %q", want, buf)
+	}
+
+	size, err := orderapi.Size(context.TODO(), sets[0][0])
 	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(chainResult, chainWant); diff != "" {
-		t.Error(diff)
+	if size != len(want) {
+		t.Errorf("Expected the streamed size %d but got: %d", len(want), size)
+	}
+
+	usage := cfg.Usage.Usage()
+	if usage.Fetches != 1 || usage.FetchedBytes != int64(len(want)) {
+		t.Errorf("Expected the streamed fetch is counted once but got: %+v", usage)
+	}
+}
+
+// testConcurrencyCatalog records the maximum number of the concurrent fetches.
+type testConcurrencyCatalog struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (c *testConcurrencyCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	c.current++
+	if c.current > c.max {
+		c.max = c.current
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	c.current--
+	c.mu.Unlock()
+
+	return []byte("content"), nil
+}
+
+func TestLimitedCatalog_Fetch(t *testing.T) {
+	t.Parallel()
+
+	counter := &testConcurrencyCatalog{}
+	limit := make(chan struct{}, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := (&limitedCatalog{catalog: counter, limit: limit}).Fetch(context.TODO())
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counter.max > 2 {
+		t.Errorf("Expected at most 2 concurrent fetches but got: %d", counter.max)
+	}
+
+	// The fetch waiting for the limit is canceled.
+	limit <- struct{}{}
+	limit <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := (&limitedCatalog{catalog: counter, limit: limit}).Fetch(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %#v error but got: %#v", context.Canceled, err)
+	}
+}
+
+func TestSrcSchemes(t *testing.T) {
+	t.Parallel()
+
+	// The URIs of the built-in source schemes. A new scheme is added here, and
+	// is dispatched to its catalog, not to the custom schemes.
+	uris := map[string]string{
+		"file":     "file://testdata/root-ca.crt",
+		"github":   "github:///repos/yuxki/cannect/contents/examples/store/root-ca.crt",
+		"s3":       "s3://cannect/root-ca.crt?versionId=3HL4kqtJlcpXroDTDmJ.rmSpXd3dIbrHY",
+		"workload": "workload:///run/spire/sockets/agent.sock?asset=bundle",
+		"plugin":   "plugin://portal/ca/root",
+	}
+
+	for name := range srcSchemes {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			uri, ok := uris[name]
+			if !ok {
+				t.Fatalf("Expected the URI of the %s scheme", name)
+			}
+
+			jsn := CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "root-ca.crt", URI: uri, Category: "certificate"}},
+				Orders:   []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "stdout://"}},
+			}
+			err := validate(jsn)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sets, err := createCatalogSets(jsn, runConfig{}, log.New(io.Discard, "", 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sets) != 1 || len(sets[0]) != 1 {
+				t.Errorf("Expected a catalog but got: %v", sets)
+			}
+		})
 	}
 }
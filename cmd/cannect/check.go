@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+)
+
+const (
+	driftMissing = "missing"
+	driftChanged = "changed"
+	// The exit status of the -check mode when any destination drifts.
+	exitDrifted = 2
+)
+
+// drift is the destination whose contents differ from the ones to be written.
+type drift struct {
+	URI    string
+	Status string
+}
+
+// driftSet collects the drifts in the -check mode. It is safe to share the
+// driftSet among the orders.
+type driftSet struct {
+	mu     sync.Mutex
+	drifts []drift
+}
+
+func newDriftSet() *driftSet {
+	return &driftSet{}
+}
+
+func (d *driftSet) add(uriText, status string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.drifts = append(d.drifts, drift{URI: uriText, Status: status})
+}
+
+// Drifts returns the drifts sorted by the URIs.
+func (d *driftSet) Drifts() []drift {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	drifts := append([]drift(nil), d.drifts...)
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].URI < drifts[j].URI
+	})
+
+	return drifts
+}
+
+// writeDrifts writes the drifts in the table format.
+func writeDrifts(w io.Writer, drifts []drift) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "DESTINATION\tSTATUS")
+	for _, d := range drifts {
+		fmt.Fprintf(tw, "%s\t%s\n", d.URI, d.Status)
+	}
+
+	return tw.Flush()
+}
+
+// checkOrder compares the contents of the order with the destination file
+// instead of writing them. The destinations of the other schemes, and the
+// sealed files whose contents differ in each sealing, are not compared.
+type checkOrder struct {
+	uriText  string
+	path     string
+	catalogs []orderapi.Catalog
+	drifts   *driftSet
+	report   *runReport
+	l        *log.Logger
+}
+
+func newCheckOrder(
+	uriText string, oJSON OrderJSON, sources []orderapi.Catalog, cfg runConfig, l *log.Logger,
+) (*checkOrder, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &checkOrder{
+		uriText:  uriText,
+		catalogs: catalogs,
+		drifts:   cfg.Drifts,
+		report:   cfg.Report,
+		l:        l,
+	}
+
+	if schemeapi.Of(uriText) == "file" && oJSON.Seal == "" {
+		uri, err := newFSURI(uriText, cfg.Root)
+		if err != nil {
+			return nil, err
+		}
+		order.path = uri.Path()
+	}
+
+	return order, nil
+}
+
+func (c *checkOrder) Order(ctx context.Context) error {
+	var buf []byte
+	for _, catalog := range c.catalogs {
+		content, err := catalog.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, content...)
+	}
+
+	if c.path == "" {
+		c.l.Print(msgs.Sprintf(msgNotCompared, c.uriText))
+		return nil
+	}
+
+	current, err := os.ReadFile(c.path)
+	status := ""
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		status = driftMissing
+	case err != nil:
+		return err
+	case !bytes.Equal(current, buf):
+		status = driftChanged
+	}
+
+	if status != "" {
+		msg := msgs.Sprintf(msgDrifted, c.uriText, status)
+		c.l.Print(msg)
+		c.drifts.add(c.uriText, status)
+		if c.report != nil {
+			c.report.warn(msg)
+		}
+	}
+
+	return nil
+}
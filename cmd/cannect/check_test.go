@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_Check(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_Check"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	content, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		"same.crt":    content,
+		"changed.crt": []byte("-----BEGIN CERTIFICATE-----\n"),
+	}
+	for name, buf := range files {
+		err := os.WriteFile(dir+"/"+name, buf, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URIs: []string{
+					"file://" + dir + "/same.crt",
+					"file://" + dir + "/changed.crt",
+					"file://" + dir + "/missing.crt",
+					"env://ROOT_CA",
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	cfg := runConfig{EnvOut: dir + "/envout.env", ConLimit: 5, NoWrite: true, Drifts: newDriftSet(), Report: newRunReport()}
+	err = run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []drift{
+		{URI: "file://" + dir + "/changed.crt", Status: driftChanged},
+		{URI: "file://" + dir + "/missing.crt", Status: driftMissing},
+	}
+	if diff := cmp.Diff(cfg.Drifts.Drifts(), want); diff != "" {
+		t.Error(diff)
+	}
+
+	// The drifts are also the warnings of the report.
+	if warnings := cfg.Report.Warnings(); len(warnings) != len(want) {
+		t.Errorf("Expected %d warnings but got: %v", len(want), warnings)
+	}
+
+	if !strings.Contains(buf.String(), msgs.Sprintf(msgNotCompared, "env://ROOT_CA")) {
+		t.Errorf("Expected env://ROOT_CA is not compared but got: %s", buf.String())
+	}
+
+	// Nothing is written.
+	changed, err := os.ReadFile(dir + "/changed.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(changed, files["changed.crt"]) {
+		t.Errorf("Expected changed.crt is not written but got: %s", changed)
+	}
+	for _, name := range []string{"missing.crt", "envout.env"} {
+		if _, err := os.Stat(dir + "/" + name); err == nil {
+			t.Errorf("Expected %s is not written", name)
+		}
+	}
+}
+
+func TestWriteDrifts(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	err := writeDrifts(&buf, []drift{
+		{URI: "file://ca.crt", Status: driftChanged},
+		{URI: "file://etc/pki/sub-ca.crt", Status: driftMissing},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "DESTINATION                STATUS\n" +
+		"file://ca.crt              changed\n" +
+		"file://etc/pki/sub-ca.crt  missing\n"
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Error(diff)
+	}
+
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	jsonFormat = "json"
+	yamlFormat = "yaml"
+	tomlFormat = "toml"
+)
+
+var (
+	errUndefinedFormat = errors.New("undefined config format")
+	errNoConfigFile    = errors.New("no config file matches the pattern")
+)
+
+// configFormat is the format of the config files set by the -format option. The
+// format is detected by the extension of each file if it is empty.
+var configFormat string
+
+// formatOf returns the format of the config file.
+func formatOf(name string) string {
+	if configFormat != "" {
+		return configFormat
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return yamlFormat
+	case ".toml":
+		return tomlFormat
+	}
+
+	return jsonFormat
+}
+
+// decodeConfig decodes the config file into the v. The YAML and TOML files are
+// decoded by gopkg.in/yaml.v3 and github.com/BurntSushi/toml, and converted to
+// JSON, so the config is defined by the JSON tags only. The unknown fields are
+// rejected, so the typos of the field names are not ignored.
+func decodeConfig(file *os.File, v interface{}) error {
+	format := formatOf(file.Name())
+	if format == jsonFormat {
+		return decodeStrict(file, v)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	switch format {
+	case yamlFormat:
+		err = yaml.Unmarshal(data, &doc)
+	case tomlFormat:
+		var tbl map[string]interface{}
+		err = toml.Unmarshal(data, &tbl)
+		doc = tbl
+	default:
+		return fmt.Errorf("%s: %w", format, errUndefinedFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", file.Name(), err)
+	}
+
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file.Name(), err)
+	}
+
+	err = decodeStrict(bytes.NewReader(buf), v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file.Name(), err)
+	}
+
+	return nil
+}
+
+// decodeStrict decodes the JSON into the v, rejecting the unknown fields.
+func decodeStrict(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	return dec.Decode(v)
+}
+
+// listFlag is the flag of the comma-separated list that can be repeated. The
+// String function returns the values joined with commas.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// configPaths returns the paths of the comma-separated list of the paths and
+// the glob patterns. The paths matched by a pattern are sorted, and the same
+// path is returned only once.
+func configPaths(list string) ([]string, error) {
+	var paths []string
+	pathSet := make(map[string]struct{})
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		matches := []string{item}
+		if strings.ContainsAny(item, "*?[") {
+			var err error
+			matches, err = filepath.Glob(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", item, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: %w", item, errNoConfigFile)
+			}
+		}
+
+		for _, match := range matches {
+			if _, ok := pathSet[match]; ok {
+				continue
+			}
+			pathSet[match] = struct{}{}
+			paths = append(paths, match)
+		}
+	}
+
+	return paths, nil
+}
+
+// openConfigFiles opens the config files of the list, and returns them with
+// the function closing them.
+func openConfigFiles(list string) ([]*os.File, func(), error) {
+	var files []*os.File
+	closeFiles := func() {
+		for _, file := range files {
+			if err := file.Close(); err != nil {
+				log.Println(msgs.Sprintf(msgCloseFailed, err))
+			}
+		}
+	}
+
+	paths, err := configPaths(list)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, p := range paths {
+		file, err := os.Open(p)
+		if err != nil {
+			closeFiles()
+			return nil, nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, closeFiles, nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeConfig(t *testing.T) {
+	t.Parallel()
+
+	load := func(t *testing.T, name string) CAnnectJSON {
+		t.Helper()
+
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		jsn, err := unmarshal(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return jsn
+	}
+
+	want := load(t, "testdata/test_catalog_order.json")
+
+	for _, name := range []string{
+		"testdata/test_catalog_order.yaml",
+		"testdata/test_catalog_order.toml",
+	} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(load(t, name), want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestDecodeConfig_UnknownField(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestDecodeConfig_UnknownField"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	configs := map[string]string{
+		"typo.json":  `{"catalogs": [{"alias": "a", "uri": "file://a", "catagory": "certificate"}], "orders": []}`,
+		"typo.yaml":  "catalogs:\n  - alias: a\n    uri: file://a\n    catagory: certificate\norders: []\n",
+		"typo.toml":  "orders = []\n[[catalogs]]\nalias = \"a\"\nuri = \"file://a\"\ncatagory = \"certificate\"\n",
+		"valid.json": `{"$schema": "cannect.schema.json", "catalogs": [], "orders": []}`,
+	}
+
+	for name, config := range configs {
+		name, config := name, config
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := filepath.Join(dir, name)
+			err := os.WriteFile(p, []byte(config), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.Open(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			_, err = unmarshal(file)
+			if strings.HasPrefix(name, "valid") {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), `unknown field "catagory"`) {
+				t.Fatalf("Expected the unknown field error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]string{
+		"config.json": jsonFormat,
+		"config.yaml": yamlFormat,
+		"config.YML":  yamlFormat,
+		"config.toml": tomlFormat,
+		"config":      jsonFormat,
+	}
+
+	for name, want := range data {
+		if got := formatOf(name); got != want {
+			t.Errorf("%s: Expected %s but got: %s", name, want, got)
+		}
+	}
+}
+
+func TestDecodeConfig_Syntax(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestDecodeConfig_Syntax"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	description := "Root CA\nof the PKI\n"
+	want := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias: "root-ca.crt", URI: "file://root-ca.crt", Category: "certificate",
+				Range: &RangeJSON{FirstBlock: true}, Description: description,
+			},
+			{
+				Alias: "sub-ca.crt", URI: "file://sub-ca.crt", Category: "certificate",
+				Range: &RangeJSON{FirstBlock: true}, Description: description,
+			},
+		},
+		Orders: []OrderJSON{{CatalogAliases: []string{"root-ca.crt", "sub-ca.crt"}, URI: "stdout://"}},
+	}
+
+	configs := map[string]string{
+		// The anchor, the merge key, the block scalars and the flow collections.
+		"anchors.yaml": `catalogs:
+  - &root
+    alias: root-ca.crt
+    uri: file://root-ca.crt
+    category: certificate
+    range: {firstBlock: true}
+    description: |
+      Root CA
+      of the PKI
+  - <<: *root
+    alias: sub-ca.crt
+    uri: >-
+      file://sub-ca.crt
+orders:
+  - {aliases: [root-ca.crt, sub-ca.crt], uri: 'stdout://'}
+`,
+		// The inline table, the dotted keys and the multi-line strings.
+		"inline.toml": `orders = [{ aliases = ["root-ca.crt", "sub-ca.crt"], uri = "stdout://" }]
+
+[[catalogs]]
+alias = "root-ca.crt"
+uri = "file://root-ca.crt"
+category = "certificate"
+range.firstBlock = true
+description = """
+Root CA
+of the PKI
+"""
+
+[[catalogs]]
+alias = "sub-ca.crt"
+uri = "file://sub-ca.crt"
+category = "certificate"
+range = { firstBlock = true }
+description = '''
+Root CA
+of the PKI
+'''
+`,
+	}
+
+	for name, config := range configs {
+		name, config := name, config
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := filepath.Join(dir, name)
+			err := os.WriteFile(p, []byte(config), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.Open(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			got, err := unmarshal(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestCreateCannectJSON_Files(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestCreateCannectJSON_Files"
+	files := map[string]string{
+		"catalogs/root.json": `{"catalogs": [{"alias": "root-ca.crt", "uri": "file://testdata/root-ca.crt", "category": "certificate"}]}`,
+		"catalogs/sub.yaml":  "catalogs:\n  - alias: sub-ca.crt\n    uri: file://testdata/sub-ca.crt\n    category: certificate\n",
+		"server.json":        `{"catalogs": [{"alias": "server.crt", "uri": "file://testdata/server.crt", "category": "certificate"}]}`,
+		"dup.json":           `{"catalogs": [{"alias": "server.crt", "uri": "file://testdata/chain.crt", "category": "certificate"}]}`,
+		"order.json":         `{"orders": [{"aliases": ["root-ca.crt", "sub-ca.crt", "server.crt"], "uri": "file://testdata/test-server.crt.crt"}]}`,
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	data := []struct {
+		testCase string
+		catalog  []string
+		aliases  []string
+		err      error
+	}{
+		{
+			"OK:Glob And Comma",
+			[]string{dir + "/catalogs/*," + dir + "/server.json"},
+			[]string{"root-ca.crt", "sub-ca.crt", "server.crt"},
+			nil,
+		},
+		{
+			"OK:Repeated",
+			[]string{dir + "/server.json", dir + "/catalogs/*", dir + "/server.json"},
+			[]string{"server.crt", "root-ca.crt", "sub-ca.crt"},
+			nil,
+		},
+		{
+			"NG:Duplicated Alias",
+			[]string{dir + "/catalogs/*", dir + "/server.json", dir + "/dup.json"},
+			nil,
+			errAliasDuplicated,
+		},
+		{
+			"NG:No Match",
+			[]string{dir + "/catalogs/*", dir + "/none/*"},
+			nil,
+			errNoConfigFile,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var catalog listFlag
+			for _, value := range d.catalog {
+				_ = catalog.Set(value)
+			}
+
+			cntJSON, err := CreateCannectJSON(catalog.String(), dir+"/order.json", "", catalogFlg|orderFlg)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %v but got: %v", d.err, err)
+			}
+			if d.err != nil {
+				return
+			}
+
+			var aliases []string
+			for _, cJSON := range cntJSON.Catalogs {
+				aliases = append(aliases, cJSON.Alias)
+			}
+			if diff := cmp.Diff(aliases, d.aliases); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+)
+
+// failoverOrder orders to the destinations in sequence until one of them
+// succeeds, so the contents are delivered to the fallback destinations when
+// the primary one is not available, like a flaky network mount. The chosen
+// destination is reported to the logger.
+type failoverOrder struct {
+	uris   []string
+	orders []Order
+	l      *log.Logger
+	report *runReport
+}
+
+func newFailoverOrder(l *log.Logger) *failoverOrder {
+	return &failoverOrder{l: l}
+}
+
+// withReport makes the failoverOrder report the failovers as the warnings to
+// the runReport if it is not nil.
+func (f *failoverOrder) withReport(report *runReport) *failoverOrder {
+	f.report = report
+	return f
+}
+
+// add appends the order to the destination of the URI. The orders are tried in
+// the order they are added.
+func (f *failoverOrder) add(uriText string, order Order) *failoverOrder {
+	f.uris = append(f.uris, uriText)
+	f.orders = append(f.orders, order)
+	return f
+}
+
+func (f *failoverOrder) Order(ctx context.Context) error {
+	var err error
+	for idx, order := range f.orders {
+		if idx > 0 {
+			msg := msgs.Sprintf(msgFailedOver, f.uris[idx-1], f.uris[idx], err)
+			f.l.Print(msg)
+			if f.report != nil {
+				f.report.warn(msg)
+			}
+		}
+
+		err = order.Order(ctx)
+		if err == nil {
+			if idx > 0 {
+				f.l.Print(msgs.Sprintf(msgOrderedFallback, f.uris[idx]))
+			}
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			// No fallback is tried after the execution is canceled.
+			return err
+		}
+	}
+
+	return err
+}
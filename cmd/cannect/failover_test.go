@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_Fallbacks(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URI: "file://testdata/no-such-dir/test-root-ca.out",
+				Fallbacks: []string{
+					"file://testdata/no-such-dir/test-root-ca-2.out",
+					"file://testdata/test-fallback-root-ca.out",
+				},
+			},
+		},
+	}
+
+	err := validate(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5}
+	err = run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := os.ReadFile("testdata/test-fallback-root-ca.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(result, want); diff != "" {
+		t.Error(diff)
+	}
+
+	reported := msgs.Sprintf(msgOrderedFallback, "file://testdata/test-fallback-root-ca.out")
+	if !strings.Contains(buf.String(), reported) {
+		t.Errorf("Expected the chosen destination is reported but got: %s", buf.String())
+	}
+}
+
+type testErrOrder struct {
+	err error
+}
+
+func (t testErrOrder) Order(ctx context.Context) error {
+	return t.err
+}
+
+func TestFailoverOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	errPrimary := errors.New("primary error")
+	errSecondary := errors.New("secondary error")
+
+	var buf bytes.Buffer
+	order := newFailoverOrder(log.New(&buf, "", 0)).
+		add("test://primary", testErrOrder{errPrimary}).
+		add("test://secondary", testErrOrder{errSecondary})
+
+	err := order.Order(context.TODO())
+	if !errors.Is(err, errSecondary) {
+		t.Fatalf("Expected %#v error but got: %#v", errSecondary, err)
+	}
+
+	want := msgs.Sprintf(msgFailedOver, "test://primary", "test://secondary", errPrimary) + "\n"
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Error(diff)
+	}
+}
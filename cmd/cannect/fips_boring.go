@@ -0,0 +1,10 @@
+//go:build boringcrypto
+
+package main
+
+// Restrict the TLS configurations to the FIPS approved settings.
+import _ "crypto/tls/fipsonly"
+
+// fipsBuild is true when cannect is built with GOEXPERIMENT=boringcrypto. The
+// FIPS mode is always enabled in this build.
+const fipsBuild = true
@@ -0,0 +1,6 @@
+//go:build !boringcrypto
+
+package main
+
+// fipsBuild is true when cannect is built with GOEXPERIMENT=boringcrypto.
+const fipsBuild = false
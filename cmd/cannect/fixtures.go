@@ -0,0 +1,234 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yuxki/cannect/internal/pkcs7"
+	"github.com/yuxki/cannect/internal/testca"
+	"github.com/yuxki/cannect/pkg/asset"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+const (
+	// fixturePassphrase is the passphrase of the encrypted private keys of the
+	// fixtures.
+	fixturePassphrase = "cannect"
+	// fixtureConfig is the name of the config using the fixtures.
+	fixtureConfig = "cannect.json"
+)
+
+// The roles of the certificates in the hierarchy of the fixtures.
+const (
+	rootRole         = "root"
+	intermediateRole = "intermediate"
+	leafRole         = "leaf"
+)
+
+var (
+	intermediateReg    = regexp.MustCompile(`(?i)(sub|inter|ica|issuing)`)
+	invalidFixtureChar = regexp.MustCompile(`[^-_a-zA-Z0-9./]`)
+)
+
+// fixtureRole returns the role of the catalog in the hierarchy, from its
+// alias, like "root-ca.crt" and "sub-ca.crt". The others are the leaf.
+func fixtureRole(alias string) string {
+	switch {
+	case strings.Contains(strings.ToLower(alias), "root"):
+		return rootRole
+	case intermediateReg.MatchString(alias):
+		return intermediateRole
+	default:
+		return leafRole
+	}
+}
+
+// fixturePath returns the path of the fixture of the catalog. The file scheme
+// catalog is at its path, and the other is at the path of its scheme and its
+// URI, like "s3/bucket/key".
+func fixturePath(cJSON CatalogJSON) (string, error) {
+	scheme := schemeapi.Of(cJSON.URI)
+	if scheme == "file" {
+		uri, err := uriapi.NewFSURI(cJSON.URI)
+		if err != nil {
+			return "", err
+		}
+		return strings.Replace(uri.Path(), ":", "", 1), nil
+	}
+
+	rest := strings.TrimPrefix(cJSON.URI, scheme+"://")
+	var segments []string
+	for _, segment := range strings.Split(invalidFixtureChar.ReplaceAllString(rest, "_"), "/") {
+		if segment != "" && segment != "." && segment != ".." {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		segments = []string{invalidFixtureChar.ReplaceAllString(cJSON.Alias, "_")}
+	}
+
+	return path.Join(append([]string{scheme}, segments...)...), nil
+}
+
+// fixtureAsset returns the dummy asset of the category for the role. The
+// public keys and the PKCS #7 bundles are the ones of the role, the private
+// keys are the one of the leaf, and the CRL of the leaf is issued by the
+// intermediate CA. The bundle is the certificate of the role.
+func fixtureAsset(ca *testca.CA, category, role string) ([]byte, error) {
+	cert := map[string]*testca.Cert{rootRole: ca.Root, intermediateRole: ca.Intermediate, leafRole: ca.Leaf}[role]
+
+	switch category {
+	case asset.CertCategory, asset.BundleCategory:
+		return cert.CertPEM(), nil
+	case asset.PrivKeyCategory:
+		return ca.Leaf.KeyPEM()
+	case asset.EncPrivKeyCategory:
+		return ca.Leaf.EncryptedKeyPEM([]byte(fixturePassphrase))
+	case asset.CRLCategory:
+		if role == leafRole {
+			cert = ca.Intermediate
+		}
+		return cert.CRLPEM()
+	case asset.PubKeyCategory:
+		return cert.PublicKeyPEM()
+	case asset.SSHPubKeyCategory:
+		return cert.SSHPublicKey(), nil
+	case asset.SSHPrivKeyCategory:
+		return ca.Leaf.SSHPrivateKeyPEM()
+	case asset.PKCS7Category:
+		der, err := pkcs7.Marshal(pkcs7.Bundle{Certificates: []*x509.Certificate{cert.Cert}})
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: der}), nil
+	case asset.DERCertCategory:
+		return cert.Cert.Raw, nil
+	case asset.DERCRLCategory:
+		if role == leafRole {
+			cert = ca.Intermediate
+		}
+		crlPEM, err := cert.CRLPEM()
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(crlPEM)
+		return block.Bytes, nil
+	default:
+		return nil, fmt.Errorf("%s: %w", category, errUndefinedCategory)
+	}
+}
+
+// genFixtures writes the dummy assets of the catalogs in the directory, and
+// returns the config whose catalogs are the fixtures. The config is run with
+// the directory as the -root option.
+func genFixtures(cntJSON CAnnectJSON, dir string, now time.Time) (CAnnectJSON, error) {
+	ca, err := testca.New(now)
+	if err != nil {
+		return cntJSON, err
+	}
+
+	fixJSON := cntJSON
+	fixJSON.Catalogs = make([]CatalogJSON, 0, len(cntJSON.Catalogs))
+	for _, cJSON := range cntJSON.Catalogs {
+		p, err := fixturePath(cJSON)
+		if err != nil {
+			return cntJSON, err
+		}
+
+		var buf []byte
+		for _, category := range cJSON.categories() {
+			part, err := fixtureAsset(ca, category, fixtureRole(cJSON.Alias))
+			if err != nil {
+				return cntJSON, err
+			}
+			buf = append(buf, part...)
+		}
+
+		name := path.Join(dir, p)
+		err = os.MkdirAll(path.Dir(name), 0o755)
+		if err != nil {
+			return cntJSON, err
+		}
+		err = os.WriteFile(name, buf, 0o600)
+		if err != nil {
+			return cntJSON, err
+		}
+
+		// The options of the remote sources are not allowed in the file scheme.
+		cJSON.URI = "file://" + p
+		cJSON.Retry = nil
+		fixJSON.Catalogs = append(fixJSON.Catalogs, cJSON)
+	}
+
+	return fixJSON, nil
+}
+
+func genFixturesMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("gen-fixtures", flag.ExitOnError)
+	catalog := new(listFlag)
+	fs.Var(catalog, "catalog", msgs.Sprintf(msgFlagCatalog))
+	order := new(listFlag)
+	fs.Var(order, "order", msgs.Sprintf(msgFlagOrder))
+	catalogOrder := new(listFlag)
+	fs.Var(catalogOrder, "catalog-order", msgs.Sprintf(msgFlagCatalogOrder))
+	out := fs.String("out", "", msgs.Sprintf(msgFlagOut))
+	fs.StringVar(&configFormat, "format", "", msgs.Sprintf(msgFlagFormat))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	fs.Usage = func() { log.Println(msgs.Sprintf(msgGenFixturesUsage)) }
+	_ = fs.Parse(args)
+
+	flgs, ok := checkExclusive(catalog.String(), order.String(), catalogOrder.String())
+	if !ok {
+		log.Println(msgs.Sprintf(msgGenFixturesUsage))
+		return 1
+	}
+
+	cntJSON, err := CreateCannectJSON(catalog.String(), order.String(), catalogOrder.String(), flgs)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	dir := *out
+	if dir == "" {
+		dir, err = os.MkdirTemp("", "cannect-fixtures-")
+	} else {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	fixJSON, err := genFixtures(cntJSON, dir, time.Now())
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	buf, err := json.MarshalIndent(fixJSON, "", "  ")
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	err = os.WriteFile(path.Join(dir, fixtureConfig), append(buf, '\n'), 0o600)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	log.Println(msgs.Sprintf(msgGenerated, len(fixJSON.Catalogs), dir, path.Join(dir, fixtureConfig)))
+	return 0
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"log"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+func TestFixturePath(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		cJSON    CatalogJSON
+		// want
+		path string
+	}{
+		{"File", CatalogJSON{Alias: "root-ca.crt", URI: "file://examples/store/root-ca.crt"}, "examples/store/root-ca.crt"},
+		{"File:Absolute", CatalogJSON{Alias: "root-ca.crt", URI: "file:///etc/pki/root-ca.crt"}, "etc/pki/root-ca.crt"},
+		{
+			"GitHub",
+			CatalogJSON{Alias: "root-ca.crt", URI: "github:///repos/yuxki/cannect/contents/root-ca.crt?ref=main"},
+			"github/repos/yuxki/cannect/contents/root-ca.crt_ref_main",
+		},
+		{"S3", CatalogJSON{Alias: "sub-ca.crt", URI: "s3://bucket/../ca/sub-ca.crt"}, "s3/bucket/ca/sub-ca.crt"},
+		{"No Path", CatalogJSON{Alias: "bundle.crt", URI: "workload://"}, "workload/bundle.crt"},
+	}
+
+	for _, d := range data {
+		got, err := fixturePath(d.cJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != d.path {
+			t.Errorf("%s: Expected %s but got: %s", d.testCase, d.path, got)
+		}
+	}
+}
+
+func TestGenFixtures(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestGenFixtures")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cntJSON := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://examples/store/root-ca.crt", Category: "certificate"},
+			{
+				Alias:    "sub-ca.crt",
+				URI:      "github:///repos/yuxki/cannect/contents/examples/store/sub-ca.crt",
+				Category: "certificate",
+				Retry:    &RetryJSON{Attempts: 3},
+			},
+			{Alias: "server.crt", URI: "s3://bucket/server.crt", Category: "certificate"},
+			{Alias: "server.key", URI: "s3://bucket/server.key", Category: "privateKey"},
+			{Alias: "server.enc.key", URI: "s3://bucket/server.enc.key", Category: "encPrivateKey"},
+			{Alias: "sub-ca.crl", URI: "s3://bucket/sub-ca.crl", Category: "CRL"},
+			{Alias: "server.pem", URI: "s3://bucket/server.pem", Categories: []string{"certificate", "privateKey"}},
+			{Alias: "ca-bundle.pem", URI: "s3://bucket/ca-bundle.pem", Category: "bundle"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"server.crt", "sub-ca.crt"}, URI: "file://certs/fullchain.crt"},
+			{CatalogAliases: []string{"server.key"}, URI: "file://certs/server.key"},
+		},
+	}
+
+	fixJSON, err := genFixtures(cntJSON, dir, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The rewritten config runs with the directory as the root.
+	err = validate(fixJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(fixJSON.Orders, cntJSON.Orders); diff != "" {
+		t.Error(diff)
+	}
+	cfg := runConfig{EnvOut: path.Join(dir, "envout.env"), ConLimit: 5, NoWrite: true, Root: dir}
+	err = run(context.TODO(), fixJSON, cfg, log.New(new(bytes.Buffer), "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(p string) *pem.Block {
+		buf, err := os.ReadFile(path.Join(dir, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(buf)
+		if block == nil {
+			t.Fatalf("%s: no PEM block", p)
+		}
+		return block
+	}
+	parse := func(p string) *x509.Certificate {
+		cert, err := x509.ParseCertificate(read(p).Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	// The leaf chains to the root through the intermediate.
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	roots.AddCert(parse("examples/store/root-ca.crt"))
+	intermediate := parse("github/repos/yuxki/cannect/contents/examples/store/sub-ca.crt")
+	intermediates.AddCert(intermediate)
+	_, err = parse("s3/bucket/server.crt").Verify(x509.VerifyOptions{
+		DNSName: "localhost", Roots: roots, Intermediates: intermediates,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	crl, err := x509.ParseRevocationList(read("s3/bucket/sub-ca.crl").Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crl.CheckSignatureFrom(intermediate); err != nil {
+		t.Error(err)
+	}
+
+	// The encrypted private key is the private key.
+	encPEM, err := os.ReadFile(path.Join(dir, "s3/bucket/server.enc.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := testca.DecryptKeyPEM(encPEM, []byte(fixturePassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(der, read("s3/bucket/server.key").Bytes); diff != "" {
+		t.Error(diff)
+	}
+}
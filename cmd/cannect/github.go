@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	textOutput   = "text"
+	githubOutput = "github"
+	// The file of the step summary is set by GitHub Actions.
+	githubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
+)
+
+var errUndefinedOutput = errors.New("undefined output")
+
+// githubEscape escapes the message of the workflow command, which ends at the
+// newline.
+func githubEscape(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubEscapeProperty escapes the property of the workflow command, like the
+// title.
+func githubEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// githubAnnotation returns the workflow command of the annotation, like
+// "::error title=file%3A//ca.crt::message".
+func githubAnnotation(level, title, msg string) string {
+	if title == "" {
+		return fmt.Sprintf("::%s::%s\n", level, githubEscape(msg))
+	}
+
+	return fmt.Sprintf("::%s title=%s::%s\n", level, githubEscapeProperty(title), githubEscape(msg))
+}
+
+// writeGitHubAnnotations writes the annotations of the warnings and the
+// errors. The error of the run is annotated only if no order reports it, like
+// the error of the config.
+func writeGitHubAnnotations(w io.Writer, results []orderResult, warnings []string, runErr error) error {
+	var b strings.Builder
+	for _, warning := range warnings {
+		b.WriteString(githubAnnotation("warning", "", warning))
+	}
+
+	annotated := false
+	for _, result := range results {
+		// The orders canceled by the failure of another one are not annotated.
+		if result.Err == nil || errors.Is(result.Err, context.Canceled) {
+			continue
+		}
+		b.WriteString(githubAnnotation("error", result.URI, result.Err.Error()))
+		annotated = true
+	}
+
+	if runErr != nil && !annotated {
+		b.WriteString(githubAnnotation("error", "", runErr.Error()))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGitHubSummary writes the markdown table of the results.
+func writeGitHubSummary(w io.Writer, title string, results []orderResult, runErr error) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", title)
+
+	if runErr != nil {
+		fmt.Fprintf(&b, ":x: %s\n\n", markdownCell(runErr.Error()))
+	}
+
+	if len(results) > 0 {
+		b.WriteString("| Destination | Catalogs | Size | SHA-256 | Result |\n")
+		b.WriteString("| --- | --- | ---: | --- | --- |\n")
+		for _, result := range results {
+			size, digest := "-", "-"
+			if result.Digest != "" {
+				size, digest = fmt.Sprint(result.Size), "`"+result.Digest+"`"
+			}
+
+			status := ":white_check_mark:"
+			if result.Err != nil {
+				status = ":x: " + markdownCell(result.Err.Error())
+			}
+
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+				result.URI, markdownCell(strings.Join(result.Aliases, ", ")), size, digest, status)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes the text in the cell of the markdown table.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r", "", "\n", "<br>").Replace(s)
+}
+
+// reportGitHub writes the annotations to the writer, and appends the summary
+// to the step summary file if it is running in GitHub Actions.
+func reportGitHub(w io.Writer, title string, results []orderResult, warnings []string, runErr error) error {
+	err := writeGitHubAnnotations(w, results, warnings, runErr)
+	if err != nil {
+		return err
+	}
+
+	summary := os.Getenv(githubStepSummaryEnv)
+	if summary == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(summary, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	err = writeGitHubSummary(file, title, results, runErr)
+	if cErr := file.Close(); err == nil {
+		err = cErr
+	}
+
+	return err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		results  []orderResult
+		warnings []string
+		err      error
+		want     string
+	}{
+		{
+			"OK",
+			[]orderResult{{URI: "file://ca.crt", Digest: "ab"}},
+			nil,
+			nil,
+			"",
+		},
+		{
+			"Order Error",
+			[]orderResult{
+				{URI: "file://ca.crt", Err: errors.New("100% failed\nretry")},
+				{URI: "s3://bucket/ca.crt", Err: context.Canceled},
+			},
+			[]string{"failed over"},
+			errors.New("100% failed\nretry"),
+			"::warning::failed over\n" +
+				"::error title=file%3A//ca.crt::100%25 failed%0Aretry\n",
+		},
+		{
+			"Config Error",
+			nil,
+			nil,
+			errors.New("root-ca.crt: alias must not be duplicated"),
+			"::error::root-ca.crt: alias must not be duplicated\n",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var b strings.Builder
+			err := writeGitHubAnnotations(&b, d.results, d.warnings, d.err)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(b.String(), d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestWriteGitHubSummary(t *testing.T) {
+	t.Parallel()
+
+	results := []orderResult{
+		{URI: "env://ROOT_CA", Aliases: []string{"root-ca.crt"}},
+		{URI: "file://ca.crt", Aliases: []string{"root-ca.crt", "sub-ca.crt"}, Size: 10, Digest: "ab"},
+		{URI: "s3://bucket/ca.crt", Aliases: []string{"root-ca.crt"}, Size: 5, Digest: "cd", Err: errors.New("a|b")},
+	}
+
+	var b strings.Builder
+	err := writeGitHubSummary(&b, "cannect", results, errors.New("a|b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "### cannect\n\n" +
+		":x: a\\|b\n\n" +
+		"| Destination | Catalogs | Size | SHA-256 | Result |\n" +
+		"| --- | --- | ---: | --- | --- |\n" +
+		"| `env://ROOT_CA` | root-ca.crt | - | - | :white_check_mark: |\n" +
+		"| `file://ca.crt` | root-ca.crt, sub-ca.crt | 10 | `ab` | :white_check_mark: |\n" +
+		"| `s3://bucket/ca.crt` | root-ca.crt | 5 | `cd` | :x: a\\|b |\n\n"
+
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Error(diff)
+	}
+}
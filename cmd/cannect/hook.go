@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/yuxki/cannect"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+)
+
+const (
+	// The environment variables passed to the hook.
+	hookURIEnv     = "CANNECT_ORDER_URI"
+	hookChangedEnv = "CANNECT_CHANGED"
+)
+
+var errEmptyHook = errors.New("hook has no command")
+
+// hookOf returns the hook of the order, or the one of the config if the order
+// does not have it.
+func hookOf(cntJSON CAnnectJSON, oJSON OrderJSON) []string {
+	if len(oJSON.Hook) > 0 {
+		return oJSON.Hook
+	}
+
+	return cntJSON.Hook
+}
+
+// withDefaultHook sets the hook to the orders that do not have it, so the
+// hook of a config file applies only to the orders in the file.
+func withDefaultHook(orders []OrderJSON, hook []string) []OrderJSON {
+	if len(hook) == 0 {
+		return orders
+	}
+
+	for idx := range orders {
+		if len(orders[idx].Hook) == 0 {
+			orders[idx].Hook = hook
+		}
+	}
+
+	return orders
+}
+
+// hookOrder runs the command of the hook after the order, like reloading the
+// server using the destination. The command is run without the shell, with
+// the URI of the order and whether the contents are changed in the
+// environment variables.
+type hookOrder struct {
+	uriText  string
+	command  []string
+	order    Order
+	catalogs []orderapi.Catalog
+	// path is the file compared with the contents, to know they are changed.
+	path    string
+	digests *digestCache
+	l       *log.Logger
+}
+
+func newHookOrder(
+	uriText string, oJSON OrderJSON, command []string, sources []orderapi.Catalog, order Order, cfg runConfig,
+	l *log.Logger,
+) (*hookOrder, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	hOrder := &hookOrder{
+		uriText:  uriText,
+		command:  command,
+		order:    order,
+		catalogs: catalogs,
+		digests:  cfg.Digests,
+		l:        l,
+	}
+
+	if schemeapi.Of(uriText) == "file" && oJSON.Seal == "" {
+		uri, err := newFSURI(uriText, cfg.Root)
+		if err != nil {
+			return nil, err
+		}
+		hOrder.path = uri.Path()
+	}
+
+	return hOrder, nil
+}
+
+// changed reports whether the contents differ from the destination file, or
+// from the ones written in the previous sync of the watch mode. The other
+// destinations are always changed.
+func (h *hookOrder) changed(ctx context.Context) (bool, error) {
+	if h.path == "" && h.digests == nil {
+		return true, nil
+	}
+
+	var buf []byte
+	for _, catalog := range h.catalogs {
+		content, err := catalog.Fetch(ctx)
+		if err != nil {
+			return false, err
+		}
+		buf = append(buf, content...)
+	}
+
+	if h.path != "" {
+		current, err := os.ReadFile(h.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return !bytes.Equal(current, buf), nil
+	}
+
+	sum := sha256.Sum256(buf)
+	return h.digests.get(h.uriText) != hex.EncodeToString(sum[:]), nil
+}
+
+func (h *hookOrder) Order(ctx context.Context) error {
+	changed, err := h.changed(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.order.Order(ctx)
+	if err != nil {
+		return err
+	}
+
+	h.l.Print(msgs.Sprintf(msgRunningHook, h.uriText, strings.Join(h.command, " ")))
+
+	cmd := exec.CommandContext(ctx, h.command[0], h.command[1:]...)
+	cmd.Env = append(os.Environ(),
+		hookURIEnv+"="+h.uriText,
+		hookChangedEnv+"="+strconv.FormatBool(changed),
+	)
+	cmd.Stdout = h.l.Writer()
+	cmd.Stderr = h.l.Writer()
+
+	err = cmd.Run()
+	if err != nil {
+		return &hookError{command: strings.Join(h.command, " "), err: err}
+	}
+
+	return nil
+}
+
+// hookError is the failure of the command of the hook. It matches
+// cannect.ErrHook, so the Runner does not report it as the failure of writing
+// the destination, and unwraps to the error of the command.
+type hookError struct {
+	command string
+	err     error
+}
+
+func (e *hookError) Error() string {
+	return e.command + ": " + e.err.Error()
+}
+
+func (e *hookError) Unwrap() error {
+	return e.err
+}
+
+func (e *hookError) Is(target error) bool {
+	return target == cannect.ErrHook
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+func TestRun_Hook(t *testing.T) {
+	t.Parallel()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skip("sh is not available")
+	}
+
+	dir := "testdata/TestRun_Hook"
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	out := path.Join(dir, "root-ca.crt")
+	hookOut := path.Join(dir, "hook.out")
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + out},
+		},
+		Hook: []string{sh, "-c", `echo "$CANNECT_ORDER_URI $CANNECT_CHANGED" >> ` + hookOut},
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		err = run(context.TODO(), jsn, newRunConfig(path.Join(dir, "cannect.env"), 5, false), log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := os.ReadFile(hookOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "file://" + out + " true\n" + "file://" + out + " false\n"
+	if diff := cmp.Diff(string(got), want); diff != "" {
+		t.Error(diff)
+	}
+
+	// The order fails with the hook.
+	jsn.Orders[0].Hook = []string{sh, "-c", "exit 1"}
+	err = run(context.TODO(), jsn, newRunConfig(path.Join(dir, "cannect.env"), 5, false), log.New(io.Discard, "", 0))
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Expected the exit error but got: %#v", err)
+	}
+	if !errors.Is(err, cannect.ErrHook) || errors.Is(err, orderapi.ErrWrite) {
+		t.Errorf("Expected the hook error, not the write error, but got: %v", err)
+	}
+}
+
+func TestUnmarshalAll_Hook(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestUnmarshalAll_Hook"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	configs := []string{
+		`{"orders": [{"aliases": ["a"], "uri": "file://a"}, {"aliases": ["b"], "uri": "file://b", "hook": ["b"]}],` +
+			` "hook": ["global"]}`,
+		`{"orders": [{"aliases": ["c"], "uri": "file://c"}]}`,
+	}
+
+	var files []*os.File
+	for idx, config := range configs {
+		name := path.Join(dir, strings.Repeat("x", idx+1)+".json")
+		err := os.WriteFile(name, []byte(config), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		files = append(files, file)
+	}
+
+	jsn, err := unmarshalAll(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hooks [][]string
+	for _, oJSON := range jsn.Orders {
+		hooks = append(hooks, hookOf(jsn, oJSON))
+	}
+
+	// The hook of the config file applies only to the orders in the file.
+	want := [][]string{{"global"}, {"b"}, nil}
+	if diff := cmp.Diff(hooks, want); diff != "" {
+		t.Error(diff)
+	}
+}
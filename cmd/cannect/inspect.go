@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// inspect writes the catalogs and orders with their descriptions and owners
+// in the table format, so large shared configs remain self-documenting.
+func inspect(w io.Writer, cntJSON CAnnectJSON) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "CATALOG\tCATEGORY\tURI\tOWNER\tDESCRIPTION")
+	for _, cJSON := range cntJSON.Catalogs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			cJSON.Alias, strings.Join(cJSON.categories(), ","), cJSON.URI, orDash(cJSON.Owner), orDash(cJSON.Description),
+		)
+	}
+
+	fmt.Fprintln(tw, "")
+	fmt.Fprintln(tw, "ORDER\tALIASES\tOWNER\tDESCRIPTION")
+	for _, oJSON := range cntJSON.Orders {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			strings.Join(oJSON.uris(), ","), strings.Join(oJSON.CatalogAliases, ","), orDash(oJSON.Owner), orDash(oJSON.Description),
+		)
+	}
+
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func inspectMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	catalog := new(listFlag)
+	fs.Var(catalog, "catalog", msgs.Sprintf(msgFlagCatalog))
+	order := new(listFlag)
+	fs.Var(order, "order", msgs.Sprintf(msgFlagOrder))
+	catalogOrder := new(listFlag)
+	fs.Var(catalogOrder, "catalog-order", msgs.Sprintf(msgFlagCatalogOrder))
+	fs.StringVar(&configFormat, "format", "", msgs.Sprintf(msgFlagFormat))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	_ = fs.Parse(args)
+
+	flgs, ok := checkExclusive(catalog.String(), order.String(), catalogOrder.String())
+	if !ok {
+		log.Println(msgs.Sprintf(msgInspectUsage))
+		return 1
+	}
+
+	cntJSON, err := CreateCannectJSON(catalog.String(), order.String(), catalogOrder.String(), flgs)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	err = inspect(os.Stdout, cntJSON)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:       "root-ca.crt",
+				URI:         "file://testdata/root-ca.crt",
+				Category:    "certificate",
+				Description: "Root CA of staging",
+				Owner:       "pki-team",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URI: "file://testdata/test-root-ca.crt.crt",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := inspect(&buf, jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "CATALOG      CATEGORY     URI                          OWNER     DESCRIPTION\n" +
+		"root-ca.crt  certificate  file://testdata/root-ca.crt  pki-team  Root CA of staging\n" +
+		"\n" +
+		"ORDER                                 ALIASES      OWNER  DESCRIPTION\n" +
+		"file://testdata/test-root-ca.crt.crt  root-ca.crt  -      -\n"
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Error(diff)
+	}
+}
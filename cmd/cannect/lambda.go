@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	stateapi "github.com/yuxki/cannect/pkg/state"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+const (
+	lambdaRuntimeAPIEnv = "AWS_LAMBDA_RUNTIME_API"
+	lambdaConfigEnv     = "CANNECT_CONFIG"
+	lambdaAPIVersion    = "2018-06-01"
+	// The file system of AWS Lambda is read-only except the /tmp directory.
+	defaultLambdaEnvOut = "/tmp/cannect.env"
+)
+
+var (
+	errNoRuntimeAPI = errors.New("AWS_LAMBDA_RUNTIME_API is not set")
+	errNoConfig     = errors.New("config is not specified")
+)
+
+// lambdaEvent is the payload of the invocation. The config overrides the
+// -config option, and the other fields, like the ones of the EventBridge
+// events, are ignored.
+type lambdaEvent struct {
+	Config string `json:"config"`
+}
+
+// lambdaReport is the response of the successful invocation.
+type lambdaReport struct {
+	Config   string   `json:"config"`
+	Catalogs []string `json:"catalogs"`
+	Orders   []string `json:"orders"`
+}
+
+// lambdaError is the error response of the invocation.
+type lambdaError struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorType    string `json:"errorType"`
+}
+
+// lambdaRuntime calls the AWS Lambda runtime API to receive the invocations and
+// respond to them, so that cannect runs as the custom runtime without any
+// Lambda library.
+type lambdaRuntime struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newLambdaRuntime(api string, client *http.Client) *lambdaRuntime {
+	return &lambdaRuntime{
+		endpoint: fmt.Sprintf("http://%s/%s/runtime/invocation/", api, lambdaAPIVersion),
+		client:   client,
+	}
+}
+
+// next waits for the next invocation, and returns its request ID, deadline and
+// payload.
+func (l *lambdaRuntime) next(ctx context.Context) (string, time.Time, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.endpoint+"next", nil)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, nil, fmt.Errorf("next invocation: %s: %s", resp.Status, payload)
+	}
+
+	deadline := time.Now().Add(time.Second * defaultTimeout)
+	if ms, err := strconv.ParseInt(resp.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+		deadline = time.UnixMilli(ms)
+	}
+
+	return resp.Header.Get("Lambda-Runtime-Aws-Request-Id"), deadline, payload, nil
+}
+
+// respond posts the report of the invocation, or the error if it is not nil.
+func (l *lambdaRuntime) respond(ctx context.Context, id string, report lambdaReport, invokeErr error) error {
+	url := l.endpoint + id + "/response"
+	var v interface{} = report
+	if invokeErr != nil {
+		url = l.endpoint + id + "/error"
+		v = lambdaError{
+			ErrorMessage: invokeErr.Error(),
+			ErrorType:    fmt.Sprintf("%T", invokeErr),
+		}
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s: %s", id, resp.Status, msg)
+	}
+
+	return nil
+}
+
+// fetchLambdaConfig returns the local path of the config. The config of the s3
+// URI is downloaded to a temporary file with the extension of the key, so that
+// its format is detected, and the function removing it is returned.
+func fetchLambdaConfig(ctx context.Context, uriText string) (string, func(), error) {
+	if schemeapi.Of(uriText) != "s3" {
+		return uriText, func() {}, nil
+	}
+
+	uri, err := uriapi.NewS3URI(uriText)
+	if err != nil {
+		return "", nil, err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	output, err := s3.NewFromConfig(cfg).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(uri.Bucket()),
+		Key:    aws.String(uri.Key()),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	defer output.Body.Close()
+
+	file, err := os.CreateTemp("", "cannect-*"+path.Ext(uri.Key()))
+	if err != nil {
+		return "", nil, err
+	}
+	remove := func() { os.Remove(file.Name()) }
+
+	_, err = io.Copy(file, output.Body)
+	if cErr := file.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		remove()
+		return "", nil, err
+	}
+
+	return file.Name(), remove, nil
+}
+
+// invokeLambda runs the config of the event, or the default config if the
+// event does not specify it, and returns the report.
+func invokeLambda(ctx context.Context, payload []byte, defaultConfig string, cfg runConfig, logger *log.Logger) (lambdaReport, error) {
+	var event lambdaEvent
+	if len(bytes.TrimSpace(payload)) > 0 {
+		err := json.Unmarshal(payload, &event)
+		if err != nil {
+			return lambdaReport{}, err
+		}
+	}
+
+	report := lambdaReport{Config: event.Config}
+	if report.Config == "" {
+		report.Config = defaultConfig
+	}
+	if report.Config == "" {
+		return report, errNoConfig
+	}
+
+	file, remove, err := fetchLambdaConfig(ctx, report.Config)
+	if err != nil {
+		return report, err
+	}
+	defer remove()
+
+	cntJSON, err := CreateCannectJSON("", "", file, catalogOrderFlg)
+	if err != nil {
+		return report, err
+	}
+
+	for _, cJSON := range cntJSON.Catalogs {
+		report.Catalogs = append(report.Catalogs, cJSON.Alias)
+	}
+	for _, oJSON := range cntJSON.Orders {
+		report.Orders = append(report.Orders, oJSON.uris()...)
+	}
+
+	return report, run(ctx, cntJSON, cfg, logger)
+}
+
+// serveLambda handles the invocation one by one until the context is done.
+// The digests of the destinations are loaded from the store before each
+// invocation and saved after it, if the store is not nil, so the unchanged
+// destinations are not rewritten by the invocations in the other instances.
+func serveLambda(
+	ctx context.Context, rt *lambdaRuntime, defaultConfig string, cfg runConfig, store stateapi.Store, logger *log.Logger,
+) error {
+	for {
+		id, deadline, payload, err := rt.next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		logger.Print(msgs.Sprintf(msgInvoked, id))
+
+		iCtx, cancel := context.WithDeadline(ctx, deadline)
+		iCfg := cfg
+		if store != nil {
+			iCfg.Digests, err = loadDigests(iCtx, store)
+			if err != nil {
+				logger.Printf("%s: %v", id, err)
+				iCfg.Digests = newDigestCache()
+			}
+		}
+		report, err := invokeLambda(iCtx, payload, defaultConfig, iCfg, logger)
+		cancel()
+		if err != nil {
+			logger.Printf("%s: %v", id, err)
+		}
+		if store != nil {
+			sErr := saveDigests(store, iCfg.Digests)
+			if sErr != nil {
+				logger.Printf("%s: %v", id, sErr)
+			}
+		}
+
+		err = rt.respond(ctx, id, report, err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func lambdaMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("lambda", flag.ExitOnError)
+	cfgURI := fs.String("config", os.Getenv(lambdaConfigEnv), msgs.Sprintf(msgFlagConfig))
+	envOut := fs.String("env-out", defaultLambdaEnvOut, msgs.Sprintf(msgFlagEnvOut))
+	conLimit := fs.Int("con-limit", defaultConLimit, msgs.Sprintf(msgFlagConLimit))
+	fips := fs.Bool("fips", false, msgs.Sprintf(msgFlagFIPS))
+	stateURI := fs.String("state", "", msgs.Sprintf(msgFlagState))
+	fs.StringVar(&configFormat, "format", "", msgs.Sprintf(msgFlagFormat))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	fs.Usage = func() { log.Println(msgs.Sprintf(msgLambdaUsage)) }
+	_ = fs.Parse(args)
+
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+
+	api := os.Getenv(lambdaRuntimeAPIEnv)
+	if api == "" {
+		logger.Println(errNoRuntimeAPI)
+		return 1
+	}
+
+	cfg := newRunConfig(*envOut, *conLimit, *fips)
+
+	var store stateapi.Store
+	if *stateURI != "" {
+		var err error
+		store, err = newStateStore(*stateURI, cfg)
+		if err != nil {
+			logger.Println(err)
+			return 1
+		}
+	}
+
+	err := serveLambda(context.Background(), newLambdaRuntime(api, http.DefaultClient), *cfgURI, cfg, store, logger)
+	if err != nil {
+		logger.Println(err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServeLambda(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestServeLambda"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	config := dir + "/config.json"
+	err = os.WriteFile(config, []byte(`{
+		"catalogs": [{"alias": "root-ca.crt", "uri": "file://testdata/root-ca.crt", "category": "certificate"}],
+		"orders": [{"aliases": ["root-ca.crt"], "uri": "file://testdata/TestServeLambda/root-ca.crt"}]
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []string{
+		`{"source": "aws.events", "detail-type": "Scheduled Event"}`,
+		`{"config": "testdata/TestServeLambda/none.json"}`,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	responses := map[string]string{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/"+lambdaAPIVersion+"/runtime/invocation/")
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if path == "next" {
+			if len(responses) == len(events) {
+				// Stop serving while waiting for the next invocation.
+				cancel()
+				<-r.Context().Done()
+				return
+			}
+
+			id := strconv.Itoa(len(responses))
+			w.Header().Set("Lambda-Runtime-Aws-Request-Id", id)
+			w.Header().Set("Lambda-Runtime-Deadline-Ms", strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10))
+			w.Write([]byte(events[len(responses)]))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		responses[path] = string(body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	rt := newLambdaRuntime(strings.TrimPrefix(srv.URL, "http://"), srv.Client())
+	cfg := runConfig{EnvOut: dir + "/envout.env", ConLimit: 5}
+	err = serveLambda(ctx, rt, config, cfg, nil, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report lambdaReport
+	err = json.Unmarshal([]byte(responses["0/response"]), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := lambdaReport{
+		Config:   config,
+		Catalogs: []string{"root-ca.crt"},
+		Orders:   []string{"file://testdata/TestServeLambda/root-ca.crt"},
+	}
+	if diff := cmp.Diff(report, want); diff != "" {
+		t.Error(diff)
+	}
+
+	_, err = os.Stat(dir + "/root-ca.crt")
+	if err != nil {
+		t.Errorf("Expected the order is written but got: %v", err)
+	}
+
+	var lErr lambdaError
+	err = json.Unmarshal([]byte(responses["1/error"]), &lErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lErr.ErrorMessage, "none.json") {
+		t.Errorf("Expected the error of the config in the event but got: %s", lErr.ErrorMessage)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// plainLogFormat is the free-form lines of the log package.
+	plainLogFormat = "plain"
+	// textLogFormat and jsonLogFormat are written by the TextHandler and the
+	// JSONHandler of slog.
+	textLogFormat = "text"
+	jsonLogFormat = "json"
+
+	defaultLogFormat = plainLogFormat
+	defaultLogLevel  = "info"
+)
+
+var (
+	errUndefinedLogFormat = errors.New("undefined log format")
+	errUndefinedLogLevel  = errors.New("undefined log level")
+)
+
+// newStructuredLog returns the logger of the text or the json format of the
+// -log-format option, writing the records of the -log-level option and above
+// to the w. It returns nil for the plain format.
+func newStructuredLog(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	err := lvl.UnmarshalText([]byte(level))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", level, errUndefinedLogLevel)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case plainLogFormat:
+		return nil, nil
+	case textLogFormat:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case jsonLogFormat:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", format, errUndefinedLogFormat)
+}
+
+// newLogger returns the logger of the -log-format and -log-level options,
+// writing to the w. Unless the format is plain, the structured log is set to
+// the cfg, and the lines of the logger are written as the info records.
+func newLogger(w io.Writer, format, level string, cfg *runConfig) (*log.Logger, error) {
+	l, err := newStructuredLog(w, format, level)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return log.New(w, "", log.LstdFlags), nil
+	}
+
+	cfg.Log = l
+	return log.New(levelWriter{l: l, level: slog.LevelInfo}, "", 0), nil
+}
+
+// levelWriter writes each line as the record of the level, so the free-form
+// lines of the log package are also in the format.
+type levelWriter struct {
+	l     *slog.Logger
+	level slog.Level
+}
+
+func (w levelWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		w.l.Log(context.Background(), w.level, line)
+	}
+
+	return len(p), nil
+}
+
+// plainHandler writes the records of the fetches and the orders of the
+// catalog and the order packages as the free-form lines of the plain format.
+// The other records are dropped, since the plain format has no fields.
+type plainHandler struct {
+	l *log.Logger
+}
+
+// plainLog returns the logger of the packages, which is the structured log if
+// it is set, or otherwise writes the plain lines to the l.
+func plainLog(l *log.Logger, structured *slog.Logger) *slog.Logger {
+	if structured != nil {
+		return structured
+	}
+
+	return slog.New(plainHandler{l: l})
+}
+
+func (h plainHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (h plainHandler) Handle(_ context.Context, r slog.Record) error {
+	var uriText string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "uri" {
+			uriText = a.Value.String()
+			return false
+		}
+		return true
+	})
+
+	switch r.Message {
+	case "fetching":
+		h.l.Print(msgs.Sprintf(msgFetching, uriText))
+	case "ordering":
+		h.l.Print(msgs.Sprintf(msgOrdering, uriText))
+	}
+
+	return nil
+}
+
+func (h plainHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h plainHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// loggedCatalog logs the duration and the size of the fetch.
+type loggedCatalog struct {
+	catalog orderapi.Catalog
+	alias   string
+	uriText string
+	l       *slog.Logger
+}
+
+func newLoggedCatalog(catalog orderapi.Catalog, cJSON CatalogJSON, l *slog.Logger) *loggedCatalog {
+	return &loggedCatalog{
+		catalog: catalog,
+		alias:   cJSON.Alias,
+		uriText: cJSON.URI,
+		l:       l,
+	}
+}
+
+func (c *loggedCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	start := time.Now()
+	buf, err := c.catalog.Fetch(ctx)
+	attrs := []slog.Attr{
+		slog.String("alias", c.alias),
+		slog.String("scheme", schemeapi.Of(c.uriText)),
+		slog.String("uri", c.uriText),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		c.l.LogAttrs(ctx, slog.LevelError, "fetch failed", append(attrs, slog.Any("error", err))...)
+		return nil, err
+	}
+
+	c.l.LogAttrs(ctx, slog.LevelDebug, "fetched", append(attrs, slog.Int("bytes", len(buf)))...)
+	return buf, nil
+}
+
+// Open logs the duration and the size when the content is read to the end.
+func (c *loggedCatalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	start := time.Now()
+	attrs := func() []slog.Attr {
+		return []slog.Attr{
+			slog.String("alias", c.alias),
+			slog.String("scheme", schemeapi.Of(c.uriText)),
+			slog.String("uri", c.uriText),
+			slog.Duration("duration", time.Since(start)),
+		}
+	}
+
+	r, err := orderapi.Open(ctx, c.catalog)
+	if err != nil {
+		c.l.LogAttrs(ctx, slog.LevelError, "fetch failed", append(attrs(), slog.Any("error", err))...)
+		return nil, err
+	}
+
+	return orderapi.OnReadEnd(r, func(n int, err error) error {
+		if err != nil {
+			c.l.LogAttrs(ctx, slog.LevelError, "fetch failed", append(attrs(), slog.Any("error", err))...)
+			return err
+		}
+
+		c.l.LogAttrs(ctx, slog.LevelDebug, "fetched", append(attrs(), slog.Int("bytes", n))...)
+		return nil
+	}), nil
+}
+
+func (c *loggedCatalog) Streams() bool {
+	return orderapi.Streams(c.catalog)
+}
+
+// loggedOrder logs the duration of the order, and the size of the contents.
+type loggedOrder struct {
+	uriText  string
+	aliases  []string
+	order    Order
+	catalogs []orderapi.Catalog
+	l        *slog.Logger
+}
+
+func newLoggedOrder(
+	uriText string, oJSON OrderJSON, sources []orderapi.Catalog, order Order, l *slog.Logger,
+) (*loggedOrder, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	lOrder := &loggedOrder{
+		uriText:  uriText,
+		aliases:  oJSON.CatalogAliases,
+		order:    order,
+		catalogs: catalogs,
+		l:        l,
+	}
+
+	return lOrder, nil
+}
+
+func (o *loggedOrder) Order(ctx context.Context) error {
+	start := time.Now()
+	err := o.order.Order(ctx)
+	attrs := []slog.Attr{
+		slog.String("aliases", strings.Join(o.aliases, ",")),
+		slog.String("scheme", schemeapi.Of(o.uriText)),
+		slog.String("uri", o.uriText),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		o.l.LogAttrs(ctx, slog.LevelError, "order failed", append(attrs, slog.Any("error", err))...)
+		return err
+	}
+
+	// The catalogs are shared, so the contents are not fetched again.
+	size := 0
+	for _, catalog := range o.catalogs {
+		n, err := orderapi.Size(ctx, catalog)
+		if err != nil {
+			return err
+		}
+		size += n
+	}
+
+	o.l.LogAttrs(ctx, slog.LevelDebug, "ordered", append(attrs, slog.Int("bytes", size))...)
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStructuredLog(t *testing.T) {
+	t.Parallel()
+
+	timeAttr := regexp.MustCompile(`^time=\S+ |"time":"[^"]+",`)
+
+	data := []struct {
+		testCase string
+		format   string
+		level    string
+		want     string
+	}{
+		{
+			"OK:text",
+			textLogFormat,
+			"info",
+			`level=INFO msg=fetching alias="root ca" bytes=10 duration=1.5ms` + "\n",
+		},
+		{
+			"OK:json",
+			jsonLogFormat,
+			"info",
+			`{"level":"INFO","msg":"fetching","alias":"root ca","bytes":10,"duration":1500000}` + "\n",
+		},
+		{"OK:level", textLogFormat, "warn", ""},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			l, err := newStructuredLog(&buf, d.format, d.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.Info("fetching", "alias", "root ca", "bytes", 10, "duration", 1500*time.Microsecond)
+			if diff := cmp.Diff(timeAttr.ReplaceAllString(buf.String(), ""), d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestPlainHandler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := plainLog(log.New(&buf, "", 0), nil).With("aliases", "root-ca.crt")
+
+	l.Info("fetching", "alias", "root-ca.crt", "scheme", "file", "uri", "file://root-ca.crt")
+	l.Debug("fetched", "uri", "file://root-ca.crt")
+	l.Info("ordering", "scheme", "stdout", "uri", "stdout://")
+
+	want := msgs.Sprintf(msgFetching, "file://root-ca.crt") + "\n" + msgs.Sprintf(msgOrdering, "stdout://") + "\n"
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		format   string
+		level    string
+		err      error
+	}{
+		{"OK:plain", plainLogFormat, "info", nil},
+		{"OK:json", jsonLogFormat, "debug", nil},
+		{"NG:format", "xml", "info", errUndefinedLogFormat},
+		{"NG:level", textLogFormat, "trace", errUndefinedLogLevel},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := newRunConfig(defaultEnvOut, 5, false)
+			_, err := newLogger(io.Discard, d.format, d.level, &cfg)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if err == nil && (cfg.Log != nil) != (d.format != plainLogFormat) {
+				t.Errorf("Unexpected structured log of %s format: %v", d.format, cfg.Log)
+			}
+		})
+	}
+}
+
+func TestRun_StructuredLog(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_StructuredLog"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "stdout://"},
+		},
+	}
+
+	var buf bytes.Buffer
+	cfg := newRunConfig(path.Join(dir, "cannect.env"), 5, false)
+	cfg.Stdout = io.Discard
+	logger, err := newLogger(&buf, jsonLogFormat, "debug", &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = run(context.TODO(), jsn, cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := make(map[string]map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		err := json.Unmarshal([]byte(line), &record)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		records[record["msg"].(string)] = record
+	}
+
+	for _, msg := range []string{"fetching", "fetched", "ordering", "ordered"} {
+		record, ok := records[msg]
+		if !ok {
+			t.Fatalf("Expected %s record but got: %s", msg, buf.String())
+		}
+		if _, ok := record["uri"]; !ok {
+			t.Errorf("Expected uri field in %s record", msg)
+		}
+	}
+
+	fetched := records["fetched"]
+	if diff := cmp.Diff(
+		[]interface{}{fetched["level"], fetched["alias"], fetched["scheme"]},
+		[]interface{}{"DEBUG", "root-ca.crt", "file"},
+	); diff != "" {
+		t.Error(diff)
+	}
+	if fetched["bytes"] != records["ordered"]["bytes"] || fetched["bytes"] == float64(0) {
+		t.Errorf("Expected the same bytes but got: %v and %v", fetched["bytes"], records["ordered"]["bytes"])
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	errMergeNotAllowed = errors.New("merge is supported only for the single uri without fallbacks")
+	errMergeConflicted = errors.New("merged orders must have the same settings except aliases")
+)
+
+// mergeOrders merges the orders with the merge to the same destination into
+// the first of them, so that a shared base bundle is composed with the
+// additions of each service. The catalogs are concatenated in the declared
+// order, and the alias already in the merged order is not added again.
+func mergeOrders(oJSONs []OrderJSON) ([]OrderJSON, error) {
+	merged := make([]OrderJSON, 0, len(oJSONs))
+	bases := make(map[string]int)
+
+	for _, oJSON := range oJSONs {
+		if !oJSON.Merge {
+			merged = append(merged, oJSON)
+			continue
+		}
+
+		idx, ok := bases[oJSON.URI]
+		if !ok {
+			bases[oJSON.URI] = len(merged)
+			oJSON.CatalogAliases = appendAliases(nil, oJSON.CatalogAliases)
+			merged = append(merged, oJSON)
+			continue
+		}
+
+		if !sameSettings(merged[idx], oJSON) {
+			// Check the merged orders are the same except aliases
+			return nil, fmt.Errorf("%s: %w", oJSON.URI, errMergeConflicted)
+		}
+		merged[idx].CatalogAliases = appendAliases(merged[idx].CatalogAliases, oJSON.CatalogAliases)
+	}
+
+	return merged, nil
+}
+
+// appendAliases appends the aliases not in the dst.
+func appendAliases(dst, aliases []string) []string {
+	for _, alias := range aliases {
+		found := false
+		for _, a := range dst {
+			found = found || a == alias
+		}
+		if !found {
+			dst = append(dst, alias)
+		}
+	}
+
+	return dst
+}
+
+// sameSettings reports whether the orders are the same except the aliases,
+// the description and the owner.
+func sameSettings(a, b OrderJSON) bool {
+	a.CatalogAliases, a.Description, a.Owner = nil, "", ""
+	b.CatalogAliases, b.Description, b.Owner = nil, "", ""
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeOrders(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		orders   []OrderJSON
+		// want
+		want []OrderJSON
+		err  error
+	}{
+		{
+			"OK:Merged",
+			[]OrderJSON{
+				{CatalogAliases: []string{"root-ca.crt", "sub-ca.crt"}, URI: "file://chain.crt", Merge: true},
+				{CatalogAliases: []string{"root-ca.crt"}, URI: "file://root-ca.crt"},
+				{CatalogAliases: []string{"sub-ca.crt", "server.crt"}, URI: "file://chain.crt", Merge: true, Owner: "web"},
+			},
+			[]OrderJSON{
+				{CatalogAliases: []string{"root-ca.crt", "sub-ca.crt", "server.crt"}, URI: "file://chain.crt", Merge: true},
+				{CatalogAliases: []string{"root-ca.crt"}, URI: "file://root-ca.crt"},
+			},
+			nil,
+		},
+		{
+			"NG:Conflicted",
+			[]OrderJSON{
+				{CatalogAliases: []string{"root-ca.crt"}, URI: "file://chain.crt", Merge: true},
+				{CatalogAliases: []string{"sub-ca.crt"}, URI: "file://chain.crt", Merge: true, Timeout: 10},
+			},
+			nil,
+			errMergeConflicted,
+		},
+	}
+
+	for _, d := range data {
+		got, err := mergeOrders(d.orders)
+		if !errors.Is(err, d.err) {
+			t.Fatalf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+		if diff := cmp.Diff(d.want, got); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+}
+
+func TestValidate_Merge(t *testing.T) {
+	t.Parallel()
+
+	catalogs := []CatalogJSON{
+		{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+		{Alias: "sub-ca.crt", URI: "file://testdata/sub-ca.crt", Category: "certificate"},
+	}
+
+	data := []struct {
+		testCase string
+		orders   []OrderJSON
+		// want
+		err error
+	}{
+		{
+			"OK:Merged",
+			[]OrderJSON{
+				{CatalogAliases: []string{"root-ca.crt"}, URI: "file://chain.out", Merge: true},
+				{CatalogAliases: []string{"sub-ca.crt"}, URI: "file://chain.out", Merge: true},
+			},
+			nil,
+		},
+		{
+			"NG:Not Merged",
+			[]OrderJSON{
+				{CatalogAliases: []string{"root-ca.crt"}, URI: "file://chain.out", Merge: true},
+				{CatalogAliases: []string{"sub-ca.crt"}, URI: "file://chain.out"},
+			},
+			errOrderURIDuplicated,
+		},
+		{
+			"NG:URIs",
+			[]OrderJSON{
+				{CatalogAliases: []string{"root-ca.crt"}, URIs: []string{"file://chain.out"}, Merge: true},
+			},
+			errMergeNotAllowed,
+		},
+		{
+			"NG:Fallbacks",
+			[]OrderJSON{
+				{
+					CatalogAliases: []string{"root-ca.crt"}, URI: "file://chain.out",
+					Fallbacks: []string{"file://fallback.out"}, Merge: true,
+				},
+			},
+			errMergeNotAllowed,
+		},
+		{
+			"NG:Conflicted",
+			[]OrderJSON{
+				{CatalogAliases: []string{"root-ca.crt"}, URI: "file://chain.out", Merge: true},
+				{CatalogAliases: []string{"sub-ca.crt"}, URI: "file://chain.out", Merge: true, Mirror: "chain"},
+			},
+			errMergeConflicted,
+		},
+	}
+
+	for _, d := range data {
+		err := validate(CAnnectJSON{Catalogs: catalogs, Orders: d.orders})
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+	}
+}
+
+func TestRun_Merge(t *testing.T) {
+	t.Parallel()
+
+	out := "testdata/test-merge-chain.out"
+	t.Cleanup(func() { os.Remove(out) })
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+			{Alias: "sub-ca.crt", URI: "file://testdata/sub-ca.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"sub-ca.crt"}, URI: "file://" + out, Merge: true},
+			{CatalogAliases: []string{"sub-ca.crt", "root-ca.crt"}, URI: "file://" + out, Merge: true},
+		},
+	}
+	err := validate(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5}
+	err = run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []byte
+	for _, path := range []string{"testdata/sub-ca.crt", "testdata/root-ca.crt"} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, b...)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,690 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+)
+
+const (
+	langEN = "en"
+	langJA = "ja"
+)
+
+// message is a key of the user-facing CLI messages.
+type message int
+
+const (
+	msgUsage message = iota
+	msgInspectUsage
+	msgOperatorUsage
+	msgReconciling
+	msgLambdaUsage
+	msgInvoked
+	msgValidateUsage
+	msgValid
+	msgWatchUsage
+	msgSchemaUsage
+	msgGenFixturesUsage
+	msgGenerated
+	msgSelftestUsage
+	msgSelftestPassed
+	msgProbeUsage
+	msgUnchanged
+	msgModified
+	msgTenantExited
+	msgUsed
+	msgCheckWarned
+	msgFetching
+	msgOrdering
+	msgFailedOver
+	msgOrderedFallback
+	msgNotWritten
+	msgNotCompared
+	msgDrifted
+	msgSkippedMirror
+	msgThrottled
+	msgRunningHook
+	msgCloseFailed
+	msgFlagCatalog
+	msgFlagOrder
+	msgFlagCatalogOrder
+	msgFlagEnvOut
+	msgFlagEnvFormat
+	msgFlagEnvBase64
+	msgFlagNoWrite
+	msgFlagDryRun
+	msgFlagCheck
+	msgFlagPolicy
+	msgFlagFormat
+	msgFlagConLimit
+	msgFlagTimeout
+	msgFlagConfigTimeout
+	msgFlagFetchTimeout
+	msgFlagFIPS
+	msgFlagRoot
+	msgFlagFSRoot
+	msgFlagFSStrict
+	msgFlagLogFormat
+	msgFlagLogLevel
+	msgFlagLang
+	msgFlagNamespace
+	msgFlagInterval
+	msgFlagWatchInterval
+	msgFlagPoll
+	msgFlagKind
+	msgFlagConfig
+	msgFlagFetch
+	msgFlagTenants
+	msgFlagTenant
+	msgFlagOutput
+	msgFlagSummary
+	msgFlagQuota
+	msgFlagSkipUnchanged
+	msgFlagState
+	msgFlagKeepGoing
+	msgFlagOut
+	msgFlagStrict
+	msgFlagProbeOutput
+	msgFlagEndpoint
+	msgFlagResolver
+	msgFlagS3Endpoint
+	msgFlagS3Region
+	msgFlagS3PathStyle
+	msgFlagMetricsTextfile
+	msgFlagMetricsAddr
+	msgCustomSchemes
+	// numMessages is the number of the messages.
+	numMessages
+)
+
+// messageCatalog holds the CLI messages for each language. Every language
+// must have all messages.
+var messageCatalog = map[string]map[message]string{
+	langEN: {
+		msgUsage: `
+Usage: cannect [inspect|validate|watch|schema|gen-fixtures|selftest|probe|operator|lambda] <OPTIONS>
+  COMMANDS
+    inspect Print the catalogs and orders with their descriptions and owners.
+    validate Check the config without writing anything. See "cannect validate -h".
+    watch Keep running, and rewrite the destinations when the contents are changed. See "cannect watch -h".
+    schema Print the JSON Schema of the config files. See "cannect schema -h".
+    gen-fixtures Generate a test CA hierarchy and the dummy assets of the catalogs. See "cannect gen-fixtures -h".
+    selftest Fetch, check and order the assets of a built-in test CA, and verify the destinations. See "cannect selftest -h".
+    probe Check the sources of the catalogs are available without downloading them. See "cannect probe -h".
+    operator Reconcile the Catalog and Order resources in Kubernetes. See "cannect operator -h".
+    lambda Run the config on each invocation as the AWS Lambda custom runtime. See "cannect lambda -h".
+  OPTIONS
+    -catalog <file-path> The path of catalog file. (required: Exclusive to -catalog-order)
+    -order <file-path> The path of order file. (required: Exclusive to -catalog-order)
+    -catalog-order <file-path> The path of file contains both orders and catalogs. (required: Exclusive to -catalog and -order)
+    -env-out <file-path> The path of env scheme output. (default: ./cannect.env)
+    -env-format <format> The format of env scheme output. "export", "dotenv", "json", "yaml" or "powershell". (default: export)
+    -env-base64 Encode the values of env scheme output in base64. (default: false)
+    -con-limit <number> The limit of concurrency. (default: 5)
+    -endpoint <host>=<address> The address dialed instead of the host of the APIs of the github and s3 scheme catalogs. Repeatable. (default: no override)
+    -resolver <address> The address of the DNS server resolving the hosts of the APIs of the github and s3 scheme catalogs. Repeatable. (default: system resolver)
+    -s3-endpoint <url> The URL of the S3-compatible API of the s3 scheme catalogs and destinations without the endpoint. (default: AWS)
+    -s3-region <region> The region of the s3 scheme catalogs and destinations without the region. (default: AWS config)
+    -s3-path-style Address the buckets of the s3 scheme in the path. (default: false)
+    -timeout <number> The number of seconds for timeout of the execution. (default: 30)
+    -config-timeout <number> The number of seconds for timeout of loading the config files. (default: 10)
+    -fetch-timeout <number> The number of seconds for timeout of each fetch of the catalogs without timeout. No limit if 0. (default: 0)
+    -format <format> The format of the config files. "json", "yaml" or "toml". (default: detected by the extension)
+    -fips Allow only FIPS approved algorithms in CA assets. (default: false)
+    -root <directory> The directory the paths of file scheme catalogs and orders are joined to, like tar -C. (default: current directory)
+    -fs-root <directory> The directory the files of file scheme catalogs must be in, after resolving the symlinks. (default: no restriction)
+    -fs-strict Reject the special files and the paths differing in case in file scheme catalogs. (default: false)
+    -log-format <format> The format of the logs. "plain", "text" or "json" for the structured logs of log/slog. (default: plain)
+    -log-level <level> The level of the structured logs. "debug", "info", "warn" or "error". (default: info)
+    -keep-going Let the other orders complete when an order fails, and report all failures at the end with the exit status 1. (default: false)
+    -no-write Fetch and check the catalogs, but never write to the destinations. (default: false)
+    -dry-run Fetch and check the catalogs, and print the sizes and checksums of the contents to be written to the destinations without writing. (default: false)
+    -check Compare the contents with the destination files without writing, and exit with 2 if any of them differs. (default: false)
+    -policy <file-path> The path of policy file contains the allowed URIs. (default: no restriction)
+    -output <mode> The output mode. "text" or "github" for the annotations and the step summary of GitHub Actions. (default: text)
+    -summary <file-path> The path of the summary of the run in JSON, with the changes detected against the previous one. It is compressed with gzip if it ends with .gz. (default: not written)
+    -quota <quota> The limits of the fetches, the fetched bytes and the writes of each run, like "fetches=100,bytes=1048576,writes=50". (default: no limit)
+    -skip-unchanged Skip writing the destinations of "file" and "s3" schemes that have the contents already, and their hooks. (default: false)
+    -state <file-path or uri> The path or the "s3" or "dynamodb" URI of the state keeping the digests of the written destinations, to skip rewriting the unchanged ones in the next runs. (default: not kept)
+    -metrics-textfile <file-path> The path of the metrics in the text format of Prometheus, written after each run. (default: not written)
+    -strict Exit with the status 3 if any check in the warn of the catalogs warns. (default: false)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgInspectUsage: `
+Usage: cannect inspect <OPTIONS>
+  OPTIONS
+    -catalog <file-path> The path of catalog file. (required: Exclusive to -catalog-order)
+    -order <file-path> The path of order file. (required: Exclusive to -catalog-order)
+    -catalog-order <file-path> The path of file contains both orders and catalogs. (required: Exclusive to -catalog and -order)
+    -format <format> The format of the config files. "json", "yaml" or "toml". (default: detected by the extension)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgOperatorUsage: `
+Usage: cannect operator <OPTIONS>
+  OPTIONS
+    -namespace <namespace> The namespace to reconcile. (default: all namespaces)
+    -interval <number> The number of seconds between the reconciliations. (default: 60)
+    -con-limit <number> The limit of concurrency. (default: 5)
+    -timeout <number> The number of seconds for timeout of each reconciliation. (default: 30)
+    -fips Allow only FIPS approved algorithms in CA assets. (default: false)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgLambdaUsage: `
+Usage: cannect lambda <OPTIONS>
+  OPTIONS
+    -config <file-path or s3-uri> The path or s3 URI of file contains both orders and catalogs. The "config" of the event overrides it. (default: $CANNECT_CONFIG)
+    -env-out <file-path> The path of env scheme output. (default: /tmp/cannect.env)
+    -con-limit <number> The limit of concurrency. (default: 5)
+    -format <format> The format of the config files. "json", "yaml" or "toml". (default: detected by the extension)
+    -fips Allow only FIPS approved algorithms in CA assets. (default: false)
+    -state <file-path or uri> The path or the "s3" or "dynamodb" URI of the state keeping the digests of the written destinations, to skip rewriting the unchanged ones in the next runs. (default: not kept)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgValidateUsage: `
+Usage: cannect validate <OPTIONS>
+  OPTIONS
+    -catalog <file-path> The path of catalog file. (required: Exclusive to -catalog-order)
+    -order <file-path> The path of order file. (required: Exclusive to -catalog-order)
+    -catalog-order <file-path> The path of file contains both orders and catalogs. (required: Exclusive to -catalog and -order)
+    -policy <file-path> The path of policy file contains the allowed URIs. (default: no restriction)
+    -output <mode> The output mode. "text" or "github" for the annotations and the step summary of GitHub Actions. (default: text)
+    -strict Exit with the status 3 if any check in the warn of the catalogs warns with -fetch. (default: false)
+    -fetch Fetch and check the catalogs, but never write to the destinations. (default: false)
+    -con-limit <number> The limit of concurrency. (default: 5)
+    -endpoint <host>=<address> The address dialed instead of the host of the APIs of the github and s3 scheme catalogs. Repeatable. (default: no override)
+    -resolver <address> The address of the DNS server resolving the hosts of the APIs of the github and s3 scheme catalogs. Repeatable. (default: system resolver)
+    -s3-endpoint <url> The URL of the S3-compatible API of the s3 scheme catalogs and destinations without the endpoint. (default: AWS)
+    -s3-region <region> The region of the s3 scheme catalogs and destinations without the region. (default: AWS config)
+    -s3-path-style Address the buckets of the s3 scheme in the path. (default: false)
+    -timeout <number> The number of seconds for timeout of the fetching. (default: 30)
+    -format <format> The format of the config files. "json", "yaml" or "toml". (default: detected by the extension)
+    -fips Allow only FIPS approved algorithms in CA assets. (default: false)
+    -root <directory> The directory the paths of file scheme catalogs and orders are joined to, like tar -C. (default: current directory)
+    -fs-root <directory> The directory the files of file scheme catalogs must be in, after resolving the symlinks. (default: no restriction)
+    -fs-strict Reject the special files and the paths differing in case in file scheme catalogs. (default: false)
+    -log-format <format> The format of the logs. "plain", "text" or "json" for the structured logs of log/slog. (default: plain)
+    -log-level <level> The level of the structured logs. "debug", "info", "warn" or "error". (default: info)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgWatchUsage: `
+Usage: cannect watch <OPTIONS>
+  OPTIONS
+    -catalog <file-path> The path of catalog file. (required: Exclusive to -catalog-order)
+    -order <file-path> The path of order file. (required: Exclusive to -catalog-order)
+    -catalog-order <file-path> The path of file contains both orders and catalogs. (required: Exclusive to -catalog and -order)
+    -interval <duration> The interval of the re-syncs, like "1h" or "30m". (default: 1h)
+    -poll <duration> The interval of checking the modifications of the config files and the local catalog files. "0" disables it. (default: 5s)
+    -env-out <file-path> The path of env scheme output. (default: ./cannect.env)
+    -env-format <format> The format of env scheme output. "export", "dotenv", "json", "yaml" or "powershell". (default: export)
+    -env-base64 Encode the values of env scheme output in base64. (default: false)
+    -con-limit <number> The limit of concurrency. (default: 5)
+    -endpoint <host>=<address> The address dialed instead of the host of the APIs of the github and s3 scheme catalogs. Repeatable. (default: no override)
+    -resolver <address> The address of the DNS server resolving the hosts of the APIs of the github and s3 scheme catalogs. Repeatable. (default: system resolver)
+    -s3-endpoint <url> The URL of the S3-compatible API of the s3 scheme catalogs and destinations without the endpoint. (default: AWS)
+    -s3-region <region> The region of the s3 scheme catalogs and destinations without the region. (default: AWS config)
+    -s3-path-style Address the buckets of the s3 scheme in the path. (default: false)
+    -timeout <number> The number of seconds for timeout of each sync. (default: 30)
+    -config-timeout <number> The number of seconds for timeout of loading the config files. (default: 10)
+    -fetch-timeout <number> The number of seconds for timeout of each fetch of the catalogs without timeout. No limit if 0. (default: 0)
+    -format <format> The format of the config files. "json", "yaml" or "toml". (default: detected by the extension)
+    -fips Allow only FIPS approved algorithms in CA assets. (default: false)
+    -root <directory> The directory the paths of file scheme catalogs and orders are joined to, like tar -C. (default: current directory)
+    -fs-root <directory> The directory the files of file scheme catalogs must be in, after resolving the symlinks. (default: no restriction)
+    -fs-strict Reject the special files and the paths differing in case in file scheme catalogs. (default: false)
+    -log-format <format> The format of the logs. "plain", "text" or "json" for the structured logs of log/slog. (default: plain)
+    -log-level <level> The level of the structured logs. "debug", "info", "warn" or "error". (default: info)
+    -keep-going Let the other orders complete when an order fails, and report all failures at the end of each sync. (default: false)
+    -policy <file-path> The path of policy file contains the allowed URIs. (default: no restriction)
+    -tenants <file-path> The path of file contains the tenants. Each tenant is watched in its own process. (Exclusive to -catalog, -order and -catalog-order)
+    -tenant <name> The name of the tenant, isolated by the built-in policy. It is set by -tenants. (default: none)
+    -quota <quota> The limits of the fetches, the fetched bytes and the writes of each sync, like "fetches=100,bytes=1048576,writes=50". (default: no limit)
+    -skip-unchanged Skip writing the destinations of "file" and "s3" schemes that have the contents already, and their hooks. (default: false)
+    -state <file-path or uri> The path or the "s3" or "dynamodb" URI of the state keeping the digests of the written destinations, to skip rewriting the unchanged ones in the next runs. (default: not kept)
+    -metrics-textfile <file-path> The path of the metrics in the text format of Prometheus, written after each sync. (default: not written)
+    -metrics-addr <address> The address serving the metrics for Prometheus at "/metrics", like ":9464". (default: not served)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgSchemaUsage: `
+Usage: cannect schema <OPTIONS>
+  OPTIONS
+    -kind <kind> The kind of the config files. "catalog", "order" or "catalog-order". (default: catalog-order)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgGenFixturesUsage: `
+Usage: cannect gen-fixtures <OPTIONS>
+  OPTIONS
+    -catalog <file-path> The path of catalog file. (required: Exclusive to -catalog-order)
+    -order <file-path> The path of order file. (required: Exclusive to -catalog-order)
+    -catalog-order <file-path> The path of file contains both orders and catalogs. (required: Exclusive to -catalog and -order)
+    -out <directory> The directory the fixtures and the config using them are written to. (default: new temporary directory)
+    -format <format> The format of the config files. "json", "yaml" or "toml". (default: detected by the extension)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgGenerated: "Generated: %d fixtures in %s, run with -root and -catalog-order %s",
+		msgSelftestUsage: `
+Usage: cannect selftest <OPTIONS>
+  OPTIONS
+    -out <directory> The directory the assets of the test CA and the destinations are written to, and kept. (default: new temporary directory removed after the test)
+    -timeout <number> The number of seconds for timeout of the test. (default: 30)
+    -fips Allow only FIPS approved algorithms in CA assets. (default: false)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)`,
+		msgProbeUsage: `
+Usage: cannect probe <OPTIONS>
+  OPTIONS
+    -catalog <file-path> The path of catalog file. (required: Exclusive to -catalog-order)
+    -order <file-path> The path of order file. (required: Exclusive to -catalog-order)
+    -catalog-order <file-path> The path of file contains both orders and catalogs. (required: Exclusive to -catalog and -order)
+    -con-limit <number> The limit of concurrency. (default: 5)
+    -endpoint <host>=<address> The address dialed instead of the host of the APIs of the github and s3 scheme catalogs. Repeatable. (default: no override)
+    -resolver <address> The address of the DNS server resolving the hosts of the APIs of the github and s3 scheme catalogs. Repeatable. (default: system resolver)
+    -s3-endpoint <url> The URL of the S3-compatible API of the s3 scheme catalogs and destinations without the endpoint. (default: AWS)
+    -s3-region <region> The region of the s3 scheme catalogs and destinations without the region. (default: AWS config)
+    -s3-path-style Address the buckets of the s3 scheme in the path. (default: false)
+    -timeout <number> The number of seconds for timeout of the execution. (default: 30)
+    -root <directory> The directory the paths of file scheme catalogs are joined to, like tar -C. (default: current directory)
+    -fs-root <directory> The directory the files of file scheme catalogs must be in, after resolving the symlinks. (default: no restriction)
+    -fs-strict Reject the special files and the paths differing in case in file scheme catalogs. (default: false)
+    -output <format> The format of the results. "json" for the JSON lines, or "text" for the table. (default: json)
+    -format <format> The format of the config files. "json", "yaml" or "toml". (default: detected by the extension)
+    -lang <language> The language of messages. "en" or "ja". (default: $LANG)
+  EXIT STATUS
+    0 All catalogs are available, or not supported to be probed.
+    1 The config is invalid.
+    4 Any catalog is unavailable.`,
+		msgSelftestPassed:      "Self-test passed: %d catalogs and %d orders",
+		msgValid:               "Valid: %d catalogs and %d orders",
+		msgUnchanged:           "Unchanged: %s",
+		msgModified:            "Modified: %s",
+		msgTenantExited:        "Tenant %s exited, restarting in %s: %v",
+		msgUsed:                "Used: %d fetches of %d bytes, %d writes of %d bytes",
+		msgReconciling:         "Reconciling: %s",
+		msgInvoked:             "Invoked: %s",
+		msgFetching:            "Fetching: %s",
+		msgOrdering:            "Ordering: %s",
+		msgFailedOver:          "Failed to order %s, falling back to %s: %v",
+		msgOrderedFallback:     "Ordered to the fallback destination: %s",
+		msgNotWritten:          "Not written (-no-write): %s",
+		msgNotCompared:         "Not compared (-check): %s",
+		msgDrifted:             "Drifted: %s (%s)",
+		msgCheckWarned:         "Warned: %s: %s check: %v",
+		msgSkippedMirror:       "Skipped the mirror %s: the same contents are written to %s",
+		msgThrottled:           "Throttled by %s, retrying in %s with %d writes in parallel",
+		msgRunningHook:         "Running the hook of %s: %s",
+		msgCloseFailed:         "failed to close file: %v",
+		msgFlagCatalog:         "The path of JSON format file contains catalogs. It can be repeated, or be comma-separated paths and glob patterns.",
+		msgFlagOrder:           "The path of JSON format file contains orders. It can be repeated, or be comma-separated paths and glob patterns.",
+		msgFlagCatalogOrder:    "The path of JSON format file contains catalogs and orders. It can be repeated, or be comma-separated paths and glob patterns.",
+		msgFlagEnvOut:          "'env' scheme output file.",
+		msgFlagEnvFormat:       `The format of 'env' scheme output. "export", "dotenv", "json", "yaml" or "powershell".`,
+		msgFlagEnvBase64:       "Encode the values of 'env' scheme output in base64.",
+		msgFlagNoWrite:         "Fetch and check the catalogs, but never write to the destinations.",
+		msgFlagDryRun:          "Fetch and check the catalogs, and print the sizes and checksums of the contents to be written to the destinations without writing.",
+		msgFlagCheck:           "Compare the contents with the destination files without writing, and exit with 2 if any of them differs.",
+		msgFlagPolicy:          "The path of JSON format file contains the allowed URIs.",
+		msgFlagFormat:          `The format of the config files. "json", "yaml" or "toml". Detected by the extension if empty.`,
+		msgFlagConLimit:        "The limit of concurrency.",
+		msgFlagTimeout:         "Timeout of the execution (seconds).",
+		msgFlagConfigTimeout:   "Timeout of loading the config files (seconds).",
+		msgFlagFetchTimeout:    "Timeout of each fetch of the catalogs without timeout (seconds). No limit if 0.",
+		msgFlagFIPS:            "Allow only FIPS approved algorithms in CA assets.",
+		msgFlagRoot:            "The directory the paths of 'file' scheme catalogs and orders are joined to, like tar -C.",
+		msgFlagFSRoot:          "The directory the files of 'file' scheme catalogs must be in, after resolving the symlinks.",
+		msgFlagFSStrict:        "Reject the special files, like devices and FIFOs, and the paths differing in case in 'file' scheme catalogs.",
+		msgFlagLogFormat:       `The format of the logs. "plain", "text" or "json" for the structured logs of slog.`,
+		msgFlagLogLevel:        `The level of the structured logs. "debug", "info", "warn" or "error".`,
+		msgFlagLang:            `The language of messages. "en" or "ja".`,
+		msgFlagNamespace:       "The namespace to reconcile. All namespaces if empty.",
+		msgFlagInterval:        "Interval of the reconciliations (seconds).",
+		msgFlagWatchInterval:   `Interval of the re-syncs, like "1h" or "30m".`,
+		msgFlagPoll:            `Interval of checking the modifications of the config files and the local catalog files. "0" disables it.`,
+		msgFlagKind:            `The kind of the config files. "catalog", "order" or "catalog-order".`,
+		msgFlagConfig:          "The path or s3 URI of file contains catalogs and orders.",
+		msgFlagFetch:           "Fetch and check the catalogs, but never write to the destinations.",
+		msgFlagTenants:         "The path of JSON format file contains the tenants.",
+		msgFlagTenant:          "The name of the tenant isolated by the built-in policy.",
+		msgFlagOutput:          `The output mode. "text" or "github" for the annotations and the step summary of GitHub Actions.`,
+		msgFlagSummary:         "The path of the summary of the run in JSON, with the changes detected against the previous one. It is compressed with gzip if it ends with .gz.",
+		msgFlagSkipUnchanged:   "Skip writing the destinations that have the contents already, and their hooks.",
+		msgFlagState:           "The path or the \"s3\" or \"dynamodb\" URI of the state keeping the digests of the written destinations, to skip rewriting the unchanged ones in the next runs.",
+		msgFlagQuota:           "The limits of the fetches, the fetched bytes and the writes, like \"fetches=100,bytes=1048576,writes=50\".",
+		msgFlagKeepGoing:       "Let the other orders complete when an order fails, and report all failures at the end.",
+		msgFlagOut:             "The directory the fixtures and the config using them are written to.",
+		msgFlagStrict:          "Exit with the status 3 if any check in the warn of the catalogs warns.",
+		msgFlagProbeOutput:     `The format of the results. "json" for the JSON lines, or "text" for the table.`,
+		msgFlagEndpoint:        `The address dialed instead of the host of the APIs of the github and s3 scheme catalogs, like "api.github.com=github-mirror.internal:8443".`,
+		msgFlagResolver:        `The address of the DNS server resolving the hosts of the APIs of the github and s3 scheme catalogs, like "10.0.0.53".`,
+		msgFlagS3Endpoint:      `The URL of the S3-compatible API the s3 scheme catalogs and destinations use by default, like "https://minio.lab:9000".`,
+		msgFlagS3Region:        "The region the s3 scheme catalogs and destinations use by default.",
+		msgFlagS3PathStyle:     "Address the buckets of the s3 scheme catalogs and destinations in the path, not in the host.",
+		msgFlagMetricsTextfile: "The path of the metrics of the fetches and the orders in the text format of Prometheus, for the textfile collector of the node exporter.",
+		msgFlagMetricsAddr:     `The address serving the metrics of the fetches and the orders for the scrape of Prometheus at "/metrics", like ":9464".`,
+		msgCustomSchemes:       "  CUSTOM SCHEMES",
+	},
+	langJA: {
+		msgUsage: `
+使い方: cannect [inspect|validate|watch|schema|gen-fixtures|selftest|probe|operator|lambda] <オプション>
+  コマンド
+    inspect カタログとオーダーを説明と所有者とともに表示します。
+    validate 何も書き込まずに設定を検査します。"cannect validate -h" を参照してください。
+    watch 実行を続け、内容が変更されたときに配置先を書き換えます。"cannect watch -h" を参照してください。
+    schema 設定ファイルの JSON Schema を表示します。"cannect schema -h" を参照してください。
+    gen-fixtures テスト用の CA 階層とカタログのダミーアセットを生成します。"cannect gen-fixtures -h" を参照してください。
+    selftest 組み込みのテスト用 CA のアセットを取得、検査、配置し、配置先を検証します。"cannect selftest -h" を参照してください。
+    probe カタログの取得元がダウンロードせずに利用できるか確認します。"cannect probe -h" を参照してください。
+    operator Kubernetes の Catalog と Order リソースを調整します。"cannect operator -h" を参照してください。
+    lambda AWS Lambda のカスタムランタイムとして呼び出しごとに設定を実行します。"cannect lambda -h" を参照してください。
+  オプション
+    -catalog <ファイルパス> カタログファイルのパス。(必須: -catalog-order と排他)
+    -order <ファイルパス> オーダーファイルのパス。(必須: -catalog-order と排他)
+    -catalog-order <ファイルパス> カタログとオーダーの両方を含むファイルのパス。(必須: -catalog と -order と排他)
+    -env-out <ファイルパス> env スキームの出力先のパス。(デフォルト: ./cannect.env)
+    -env-format <形式> env スキームの出力の形式。"export"、"dotenv"、"json"、"yaml" または "powershell"。(デフォルト: export)
+    -env-base64 env スキームの出力の値を base64 でエンコードします。(デフォルト: false)
+    -con-limit <数値> 並行数の上限。(デフォルト: 5)
+    -endpoint <ホスト>=<アドレス> github と s3 スキームのカタログの API のホストの代わりに接続するアドレス。複数指定できます。(デフォルト: 上書きなし)
+    -resolver <アドレス> github と s3 スキームのカタログの API のホストを名前解決する DNS サーバーのアドレス。複数指定できます。(デフォルト: システムのリゾルバー)
+    -s3-endpoint <url> endpoint のない s3 スキームのカタログと出力先の S3 互換 API の URL。(デフォルト: AWS)
+    -s3-region <リージョン> region のない s3 スキームのカタログと出力先のリージョン。(デフォルト: AWS の設定)
+    -s3-path-style s3 スキームのバケットをパスで指定します。(デフォルト: false)
+    -timeout <数値> 実行のタイムアウトの秒数。(デフォルト: 30)
+    -config-timeout <数値> 設定ファイル読み込みのタイムアウトの秒数。(デフォルト: 10)
+    -fetch-timeout <数値> タイムアウトのないカタログの各取得のタイムアウトの秒数。0 の場合は制限しません。(デフォルト: 0)
+    -format <形式> 設定ファイルの形式。"json"、"yaml" または "toml"。(デフォルト: 拡張子から判定)
+    -fips CA アセットで FIPS 承認済みのアルゴリズムのみを許可します。(デフォルト: false)
+    -root <ディレクトリ> file スキームのカタログとオーダーのパスを、tar -C のように連結するディレクトリ。(デフォルト: カレントディレクトリ)
+    -fs-root <ディレクトリ> file スキームのカタログのファイルが、シンボリックリンクの解決後に含まれるべきディレクトリ。(デフォルト: 制限なし)
+    -fs-strict file スキームのカタログで、特殊ファイルと大文字小文字が異なるパスを拒否します。(デフォルト: false)
+    -log-format <形式> ログの形式。"plain"、または slog の構造化ログの "text" か "json"。(デフォルト: plain)
+    -log-level <レベル> 構造化ログのレベル。"debug"、"info"、"warn" または "error"。(デフォルト: info)
+    -keep-going オーダーが失敗しても他のオーダーを完了させ、最後にすべての失敗を終了ステータス 1 で報告します。(デフォルト: false)
+    -no-write カタログを取得して検査しますが、配置先には書き込みません。(デフォルト: false)
+    -dry-run カタログを取得して検査し、配置先に書き込まずに書き込む内容のサイズとチェックサムを表示します。(デフォルト: false)
+    -check 書き込まずに内容を配置先のファイルと比較し、差分がある場合は終了ステータス 2 で終了します。(デフォルト: false)
+    -policy <ファイルパス> 許可する URI を含むポリシーファイルのパス。(デフォルト: 制限なし)
+    -output <モード> 出力モード。"text" または GitHub Actions のアノテーションとステップサマリーを出力する "github"。(デフォルト: text)
+    -summary <ファイルパス> 実行結果の JSON のサマリーのパス。変更は前回のサマリーと比較して検出します。.gz で終わる場合は gzip で圧縮します。(デフォルト: 書き込まない)
+    -quota <クォータ> 各実行の取得数、取得バイト数と書き込み数の上限。"fetches=100,bytes=1048576,writes=50" など。(デフォルト: 制限なし)
+    -skip-unchanged 既に内容を持つ "file" と "s3" スキームの配置先への書き込みと、そのフックを省略します。(デフォルト: false)
+    -state <ファイルパスまたは URI> 書き込んだ配置先のダイジェストを保持する状態のパス、または "s3" か "dynamodb" の URI。次回以降の実行で変更のない配置先を書き直しません。(デフォルト: 保持しない)
+    -metrics-textfile <file-path> Prometheus のテキスト形式のメトリクスを実行ごとに書き込むパス。(デフォルト: 書き込まない)
+    -strict カタログの warn のチェックが警告した場合、終了ステータス 3 で終了します。(デフォルト: false)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgInspectUsage: `
+使い方: cannect inspect <オプション>
+  オプション
+    -catalog <ファイルパス> カタログファイルのパス。(必須: -catalog-order と排他)
+    -order <ファイルパス> オーダーファイルのパス。(必須: -catalog-order と排他)
+    -catalog-order <ファイルパス> カタログとオーダーの両方を含むファイルのパス。(必須: -catalog と -order と排他)
+    -format <形式> 設定ファイルの形式。"json"、"yaml" または "toml"。(デフォルト: 拡張子から判定)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgOperatorUsage: `
+使い方: cannect operator <オプション>
+  オプション
+    -namespace <ネームスペース> 調整するネームスペース。(デフォルト: 全ネームスペース)
+    -interval <数値> 調整の間隔の秒数。(デフォルト: 60)
+    -con-limit <数値> 並行数の上限。(デフォルト: 5)
+    -timeout <数値> 各調整のタイムアウトの秒数。(デフォルト: 30)
+    -fips CA アセットで FIPS 承認済みのアルゴリズムのみを許可します。(デフォルト: false)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgLambdaUsage: `
+使い方: cannect lambda <オプション>
+  オプション
+    -config <ファイルパスまたは s3 URI> カタログとオーダーの両方を含むファイルのパスまたは s3 URI。イベントの "config" が優先されます。(デフォルト: $CANNECT_CONFIG)
+    -env-out <ファイルパス> env スキームの出力先のパス。(デフォルト: /tmp/cannect.env)
+    -con-limit <数値> 並行数の上限。(デフォルト: 5)
+    -format <形式> 設定ファイルの形式。"json"、"yaml" または "toml"。(デフォルト: 拡張子から判定)
+    -fips CA アセットで FIPS 承認済みのアルゴリズムのみを許可します。(デフォルト: false)
+    -state <ファイルパスまたは URI> 書き込んだ配置先のダイジェストを保持する状態のパス、または "s3" か "dynamodb" の URI。次回以降の実行で変更のない配置先を書き直しません。(デフォルト: 保持しない)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgValidateUsage: `
+使い方: cannect validate <オプション>
+  オプション
+    -catalog <ファイルパス> カタログファイルのパス。(必須: -catalog-order と排他)
+    -order <ファイルパス> オーダーファイルのパス。(必須: -catalog-order と排他)
+    -catalog-order <ファイルパス> カタログとオーダーの両方を含むファイルのパス。(必須: -catalog と -order と排他)
+    -policy <ファイルパス> 許可する URI を含むポリシーファイルのパス。(デフォルト: 制限なし)
+    -output <モード> 出力モード。"text" または GitHub Actions のアノテーションとステップサマリーを出力する "github"。(デフォルト: text)
+    -strict -fetch でカタログの warn のチェックが警告した場合、終了ステータス 3 で終了します。(デフォルト: false)
+    -fetch カタログを取得して検査しますが、配置先には書き込みません。(デフォルト: false)
+    -con-limit <数値> 並行数の上限。(デフォルト: 5)
+    -endpoint <ホスト>=<アドレス> github と s3 スキームのカタログの API のホストの代わりに接続するアドレス。複数指定できます。(デフォルト: 上書きなし)
+    -resolver <アドレス> github と s3 スキームのカタログの API のホストを名前解決する DNS サーバーのアドレス。複数指定できます。(デフォルト: システムのリゾルバー)
+    -s3-endpoint <url> endpoint のない s3 スキームのカタログと出力先の S3 互換 API の URL。(デフォルト: AWS)
+    -s3-region <リージョン> region のない s3 スキームのカタログと出力先のリージョン。(デフォルト: AWS の設定)
+    -s3-path-style s3 スキームのバケットをパスで指定します。(デフォルト: false)
+    -timeout <数値> 取得のタイムアウトの秒数。(デフォルト: 30)
+    -format <形式> 設定ファイルの形式。"json"、"yaml" または "toml"。(デフォルト: 拡張子から判定)
+    -fips CA アセットで FIPS 承認済みのアルゴリズムのみを許可します。(デフォルト: false)
+    -root <ディレクトリ> file スキームのカタログとオーダーのパスを、tar -C のように連結するディレクトリ。(デフォルト: カレントディレクトリ)
+    -fs-root <ディレクトリ> file スキームのカタログのファイルが、シンボリックリンクの解決後に含まれるべきディレクトリ。(デフォルト: 制限なし)
+    -fs-strict file スキームのカタログで、特殊ファイルと大文字小文字が異なるパスを拒否します。(デフォルト: false)
+    -log-format <形式> ログの形式。"plain"、または slog の構造化ログの "text" か "json"。(デフォルト: plain)
+    -log-level <レベル> 構造化ログのレベル。"debug"、"info"、"warn" または "error"。(デフォルト: info)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgWatchUsage: `
+使い方: cannect watch <オプション>
+  オプション
+    -catalog <ファイルパス> カタログファイルのパス。(必須: -catalog-order と排他)
+    -order <ファイルパス> オーダーファイルのパス。(必須: -catalog-order と排他)
+    -catalog-order <ファイルパス> カタログとオーダーの両方を含むファイルのパス。(必須: -catalog と -order と排他)
+    -interval <期間> 再同期の間隔。"1h" や "30m" など。(デフォルト: 1h)
+    -poll <期間> 設定ファイルとローカルのカタログファイルの変更を確認する間隔。"0" で無効になります。(デフォルト: 5s)
+    -env-out <ファイルパス> env スキームの出力先のパス。(デフォルト: ./cannect.env)
+    -env-format <形式> env スキームの出力の形式。"export"、"dotenv"、"json"、"yaml" または "powershell"。(デフォルト: export)
+    -env-base64 env スキームの出力の値を base64 でエンコードします。(デフォルト: false)
+    -con-limit <数値> 並行数の上限。(デフォルト: 5)
+    -endpoint <ホスト>=<アドレス> github と s3 スキームのカタログの API のホストの代わりに接続するアドレス。複数指定できます。(デフォルト: 上書きなし)
+    -resolver <アドレス> github と s3 スキームのカタログの API のホストを名前解決する DNS サーバーのアドレス。複数指定できます。(デフォルト: システムのリゾルバー)
+    -s3-endpoint <url> endpoint のない s3 スキームのカタログと出力先の S3 互換 API の URL。(デフォルト: AWS)
+    -s3-region <リージョン> region のない s3 スキームのカタログと出力先のリージョン。(デフォルト: AWS の設定)
+    -s3-path-style s3 スキームのバケットをパスで指定します。(デフォルト: false)
+    -timeout <数値> 各同期のタイムアウトの秒数。(デフォルト: 30)
+    -config-timeout <数値> 設定ファイル読み込みのタイムアウトの秒数。(デフォルト: 10)
+    -fetch-timeout <数値> タイムアウトのないカタログの各取得のタイムアウトの秒数。0 の場合は制限しません。(デフォルト: 0)
+    -format <形式> 設定ファイルの形式。"json"、"yaml" または "toml"。(デフォルト: 拡張子から判定)
+    -fips CA アセットで FIPS 承認済みのアルゴリズムのみを許可します。(デフォルト: false)
+    -root <ディレクトリ> file スキームのカタログとオーダーのパスを、tar -C のように連結するディレクトリ。(デフォルト: カレントディレクトリ)
+    -fs-root <ディレクトリ> file スキームのカタログのファイルが、シンボリックリンクの解決後に含まれるべきディレクトリ。(デフォルト: 制限なし)
+    -fs-strict file スキームのカタログで、特殊ファイルと大文字小文字が異なるパスを拒否します。(デフォルト: false)
+    -log-format <形式> ログの形式。"plain"、または slog の構造化ログの "text" か "json"。(デフォルト: plain)
+    -log-level <レベル> 構造化ログのレベル。"debug"、"info"、"warn" または "error"。(デフォルト: info)
+    -keep-going オーダーが失敗しても他のオーダーを完了させ、同期の最後にすべての失敗を報告します。(デフォルト: false)
+    -policy <ファイルパス> 許可する URI を含むポリシーファイルのパス。(デフォルト: 制限なし)
+    -tenants <ファイルパス> テナントを含むファイルのパス。各テナントは個別のプロセスで監視されます。(-catalog、-order と -catalog-order と排他)
+    -tenant <名前> 組み込みのポリシーで隔離されるテナントの名前。-tenants により設定されます。(デフォルト: なし)
+    -quota <クォータ> 各同期の取得数、取得バイト数と書き込み数の上限。"fetches=100,bytes=1048576,writes=50" など。(デフォルト: 制限なし)
+    -skip-unchanged 既に内容を持つ "file" と "s3" スキームの配置先への書き込みと、そのフックを省略します。(デフォルト: false)
+    -state <ファイルパスまたは URI> 書き込んだ配置先のダイジェストを保持する状態のパス、または "s3" か "dynamodb" の URI。次回以降の実行で変更のない配置先を書き直しません。(デフォルト: 保持しない)
+    -metrics-textfile <file-path> Prometheus のテキスト形式のメトリクスを同期ごとに書き込むパス。(デフォルト: 書き込まない)
+    -metrics-addr <address> Prometheus 向けのメトリクスを "/metrics" で提供するアドレス。":9464" のように指定します。(デフォルト: 提供しない)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgSchemaUsage: `
+使い方: cannect schema <オプション>
+  オプション
+    -kind <種類> 設定ファイルの種類。"catalog"、"order" または "catalog-order"。(デフォルト: catalog-order)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgGenFixturesUsage: `
+使い方: cannect gen-fixtures <オプション>
+  オプション
+    -catalog <ファイルパス> カタログファイルのパス。(必須: -catalog-order と排他)
+    -order <ファイルパス> オーダーファイルのパス。(必須: -catalog-order と排他)
+    -catalog-order <ファイルパス> カタログとオーダーの両方を含むファイルのパス。(必須: -catalog と -order と排他)
+    -out <ディレクトリ> フィクスチャとそれを使う設定を書き込むディレクトリ。(デフォルト: 新しい一時ディレクトリ)
+    -format <形式> 設定ファイルの形式。"json"、"yaml" または "toml"。(デフォルト: 拡張子から判定)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgGenerated: "生成しました: フィクスチャ %d 件 (%s)。-root と -catalog-order %s で実行してください",
+		msgSelftestUsage: `
+使い方: cannect selftest <オプション>
+  オプション
+    -out <ディレクトリ> テスト用 CA のアセットと配置先を書き込んで残すディレクトリ。(デフォルト: テスト後に削除される新しい一時ディレクトリ)
+    -timeout <数値> テストのタイムアウトの秒数。(デフォルト: 30)
+    -fips CA アセットで FIPS 承認済みのアルゴリズムのみを許可します。(デフォルト: false)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)`,
+		msgProbeUsage: `
+使い方: cannect probe <オプション>
+  オプション
+    -catalog <ファイルパス> カタログファイルのパス。(必須: -catalog-order と排他)
+    -order <ファイルパス> オーダーファイルのパス。(必須: -catalog-order と排他)
+    -catalog-order <ファイルパス> カタログとオーダーの両方を含むファイルのパス。(必須: -catalog と -order と排他)
+    -con-limit <数値> 並行数の上限。(デフォルト: 5)
+    -endpoint <ホスト>=<アドレス> github と s3 スキームのカタログの API のホストの代わりに接続するアドレス。複数指定できます。(デフォルト: 上書きなし)
+    -resolver <アドレス> github と s3 スキームのカタログの API のホストを名前解決する DNS サーバーのアドレス。複数指定できます。(デフォルト: システムのリゾルバー)
+    -s3-endpoint <url> endpoint のない s3 スキームのカタログと出力先の S3 互換 API の URL。(デフォルト: AWS)
+    -s3-region <リージョン> region のない s3 スキームのカタログと出力先のリージョン。(デフォルト: AWS の設定)
+    -s3-path-style s3 スキームのバケットをパスで指定します。(デフォルト: false)
+    -timeout <数値> 実行のタイムアウトの秒数。(デフォルト: 30)
+    -root <ディレクトリ> file スキームのカタログのパスを、tar -C のように連結するディレクトリ。(デフォルト: カレントディレクトリ)
+    -fs-root <ディレクトリ> file スキームのカタログのファイルが、シンボリックリンクの解決後に含まれるべきディレクトリ。(デフォルト: 制限なし)
+    -fs-strict file スキームのカタログで、特殊ファイルと大文字小文字が異なるパスを拒否します。(デフォルト: false)
+    -output <形式> 結果の形式。JSON Lines の "json" または表形式の "text"。(デフォルト: json)
+    -format <形式> 設定ファイルの形式。"json"、"yaml" または "toml"。(デフォルト: 拡張子から判定)
+    -lang <言語> メッセージの言語。"en" または "ja"。(デフォルト: $LANG)
+  終了ステータス
+    0 すべてのカタログが利用可能か、確認に対応していません。
+    1 設定が不正です。
+    4 いずれかのカタログが利用できません。`,
+		msgSelftestPassed:      "セルフテストに成功しました: カタログ %d 件、オーダー %d 件",
+		msgValid:               "有効です: カタログ %d 件、オーダー %d 件",
+		msgUnchanged:           "変更はありません: %s",
+		msgModified:            "変更されました: %s",
+		msgTenantExited:        "テナント %s が終了したため %s 後に再起動します: %v",
+		msgUsed:                "使用量: 取得 %d 回 (%d バイト)、書き込み %d 回 (%d バイト)",
+		msgReconciling:         "調整中: %s",
+		msgInvoked:             "呼び出し: %s",
+		msgFetching:            "取得中: %s",
+		msgOrdering:            "配置中: %s",
+		msgFailedOver:          "%s への配置に失敗したため %s にフォールバックします: %v",
+		msgOrderedFallback:     "フォールバック先に配置しました: %s",
+		msgNotWritten:          "書き込みません (-no-write): %s",
+		msgNotCompared:         "比較しません (-check): %s",
+		msgDrifted:             "差分があります: %s (%s)",
+		msgCheckWarned:         "警告: %s: %s チェック: %v",
+		msgSkippedMirror:       "ミラー %s をスキップしました: 同じ内容が %s に書き込まれています",
+		msgThrottled:           "%s にスロットリングされたため %s 後に並列数 %d で再試行します",
+		msgRunningHook:         "%s のフックを実行中: %s",
+		msgCloseFailed:         "ファイルのクローズに失敗しました: %v",
+		msgFlagCatalog:         "カタログを含む JSON ファイルのパス。繰り返し指定、またはカンマ区切りのパスと glob パターンを指定できます。",
+		msgFlagOrder:           "オーダーを含む JSON ファイルのパス。繰り返し指定、またはカンマ区切りのパスと glob パターンを指定できます。",
+		msgFlagCatalogOrder:    "カタログとオーダーを含む JSON ファイルのパス。繰り返し指定、またはカンマ区切りのパスと glob パターンを指定できます。",
+		msgFlagEnvOut:          "'env' スキームの出力ファイル。",
+		msgFlagEnvFormat:       `'env' スキームの出力の形式。"export"、"dotenv"、"json"、"yaml" または "powershell"。`,
+		msgFlagEnvBase64:       "'env' スキームの出力の値を base64 でエンコードします。",
+		msgFlagNoWrite:         "カタログを取得して検査しますが、配置先には書き込みません。",
+		msgFlagDryRun:          "カタログを取得して検査し、配置先に書き込まずに書き込む内容のサイズとチェックサムを表示します。",
+		msgFlagCheck:           "書き込まずに内容を配置先のファイルと比較し、差分がある場合は終了ステータス 2 で終了します。",
+		msgFlagPolicy:          "許可する URI を含む JSON ファイルのパス。",
+		msgFlagFormat:          `設定ファイルの形式。"json"、"yaml" または "toml"。空の場合は拡張子から判定します。`,
+		msgFlagConLimit:        "並行数の上限。",
+		msgFlagTimeout:         "実行のタイムアウト (秒)。",
+		msgFlagConfigTimeout:   "設定ファイル読み込みのタイムアウト (秒)。",
+		msgFlagFetchTimeout:    "タイムアウトのないカタログの各取得のタイムアウト (秒)。0 の場合は制限しません。",
+		msgFlagFIPS:            "CA アセットで FIPS 承認済みのアルゴリズムのみを許可します。",
+		msgFlagRoot:            "'file' スキームのカタログとオーダーのパスを、tar -C のように連結するディレクトリ。",
+		msgFlagFSRoot:          "'file' スキームのカタログのファイルが、シンボリックリンクの解決後に含まれるべきディレクトリ。",
+		msgFlagFSStrict:        "'file' スキームのカタログで、デバイスや FIFO などの特殊ファイルと、大文字小文字が異なるパスを拒否します。",
+		msgFlagLogFormat:       `ログの形式。"plain"、または slog の構造化ログの "text" か "json"。`,
+		msgFlagLogLevel:        `構造化ログのレベル。"debug"、"info"、"warn" または "error"。`,
+		msgFlagLang:            `メッセージの言語。"en" または "ja"。`,
+		msgFlagNamespace:       "調整するネームスペース。空の場合は全ネームスペース。",
+		msgFlagInterval:        "調整の間隔 (秒)。",
+		msgFlagWatchInterval:   `再同期の間隔。"1h" や "30m" など。`,
+		msgFlagPoll:            `設定ファイルとローカルのカタログファイルの変更を確認する間隔。"0" で無効になります。`,
+		msgFlagKind:            `設定ファイルの種類。"catalog"、"order" または "catalog-order"。`,
+		msgFlagConfig:          "カタログとオーダーを含むファイルのパスまたは s3 URI。",
+		msgFlagFetch:           "カタログを取得して検査しますが、配置先には書き込みません。",
+		msgFlagTenants:         "テナントを含む JSON ファイルのパス。",
+		msgFlagTenant:          "組み込みのポリシーで隔離されるテナントの名前。",
+		msgFlagOutput:          `出力モード。"text" または GitHub Actions のアノテーションとステップサマリーを出力する "github"。`,
+		msgFlagSummary:         "実行結果の JSON のサマリーのパス。変更は前回のサマリーと比較して検出します。.gz で終わる場合は gzip で圧縮します。",
+		msgFlagSkipUnchanged:   "既に内容を持つ配置先への書き込みと、そのフックを省略します。",
+		msgFlagState:           "書き込んだ配置先のダイジェストを保持する状態のパス、または \"s3\" か \"dynamodb\" の URI。次回以降の実行で変更のない配置先を書き直しません。",
+		msgFlagQuota:           "取得数、取得バイト数と書き込み数の上限。\"fetches=100,bytes=1048576,writes=50\" など。",
+		msgFlagKeepGoing:       "オーダーが失敗しても他のオーダーを完了させ、最後にすべての失敗を報告します。",
+		msgFlagOut:             "フィクスチャとそれを使う設定を書き込むディレクトリ。",
+		msgFlagStrict:          "カタログの warn のチェックが警告した場合、終了ステータス 3 で終了します。",
+		msgFlagProbeOutput:     `結果の形式。JSON Lines の "json" または表形式の "text"。`,
+		msgFlagEndpoint:        `github と s3 スキームのカタログの API のホストの代わりに接続するアドレス。"api.github.com=github-mirror.internal:8443" のように指定します。`,
+		msgFlagResolver:        `github と s3 スキームのカタログの API のホストを名前解決する DNS サーバーのアドレス。"10.0.0.53" のように指定します。`,
+		msgFlagS3Endpoint:      `s3 スキームのカタログと出力先がデフォルトで使う S3 互換 API の URL。"https://minio.lab:9000" のように指定します。`,
+		msgFlagS3Region:        "s3 スキームのカタログと出力先がデフォルトで使うリージョン。",
+		msgFlagS3PathStyle:     "s3 スキームのカタログと出力先のバケットをホストではなくパスで指定します。",
+		msgFlagMetricsTextfile: "取得とオーダーのメトリクスを Prometheus のテキスト形式で書き込むパス。node exporter の textfile コレクター向けです。",
+		msgFlagMetricsAddr:     `取得とオーダーのメトリクスを Prometheus のスクレイプ向けに "/metrics" で提供するアドレス。":9464" のように指定します。`,
+		msgCustomSchemes:       "  カスタムスキーム",
+	},
+}
+
+// printer formats the CLI messages in its language.
+type printer struct {
+	lang string
+}
+
+// newPrinter returns the printer of the language. It falls back to English
+// if the language is not supported.
+func newPrinter(lang string) printer {
+	if _, ok := messageCatalog[lang]; !ok {
+		lang = langEN
+	}
+
+	return printer{lang: lang}
+}
+
+func (p printer) Sprintf(msg message, args ...interface{}) string {
+	return fmt.Sprintf(messageCatalog[p.lang][msg], args...)
+}
+
+// usage returns the usage of the CLI, including the registered custom schemes.
+func usage() string {
+	text := msgs.Sprintf(msgUsage)
+
+	schemes := schemeapi.Schemes()
+	if len(schemes) == 0 {
+		return text
+	}
+
+	text += "\n" + msgs.Sprintf(msgCustomSchemes)
+	for _, s := range schemes {
+		text += fmt.Sprintf("\n    %s %s", s.Name, s.Usage)
+	}
+
+	return text
+}
+
+// msgs is the printer used in the CLI. It is set by the -lang option.
+var msgs = newPrinter(langEN)
+
+// detectLang returns the language of the locale environment variables, like
+// "ja" of "ja_JP.UTF-8".
+func detectLang() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+
+		lang := strings.ToLower(v)
+		if idx := strings.IndexAny(lang, "_.@-"); idx >= 0 {
+			lang = lang[:idx]
+		}
+
+		return lang
+	}
+
+	return langEN
+}
+
+// langFromArgs returns the value of the -lang option in the arguments, so the
+// messages of the other options can be localized before parsing them.
+func langFromArgs(args []string) string {
+	for idx, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+
+		if strings.HasPrefix(name, "lang=") {
+			return strings.TrimPrefix(name, "lang=")
+		}
+
+		if name == "lang" && idx+1 < len(args) {
+			return args[idx+1]
+		}
+	}
+
+	return detectLang()
+}
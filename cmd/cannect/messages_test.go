@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMessageCatalog(t *testing.T) {
+	t.Parallel()
+
+	for lang, msgMap := range messageCatalog {
+		if len(msgMap) != len(messageCatalog[langEN]) {
+			t.Errorf("%s: expected %d messages but got: %d", lang, len(messageCatalog[langEN]), len(msgMap))
+		}
+
+		for key := message(0); key < numMessages; key++ {
+			if msgMap[key] == "" {
+				t.Errorf("%s: message %d is missing", lang, key)
+			}
+		}
+	}
+}
+
+func TestLangFromArgs(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testcase string
+		// input
+		args []string
+		// want
+		lang string
+	}{
+		{"OK:separated value", []string{"-catalog", "c.json", "-lang", "ja"}, langJA},
+		{"OK:joined value", []string{"--lang=ja", "-catalog", "c.json"}, langJA},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			if lang := langFromArgs(d.args); lang != d.lang {
+				t.Errorf("Expected lang is %s but got: %s", d.lang, lang)
+			}
+		})
+	}
+
+	if p := newPrinter("xx"); p.lang != langEN {
+		t.Errorf("Expected fallback lang is %s but got: %s", langEN, p.lang)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sync"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+)
+
+// mirrorWrite is the write of the contents of a digest to a mirror group.
+type mirrorWrite struct {
+	uriText string
+	done    chan struct{}
+	err     error
+}
+
+// mirrorSet records the contents written to the mirror groups in a run. The
+// destinations declared as mirrors are synchronized outside cannect, like the
+// replicated S3 buckets, so the same contents are written to only one of them.
+type mirrorSet struct {
+	mu     sync.Mutex
+	writes map[string]*mirrorWrite
+}
+
+func newMirrorSet() *mirrorSet {
+	return &mirrorSet{writes: make(map[string]*mirrorWrite)}
+}
+
+// claim returns the write of the key, and whether it is claimed by the caller.
+func (m *mirrorSet) claim(key, uriText string) (*mirrorWrite, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if w, ok := m.writes[key]; ok {
+		return w, false
+	}
+
+	w := &mirrorWrite{uriText: uriText, done: make(chan struct{})}
+	m.writes[key] = w
+
+	return w, true
+}
+
+// mirrorOrder skips the order if the same contents have been written to
+// another destination in the mirror group.
+type mirrorOrder struct {
+	group    string
+	uriText  string
+	order    Order
+	catalogs []orderapi.Catalog
+	set      *mirrorSet
+	l        *log.Logger
+}
+
+func newMirrorOrder(
+	uriText string, oJSON OrderJSON, sources []orderapi.Catalog, order Order, set *mirrorSet, l *log.Logger,
+) (*mirrorOrder, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	mOrder := &mirrorOrder{
+		group:    oJSON.Mirror,
+		uriText:  uriText,
+		order:    order,
+		catalogs: catalogs,
+		set:      set,
+		l:        l,
+	}
+
+	return mOrder, nil
+}
+
+func (m *mirrorOrder) Order(ctx context.Context) error {
+	h := sha256.New()
+	for _, catalog := range m.catalogs {
+		buf, err := catalog.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		h.Write(buf)
+	}
+
+	// The schemes writing each catalog are not mirrors of the others.
+	key := m.group + "\x00" + schemeapi.Of(m.uriText) + "\x00" + hex.EncodeToString(h.Sum(nil))
+
+	w, claimed := m.set.claim(key, m.uriText)
+	if claimed {
+		w.err = m.order.Order(ctx)
+		close(w.done)
+		return w.err
+	}
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if w.err != nil {
+		// Write by itself, since the mirror has not been written.
+		return m.order.Order(ctx)
+	}
+
+	m.l.Print(msgs.Sprintf(msgSkippedMirror, m.uriText, w.uriText))
+
+	return nil
+}
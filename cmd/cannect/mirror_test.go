@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRun_Mirror(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+			{
+				Alias:    "sub-ca.crt",
+				URI:      "file://testdata/sub-ca.crt",
+				Category: "certificate",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URIs: []string{
+					"file://testdata/test-mirror-1.out",
+					"file://testdata/test-mirror-2.out",
+				},
+				Mirror: "ca",
+			},
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URI:    "file://testdata/test-mirror-3.out",
+				Mirror: "ca",
+			},
+			{
+				CatalogAliases: []string{
+					"sub-ca.crt",
+				},
+				URI:    "file://testdata/test-mirror-4.out",
+				Mirror: "ca",
+			},
+		},
+	}
+
+	paths := []string{
+		"testdata/test-mirror-1.out",
+		"testdata/test-mirror-2.out",
+		"testdata/test-mirror-3.out",
+		"testdata/test-mirror-4.out",
+	}
+	t.Cleanup(func() {
+		for _, p := range paths {
+			os.Remove(p)
+		}
+	})
+
+	var buf bytes.Buffer
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5}
+	err := run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var written int
+	for _, p := range paths[:3] {
+		if _, err := os.Stat(p); err == nil {
+			written++
+		}
+	}
+	if written != 1 {
+		t.Errorf("Expected the root CA is written once but got: %d times", written)
+	}
+
+	if _, err := os.Stat(paths[3]); err != nil {
+		t.Errorf("Expected the different contents are written but got: %v", err)
+	}
+
+	if n := strings.Count(buf.String(), "Skipped the mirror"); n != 2 {
+		t.Errorf("Expected 2 mirrors are skipped but got: %d", n)
+	}
+}
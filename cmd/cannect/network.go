@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+)
+
+var errInvalidEndpoint = errors.New("endpoint must be <host>=<address>")
+
+// networkFlags are the options of how the remote catalogs and destinations
+// reach their APIs.
+type networkFlags struct {
+	endpoints   listFlag
+	resolvers   listFlag
+	s3Endpoint  *string
+	s3Region    *string
+	s3PathStyle *bool
+}
+
+func addNetworkFlags(fs *flag.FlagSet) *networkFlags {
+	n := new(networkFlags)
+	fs.Var(&n.endpoints, "endpoint", msgs.Sprintf(msgFlagEndpoint))
+	fs.Var(&n.resolvers, "resolver", msgs.Sprintf(msgFlagResolver))
+	n.s3Endpoint = fs.String("s3-endpoint", "", msgs.Sprintf(msgFlagS3Endpoint))
+	n.s3Region = fs.String("s3-region", "", msgs.Sprintf(msgFlagS3Region))
+	n.s3PathStyle = fs.Bool("s3-path-style", false, msgs.Sprintf(msgFlagS3PathStyle))
+
+	return n
+}
+
+// s3 returns the S3JSON of the run.
+func (n *networkFlags) s3() (S3JSON, error) {
+	sJSON := S3JSON{Endpoint: *n.s3Endpoint, Region: *n.s3Region, PathStyle: *n.s3PathStyle}
+	return sJSON, sJSON.check()
+}
+
+// network returns the Network of the options. Each option is a
+// comma-separated list, like "api.github.com=github-mirror.internal:8443".
+// The resolvers without port use the port 53.
+func (n *networkFlags) network() (catalogapi.Network, error) {
+	var network catalogapi.Network
+
+	for _, item := range splitList(n.endpoints.String()) {
+		host, addr, ok := strings.Cut(item, "=")
+		if !ok || host == "" || addr == "" {
+			// Check the endpoint is the pair of the host and the address
+			return network, fmt.Errorf("%s: %w", item, errInvalidEndpoint)
+		}
+		if network.Endpoints == nil {
+			network.Endpoints = make(map[string]string)
+		}
+		network.Endpoints[host] = addr
+	}
+
+	for _, item := range splitList(n.resolvers.String()) {
+		if _, _, err := net.SplitHostPort(item); err != nil {
+			item = net.JoinHostPort(item, "53")
+		}
+		network.Resolvers = append(network.Resolvers, item)
+	}
+
+	return network, nil
+}
+
+// httpClient returns the client of the Network, or nil if no option is set.
+func (n *networkFlags) httpClient() (*http.Client, error) {
+	network, err := n.network()
+	if err != nil {
+		return nil, err
+	}
+
+	return network.HTTPClient(), nil
+}
+
+// splitList returns the non-empty items of the comma-separated list.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
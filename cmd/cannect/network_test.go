@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/go-cmp/cmp"
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+)
+
+func TestNetworkFlags_Network(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase  string
+		endpoints []string
+		resolvers []string
+		// want
+		want catalogapi.Network
+		err  error
+	}{
+		{"OK:No Options", nil, nil, catalogapi.Network{}, nil},
+		{
+			"OK:Options",
+			[]string{"api.github.com=github-mirror.internal:8443", "s3.amazonaws.com=s3-mirror.internal,sts.amazonaws.com=sts.internal"},
+			[]string{"10.0.0.53", "10.0.1.53:5353"},
+			catalogapi.Network{
+				Endpoints: map[string]string{
+					"api.github.com":    "github-mirror.internal:8443",
+					"s3.amazonaws.com":  "s3-mirror.internal",
+					"sts.amazonaws.com": "sts.internal",
+				},
+				Resolvers: []string{"10.0.0.53:53", "10.0.1.53:5353"},
+			},
+			nil,
+		},
+		{"NG:No Address", []string{"api.github.com="}, nil, catalogapi.Network{}, errInvalidEndpoint},
+		{"NG:No Pair", []string{"github-mirror.internal"}, nil, catalogapi.Network{}, errInvalidEndpoint},
+	}
+
+	for _, d := range data {
+		flags := &networkFlags{endpoints: d.endpoints, resolvers: d.resolvers}
+		got, err := flags.network()
+		if !errors.Is(err, d.err) {
+			t.Fatalf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if diff := cmp.Diff(d.want, got); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+}
+
+func TestS3JSON_Options(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		s3JSON   *S3JSON
+		run      S3JSON
+		// want
+		want s3.Options
+		none bool
+	}{
+		{"None", nil, S3JSON{}, s3.Options{}, true},
+		{
+			"Run",
+			nil,
+			S3JSON{Endpoint: "https://minio.lab:9000", Region: "us-east-1", PathStyle: true},
+			s3.Options{BaseEndpoint: aws.String("https://minio.lab:9000"), Region: "us-east-1", UsePathStyle: true},
+			false,
+		},
+		{
+			"Element Over Run",
+			&S3JSON{Endpoint: "http://minio.test:9000"},
+			S3JSON{Endpoint: "https://minio.lab:9000", Region: "us-east-1"},
+			s3.Options{BaseEndpoint: aws.String("http://minio.test:9000"), Region: "us-east-1"},
+			false,
+		},
+		{
+			"Element Path Style",
+			&S3JSON{PathStyle: true},
+			S3JSON{},
+			s3.Options{UsePathStyle: true},
+			false,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			optFns := d.s3JSON.options(d.run)
+			if d.none {
+				if optFns != nil {
+					t.Fatalf("Expected no options but got: %d", len(optFns))
+				}
+				return
+			}
+
+			var got s3.Options
+			for _, fn := range optFns {
+				fn(&got)
+			}
+			if got.Region != d.want.Region || got.UsePathStyle != d.want.UsePathStyle ||
+				aws.ToString(got.BaseEndpoint) != aws.ToString(d.want.BaseEndpoint) {
+				t.Errorf("Expected %v %s %v but got: %v %s %v",
+					aws.ToString(d.want.BaseEndpoint), d.want.Region, d.want.UsePathStyle,
+					aws.ToString(got.BaseEndpoint), got.Region, got.UsePathStyle,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+// noWriteOrder fetches the contents of the order instead of writing them, so
+// the catalogs are fetched and checked, but the destination is never mutated.
+// It is used in the -no-write mode for the audit-only jobs.
+type noWriteOrder struct {
+	uriText  string
+	catalogs []orderapi.Catalog
+	l        *log.Logger
+}
+
+func newNoWriteOrder(uriText string, oJSON OrderJSON, sources []orderapi.Catalog, l *log.Logger) (*noWriteOrder, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &noWriteOrder{
+		uriText:  uriText,
+		catalogs: catalogs,
+		l:        l,
+	}
+
+	return order, nil
+}
+
+func (n *noWriteOrder) Order(ctx context.Context) error {
+	for _, catalog := range n.catalogs {
+		_, err := catalog.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	n.l.Print(msgs.Sprintf(msgNotWritten, n.uriText))
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRun_NoWrite(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URIs: []string{
+					"file://testdata/test-no-write.out",
+					"env://ROOT_CA",
+				},
+			},
+		},
+	}
+
+	envOut := "testdata/test-no-write.env"
+
+	var buf bytes.Buffer
+	cfg := runConfig{EnvOut: envOut, ConLimit: 5, NoWrite: true}
+	err := run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"testdata/test-no-write.out", envOut} {
+		_, err := os.Stat(p)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected %s is not written but got: %v", p, err)
+		}
+	}
+
+	for _, uri := range jsn.Orders[0].URIs {
+		if !strings.Contains(buf.String(), msgs.Sprintf(msgNotWritten, uri)) {
+			t.Errorf("Expected %s is reported but got: %s", uri, buf.String())
+		}
+	}
+
+	// The catalogs are still checked.
+	jsn.Catalogs[0].Category = "privateKey"
+	err = run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+	if err == nil {
+		t.Fatal("Expected the check error but got nil")
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/yuxki/cannect/pkg/kube"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+)
+
+const (
+	crdGroupVersion = "cannect.yuxki.github.io/v1alpha1"
+	defaultInterval = 60
+)
+
+var (
+	errOperatorURINotAllowed   = errors.New("uri is not allowed in operator mode")
+	errOperatorHookNotAllowed  = errors.New("hook is not allowed in operator mode")
+	errOperatorFieldNotAllowed = errors.New("field is not allowed in operator mode")
+)
+
+type resourceMeta struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// catalogResource is the Catalog custom resource. Its spec is the catalog
+// element.
+type catalogResource struct {
+	Metadata resourceMeta `json:"metadata"`
+	Spec     CatalogJSON  `json:"spec"`
+}
+
+// orderResource is the Order custom resource. Its spec is the order element.
+type orderResource struct {
+	Metadata resourceMeta `json:"metadata"`
+	Spec     OrderJSON    `json:"spec"`
+}
+
+func resourcePath(namespace, resource string) string {
+	if namespace == "" {
+		return fmt.Sprintf("/apis/%s/%s", crdGroupVersion, resource)
+	}
+
+	return fmt.Sprintf("/apis/%s/namespaces/%s/%s", crdGroupVersion, namespace, resource)
+}
+
+// fetchResources lists the Catalog and Order resources in the namespace, or in
+// all namespaces if it is empty, and returns the CAnnectJSON of each
+// namespace. The name of the Catalog is its alias unless the alias is set.
+func fetchResources(ctx context.Context, client *kube.Client, namespace string) (map[string]CAnnectJSON, error) {
+	var catalogs struct {
+		Items []catalogResource `json:"items"`
+	}
+	err := client.Get(ctx, resourcePath(namespace, "catalogs"), &catalogs)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders struct {
+		Items []orderResource `json:"items"`
+	}
+	err = client.Get(ctx, resourcePath(namespace, "orders"), &orders)
+	if err != nil {
+		return nil, err
+	}
+
+	cntJSONs := make(map[string]CAnnectJSON)
+
+	for _, item := range catalogs.Items {
+		cJSON := item.Spec
+		if cJSON.Alias == "" {
+			cJSON.Alias = item.Metadata.Name
+		}
+
+		cntJSON := cntJSONs[item.Metadata.Namespace]
+		cntJSON.Catalogs = append(cntJSON.Catalogs, cJSON)
+		cntJSONs[item.Metadata.Namespace] = cntJSON
+	}
+
+	for _, item := range orders.Items {
+		cntJSON := cntJSONs[item.Metadata.Namespace]
+		cntJSON.Orders = append(cntJSON.Orders, item.Spec)
+		cntJSONs[item.Metadata.Namespace] = cntJSON
+	}
+
+	return cntJSONs, nil
+}
+
+// operatorCatalogSchemes are the schemes of the catalogs allowed in operator
+// mode. The file, workload and plugin catalogs read the files, the identity or
+// the commands of the operator, so they are not allowed.
+var operatorCatalogSchemes = []string{"github", "s3"}
+
+// operatorCatalogFields, operatorS3Fields and operatorOrderFields are the
+// fields of the resources allowed in operator mode. The fields not listed,
+// including the ones added in the future, are rejected. The roleArn and the
+// endpoint of s3 are not allowed, so that the resources neither assume the
+// roles of the operator nor send its signed requests to other hosts.
+var (
+	operatorCatalogFields = []string{
+		"alias", "uri", "category", "categories", "caPolicy", "keyPolicy", "minRemainingValidity",
+		"warnBefore", "filter", "range", "retry", "s3", "timeout", "warn", "description", "owner",
+	}
+	operatorS3Fields    = []string{"region", "pathStyle"}
+	operatorOrderFields = []string{
+		"aliases", "uri", "uris", "fallbacks", "merge", "verify", "matchKey", "mergeCRL", "unwrapPKCS7",
+		"format", "normalize", "edge", "join", "timeout", "description", "owner",
+	}
+)
+
+// checkOperatorJSON checks the resources only touch the cluster, and only the
+// namespace they belong to, and run no hook or plugin. The schemes and the
+// fields are allow-listed, so anyone allowed to create the resources can not
+// read the files or the identity of the operator, use its credentials
+// elsewhere, write to the other namespaces, or run commands in the operator.
+func checkOperatorJSON(namespace string, cntJSON CAnnectJSON) error {
+	for _, cJSON := range cntJSON.Catalogs {
+		if !containsString(operatorCatalogSchemes, schemeapi.Of(cJSON.URI)) {
+			return fmt.Errorf("%s: %w", cJSON.URI, errOperatorURINotAllowed)
+		}
+
+		err := checkOperatorFields(cJSON, operatorCatalogFields)
+		if err == nil && cJSON.S3 != nil {
+			err = checkOperatorFields(cJSON.S3, operatorS3Fields)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", cJSON.Alias, err)
+		}
+	}
+
+	prefix := fmt.Sprintf("k8s://%s/", namespace)
+	for _, oJSON := range cntJSON.Orders {
+		if hook := hookOf(cntJSON, oJSON); len(hook) > 0 {
+			return fmt.Errorf("%s: %w", strings.Join(hook, " "), errOperatorHookNotAllowed)
+		}
+
+		for _, uri := range oJSON.destinations() {
+			if !strings.HasPrefix(uri, prefix) {
+				return fmt.Errorf("%s: %w", uri, errOperatorURINotAllowed)
+			}
+		}
+
+		err := checkOperatorFields(oJSON, operatorOrderFields)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(oJSON.destinations(), ","), err)
+		}
+	}
+
+	return nil
+}
+
+// checkOperatorFields checks the fields set in the element are all allowed.
+// The fields are the keys of the JSON of the element, so the fields left empty
+// are not set.
+func checkOperatorFields(element interface{}, allowed []string) error {
+	b, err := json.Marshal(element)
+	if err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !containsString(allowed, name) {
+			return fmt.Errorf("%s: %w", name, errOperatorFieldNotAllowed)
+		}
+	}
+
+	return nil
+}
+
+// reconcile orders the resources of each namespace. The failure in a namespace
+// does not stop the others.
+func reconcile(ctx context.Context, client *kube.Client, namespace string, cfg runConfig, logger *log.Logger) error {
+	cntJSONs, err := fetchResources(ctx, client, namespace)
+	if err != nil {
+		return err
+	}
+
+	namespaces := make([]string, 0, len(cntJSONs))
+	for ns := range cntJSONs {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	var firstErr error
+	for _, ns := range namespaces {
+		logger.Print(msgs.Sprintf(msgReconciling, ns))
+
+		cntJSON := cntJSONs[ns]
+
+		err := checkOperatorJSON(ns, cntJSON)
+		if err == nil {
+			err = validate(cntJSON)
+		}
+		if err == nil {
+			err = run(ctx, cntJSON, cfg, logger)
+		}
+		if err != nil {
+			logger.Printf("%s: %v", ns, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+func operatorMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("operator", flag.ExitOnError)
+	namespace := fs.String("namespace", "", msgs.Sprintf(msgFlagNamespace))
+	interval := fs.Int64("interval", defaultInterval, msgs.Sprintf(msgFlagInterval))
+	conLimit := fs.Int("con-limit", defaultConLimit, msgs.Sprintf(msgFlagConLimit))
+	timeout := fs.Int64("timeout", defaultTimeout, msgs.Sprintf(msgFlagTimeout))
+	fips := fs.Bool("fips", false, msgs.Sprintf(msgFlagFIPS))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	fs.Usage = func() { log.Println(msgs.Sprintf(msgOperatorUsage)) }
+	_ = fs.Parse(args)
+
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+
+	client, err := kube.NewInClusterClient()
+	if err != nil {
+		logger.Println(err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg := newRunConfig(defaultEnvOut, *conLimit, *fips)
+
+	ticker := time.NewTicker(time.Second * time.Duration(*interval))
+	defer ticker.Stop()
+
+	for {
+		rCtx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(*timeout))
+		err := reconcile(rCtx, client, *namespace, cfg, logger)
+		cancel()
+		if err != nil {
+			logger.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+
+	return false
+}
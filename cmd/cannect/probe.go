@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+// The status of the catalog probed.
+const (
+	probeAvailable   = "available"
+	probeUnavailable = "unavailable"
+	probeUnsupported = "unsupported"
+)
+
+// The exit status of the probe command when any catalog is unavailable.
+const exitUnavailable = 4
+
+// The output of the results in the JSON lines.
+const jsonOutput = "json"
+
+// prober checks the source of the catalog is available without fetching its
+// content, and returns the size of the content, or -1 if it is not known.
+type prober interface {
+	Probe(context.Context) (int64, error)
+}
+
+// probeResult is the result of probing a catalog. The code is the exit status
+// of the catalog, and the probe command exits with the highest one.
+type probeResult struct {
+	Alias     string `json:"alias"`
+	URI       string `json:"uri"`
+	Status    string `json:"status"`
+	Code      int    `json:"code"`
+	LatencyMS int64  `json:"latencyMs"`
+	Size      *int64 `json:"size,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// newProber returns the prober of the catalog, or nil if the scheme does not
+// support the probe. The catalogs of the custom schemes are probed if they
+// implement the prober.
+func newProber(cJSON CatalogJSON, cfg runConfig) (prober, error) {
+	switch schemeapi.Of(cJSON.URI) {
+	case "file":
+		uri, err := newFSURI(cJSON.URI, cfg.Root)
+		if err != nil {
+			return nil, err
+		}
+		return catalogapi.NewFSCatalog(uri, cJSON.Alias, nil).WithGuard(cfg.FSGuard), nil
+	case "github":
+		uri, err := uriapi.NewGitHubURI(cJSON.URI)
+		if err != nil {
+			return nil, err
+		}
+		retry, err := cJSON.Retry.retry()
+		if err != nil {
+			return nil, err
+		}
+		return catalogapi.NewGitHubCatalog(uri, cJSON.Alias, nil).WithRetry(retry).WithRateLimiter(cfg.GitHubLimiter).
+			WithHTTPClient(cfg.HTTPClient), nil
+	case "s3":
+		uri, err := uriapi.NewS3URI(cJSON.URI)
+		if err != nil {
+			return nil, err
+		}
+		retry, err := cJSON.Retry.retry()
+		if err != nil {
+			return nil, err
+		}
+		return catalogapi.NewS3Catalog(uri, cJSON.Alias, nil).WithRetry(retry).WithHTTPClient(cfg.HTTPClient).
+			WithOptions(cJSON.S3.options(cfg.S3)...).WithAssumeRole(cJSON.S3.assumeRole()), nil
+	case "workload", "plugin":
+		return nil, nil
+	}
+
+	s, uri, err := customScheme(cJSON.URI, true)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := s.NewCatalog(uri, cJSON.Alias, nil)
+	if err != nil {
+		return nil, err
+	}
+	p, _ := catalog.(prober)
+
+	return p, nil
+}
+
+// probe probes the catalogs concurrently within the limit of the runConfig,
+// and returns the results in the order of the catalogs.
+func probe(ctx context.Context, cntJSON CAnnectJSON, cfg runConfig) ([]probeResult, error) {
+	probers := make([]prober, len(cntJSON.Catalogs))
+	for idx, cJSON := range cntJSON.Catalogs {
+		p, err := newProber(cJSON, cfg)
+		if err != nil {
+			return nil, err
+		}
+		probers[idx] = p
+	}
+
+	results := make([]probeResult, len(cntJSON.Catalogs))
+	limit := make(chan struct{}, cfg.ConLimit)
+	var wg sync.WaitGroup
+
+	for idx, cJSON := range cntJSON.Catalogs {
+		results[idx] = probeResult{Alias: cJSON.Alias, URI: cJSON.URI, Status: probeUnsupported}
+		if probers[idx] == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *probeResult, p prober, cJSON CatalogJSON) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			pCtx := ctx
+			if cJSON.Timeout > 0 {
+				var cancel context.CancelFunc
+				pCtx, cancel = context.WithTimeout(ctx, time.Second*time.Duration(cJSON.Timeout))
+				defer cancel()
+			}
+
+			start := time.Now()
+			size, err := p.Probe(pCtx)
+			result.LatencyMS = time.Since(start).Milliseconds()
+			if err != nil {
+				result.Status, result.Code, result.Error = probeUnavailable, exitUnavailable, err.Error()
+				return
+			}
+
+			result.Status = probeAvailable
+			if size >= 0 {
+				result.Size = &size
+			}
+		}(&results[idx], probers[idx], cJSON)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// writeProbeResults writes the results in the JSON lines, or in the table
+// format for the text output.
+func writeProbeResults(w io.Writer, results []probeResult, output string) error {
+	if output == jsonOutput {
+		enc := json.NewEncoder(w)
+		for _, result := range results {
+			err := enc.Encode(result)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATALOG\tSTATUS\tLATENCY\tSIZE\tURI\tERROR")
+	for _, result := range results {
+		size := "-"
+		if result.Size != nil {
+			size = strconv.FormatInt(*result.Size, 10)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%dms\t%s\t%s\t%s\n",
+			result.Alias, result.Status, result.LatencyMS, size, result.URI, orDash(result.Error),
+		)
+	}
+
+	return tw.Flush()
+}
+
+func probeMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	catalog := new(listFlag)
+	fs.Var(catalog, "catalog", msgs.Sprintf(msgFlagCatalog))
+	order := new(listFlag)
+	fs.Var(order, "order", msgs.Sprintf(msgFlagOrder))
+	catalogOrder := new(listFlag)
+	fs.Var(catalogOrder, "catalog-order", msgs.Sprintf(msgFlagCatalogOrder))
+	conLimit := fs.Int("con-limit", defaultConLimit, msgs.Sprintf(msgFlagConLimit))
+	timeout := fs.Int64("timeout", defaultTimeout, msgs.Sprintf(msgFlagTimeout))
+	root := fs.String("root", "", msgs.Sprintf(msgFlagRoot))
+	fsRoot := fs.String("fs-root", "", msgs.Sprintf(msgFlagFSRoot))
+	fsStrict := fs.Bool("fs-strict", false, msgs.Sprintf(msgFlagFSStrict))
+	output := fs.String("output", jsonOutput, msgs.Sprintf(msgFlagProbeOutput))
+	netFlags := addNetworkFlags(fs)
+	fs.StringVar(&configFormat, "format", "", msgs.Sprintf(msgFlagFormat))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	fs.Usage = func() { log.Println(msgs.Sprintf(msgProbeUsage)) }
+	_ = fs.Parse(args)
+
+	flgs, ok := checkExclusive(catalog.String(), order.String(), catalogOrder.String())
+	if !ok {
+		log.Println(msgs.Sprintf(msgProbeUsage))
+		return 1
+	}
+
+	switch *output {
+	case jsonOutput, textOutput:
+	default:
+		log.Printf("%s: %v", *output, errUndefinedOutput)
+		return 1
+	}
+
+	httpClient, err := netFlags.httpClient()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	s3JSON, err := netFlags.s3()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	cntJSON, err := CreateCannectJSON(catalog.String(), order.String(), catalogOrder.String(), flgs)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	cfg := newRunConfig(defaultEnvOut, *conLimit, false)
+	cfg.HTTPClient = httpClient
+	cfg.S3 = s3JSON
+	cfg.FSGuard = newFSGuard(*fsRoot, *root, *fsStrict)
+	cfg.Root = *root
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(*timeout))
+	results, err := probe(ctx, cntJSON, cfg)
+	cancel()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	err = writeProbeResults(os.Stdout, results, *output)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	code := 0
+	for _, result := range results {
+		if result.Code > code {
+			code = result.Code
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+			{Alias: "missing.crt", URI: "file://testdata/test-probe-missing.crt", Category: "certificate"},
+			{Alias: "testdata", URI: "file://testdata", Category: "certificate"},
+			{Alias: "bundle.crt", URI: "workload:///run/spire/sockets/agent.sock?asset=bundle", Category: "certificate"},
+		},
+	}
+
+	cfg := runConfig{ConLimit: 2}
+	results, err := probe(context.Background(), jsn, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got, want []string
+	for _, result := range results {
+		got = append(got, result.Alias+" "+result.Status)
+		if (result.Code != 0) != (result.Error != "") {
+			t.Errorf("%s: Expected the code with the error but got: %#v", result.Alias, result)
+		}
+	}
+	want = []string{
+		"root-ca.crt " + probeAvailable,
+		"missing.crt " + probeUnavailable,
+		"testdata " + probeUnavailable,
+		"bundle.crt " + probeUnsupported,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	if results[0].Size == nil || *results[0].Size == 0 {
+		t.Errorf("Expected the size of the file but got: %v", results[0].Size)
+	}
+	if results[1].Code != exitUnavailable {
+		t.Errorf("Expected the code %d but got: %d", exitUnavailable, results[1].Code)
+	}
+}
+
+func TestWriteProbeResults(t *testing.T) {
+	t.Parallel()
+
+	size := int64(1024)
+	results := []probeResult{
+		{Alias: "root-ca.crt", URI: "file://root-ca.crt", Status: probeAvailable, LatencyMS: 1, Size: &size},
+		{Alias: "sub-ca.crt", URI: "s3://ca/sub-ca.crt", Status: probeUnavailable, Code: exitUnavailable, Error: "403 Forbidden"},
+	}
+
+	data := []struct {
+		testCase string
+		output   string
+		// want
+		want string
+	}{
+		{
+			"JSON",
+			jsonOutput,
+			`{"alias":"root-ca.crt","uri":"file://root-ca.crt","status":"available","code":0,"latencyMs":1,"size":1024}
+{"alias":"sub-ca.crt","uri":"s3://ca/sub-ca.crt","status":"unavailable","code":4,"latencyMs":0,"error":"403 Forbidden"}
+`,
+		},
+		{
+			"Text",
+			textOutput,
+			strings.Join([]string{
+				"CATALOG      STATUS       LATENCY  SIZE  URI                 ERROR",
+				"root-ca.crt  available    1ms      1024  file://root-ca.crt  -",
+				"sub-ca.crt   unavailable  0ms      -     s3://ca/sub-ca.crt  403 Forbidden",
+				"",
+			}, "\n"),
+		},
+	}
+
+	for _, d := range data {
+		var buf bytes.Buffer
+		err := writeProbeResults(&buf, results, d.output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(d.want, buf.String()); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+// orderResult is the result of the order to a destination. The digest is the
+// SHA-256 of the concatenated contents of the catalogs, and is empty if they
+// are not fetched. The result is changed if the order succeeded and the digest
+// differs from the previous one.
+type orderResult struct {
+	URI      string
+	Aliases  []string
+	Size     int
+	Digest   string
+	Changed  bool
+	Duration time.Duration
+	Err      error
+}
+
+// runReport collects the results of the orders and the warnings in a run. It
+// is safe to share the runReport among the orders.
+type runReport struct {
+	mu       sync.Mutex
+	results  []orderResult
+	warnings []string
+	previous map[string]string
+}
+
+func newRunReport() *runReport {
+	return &runReport{}
+}
+
+// withPrevious sets the digests of the destinations in the previous run, which
+// the changes are detected against.
+func (r *runReport) withPrevious(digests map[string]string) *runReport {
+	r.previous = digests
+	return r
+}
+
+func (r *runReport) add(result orderResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.results = append(r.results, result)
+}
+
+func (r *runReport) warn(msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.warnings = append(r.warnings, msg)
+}
+
+// Results returns the results sorted by the URIs, since the orders run
+// concurrently.
+func (r *runReport) Results() []orderResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := append([]orderResult(nil), r.results...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].URI < results[j].URI
+	})
+
+	return results
+}
+
+func (r *runReport) Warnings() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.warnings...)
+}
+
+// configResults returns the results of the destinations in the config without
+// fetching the catalogs.
+func configResults(cntJSON CAnnectJSON) []orderResult {
+	var results []orderResult
+	for _, oJSON := range cntJSON.Orders {
+		for _, uri := range oJSON.uris() {
+			results = append(results, orderResult{URI: uri, Aliases: oJSON.CatalogAliases})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].URI < results[j].URI
+	})
+
+	return results
+}
+
+// writeReport writes the results in the table format. The size and digest
+// are the ones of the contents that are, or would be in the -dry-run mode,
+// written to the destinations.
+func writeReport(w io.Writer, results []orderResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "DESTINATION\tALIASES\tSIZE\tSHA-256")
+	for _, result := range results {
+		size := "-"
+		if result.Digest != "" {
+			size = fmt.Sprint(result.Size)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			result.URI, strings.Join(result.Aliases, ","), size, orDash(result.Digest),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// reportOrder records the result of the order to the runReport.
+type reportOrder struct {
+	uriText  string
+	aliases  []string
+	order    Order
+	catalogs []orderapi.Catalog
+	report   *runReport
+}
+
+func newReportOrder(
+	uriText string, oJSON OrderJSON, sources []orderapi.Catalog, order Order, report *runReport,
+) (*reportOrder, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	rOrder := &reportOrder{
+		uriText:  uriText,
+		aliases:  oJSON.CatalogAliases,
+		order:    order,
+		catalogs: catalogs,
+		report:   report,
+	}
+
+	return rOrder, nil
+}
+
+func (r *reportOrder) Order(ctx context.Context) error {
+	start := time.Now()
+	result := orderResult{URI: r.uriText, Aliases: r.aliases}
+
+	h := sha256.New()
+	for _, catalog := range r.catalogs {
+		buf, err := catalog.Fetch(ctx)
+		if err != nil {
+			result.Err = err
+			result.Duration = time.Since(start)
+			r.report.add(result)
+			return err
+		}
+		h.Write(buf)
+		result.Size += len(buf)
+	}
+	result.Digest = hex.EncodeToString(h.Sum(nil))
+
+	result.Err = r.order.Order(ctx)
+	result.Changed = result.Err == nil && r.report.previous[r.uriText] != result.Digest
+	result.Duration = time.Since(start)
+	r.report.add(result)
+
+	return result.Err
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_Report(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URI: "file://testdata/test-report.out",
+				Fallbacks: []string{
+					"file://testdata/test-report-fallback.out",
+				},
+			},
+		},
+	}
+	t.Cleanup(func() { os.Remove("testdata/test-report.out") })
+
+	content, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5, Report: newRunReport()}
+	err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []orderResult{
+		{
+			URI:     "file://testdata/test-report.out",
+			Aliases: []string{"root-ca.crt"},
+			Size:    len(content),
+			Digest:  hex.EncodeToString(sum[:]),
+			Changed: true,
+		},
+	}
+	results := cfg.Report.Results()
+	for idx := range results {
+		if results[idx].Duration <= 0 {
+			t.Errorf("Expected the duration of %s but got: %v", results[idx].URI, results[idx].Duration)
+		}
+		results[idx].Duration = 0
+	}
+	if diff := cmp.Diff(results, want); diff != "" {
+		t.Error(diff)
+	}
+	if warnings := cfg.Report.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings but got: %v", warnings)
+	}
+}
+
+func TestRun_Report_NoWrite(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URIs: []string{
+					"file://testdata/test-report-dry-run.out",
+					"env://ROOT_CA",
+				},
+			},
+		},
+	}
+
+	envOut := "testdata/test-report-dry-run.env"
+	cfg := runConfig{EnvOut: envOut, ConLimit: 5, NoWrite: true, Report: newRunReport()}
+	err := run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"testdata/test-report-dry-run.out", envOut} {
+		_, err := os.Stat(p)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected %s is not written but got: %v", p, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	err = writeReport(&buf, cfg.Report.Results())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+
+	want := "DESTINATION                              ALIASES      SIZE  SHA-256\n" +
+		fmt.Sprintf("env://ROOT_CA                            root-ca.crt  %d  %x\n", len(content), sum) +
+		fmt.Sprintf("file://testdata/test-report-dry-run.out  root-ca.crt  %d  %x\n", len(content), sum)
+
+	if diff := cmp.Diff(buf.String(), want); diff != "" {
+		t.Error(diff)
+	}
+}
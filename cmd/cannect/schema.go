@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+	// The kinds of the config files, like the -catalog, -order and
+	// -catalog-order options.
+	catalogKind      = "catalog"
+	orderKind        = "order"
+	catalogOrderKind = "catalog-order"
+)
+
+var errUndefinedSchemaKind = errors.New("undefined schema kind")
+
+// schemaKinds maps the kinds to the types of the config files.
+var schemaKinds = map[string]reflect.Type{
+	catalogKind:      reflect.TypeOf(CatalogsJSON{}),
+	orderKind:        reflect.TypeOf(OrdersJSON{}),
+	catalogOrderKind: reflect.TypeOf(CAnnectJSON{}),
+}
+
+// schemaEnums maps "<type>.<field>" to the values allowed by the validation.
+var schemaEnums = map[string][]string{
+	"CatalogJSON.warn":            warnChecks,
+	"KeyPolicyJSON.allowedCurves": keyCurves,
+	"OrderJSON.seal":              {tpm2Seal},
+	"OrderJSON.verify":            {crossSignedVerify, chainVerify},
+	"OrderJSON.format":            {pemFormat, derFormat},
+	"JoinJSON.finalNewline": func() []string {
+		var values []string
+		for value := range finalNewlines {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		sort.Strings(values)
+		return values
+	}(),
+	"WebhookJSON.method":      {http.MethodPost, http.MethodPut, http.MethodPatch},
+	"InvalidateJSON.provider": {cloudFrontProvider, cloudCDNProvider},
+}
+
+// schemaExamples maps "<type>.<field>" to the typical values, for the fields
+// that also accept the values defined in the config, like the categories.
+var schemaExamples = map[string][]string{
+	"CatalogJSON.category": builtinCategories,
+}
+
+// configSchema returns the JSON Schema of the config file of the kind.
+func configSchema(kind string) (map[string]interface{}, error) {
+	t, ok := schemaKinds[kind]
+	if !ok {
+		return nil, errUndefinedSchemaKind
+	}
+
+	schema := jsonSchema(t)
+	schema["$schema"] = schemaDraft
+	schema["title"] = "cannect " + kind + " config"
+
+	return schema, nil
+}
+
+// jsonSchema returns the JSON Schema of the type. The properties are the ones
+// of the JSON tags, and required unless they are omitempty. The other
+// properties are not allowed, as the config files are decoded strictly.
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonSchema(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		var required []string
+		for idx := 0; idx < t.NumField(); idx++ {
+			field := t.Field(idx)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			property := jsonSchema(field.Type)
+			if enum, ok := schemaEnums[t.Name()+"."+name]; ok {
+				if items, ok := property["items"].(map[string]interface{}); ok {
+					items["enum"] = enum
+				} else {
+					property["enum"] = enum
+				}
+			}
+			if examples, ok := schemaExamples[t.Name()+"."+name]; ok {
+				property["examples"] = examples
+			}
+			properties[name] = property
+
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+
+		schema := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+
+	return map[string]interface{}{}
+}
+
+func schemaMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	kind := fs.String("kind", catalogOrderKind, msgs.Sprintf(msgFlagKind))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	fs.Usage = func() { log.Println(msgs.Sprintf(msgSchemaUsage)) }
+	_ = fs.Parse(args)
+
+	schema, err := configSchema(*kind)
+	if err != nil {
+		log.Printf("%s: %v", *kind, err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(schema)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConfigSchema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := configSchema(catalogOrderKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(schema["required"], []string{"catalogs", "orders"}); diff != "" {
+		t.Error(diff)
+	}
+
+	catalogs := schema["properties"].(map[string]interface{})["catalogs"].(map[string]interface{})
+	catalog := catalogs["items"].(map[string]interface{})
+	if catalog["additionalProperties"] != false {
+		t.Errorf("Expected no additional properties but got: %v", catalog["additionalProperties"])
+	}
+	if diff := cmp.Diff(catalog["required"], []string{"alias", "uri"}); diff != "" {
+		t.Error(diff)
+	}
+
+	category := catalog["properties"].(map[string]interface{})["category"].(map[string]interface{})
+	want := []string{
+		"certificate", "privateKey", "encPrivateKey", "CRL", "publicKey", "sshPublicKey", "sshPrivateKey",
+		"pkcs7", "derCertificate", "derCRL", "bundle",
+	}
+	if diff := cmp.Diff(category["examples"], want); diff != "" {
+		t.Error(diff)
+	}
+	if _, ok := category["enum"]; ok {
+		t.Error("Expected no enum of the category, since the categories can be defined")
+	}
+
+	_, err = configSchema("policy")
+	if !errors.Is(err, errUndefinedSchemaKind) {
+		t.Fatalf("Expected %#v error but got: %#v", errUndefinedSchemaKind, err)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+var errSelftestFailed = errors.New("self-test failed")
+
+// selftestPassphrase is the passphrase of the encrypted private key of the
+// self-test.
+const selftestPassphrase = "cannect-selftest"
+
+// selftestConfig writes the assets of the test CA into the "store" directory
+// in the directory, and returns the config ordering all of them into the
+// "certs", "private" and "crl" directories.
+func selftestConfig(ca *testca.CA, dir string) (CAnnectJSON, error) {
+	keyPEM, err := ca.Leaf.KeyPEM()
+	if err != nil {
+		return CAnnectJSON{}, err
+	}
+	encKeyPEM, err := ca.Leaf.EncryptedKeyPEM([]byte(selftestPassphrase))
+	if err != nil {
+		return CAnnectJSON{}, err
+	}
+	crlPEM, err := ca.Intermediate.CRLPEM()
+	if err != nil {
+		return CAnnectJSON{}, err
+	}
+
+	assets := []struct {
+		alias    string
+		category string
+		content  []byte
+	}{
+		{"root-ca.crt", "certificate", ca.Root.CertPEM()},
+		{"sub-ca.crt", "certificate", ca.Intermediate.CertPEM()},
+		{"server.crt", "certificate", ca.Leaf.CertPEM()},
+		{"server.key", "privateKey", keyPEM},
+		{"server.enc.key", "encPrivateKey", encKeyPEM},
+		{"sub-ca.crl", "CRL", crlPEM},
+	}
+
+	for _, d := range []string{"store", "certs", "private", "crl"} {
+		err = os.MkdirAll(path.Join(dir, d), 0o700)
+		if err != nil {
+			return CAnnectJSON{}, err
+		}
+	}
+
+	var cntJSON CAnnectJSON
+	for _, a := range assets {
+		err = os.WriteFile(path.Join(dir, "store", a.alias), a.content, 0o600)
+		if err != nil {
+			return CAnnectJSON{}, err
+		}
+
+		cntJSON.Catalogs = append(cntJSON.Catalogs, CatalogJSON{
+			Alias:    a.alias,
+			URI:      "file://store/" + a.alias,
+			Category: a.category,
+		})
+	}
+
+	cntJSON.Orders = []OrderJSON{
+		{CatalogAliases: []string{"server.crt", "sub-ca.crt"}, URI: "file://certs/fullchain.crt"},
+		{CatalogAliases: []string{"root-ca.crt"}, URI: "file://certs/ca.crt"},
+		{CatalogAliases: []string{"server.key"}, URI: "file://private/server.key"},
+		{CatalogAliases: []string{"server.enc.key"}, URI: "file://private/server.enc.key"},
+		{CatalogAliases: []string{"sub-ca.crl"}, URI: "file://crl/sub-ca.crl"},
+	}
+
+	return cntJSON, nil
+}
+
+// readPEMs reads the PEM blocks of the file in the directory.
+func readPEMs(dir, p string) ([]*pem.Block, error) {
+	buf, err := os.ReadFile(path.Join(dir, p))
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []*pem.Block
+	for {
+		var block *pem.Block
+		block, buf = pem.Decode(buf)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("%s: %w", p, errSelftestFailed)
+	}
+
+	return blocks, nil
+}
+
+// verifySelftest checks the destinations of the config of selftestConfig: the
+// chain is verified by the root CA, the private keys are the one of the leaf,
+// and the CRL is issued by the intermediate CA.
+func verifySelftest(dir string) error {
+	var certs []*x509.Certificate
+	for _, p := range []string{"certs/fullchain.crt", "certs/ca.crt"} {
+		blocks, err := readPEMs(dir, p)
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %w", p, err, errSelftestFailed)
+			}
+			certs = append(certs, cert)
+		}
+	}
+
+	// Check the full chain is the leaf and the intermediate CA
+	if len(certs) != 3 {
+		return fmt.Errorf("%s: %w", "certs/fullchain.crt", errSelftestFailed)
+	}
+	leaf, intermediate, root := certs[0], certs[1], certs[2]
+
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates.AddCert(intermediate)
+	_, err := leaf.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: roots, Intermediates: intermediates})
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", "certs/fullchain.crt", err, errSelftestFailed)
+	}
+
+	keyBlocks, err := readPEMs(dir, "private/server.key")
+	if err != nil {
+		return err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(keyBlocks[0].Bytes)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", "private/server.key", err, errSelftestFailed)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("%s: %w", "private/server.key", errSelftestFailed)
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(leaf.PublicKey) {
+		return fmt.Errorf("%s: %w", "private/server.key", errSelftestFailed)
+	}
+
+	encKeyPEM, err := os.ReadFile(path.Join(dir, "private/server.enc.key"))
+	if err != nil {
+		return err
+	}
+	der, err := testca.DecryptKeyPEM(encKeyPEM, []byte(selftestPassphrase))
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", "private/server.enc.key", err, errSelftestFailed)
+	}
+	if !bytes.Equal(der, keyBlocks[0].Bytes) {
+		return fmt.Errorf("%s: %w", "private/server.enc.key", errSelftestFailed)
+	}
+
+	crlBlocks, err := readPEMs(dir, "crl/sub-ca.crl")
+	if err != nil {
+		return err
+	}
+	crl, err := x509.ParseRevocationList(crlBlocks[0].Bytes)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", "crl/sub-ca.crl", err, errSelftestFailed)
+	}
+	err = crl.CheckSignatureFrom(intermediate)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", "crl/sub-ca.crl", err, errSelftestFailed)
+	}
+
+	return nil
+}
+
+// selftest fetches, checks and orders the assets of the test CA in the
+// directory through the same pipeline as the config files, and verifies the
+// destinations.
+func selftest(ctx context.Context, dir string, cfg runConfig, l *log.Logger) (CAnnectJSON, error) {
+	ca, err := testca.New(time.Now())
+	if err != nil {
+		return CAnnectJSON{}, err
+	}
+
+	cntJSON, err := selftestConfig(ca, dir)
+	if err != nil {
+		return cntJSON, err
+	}
+
+	err = validate(cntJSON)
+	if err != nil {
+		return cntJSON, err
+	}
+
+	cfg.Root = dir
+	err = run(ctx, cntJSON, cfg, l)
+	if err != nil {
+		return cntJSON, err
+	}
+
+	return cntJSON, verifySelftest(dir)
+}
+
+func selftestMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	out := fs.String("out", "", msgs.Sprintf(msgFlagOut))
+	timeout := fs.Int64("timeout", defaultTimeout, msgs.Sprintf(msgFlagTimeout))
+	fips := fs.Bool("fips", false, msgs.Sprintf(msgFlagFIPS))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	fs.Usage = func() { log.Println(msgs.Sprintf(msgSelftestUsage)) }
+	_ = fs.Parse(args)
+
+	dir := *out
+	var err error
+	if dir == "" {
+		dir, err = os.MkdirTemp("", "cannect-selftest-")
+		if err == nil {
+			defer os.RemoveAll(dir)
+		}
+	} else {
+		err = os.MkdirAll(dir, 0o700)
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+	cfg := newRunConfig(path.Join(dir, "cannect.env"), defaultConLimit, *fips)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(*timeout))
+	cntJSON, err := selftest(ctx, dir, cfg, logger)
+	cancel()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	log.Println(msgs.Sprintf(msgSelftestPassed, len(cntJSON.Catalogs), len(cntJSON.Orders)))
+	return 0
+}
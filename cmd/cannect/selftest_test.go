@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+func TestSelftest(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestSelftest")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cfg := runConfig{EnvOut: path.Join(dir, "envout.env"), ConLimit: 5, FIPS: true}
+	cntJSON, err := selftest(context.TODO(), dir, cfg, log.New(new(bytes.Buffer), "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cntJSON.Catalogs) != 6 || len(cntJSON.Orders) != 5 {
+		t.Errorf("Expected 6 catalogs and 5 orders but got: %d and %d", len(cntJSON.Catalogs), len(cntJSON.Orders))
+	}
+
+	// The destinations of another CA are not verified.
+	other, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := other.Leaf.KeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase string
+		p        string
+		content  []byte
+	}{
+		{"NG:Root CA", "certs/ca.crt", other.Root.CertPEM()},
+		{"NG:Private Key", "private/server.key", otherKey},
+		{"NG:Empty", "crl/sub-ca.crl", nil},
+	}
+
+	for _, d := range data {
+		name := path.Join(dir, d.p)
+		orig, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(name, d.content, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = verifySelftest(dir)
+		if !errors.Is(err, errSelftestFailed) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, errSelftestFailed, err)
+		}
+
+		err = os.WriteFile(name, orig, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
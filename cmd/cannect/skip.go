@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	metricsapi "github.com/yuxki/cannect/pkg/metrics"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+// destination reports whether it already has the contents of the order.
+type destination interface {
+	Unchanged(context.Context) (bool, error)
+}
+
+// skipOrder runs the inner order only if the destination does not have the
+// contents already, so the file keeps its modification time, and the hook is
+// not run for nothing. It is used with the -skip-unchanged option.
+type skipOrder struct {
+	uriText string
+	order   Order
+	dst     destination
+	l       *log.Logger
+}
+
+// newSkipOrder returns the order skipping the unchanged destination. The
+// destinations of the "file" scheme without seal, and the "s3" scheme are
+// compared, and the order is returned as it is for the others.
+func newSkipOrder(
+	uriText string, oJSON OrderJSON, sources []orderapi.Catalog, order Order, cfg runConfig, l *log.Logger,
+) (Order, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	var dst destination
+	switch schemeapi.Of(uriText) {
+	case "file":
+		if oJSON.Seal != "" {
+			return order, nil
+		}
+		uri, err := newFSURI(uriText, cfg.Root)
+		if err != nil {
+			return nil, err
+		}
+		dst = orderapi.NewFSOrder(uri, catalogs)
+	case "s3":
+		uri, err := uriapi.NewS3URI(uriText)
+		if err != nil {
+			return nil, err
+		}
+		dst = orderapi.NewS3Order(uri, catalogs).WithOptions(oJSON.S3.options(cfg.S3)...)
+	default:
+		return order, nil
+	}
+
+	return &skipOrder{uriText: uriText, order: order, dst: dst, l: l}, nil
+}
+
+func (s *skipOrder) Order(ctx context.Context) error {
+	unchanged, err := s.dst.Unchanged(ctx)
+	if err != nil {
+		return err
+	}
+
+	if unchanged {
+		s.l.Print(msgs.Sprintf(msgUnchanged, s.uriText))
+		metricsapi.MarkSkipped(ctx)
+		return nil
+	}
+
+	return s.order.Order(ctx)
+}
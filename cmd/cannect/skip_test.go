@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_SkipUnchanged(t *testing.T) {
+	t.Parallel()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skip("sh is not available")
+	}
+
+	dir := "testdata/TestRun_SkipUnchanged"
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	rootCA, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unchanged := path.Join(dir, "unchanged.crt")
+	changed := path.Join(dir, "changed.crt")
+	hookOut := path.Join(dir, "hook.out")
+	err = os.WriteFile(unchanged, rootCA, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(changed, []byte("old"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	err = os.Chtimes(unchanged, modTime, modTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + unchanged},
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + changed},
+		},
+		Hook: []string{sh, "-c", `echo "$CANNECT_ORDER_URI" >> ` + hookOut},
+	}
+
+	var logs bytes.Buffer
+	cfg := newRunConfig(path.Join(dir, "cannect.env"), 1, false)
+	cfg.SkipUnchanged = true
+	err = run(context.TODO(), jsn, cfg, log.New(&logs, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(logs.Bytes(), []byte(msgs.Sprintf(msgUnchanged, "file://"+unchanged))) {
+		t.Errorf("Expected %s is unchanged:\n%s", unchanged, logs.String())
+	}
+
+	info, err := os.Stat(unchanged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("Expected the modification time %s but got: %s", modTime, info.ModTime())
+	}
+
+	got, err := os.ReadFile(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, rootCA); diff != "" {
+		t.Error(diff)
+	}
+
+	// The hook is run only for the changed destination.
+	hooks, err := os.ReadFile(hookOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(hooks), "file://"+changed+"\n"); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	stateapi "github.com/yuxki/cannect/pkg/state"
+)
+
+// stateTimeout limits each load and save of the state.
+const stateTimeout = 10 * time.Second
+
+// stateJSON is the state saved between the runs with the -state option.
+type stateJSON struct {
+	// Digests are the digests of the contents written to the destinations,
+	// by the URIs of the destinations.
+	Digests map[string]string `json:"digests"`
+}
+
+// newStateStore returns the Store of the URI. The s3 and the dynamodb schemes
+// call the APIs with the client and the s3 defaults of the run.
+func newStateStore(uriText string, cfg runConfig) (stateapi.Store, error) {
+	store, err := stateapi.New(uriText)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s := store.(type) {
+	case *stateapi.S3Store:
+		optFns := (*S3JSON)(nil).options(cfg.S3)
+		if cfg.HTTPClient != nil {
+			optFns = append(optFns, func(o *s3.Options) {
+				o.HTTPClient = cfg.HTTPClient
+			})
+		}
+		s.WithOptions(optFns...)
+	case *stateapi.DynamoDBStore:
+		s.WithHTTPClient(cfg.HTTPClient)
+	}
+
+	return store, nil
+}
+
+// loadDigests returns the digestCache with the digests saved in the store.
+// It is empty if nothing is saved yet.
+func loadDigests(ctx context.Context, store stateapi.Store) (*digestCache, error) {
+	ctx, cancel := context.WithTimeout(ctx, stateTimeout)
+	defer cancel()
+
+	digests := newDigestCache()
+
+	buf, err := store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if buf == nil {
+		return digests, nil
+	}
+
+	var sJSON stateJSON
+	err = json.Unmarshal(buf, &sJSON)
+	if err != nil {
+		return nil, fmt.Errorf("state: %w", err)
+	}
+
+	for uriText, digest := range sJSON.Digests {
+		digests.set(uriText, digest)
+	}
+
+	return digests, nil
+}
+
+// saveDigests saves the digests to the store. It is not bound to the context
+// of the run, so the digests are saved even if the run timed out.
+func saveDigests(store stateapi.Store, digests *digestCache) error {
+	buf, err := json.Marshal(stateJSON{Digests: digests.all()})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+
+	return store.Save(ctx, buf)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	stateapi "github.com/yuxki/cannect/pkg/state"
+)
+
+func TestRun_State(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_State"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + dir + "/root-ca.crt"},
+		},
+	}
+
+	content, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := newStateStore(path.Join(dir, "state.json"), runConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase string
+		// The content of the destination before the run.
+		before []byte
+		want   []byte
+	}{
+		{"OK:first", nil, content},
+		// The digest saved by the first run skips the write.
+		{"OK:unchanged", []byte("modified by others"), []byte("modified by others")},
+	}
+
+	// The runs are sequential, since the second one loads the state of the first.
+	for _, d := range data {
+		if d.before != nil {
+			err = os.WriteFile(path.Join(dir, "root-ca.crt"), d.before, 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cfg := runConfig{EnvOut: dir + "/envout.env", ConLimit: 5}
+		cfg.Digests, err = loadDigests(context.TODO(), store)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = saveDigests(store, cfg.Digests)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(path.Join(dir, "root-ca.crt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(d.want, got); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+}
+
+func TestLoadDigests(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestLoadDigests"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	data := []struct {
+		testCase string
+		state    string
+		// want
+		digests map[string]string
+		err     bool
+	}{
+		{"OK:no-state", "", map[string]string{}, false},
+		{
+			"OK:digests", `{"digests":{"file://ca.crt":"e3b0c442"}}`,
+			map[string]string{"file://ca.crt": "e3b0c442"}, false,
+		},
+		{"NG:invalid", `{"digests":`, nil, true},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			name := path.Join(dir, d.testCase+".json")
+			if d.state != "" {
+				err := os.WriteFile(name, []byte(d.state), 0o600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			digests, err := loadDigests(context.TODO(), stateapi.NewFileStore(name))
+			if (err != nil) != d.err {
+				t.Fatalf("Expected error %t but got: %v", d.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(d.digests, digests.all()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestNewStateStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := newStateStore("vault://kv/data/state", runConfig{})
+	if !errors.Is(err, stateapi.ErrUnsupportedScheme) {
+		t.Errorf("Expected %#v error but got: %#v", stateapi.ErrUnsupportedScheme, err)
+	}
+}
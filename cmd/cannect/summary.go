@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// The suffix of the summary file compressed with gzip.
+const gzipSuffix = ".gz"
+
+// runSummary is the machine-readable summary of a run, written with the
+// -summary option to be archived as the audit evidence of the distribution.
+type runSummary struct {
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt"`
+	NoWrite    bool           `json:"noWrite"`
+	Error      string         `json:"error,omitempty"`
+	Orders     []orderSummary `json:"orders"`
+	Usage      *runUsage      `json:"usage,omitempty"`
+	Warnings   []string       `json:"warnings,omitempty"`
+}
+
+type orderSummary struct {
+	Destination string   `json:"destination"`
+	Aliases     []string `json:"aliases"`
+	Bytes       int      `json:"bytes"`
+	SHA256      string   `json:"sha256,omitempty"`
+	Changed     bool     `json:"changed"`
+	DurationMs  int64    `json:"durationMs"`
+	Error       string   `json:"error,omitempty"`
+}
+
+func newRunSummary(
+	results []orderResult, startedAt, finishedAt time.Time, noWrite bool, runErr error,
+) runSummary {
+	summary := runSummary{
+		StartedAt:  startedAt.UTC(),
+		FinishedAt: finishedAt.UTC(),
+		NoWrite:    noWrite,
+		Orders:     make([]orderSummary, 0, len(results)),
+	}
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+
+	for _, result := range results {
+		oSummary := orderSummary{
+			Destination: result.URI,
+			Aliases:     result.Aliases,
+			Bytes:       result.Size,
+			SHA256:      result.Digest,
+			Changed:     result.Changed,
+			DurationMs:  result.Duration.Milliseconds(),
+		}
+		if result.Err != nil {
+			oSummary.Error = result.Err.Error()
+		}
+		summary.Orders = append(summary.Orders, oSummary)
+	}
+
+	return summary
+}
+
+// writeSummary writes the summary in JSON to the file. The file is compressed
+// with gzip if its name ends with ".gz", so the summaries kept on the large
+// fleets take less disk space.
+func writeSummary(name string, summary runSummary) error {
+	buf, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	if strings.HasSuffix(name, gzipSuffix) {
+		var gz bytes.Buffer
+
+		w := gzip.NewWriter(&gz)
+		_, err = w.Write(buf)
+		if err != nil {
+			return err
+		}
+		err = w.Close()
+		if err != nil {
+			return err
+		}
+
+		buf = gz.Bytes()
+	}
+
+	return os.WriteFile(name, buf, 0o666)
+}
+
+// decompress returns the content decompressed if it is compressed with gzip,
+// or the content as it is, so the uncompressed summaries are still read.
+func decompress(content []byte) ([]byte, error) {
+	if !bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		return content, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// readSummaryDigests returns the digests of the destinations written
+// successfully in the previous summary, so the changes are detected against
+// them. The summaries of the runs without writing, and the missing file are
+// ignored.
+func readSummaryDigests(name string) (map[string]string, error) {
+	buf, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err = decompress(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var summary runSummary
+	err = json.Unmarshal(buf, &summary)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if summary.NoWrite {
+		return nil, nil
+	}
+
+	digests := make(map[string]string)
+	for _, oSummary := range summary.Orders {
+		if oSummary.Error == "" {
+			digests[oSummary.Destination] = oSummary.SHA256
+		}
+	}
+
+	return digests, nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_Summary(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_Summary"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + dir + "/root-ca.crt"},
+		},
+	}
+
+	content, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+
+	name := path.Join(dir, "summary.json")
+	data := []struct {
+		testCase string
+		changed  bool
+	}{
+		{"OK:first", true},
+		{"OK:unchanged", false},
+	}
+
+	// The runs are sequential, since the second one reads the summary of the first.
+	for _, d := range data {
+		digests, err := readSummaryDigests(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := runConfig{EnvOut: dir + "/envout.env", ConLimit: 5, Report: newRunReport().withPrevious(digests)}
+		startedAt := time.Now()
+		err = run(context.TODO(), jsn, cfg, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = writeSummary(name, newRunSummary(cfg.Report.Results(), startedAt, time.Now(), cfg.NoWrite, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got runSummary
+		err = json.Unmarshal(buf, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Error != "" || got.FinishedAt.Before(got.StartedAt) {
+			t.Errorf("%s: Unexpected summary of the run: %+v", d.testCase, got)
+		}
+		if len(got.Orders) != 1 {
+			t.Fatalf("%s: Expected an order but got: %+v", d.testCase, got.Orders)
+		}
+
+		got.Orders[0].DurationMs = 0
+		want := orderSummary{
+			Destination: "file://" + dir + "/root-ca.crt",
+			Aliases:     []string{"root-ca.crt"},
+			Bytes:       len(content),
+			SHA256:      hex.EncodeToString(sum[:]),
+			Changed:     d.changed,
+		}
+		if diff := cmp.Diff(got.Orders[0], want); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+}
+
+func TestNewRunSummary(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	results := []orderResult{
+		{
+			URI:      "s3://ca-bucket/root-ca.crt",
+			Aliases:  []string{"root-ca.crt"},
+			Duration: 1500 * time.Millisecond,
+			Err:      errors.New("access denied"),
+		},
+	}
+
+	got := newRunSummary(results, startedAt, startedAt.Add(2*time.Second), false, errors.New("access denied"))
+	want := runSummary{
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(2 * time.Second),
+		Error:      "access denied",
+		Orders: []orderSummary{
+			{
+				Destination: "s3://ca-bucket/root-ca.crt",
+				Aliases:     []string{"root-ca.crt"},
+				DurationMs:  1500,
+				Error:       "access denied",
+			},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReadSummaryDigests(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestReadSummaryDigests"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	orders := []orderSummary{
+		{Destination: "file://etc/pki/root-ca.crt", SHA256: "ab"},
+		{Destination: "s3://ca-bucket/root-ca.crt", SHA256: "ab", Error: "access denied"},
+	}
+
+	data := []struct {
+		testCase string
+		content  string
+		want     map[string]string
+		err      bool
+	}{
+		{
+			"OK:written",
+			string(mustMarshal(t, runSummary{Orders: orders})),
+			map[string]string{"file://etc/pki/root-ca.crt": "ab"},
+			false,
+		},
+		{"OK:no write", string(mustMarshal(t, runSummary{NoWrite: true, Orders: orders})), nil, false},
+		{"OK:not exist", "", nil, false},
+		{
+			"OK:gzip",
+			string(mustGzip(t, mustMarshal(t, runSummary{Orders: orders}))),
+			map[string]string{"file://etc/pki/root-ca.crt": "ab"},
+			false,
+		},
+		{"NG:invalid", "{", nil, true},
+		{"NG:invalid gzip", "\x1f\x8b", nil, true},
+	}
+
+	for idx, d := range data {
+		d := d
+		name := path.Join(dir, fmt.Sprintf("%d.json", idx))
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			if d.content != "" {
+				err := os.WriteFile(name, []byte(d.content), 0o600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := readSummaryDigests(name)
+			if (err != nil) != d.err {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(got, d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestWriteSummary_Gzip(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestWriteSummary_Gzip"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	summary := runSummary{Orders: []orderSummary{{Destination: "file://etc/pki/root-ca.crt", SHA256: "ab"}}}
+	for _, name := range []string{"summary.json", "summary.json.gz"} {
+		name := path.Join(dir, name)
+		err := writeSummary(name, summary)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gzipped := buf[0] == 0x1f && buf[1] == 0x8b; gzipped != strings.HasSuffix(name, ".gz") {
+			t.Errorf("%s: Expected compressed only with .gz but got: %v", name, gzipped)
+		}
+
+		got, err := readSummaryDigests(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, map[string]string{"file://etc/pki/root-ca.crt": "ab"}); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func mustGzip(t *testing.T, content []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errInvalidTenantName = errors.New("invalid tenant name")
+	errTenantDuplicated  = errors.New("tenant name must not be duplicated")
+	errNoTenantRoot      = errors.New("root of tenant must be specified")
+	errNoTenantConfig    = errors.New("config files of tenant must be specified")
+	errOutsideTenantRoot = errors.New("path is outside the root of tenant")
+	errInvalidEnvLine    = errors.New("invalid line of env file")
+)
+
+// tenantRestartDelay is the delay before restarting the process of the tenant
+// exited unexpectedly.
+const tenantRestartDelay = 10 * time.Second
+
+var (
+	tenantNameReg = regexp.MustCompile("^[-_a-zA-Z0-9]+$")
+	driveReg      = regexp.MustCompile("^[a-zA-Z]:")
+)
+
+// TenantsJSON configures the tenants served by the watch command, so one
+// cannect service can serve the distribution pipelines of several teams.
+type TenantsJSON struct {
+	Tenants []TenantJSON `json:"tenants"`
+}
+
+// TenantJSON configures a tenant. The paths of the config files are in the
+// Root, and the EnvFile has the credentials of the tenant, like GITHUB_TOKEN,
+// in KEY=VALUE lines. The Policy is the path of the policy file restricting
+// the config files of the tenant further, and the Quota limits each sync of
+// the tenant instead of the -quota option.
+type TenantJSON struct {
+	Name         string     `json:"name"`
+	Root         string     `json:"root"`
+	Catalog      []string   `json:"catalog,omitempty"`
+	Order        []string   `json:"order,omitempty"`
+	CatalogOrder []string   `json:"catalogOrder,omitempty"`
+	EnvFile      string     `json:"envFile,omitempty"`
+	Policy       string     `json:"policy,omitempty"`
+	Quota        *QuotaJSON `json:"quota,omitempty"`
+}
+
+// tenantPolicy isolates the tenants from each other and from the host. The
+// schemes writing the local paths outside the root, and sharing the
+// credentials of the host, like the service account of Kubernetes, are not
+// allowed, and so are the hooks, the commands, and the endpoints and the roles
+// of s3.
+var tenantPolicy = PolicyJSON{
+	Catalogs: []string{"file://**", "github://**", "s3://**"},
+	Orders: []string{
+		"file://**", "env://**", "stdout://**", "vault://**", "github://**", "secretsmanager://**", "ssm://**",
+		"s3://**", "gcs://**", "azblob://**", "https://**", "dns://**", "mqtt://**", "mqtts://**", "nats://**",
+	},
+	Hooks:    []string{},
+	Commands: []string{},
+}
+
+// tenantEnvKeys are the environment variables passed from the service to the
+// processes of the tenants. The others, like the credentials of the service,
+// are not passed.
+var tenantEnvKeys = []string{
+	"PATH", "USER", "TMPDIR", "TEMP", "TMP", "SYSTEMROOT", "LANG", "LC_ALL", "LC_MESSAGES", "TZ",
+	"SSL_CERT_FILE", "SSL_CERT_DIR", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy",
+	"no_proxy", sourceDateEpochEnv,
+}
+
+// tenantIsolationEnv are the environment variables set for the processes of
+// the tenants, so the tenant without its own credentials fails instead of
+// using the ones of the host, like the shared files of AWS and the metadata
+// of EC2. The HOME is the root of the tenant.
+var tenantIsolationEnv = []string{
+	"AWS_EC2_METADATA_DISABLED=true", "AWS_SHARED_CREDENTIALS_FILE=" + os.DevNull, "AWS_CONFIG_FILE=" + os.DevNull,
+}
+
+func loadTenants(name string) (TenantsJSON, error) {
+	var tJSON TenantsJSON
+
+	file, err := os.Open(name)
+	if err != nil {
+		return tJSON, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Println(msgs.Sprintf(msgCloseFailed, err))
+		}
+	}()
+
+	err = json.NewDecoder(file).Decode(&tJSON)
+	if err != nil {
+		return tJSON, err
+	}
+
+	return tJSON, tJSON.validate()
+}
+
+func (t TenantsJSON) validate() error {
+	names := make(map[string]struct{}, len(t.Tenants))
+	for _, tenant := range t.Tenants {
+		if !tenantNameReg.MatchString(tenant.Name) {
+			// Check the name can be a prefix of the logs
+			return fmt.Errorf("%s: %w", tenant.Name, errInvalidTenantName)
+		}
+
+		if _, ok := names[tenant.Name]; ok {
+			// Check the tenant is unique
+			return fmt.Errorf("%s: %w", tenant.Name, errTenantDuplicated)
+		}
+		names[tenant.Name] = struct{}{}
+
+		if tenant.Root == "" {
+			// Check the root confining the files of the tenant
+			return fmt.Errorf("%s: %w", tenant.Name, errNoTenantRoot)
+		}
+
+		_, ok := checkExclusive(
+			strings.Join(tenant.Catalog, ","), strings.Join(tenant.Order, ","), strings.Join(tenant.CatalogOrder, ","),
+		)
+		if !ok {
+			// Check the config files are specified like the options
+			return fmt.Errorf("%s: %w", tenant.Name, errNoTenantConfig)
+		}
+
+		for _, paths := range [][]string{tenant.Catalog, tenant.Order, tenant.CatalogOrder} {
+			for _, p := range paths {
+				_, err := tenantPath(tenant.Root, p)
+				if err != nil {
+					// Check the config files are in the root
+					return fmt.Errorf("%s: %w", tenant.Name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// tenantPath returns the path in the root of the tenant.
+func tenantPath(root, p string) (string, error) {
+	p = path.Clean(strings.ReplaceAll(p, `\`, "/"))
+	if p == ".." || strings.HasPrefix(p, "../") || path.IsAbs(p) || driveReg.MatchString(p) {
+		return "", fmt.Errorf("%s: %w", p, errOutsideTenantRoot)
+	}
+
+	return path.Join(strings.ReplaceAll(root, `\`, "/"), p), nil
+}
+
+// args returns the arguments of the watch command for the tenant. The options
+// set for the service are passed, except the ones of the config files and
+// the paths, which are confined in the root.
+func (t TenantJSON) args(fs *flag.FlagSet) ([]string, error) {
+	args := []string{"watch", "-tenant", t.Name, "-root", t.Root, "-fs-strict", "-env-out", path.Join(t.Root, "cannect.env")}
+
+	for _, opt := range []struct {
+		name  string
+		paths []string
+	}{
+		{"catalog", t.Catalog},
+		{"order", t.Order},
+		{"catalog-order", t.CatalogOrder},
+	} {
+		for _, p := range opt.paths {
+			tp, err := tenantPath(t.Root, p)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "-"+opt.name, tp)
+		}
+	}
+
+	if t.Policy != "" {
+		args = append(args, "-policy", t.Policy)
+	}
+
+	if t.Quota != nil {
+		args = append(args, "-quota", t.Quota.String())
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		// The metrics and the states of the tenants would collide in the
+		// files and the address.
+		case "tenants", "tenant", "catalog", "order", "catalog-order", "root", "fs-root", "fs-strict", "env-out", "policy",
+			"metrics-textfile", "metrics-addr", "state":
+			return
+		case "quota":
+			if t.Quota != nil {
+				return
+			}
+		}
+		args = append(args, "-"+f.Name+"="+f.Value.String())
+	})
+
+	return args, nil
+}
+
+// env returns the environment variables of the process of the tenant. They
+// are the allowed ones of the service, the ones isolating the tenant from the
+// credentials of the host, and the ones in the EnvFile, which may override
+// the others.
+func (t TenantJSON) env(environ []string) ([]string, error) {
+	allowed := make(map[string]struct{}, len(tenantEnvKeys))
+	for _, key := range tenantEnvKeys {
+		allowed[key] = struct{}{}
+	}
+
+	var env []string
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := allowed[key]; ok {
+			env = append(env, kv)
+		}
+	}
+
+	home, err := filepath.Abs(t.Root)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, "HOME="+home)
+	env = append(env, tenantIsolationEnv...)
+
+	if t.EnvFile == "" {
+		return env, nil
+	}
+
+	file, err := os.Open(t.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Println(msgs.Sprintf(msgCloseFailed, err))
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s: %w", t.EnvFile, errInvalidEnvLine)
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env = append(env, key+"="+value)
+	}
+
+	return env, scanner.Err()
+}
+
+// superviseTenants runs the watch command of each tenant in its own process,
+// so the credentials, the states and the failures of the tenants are
+// isolated. The process exited unexpectedly is restarted after the delay.
+func superviseTenants(ctx context.Context, tJSON TenantsJSON, fs *flag.FlagSet, logger *log.Logger) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmds := make([]func() *exec.Cmd, 0, len(tJSON.Tenants))
+	for _, tenant := range tJSON.Tenants {
+		args, err := tenant.args(fs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", tenant.Name, err)
+		}
+		env, err := tenant.env(os.Environ())
+		if err != nil {
+			return fmt.Errorf("%s: %w", tenant.Name, err)
+		}
+
+		cmds = append(cmds, func() *exec.Cmd {
+			cmd := exec.Command(executable, args...)
+			cmd.Env = env
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			return cmd
+		})
+	}
+
+	var wg sync.WaitGroup
+	for idx := range cmds {
+		wg.Add(1)
+		go func(name string, newCmd func() *exec.Cmd) {
+			defer wg.Done()
+			superviseTenant(ctx, name, newCmd, logger)
+		}(tJSON.Tenants[idx].Name, cmds[idx])
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func superviseTenant(ctx context.Context, name string, newCmd func() *exec.Cmd, logger *log.Logger) {
+	for {
+		err := runTenant(ctx, newCmd())
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Print(msgs.Sprintf(msgTenantExited, name, tenantRestartDelay, err))
+
+		timer := time.NewTimer(tenantRestartDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// runTenant runs the process until it exits. The process is interrupted when
+// the context is done, so it completes the sync in progress.
+func runTenant(ctx context.Context, cmd *exec.Cmd) error {
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := cmd.Process.Signal(os.Interrupt); err != nil {
+				// The interrupt is not supported on Windows.
+				_ = cmd.Process.Kill()
+			}
+		case <-done:
+		}
+	}()
+
+	return cmd.Wait()
+}
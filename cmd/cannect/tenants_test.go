@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTenantsJSON_Validate(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		tenant   TenantJSON
+		// want
+		err error
+	}{
+		{"OK", TenantJSON{Name: "team-a", Root: "teams/a", CatalogOrder: []string{"cannect.json"}}, nil},
+		{"NG:Name", TenantJSON{Name: "team a", Root: "teams/a", CatalogOrder: []string{"cannect.json"}}, errInvalidTenantName},
+		{"NG:No Root", TenantJSON{Name: "team-a", CatalogOrder: []string{"cannect.json"}}, errNoTenantRoot},
+		{"NG:No Config", TenantJSON{Name: "team-a", Root: "teams/a"}, errNoTenantConfig},
+		{
+			"NG:Exclusive Config",
+			TenantJSON{Name: "team-a", Root: "teams/a", Catalog: []string{"catalog.json"}, CatalogOrder: []string{"cannect.json"}},
+			errNoTenantConfig,
+		},
+		{"NG:Outside Root", TenantJSON{Name: "team-a", Root: "teams/a", CatalogOrder: []string{"../b/cannect.json"}}, errOutsideTenantRoot},
+		{"NG:Absolute Path", TenantJSON{Name: "team-a", Root: "teams/a", CatalogOrder: []string{"/etc/cannect.json"}}, errOutsideTenantRoot},
+	}
+
+	for _, d := range data {
+		err := TenantsJSON{Tenants: []TenantJSON{d.tenant}}.validate()
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+	}
+
+	tenant := TenantJSON{Name: "team-a", Root: "teams/a", CatalogOrder: []string{"cannect.json"}}
+	err := TenantsJSON{Tenants: []TenantJSON{tenant, tenant}}.validate()
+	if !errors.Is(err, errTenantDuplicated) {
+		t.Errorf("Expected %#v error but got: %#v", errTenantDuplicated, err)
+	}
+}
+
+func TestTenantJSON_Args(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.String("tenants", "", "")
+	fs.String("root", "", "")
+	fs.String("interval", "", "")
+	fs.String("log-format", "", "")
+	fs.String("quota", "", "")
+	fs.String("metrics-addr", "", "")
+	err := fs.Parse([]string{
+		"-tenants", "tenants.json", "-root", "/", "-interval", "30m", "-quota", "fetches=100", "-metrics-addr", ":9464",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tenant := TenantJSON{
+		Name:    "team-a",
+		Root:    "teams/a",
+		Catalog: []string{"catalog.json"},
+		Order:   []string{"order.json", "sub/order.json"},
+		Policy:  "policies/a.json",
+		Quota:   &QuotaJSON{Fetches: 10, Writes: 5},
+	}
+	got, err := tenant.args(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"watch", "-tenant", "team-a", "-root", "teams/a", "-fs-strict", "-env-out", "teams/a/cannect.env",
+		"-catalog", "teams/a/catalog.json", "-order", "teams/a/order.json", "-order", "teams/a/sub/order.json",
+		"-policy", "policies/a.json", "-quota", "fetches=10,writes=5", "-interval=30m",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestTenantJSON_Env(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestTenantJSON_Env")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	envFile := path.Join(dir, "team-a.env")
+	err = os.WriteFile(envFile, []byte("# credentials of team-a\n\nGITHUB_TOKEN=ghp_a\nexport AWS_PROFILE=\"team-a\"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidFile := path.Join(dir, "invalid.env")
+	err = os.WriteFile(invalidFile, []byte("GITHUB_TOKEN\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	environ := []string{
+		"PATH=/usr/bin", "HOME=/root", "GITHUB_TOKEN=ghp_service", "AWS_SECRET_ACCESS_KEY=secret", "TZ=UTC",
+	}
+
+	got, err := TenantJSON{Name: "team-a", Root: dir, EnvFile: envFile}.env(environ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]string{"PATH=/usr/bin", "TZ=UTC", "HOME=" + home}, tenantIsolationEnv...)
+	want = append(want, "GITHUB_TOKEN=ghp_a", "AWS_PROFILE=team-a")
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+
+	_, err = TenantJSON{Name: "team-a", EnvFile: invalidFile}.env(environ)
+	if !errors.Is(err, errInvalidEnvLine) {
+		t.Errorf("Expected %#v error but got: %#v", errInvalidEnvLine, err)
+	}
+}
+
+// tenantCredentialsEnv runs TestTenantJSON_Env_Credentials as the helper
+// process retrieving the AWS credentials.
+const tenantCredentialsEnv = "CANNECT_TEST_TENANT_CREDENTIALS"
+
+func TestTenantJSON_Env_Credentials(t *testing.T) {
+	if os.Getenv(tenantCredentialsEnv) != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = cfg.Credentials.Retrieve(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	t.Parallel()
+
+	dir := path.Join("testdata", t.Name())
+	err := os.MkdirAll(path.Join(dir, "host", ".aws"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	err = os.WriteFile(
+		path.Join(dir, "host", ".aws", "credentials"),
+		[]byte("[default]\naws_access_key_id = AKIAHOST\naws_secret_access_key = host\n"), 0o600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, err := filepath.Abs(path.Join(dir, "host"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	environ := []string{"PATH=" + os.Getenv("PATH"), "HOME=" + host}
+
+	retrieve := func(env []string) error {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestTenantJSON_Env_Credentials$")
+		cmd.Env = append(env, tenantCredentialsEnv+"=1")
+		return cmd.Run()
+	}
+
+	// The credentials of the host are found without the isolation.
+	err = retrieve(environ)
+	if err != nil {
+		t.Fatalf("Expected the credentials of the host but got: %v", err)
+	}
+
+	env, err := TenantJSON{Name: "team-a", Root: path.Join(dir, "team-a")}.env(environ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = retrieve(env)
+	if err == nil {
+		t.Error("Expected the tenant without credentials to fail but got the credentials of the host")
+	}
+}
+
+func TestTenantPolicy(t *testing.T) {
+	t.Parallel()
+
+	catalogs := []CatalogJSON{{Alias: "root-ca.crt", URI: "file://root-ca.crt", Category: "certificate"}}
+
+	data := []struct {
+		testCase string
+		cntJSON  CAnnectJSON
+		// want
+		err error
+	}{
+		{
+			"OK",
+			CAnnectJSON{
+				Catalogs: catalogs,
+				Orders:   []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "file://out/root-ca.crt"}},
+			},
+			nil,
+		},
+		{
+			"NG:Local Scheme",
+			CAnnectJSON{
+				Catalogs: catalogs,
+				Orders:   []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "tar:///tmp/ca.tar?path=root-ca.crt"}},
+			},
+			errURINotAllowed,
+		},
+		{
+			"NG:Workload Catalog",
+			CAnnectJSON{
+				Catalogs: []CatalogJSON{{Alias: "bundle.crt", URI: "workload://", Category: "certificate"}},
+				Orders:   []OrderJSON{{CatalogAliases: []string{"bundle.crt"}, URI: "file://out/bundle.crt"}},
+			},
+			errURINotAllowed,
+		},
+		{
+			"NG:Hook",
+			CAnnectJSON{
+				Catalogs: catalogs,
+				Orders:   []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "file://out/root-ca.crt"}},
+				Hook:     []string{"systemctl", "reload", "nginx"},
+			},
+			errHookNotAllowed,
+		},
+	}
+
+	for _, d := range data {
+		err := tenantPolicy.check(d.cntJSON)
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+	}
+}
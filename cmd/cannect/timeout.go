@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+// timeoutCatalog limits the time of fetching the catalog, so that a slow
+// source does not consume the deadline of the unrelated orders.
+type timeoutCatalog struct {
+	catalog orderapi.Catalog
+	alias   string
+	timeout time.Duration
+}
+
+// newTimeoutCatalog returns the catalog limited by the timeout of the
+// CatalogJSON, or the default timeout. The catalog is returned as it is if
+// both of them are 0.
+func newTimeoutCatalog(catalog orderapi.Catalog, cJSON CatalogJSON, defaultTimeout time.Duration) orderapi.Catalog {
+	timeout := defaultTimeout
+	if cJSON.Timeout > 0 {
+		timeout = time.Second * time.Duration(cJSON.Timeout)
+	}
+	if timeout <= 0 {
+		return catalog
+	}
+
+	return &timeoutCatalog{catalog: catalog, alias: cJSON.Alias, timeout: timeout}
+}
+
+func (c *timeoutCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	tCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	buf, err := c.catalog.Fetch(tCtx)
+	if err != nil && ownDeadline(ctx, tCtx) {
+		return nil, timeoutError{phase: "fetch of " + c.alias, err: err}
+	}
+
+	return buf, err
+}
+
+// Open opens the catalog with the timeout, which is kept until the reader is
+// closed.
+func (c *timeoutCatalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	tCtx, cancel := context.WithTimeout(ctx, c.timeout)
+
+	r, err := orderapi.Open(tCtx, c.catalog)
+	if err != nil {
+		cancel()
+		if ownDeadline(ctx, tCtx) {
+			return nil, timeoutError{phase: "fetch of " + c.alias, err: err}
+		}
+		return nil, err
+	}
+
+	return orderapi.OnReadEnd(r, func(_ int, err error) error {
+		if err != nil && ownDeadline(ctx, tCtx) {
+			err = timeoutError{phase: "fetch of " + c.alias, err: err}
+		}
+		cancel()
+		return err
+	}), nil
+}
+
+func (c *timeoutCatalog) Streams() bool {
+	return orderapi.Streams(c.catalog)
+}
+
+// timeoutOrder limits the time of the order, including the fetches of its
+// catalogs.
+type timeoutOrder struct {
+	order   Order
+	uriText string
+	timeout time.Duration
+}
+
+func newTimeoutOrder(uriText string, oJSON OrderJSON, order Order) Order {
+	if oJSON.Timeout <= 0 {
+		return order
+	}
+
+	return &timeoutOrder{order: order, uriText: uriText, timeout: time.Second * time.Duration(oJSON.Timeout)}
+}
+
+func (o *timeoutOrder) Order(ctx context.Context) error {
+	tCtx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	err := o.order.Order(tCtx)
+	if err != nil && ownDeadline(ctx, tCtx) {
+		return timeoutError{phase: "order to " + o.uriText, err: err}
+	}
+
+	return err
+}
+
+// ownDeadline reports whether the deadline of the child context is exceeded
+// while the parent context is still alive.
+func ownDeadline(parent, child context.Context) bool {
+	return parent.Err() == nil && errors.Is(child.Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testBlockingCatalog and testBlockingOrder return after the context is done.
+type testBlockingCatalog struct{}
+
+func (testBlockingCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type testBlockingOrder struct{}
+
+func (testBlockingOrder) Order(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestNewTimeoutCatalog(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase       string
+		timeout        int64
+		defaultTimeout time.Duration
+		// want
+		want time.Duration
+	}{
+		{"No Timeout", 0, 0, 0},
+		{"Default", 0, 5 * time.Second, 5 * time.Second},
+		{"Catalog", 10, 5 * time.Second, 10 * time.Second},
+	}
+
+	for _, d := range data {
+		catalog := newTimeoutCatalog(testBlockingCatalog{}, CatalogJSON{Alias: "slow", Timeout: d.timeout}, d.defaultTimeout)
+
+		var got time.Duration
+		if tCatalog, ok := catalog.(*timeoutCatalog); ok {
+			got = tCatalog.timeout
+		}
+		if got != d.want {
+			t.Errorf("%s: Expected %s timeout but got: %s", d.testCase, d.want, got)
+		}
+	}
+}
+
+func TestTimeoutCatalog_Fetch(t *testing.T) {
+	t.Parallel()
+
+	catalog := &timeoutCatalog{catalog: testBlockingCatalog{}, alias: "slow", timeout: 10 * time.Millisecond}
+
+	_, err := catalog.Fetch(context.Background())
+	var tErr timeoutError
+	if !errors.As(err, &tErr) || tErr.phase != "fetch of slow" {
+		t.Fatalf("Expected the timeout of the fetch but got: %#v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %#v error but got: %#v", context.DeadlineExceeded, err)
+	}
+
+	// The cancel of the parent is not reported as the timeout of the fetch.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = catalog.Fetch(ctx)
+	if errors.As(err, &tErr) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %#v error but got: %#v", context.Canceled, err)
+	}
+}
+
+func TestTimeoutOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	if order := newTimeoutOrder("file://out.crt", OrderJSON{}, testBlockingOrder{}); order != (testBlockingOrder{}) {
+		t.Errorf("Expected the order without timeout but got: %#v", order)
+	}
+
+	order := &timeoutOrder{order: testBlockingOrder{}, uriText: "file://out.crt", timeout: 10 * time.Millisecond}
+
+	err := order.Order(context.Background())
+	var tErr timeoutError
+	if !errors.As(err, &tErr) || tErr.phase != "order to file://out.crt" {
+		t.Fatalf("Expected the timeout of the order but got: %#v", err)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+				Timeout:  10,
+			},
+		},
+		Orders: []OrderJSON{
+			{
+				CatalogAliases: []string{
+					"root-ca.crt",
+				},
+				URI:     "file://testdata/test-timeout-root-ca.out",
+				Timeout: 10,
+			},
+		},
+	}
+	t.Cleanup(func() { os.Remove("testdata/test-timeout-root-ca.out") })
+
+	err := validate(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5, FetchTimeout: 5 * time.Second}
+	err = run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("testdata/test-timeout-root-ca.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
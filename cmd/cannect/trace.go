@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	traceapi "github.com/yuxki/cannect/pkg/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// traceFlushTimeout limits the time of exporting the spans of a run.
+const traceFlushTimeout = 10 * time.Second
+
+// newTracerProvider returns the TracerProvider exporting the spans to the
+// OTLP/HTTP endpoint, if OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or
+// OTEL_EXPORTER_OTLP_ENDPOINT is set. The exporter and the resource read the
+// other environment variables of OpenTelemetry, like OTEL_EXPORTER_OTLP_HEADERS
+// and OTEL_SERVICE_NAME. It returns nil if no endpoint is set, or
+// OTEL_TRACES_EXPORTER is "none".
+func newTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	if os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return nil, nil
+	}
+	if os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return nil, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "cannect")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)), nil
+}
+
+// traced runs the f in the span of the name, and exports the spans of the
+// provider after it, so the spans of the run are exported even if the run
+// timed out. The f is run as it is if the provider is nil.
+func traced(
+	ctx context.Context, provider *sdktrace.TracerProvider, name string, logger *log.Logger,
+	f func(context.Context) error,
+) error {
+	if provider == nil {
+		return f(ctx)
+	}
+
+	sCtx, span := provider.Tracer(traceapi.InstrumentationName).Start(ctx, name)
+	err := f(sCtx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+
+	fCtx, cancel := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancel()
+
+	fErr := provider.ForceFlush(fCtx)
+	if fErr != nil {
+		logger.Println(fErr)
+	}
+
+	return err
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+var (
+	errInvalidQuota  = errors.New("invalid quota")
+	errQuotaExceeded = errors.New("quota is exceeded")
+)
+
+// QuotaJSON limits the usage of a run, or a sync of the watch command, so a
+// runaway config does not exhaust the sources and the destinations shared by
+// the tenants. The Fetches and the Bytes are the ones of the sources, and the
+// Writes are the destinations written. They are not limited if they are 0.
+type QuotaJSON struct {
+	Fetches int   `json:"fetches,omitempty"`
+	Bytes   int64 `json:"bytes,omitempty"`
+	Writes  int   `json:"writes,omitempty"`
+}
+
+// parseQuota parses the quota of the -quota option, like
+// "fetches=100,bytes=1048576,writes=50".
+func parseQuota(spec string) (QuotaJSON, error) {
+	var quota QuotaJSON
+	if spec == "" {
+		return quota, nil
+	}
+
+	for _, field := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return quota, fmt.Errorf("%s: %w", field, errInvalidQuota)
+		}
+
+		switch key {
+		case "fetches":
+			quota.Fetches = int(n)
+		case "bytes":
+			quota.Bytes = n
+		case "writes":
+			quota.Writes = int(n)
+		default:
+			return quota, fmt.Errorf("%s: %w", field, errInvalidQuota)
+		}
+	}
+
+	return quota, nil
+}
+
+// String returns the quota in the format of the -quota option.
+func (q QuotaJSON) String() string {
+	var fields []string
+	if q.Fetches > 0 {
+		fields = append(fields, fmt.Sprintf("fetches=%d", q.Fetches))
+	}
+	if q.Bytes > 0 {
+		fields = append(fields, fmt.Sprintf("bytes=%d", q.Bytes))
+	}
+	if q.Writes > 0 {
+		fields = append(fields, fmt.Sprintf("writes=%d", q.Writes))
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// runUsage is the usage of a run, for the chargeback of the shared
+// deployments, and the detection of the runaway configs.
+type runUsage struct {
+	Fetches      int            `json:"fetches"`
+	FetchedBytes int64          `json:"fetchedBytes"`
+	Writes       int            `json:"writes"`
+	WrittenBytes int64          `json:"writtenBytes"`
+	Catalogs     []catalogUsage `json:"catalogs"`
+}
+
+type catalogUsage struct {
+	Alias   string `json:"alias"`
+	Fetches int    `json:"fetches"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// usageMeter counts the fetches of the sources and the writes to the
+// destinations in a run, and enforces the quota. It is safe to share the
+// usageMeter among the catalogs and the orders.
+type usageMeter struct {
+	mu       sync.Mutex
+	quota    QuotaJSON
+	usage    runUsage
+	catalogs map[string]*catalogUsage
+}
+
+func newUsageMeter(quota QuotaJSON) *usageMeter {
+	return &usageMeter{quota: quota, catalogs: make(map[string]*catalogUsage)}
+}
+
+// startFetch counts the fetch of the alias, and returns the error if it
+// exceeds the quota.
+func (u *usageMeter) startFetch(alias string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.quota.Fetches > 0 && u.usage.Fetches >= u.quota.Fetches {
+		return fmt.Errorf("%s: %d fetches: %w", alias, u.quota.Fetches, errQuotaExceeded)
+	}
+
+	u.usage.Fetches++
+	u.catalog(alias).Fetches++
+
+	return nil
+}
+
+// fetched counts the bytes fetched for the alias, and returns the error if
+// they exceed the quota.
+func (u *usageMeter) fetched(alias string, size int) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.usage.FetchedBytes += int64(size)
+	u.catalog(alias).Bytes += int64(size)
+
+	if u.quota.Bytes > 0 && u.usage.FetchedBytes > u.quota.Bytes {
+		return fmt.Errorf("%s: %d bytes: %w", alias, u.quota.Bytes, errQuotaExceeded)
+	}
+
+	return nil
+}
+
+func (u *usageMeter) catalog(alias string) *catalogUsage {
+	cUsage, ok := u.catalogs[alias]
+	if !ok {
+		cUsage = &catalogUsage{Alias: alias}
+		u.catalogs[alias] = cUsage
+	}
+
+	return cUsage
+}
+
+// startWrite reserves the write to the destination, and returns the error if
+// it exceeds the quota. The reservation is released by the done if the write
+// failed.
+func (u *usageMeter) startWrite(uriText string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.quota.Writes > 0 && u.usage.Writes >= u.quota.Writes {
+		return fmt.Errorf("%s: %d writes: %w", uriText, u.quota.Writes, errQuotaExceeded)
+	}
+
+	u.usage.Writes++
+
+	return nil
+}
+
+func (u *usageMeter) doneWrite(size int, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err != nil {
+		u.usage.Writes--
+		return
+	}
+
+	u.usage.WrittenBytes += int64(size)
+}
+
+// Usage returns the usage with the catalogs sorted by the aliases.
+func (u *usageMeter) Usage() runUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usage := u.usage
+	usage.Catalogs = make([]catalogUsage, 0, len(u.catalogs))
+	for _, cUsage := range u.catalogs {
+		usage.Catalogs = append(usage.Catalogs, *cUsage)
+	}
+	sort.Slice(usage.Catalogs, func(i, j int) bool {
+		return usage.Catalogs[i].Alias < usage.Catalogs[j].Alias
+	})
+
+	return usage
+}
+
+// meteredCatalog counts the fetches of the source. It is wrapped by the shared
+// catalog, so only the actual fetches are counted.
+type meteredCatalog struct {
+	catalog orderapi.Catalog
+	alias   string
+	meter   *usageMeter
+}
+
+func newMeteredCatalog(catalog orderapi.Catalog, cJSON CatalogJSON, meter *usageMeter) *meteredCatalog {
+	return &meteredCatalog{catalog: catalog, alias: cJSON.Alias, meter: meter}
+}
+
+func (c *meteredCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	err := c.meter.startFetch(c.alias)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := c.catalog.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.meter.fetched(c.alias, len(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// Open counts the fetch, and the bytes read when the content is read to the
+// end.
+func (c *meteredCatalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	err := c.meter.startFetch(c.alias)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := orderapi.Open(ctx, c.catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderapi.OnReadEnd(r, func(n int, err error) error {
+		if err != nil {
+			return err
+		}
+
+		return c.meter.fetched(c.alias, n)
+	}), nil
+}
+
+func (c *meteredCatalog) Streams() bool {
+	return orderapi.Streams(c.catalog)
+}
+
+// meteredOrder counts the write to the destination. The size is the one of
+// the contents of the catalogs, which are fetched already by the order.
+type meteredOrder struct {
+	uriText  string
+	order    Order
+	catalogs []orderapi.Catalog
+	meter    *usageMeter
+}
+
+func newMeteredOrder(
+	uriText string, oJSON OrderJSON, sources []orderapi.Catalog, order Order, meter *usageMeter,
+) (*meteredOrder, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	return &meteredOrder{uriText: uriText, order: order, catalogs: catalogs, meter: meter}, nil
+}
+
+func (m *meteredOrder) Order(ctx context.Context) error {
+	err := m.meter.startWrite(m.uriText)
+	if err != nil {
+		return err
+	}
+
+	err = m.order.Order(ctx)
+	if err != nil {
+		m.meter.doneWrite(0, err)
+		return err
+	}
+
+	var size int
+	for _, catalog := range m.catalogs {
+		n, err := orderapi.Size(ctx, catalog)
+		if err != nil {
+			// The size is unknown, but the destination is written.
+			break
+		}
+		size += n
+	}
+	m.meter.doneWrite(size, nil)
+
+	return nil
+}
+
+// logUsage writes the usage of the sync of the watch command.
+func logUsage(logger *log.Logger, cfg runConfig, usage runUsage) {
+	if cfg.Log != nil {
+		cfg.Log.Info("usage",
+			"fetches", usage.Fetches, "fetchedBytes", usage.FetchedBytes,
+			"writes", usage.Writes, "writtenBytes", usage.WrittenBytes,
+		)
+		return
+	}
+
+	logger.Print(msgs.Sprintf(msgUsed, usage.Fetches, usage.FetchedBytes, usage.Writes, usage.WrittenBytes))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseQuota(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		spec     string
+		// want
+		quota QuotaJSON
+		err   error
+	}{
+		{"OK:Empty", "", QuotaJSON{}, nil},
+		{"OK", "fetches=100,bytes=1048576,writes=50", QuotaJSON{Fetches: 100, Bytes: 1048576, Writes: 50}, nil},
+		{"NG:Key", "files=1", QuotaJSON{}, errInvalidQuota},
+		{"NG:Value", "fetches=-1", QuotaJSON{}, errInvalidQuota},
+		{"NG:No Value", "fetches", QuotaJSON{}, errInvalidQuota},
+	}
+
+	for _, d := range data {
+		quota, err := parseQuota(d.spec)
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+		if err == nil && quota != d.quota {
+			t.Errorf("%s: Expected %#v but got: %#v", d.testCase, d.quota, quota)
+		}
+		if err == nil && quota.String() != d.spec {
+			t.Errorf("%s: Expected %s but got: %s", d.testCase, d.spec, quota.String())
+		}
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	t.Parallel()
+
+	rootCA, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{
+				Alias:    "root-ca.crt",
+				URI:      "file://testdata/root-ca.crt",
+				Category: "certificate",
+			},
+		},
+	}
+	for i := 0; i < 3; i++ {
+		out := fmt.Sprintf("testdata/test-usage-%d.out", i)
+		t.Cleanup(func() { os.Remove(out) })
+
+		jsn.Orders = append(jsn.Orders, OrderJSON{
+			CatalogAliases: []string{"root-ca.crt"},
+			URI:            "file://" + out,
+		})
+	}
+
+	err = validate(jsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := int64(len(rootCA))
+	data := []struct {
+		testCase string
+		quota    QuotaJSON
+		// want
+		usage runUsage
+		err   error
+	}{
+		{
+			"OK",
+			QuotaJSON{Fetches: 1, Bytes: size, Writes: 3},
+			runUsage{
+				Fetches: 1, FetchedBytes: size, Writes: 3, WrittenBytes: 3 * size,
+				Catalogs: []catalogUsage{{Alias: "root-ca.crt", Fetches: 1, Bytes: size}},
+			},
+			nil,
+		},
+		{
+			"NG:Bytes",
+			QuotaJSON{Bytes: size - 1},
+			runUsage{
+				Fetches: 1, FetchedBytes: size,
+				Catalogs: []catalogUsage{{Alias: "root-ca.crt", Fetches: 1, Bytes: size}},
+			},
+			errQuotaExceeded,
+		},
+	}
+
+	for _, d := range data {
+		cfg := runConfig{EnvOut: "./envout.env", ConLimit: 1, KeepGoing: true, Usage: newUsageMeter(d.quota)}
+		err = run(context.TODO(), jsn, cfg, log.New(new(bytes.Buffer), "", 0))
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+		if diff := cmp.Diff(cfg.Usage.Usage(), d.usage); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+
+	// The writes over the quota are not done.
+	meter := newUsageMeter(QuotaJSON{Writes: 2})
+	cfg := runConfig{EnvOut: "./envout.env", ConLimit: 1, KeepGoing: true, Usage: meter}
+	err = run(context.TODO(), jsn, cfg, log.New(new(bytes.Buffer), "", 0))
+	if !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("Expected %#v error but got: %#v", errQuotaExceeded, err)
+	}
+	if got := meter.Usage().Writes; got != 2 {
+		t.Errorf("Expected 2 writes but got: %d", got)
+	}
+}
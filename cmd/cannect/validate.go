@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+)
+
+// validateConfig checks the config against the policy if it is specified. If
+// fetch is true, it also fetches and checks the catalogs, but never writes to
+// the destinations, like the -no-write option.
+func validateConfig(ctx context.Context, cntJSON CAnnectJSON, policy string, fetch bool, cfg runConfig, logger *log.Logger) error {
+	if policy != "" {
+		pJSON, err := loadPolicy(policy)
+		if err != nil {
+			return err
+		}
+
+		err = pJSON.check(cntJSON)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !fetch {
+		return nil
+	}
+
+	cfg.NoWrite = true
+	return run(ctx, cntJSON, cfg, logger)
+}
+
+func validateMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	catalog := new(listFlag)
+	fs.Var(catalog, "catalog", msgs.Sprintf(msgFlagCatalog))
+	order := new(listFlag)
+	fs.Var(order, "order", msgs.Sprintf(msgFlagOrder))
+	catalogOrder := new(listFlag)
+	fs.Var(catalogOrder, "catalog-order", msgs.Sprintf(msgFlagCatalogOrder))
+	policy := fs.String("policy", "", msgs.Sprintf(msgFlagPolicy))
+	fetch := fs.Bool("fetch", false, msgs.Sprintf(msgFlagFetch))
+	conLimit := fs.Int("con-limit", defaultConLimit, msgs.Sprintf(msgFlagConLimit))
+	timeout := fs.Int64("timeout", defaultTimeout, msgs.Sprintf(msgFlagTimeout))
+	fips := fs.Bool("fips", false, msgs.Sprintf(msgFlagFIPS))
+	root := fs.String("root", "", msgs.Sprintf(msgFlagRoot))
+	fsRoot := fs.String("fs-root", "", msgs.Sprintf(msgFlagFSRoot))
+	fsStrict := fs.Bool("fs-strict", false, msgs.Sprintf(msgFlagFSStrict))
+	logFormat := fs.String("log-format", defaultLogFormat, msgs.Sprintf(msgFlagLogFormat))
+	logLevel := fs.String("log-level", defaultLogLevel, msgs.Sprintf(msgFlagLogLevel))
+	output := fs.String("output", textOutput, msgs.Sprintf(msgFlagOutput))
+	strict := fs.Bool("strict", false, msgs.Sprintf(msgFlagStrict))
+	netFlags := addNetworkFlags(fs)
+	fs.StringVar(&configFormat, "format", "", msgs.Sprintf(msgFlagFormat))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	fs.Usage = func() { log.Println(msgs.Sprintf(msgValidateUsage)) }
+	_ = fs.Parse(args)
+
+	flgs, ok := checkExclusive(catalog.String(), order.String(), catalogOrder.String())
+	if !ok {
+		log.Println(msgs.Sprintf(msgValidateUsage))
+		return 1
+	}
+
+	switch *output {
+	case textOutput, githubOutput:
+	default:
+		log.Printf("%s: %v", *output, errUndefinedOutput)
+		return 1
+	}
+
+	httpClient, err := netFlags.httpClient()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	s3JSON, err := netFlags.s3()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	cfg := newRunConfig(defaultEnvOut, *conLimit, *fips)
+	cfg.HTTPClient = httpClient
+	cfg.S3 = s3JSON
+	cfg.FSGuard = newFSGuard(*fsRoot, *root, *fsStrict)
+	cfg.Root = *root
+	cfg.Warnings = newWarningSet()
+	if *output == githubOutput {
+		cfg.Report = newRunReport()
+	}
+	logger, err := newLogger(os.Stderr, *logFormat, *logLevel, &cfg)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	cntJSON, err := CreateCannectJSON(catalog.String(), order.String(), catalogOrder.String(), flgs)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(*timeout))
+		err = validateConfig(ctx, cntJSON, *policy, *fetch, cfg, logger)
+		cancel()
+	}
+
+	if cfg.Report != nil {
+		results := cfg.Report.Results()
+		if !*fetch {
+			results = configResults(cntJSON)
+		}
+
+		rErr := reportGitHub(os.Stdout, "cannect validate", results, cfg.Report.Warnings(), err)
+		if rErr != nil {
+			log.Println(rErr)
+		}
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	log.Println(msgs.Sprintf(msgValid, len(cntJSON.Catalogs), len(cntJSON.Orders)))
+	if *strict && len(cfg.Warnings.Warnings()) > 0 {
+		return exitWarned
+	}
+	return 0
+}
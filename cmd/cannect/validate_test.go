@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	t.Parallel()
+
+	newJSON := func(catalogURI, orderURI string) CAnnectJSON {
+		return CAnnectJSON{
+			Catalogs: []CatalogJSON{
+				{
+					Alias:    "root-ca.crt",
+					URI:      catalogURI,
+					Category: "certificate",
+				},
+			},
+			Orders: []OrderJSON{
+				{
+					CatalogAliases: []string{
+						"root-ca.crt",
+					},
+					URI: orderURI,
+				},
+			},
+		}
+	}
+
+	data := []struct {
+		testCase string
+		jsn      CAnnectJSON
+		policy   string
+		fetch    bool
+		err      error
+	}{
+		{
+			"OK:Policy",
+			newJSON("file://testdata/root-ca.crt", "file://testdata/test-validate-policy.out"),
+			"testdata/test_policy.json",
+			false,
+			nil,
+		},
+		{
+			"OK:Fetch",
+			newJSON("file://testdata/root-ca.crt", "file://testdata/test-validate-fetch.out"),
+			"",
+			true,
+			nil,
+		},
+		{
+			"OK:Not Fetched",
+			newJSON("file://testdata/none.crt", "file://testdata/test-validate-not-fetched.out"),
+			"",
+			false,
+			nil,
+		},
+		{
+			"NG:Policy",
+			newJSON("file://testdata/root-ca.crt", "file://testdata/test-validate-policy.crt"),
+			"testdata/test_policy.json",
+			false,
+			errURINotAllowed,
+		},
+		{
+			"NG:Fetch",
+			newJSON("file://testdata/none.crt", "file://testdata/test-validate-ng-fetch.out"),
+			"",
+			true,
+			fs.ErrNotExist,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := runConfig{EnvOut: "./envout.env", ConLimit: 5}
+			err := validateConfig(context.TODO(), d.jsn, d.policy, d.fetch, cfg, log.New(io.Discard, "", 0))
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %v but got: %v", d.err, err)
+			}
+
+			// Nothing is written in any case.
+			uri := d.jsn.Orders[0].URI
+			_, err = os.Stat(uri[len("file://"):])
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Expected %s is not written but got: %v", uri, err)
+			}
+		})
+	}
+}
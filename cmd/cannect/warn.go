@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/yuxki/cannect/pkg/asset"
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+)
+
+// The names of the checks of the catalog, which can be warned instead of
+// failing by the warn of the catalog element. The category is always checked.
+const (
+	caPolicyCheck  = "caPolicy"
+	keyPolicyCheck = "keyPolicy"
+	fipsCheck      = "fips"
+)
+
+// expiryCheck is the name of the check of the warnBefore of the catalog, which
+// always warns.
+const expiryCheck = "expiry"
+
+// The exit status of the -strict mode when any check warns.
+const exitWarned = 3
+
+var errUndefinedCheck = errors.New("undefined check")
+
+// warnChecks are the names of the checks which can be warned.
+var warnChecks = []string{caPolicyCheck, keyPolicyCheck, fipsCheck}
+
+// checkWarn reports whether the check of the name is defined.
+func checkWarn(name string) error {
+	for _, check := range warnChecks {
+		if name == check {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: %w", name, errUndefinedCheck)
+}
+
+// warningSet collects the warnings of the checks in a run. It is safe to share
+// the warningSet among the catalogs.
+type warningSet struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+func newWarningSet() *warningSet {
+	return &warningSet{}
+}
+
+func (w *warningSet) add(msg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.warnings = append(w.warnings, msg)
+}
+
+func (w *warningSet) Warnings() []string {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string(nil), w.warnings...)
+}
+
+// passCheck accepts any content. It is wrapped by the check warned alone,
+// after the checks of the catalog before it.
+type passCheck struct{}
+
+func (passCheck) CheckContent([]byte) error {
+	return nil
+}
+
+// warnedCheck returns the checker of the additional check of the name, which
+// is created by the wrap with the checker it wraps. If the check is in the
+// warn of the catalog, its error is logged and collected as the warning, and
+// the content is still checked by the checker.
+func warnedCheck(
+	checker catalogapi.AssetChecker, name string, wrap func(asset.Checker) asset.Checker,
+	cJSON CatalogJSON, cfg runConfig, logger *log.Logger,
+) catalogapi.AssetChecker {
+	warned := false
+	for _, w := range cJSON.Warn {
+		warned = warned || w == name
+	}
+	if !warned {
+		return wrap(checker)
+	}
+
+	return asset.NewWarn(wrap(passCheck{}), checker, checkWarner(name, cJSON, cfg, logger))
+}
+
+// checkWarner returns the function warning the error of the check of the name.
+// The warning is logged and collected in the run.
+func checkWarner(name string, cJSON CatalogJSON, cfg runConfig, logger *log.Logger) func(error) {
+	return func(err error) {
+		msg := msgs.Sprintf(msgCheckWarned, cJSON.Alias, name, err)
+		if cfg.Log != nil {
+			cfg.Log.Warn("check warned", "alias", cJSON.Alias, "check", name, "error", err.Error())
+		} else {
+			logger.Print(msg)
+		}
+
+		if cfg.Warnings != nil {
+			cfg.Warnings.add(msg)
+		}
+		if cfg.Report != nil {
+			cfg.Report.warn(msg)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yuxki/cannect/pkg/asset"
+)
+
+func TestRun_Warn(t *testing.T) {
+	t.Parallel()
+
+	out := "testdata/test-warn.out"
+	t.Cleanup(func() { os.Remove(out) })
+
+	data := []struct {
+		testCase string
+		warn     []string
+		content  string
+		// want
+		err      error
+		warnings int
+	}{
+		{"OK:Warned", []string{caPolicyCheck}, "", nil, 1},
+		{"NG:Not Warned", nil, "", asset.ErrCAPolicyViolation, 0},
+		{"NG:Malformed", []string{caPolicyCheck}, "-----BEGIN X509 CRL-----\n", asset.ErrUnexpectedCAAsset, 0},
+	}
+
+	for _, d := range data {
+		src := "testdata/root-ca.crt"
+		if d.content != "" {
+			src = "testdata/test-warn-malformed.crt"
+			err := os.WriteFile(src, []byte(d.content), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.Remove("testdata/test-warn-malformed.crt") })
+		}
+
+		jsn := CAnnectJSON{
+			Catalogs: []CatalogJSON{
+				{
+					Alias:    "root-ca.crt",
+					URI:      "file://" + src,
+					Category: "certificate",
+					CAPolicy: &CAPolicyJSON{RequireNameConstraints: true},
+					Warn:     d.warn,
+				},
+			},
+			Orders: []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + out}},
+		}
+		err := validate(jsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		cfg := runConfig{EnvOut: "./envout.env", ConLimit: 1, Warnings: newWarningSet(), Report: newRunReport()}
+		err = run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+
+		warnings := cfg.Warnings.Warnings()
+		if len(warnings) != d.warnings || len(cfg.Report.Warnings()) != d.warnings {
+			t.Errorf("%s: Expected %d warnings but got: %v", d.testCase, d.warnings, warnings)
+		}
+		for _, warning := range warnings {
+			if !strings.Contains(buf.String(), warning) {
+				t.Errorf("%s: Expected the warning logged but got: %s", d.testCase, buf.String())
+			}
+		}
+	}
+}
+
+func TestRun_Expiry(t *testing.T) {
+	t.Parallel()
+
+	out := "testdata/test-expiry.out"
+	t.Cleanup(func() { os.Remove(out) })
+
+	// The certificate in the testdata expires in 2033.
+	century, week := 36500, 7
+
+	data := []struct {
+		testCase             string
+		minRemainingValidity *int
+		warnBefore           *int
+		// want
+		err      error
+		warnings int
+	}{
+		{"OK:Valid", &week, &week, nil, 0},
+		{"OK:Warned", &week, &century, nil, 1},
+		{"NG:Expiring", &century, nil, asset.ErrExpiring, 0},
+	}
+
+	for _, d := range data {
+		jsn := CAnnectJSON{
+			Catalogs: []CatalogJSON{
+				{
+					Alias:                "root-ca.crt",
+					URI:                  "file://testdata/root-ca.crt",
+					Category:             "certificate",
+					MinRemainingValidity: d.minRemainingValidity,
+					WarnBefore:           d.warnBefore,
+				},
+			},
+			Orders: []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + out}},
+		}
+		err := validate(jsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		cfg := runConfig{EnvOut: "./envout.env", ConLimit: 1, Warnings: newWarningSet()}
+		err = run(context.TODO(), jsn, cfg, log.New(&buf, "", 0))
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+
+		warnings := cfg.Warnings.Warnings()
+		if len(warnings) != d.warnings {
+			t.Errorf("%s: Expected %d warnings but got: %v", d.testCase, d.warnings, warnings)
+		}
+		for _, warning := range warnings {
+			if !strings.Contains(warning, expiryCheck) {
+				t.Errorf("%s: Expected the warning of %s but got: %s", d.testCase, expiryCheck, warning)
+			}
+		}
+	}
+}
+
+func TestValidate_Warn(t *testing.T) {
+	t.Parallel()
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate", Warn: []string{"category"}},
+		},
+		Orders: []OrderJSON{{CatalogAliases: []string{"root-ca.crt"}, URI: "file://testdata/root-ca.out"}},
+	}
+
+	err := validate(jsn)
+	if !errors.Is(err, errUndefinedCheck) {
+		t.Errorf("Expected %#v error but got: %#v", errUndefinedCheck, err)
+	}
+}
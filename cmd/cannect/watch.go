@@ -0,0 +1,533 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	metricsapi "github.com/yuxki/cannect/pkg/metrics"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	stateapi "github.com/yuxki/cannect/pkg/state"
+	traceapi "github.com/yuxki/cannect/pkg/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	defaultWatchInterval = time.Hour
+	defaultWatchPoll     = 5 * time.Second
+)
+
+// digestCache keeps the digests of the contents written to the destinations
+// in the watch mode, or in the runs sharing the state. It is safe to share the digestCache among the orders.
+type digestCache struct {
+	mu      sync.Mutex
+	digests map[string]string
+}
+
+func newDigestCache() *digestCache {
+	return &digestCache{digests: make(map[string]string)}
+}
+
+func (d *digestCache) get(uriText string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.digests[uriText]
+}
+
+func (d *digestCache) set(uriText, digest string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.digests[uriText] = digest
+}
+
+// all returns the copy of the digests.
+func (d *digestCache) all() map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	digests := make(map[string]string, len(d.digests))
+	for uriText, digest := range d.digests {
+		digests[uriText] = digest
+	}
+
+	return digests
+}
+
+// unchangedOrder runs the inner order only if the contents differ from the
+// ones written in the previous run, so the destinations are not rewritten in
+// every sync of the watch mode.
+type unchangedOrder struct {
+	uriText  string
+	order    Order
+	catalogs []orderapi.Catalog
+	digests  *digestCache
+	l        *log.Logger
+}
+
+func newUnchangedOrder(
+	uriText string, oJSON OrderJSON, sources []orderapi.Catalog, order Order, digests *digestCache, l *log.Logger,
+) (*unchangedOrder, error) {
+	catalogs, err := orderCatalogs(oJSON, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	uOrder := &unchangedOrder{
+		uriText:  uriText,
+		order:    order,
+		catalogs: catalogs,
+		digests:  digests,
+		l:        l,
+	}
+
+	return uOrder, nil
+}
+
+func (u *unchangedOrder) Order(ctx context.Context) error {
+	h := sha256.New()
+	for _, catalog := range u.catalogs {
+		buf, err := catalog.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		h.Write(buf)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	if u.digests.get(u.uriText) == digest {
+		u.l.Print(msgs.Sprintf(msgUnchanged, u.uriText))
+		metricsapi.MarkSkipped(ctx)
+		return nil
+	}
+
+	err := u.order.Order(ctx)
+	if err != nil {
+		return err
+	}
+
+	u.digests.set(u.uriText, digest)
+	return nil
+}
+
+// writeChanged writes the content to the file only if it differs from the
+// current one.
+func writeChanged(name string, content []byte, l *log.Logger) error {
+	current, err := os.ReadFile(name)
+	if err == nil && bytes.Equal(current, content) {
+		l.Print(msgs.Sprintf(msgUnchanged, name))
+		return nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return os.WriteFile(name, content, 0o666)
+}
+
+// watchFiles returns the config files and the local files of the catalogs in
+// the root directory, whose modifications trigger the sync.
+func watchFiles(configFiles []string, cntJSON CAnnectJSON, root string) []string {
+	files := append([]string(nil), configFiles...)
+	for _, cJSON := range cntJSON.Catalogs {
+		if schemeapi.Of(cJSON.URI) != "file" {
+			continue
+		}
+
+		uri, err := newFSURI(cJSON.URI, root)
+		if err != nil {
+			continue
+		}
+		files = append(files, uri.Path())
+	}
+
+	return files
+}
+
+// modTimes returns the modification times of the files. The time of the
+// missing file is zero, so its creation is also a modification.
+func modTimes(files []string) map[string]time.Time {
+	times := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		var modTime time.Time
+		if info, err := os.Stat(file); err == nil {
+			modTime = info.ModTime()
+		}
+		times[file] = modTime
+	}
+
+	return times
+}
+
+// modifiedFile returns the first file modified since the times were taken.
+func modifiedFile(times map[string]time.Time) (string, bool) {
+	for file, modTime := range modTimes(keysOf(times)) {
+		if !modTime.Equal(times[file]) {
+			return file, true
+		}
+	}
+
+	return "", false
+}
+
+func keysOf(times map[string]time.Time) []string {
+	keys := make([]string, 0, len(times))
+	for key := range times {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// watcher syncs the destinations with the catalogs repeatedly.
+type watcher struct {
+	// load loads the config in each sync, so the changes of the config are
+	// also applied.
+	load        func(context.Context) (CAnnectJSON, error)
+	configFiles []string
+	interval    time.Duration
+	// poll is the interval of checking the modification times of the files.
+	// They are not checked if it is zero.
+	poll    time.Duration
+	timeout time.Duration
+	// quota limits each sync.
+	quota QuotaJSON
+	// metrics is written to the textfile after each sync, if both are set.
+	metrics  *metricsapi.Registry
+	textfile string
+	// tracer emits the span of each sync if it is not nil.
+	tracer *sdktrace.TracerProvider
+	// state keeps the digests of the destinations across the restarts if it
+	// is not nil.
+	state stateapi.Store
+}
+
+// sync loads the config and runs the orders, and returns the modification
+// times of the files to watch until the next sync.
+func (w watcher) sync(ctx context.Context, cfg runConfig, logger *log.Logger) map[string]time.Time {
+	sCtx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	cntJSON, err := w.load(sCtx)
+	if err != nil {
+		logger.Println(err)
+		return modTimes(w.configFiles)
+	}
+
+	times := modTimes(watchFiles(w.configFiles, cntJSON, cfg.Root))
+
+	cfg.Usage = newUsageMeter(w.quota)
+	err = traced(sCtx, w.tracer, "cannect.sync", logger, func(ctx context.Context) error {
+		return execute(ctx, cntJSON, cfg, logger)
+	})
+	if err != nil {
+		logger.Println(err)
+	}
+	logUsage(logger, cfg, cfg.Usage.Usage())
+
+	if w.state != nil {
+		err = saveDigests(w.state, cfg.Digests)
+		if err != nil {
+			logger.Println(err)
+		}
+	}
+
+	if w.metrics != nil && w.textfile != "" {
+		err = w.metrics.WriteTextfile(w.textfile)
+		if err != nil {
+			logger.Println(err)
+		}
+	}
+
+	return times
+}
+
+// run syncs the destinations at the interval, and when any file to watch is
+// modified, until the context is done. The destinations whose contents are
+// not changed are not rewritten.
+func (w watcher) run(ctx context.Context, cfg runConfig, logger *log.Logger) {
+	cfg.Digests = newDigestCache()
+	if w.state != nil {
+		// The destinations are rewritten once if the state is not loaded.
+		digests, err := loadDigests(ctx, w.state)
+		if err != nil {
+			logger.Println(err)
+		} else {
+			cfg.Digests = digests
+		}
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var pollC <-chan time.Time
+	if w.poll > 0 {
+		pollTicker := time.NewTicker(w.poll)
+		defer pollTicker.Stop()
+		pollC = pollTicker.C
+	}
+
+	for {
+		times := w.sync(ctx, cfg, logger)
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				break wait
+			case <-pollC:
+				if file, ok := modifiedFile(times); ok {
+					logger.Print(msgs.Sprintf(msgModified, file))
+					break wait
+				}
+			}
+		}
+	}
+}
+
+func watchMain(args []string) int {
+	msgs = newPrinter(langFromArgs(args))
+
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	catalog := new(listFlag)
+	fs.Var(catalog, "catalog", msgs.Sprintf(msgFlagCatalog))
+	order := new(listFlag)
+	fs.Var(order, "order", msgs.Sprintf(msgFlagOrder))
+	catalogOrder := new(listFlag)
+	fs.Var(catalogOrder, "catalog-order", msgs.Sprintf(msgFlagCatalogOrder))
+	interval := fs.Duration("interval", defaultWatchInterval, msgs.Sprintf(msgFlagWatchInterval))
+	poll := fs.Duration("poll", defaultWatchPoll, msgs.Sprintf(msgFlagPoll))
+	envOut := fs.String("env-out", defaultEnvOut, msgs.Sprintf(msgFlagEnvOut))
+	envFormat := fs.String("env-format", defaultEnvFormat, msgs.Sprintf(msgFlagEnvFormat))
+	envBase64 := fs.Bool("env-base64", false, msgs.Sprintf(msgFlagEnvBase64))
+	conLimit := fs.Int("con-limit", defaultConLimit, msgs.Sprintf(msgFlagConLimit))
+	timeout := fs.Int64("timeout", defaultTimeout, msgs.Sprintf(msgFlagTimeout))
+	configTimeout := fs.Int64("config-timeout", defaultConfigTimeout, msgs.Sprintf(msgFlagConfigTimeout))
+	fetchTimeout := fs.Int64("fetch-timeout", 0, msgs.Sprintf(msgFlagFetchTimeout))
+	fips := fs.Bool("fips", false, msgs.Sprintf(msgFlagFIPS))
+	root := fs.String("root", "", msgs.Sprintf(msgFlagRoot))
+	fsRoot := fs.String("fs-root", "", msgs.Sprintf(msgFlagFSRoot))
+	fsStrict := fs.Bool("fs-strict", false, msgs.Sprintf(msgFlagFSStrict))
+	logFormat := fs.String("log-format", defaultLogFormat, msgs.Sprintf(msgFlagLogFormat))
+	logLevel := fs.String("log-level", defaultLogLevel, msgs.Sprintf(msgFlagLogLevel))
+	keepGoing := fs.Bool("keep-going", false, msgs.Sprintf(msgFlagKeepGoing))
+	policy := fs.String("policy", "", msgs.Sprintf(msgFlagPolicy))
+	tenants := fs.String("tenants", "", msgs.Sprintf(msgFlagTenants))
+	tenant := fs.String("tenant", "", msgs.Sprintf(msgFlagTenant))
+	quotaSpec := fs.String("quota", "", msgs.Sprintf(msgFlagQuota))
+	skipUnchanged := fs.Bool("skip-unchanged", false, msgs.Sprintf(msgFlagSkipUnchanged))
+	stateURI := fs.String("state", "", msgs.Sprintf(msgFlagState))
+	metricsTextfile := fs.String("metrics-textfile", "", msgs.Sprintf(msgFlagMetricsTextfile))
+	metricsAddr := fs.String("metrics-addr", "", msgs.Sprintf(msgFlagMetricsAddr))
+	netFlags := addNetworkFlags(fs)
+	fs.StringVar(&configFormat, "format", "", msgs.Sprintf(msgFlagFormat))
+	fs.String("lang", msgs.lang, msgs.Sprintf(msgFlagLang))
+	fs.Usage = func() { log.Println(msgs.Sprintf(msgWatchUsage)) }
+	_ = fs.Parse(args)
+
+	if *tenants != "" {
+		return tenantsMain(*tenants, fs, catalog.String()+order.String()+catalogOrder.String() != "")
+	}
+
+	flgs, ok := checkExclusive(catalog.String(), order.String(), catalogOrder.String())
+	if !ok || *interval <= 0 || *poll < 0 {
+		log.Println(msgs.Sprintf(msgWatchUsage))
+		return 1
+	}
+
+	format, ok := envFormats[*envFormat]
+	if !ok {
+		log.Printf("%s: %v", *envFormat, errUndefinedEnvFormat)
+		return 1
+	}
+
+	quota, err := parseQuota(*quotaSpec)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	httpClient, err := netFlags.httpClient()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	s3JSON, err := netFlags.s3()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	var configFiles []string
+	for _, list := range []string{catalog.String(), order.String(), catalogOrder.String()} {
+		paths, err := configPaths(list)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		configFiles = append(configFiles, paths...)
+	}
+
+	cfg := newRunConfig(*envOut, *conLimit, *fips)
+	cfg.HTTPClient = httpClient
+	cfg.S3 = s3JSON
+	cfg.EnvFormat = format
+	cfg.EnvBase64 = *envBase64
+	cfg.FSGuard = newFSGuard(*fsRoot, *root, *fsStrict)
+	cfg.Root = *root
+	cfg.KeepGoing = *keepGoing
+	cfg.FetchTimeout = time.Second * time.Duration(*fetchTimeout)
+	cfg.SkipUnchanged = *skipUnchanged
+	logger, err := newLogger(os.Stdout, *logFormat, *logLevel, &cfg)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	policies := make([]PolicyJSON, 0, 2)
+	if *tenant != "" {
+		if cfg.Log != nil {
+			cfg.Log = cfg.Log.With("tenant", *tenant)
+		} else {
+			logger.SetPrefix("[" + *tenant + "] ")
+		}
+		policies = append(policies, tenantPolicy)
+	}
+	if *policy != "" {
+		pJSON, err := loadPolicy(*policy)
+		if err != nil {
+			logger.Println(err)
+			return 1
+		}
+		policies = append(policies, pJSON)
+	}
+
+	w := watcher{
+		load: func(ctx context.Context) (CAnnectJSON, error) {
+			ctx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(*configTimeout))
+			defer cancel()
+
+			cntJSON, err := loadConfig(ctx, catalog.String(), order.String(), catalogOrder.String(), flgs)
+			if err != nil {
+				return cntJSON, err
+			}
+
+			for _, pJSON := range policies {
+				err = pJSON.check(cntJSON)
+				if err != nil {
+					return cntJSON, err
+				}
+			}
+
+			return cntJSON, nil
+		},
+		configFiles: configFiles,
+		interval:    *interval,
+		poll:        *poll,
+		quota:       quota,
+		timeout:     time.Second * time.Duration(*configTimeout+*timeout),
+		textfile:    *metricsTextfile,
+	}
+
+	if *stateURI != "" {
+		w.state, err = newStateStore(*stateURI, cfg)
+		if err != nil {
+			logger.Println(err)
+			return 1
+		}
+	}
+
+	if *metricsTextfile != "" || *metricsAddr != "" {
+		w.metrics = metricsapi.NewRegistry()
+		cfg.Metrics = w.metrics
+	}
+
+	w.tracer, err = newTracerProvider(context.Background())
+	if err != nil {
+		logger.Println(err)
+		return 1
+	}
+	if w.tracer != nil {
+		cfg.Tracer = w.tracer
+	}
+
+	if *metricsAddr != "" {
+		srv, err := serveMetrics(*metricsAddr, w.metrics, logger)
+		if err != nil {
+			logger.Println(err)
+			return 1
+		}
+		defer srv.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w.run(traceapi.ContextFromEnv(ctx), cfg, logger)
+	return 0
+}
+
+// serveMetrics serves the metrics at "/metrics" of the address until the
+// server is closed.
+func serveMetrics(addr string, registry *metricsapi.Registry, logger *log.Logger) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		err := srv.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Println(err)
+		}
+	}()
+
+	return srv, nil
+}
+
+// tenantsMain supervises the watch commands of the tenants until the
+// interrupt. The config files are specified per tenant, so the options of
+// them are not allowed.
+func tenantsMain(name string, fs *flag.FlagSet, hasConfig bool) int {
+	if hasConfig {
+		log.Println(msgs.Sprintf(msgWatchUsage))
+		return 1
+	}
+
+	tJSON, err := loadTenants(name)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = superviseTenants(ctx, tJSON, fs, log.New(os.Stdout, "", log.LstdFlags))
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	return 0
+}
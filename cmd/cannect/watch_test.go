@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metricsapi "github.com/yuxki/cannect/pkg/metrics"
+)
+
+func testWaitFile(t *testing.T, name, want string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := os.ReadFile(name)
+		if err == nil && string(got) == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to be %q but got: %q, %v", name, want, got, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRun_Digests(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestRun_Digests"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	out := path.Join(dir, "root-ca.crt")
+	envOut := path.Join(dir, "cannect.env")
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URIs: []string{"file://" + out, "env://ROOT_CA"}},
+		},
+	}
+
+	cfg := newRunConfig(envOut, 5, false)
+	cfg.Digests = newDigestCache()
+
+	var logs bytes.Buffer
+	err = run(context.TODO(), jsn, cfg, log.New(&logs, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modify the destinations, to see they are not rewritten.
+	for _, name := range []string{out, envOut} {
+		err = os.WriteFile(name, []byte("modified"), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = run(context.TODO(), jsn, cfg, log.New(&logs, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(got), "modified"); diff != "" {
+		t.Error(diff)
+	}
+
+	// The env file is compared with the file itself, so it is rewritten.
+	got, err = os.ReadFile(envOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) == "modified" {
+		t.Error("Expected the env file to be rewritten")
+	}
+
+	if !bytes.Contains(logs.Bytes(), []byte(msgs.Sprintf(msgUnchanged, "file://"+out))) {
+		t.Errorf("Expected the unchanged log but got: %s", logs.String())
+	}
+
+	err = run(context.TODO(), jsn, cfg, log.New(&logs, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(logs.Bytes(), []byte(msgs.Sprintf(msgUnchanged, envOut))) {
+		t.Errorf("Expected the unchanged log but got: %s", logs.String())
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestWatcher_Run"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	src := path.Join(dir, "root-ca.crt")
+	out := path.Join(dir, "root-ca.out")
+
+	first, err := os.ReadFile("testdata/root-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := os.ReadFile("testdata/sub-ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(src, first, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://" + src, Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + out},
+		},
+	}
+
+	w := watcher{
+		load:     func(context.Context) (CAnnectJSON, error) { return jsn, nil },
+		interval: time.Hour,
+		poll:     10 * time.Millisecond,
+		timeout:  5 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.run(ctx, newRunConfig(path.Join(dir, "cannect.env"), 5, false), log.New(io.Discard, "", 0))
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	testWaitFile(t, out, string(first))
+
+	err = os.WriteFile(src, second, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Move the modification time, in case the file system has the coarse one.
+	modTime := time.Now().Add(time.Minute)
+	err = os.Chtimes(src, modTime, modTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testWaitFile(t, out, string(second))
+}
+
+func TestWatcher_Sync_Metrics(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestWatcher_Sync_Metrics"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	out := path.Join(dir, "root-ca.crt")
+	jsn := CAnnectJSON{
+		Catalogs: []CatalogJSON{
+			{Alias: "root-ca.crt", URI: "file://testdata/root-ca.crt", Category: "certificate"},
+			{Alias: "missing.crt", URI: "file://" + path.Join(dir, "missing.crt"), Category: "certificate"},
+		},
+		Orders: []OrderJSON{
+			{CatalogAliases: []string{"root-ca.crt"}, URI: "file://" + out},
+			{CatalogAliases: []string{"missing.crt"}, URI: "file://" + path.Join(dir, "missing.out")},
+		},
+	}
+
+	w := watcher{
+		load:     func(context.Context) (CAnnectJSON, error) { return jsn, nil },
+		timeout:  5 * time.Second,
+		metrics:  metricsapi.NewRegistry(),
+		textfile: path.Join(dir, "cannect.prom"),
+	}
+
+	cfg := newRunConfig(path.Join(dir, "cannect.env"), 5, false)
+	cfg.Digests = newDigestCache()
+	cfg.KeepGoing = true
+	cfg.Metrics = w.metrics
+	for i := 0; i < 2; i++ {
+		w.sync(context.TODO(), cfg, log.New(io.Discard, "", 0))
+	}
+
+	got, err := os.ReadFile(w.textfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sample := range []string{
+		`cannect_orders_total{uri="file://` + out + `",result="written"} 1`,
+		`cannect_orders_total{uri="file://` + out + `",result="skipped"} 1`,
+		`cannect_orders_total{uri="file://` + path.Join(dir, "missing.out") + `",result="failure"} 2`,
+		`cannect_fetches_total{alias="missing.crt",uri="file://` + path.Join(dir, "missing.crt") + `",result="failure"} 2`,
+	} {
+		if !bytes.Contains(got, []byte(sample+"\n")) {
+			t.Errorf("Expected %s but got: %s", sample, got)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package cannect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yuxki/cannect/pkg/asset"
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+var (
+	ErrAliasNotFound          = errors.New("alias in destination not found in sources")
+	ErrUndefinedCategory      = errors.New("undefined category")
+	ErrEmptyCategoryName      = errors.New("name of category must be specified")
+	ErrCategoryDuplicated     = errors.New("category must not be duplicated")
+	ErrInvalidCategoryPattern = errors.New("invalid category pattern")
+)
+
+// BuiltinCategories are the categories supported by cannect.
+var BuiltinCategories = []string{
+	asset.CertCategory, asset.PrivKeyCategory, asset.EncPrivKeyCategory, asset.CRLCategory,
+	asset.PubKeyCategory, asset.SSHPubKeyCategory, asset.SSHPrivKeyCategory, asset.PKCS7Category,
+	asset.DERCertCategory, asset.DERCRLCategory, asset.BundleCategory,
+}
+
+// Config is the catalogs and the orders of the config of the cannect command.
+// It has the fields built with catalog.New and order.New, and the other fields
+// of the command, like the filters and the hooks, are rejected by LoadConfig,
+// so a config is never run with a part of it ignored.
+type Config struct {
+	Schema     string           `json:"$schema,omitempty"`
+	Categories []CategoryConfig `json:"categories,omitempty"`
+	Catalogs   []CatalogConfig  `json:"catalogs"`
+	Orders     []OrderConfig    `json:"orders"`
+}
+
+// CategoryConfig defines the category of the CA-adjacent assets that cannect
+// does not support. The content must match the Pattern, or is not validated if
+// the Pattern is "none".
+type CategoryConfig struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// CatalogConfig is the source of the contents. The Categories combine the PEM
+// categories, and the Category is used if it is empty. The Timeout is the
+// number of seconds of each fetch.
+type CatalogConfig struct {
+	Alias       string   `json:"alias"`
+	URI         string   `json:"uri"`
+	Category    string   `json:"category,omitempty"`
+	Categories  []string `json:"categories,omitempty"`
+	Timeout     int64    `json:"timeout,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+}
+
+// OrderConfig is the destination of the contents of the catalogs of the
+// aliases. The URIs is used when the contents are fanned out to several
+// destinations. The Timeout is the number of seconds of the order.
+type OrderConfig struct {
+	CatalogAliases []string `json:"aliases"`
+	URI            string   `json:"uri,omitempty"`
+	URIs           []string `json:"uris,omitempty"`
+	Timeout        int64    `json:"timeout,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Owner          string   `json:"owner,omitempty"`
+}
+
+// uris returns the destinations of the order.
+func (o OrderConfig) uris() []string {
+	if o.URI == "" {
+		return o.URIs
+	}
+
+	return append([]string{o.URI}, o.URIs...)
+}
+
+// LoadConfig decodes the JSON config file of the name. The unknown fields are
+// rejected, so the typos of the field names are not ignored.
+func LoadConfig(name string) (Config, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return Config{}, err
+	}
+	defer file.Close()
+
+	var cfg Config
+
+	dec := json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&cfg)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return cfg, nil
+}
+
+// Options are the options of the catalogs and the orders built by
+// BuildOrders, in addition to the ones of the config.
+type Options struct {
+	CatalogOptions []catalogapi.Option
+	OrderOptions   []orderapi.Option
+	// FetchTimeout limits each fetch of the catalogs without their own
+	// timeout.
+	FetchTimeout time.Duration
+}
+
+// Option sets the Options.
+type Option func(*Options)
+
+// WithCatalogOptions adds the options of all the catalogs, like
+// catalog.WithHTTPClient.
+func WithCatalogOptions(opts ...catalogapi.Option) Option {
+	return func(o *Options) {
+		o.CatalogOptions = append(o.CatalogOptions, opts...)
+	}
+}
+
+// WithOrderOptions adds the options of all the orders, like order.WithStdout.
+func WithOrderOptions(opts ...orderapi.Option) Option {
+	return func(o *Options) {
+		o.OrderOptions = append(o.OrderOptions, opts...)
+	}
+}
+
+// WithFetchTimeout limits each fetch of the catalogs without their own
+// timeout, like the -fetch-timeout option of the command.
+func WithFetchTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.FetchTimeout = timeout
+	}
+}
+
+// BuildOrders returns the Runner of the orders of the config, like the cannect
+// command. The orders are named by the URIs of their destinations. The
+// catalogs of the same source are shared by the orders with the Sources, so
+// each source is fetched once.
+func BuildOrders(cfg Config, opts ...Option) (*Runner, error) {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	custom, err := CategoryCheckers(cfg.Categories)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs := make(map[string]CatalogConfig, len(cfg.Catalogs))
+	for _, cCfg := range cfg.Catalogs {
+		catalogs[cCfg.Alias] = cCfg
+	}
+
+	sources := NewSources()
+	catalogSets := make([][]orderapi.Catalog, 0, len(cfg.Orders))
+	for _, oCfg := range cfg.Orders {
+		catalogSet := make([]orderapi.Catalog, 0, len(oCfg.CatalogAliases))
+		for _, alias := range oCfg.CatalogAliases {
+			cCfg, ok := catalogs[alias]
+			if !ok {
+				return nil, fmt.Errorf("%s: %w", alias, ErrAliasNotFound)
+			}
+
+			key, err := cCfg.sharedKey()
+			if err != nil {
+				return nil, err
+			}
+
+			catalog, err := sources.Catalog(key, len(oCfg.uris()), func() (orderapi.Catalog, error) {
+				return cCfg.build(custom, o)
+			})
+			if err != nil {
+				return nil, err
+			}
+			catalogSet = append(catalogSet, catalog)
+		}
+		catalogSets = append(catalogSets, catalogSet)
+	}
+	sources.Stream()
+
+	runner := NewRunner()
+	for idx, oCfg := range cfg.Orders {
+		for _, uriText := range oCfg.uris() {
+			oOpts := append([]orderapi.Option{orderapi.WithCatalogs(catalogSets[idx]...)}, o.OrderOptions...)
+			if oCfg.Timeout > 0 {
+				oOpts = append(oOpts, orderapi.WithTimeout(time.Second*time.Duration(oCfg.Timeout)))
+			}
+
+			order, err := orderapi.New(uriText, oOpts...)
+			if err != nil {
+				return nil, err
+			}
+			runner.Add(uriText, order)
+		}
+	}
+
+	return runner, nil
+}
+
+// build returns the catalog of the config.
+func (c CatalogConfig) build(custom map[string]asset.Checker, o Options) (orderapi.Catalog, error) {
+	checker, err := c.checker(custom)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := o.FetchTimeout
+	if c.Timeout > 0 {
+		timeout = time.Second * time.Duration(c.Timeout)
+	}
+
+	cOpts := append([]catalogapi.Option{}, o.CatalogOptions...)
+	if timeout > 0 {
+		cOpts = append(cOpts, catalogapi.WithTimeout(timeout))
+	}
+
+	return catalogapi.New(c.URI, c.Alias, checker, cOpts...)
+}
+
+// checker returns the Checker of the categories of the catalog.
+func (c CatalogConfig) checker(custom map[string]asset.Checker) (asset.Checker, error) {
+	if len(c.Categories) == 0 {
+		return CategoryChecker(c.Category, custom)
+	}
+
+	checkers := make([]asset.Checker, 0, len(c.Categories))
+	for _, category := range c.Categories {
+		checker, err := CategoryChecker(category, custom)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+
+	return asset.NewMulti(strings.Join(c.Categories, ","), checkers...), nil
+}
+
+// sharedKey returns the key of the catalog shared by the orders, which is the
+// normalized URI and the options changing the result of the fetch.
+func (c CatalogConfig) sharedKey() (string, error) {
+	c.URI = NormalizeURI(c.URI)
+	c.Alias, c.Description, c.Owner = "", "", ""
+
+	key, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return string(key), nil
+}
+
+// CategoryChecker returns the Checker of the built-in or the custom category.
+func CategoryChecker(category string, custom map[string]asset.Checker) (asset.Checker, error) {
+	switch category {
+	case asset.CertCategory:
+		return asset.NewCertiricate(), nil
+	case asset.PrivKeyCategory:
+		return asset.NewPrivateKey(), nil
+	case asset.EncPrivKeyCategory:
+		return asset.NewEncryptedPrivateKey(), nil
+	case asset.CRLCategory:
+		return asset.NewCRL(), nil
+	case asset.PubKeyCategory:
+		return asset.NewPublicKey(), nil
+	case asset.SSHPubKeyCategory:
+		return asset.NewSSHPublicKey(), nil
+	case asset.SSHPrivKeyCategory:
+		return asset.NewSSHPrivateKey(), nil
+	case asset.PKCS7Category:
+		return asset.NewPKCS7(), nil
+	case asset.DERCertCategory:
+		return asset.NewDERCertificate(), nil
+	case asset.DERCRLCategory:
+		return asset.NewDERCRL(), nil
+	case asset.BundleCategory:
+		return asset.NewBundle(), nil
+	}
+
+	checker, ok := custom[category]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", category, ErrUndefinedCategory)
+	}
+
+	return checker, nil
+}
+
+// NoneCategoryPattern is the pattern of the category whose content is not
+// validated.
+const NoneCategoryPattern = "none"
+
+// Checker returns the Checker of the category.
+func (c CategoryConfig) Checker() (asset.Custom, error) {
+	if c.Pattern == NoneCategoryPattern {
+		return asset.NewCustom(c.Name, nil), nil
+	}
+
+	reg, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return asset.Custom{}, fmt.Errorf("%s: %w", c.Pattern, ErrInvalidCategoryPattern)
+	}
+
+	return asset.NewCustom(c.Name, reg), nil
+}
+
+// CategoryCheckers returns the Checkers of the categories by their names.
+func CategoryCheckers(categories []CategoryConfig) (map[string]asset.Checker, error) {
+	checkers := make(map[string]asset.Checker, len(categories))
+	for _, category := range categories {
+		if category.Name == "" {
+			return nil, ErrEmptyCategoryName
+		}
+		for _, builtin := range BuiltinCategories {
+			if category.Name == builtin {
+				return nil, fmt.Errorf("%s: %w", category.Name, ErrCategoryDuplicated)
+			}
+		}
+		if _, ok := checkers[category.Name]; ok {
+			return nil, fmt.Errorf("%s: %w", category.Name, ErrCategoryDuplicated)
+		}
+
+		checker, err := category.Checker()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", category.Name, err)
+		}
+		checkers[category.Name] = checker
+	}
+
+	return checkers, nil
+}
+
+// Sources shares the catalogs of the same source among the orders, so the
+// source is fetched once for all of them. The catalog read by a single
+// destination streams its content instead, since it is not shared.
+type Sources struct {
+	shared map[string]*orderapi.SharedCatalog
+	reads  map[string]int
+}
+
+func NewSources() *Sources {
+	sources := &Sources{
+		shared: make(map[string]*orderapi.SharedCatalog),
+		reads:  make(map[string]int),
+	}
+
+	return sources
+}
+
+// Catalog returns the shared catalog of the key, which identifies the source
+// and the options changing the result of the fetch, like the normalized URI.
+// The catalog is built by the build if the key is new. The reads is the number
+// of the destinations reading the catalog.
+func (s *Sources) Catalog(key string, reads int, build func() (orderapi.Catalog, error)) (orderapi.Catalog, error) {
+	s.reads[key] += reads
+	if catalog, ok := s.shared[key]; ok {
+		return catalog, nil
+	}
+
+	catalog, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	shared := orderapi.NewSharedCatalog(catalog)
+	s.shared[key] = shared
+
+	return shared, nil
+}
+
+// Stream makes the catalogs read by a single destination stream their
+// contents. It is called after all the catalogs are added.
+func (s *Sources) Stream() {
+	for key, reads := range s.reads {
+		if reads == 1 {
+			s.shared[key].WithStream()
+		}
+	}
+}
+
+// NormalizeURI returns the URI whose scheme is in lower case, path is cleaned
+// and query parameters are sorted, or the URI itself if it cannot be parsed.
+func NormalizeURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Path != "" {
+		u.Path = path.Clean(u.Path)
+		u.RawPath = ""
+	}
+	u.RawQuery = u.Query().Encode()
+
+	return u.String()
+}
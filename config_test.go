@@ -0,0 +1,212 @@
+package cannect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("testdata", t.Name())
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	data := []struct {
+		testCase string
+		config   string
+		// want
+		cfg     Config
+		wantErr bool
+	}{
+		{
+			"OK",
+			`{"catalogs": [{"alias": "ca", "uri": "file://ca.crt", "category": "certificate", "timeout": 3}],
+			"orders": [{"aliases": ["ca"], "uri": "file://out.crt"}]}`,
+			Config{
+				Catalogs: []CatalogConfig{{Alias: "ca", URI: "file://ca.crt", Category: "certificate", Timeout: 3}},
+				Orders:   []OrderConfig{{CatalogAliases: []string{"ca"}, URI: "file://out.crt"}},
+			},
+			false,
+		},
+		{
+			"NG:unknown field",
+			`{"catalogs": [{"alias": "ca", "uri": "file://ca.crt", "category": "certificate", "filter": {}}],
+			"orders": [{"aliases": ["ca"], "uri": "file://out.crt"}]}`,
+			Config{},
+			true,
+		},
+		{"NG:invalid JSON", `{"catalogs": [`, Config{}, true},
+	}
+
+	for idx, d := range data {
+		name := filepath.Join(dir, string(rune('a'+idx))+".json")
+		err := os.WriteFile(name, []byte(d.config), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadConfig(name)
+		if (err != nil) != d.wantErr {
+			t.Fatalf("%s: Expected error %t but got: %v", d.testCase, d.wantErr, err)
+		}
+		if diff := cmp.Diff(cfg, d.cfg); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+
+	_, err = LoadConfig(filepath.Join(dir, "none.json"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected %#v error but got: %#v", os.ErrNotExist, err)
+	}
+}
+
+func TestBuildOrders(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("testdata", t.Name())
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	err = os.WriteFile(filepath.Join(dir, "token.txt"), []byte("token\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uri := "file://" + filepath.ToSlash(filepath.Join(dir, "token.txt"))
+	categories := []CategoryConfig{{Name: "token", Pattern: "^token\n$"}}
+
+	data := []struct {
+		testCase string
+		cfg      Config
+		// want
+		stdout string
+		err    error
+	}{
+		{
+			"OK",
+			Config{
+				Categories: categories,
+				Catalogs: []CatalogConfig{
+					{Alias: "a", URI: uri, Category: "token"},
+					{Alias: "b", URI: strings.Replace(uri, "/token.txt", "/./token.txt", 1), Category: "token"},
+				},
+				Orders: []OrderConfig{{CatalogAliases: []string{"a", "b"}, URI: "stdout://"}},
+			},
+			"token\ntoken\n",
+			nil,
+		},
+		{
+			"NG:alias not found",
+			Config{
+				Categories: categories,
+				Catalogs:   []CatalogConfig{{Alias: "a", URI: uri, Category: "token"}},
+				Orders:     []OrderConfig{{CatalogAliases: []string{"c"}, URI: "stdout://"}},
+			},
+			"",
+			ErrAliasNotFound,
+		},
+		{
+			"NG:undefined category",
+			Config{
+				Catalogs: []CatalogConfig{{Alias: "a", URI: uri, Category: "token"}},
+				Orders:   []OrderConfig{{CatalogAliases: []string{"a"}, URI: "stdout://"}},
+			},
+			"",
+			ErrUndefinedCategory,
+		},
+		{
+			"NG:category duplicated",
+			Config{
+				Categories: []CategoryConfig{{Name: "certificate", Pattern: NoneCategoryPattern}},
+				Catalogs:   []CatalogConfig{{Alias: "a", URI: uri, Category: "certificate"}},
+				Orders:     []OrderConfig{{CatalogAliases: []string{"a"}, URI: "stdout://"}},
+			},
+			"",
+			ErrCategoryDuplicated,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			runner, err := BuildOrders(d.cfg, WithOrderOptions(orderapi.WithStdout(&buf)))
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			err = runner.Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(buf.String(), d.stdout); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// testFetchCatalog counts the fetches.
+type testFetchCatalog struct {
+	fetched *int
+}
+
+func (c testFetchCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	*c.fetched++
+	return []byte("token\n"), nil
+}
+
+func TestSources(t *testing.T) {
+	t.Parallel()
+
+	var built, fetched int
+	build := func() (orderapi.Catalog, error) {
+		built++
+		return testFetchCatalog{fetched: &fetched}, nil
+	}
+
+	sources := NewSources()
+	for _, key := range []string{"a", "a", "b"} {
+		catalog, err := sources.Catalog(key, 1, build)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = catalog.Fetch(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sources.Stream()
+
+	if built != 2 || fetched != 2 {
+		t.Errorf("Expected 2 catalogs fetched once but got: built %d, fetched %d", built, fetched)
+	}
+	if sources.shared["a"].Streams() {
+		t.Error("Expected the catalog of the 2 reads not to stream")
+	}
+}
@@ -0,0 +1,152 @@
+// Package awsjson calls the AWS APIs that use the AWS JSON protocols, such as
+// Secrets Manager, Systems Manager and DynamoDB. The APIs are called with the
+// signature version 4 and the default credentials of the AWS SDK, so the
+// service packages of the SDK are not required.
+package awsjson
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// ErrNoCredentials means the default credentials of the AWS SDK are not found.
+var ErrNoCredentials = errors.New("no AWS credentials are configured")
+
+// APIError is used to represent an error response of the AWS JSON protocol.
+type APIError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+	status  string
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.status, e.Type, e.Message)
+}
+
+// HasCode reports whether the error is the exception named code.
+func (e APIError) HasCode(code string) bool {
+	// The type may be prefixed with the namespace like "namespace#Code".
+	return e.Type == code || strings.HasSuffix(e.Type, "#"+code)
+}
+
+// Client calls the API of a service. The protocol is the AWS JSON 1.1 by
+// default.
+type Client struct {
+	cfg     aws.Config
+	service string
+	prefix  string
+	version string
+	client  *http.Client
+}
+
+// NewClient returns the Client calling the API with the client, or the
+// default client if it is nil. The service is the name in the endpoint and the
+// signature, and the prefix is the one of the X-Amz-Target header.
+func NewClient(ctx context.Context, service, prefix string, client *http.Client) (Client, error) {
+	var optFns []func(*config.LoadOptions) error
+	if client != nil {
+		optFns = append(optFns, config.WithHTTPClient(client))
+	} else {
+		client = http.DefaultClient
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return Client{}, err
+	}
+
+	return Client{
+		cfg:     cfg,
+		service: service,
+		prefix:  prefix,
+		version: "1.1",
+		client:  client,
+	}, nil
+}
+
+// WithVersion sets the version of the protocol, like "1.0" of DynamoDB.
+func (c Client) WithVersion(version string) Client {
+	c.version = version
+	return c
+}
+
+// endpoint returns the URL of the API. The environment variable
+// "AWS_ENDPOINT_URL" overrides it, for VPC endpoints and local emulators.
+func (c Client) endpoint() string {
+	if ep := os.Getenv("AWS_ENDPOINT_URL"); ep != "" {
+		return ep
+	}
+
+	return fmt.Sprintf("https://%s.%s.amazonaws.com/", c.service, c.cfg.Region)
+}
+
+// Call calls the action with the input, and decodes the output to the out if
+// it is not nil. The error response is returned as the APIError.
+func (c Client) Call(ctx context.Context, action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-"+c.version)
+	req.Header.Set("X-Amz-Target", fmt.Sprintf("%s.%s", c.prefix, action))
+
+	if c.cfg.Credentials == nil {
+		return ErrNoCredentials
+	}
+	creds, err := c.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	err = v4.NewSigner().SignHTTP(
+		ctx, creds, req, hex.EncodeToString(sum[:]), c.service, c.cfg.Region, time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := APIError{status: resp.Status}
+		if err := json.Unmarshal(respBody, &apiErr); err != nil {
+			apiErr.Message = strings.TrimSpace(string(respBody))
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(respBody, out)
+}
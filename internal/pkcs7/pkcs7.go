@@ -0,0 +1,122 @@
+// Package pkcs7 reads and writes the certificates and the CRLs of the
+// degenerate PKCS #7 SignedData in RFC 2315, the bundles known as .p7b and
+// .p7c files. The signatures of the SignedData are not verified.
+package pkcs7
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// ErrNotSignedData means the content is not the SignedData of PKCS #7.
+var ErrNotSignedData = errors.New("content is not PKCS #7 SignedData")
+
+var (
+	oidData       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+)
+
+// contentInfo is the ContentInfo. The Content is the whole [0] EXPLICIT
+// element, since the asn1 package does not unwrap the RawValue.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue `asn1:"set"`
+}
+
+// Bundle is the certificates and the CRLs in the SignedData.
+type Bundle struct {
+	Certificates []*x509.Certificate
+	CRLs         []*x509.RevocationList
+}
+
+// Parse parses the DER of the ContentInfo of the SignedData.
+func Parse(der []byte) (Bundle, error) {
+	var bundle Bundle
+
+	var info contentInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return bundle, fmt.Errorf("%s: %w", err, ErrNotSignedData)
+	}
+	if len(rest) > 0 || !info.ContentType.Equal(oidSignedData) {
+		return bundle, ErrNotSignedData
+	}
+
+	var sd signedData
+	rest, err = asn1.Unmarshal(info.Content.Bytes, &sd)
+	if err != nil {
+		return bundle, fmt.Errorf("%s: %w", err, ErrNotSignedData)
+	}
+	if len(rest) > 0 {
+		return bundle, ErrNotSignedData
+	}
+
+	if len(sd.Certificates.Bytes) > 0 {
+		bundle.Certificates, err = x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return bundle, err
+		}
+	}
+
+	for rest := sd.CRLs.Bytes; len(rest) > 0; {
+		var raw asn1.RawValue
+		rest, err = asn1.Unmarshal(rest, &raw)
+		if err != nil {
+			return bundle, fmt.Errorf("%s: %w", err, ErrNotSignedData)
+		}
+
+		crl, err := x509.ParseRevocationList(raw.FullBytes)
+		if err != nil {
+			return bundle, err
+		}
+		bundle.CRLs = append(bundle.CRLs, crl)
+	}
+
+	return bundle, nil
+}
+
+// Marshal returns the DER of the ContentInfo of the degenerate SignedData
+// having the certificates and the CRLs, like "openssl crl2pkcs7".
+func Marshal(bundle Bundle) ([]byte, error) {
+	var certs, crls []byte
+	for _, cert := range bundle.Certificates {
+		certs = append(certs, cert.Raw...)
+	}
+	for _, crl := range bundle.CRLs {
+		crls = append(crls, crl.Raw...)
+	}
+
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+		ContentInfo:      contentInfo{ContentType: oidData},
+		SignerInfos:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+	}
+	if len(certs) > 0 {
+		sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs}
+	}
+	if len(crls) > 0 {
+		sd.CRLs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: crls}
+	}
+
+	content, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content},
+	})
+}
@@ -0,0 +1,85 @@
+package pkcs7
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+func TestMarshal_Parse(t *testing.T) {
+	t.Parallel()
+
+	ca, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlPEM, err := ca.Intermediate.CRLPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(crlPEM)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase string
+		bundle   Bundle
+	}{
+		{"Certificates", Bundle{Certificates: []*x509.Certificate{ca.Leaf.Cert, ca.Intermediate.Cert}}},
+		{"Certificates And CRLs", Bundle{Certificates: []*x509.Certificate{ca.Root.Cert}, CRLs: []*x509.RevocationList{crl}}},
+		{"Empty", Bundle{}},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			der, err := Marshal(d.bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Parse(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got.Certificates) != len(d.bundle.Certificates) || len(got.CRLs) != len(d.bundle.CRLs) {
+				t.Fatalf("Expected %d certificates and %d CRLs but got: %d, %d",
+					len(d.bundle.Certificates), len(d.bundle.CRLs), len(got.Certificates), len(got.CRLs))
+			}
+			for idx := range got.Certificates {
+				if !got.Certificates[idx].Equal(d.bundle.Certificates[idx]) {
+					t.Errorf("Expected the certificate %d in order", idx)
+				}
+			}
+			for idx := range got.CRLs {
+				if !bytes.Equal(got.CRLs[idx].Raw, d.bundle.CRLs[idx].Raw) {
+					t.Errorf("Expected the CRL %d in order", idx)
+				}
+			}
+		})
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	t.Parallel()
+
+	// The ContentInfo of the data, not the SignedData.
+	data := []byte{0x30, 0x0b, 0x06, 0x09, 0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x01, 0x07, 0x01}
+
+	for _, der := range [][]byte{nil, []byte("not DER"), data} {
+		_, err := Parse(der)
+		if !errors.Is(err, ErrNotSignedData) {
+			t.Errorf("Expected %#v error but got: %#v", ErrNotSignedData, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package testca
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+)
+
+// The names of the key of P-256 in the OpenSSH formats.
+const (
+	sshKeyType = "ecdsa-sha2-nistp256"
+	sshCurve   = "nistp256"
+)
+
+// sshBuffer builds the wire format of SSH in RFC 4251.
+type sshBuffer []byte
+
+func (b sshBuffer) uint32(v uint32) sshBuffer {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, v)
+
+	return append(b, buf...)
+}
+
+func (b sshBuffer) string(s []byte) sshBuffer {
+	return append(b.uint32(uint32(len(s))), s...)
+}
+
+func (b sshBuffer) mpint(n *big.Int) sshBuffer {
+	buf := n.Bytes()
+	if len(buf) > 0 && buf[0]&0x80 != 0 {
+		buf = append([]byte{0}, buf...)
+	}
+
+	return b.string(buf)
+}
+
+// sshPoint returns the uncompressed point of the public key.
+func (c *Cert) sshPoint() []byte {
+	//nolint:staticcheck // crypto/ecdh is not available in Go 1.19.
+	return elliptic.Marshal(c.Key.Curve, c.Key.X, c.Key.Y)
+}
+
+// sshPublicKey returns the public key in the wire format of SSH.
+func (c *Cert) sshPublicKey() []byte {
+	return sshBuffer{}.string([]byte(sshKeyType)).string([]byte(sshCurve)).string(c.sshPoint())
+}
+
+// SSHPublicKey returns the public key in the line of authorized_keys, with the
+// common name of the certificate as the comment.
+func (c *Cert) SSHPublicKey() []byte {
+	line := sshKeyType + " " + base64.StdEncoding.EncodeToString(c.sshPublicKey()) + " " + c.Cert.Subject.CommonName
+
+	return []byte(line + "\n")
+}
+
+// SSHPrivateKeyPEM returns the private key in the PEM of the unencrypted
+// OpenSSH private key format without a passphrase.
+func (c *Cert) SSHPrivateKeyPEM() ([]byte, error) {
+	check := make([]byte, 4)
+	_, err := rand.Read(check)
+	if err != nil {
+		return nil, err
+	}
+	checkInt := binary.BigEndian.Uint32(check)
+
+	private := sshBuffer{}.uint32(checkInt).uint32(checkInt).
+		string([]byte(sshKeyType)).string([]byte(sshCurve)).string(c.sshPoint()).mpint(c.Key.D).
+		string([]byte(c.Cert.Subject.CommonName))
+	// Pad the private section to the block size of the "none" cipher.
+	for idx := byte(1); len(private)%8 != 0; idx++ {
+		private = append(private, idx)
+	}
+
+	buf := append(sshBuffer("openssh-key-v1\x00"), sshBuffer{}.
+		string([]byte("none")).string([]byte("none")).string(nil).uint32(1).
+		string(c.sshPublicKey()).string(private)...)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: buf}), nil
+}
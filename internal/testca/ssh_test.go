@@ -0,0 +1,43 @@
+package testca
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCert_SSH(t *testing.T) {
+	t.Parallel()
+
+	ca, err := New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := strings.Fields(string(ca.Leaf.SSHPublicKey()))
+	if len(fields) != 3 || fields[0] != sshKeyType || fields[2] != "localhost" {
+		t.Fatalf("Expected the line of authorized_keys but got: %v", fields)
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(blob, ca.Leaf.sshPublicKey()) {
+		t.Error("Expected the public key of the leaf")
+	}
+
+	buf, err := ca.Leaf.SSHPrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil || block.Type != "OPENSSH PRIVATE KEY" {
+		t.Fatalf("Expected the OpenSSH private key but got: %s", buf)
+	}
+	if !bytes.HasPrefix(block.Bytes, []byte("openssh-key-v1\x00")) || !bytes.Contains(block.Bytes, blob) {
+		t.Error("Expected the OpenSSH private key of the leaf")
+	}
+}
@@ -0,0 +1,389 @@
+// Package testca generates a small CA hierarchy of a root CA, an intermediate
+// CA and a leaf, and their keys and CRLs, for the tests and the self-tests of
+// cannect. The assets must never be used as the real CA materials.
+package testca
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+)
+
+var (
+	// ErrNotCA means the CRL is requested to the certificate that is not a CA.
+	ErrNotCA = errors.New("certificate is not a CA")
+	// ErrUnsupportedEncryption means the encrypted private key is not the one
+	// of EncryptedKeyPEM.
+	ErrUnsupportedEncryption = errors.New("unsupported encryption of private key")
+	// ErrDecryption means the encrypted private key can not be decrypted with
+	// the passphrase.
+	ErrDecryption = errors.New("failed to decrypt private key")
+)
+
+// Cert is a certificate and its private key in the hierarchy.
+type Cert struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+	now  time.Time
+}
+
+// CA is the hierarchy of the root CA, the intermediate CA issued by the root,
+// and the leaf for "localhost" issued by the intermediate.
+type CA struct {
+	Root         *Cert
+	Intermediate *Cert
+	Leaf         *Cert
+}
+
+// New generates the hierarchy whose certificates are valid at the time.
+func New(now time.Time) (*CA, error) {
+	root, err := Issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cannect Test Root CA"},
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            1,
+	}, nil, now)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediate, err := Issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "cannect Test Intermediate CA"},
+		NotAfter:              now.AddDate(5, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}, root, now)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := Issue(&x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotAfter:     now.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, intermediate, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{Root: root, Intermediate: intermediate, Leaf: leaf}, nil
+}
+
+// Template returns the template of the certificate of the serial and the
+// common name, valid from an hour before the time to an hour after it.
+func Template(serial int64, cn string, now time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+	}
+}
+
+// CATemplate returns the Template of the CA certificate, which signs the
+// certificates and the CRLs.
+func CATemplate(serial int64, cn string, now time.Time) *x509.Certificate {
+	tmpl := Template(serial, cn, now)
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	tmpl.BasicConstraintsValid = true
+	tmpl.IsCA = true
+
+	return tmpl
+}
+
+// Issue generates the key and the certificate of the template, issued by the
+// issuer, or self-signed if the issuer is nil. The NotBefore of the template
+// is set to an hour before the time if it is zero.
+func Issue(tmpl *x509.Certificate, issuer *Cert, now time.Time) (*Cert, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if tmpl.NotBefore.IsZero() {
+		tmpl.NotBefore = now.Add(-time.Hour)
+	}
+	var parent *x509.Certificate
+	var signer crypto.Signer = key
+	if issuer != nil {
+		parent, signer = issuer.Cert, issuer.Key
+	}
+
+	cert, err := Sign(tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cert{Cert: cert, Key: key, now: now}, nil
+}
+
+// Sign generates the certificate of the template for the public key, signed
+// by the parent with the signer, or self-signed if the parent is nil. It is
+// for the keys other than the ECDSA of Issue, and the certificates sharing a
+// key, like the cross-signed ones.
+func Sign(tmpl, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	if parent == nil {
+		parent = tmpl
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+// CertPEM returns the certificate in PEM.
+func (c *Cert) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+}
+
+// KeyPEM returns the private key in the PKCS #8 PEM.
+func (c *Cert) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// PublicKeyPEM returns the public key in the PKIX PEM.
+func (c *Cert) PublicKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(&c.Key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// EncryptedKeyPEM returns the private key encrypted with the passphrase, in
+// the PKCS #8 PEM of PBES2, like "openssl pkcs8 -topk8 -v2 aes256".
+func (c *Cert) EncryptedKeyPEM(passphrase []byte) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := encryptPKCS8(der, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encrypted}), nil
+}
+
+// CRLPEM returns the empty CRL issued by the CA in PEM.
+func (c *Cert) CRLPEM() ([]byte, error) {
+	if !c.Cert.IsCA {
+		return nil, ErrNotCA
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: c.now.Add(-time.Hour),
+		NextUpdate: c.now.AddDate(0, 0, 7),
+	}, c.Cert, c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+// The object identifiers of PBES2 with PBKDF2, HMAC-SHA256 and AES-256-CBC.
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+const pbkdf2Iterations = 100000
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	PRF            pkix.AlgorithmIdentifier
+}
+
+// encryptPKCS8 encrypts the PKCS #8 private key with the passphrase, in the
+// EncryptedPrivateKeyInfo of PBES2.
+func encryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	for _, b := range [][]byte{salt, iv} {
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := aes.NewCipher(pbkdf2SHA256(passphrase, salt, pbkdf2Iterations, 32))
+	if err != nil {
+		return nil, err
+	}
+
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	encrypted := append(append([]byte(nil), der...), make([]byte, padding)...)
+	for i := len(der); i < len(encrypted); i++ {
+		encrypted[i] = byte(padding)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbkdf2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+}
+
+// DecryptKeyPEM decrypts the PEM of EncryptedKeyPEM with the passphrase, and
+// returns the PKCS #8 DER of the private key.
+func DecryptKeyPEM(data, passphrase []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "ENCRYPTED PRIVATE KEY" {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	var info encryptedPrivateKeyInfo
+	_, err := asn1.Unmarshal(block.Bytes, &info)
+	if err != nil {
+		return nil, ErrUnsupportedEncryption
+	}
+	var params pbes2Params
+	_, err = asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params)
+	if err != nil {
+		return nil, ErrUnsupportedEncryption
+	}
+	var kdf pbkdf2Params
+	_, err = asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf)
+	if err != nil {
+		return nil, ErrUnsupportedEncryption
+	}
+	var iv []byte
+	_, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv)
+	if err != nil {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	// Check the algorithms are the ones of encryptPKCS8
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) ||
+		!params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) ||
+		!kdf.PRF.Algorithm.Equal(oidHMACSHA256) ||
+		!params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	// Check the length of the data is the multiple of the block
+	data = info.EncryptedData
+	if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, ErrDecryption
+	}
+
+	cb, err := aes.NewCipher(pbkdf2SHA256(passphrase, kdf.Salt, kdf.IterationCount, 32))
+	if err != nil {
+		return nil, err
+	}
+	der := make([]byte, len(data))
+	cipher.NewCBCDecrypter(cb, iv).CryptBlocks(der, data)
+
+	// Check the padding, which is broken by the wrong passphrase
+	padding := int(der[len(der)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, ErrDecryption
+	}
+	for _, b := range der[len(der)-padding:] {
+		if int(b) != padding {
+			return nil, ErrDecryption
+		}
+	}
+
+	// Check the key is parsed, in case the padding happens to be valid
+	der = der[:len(der)-padding]
+	_, err = x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	return der, nil
+}
+
+// pbkdf2SHA256 derives the key of the length with PBKDF2 of RFC 8018 and
+// HMAC-SHA256.
+func pbkdf2SHA256(password, salt []byte, iterations, length int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < length; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		_ = binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+
+	return key[:length]
+}
@@ -0,0 +1,139 @@
+package testca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	ca, err := New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	roots.AddCert(ca.Root.Cert)
+	intermediates.AddCert(ca.Intermediate.Cert)
+	chains, err := ca.Leaf.Cert.Verify(x509.VerifyOptions{
+		DNSName: "localhost", Roots: roots, Intermediates: intermediates,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chains) != 1 || len(chains[0]) != 3 {
+		t.Errorf("Expected the chain of 3 certificates but got: %v", chains)
+	}
+
+	for _, issuer := range []*Cert{ca.Root, ca.Intermediate} {
+		buf, err := issuer.CRLPEM()
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(buf)
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := crl.CheckSignatureFrom(issuer.Cert); err != nil {
+			t.Error(err)
+		}
+	}
+
+	_, err = ca.Leaf.CRLPEM()
+	if !errors.Is(err, ErrNotCA) {
+		t.Errorf("Expected %#v error but got: %#v", ErrNotCA, err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	root, err := Issue(CATemplate(1, "Root CA", now), nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := Issue(CATemplate(2, "Sub CA", now), root, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The self-signed certificate of the key of the sub CA shares its key.
+	cross, err := Sign(CATemplate(3, "Sub CA", now), nil, &sub.Key.PublicKey, sub.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sub.Key.PublicKey.Equal(cross.PublicKey) {
+		t.Error("Expected the certificates share the key")
+	}
+
+	if err := sub.Cert.CheckSignatureFrom(root.Cert); err != nil {
+		t.Error(err)
+	}
+	if err := cross.CheckSignatureFrom(cross); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDecryptKeyPEM(t *testing.T) {
+	t.Parallel()
+
+	ca, err := New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPEM, err := ca.Leaf.KeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encPEM, err := ca.Leaf.EncryptedKeyPEM([]byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(keyPEM)
+
+	data := []struct {
+		testCase   string
+		data       []byte
+		passphrase string
+		// want
+		err error
+	}{
+		{"OK", encPEM, "passphrase", nil},
+		{"NG:Passphrase", encPEM, "wrong", ErrDecryption},
+		{"NG:Not Encrypted", keyPEM, "passphrase", ErrUnsupportedEncryption},
+	}
+
+	for _, d := range data {
+		der, err := DecryptKeyPEM(d.data, []byte(d.passphrase))
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+		if err == nil && !bytes.Equal(der, block.Bytes) {
+			t.Errorf("%s: Expected the private key but got: %x", d.testCase, der)
+		}
+	}
+}
+
+func TestPBKDF2SHA256(t *testing.T) {
+	t.Parallel()
+
+	// The test vector of PBKDF2-HMAC-SHA256 from RFC 7914.
+	want := []byte{
+		0x55, 0xac, 0x04, 0x6e, 0x56, 0xe3, 0x08, 0x9f, 0xec, 0x16, 0x91, 0xc2, 0x25, 0x44, 0xb6, 0x05,
+		0xf9, 0x41, 0x85, 0x21, 0x6d, 0xde, 0x04, 0x65, 0xe6, 0x8b, 0x9d, 0x57, 0xc2, 0x0d, 0xac, 0xbc,
+		0x49, 0xca, 0x9c, 0xcc, 0xf1, 0x79, 0xb6, 0x45, 0x99, 0x16, 0x64, 0xb3, 0x9d, 0x77, 0xef, 0x31,
+		0x7c, 0x71, 0xb8, 0x45, 0xb1, 0xe3, 0x0b, 0xd5, 0x09, 0x11, 0x20, 0x41, 0xd3, 0xa1, 0x97, 0x83,
+	}
+	got := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected %x but got: %x", want, got)
+	}
+}
@@ -0,0 +1,61 @@
+package asset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrChainOrder means a certificate in the content is not followed by its
+	// issuer.
+	ErrChainOrder = errors.New("certificates are not in the order of the chain")
+	// ErrChainSignature means a certificate in the content is not signed by the
+	// next certificate.
+	ErrChainSignature = errors.New("certificate is not signed by the next certificate")
+	// ErrChainIncomplete means the content has no certificate, or its last
+	// certificate is not a self-signed root.
+	ErrChainIncomplete = errors.New("chain does not end with a root certificate")
+)
+
+// Chain verifies that the certificates in the content are a complete chain in
+// the order from the leaf to the root: every certificate is followed by its
+// issuer and signed by it, and the last certificate is a self-signed root.
+// The blocks other than the certificates are ignored.
+type Chain struct{}
+
+func NewChain() Chain {
+	return Chain{}
+}
+
+func (c Chain) CheckContent(content []byte) error {
+	certs, err := parseCertificates(content)
+	if err != nil {
+		return err
+	}
+
+	if len(certs) == 0 {
+		return ErrChainIncomplete
+	}
+
+	for idx := 0; idx < len(certs)-1; idx++ {
+		cert, issuer := certs[idx], certs[idx+1]
+
+		if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+			return fmt.Errorf("certificate %d (%s) is issued by %s, but followed by %s: %w",
+				idx, cert.Subject, cert.Issuer, issuer.Subject, ErrChainOrder)
+		}
+
+		err := cert.CheckSignatureFrom(issuer)
+		if err != nil {
+			return fmt.Errorf("certificate %d (%s): %s: %w", idx, cert.Subject, err, ErrChainSignature)
+		}
+	}
+
+	if last := certs[len(certs)-1]; !isSelfSigned(last) {
+		return fmt.Errorf("certificate %d (%s) is issued by %s: %w",
+			len(certs)-1, last.Subject, last.Issuer, ErrChainIncomplete)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package asset
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoCrossSigned means the content does not contain cross-signed
+	// certificates.
+	ErrNoCrossSigned = errors.New("cross-signed certificates are not found")
+	// ErrCrossSignedPath means the content can not be validated through one of
+	// the cross-signed certificates.
+	ErrCrossSignedPath = errors.New("certification path via cross-signed certificate is invalid")
+)
+
+// CrossSigned verifies that the content is a certificate bundle that contains
+// the cross-signed certificates, that are the certificates that have the same
+// subject and public key but are issued by different issuers. It verifies
+// that the leaf certificate of the bundle validates via every cross-signed
+// certificate, so the bundle works for the clients that trust either of the
+// old or new root CAs. The self-signed certificates in the bundle are used as
+// the root CAs, or the system roots are used if the bundle has none.
+type CrossSigned struct{}
+
+func NewCrossSigned() CrossSigned {
+	return CrossSigned{}
+}
+
+func (c CrossSigned) CheckContent(content []byte) error {
+	certs, err := parseCertificates(content)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string][]*x509.Certificate)
+	for _, cert := range certs {
+		key := string(cert.RawSubject) + string(cert.RawSubjectPublicKeyInfo)
+		groups[key] = append(groups[key], cert)
+	}
+
+	roots, intermediates, leaf, err := splitBundle(certs)
+	if err != nil {
+		return err
+	}
+
+	found := false
+
+	for _, cert := range certs {
+		key := string(cert.RawSubject) + string(cert.RawSubjectPublicKeyInfo)
+		variants := groups[key]
+		if len(variants) < 2 || isSelfSigned(cert) {
+			continue
+		}
+		found = true
+
+		// Build the path only with this variant of the cross-signed certificates.
+		pool := x509.NewCertPool()
+		for _, inter := range intermediates {
+			if containsCert(variants, inter) && !inter.Equal(cert) {
+				continue
+			}
+			pool.AddCert(inter)
+		}
+
+		target := leaf
+		if target == nil {
+			target = cert
+		}
+
+		_, err := target.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: pool,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return fmt.Errorf("via %s issued by %s: %s: %w", cert.Subject, cert.Issuer, err, ErrCrossSignedPath)
+		}
+	}
+
+	if !found {
+		return ErrNoCrossSigned
+	}
+
+	return nil
+}
+
+// parseCertificates parses all certificates in the PEM content.
+func parseCertificates(content []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	rest := content
+	for idx := 0; ; idx++ {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", idx, err)
+		}
+
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+// splitBundle splits the certificates into the roots, intermediates and the
+// leaf. The leaf is nil if every certificate is a CA certificate.
+func splitBundle(certs []*x509.Certificate) (*x509.CertPool, []*x509.Certificate, *x509.Certificate, error) {
+	var intermediates []*x509.Certificate
+	var leaf *x509.Certificate
+
+	roots := x509.NewCertPool()
+	hasRoot := false
+
+	for _, cert := range certs {
+		switch {
+		case isSelfSigned(cert):
+			roots.AddCert(cert)
+			hasRoot = true
+		case cert.IsCA:
+			intermediates = append(intermediates, cert)
+		case leaf == nil:
+			leaf = cert
+		}
+	}
+
+	if !hasRoot {
+		sys, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		roots = sys
+	}
+
+	return roots, intermediates, leaf, nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
+
+func containsCert(certs []*x509.Certificate, target *x509.Certificate) bool {
+	for _, cert := range certs {
+		if cert.Equal(target) {
+			return true
+		}
+	}
+
+	return false
+}
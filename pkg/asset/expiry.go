@@ -0,0 +1,108 @@
+package asset
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuxki/cannect/internal/pkcs7"
+)
+
+// ErrExpiring means a certificate in the content is expired, or expires within
+// the remaining validity required by ExpiryCheck.
+var ErrExpiring = errors.New("certificate is expired or expires within the remaining validity")
+
+// ExpiryCheck wraps a Checker, and additionally verifies that every
+// certificate in the content is valid for the remaining validity or longer,
+// so the chain containing an expired certificate is never distributed. The
+// certificates in the PKCS #7 bundles and in DER are verified too.
+type ExpiryCheck struct {
+	checker   Checker
+	remaining time.Duration
+	now       func() time.Time
+}
+
+func NewExpiryCheck(checker Checker, remaining time.Duration) ExpiryCheck {
+	return ExpiryCheck{checker: checker, remaining: remaining, now: time.Now}
+}
+
+// WithClock makes the ExpiryCheck verify the certificates at the time returned
+// by the now instead of the current time, like a fixed time of the tests.
+func (e ExpiryCheck) WithClock(now func() time.Time) ExpiryCheck {
+	e.now = now
+	return e
+}
+
+func (e ExpiryCheck) CheckContent(content []byte) error {
+	err := e.checker.CheckContent(content)
+	if err != nil {
+		return err
+	}
+
+	deadline := e.now().Add(e.remaining)
+
+	var violations []string
+	for idx, block := range contentBlocks(content) {
+		for _, cert := range blockCertificates(block) {
+			if cert.NotAfter.Before(deadline) {
+				violations = append(violations, fmt.Sprintf(
+					"block %d (%s): %s expires at %s", idx, block.Type, cert.Subject, cert.NotAfter.UTC().Format(time.RFC3339),
+				))
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(violations, ", "), ErrExpiring)
+	}
+
+	return nil
+}
+
+// contentBlocks returns the PEM blocks in the content, or the DER of the
+// certificates or the CRL as the blocks if the content has no PEM block.
+func contentBlocks(content []byte) []*pem.Block {
+	var blocks []*pem.Block
+
+	rest := content
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) == 0 {
+		return derBlocks(content)
+	}
+
+	return blocks
+}
+
+// blockCertificates returns the certificates in the "CERTIFICATE" block or the
+// "PKCS7" block. The blocks that can not be parsed are skipped, since they are
+// rejected by the Checker of the category.
+func blockCertificates(block *pem.Block) []*x509.Certificate {
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		return []*x509.Certificate{cert}
+	case "PKCS7":
+		bundle, err := pkcs7.Parse(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		return bundle.Certificates
+	}
+
+	return nil
+}
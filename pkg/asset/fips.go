@@ -0,0 +1,132 @@
+package asset
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const minFIPSRSABits = 2048
+
+// ErrNotFIPSApproved means the content uses an algorithm that is not approved
+// in FIPS 140.
+var ErrNotFIPSApproved = errors.New("not FIPS approved algorithm is used")
+
+// Checker verifies that the content in the asset as expected.
+type Checker interface {
+	CheckContent([]byte) error
+}
+
+// FIPS wraps a Checker, and additionally verifies that the certificates,
+// including the ones in the PKCS #7 bundles, CRLs, public keys and private keys
+// in the content use only FIPS approved
+// algorithms. Only RSA keys of 2048 bits or more, ECDSA keys on P-256, P-384
+// and P-521, and the signatures of them with SHA-2 are approved. The content
+// without PEM is inspected as the DER of the certificates or a CRL, and the
+// keys of OpenSSH are not inspected.
+type FIPS struct {
+	checker Checker
+}
+
+func NewFIPS(checker Checker) FIPS {
+	return FIPS{checker: checker}
+}
+
+func (f FIPS) CheckContent(content []byte) error {
+	err := f.checker.CheckContent(content)
+	if err != nil {
+		return err
+	}
+
+	var violations []string
+	for idx, block := range contentBlocks(content) {
+		reason := checkFIPSBlock(block)
+		if reason != "" {
+			violations = append(violations, fmt.Sprintf("block %d (%s): %s", idx, block.Type, reason))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(violations, ", "), ErrNotFIPSApproved)
+	}
+
+	return nil
+}
+
+// derBlocks returns the DER of the certificates or the CRL in the content as
+// the blocks of their types, or nil if the content is not either of them.
+func derBlocks(content []byte) []*pem.Block {
+	if certs, err := x509.ParseCertificates(content); err == nil {
+		blocks := make([]*pem.Block, 0, len(certs))
+		for _, cert := range certs {
+			blocks = append(blocks, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		}
+		return blocks
+	}
+	if _, err := x509.ParseRevocationList(content); err == nil {
+		return []*pem.Block{{Type: "X509 CRL", Bytes: content}}
+	}
+
+	return nil
+}
+
+// checkFIPSBlock returns the reason why the block is not approved, or an empty
+// string if the block is approved or can not be inspected.
+func checkFIPSBlock(block *pem.Block) string {
+	sigs, keys, err := blockAlgorithms(block)
+	if err != nil {
+		return err.Error()
+	}
+
+	for _, sig := range sigs {
+		if reason := checkFIPSSignature(sig); reason != "" {
+			return reason
+		}
+	}
+
+	for _, key := range keys {
+		if reason := checkFIPSKey(key); reason != "" {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+func checkFIPSSignature(algo x509.SignatureAlgorithm) string {
+	switch algo {
+	case x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS,
+		x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+		return ""
+	}
+
+	return fmt.Sprintf("signature algorithm %s", algo)
+}
+
+func checkFIPSKey(key interface{}) string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minFIPSRSABits {
+			return fmt.Sprintf("RSA key of %d bits", k.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		default:
+			return fmt.Sprintf("ECDSA curve %s", k.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		return "Ed25519 key"
+	default:
+		return fmt.Sprintf("key type %T", key)
+	}
+
+	return ""
+}
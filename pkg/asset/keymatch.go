@@ -0,0 +1,111 @@
+package asset
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoKeyPair means the content does not contain both of a certificate and
+	// a private key.
+	ErrNoKeyPair = errors.New("certificate and private key are not found")
+	// ErrKeyMismatch means a private key in the content does not correspond to
+	// any certificate in the content.
+	ErrKeyMismatch = errors.New("private key does not match the certificate")
+)
+
+// KeyMatch verifies that every private key in the content corresponds to the
+// public key of a certificate in the content, so that a server never gets a
+// certificate with the key of another one. The RSA, ECDSA and Ed25519 keys are
+// supported.
+type KeyMatch struct{}
+
+func NewKeyMatch() KeyMatch {
+	return KeyMatch{}
+}
+
+func (k KeyMatch) CheckContent(content []byte) error {
+	certs, err := parseCertificates(content)
+	if err != nil {
+		return err
+	}
+
+	var keys []crypto.PublicKey
+
+	rest := content
+	for idx := 0; ; idx++ {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		key, err := parsePrivateKey(block)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", idx, err)
+		}
+		if key != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(certs) == 0 || len(keys) == 0 {
+		return ErrNoKeyPair
+	}
+
+	for idx, key := range keys {
+		if !matchesAny(key, certs) {
+			return fmt.Errorf("private key %d: %w", idx, ErrKeyMismatch)
+		}
+	}
+
+	return nil
+}
+
+// parsePrivateKey returns the public key of the private key block, or nil if
+// the block is not a private key.
+func parsePrivateKey(block *pem.Block) (crypto.PublicKey, error) {
+	var key interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", key)
+	}
+
+	return signer.Public(), nil
+}
+
+// matchesAny reports whether the public key is the one of any certificate.
+func matchesAny(key crypto.PublicKey, certs []*x509.Certificate) bool {
+	pub, ok := key.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false
+	}
+
+	for _, cert := range certs {
+		if pub.Equal(cert.PublicKey) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,173 @@
+package asset
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yuxki/cannect/internal/pkcs7"
+)
+
+// ed25519Curve is the name of Ed25519 in the AllowedCurves of KeyPolicy.
+const ed25519Curve = "Ed25519"
+
+// ErrKeyPolicyViolation means a key or a signature in the content does not
+// meet the KeyPolicy.
+var ErrKeyPolicyViolation = errors.New("key policy is violated")
+
+// KeyPolicy defines the requirements for the strength of the keys and the
+// algorithms of the signatures.
+type KeyPolicy struct {
+	// MinRSABits is the minimum size of the RSA keys. It is not limited if it
+	// is zero.
+	MinRSABits int
+	// AllowedCurves is the list of the names of the allowed curves, like
+	// "P-256", and "Ed25519". Any curve is allowed if it is empty.
+	AllowedCurves []string
+	// BannedSignatureAlgorithms is the list of the names of the banned
+	// signature algorithms, like "SHA1-RSA" and "ECDSA-SHA1".
+	BannedSignatureAlgorithms []string
+}
+
+// KeyPolicyCheck wraps a Checker, and additionally verifies that the
+// certificates, including the ones in the PKCS #7 bundles, CRLs, public keys
+// and private keys in the content meet the KeyPolicy. The content without PEM
+// is inspected as the DER of the certificates or a CRL, and the keys of
+// OpenSSH are not inspected.
+type KeyPolicyCheck struct {
+	checker Checker
+	policy  KeyPolicy
+}
+
+func NewKeyPolicyCheck(checker Checker, policy KeyPolicy) KeyPolicyCheck {
+	return KeyPolicyCheck{checker: checker, policy: policy}
+}
+
+func (k KeyPolicyCheck) CheckContent(content []byte) error {
+	err := k.checker.CheckContent(content)
+	if err != nil {
+		return err
+	}
+
+	var violations []string
+	for idx, block := range contentBlocks(content) {
+		sigs, keys, err := blockAlgorithms(block)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", idx, err)
+		}
+
+		for _, reason := range k.policy.check(sigs, keys) {
+			violations = append(violations, fmt.Sprintf("block %d (%s): %s", idx, block.Type, reason))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(violations, ", "), ErrKeyPolicyViolation)
+	}
+
+	return nil
+}
+
+// check returns the reasons why the signatures and the keys do not meet the
+// policy.
+func (p KeyPolicy) check(sigs []x509.SignatureAlgorithm, keys []crypto.PublicKey) []string {
+	var reasons []string
+
+	for _, sig := range sigs {
+		for _, banned := range p.BannedSignatureAlgorithms {
+			if sig.String() == banned {
+				reasons = append(reasons, fmt.Sprintf("signature algorithm %s is banned", sig))
+			}
+		}
+	}
+
+	for _, key := range keys {
+		curve := ""
+
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			if k.N.BitLen() < p.MinRSABits {
+				reasons = append(reasons, fmt.Sprintf("RSA key of %d bits is less than %d", k.N.BitLen(), p.MinRSABits))
+			}
+			continue
+		case *ecdsa.PublicKey:
+			curve = k.Curve.Params().Name
+		case ed25519.PublicKey:
+			curve = ed25519Curve
+		default:
+			continue
+		}
+
+		if len(p.AllowedCurves) > 0 && !containsString(p.AllowedCurves, curve) {
+			reasons = append(reasons, fmt.Sprintf("curve %s is not allowed", curve))
+		}
+	}
+
+	return reasons
+}
+
+// blockAlgorithms returns the algorithms of the signatures and the public keys
+// in the certificates, the PKCS #7 bundles, the CRLs, the public keys and the
+// private keys. The other blocks have none of them.
+func blockAlgorithms(block *pem.Block) ([]x509.SignatureAlgorithm, []crypto.PublicKey, error) {
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []x509.SignatureAlgorithm{cert.SignatureAlgorithm}, []crypto.PublicKey{cert.PublicKey}, nil
+	case "X509 CRL":
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []x509.SignatureAlgorithm{crl.SignatureAlgorithm}, nil, nil
+	case "PKCS7":
+		bundle, err := pkcs7.Parse(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var sigs []x509.SignatureAlgorithm
+		var keys []crypto.PublicKey
+		for _, cert := range bundle.Certificates {
+			sigs = append(sigs, cert.SignatureAlgorithm)
+			keys = append(keys, cert.PublicKey)
+		}
+
+		return sigs, keys, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, []crypto.PublicKey{key}, nil
+	}
+
+	key, err := parsePrivateKey(block)
+	if err != nil || key == nil {
+		return nil, nil, err
+	}
+
+	return nil, []crypto.PublicKey{key}, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+
+	return false
+}
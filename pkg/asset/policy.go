@@ -0,0 +1,140 @@
+package asset
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCAPolicyViolation means a CA certificate in the content does not meet
+// the CAPolicy.
+var ErrCAPolicyViolation = errors.New("CA certificate policy is violated")
+
+// CAPolicy defines the requirements for the CA certificates, that is derived
+// from the CP/CPS of the Private CA.
+type CAPolicy struct {
+	// RequiredPolicies is the list of certificate policy OIDs that must be
+	// contained in the certificatePolicies extension.
+	RequiredPolicies []string
+	// ForbiddenPolicies is the list of certificate policy OIDs that must not
+	// be contained in the certificatePolicies extension.
+	ForbiddenPolicies []string
+	// MaxPathLen is the maximum pathLenConstraint of basicConstraints. The
+	// pathLenConstraint must be present if it is not nil.
+	MaxPathLen *int
+	// RequireNameConstraints requires the nameConstraints extension.
+	RequireNameConstraints bool
+	// PermittedDNSDomains is the list of DNS domains that must be contained in
+	// the permitted subtrees of nameConstraints.
+	PermittedDNSDomains []string
+}
+
+// CAPolicyCheck wraps a Checker, and additionally verifies that every CA
+// certificate in the content meets the CAPolicy. The certificates that are
+// not CA are not verified.
+type CAPolicyCheck struct {
+	checker Checker
+	policy  CAPolicy
+}
+
+func NewCAPolicyCheck(checker Checker, policy CAPolicy) CAPolicyCheck {
+	return CAPolicyCheck{checker: checker, policy: policy}
+}
+
+func (c CAPolicyCheck) CheckContent(content []byte) error {
+	err := c.checker.CheckContent(content)
+	if err != nil {
+		return err
+	}
+
+	var violations []string
+
+	rest := content
+	for idx := 0; ; idx++ {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", idx, err)
+		}
+
+		if !cert.IsCA {
+			continue
+		}
+
+		for _, reason := range c.policy.check(cert) {
+			violations = append(violations, fmt.Sprintf("block %d (%s): %s", idx, cert.Subject, reason))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(violations, ", "), ErrCAPolicyViolation)
+	}
+
+	return nil
+}
+
+// check returns the reasons why the certificate does not meet the policy.
+func (p CAPolicy) check(cert *x509.Certificate) []string {
+	var reasons []string
+
+	oids := make(map[string]struct{}, len(cert.PolicyIdentifiers))
+	for _, oid := range cert.PolicyIdentifiers {
+		oids[oid.String()] = struct{}{}
+	}
+
+	for _, oid := range p.RequiredPolicies {
+		if _, ok := oids[oid]; !ok {
+			reasons = append(reasons, fmt.Sprintf("policy %s is required", oid))
+		}
+	}
+
+	for _, oid := range p.ForbiddenPolicies {
+		if _, ok := oids[oid]; ok {
+			reasons = append(reasons, fmt.Sprintf("policy %s is forbidden", oid))
+		}
+	}
+
+	if p.MaxPathLen != nil {
+		switch {
+		case cert.MaxPathLen < 0 || (cert.MaxPathLen == 0 && !cert.MaxPathLenZero):
+			reasons = append(reasons, "pathLenConstraint is required")
+		case cert.MaxPathLen > *p.MaxPathLen:
+			reasons = append(reasons,
+				fmt.Sprintf("pathLenConstraint %d exceeds %d", cert.MaxPathLen, *p.MaxPathLen),
+			)
+		}
+	}
+
+	hasNameConstraints := len(cert.PermittedDNSDomains) > 0 || len(cert.ExcludedDNSDomains) > 0 ||
+		len(cert.PermittedIPRanges) > 0 || len(cert.ExcludedIPRanges) > 0 ||
+		len(cert.PermittedEmailAddresses) > 0 || len(cert.ExcludedEmailAddresses) > 0 ||
+		len(cert.PermittedURIDomains) > 0 || len(cert.ExcludedURIDomains) > 0
+	if p.RequireNameConstraints && !hasNameConstraints {
+		reasons = append(reasons, "nameConstraints is required")
+	}
+
+	permitted := make(map[string]struct{}, len(cert.PermittedDNSDomains))
+	for _, domain := range cert.PermittedDNSDomains {
+		permitted[domain] = struct{}{}
+	}
+
+	for _, domain := range p.PermittedDNSDomains {
+		if _, ok := permitted[domain]; !ok {
+			reasons = append(reasons, fmt.Sprintf("permitted DNS domain %s is required", domain))
+		}
+	}
+
+	return reasons
+}
@@ -0,0 +1,30 @@
+package asset
+
+// Warn runs the Checker of an additional check, like CAPolicyCheck and FIPS,
+// after the base Checker, and reports its error to the warn function instead
+// of failing, so that the check is enforced gradually. The content must still
+// pass the base Checker, since the malformed content is never distributed. The
+// checker should not wrap the base, or the base is run twice.
+type Warn struct {
+	checker Checker
+	base    Checker
+	warn    func(error)
+}
+
+func NewWarn(checker, base Checker, warn func(error)) Warn {
+	return Warn{checker: checker, base: base, warn: warn}
+}
+
+func (w Warn) CheckContent(content []byte) error {
+	err := w.base.CheckContent(content)
+	if err != nil {
+		return err
+	}
+
+	err = w.checker.CheckContent(content)
+	if err != nil {
+		w.warn(err)
+	}
+
+	return nil
+}
@@ -3,45 +3,157 @@ package catalog
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/google/go-github/v55/github"
 	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
 )
 
-type Logger interface {
-	// Log about provided URI.
-	Log(uriText string)
-}
-
 type AssetChecker interface {
 	// Verify that the content in the asset as expected.
 	CheckContent([]byte) error
 }
 
+// BlockChecker is the AssetChecker that verifies the PEM blocks of the content
+// one by one, so the content can be checked while it is read as a stream.
+type BlockChecker interface {
+	AssetChecker
+	// CheckBlocks verifies the part of the content, whose first block is the
+	// first-th block of the content.
+	CheckBlocks(part []byte, first int) error
+}
+
+// Filter filters the fetched content before it is checked.
+type Filter interface {
+	Filter([]byte) ([]byte, error)
+}
+
+// filterContent applies the filter to the content if it is set.
+func filterContent(filter Filter, content []byte) ([]byte, error) {
+	if filter == nil {
+		return content, nil
+	}
+
+	return filter.Filter(content)
+}
+
+var (
+	// ErrFetch is matched by the FetchError, whose source is missing or
+	// unreachable.
+	ErrFetch = errors.New("fetch failed")
+	// ErrContent is matched by the ContentError, whose content is fetched but
+	// is not the expected one.
+	ErrContent = errors.New("content is invalid")
+)
+
 // FetchError is used to represent an error that occurs when fetching a
 // data fails.
 type FetchError struct {
 	uri    string
+	alias  string
 	reason string
+	err    error
+}
+
+// NewFetchError returns the FetchError of the err, for the catalogs
+// implemented outside the package.
+func NewFetchError(uri, alias string, err error) error {
+	return FetchError{uri: uri, alias: alias, reason: err.Error(), err: err}
+}
+
+// fetchError returns the FetchError of the err.
+func fetchError(uri, alias string, err error) error {
+	return NewFetchError(uri, alias, err)
 }
 
 func (e FetchError) Error() string {
 	return fmt.Sprintf("fetch failed at %s: %s", e.uri, e.reason)
 }
 
+func (e FetchError) Unwrap() error {
+	return e.err
+}
+
+func (e FetchError) Is(target error) bool {
+	return target == ErrFetch
+}
+
+// URI returns the URI of the catalog.
+func (e FetchError) URI() string {
+	return e.uri
+}
+
+// Alias returns the alias of the catalog.
+func (e FetchError) Alias() string {
+	return e.alias
+}
+
+// ContentError is used to represent an error that occurs when the fetched
+// content fails the filter or the check.
+type ContentError struct {
+	uri   string
+	alias string
+	err   error
+}
+
+// NewContentError returns the ContentError of the err, for the catalogs
+// implemented outside the package.
+func NewContentError(uri, alias string, err error) error {
+	return ContentError{uri: uri, alias: alias, err: err}
+}
+
+// contentError returns the ContentError of the err, which is prefixed with the
+// location of the content.
+func contentError(uri, alias, location string, err error) error {
+	return NewContentError(uri, alias, fmt.Errorf("%s: %w", location, err))
+}
+
+func (e ContentError) Error() string {
+	return e.err.Error()
+}
+
+func (e ContentError) Unwrap() error {
+	return e.err
+}
+
+func (e ContentError) Is(target error) bool {
+	return target == ErrContent
+}
+
+// URI returns the URI of the catalog.
+func (e ContentError) URI() string {
+	return e.uri
+}
+
+// Alias returns the alias of the catalog.
+func (e ContentError) Alias() string {
+	return e.alias
+}
+
 // FSCatalog is an implementation of the Catalog interface. It is responsible for
 // fetching assets held by a Private CA from the local filesystem.
 type FSCatalog struct {
 	uri     uriapi.FSURI
 	alias   string
 	checker AssetChecker
-	logger  Logger
+	filter  Filter
+	rng     Range
+	guard   FSGuard
+	fsys    fs.FS
+	logger  *slog.Logger
 }
 
 func NewFSCatalog(uri uriapi.FSURI, alias string, checker AssetChecker) *FSCatalog {
@@ -58,27 +170,109 @@ func NewFSCatalog(uri uriapi.FSURI, alias string, checker AssetChecker) *FSCatal
 // and return the content of the file as a byte slice.
 func (f *FSCatalog) Fetch(ctx context.Context) ([]byte, error) {
 	if f.logger != nil {
-		f.logger.Log(f.uri.Text())
+		f.logger.InfoContext(ctx, "fetching", "alias", f.alias, "scheme", f.uri.Scheme(), "uri", f.uri.Text())
 	}
 
-	buf, err := os.ReadFile(f.uri.Path())
+	buf, err := f.read()
 	if err != nil {
-		return nil, err
+		return nil, fetchError(f.uri.Text(), f.alias, err)
+	}
+
+	buf, err = filterContent(f.filter, buf)
+	if err != nil {
+		return nil, contentError(f.uri.Text(), f.alias, f.uri.Path(), err)
 	}
 
 	err = f.checker.CheckContent(buf)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", f.uri.Path(), err)
+		return nil, contentError(f.uri.Text(), f.alias, f.uri.Path(), err)
 	}
 
 	return buf, nil
 }
 
-func (f *FSCatalog) WithLogger(l Logger) *FSCatalog {
+// read reads the range of the file, or the whole file.
+func (f *FSCatalog) read() ([]byte, error) {
+	if f.fsys == nil {
+		err := f.guard.check(f.uri.Path())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !f.rng.partial() {
+		if f.fsys != nil {
+			return fs.ReadFile(f.fsys, fsName(f.uri.Path()))
+		}
+		return os.ReadFile(f.uri.Path())
+	}
+
+	file, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if seeker, ok := file.(io.Seeker); ok {
+		_, err = seeker.Seek(f.rng.Offset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(io.Discard, file, f.rng.Offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f.rng.read(file)
+}
+
+// open opens the file in the file system of the FSCatalog.
+func (f *FSCatalog) open() (io.ReadCloser, error) {
+	if f.fsys != nil {
+		return f.fsys.Open(fsName(f.uri.Path()))
+	}
+
+	return os.Open(f.uri.Path())
+}
+
+// fsName returns the name of the path in the fs.FS, which is unrooted.
+func fsName(p string) string {
+	return strings.TrimPrefix(path.Clean(p), "/")
+}
+
+func (f *FSCatalog) WithLogger(l *slog.Logger) *FSCatalog {
 	f.logger = l
 	return f
 }
 
+// WithFilter makes the FSCatalog filter the fetched content with the Filter
+// before checking it.
+func (f *FSCatalog) WithFilter(filter Filter) *FSCatalog {
+	f.filter = filter
+	return f
+}
+
+// WithRange makes the FSCatalog read only the range of the file.
+func (f *FSCatalog) WithRange(rng Range) *FSCatalog {
+	f.rng = rng
+	return f
+}
+
+// WithGuard makes the FSCatalog check the file with the FSGuard before
+// reading it.
+func (f *FSCatalog) WithGuard(g FSGuard) *FSCatalog {
+	f.guard = g
+	return f
+}
+
+// WithFS makes the FSCatalog read the file from the fsys instead of the OS
+// file system, like the fstest.MapFS of the tests. The path of the URI is the
+// name in the fsys without the leading slash. The FSGuard is not applied, since
+// it inspects the OS file system.
+func (f *FSCatalog) WithFS(fsys fs.FS) *FSCatalog {
+	f.fsys = fsys
+	return f
+}
+
 // GitHubCatalog is an implementation of the Catalog interface.
 // It is responsible for fetching assets held by a Private CA from a GitHub repository.
 // It uses the GitHub Get Repository Content API for this purpose.
@@ -86,7 +280,12 @@ type GitHubCatalog struct {
 	uri     uriapi.GitHubURI
 	alias   string
 	checker AssetChecker
-	logger  Logger
+	filter  Filter
+	retry   Retry
+	limiter *RateLimiter
+	logger  *slog.Logger
+	client  *github.Client
+	http    *http.Client
 }
 
 func NewGitHubCatalog(uri uriapi.GitHubURI, alias string, checker AssetChecker) *GitHubCatalog {
@@ -102,46 +301,123 @@ func NewGitHubCatalog(uri uriapi.GitHubURI, alias string, checker AssetChecker)
 // The Fetch function utilizes the Get repository content API in GitHub. It
 // requires the usage of an environment variable called "GITHUB_TOKEN" to authorize the
 // request. The function then returns the content of the file as a byte slice.
+// The content of the file over 1 MB, which the API does not return, is fetched
+// with the Get a blob API.
 func (g *GitHubCatalog) Fetch(ctx context.Context) ([]byte, error) {
 	if g.logger != nil {
-		g.logger.Log(g.uri.Text())
-	}
-
-	client := github.NewClient(nil).WithAuthToken(os.Getenv("GITHUB_TOKEN"))
-	content, _, _, err := client.Repositories.GetContents(ctx,
-		g.uri.Owner(),
-		g.uri.Repo(),
-		g.uri.RepoPath(),
-		&github.RepositoryContentGetOptions{
-			Ref: g.uri.Ref(),
-		},
-	)
+		g.logger.InfoContext(ctx, "fetching", "alias", g.alias, "scheme", g.uri.Scheme(), "uri", g.uri.Text())
+	}
+
+	client := g.client
+	if client == nil {
+		client = github.NewClient(g.http).WithAuthToken(os.Getenv("GITHUB_TOKEN"))
+	}
+
+	var content *github.RepositoryContent
+	err := g.request(ctx, func(ctx context.Context) (resp *github.Response, err error) {
+		content, _, resp, err = client.Repositories.GetContents(ctx,
+			g.uri.Owner(),
+			g.uri.Repo(),
+			g.uri.RepoPath(),
+			&github.RepositoryContentGetOptions{
+				Ref: g.uri.Ref(),
+			},
+		)
+		return resp, err
+	})
 	if err != nil {
-		return nil, err
+		return nil, fetchError(g.uri.Text(), g.alias, err)
 	}
 
 	if *content.Type != "file" {
-		return nil, FetchError{uri: g.uri.Text(), reason: "Only support file type."}
+		return nil, FetchError{uri: g.uri.Text(), alias: g.alias, reason: "Only support file type."}
 	}
 
-	buf, err := base64.URLEncoding.DecodeString(*content.Content)
+	var buf []byte
+	if content.GetEncoding() == "none" || content.Content == nil {
+		// The content of the large file is not returned.
+		err = g.request(ctx, func(ctx context.Context) (resp *github.Response, err error) {
+			buf, resp, err = client.Git.GetBlobRaw(ctx, g.uri.Owner(), g.uri.Repo(), content.GetSHA())
+			return resp, err
+		})
+	} else {
+		buf, err = base64.URLEncoding.DecodeString(*content.Content)
+	}
 	if err != nil {
-		return nil, err
+		return nil, fetchError(g.uri.Text(), g.alias, err)
+	}
+
+	buf, err = filterContent(g.filter, buf)
+	if err != nil {
+		return nil, contentError(g.uri.Text(), g.alias, g.uri.Path(), err)
 	}
 
 	err = g.checker.CheckContent(buf)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", g.uri.Path(), err)
+		return nil, contentError(g.uri.Text(), g.alias, g.uri.Path(), err)
 	}
 
 	return buf, nil
 }
 
-func (g *GitHubCatalog) WithLogger(l Logger) *GitHubCatalog {
+// request calls the API with the retry and the rate limit.
+func (g *GitHubCatalog) request(ctx context.Context, call func(context.Context) (*github.Response, error)) error {
+	err := g.retry.do(ctx, func(ctx context.Context) error {
+		err := g.limiter.wait(ctx)
+		if err != nil {
+			return err
+		}
+
+		resp, err := call(ctx)
+		g.limiter.observe(resp)
+		return err
+	})
+
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		err = rateLimited(rateErr.Rate.Reset.Time)
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return fmt.Errorf("%s: %w", g.uri.Text(), err)
+	}
+
+	return err
+}
+
+func (g *GitHubCatalog) WithLogger(l *slog.Logger) *GitHubCatalog {
 	g.logger = l
 	return g
 }
 
+// WithFilter makes the GitHubCatalog filter the fetched content with the Filter
+// before checking it.
+func (g *GitHubCatalog) WithFilter(filter Filter) *GitHubCatalog {
+	g.filter = filter
+	return g
+}
+
+// WithRetry makes the GitHubCatalog retry the transient errors of the API.
+// The rate limit errors are retried after the reset of the limit.
+func (g *GitHubCatalog) WithRetry(retry Retry) *GitHubCatalog {
+	g.retry = retry
+	return g
+}
+
+// WithRateLimiter makes the GitHubCatalog throttle the requests with the rate
+// limit shared by the RateLimiter, and fail with ErrRateLimited and the time of
+// the reset if the limit is exhausted.
+func (g *GitHubCatalog) WithRateLimiter(limiter *RateLimiter) *GitHubCatalog {
+	g.limiter = limiter
+	return g
+}
+
+// WithHTTPClient makes the GitHubCatalog call the API with the client, like the
+// one of the Network.
+func (g *GitHubCatalog) WithHTTPClient(c *http.Client) *GitHubCatalog {
+	g.http = c
+	return g
+}
+
 // S3Catalog is an implementation of the Catalog interface.
 // It is responsible for fetching assets held by a Private CA from a AWS S3.
 // It uses the AWS S3 GetObject API for this purpose.
@@ -149,37 +425,79 @@ type S3Catalog struct {
 	uri     uriapi.S3URI
 	alias   string
 	checker AssetChecker
-	logger  Logger
+	filter  Filter
+	rng     Range
+	retry   Retry
+	logger  *slog.Logger
+	http    *http.Client
+	opts    []func(*s3.Options)
+	role    AssumeRole
 }
 
+// AssumeRole is the IAM role assumed with the default credentials before
+// calling the API, for the buckets in the other accounts.
+type AssumeRole struct {
+	RoleARN string
+	// ExternalID is required by the trust policy of the role, if any.
+	ExternalID string
+}
+
+// assumeRoleSession is the name of the session of the assumed role, recorded
+// in CloudTrail.
+const assumeRoleSession = "cannect"
+
 // The Fetch function utilizes the GetObjcet API in AWS S3. It
 // requires the usage of an environment variable "AWS_ACCESS_KEY_ID" and
 // "AWS_SECRET_ACCESS_KEY", "AWS_DEFAULT_REGION", to authorize the request.
-// The function then returns the content of the file as a byte slice.
+// The function then checks the content of the file with the AssetChecker, and
+// returns it as a byte slice.
 func (s *S3Catalog) Fetch(ctx context.Context) ([]byte, error) {
 	if s.logger != nil {
-		s.logger.Log(s.uri.Text())
+		s.logger.InfoContext(ctx, "fetching", "alias", s.alias, "scheme", s.uri.Scheme(), "uri", s.uri.Text())
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := s.loadConfig(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fetchError(s.uri.Text(), s.alias, err)
 	}
 
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(cfg, s.opts...)
 
-	output, err := client.GetObject(ctx, &s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.uri.Bucket()),
 		Key:    aws.String(s.uri.Key()),
+	}
+	if v := s.uri.VersionID(); v != "" {
+		input.VersionId = aws.String(v)
+	}
+	if rng := s.rng.httpRange(); rng != "" {
+		input.Range = aws.String(rng)
+	}
+
+	var buf []byte
+	err = s.retry.do(ctx, func(ctx context.Context) error {
+		output, err := client.GetObject(ctx, input)
+		if err != nil {
+			return err
+		}
+		defer output.Body.Close()
+
+		// The body is closed without reading the rest after the first block.
+		buf, err = s.rng.read(output.Body)
+		return err
 	})
 	if err != nil {
-		return nil, err
+		return nil, fetchError(s.uri.Text(), s.alias, err)
 	}
-	defer output.Body.Close()
 
-	buf, err := io.ReadAll(output.Body)
+	buf, err = filterContent(s.filter, buf)
 	if err != nil {
-		return nil, err
+		return nil, contentError(s.uri.Text(), s.alias, s.uri.Path(), err)
+	}
+
+	err = s.checker.CheckContent(buf)
+	if err != nil {
+		return nil, contentError(s.uri.Text(), s.alias, s.uri.Path(), err)
 	}
 
 	return buf, nil
@@ -195,7 +513,78 @@ func NewS3Catalog(uri uriapi.S3URI, alias string, checker AssetChecker) *S3Catal
 	return ctlg
 }
 
-func (s *S3Catalog) WithLogger(l Logger) *S3Catalog {
+func (s *S3Catalog) WithLogger(l *slog.Logger) *S3Catalog {
 	s.logger = l
 	return s
 }
+
+// WithFilter makes the S3Catalog filter the fetched content with the Filter
+// before checking it.
+func (s *S3Catalog) WithFilter(filter Filter) *S3Catalog {
+	s.filter = filter
+	return s
+}
+
+// WithRange makes the S3Catalog get only the range of the object with the
+// Range header.
+func (s *S3Catalog) WithRange(rng Range) *S3Catalog {
+	s.rng = rng
+	return s
+}
+
+// WithRetry makes the S3Catalog retry the transient errors of the API, in
+// addition to the retries of the AWS SDK in each request.
+func (s *S3Catalog) WithRetry(retry Retry) *S3Catalog {
+	s.retry = retry
+	return s
+}
+
+// WithHTTPClient makes the S3Catalog call the API with the client, like the
+// one of the Network.
+func (s *S3Catalog) WithHTTPClient(c *http.Client) *S3Catalog {
+	s.http = c
+	return s
+}
+
+// WithOptions makes the S3Catalog create the client with the options of the
+// AWS SDK, like the endpoint and the path-style addressing of the
+// S3-compatible object stores.
+func (s *S3Catalog) WithOptions(optFns ...func(*s3.Options)) *S3Catalog {
+	s.opts = optFns
+	return s
+}
+
+// WithAssumeRole makes the S3Catalog call the API with the credentials of the
+// role, assumed with the AWS STS AssumeRole API.
+func (s *S3Catalog) WithAssumeRole(role AssumeRole) *S3Catalog {
+	s.role = role
+	return s
+}
+
+// loadConfig loads the default config of the AWS SDK with the client, and the
+// credentials of the role to assume.
+func (s *S3Catalog) loadConfig(ctx context.Context) (aws.Config, error) {
+	var optFns []func(*config.LoadOptions) error
+	if s.http != nil {
+		optFns = append(optFns, config.WithHTTPClient(s.http))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return cfg, err
+	}
+
+	if s.role.RoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), s.role.RoleARN,
+			func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = assumeRoleSession
+				if s.role.ExternalID != "" {
+					o.ExternalID = aws.String(s.role.ExternalID)
+				}
+			},
+		)
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	return cfg, nil
+}
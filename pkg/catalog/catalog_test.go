@@ -0,0 +1,286 @@
+package catalog
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v55/github"
+	"github.com/yuxki/cannect/pkg/plugin"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+var errTestCheck = errors.New("test check failed")
+
+// recordChecker records the content it checks, and fails with the err.
+type recordChecker struct {
+	checked []byte
+	err     error
+}
+
+func (r *recordChecker) CheckContent(buf []byte) error {
+	r.checked = buf
+	return r.err
+}
+
+// fetcher is the Catalog of the order package.
+type fetcher interface {
+	Fetch(ctx context.Context) ([]byte, error)
+}
+
+// TestCatalog_CheckContent is the conformance test of the catalogs. Every
+// catalog must check the fetched content with the AssetChecker, and must not
+// return the content failing the check, whose error is the ContentError. The new catalog is added to the
+// catalogs.
+func TestCatalog_CheckContent(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestCatalog_CheckContent")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	content := []byte("-----BEGIN CERTIFICATE-----\n")
+
+	// The program of the plugin is written before running any of them.
+	program := path.Join(dir, plugin.Program("portal"))
+	err = os.WriteFile(program, []byte("#!/bin/sh\ncat > /dev/null\necho '{\"content\":\""+
+		base64.StdEncoding.EncodeToString(content)+"\"}'\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalogs := []struct {
+		name string
+		// newCatalog returns the catalog fetching the content.
+		newCatalog func(t *testing.T, name string, checker AssetChecker) fetcher
+	}{
+		{
+			"FS",
+			func(t *testing.T, name string, checker AssetChecker) fetcher {
+				t.Helper()
+
+				file := path.Join(dir, name)
+				err := os.WriteFile(file, content, 0o600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				uri, err := uriapi.NewFSURI("file://" + file)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return NewFSCatalog(uri, "root-ca.crt", checker)
+			},
+		},
+		{
+			"GitHub",
+			func(t *testing.T, name string, checker AssetChecker) fetcher {
+				t.Helper()
+
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"type":"file","encoding":"base64","content":"` +
+						base64.URLEncoding.EncodeToString(content) + `"}`))
+				}))
+				t.Cleanup(srv.Close)
+
+				client := github.NewClient(nil)
+				var err error
+				client.BaseURL, err = url.Parse(srv.URL + "/")
+				if err != nil {
+					t.Fatal(err)
+				}
+				uri, err := uriapi.NewGitHubURI("github:///repos/yuxki/pki/contents/ca/root-ca.crt")
+				if err != nil {
+					t.Fatal(err)
+				}
+				catalog := NewGitHubCatalog(uri, "root-ca.crt", checker)
+				catalog.client = client
+
+				return catalog
+			},
+		},
+		{
+			"S3",
+			func(t *testing.T, name string, checker AssetChecker) fetcher {
+				t.Helper()
+
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/pki/ca/root-ca.crt" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Write(content)
+				}))
+				t.Cleanup(srv.Close)
+
+				uri, err := uriapi.NewS3URI("s3://pki/ca/root-ca.crt")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return NewS3Catalog(uri, "root-ca.crt", checker).WithOptions(func(o *s3.Options) {
+					o.BaseEndpoint = aws.String(srv.URL)
+					o.UsePathStyle = true
+					o.Region = "us-east-1"
+					o.Credentials = aws.AnonymousCredentials{}
+				})
+			},
+		},
+		{
+			"Workload",
+			func(t *testing.T, name string, checker AssetChecker) fetcher {
+				t.Helper()
+
+				// The key asset is not parsed, so any bytes are the key.
+				sock := path.Join(dir, name+".sock")
+				testWorkloadAPI(t, sock, appendProtoBytes(nil, 1, appendProtoBytes(nil, 3, []byte("key"))), nil)
+
+				uri, err := uriapi.NewWorkloadURI("workload://" + sock + "?asset=key")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return NewWorkloadCatalog(uri, "spire", checker)
+			},
+		},
+		{
+			"Plugin",
+			func(t *testing.T, name string, checker AssetChecker) fetcher {
+				t.Helper()
+
+				if _, err := exec.LookPath("sh"); err != nil || runtime.GOOS == "windows" {
+					t.Skip("sh is not available")
+				}
+
+				uri, err := uriapi.NewPluginURI("plugin://portal/ca/root-ca.crt")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return NewPluginCatalog(uri, "root-ca.crt", checker).WithProgram("./" + program)
+			},
+		},
+	}
+
+	for _, c := range catalogs {
+		for _, checkErr := range []error{nil, errTestCheck} {
+			c, checkErr := c, checkErr
+			name := c.name + "-OK"
+			if checkErr != nil {
+				name = c.name + "-NG"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				checker := &recordChecker{err: checkErr}
+				catalog := c.newCatalog(t, name, checker)
+
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+
+				got, err := catalog.Fetch(ctx)
+				if checker.checked == nil {
+					t.Fatalf("Expected the content is checked but got: %v", err)
+				}
+				if !errors.Is(err, checkErr) {
+					t.Fatalf("Expected %#v error but got: %#v", checkErr, err)
+				}
+				if checkErr != nil {
+					if got != nil {
+						t.Errorf("Expected no content but got: %s", got)
+					}
+					var cErr ContentError
+					if !errors.As(err, &cErr) || !errors.Is(err, ErrContent) || errors.Is(err, ErrFetch) {
+						t.Errorf("Expected ContentError but got: %#v", err)
+					}
+					return
+				}
+				if diff := cmp.Diff(checker.checked, got); diff != "" {
+					t.Error(diff)
+				}
+			})
+		}
+	}
+}
+
+func TestFSCatalog_WithFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"pki/ca.crt": &fstest.MapFile{Data: []byte("-----BEGIN CERTIFICATE-----\n")},
+	}
+
+	data := []struct {
+		testCase string
+		uriText  string
+		rng      Range
+		// want
+		content string
+		err     error
+	}{
+		{"Relative", "file://pki/ca.crt", Range{}, "-----BEGIN CERTIFICATE-----\n", nil},
+		{"Absolute", "file:///pki/ca.crt", Range{}, "-----BEGIN CERTIFICATE-----\n", nil},
+		{"Range", "file://pki/ca.crt", Range{Offset: 11, Length: 11}, "CERTIFICATE", nil},
+		{"Not Found", "file://pki/missing.crt", Range{}, "", fs.ErrNotExist},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := uriapi.NewFSURI(d.uriText)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			catalog := NewFSCatalog(uri, "", &recordChecker{}).WithFS(fsys).WithRange(d.rng)
+			buf, err := catalog.Fetch(context.TODO())
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if string(buf) != d.content {
+				t.Errorf("Expected %q but got: %q", d.content, buf)
+			}
+		})
+	}
+}
+
+func TestFSCatalog_Fetch_Errors(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewFSURI("file://testdata/TestFSCatalog_Fetch_Errors.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewFSCatalog(uri, "root", &recordChecker{}).Fetch(context.TODO())
+	var fErr FetchError
+	if !errors.As(err, &fErr) {
+		t.Fatalf("Expected FetchError but got: %#v", err)
+	}
+	if !errors.Is(err, ErrFetch) || !errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrContent) {
+		t.Errorf("Expected the missing source but got: %v", err)
+	}
+	if fErr.URI() != uri.Text() || fErr.Alias() != "root" {
+		t.Errorf("Expected %s and root but got: %s and %s", uri.Text(), fErr.URI(), fErr.Alias())
+	}
+}
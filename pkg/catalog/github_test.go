@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v55/github"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestGitHubCatalog_Fetch(t *testing.T) {
+	t.Parallel()
+
+	small := "-----BEGIN CERTIFICATE-----\n"
+	large := strings.Repeat("-----BEGIN X509 CRL-----\n", 50000)
+
+	data := []struct {
+		testCase string
+		contents string
+		// want
+		want     string
+		requests []string
+	}{
+		{
+			"Small",
+			`{"type":"file","encoding":"base64","size":28,"sha":"small-sha","content":"` +
+				base64.URLEncoding.EncodeToString([]byte(small)) + `"}`,
+			small,
+			[]string{"/repos/yuxki/pki/contents/ca/crl.pem"},
+		},
+		{
+			"Large",
+			`{"type":"file","encoding":"none","size":1250000,"sha":"large-sha","content":""}`,
+			large,
+			[]string{"/repos/yuxki/pki/contents/ca/crl.pem", "/repos/yuxki/pki/git/blobs/large-sha"},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var requests []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/yuxki/pki/contents/ca/crl.pem", func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Path)
+				w.Write([]byte(d.contents))
+			})
+			mux.HandleFunc("/repos/yuxki/pki/git/blobs/large-sha", func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.Path)
+				if r.Header.Get("Accept") != "application/vnd.github.v3.raw" {
+					w.WriteHeader(http.StatusNotAcceptable)
+					return
+				}
+				w.Write([]byte(large))
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			client := github.NewClient(nil)
+			var err error
+			client.BaseURL, err = url.Parse(srv.URL + "/")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			uri, err := uriapi.NewGitHubURI("github:///repos/yuxki/pki/contents/ca/crl.pem")
+			if err != nil {
+				t.Fatal(err)
+			}
+			catalog := NewGitHubCatalog(uri, "crl.pem", testChecker{})
+			catalog.client = client
+
+			got, err := catalog.Fetch(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != d.want {
+				t.Errorf("Expected %d bytes but got: %d bytes", len(d.want), len(got))
+			}
+			if diff := cmp.Diff(d.requests, requests); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestGitHubCatalog_Probe(t *testing.T) {
+	t.Parallel()
+
+	var gotMethod, gotQuery string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/yuxki/pki/contents/ca/root-ca.crt", func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotQuery = r.Method, r.URL.RawQuery
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := github.NewClient(nil)
+	var err error
+	client.BaseURL, err = url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"ca/root-ca.crt?ref=v1", "ca/missing.crt"} {
+		uri, err := uriapi.NewGitHubURI("github:///repos/yuxki/pki/contents/" + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		catalog := NewGitHubCatalog(uri, "root-ca.crt", testChecker{})
+		catalog.client = client
+
+		size, err := catalog.Probe(context.TODO())
+		if strings.HasPrefix(path, "ca/missing.crt") {
+			if err == nil {
+				t.Error("Expected the error of the missing file but got nil")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != -1 || gotMethod != http.MethodHead || gotQuery != "ref=v1" {
+			t.Errorf("Expected HEAD with ref=v1 but got: %s %s (%d)", gotMethod, gotQuery, size)
+		}
+	}
+}
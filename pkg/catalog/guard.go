@@ -0,0 +1,120 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrOutsideRoot    = errors.New("file is outside the root directory")
+	ErrNotRegularFile = errors.New("file is not a regular file")
+	ErrCaseMismatch   = errors.New("file name differs in case")
+)
+
+// FSGuard restricts the files read by the FSCatalog, so that a config cannot
+// make it read arbitrary files of the host, like the ones under /etc or the
+// devices. The zero value restricts nothing.
+type FSGuard struct {
+	// Root is the directory the file must be in, after the symlinks are
+	// resolved. The file is not restricted if it is empty.
+	Root string
+	// RegularOnly rejects the devices, FIFOs, sockets and directories, which
+	// may block the read or never end.
+	RegularOnly bool
+	// CaseSensitive rejects the path whose names differ in case from the ones
+	// in the directories, which the case-insensitive file systems accept.
+	CaseSensitive bool
+}
+
+// check returns the error if the file of the name is not allowed.
+func (g FSGuard) check(name string) error {
+	if g.CaseSensitive {
+		err := checkCase(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if g.Root != "" {
+		err := checkRoot(g.Root, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if g.RegularOnly {
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s: %w", name, ErrNotRegularFile)
+		}
+	}
+
+	return nil
+}
+
+// checkCase checks that each name of the path is in its directory exactly.
+func checkCase(name string) error {
+	for p := filepath.Clean(name); ; p = filepath.Dir(p) {
+		base, parent := filepath.Base(p), filepath.Dir(p)
+		if p == parent || base == "." || base == ".." {
+			return nil
+		}
+
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, entry := range entries {
+			if entry.Name() == base {
+				found = true
+				break
+			}
+		}
+		if !found {
+			// Report the missing file as it is.
+			_, err := os.Stat(p)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("%s: %w", name, ErrCaseMismatch)
+		}
+	}
+}
+
+// checkRoot checks that the file is in the root directory, after the symlinks
+// of both are resolved.
+func checkRoot(root, name string) error {
+	resolvedRoot, err := resolvePath(root)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolvePath(name)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(resolvedRoot, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s: %w", name, ErrOutsideRoot)
+	}
+
+	return nil
+}
+
+func resolvePath(name string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Abs(resolved)
+}
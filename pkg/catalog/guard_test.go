@@ -0,0 +1,99 @@
+package catalog
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFSGuard_Check(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestFSGuard_Check"
+	root := filepath.Join(dir, "root")
+	err := os.MkdirAll(filepath.Join(root, "sub"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for _, name := range []string{filepath.Join(root, "ca.crt"), filepath.Join(dir, "secret.key")} {
+		err = os.WriteFile(name, []byte("content"), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outside := filepath.Join(root, "outside.crt")
+	inside := filepath.Join(root, "sub", "inside.crt")
+	symlinked := true
+	if os.Symlink(filepath.Join("..", "secret.key"), outside) != nil ||
+		os.Symlink(filepath.Join("..", "ca.crt"), inside) != nil {
+		// Symlinks need the privilege on Windows.
+		symlinked = false
+	}
+
+	data := []struct {
+		testCase string
+		guard    FSGuard
+		name     string
+		err      error
+		symlink  bool
+	}{
+		{"OK:zero", FSGuard{}, filepath.Join(dir, "secret.key"), nil, false},
+		{"OK:in root", FSGuard{Root: root}, filepath.Join(root, "ca.crt"), nil, false},
+		{"OK:symlink in root", FSGuard{Root: root}, inside, nil, true},
+		{"OK:regular", FSGuard{RegularOnly: true}, filepath.Join(root, "ca.crt"), nil, false},
+		{"OK:case", FSGuard{CaseSensitive: true}, filepath.Join(root, "ca.crt"), nil, false},
+		{"NG:outside root", FSGuard{Root: root}, filepath.Join(dir, "secret.key"), ErrOutsideRoot, false},
+		{"NG:parent of root", FSGuard{Root: root}, filepath.Join(root, "..", "secret.key"), ErrOutsideRoot, false},
+		{"NG:symlink outside root", FSGuard{Root: root}, outside, ErrOutsideRoot, true},
+		{"NG:directory", FSGuard{RegularOnly: true}, filepath.Join(root, "sub"), ErrNotRegularFile, false},
+		{"NG:missing", FSGuard{CaseSensitive: true}, filepath.Join(root, "missing.crt"), fs.ErrNotExist, false},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			if d.symlink && !symlinked {
+				t.Skip("symlink is not available")
+			}
+
+			err := d.guard.check(d.name)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+		})
+	}
+}
+
+func TestFSGuard_Check_CaseInsensitive(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestFSGuard_Check_CaseInsensitive"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	err = os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("content"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upper := filepath.Join(dir, strings.ToUpper("ca.crt"))
+	if _, err := os.Stat(upper); err != nil {
+		t.Skip("file system is case-sensitive")
+	}
+
+	err = FSGuard{CaseSensitive: true}.check(upper)
+	if !errors.Is(err, ErrCaseMismatch) {
+		t.Fatalf("Expected %#v error but got: %#v", ErrCaseMismatch, err)
+	}
+}
@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Network configures how the remote catalogs reach their APIs, for the
+// split-horizon and air-gapped networks where the APIs are reached via the
+// internal mirrors.
+type Network struct {
+	// Endpoints maps the host, or the host and port, of the API to the address
+	// dialed instead, like "api.github.com" to "github-mirror.internal:8443".
+	// The TLS certificate is still verified against the host of the API.
+	Endpoints map[string]string
+	// Resolvers are the addresses of the DNS servers resolving the hosts
+	// instead of the system resolver. They are used in turn when a query
+	// fails.
+	Resolvers []string
+}
+
+// HTTPClient returns the client reaching the APIs through the Network. It
+// returns nil if nothing is configured, so the default client is used.
+func (n Network) HTTPClient() *http.Client {
+	if len(n.Endpoints) == 0 && len(n.Resolvers) == 0 {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if len(n.Resolvers) > 0 {
+		dialer.Resolver = n.resolver()
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, n.endpoint(addr))
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// endpoint returns the address dialed for the address of the API. The port of
+// the API is kept if the endpoint of the host does not have one.
+func (n Network) endpoint(addr string) string {
+	if ep, ok := n.Endpoints[addr]; ok {
+		return ep
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	ep, ok := n.Endpoints[host]
+	if !ok {
+		return addr
+	}
+	if _, _, err := net.SplitHostPort(ep); err == nil {
+		return ep
+	}
+
+	return net.JoinHostPort(ep, port)
+}
+
+// resolver returns the resolver querying the Resolvers. The next one is used
+// for each retry of the query.
+func (n Network) resolver() *net.Resolver {
+	var next uint32
+	resolvers := n.Resolvers
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			idx := (atomic.AddUint32(&next, 1) - 1) % uint32(len(resolvers))
+			var d net.Dialer
+			return d.DialContext(ctx, network, resolvers[idx])
+		},
+	}
+}
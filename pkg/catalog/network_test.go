@@ -0,0 +1,71 @@
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNetwork_Endpoint(t *testing.T) {
+	t.Parallel()
+
+	network := Network{Endpoints: map[string]string{
+		"api.github.com":   "github-mirror.internal",
+		"s3.amazonaws.com": "s3-mirror.internal:9000",
+		"example.com:8443": "10.0.0.1:443",
+	}}
+
+	data := []struct {
+		addr string
+		// want
+		want string
+	}{
+		{"api.github.com:443", "github-mirror.internal:443"},
+		{"s3.amazonaws.com:443", "s3-mirror.internal:9000"},
+		{"example.com:8443", "10.0.0.1:443"},
+		{"example.com:443", "example.com:443"},
+	}
+
+	for _, d := range data {
+		if got := network.endpoint(d.addr); got != d.want {
+			t.Errorf("%s: Expected %s but got: %s", d.addr, d.want, got)
+		}
+	}
+}
+
+func TestNetwork_HTTPClient(t *testing.T) {
+	t.Parallel()
+
+	if client := (Network{}).HTTPClient(); client != nil {
+		t.Errorf("Expected no client but got: %#v", client)
+	}
+
+	var gotHost string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := Network{Endpoints: map[string]string{"api.github.test": u.Host}}.HTTPClient()
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://api.github.test/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// The request is sent to the endpoint with the host of the API.
+	if gotHost != "api.github.test" {
+		t.Errorf("Expected the host api.github.test but got: %s", gotHost)
+	}
+}
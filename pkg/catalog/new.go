@@ -0,0 +1,194 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// ErrUnsupportedScheme is returned by New for the schemes it cannot build.
+var ErrUnsupportedScheme = errors.New("unsupported scheme")
+
+// Catalog fetches the content of the source of its URI. It is the same as the
+// Catalog of the order package.
+type Catalog interface {
+	Fetch(context.Context) ([]byte, error)
+}
+
+// Options are the options of the catalog built by New. The options not used by
+// the scheme of the catalog are ignored.
+type Options struct {
+	Logger     *slog.Logger
+	Filter     Filter
+	Range      Range
+	Retry      Retry
+	Timeout    time.Duration
+	HTTPClient *http.Client
+}
+
+// Option sets the Options.
+type Option func(*Options)
+
+// WithLogger sets the logger of the catalog, which logs each fetch at the info
+// level.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithFilter sets the filter of the fetched content, which is applied before
+// the content is checked.
+func WithFilter(filter Filter) Option {
+	return func(o *Options) {
+		o.Filter = filter
+	}
+}
+
+// WithRange makes the file and the s3 catalogs read only the range of the
+// source.
+func WithRange(rng Range) Option {
+	return func(o *Options) {
+		o.Range = rng
+	}
+}
+
+// WithRetry sets the retry of the failed requests of the github and the s3
+// catalogs.
+func WithRetry(retry Retry) Option {
+	return func(o *Options) {
+		o.Retry = retry
+	}
+}
+
+// WithTimeout limits the time of each fetch of the catalog. It is not limited
+// if the timeout is 0.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// WithHTTPClient sets the client of the requests of the github and the s3
+// catalogs.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *Options) {
+		o.HTTPClient = c
+	}
+}
+
+// NewOptions returns the Options set by the opts in order.
+func NewOptions(opts ...Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// New returns the Catalog of the source of the URI with the options, like the
+// catalogs of the CLI config. The file, github, s3, workload and plugin
+// schemes are supported.
+func New(uriText, alias string, checker AssetChecker, opts ...Option) (Catalog, error) {
+	o := NewOptions(opts...)
+
+	scheme := uriText
+	if idx := strings.Index(uriText, "://"); idx >= 0 {
+		scheme = uriText[:idx]
+	}
+
+	var catalog Catalog
+
+	switch scheme {
+	case "file":
+		uri, err := uriapi.NewFSURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		catalog = NewFSCatalog(uri, alias, checker).WithLogger(o.Logger).WithFilter(o.Filter).WithRange(o.Range)
+	case "github":
+		uri, err := uriapi.NewGitHubURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		catalog = NewGitHubCatalog(uri, alias, checker).WithLogger(o.Logger).WithFilter(o.Filter).WithRetry(o.Retry).
+			WithHTTPClient(o.HTTPClient)
+	case "s3":
+		uri, err := uriapi.NewS3URI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		catalog = NewS3Catalog(uri, alias, checker).WithLogger(o.Logger).WithFilter(o.Filter).WithRange(o.Range).
+			WithRetry(o.Retry).WithHTTPClient(o.HTTPClient)
+	case "workload":
+		uri, err := uriapi.NewWorkloadURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		catalog = NewWorkloadCatalog(uri, alias, checker).WithLogger(o.Logger).WithFilter(o.Filter)
+	case "plugin":
+		uri, err := uriapi.NewPluginURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		catalog = NewPluginCatalog(uri, alias, checker).WithLogger(o.Logger).WithFilter(o.Filter)
+	default:
+		return nil, fmt.Errorf("%s: %w", uriText, ErrUnsupportedScheme)
+	}
+
+	if o.Timeout > 0 {
+		catalog = &timeoutCatalog{catalog: catalog, timeout: o.Timeout}
+	}
+
+	return catalog, nil
+}
+
+// timeoutCatalog limits the time of each fetch of the catalog.
+type timeoutCatalog struct {
+	catalog Catalog
+	timeout time.Duration
+}
+
+func (t *timeoutCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.catalog.Fetch(ctx)
+}
+
+// Open opens the FSCatalog with the timeout, and fetches the other catalog.
+// The reader of the FSCatalog does not use the context.
+func (t *timeoutCatalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	if f, ok := t.catalog.(*FSCatalog); ok {
+		return f.Open(ctx)
+	}
+
+	buf, err := t.catalog.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(buf)), nil
+}
+
+func (t *timeoutCatalog) Streams() bool {
+	_, ok := t.catalog.(*FSCatalog)
+	return ok
+}
@@ -0,0 +1,90 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// testRoundTripper responds to the requests with the function.
+type testRoundTripper func(*http.Request) (*http.Response, error)
+
+func (t testRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t(req)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	uriText := "github:///repos/yuxki/pki/contents/ca/root-ca.crt"
+	client := &http.Client{Transport: testRoundTripper(func(req *http.Request) (*http.Response, error) {
+		body := `{"type":"file","encoding":"base64","content":"` +
+			base64.StdEncoding.EncodeToString([]byte("root")) + `"}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	catalog, err := New(uriText, "root-ca.crt", testChecker{}, WithHTTPClient(client), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := catalog.Fetch(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "root" {
+		t.Errorf("Expected the content of the client but got: %q", content)
+	}
+
+	var record map[string]interface{}
+	err = json.Unmarshal(buf.Bytes(), &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "fetching" || record["alias"] != "root-ca.crt" || record["scheme"] != "github" ||
+		record["uri"] != uriText {
+		t.Errorf("Expected the fetch is logged but got: %s", buf.Bytes())
+	}
+
+	_, err = New("vault://secret/data/ca", "root-ca.crt", testChecker{})
+	if !errors.Is(err, ErrUnsupportedScheme) {
+		t.Fatalf("Expected %#v error but got: %#v", ErrUnsupportedScheme, err)
+	}
+}
+
+func TestNew_WithTimeout(t *testing.T) {
+	t.Parallel()
+
+	client := &http.Client{Transport: testRoundTripper(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})}
+
+	catalog, err := New("github:///repos/yuxki/pki/contents/ca/root-ca.crt", "root-ca.crt", testChecker{},
+		WithHTTPClient(client), WithTimeout(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = catalog.Fetch(context.TODO())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %#v error but got: %#v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,90 @@
+package catalog
+
+import (
+	"context"
+	"io"
+
+	"github.com/yuxki/cannect/pkg/plugin"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// PluginCatalog is an implementation of the Catalog interface. It is
+// responsible for fetching assets through the external program of the plugin,
+// speaking the protocol of the plugin package.
+type PluginCatalog struct {
+	uri     uriapi.PluginURI
+	alias   string
+	checker AssetChecker
+	filter  Filter
+	logger  *slog.Logger
+	program string
+	stderr  io.Writer
+}
+
+func NewPluginCatalog(uri uriapi.PluginURI, alias string, checker AssetChecker) *PluginCatalog {
+	ctlg := &PluginCatalog{
+		uri:     uri,
+		alias:   alias,
+		checker: checker,
+		program: plugin.Program(uri.Name()),
+	}
+
+	return ctlg
+}
+
+// Fetch runs the program of the plugin with the fetch request, and checks the
+// content of the response.
+func (p *PluginCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	if p.logger != nil {
+		p.logger.InfoContext(ctx, "fetching", "alias", p.alias, "scheme", p.uri.Scheme(), "uri", p.uri.Text())
+	}
+
+	resp, err := plugin.Call(ctx, p.program, plugin.Request{
+		Operation: plugin.FetchOperation,
+		URI:       p.uri.Text(),
+		Path:      p.uri.Path(),
+		Alias:     p.alias,
+	}, p.stderr)
+	if err != nil {
+		return nil, fetchError(p.uri.Text(), p.alias, err)
+	}
+
+	buf, err := filterContent(p.filter, resp.Content)
+	if err != nil {
+		return nil, contentError(p.uri.Text(), p.alias, p.uri.Text(), err)
+	}
+
+	err = p.checker.CheckContent(buf)
+	if err != nil {
+		return nil, contentError(p.uri.Text(), p.alias, p.uri.Text(), err)
+	}
+
+	return buf, nil
+}
+
+func (p *PluginCatalog) WithLogger(l *slog.Logger) *PluginCatalog {
+	p.logger = l
+	return p
+}
+
+// WithFilter makes the PluginCatalog filter the fetched content with the
+// Filter before checking it.
+func (p *PluginCatalog) WithFilter(filter Filter) *PluginCatalog {
+	p.filter = filter
+	return p
+}
+
+// WithProgram makes the PluginCatalog run the program, like the absolute path,
+// instead of the one of the plugin in PATH.
+func (p *PluginCatalog) WithProgram(program string) *PluginCatalog {
+	p.program = program
+	return p
+}
+
+// WithStderr sets the writer of the standard error of the program. It is
+// discarded by default.
+func (p *PluginCatalog) WithStderr(w io.Writer) *PluginCatalog {
+	p.stderr = w
+	return p
+}
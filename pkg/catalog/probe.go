@@ -0,0 +1,106 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/go-github/v55/github"
+)
+
+var ErrNotFile = errors.New("not a regular file")
+
+// Probe checks the file of the FSCatalog is readable without reading it, and
+// returns its size.
+func (f *FSCatalog) Probe(ctx context.Context) (int64, error) {
+	err := f.guard.check(f.uri.Path())
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(f.uri.Path())
+	if err != nil {
+		return 0, err
+	}
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("%s: %w", f.uri.Path(), ErrNotFile)
+	}
+
+	file, err := os.Open(f.uri.Path())
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), file.Close()
+}
+
+// Probe checks the file of the GitHubCatalog exists with the HEAD request of
+// the Get repository content API, without downloading the content. The size is
+// -1 since it is not returned.
+func (g *GitHubCatalog) Probe(ctx context.Context) (int64, error) {
+	client := g.client
+	if client == nil {
+		client = github.NewClient(g.http).WithAuthToken(os.Getenv("GITHUB_TOKEN"))
+	}
+
+	u := fmt.Sprintf("repos/%s/%s/contents/%s",
+		g.uri.Owner(), g.uri.Repo(), (&url.URL{Path: g.uri.RepoPath()}).String(),
+	)
+	if g.uri.Ref() != "" {
+		u += "?" + url.Values{"ref": {g.uri.Ref()}}.Encode()
+	}
+
+	err := g.request(ctx, func(ctx context.Context) (*github.Response, error) {
+		req, err := client.NewRequest(http.MethodHead, u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.Do(ctx, req, nil)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return -1, nil
+}
+
+// Probe checks the object of the S3Catalog exists with the HeadObject API,
+// and returns its size.
+func (s *S3Catalog) Probe(ctx context.Context) (int64, error) {
+	cfg, err := s.loadConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	client := s3.NewFromConfig(cfg, s.opts...)
+
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(s.uri.Bucket()),
+		Key:    aws.String(s.uri.Key()),
+	}
+	if v := s.uri.VersionID(); v != "" {
+		input.VersionId = aws.String(v)
+	}
+
+	var size int64
+	err = s.retry.do(ctx, func(ctx context.Context) error {
+		output, err := client.HeadObject(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		size = output.ContentLength
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
@@ -0,0 +1,70 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Range is the part of the source fetched by the catalog, so that a huge
+// combined file is not read entirely into memory when only a part of it is
+// needed.
+type Range struct {
+	// Offset is the number of bytes skipped at the head of the source.
+	Offset int64
+	// Length is the maximum number of bytes read from the offset. The source
+	// is read to the end if it is 0.
+	Length int64
+	// FirstBlock stops reading at the end of the first PEM block.
+	FirstBlock bool
+}
+
+// partial reports whether the range is a part of the source.
+func (r Range) partial() bool {
+	return r.Offset > 0 || r.Length > 0 || r.FirstBlock
+}
+
+// httpRange returns the value of the HTTP Range header, or empty if the whole
+// source is read from the offset 0.
+func (r Range) httpRange() string {
+	if r.Length > 0 {
+		return fmt.Sprintf("bytes=%d-%d", r.Offset, r.Offset+r.Length-1)
+	}
+	if r.Offset > 0 {
+		return fmt.Sprintf("bytes=%d-", r.Offset)
+	}
+
+	return ""
+}
+
+var pemEnd = []byte("-----END ")
+
+// read reads the range from the reader positioned at the offset.
+func (r Range) read(reader io.Reader) ([]byte, error) {
+	if r.Length > 0 {
+		reader = io.LimitReader(reader, r.Length)
+	}
+
+	if !r.FirstBlock {
+		return io.ReadAll(reader)
+	}
+
+	var buf bytes.Buffer
+	br := bufio.NewReader(reader)
+	for {
+		line, err := br.ReadBytes('\n')
+		buf.Write(line)
+
+		if bytes.HasPrefix(bytes.TrimLeft(line, " \t"), pemEnd) {
+			return buf.Bytes(), nil
+		}
+		if errors.Is(err, io.EOF) {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
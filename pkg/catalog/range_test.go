@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRange_Read(t *testing.T) {
+	t.Parallel()
+
+	content := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n" +
+		"-----BEGIN CERTIFICATE-----\nMIIC\n-----END CERTIFICATE-----\n"
+
+	data := []struct {
+		testCase string
+		rng      Range
+		want     string
+	}{
+		{"Whole", Range{}, content},
+		{"Length", Range{Length: 27}, "-----BEGIN CERTIFICATE-----"},
+		{"First Block", Range{FirstBlock: true}, content[:59]},
+		{"First Block Not Ended", Range{Length: 40, FirstBlock: true}, content[:40]},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := d.rng.read(strings.NewReader(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(string(got), d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRange_HTTPRange(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		rng  Range
+		want string
+	}{
+		{Range{}, ""},
+		{Range{FirstBlock: true}, ""},
+		{Range{Offset: 100}, "bytes=100-"},
+		{Range{Offset: 100, Length: 50}, "bytes=100-149"},
+	}
+
+	for _, d := range data {
+		if got := d.rng.httpRange(); got != d.want {
+			t.Errorf("Expected %q but got: %q", d.want, got)
+		}
+	}
+}
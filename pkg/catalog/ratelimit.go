@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// ErrRateLimited means the rate limit of the GitHub API is exhausted, and it
+// is not reset before the deadline.
+var ErrRateLimited = errors.New("rate limit of GitHub API is exhausted")
+
+// reserveRatio is the ratio of the remaining requests to the limit, under
+// which the requests are spread until the reset of the limit.
+const reserveRatio = 10
+
+// RateLimiter throttles the requests of the GitHub catalogs sharing it, with
+// the rate limit of the X-RateLimit-Remaining and X-RateLimit-Reset headers of
+// the last response. The requests wait for the reset if the limit is
+// exhausted, and are spread until the reset if the remaining requests are
+// under a tenth of the limit, so that the catalogs fetched later do not fail
+// with 403 in the middle of a run.
+type RateLimiter struct {
+	mu   sync.Mutex
+	rate github.Rate
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{}
+}
+
+// rateLimited returns the error of the exhausted rate limit with the time of
+// its reset.
+func rateLimited(reset time.Time) error {
+	return fmt.Errorf("%w until %s", ErrRateLimited, reset.Format(time.RFC3339))
+}
+
+// wait blocks until the request is allowed by the rate limit. The request is
+// counted in the remaining requests, so that the concurrent requests are
+// spread. It returns the error of the exhausted rate limit if the limit is not
+// reset before the deadline of the context.
+func (r *RateLimiter) wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	rate := r.rate
+	until := time.Until(rate.Reset.Time)
+	if rate.Limit == 0 || until <= 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	r.rate.Remaining--
+	r.mu.Unlock()
+
+	var delay time.Duration
+	switch {
+	case rate.Remaining <= 0:
+		delay = until
+	case rate.Remaining*reserveRatio <= rate.Limit:
+		delay = until / time.Duration(rate.Remaining+1)
+	default:
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		if rate.Remaining <= 0 {
+			return rateLimited(rate.Reset.Time)
+		}
+		// The spreading is given up, since the request is still allowed.
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe updates the rate limit with the one of the response. The remaining
+// requests are not increased in the same window of the limit, since the
+// responses of the concurrent requests arrive in any order.
+func (r *RateLimiter) observe(resp *github.Response) {
+	if r == nil || resp == nil || resp.Rate.Limit == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if resp.Rate.Reset.Time.Equal(r.rate.Reset.Time) && resp.Rate.Remaining > r.rate.Remaining {
+		return
+	}
+	r.rate = resp.Rate
+}
@@ -0,0 +1,93 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase  string
+		limit     int
+		remaining int
+		reset     time.Duration
+		timeout   time.Duration
+		// want
+		minDelay time.Duration
+		err      error
+	}{
+		{"OK:Not Observed", 0, 0, 0, 0, 0, nil},
+		{"OK:Enough", 5000, 4000, time.Hour, 0, 0, nil},
+		{"OK:Reset Passed", 5000, 0, -time.Second, 0, 0, nil},
+		{"OK:Spread", 100, 4, 250 * time.Millisecond, 0, 40 * time.Millisecond, nil},
+		{"OK:Spread Given Up", 100, 4, time.Hour, time.Second, 0, nil},
+		{"OK:Exhausted", 100, 0, 50 * time.Millisecond, 0, 40 * time.Millisecond, nil},
+		{"NG:Exhausted", 100, 0, time.Hour, time.Second, 0, ErrRateLimited},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			limiter := NewRateLimiter()
+			limiter.observe(&github.Response{Rate: github.Rate{
+				Limit:     d.limit,
+				Remaining: d.remaining,
+				Reset:     github.Timestamp{Time: time.Now().Add(d.reset)},
+			}})
+
+			ctx := context.Background()
+			if d.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, d.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			err := limiter.wait(ctx)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if elapsed := time.Since(start); elapsed < d.minDelay || (d.minDelay == 0 && elapsed > 100*time.Millisecond) {
+				t.Errorf("Expected the delay of %s but got: %s", d.minDelay, elapsed)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Observe(t *testing.T) {
+	t.Parallel()
+
+	reset := github.Timestamp{Time: time.Now().Add(time.Hour)}
+	limiter := NewRateLimiter()
+	limiter.observe(nil)
+	limiter.observe(&github.Response{Rate: github.Rate{Limit: 5000, Remaining: 10, Reset: reset}})
+	// The late response of the same window does not increase the remaining.
+	limiter.observe(&github.Response{Rate: github.Rate{Limit: 5000, Remaining: 20, Reset: reset}})
+	if limiter.rate.Remaining != 10 {
+		t.Errorf("Expected 10 remaining but got: %d", limiter.rate.Remaining)
+	}
+
+	// The new window resets the remaining.
+	next := github.Timestamp{Time: reset.Add(time.Hour)}
+	limiter.observe(&github.Response{Rate: github.Rate{Limit: 5000, Remaining: 4999, Reset: next}})
+	if limiter.rate.Remaining != 4999 {
+		t.Errorf("Expected 4999 remaining but got: %d", limiter.rate.Remaining)
+	}
+
+	// The requests are counted in the remaining.
+	err := limiter.wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limiter.rate.Remaining != 4998 {
+		t.Errorf("Expected 4998 remaining but got: %d", limiter.rate.Remaining)
+	}
+}
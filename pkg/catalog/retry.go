@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// Retry is the policy of retrying the failed requests of the remote catalogs
+// with the exponential backoff, so that a transient error of the API, like 502
+// of GitHub, does not fail the whole run. The zero value does not retry.
+type Retry struct {
+	// Attempts is the maximum number of the requests including the first one.
+	Attempts int
+	// BaseDelay is the delay before the first retry. It is doubled for each
+	// retry.
+	BaseDelay time.Duration
+	// Jitter is the fraction of the delay randomized, from 0 to 1, so that the
+	// clients failed at the same time do not retry at the same time.
+	Jitter float64
+	// MaxElapsed is the maximum time from the first request, which the retry
+	// must be started in. It is not limited if it is 0.
+	MaxElapsed time.Duration
+}
+
+// do calls the request until it succeeds, it fails with the error that is
+// not transient, or the attempts run out. The retry is given up if the delay
+// exceeds the deadline of the context or the MaxElapsed, and the last error
+// is returned.
+func (r Retry) do(ctx context.Context, request func(context.Context) error) error {
+	return r.Do(ctx, request, retryAfter)
+}
+
+// Do calls the request like the remote catalogs, but the transient function
+// tells whether the error is transient instead, so the orders retry their
+// writes with the same policy. The transient function returns the time to
+// wait, or 0 to wait for the backoff.
+func (r Retry) Do(
+	ctx context.Context, request func(context.Context) error, transient func(error) (time.Duration, bool),
+) error {
+	start := time.Now()
+	delay := r.BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := request(ctx)
+		if err == nil || attempt >= r.Attempts {
+			return err
+		}
+
+		wait, ok := transient(err)
+		if !ok {
+			return err
+		}
+		if wait <= 0 {
+			wait = r.jitter(delay)
+			delay *= 2
+		}
+
+		if r.MaxElapsed > 0 && time.Since(start)+wait > r.MaxElapsed {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// jitter returns the delay reduced by the random fraction of the Jitter.
+func (r Retry) jitter(delay time.Duration) time.Duration {
+	if r.Jitter <= 0 {
+		return delay
+	}
+
+	//nolint:gosec // The jitter does not need the secure random numbers.
+	return delay - time.Duration(float64(delay)*r.Jitter*rand.Float64())
+}
+
+// httpStatusError is the error of the HTTP response of the AWS SDK.
+type httpStatusError interface {
+	HTTPStatusCode() int
+}
+
+// retryAfter reports whether the error is transient, and returns the time
+// until the limit is reset if the error is of the rate limit of GitHub. The
+// time is 0 for the other errors, and the backoff is used.
+func retryAfter(err error) (time.Duration, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return time.Until(rateErr.Rate.Reset.Time), true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return 0, true
+	}
+
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		return 0, transientStatus(respErr.Response.StatusCode)
+	}
+
+	var statusErr httpStatusError
+	if errors.As(err, &statusErr) {
+		return 0, transientStatus(statusErr.HTTPStatusCode())
+	}
+
+	var netErr net.Error
+	return 0, errors.As(err, &netErr)
+}
+
+// transientStatus reports whether the request of the status may succeed if it
+// is retried.
+func transientStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
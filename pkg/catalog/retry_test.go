@@ -0,0 +1,121 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// testStatusError is the error of the HTTP response like the AWS SDK.
+type testStatusError int
+
+func (e testStatusError) Error() string {
+	return http.StatusText(int(e))
+}
+
+func (e testStatusError) HTTPStatusCode() int {
+	return int(e)
+}
+
+func TestRetry_Do(t *testing.T) {
+	t.Parallel()
+
+	errBadGateway := testStatusError(http.StatusBadGateway)
+	errNotFound := testStatusError(http.StatusNotFound)
+
+	data := []struct {
+		testCase string
+		retry    Retry
+		errs     []error
+		timeout  time.Duration
+		// want
+		calls int
+		err   error
+	}{
+		{"OK:No Retry", Retry{}, []error{nil}, 0, 1, nil},
+		{"OK:Transient", Retry{Attempts: 3, BaseDelay: time.Millisecond, Jitter: 0.5}, []error{errBadGateway, errBadGateway, nil}, 0, 3, nil},
+		{"NG:Zero Value", Retry{}, []error{errBadGateway, nil}, 0, 1, errBadGateway},
+		{"NG:Attempts Run Out", Retry{Attempts: 2, BaseDelay: time.Millisecond}, []error{errBadGateway, errBadGateway, nil}, 0, 2, errBadGateway},
+		{"NG:Not Transient", Retry{Attempts: 3, BaseDelay: time.Millisecond}, []error{errNotFound, nil}, 0, 1, errNotFound},
+		{"NG:Max Elapsed", Retry{Attempts: 3, BaseDelay: time.Hour, MaxElapsed: time.Minute}, []error{errBadGateway, nil}, 0, 1, errBadGateway},
+		{"NG:Deadline", Retry{Attempts: 3, BaseDelay: time.Hour}, []error{errBadGateway, nil}, time.Minute, 1, errBadGateway},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if d.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, d.timeout)
+				defer cancel()
+			}
+
+			var calls int
+			err := d.retry.do(ctx, func(context.Context) error {
+				err := d.errs[calls]
+				calls++
+				return err
+			})
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if calls != d.calls {
+				t.Errorf("Expected %d calls but got: %d", d.calls, calls)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	retryAfterSec := 30 * time.Second
+	reset := time.Now().Add(time.Hour)
+
+	data := []struct {
+		testCase string
+		err      error
+		// want
+		wait      time.Duration
+		retryable bool
+	}{
+		{"Bad Gateway", testStatusError(http.StatusBadGateway), 0, true},
+		{"Too Many Requests", testStatusError(http.StatusTooManyRequests), 0, true},
+		{"Forbidden", testStatusError(http.StatusForbidden), 0, false},
+		{
+			"GitHub Server Error",
+			&github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+			0,
+			true,
+		},
+		{
+			"GitHub Not Found",
+			&github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}},
+			0,
+			false,
+		},
+		{"GitHub Abuse Rate Limit", &github.AbuseRateLimitError{RetryAfter: &retryAfterSec}, retryAfterSec, true},
+		{"Canceled", context.Canceled, 0, false},
+		{"Other", errors.New("other"), 0, false},
+	}
+
+	for _, d := range data {
+		wait, retryable := retryAfter(d.err)
+		if wait != d.wait || retryable != d.retryable {
+			t.Errorf("%s: Expected (%s, %t) but got: (%s, %t)", d.testCase, d.wait, d.retryable, wait, retryable)
+		}
+	}
+
+	// The wait of the rate limit is until the reset.
+	wait, retryable := retryAfter(&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}})
+	if !retryable || wait <= 59*time.Minute || wait > time.Hour {
+		t.Errorf("Expected the wait until the reset but got: (%s, %t)", wait, retryable)
+	}
+}
@@ -0,0 +1,216 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+var (
+	ErrContentChanged = errors.New("content is changed after it is checked")
+	// ErrBlockTooLarge means a PEM block of the content streamed by Open
+	// exceeds the MaxBlockSize.
+	ErrBlockTooLarge = errors.New("PEM block is too large")
+)
+
+// MaxBlockSize is the maximum size of a PEM block of the content streamed by
+// Open, which is held in memory until its end line is read.
+const MaxBlockSize = 64 << 20
+
+// Open opens the file to read it as a stream, so the content is not held in
+// memory while it is written. If the checker is a BlockChecker, every PEM block
+// is checked as soon as it is read, and the reader fails instead of returning
+// the rest of the content when a block is not expected, so the writer must
+// discard what it has written. Otherwise, the content is checked like Fetch,
+// and the file is opened again, and the reader fails at the end instead of
+// returning io.EOF if the file is changed after the check. The filtered or the
+// partial content is read from the memory, since it differs from the file.
+func (f *FSCatalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	checker, ok := f.checker.(BlockChecker)
+	if !ok || f.filter != nil || f.rng.partial() {
+		return f.openChecked(ctx)
+	}
+
+	if f.logger != nil {
+		f.logger.InfoContext(ctx, "fetching", "alias", f.alias, "scheme", f.uri.Scheme(), "uri", f.uri.Text())
+	}
+
+	if f.fsys == nil {
+		err := f.guard.check(f.uri.Path())
+		if err != nil {
+			return nil, fetchError(f.uri.Text(), f.alias, err)
+		}
+	}
+
+	file, err := f.open()
+	if err != nil {
+		return nil, fetchError(f.uri.Text(), f.alias, err)
+	}
+
+	r := &blockReader{
+		file:    file,
+		uri:     f.uri.Text(),
+		alias:   f.alias,
+		name:    f.uri.Path(),
+		checker: checker,
+		max:     MaxBlockSize,
+	}
+
+	return r, nil
+}
+
+// openChecked checks the whole content with Fetch, and opens the file again
+// to read it as a stream.
+func (f *FSCatalog) openChecked(ctx context.Context) (io.ReadCloser, error) {
+	buf, err := f.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.filter != nil || f.rng.partial() {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}
+
+	sum := sha256.Sum256(buf)
+
+	file, err := f.open()
+	if err != nil {
+		return nil, fetchError(f.uri.Text(), f.alias, err)
+	}
+
+	r := &digestReader{
+		file:  file,
+		uri:   f.uri.Text(),
+		alias: f.alias,
+		name:  f.uri.Path(),
+		hash:  sha256.New(),
+		sum:   sum[:],
+	}
+
+	return r, nil
+}
+
+// blockReader reads the file, and checks every PEM block when its end line is
+// read. Only the block being read is held in memory, up to the max, and each
+// byte is scanned once for the end line.
+type blockReader struct {
+	file    io.ReadCloser
+	uri     string
+	alias   string
+	name    string
+	checker BlockChecker
+	max     int
+	pending []byte
+	// scan is the offset of the pending from where the end line, or the
+	// newline of the end line if ended, is searched.
+	scan   int
+	ended  bool
+	blocks int
+	err    error
+}
+
+func (b *blockReader) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	n, err := b.file.Read(p)
+	b.pending = append(b.pending, p[:n]...)
+
+	cerr := b.checkBlocks(err == io.EOF)
+	if cerr != nil {
+		b.err = contentError(b.uri, b.alias, b.name, cerr)
+		return n, b.err
+	}
+
+	return n, err
+}
+
+// checkBlocks checks the blocks whose end lines are read, and the rest of the
+// content at the end of the file.
+func (b *blockReader) checkBlocks(eof bool) error {
+	for {
+		if !b.ended {
+			idx := bytes.Index(b.pending[b.scan:], pemEnd)
+			if idx < 0 {
+				// The end line may be split by the read, so its head is
+				// searched again.
+				if b.scan = len(b.pending) - len(pemEnd) + 1; b.scan < 0 {
+					b.scan = 0
+				}
+				break
+			}
+
+			b.scan += idx
+			b.ended = true
+		}
+
+		eol := bytes.IndexByte(b.pending[b.scan:], '\n')
+		if eol < 0 {
+			b.scan = len(b.pending)
+			break
+		}
+
+		end := b.scan + eol + 1
+		err := b.checker.CheckBlocks(b.pending[:end], b.blocks)
+		if err != nil {
+			return err
+		}
+
+		b.blocks++
+		b.pending = append(b.pending[:0], b.pending[end:]...)
+		b.scan = 0
+		b.ended = false
+	}
+
+	if len(b.pending) > b.max {
+		return fmt.Errorf("%d bytes: %w", len(b.pending), ErrBlockTooLarge)
+	}
+
+	if !eof || (b.blocks > 0 && len(bytes.TrimSpace(b.pending)) == 0) {
+		return nil
+	}
+
+	err := b.checker.CheckBlocks(b.pending, b.blocks)
+	if err != nil {
+		return err
+	}
+
+	b.blocks++
+	b.pending = nil
+
+	return nil
+}
+
+func (b *blockReader) Close() error {
+	return b.file.Close()
+}
+
+// digestReader reads the file, and verifies its digest at the end.
+type digestReader struct {
+	file  io.ReadCloser
+	uri   string
+	alias string
+	name  string
+	hash  hash.Hash
+	sum   []byte
+}
+
+func (d *digestReader) Read(p []byte) (int, error) {
+	n, err := d.file.Read(p)
+	d.hash.Write(p[:n])
+
+	if err == io.EOF && !bytes.Equal(d.hash.Sum(nil), d.sum) {
+		return n, contentError(d.uri, d.alias, d.name, ErrContentChanged)
+	}
+
+	return n, err
+}
+
+func (d *digestReader) Close() error {
+	return d.file.Close()
+}
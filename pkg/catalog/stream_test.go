@@ -0,0 +1,231 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestFSCatalog_Open(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		checkErr error
+		changed  bool
+		// want
+		openErr error
+		readErr error
+	}{
+		{"Streamed", nil, false, nil, nil},
+		{"Check Failure", errTestCheck, false, errTestCheck, nil},
+		{"Changed After Check", nil, true, nil, ErrContentChanged},
+	}
+
+	dir := path.Join("testdata", "TestFSCatalog_Open")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	content := []byte("-----BEGIN X509 CRL-----\n")
+
+	for idx, d := range data {
+		idx, d := idx, d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			file := path.Join(dir, fmt.Sprintf("%d.crl", idx))
+			err := os.WriteFile(file, content, 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			uri, err := uriapi.NewFSURI("file://" + file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checker := &recordChecker{err: d.checkErr}
+			r, err := NewFSCatalog(uri, "", checker).Open(context.TODO())
+			if !errors.Is(err, d.openErr) {
+				t.Fatalf("Expected %#v error but got: %#v", d.openErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer r.Close()
+
+			if d.changed {
+				err = os.WriteFile(file, []byte("-----BEGIN X509 CRL-----\nchanged\n"), 0o600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			buf, err := io.ReadAll(r)
+			if !errors.Is(err, d.readErr) {
+				t.Fatalf("Expected %#v error but got: %#v", d.readErr, err)
+			}
+			if err == nil && string(buf) != string(content) {
+				t.Errorf("Expected %q but got: %q", content, buf)
+			}
+		})
+	}
+}
+
+// blockChecker records the parts it checks, and fails with the err at the
+// block of the index failAt.
+type blockChecker struct {
+	parts  []string
+	failAt int
+	err    error
+}
+
+func (b *blockChecker) CheckContent(buf []byte) error {
+	return b.CheckBlocks(buf, 0)
+}
+
+func (b *blockChecker) CheckBlocks(part []byte, first int) error {
+	b.parts = append(b.parts, string(part))
+	if first == b.failAt {
+		return b.err
+	}
+	if !strings.Contains(string(part), "-----BEGIN ") {
+		return errTestCheck
+	}
+	return nil
+}
+
+func TestFSCatalog_Open_Blocks(t *testing.T) {
+	t.Parallel()
+
+	block := "-----BEGIN X509 CRL-----\nMA==\n-----END X509 CRL-----\n"
+
+	data := []struct {
+		testCase string
+		content  string
+		failAt   int
+		// want
+		parts   []string
+		readErr error
+	}{
+		{"Blocks", block + block, -1, []string{block, block}, nil},
+		{"Without Last Newline", block + strings.TrimSuffix(block, "\n"), -1, []string{block, strings.TrimSuffix(block, "\n")}, nil},
+		{"Trailing Spaces", block + "\n\n", -1, []string{block}, nil},
+		{"Second Block Failure", block + block + block, 1, []string{block, block}, errTestCheck},
+		{"No Block", "\n", -1, []string{"\n"}, errTestCheck},
+	}
+
+	dir := path.Join("testdata", "TestFSCatalog_Open_Blocks")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for idx, d := range data {
+		idx, d := idx, d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			file := path.Join(dir, fmt.Sprintf("%d.crl", idx))
+			err := os.WriteFile(file, []byte(d.content), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			uri, err := uriapi.NewFSURI("file://" + file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checker := &blockChecker{failAt: d.failAt, err: errTestCheck}
+			r, err := NewFSCatalog(uri, "", checker).Open(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			// The small buffer splits the blocks across the reads.
+			buf, err := io.ReadAll(iotest.OneByteReader(r))
+			if !errors.Is(err, d.readErr) {
+				t.Fatalf("Expected %#v error but got: %#v", d.readErr, err)
+			}
+
+			var cErr ContentError
+			if err != nil && !errors.As(err, &cErr) {
+				t.Errorf("Expected ContentError but got: %#v", err)
+			}
+			if err == nil && string(buf) != d.content {
+				t.Errorf("Expected %q but got: %q", d.content, buf)
+			}
+			if !reflect.DeepEqual(checker.parts, d.parts) {
+				t.Errorf("Expected %q parts but got: %q", d.parts, checker.parts)
+			}
+		})
+	}
+}
+
+func TestBlockReader_Large(t *testing.T) {
+	t.Parallel()
+
+	// A large CRL is a single PEM block.
+	line := strings.Repeat("A", 64) + "\n"
+	block := "-----BEGIN X509 CRL-----\n" + strings.Repeat(line, (8<<20)/len(line)) + "-----END X509 CRL-----\n"
+
+	data := []struct {
+		testCase string
+		max      int
+		// want
+		parts   int
+		readErr error
+	}{
+		{"OK", MaxBlockSize, 1, nil},
+		{"NG:Too Large", 1 << 20, 0, ErrBlockTooLarge},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			checker := &blockChecker{failAt: -1}
+			r := &blockReader{
+				file:    io.NopCloser(strings.NewReader(block)),
+				checker: checker,
+				max:     d.max,
+			}
+
+			// The small buffer reads the block in thousands of reads, which
+			// must not scan the block again on each of them.
+			n, err := io.CopyBuffer(struct{ io.Writer }{io.Discard}, struct{ io.Reader }{r}, make([]byte, 4096))
+			if !errors.Is(err, d.readErr) {
+				t.Fatalf("Expected %#v error but got: %#v", d.readErr, err)
+			}
+			if err == nil && n != int64(len(block)) {
+				t.Errorf("Expected %d bytes but got: %d", len(block), n)
+			}
+			if len(checker.parts) != d.parts {
+				t.Errorf("Expected %d parts but got: %d", d.parts, len(checker.parts))
+			}
+			if d.parts > 0 && checker.parts[0] != block {
+				t.Error("Expected the whole block to be checked")
+			}
+			// The pending exceeds the max by the last read at most.
+			if len(r.pending) > d.max+4096 {
+				t.Errorf("Expected at most %d pending bytes but got: %d", d.max+4096, len(r.pending))
+			}
+		})
+	}
+}
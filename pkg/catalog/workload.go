@@ -0,0 +1,343 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+	"golang.org/x/net/http2"
+)
+
+var (
+	ErrNoSVID           = errors.New("X509-SVID is not returned by the workload API")
+	ErrWorkloadStatus   = errors.New("workload API returned the error status")
+	ErrMalformedMessage = errors.New("malformed message of the workload API")
+	ErrNoEndpoint       = errors.New("endpoint of the workload API is not specified")
+)
+
+// WorkloadCatalog is an implementation of the Catalog interface. It is
+// responsible for fetching the X509-SVID, its private key, or the trust bundle
+// of the workload from the SPIFFE Workload API, like the SPIRE agent, so the
+// identities issued by the mesh are distributed with the cataloged CAs.
+type WorkloadCatalog struct {
+	uri     uriapi.WorkloadURI
+	alias   string
+	checker AssetChecker
+	filter  Filter
+	logger  *slog.Logger
+}
+
+func NewWorkloadCatalog(uri uriapi.WorkloadURI, alias string, checker AssetChecker) *WorkloadCatalog {
+	ctlg := &WorkloadCatalog{
+		uri:     uri,
+		alias:   alias,
+		checker: checker,
+	}
+
+	return ctlg
+}
+
+// The Fetch function calls the FetchX509SVID of the Workload API, and returns
+// the asset of the default SVID, which is the first one, in PEM. The socket is
+// the path of the URI, or the environment variable "SPIFFE_ENDPOINT_SOCKET".
+// The certificates are in the "CERTIFICATE" blocks, and the private key is in
+// the "PRIVATE KEY" block of PKCS #8.
+func (w *WorkloadCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	if w.logger != nil {
+		w.logger.InfoContext(ctx, "fetching", "alias", w.alias, "scheme", w.uri.Scheme(), "uri", w.uri.Text())
+	}
+
+	network, address, err := w.endpoint()
+	if err != nil {
+		return nil, fetchError(w.uri.Text(), w.alias, err)
+	}
+
+	svid, err := fetchX509SVID(ctx, network, address)
+	if err != nil {
+		return nil, fetchError(w.uri.Text(), w.alias, err)
+	}
+
+	var buf []byte
+	switch w.uri.Asset() {
+	case "svid":
+		buf, err = encodeCertificates(svid.certificates)
+	case "key":
+		buf = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: svid.key})
+	default:
+		buf, err = encodeCertificates(svid.bundle)
+	}
+	if err != nil {
+		return nil, contentError(w.uri.Text(), w.alias, w.uri.Text(), err)
+	}
+
+	buf, err = filterContent(w.filter, buf)
+	if err != nil {
+		return nil, contentError(w.uri.Text(), w.alias, w.uri.Text(), err)
+	}
+
+	err = w.checker.CheckContent(buf)
+	if err != nil {
+		return nil, contentError(w.uri.Text(), w.alias, w.uri.Text(), err)
+	}
+
+	return buf, nil
+}
+
+// endpoint returns the network and the address of the Workload API. The
+// environment variable is "unix:///path" or "tcp://ip:port" format.
+func (w *WorkloadCatalog) endpoint() (string, string, error) {
+	if w.uri.Path() != "" {
+		return "unix", w.uri.Path(), nil
+	}
+
+	socket := os.Getenv("SPIFFE_ENDPOINT_SOCKET")
+	switch {
+	case strings.HasPrefix(socket, "unix:"):
+		return "unix", strings.TrimPrefix(strings.TrimPrefix(socket, "unix:"), "//"), nil
+	case strings.HasPrefix(socket, "tcp://"):
+		return "tcp", strings.TrimPrefix(socket, "tcp://"), nil
+	}
+
+	return "", "", fmt.Errorf("%s: %w", w.uri.Text(), ErrNoEndpoint)
+}
+
+func (w *WorkloadCatalog) WithLogger(l *slog.Logger) *WorkloadCatalog {
+	w.logger = l
+	return w
+}
+
+// WithFilter makes the WorkloadCatalog filter the fetched content with the
+// Filter before checking it.
+func (w *WorkloadCatalog) WithFilter(filter Filter) *WorkloadCatalog {
+	w.filter = filter
+	return w
+}
+
+// x509SVID is the X509SVID message of the Workload API. The certificates and
+// the bundle are the concatenated DER.
+type x509SVID struct {
+	certificates []byte
+	key          []byte
+	bundle       []byte
+}
+
+// grpcMaxMessageSize is the limit of the received message, which is the
+// default of gRPC.
+const grpcMaxMessageSize = 4 << 20
+
+// grpcCodes are the names of the status codes of gRPC.
+var grpcCodes = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound", "AlreadyExists",
+	"PermissionDenied", "ResourceExhausted", "FailedPrecondition", "Aborted", "OutOfRange",
+	"Unimplemented", "Internal", "Unavailable", "DataLoss", "Unauthenticated",
+}
+
+// fetchX509SVID calls the FetchX509SVID of the Workload API with gRPC over
+// HTTP/2 without TLS, and returns the default SVID of the first response. The
+// error status of the response headers or the trailers is reported as
+// ErrWorkloadStatus.
+func fetchX509SVID(ctx context.Context, network, address string) (x509SVID, error) {
+	tr := &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, _, _ string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}
+	defer tr.CloseIdleConnections()
+
+	// The stream of the Workload API is not ended by the server, so the
+	// request is canceled after the first response.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The X509SVIDRequest is empty.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		"http://localhost/SpiffeWorkloadAPI/FetchX509SVID", bytes.NewReader(make([]byte, 5)))
+	if err != nil {
+		return x509SVID{}, err
+	}
+	req.Header.Set("content-type", "application/grpc")
+	req.Header.Set("te", "trailers")
+	req.Header.Set("workload.spiffe.io", "true")
+
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		return x509SVID{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return x509SVID{}, fmt.Errorf("HTTP status %d: %w", resp.StatusCode, ErrWorkloadStatus)
+	}
+
+	// The response without the message has the status in the headers.
+	err = grpcStatus(resp.Header)
+	if err != nil {
+		return x509SVID{}, err
+	}
+
+	prefix := make([]byte, 5)
+	_, err = io.ReadFull(resp.Body, prefix)
+	if errors.Is(err, io.EOF) {
+		// The trailers are read at the end of the body.
+		err = grpcStatus(resp.Trailer)
+		if err != nil {
+			return x509SVID{}, err
+		}
+		return x509SVID{}, ErrNoSVID
+	}
+	if err != nil {
+		return x509SVID{}, err
+	}
+
+	if prefix[0] != 0 {
+		// The compression is not requested.
+		return x509SVID{}, ErrMalformedMessage
+	}
+
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > grpcMaxMessageSize {
+		return x509SVID{}, fmt.Errorf("%d bytes: %w", size, ErrMalformedMessage)
+	}
+
+	msg := make([]byte, size)
+	_, err = io.ReadFull(resp.Body, msg)
+	if err != nil {
+		return x509SVID{}, fmt.Errorf("%s: %w", err, ErrMalformedMessage)
+	}
+
+	return parseX509SVIDResponse(msg)
+}
+
+// grpcStatus returns the error of the grpc-status and the grpc-message in the
+// header, or nil if the status is OK or not contained.
+func grpcStatus(header http.Header) error {
+	status := header.Get("grpc-status")
+	if status == "" || status == "0" {
+		return nil
+	}
+
+	code := "Code(" + status + ")"
+	if n, err := strconv.Atoi(status); err == nil && n >= 0 && n < len(grpcCodes) {
+		code = grpcCodes[n]
+	}
+
+	msg, err := url.PathUnescape(header.Get("grpc-message"))
+	if err != nil {
+		msg = header.Get("grpc-message")
+	}
+
+	return fmt.Errorf("%s: %s: %w", code, msg, ErrWorkloadStatus)
+}
+
+// parseX509SVIDResponse returns the first SVID of the X509SVIDResponse.
+func parseX509SVIDResponse(b []byte) (x509SVID, error) {
+	var svid x509SVID
+	var found bool
+
+	err := walkProto(b, func(num uint64, value []byte) error {
+		if num != 1 || found {
+			return nil
+		}
+		found = true
+
+		return walkProto(value, func(num uint64, value []byte) error {
+			switch num {
+			case 2:
+				svid.certificates = value
+			case 3:
+				svid.key = value
+			case 4:
+				svid.bundle = value
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return x509SVID{}, err
+	}
+	if !found {
+		return x509SVID{}, ErrNoSVID
+	}
+
+	return svid, nil
+}
+
+// walkProto calls the fn with the length-delimited fields of the protocol
+// buffers message. The other fields are skipped.
+func walkProto(b []byte, fn func(num uint64, value []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrMalformedMessage
+		}
+		b = b[n:]
+
+		switch key & 0x7 {
+		case 0:
+			_, n = binary.Uvarint(b)
+			if n <= 0 {
+				return ErrMalformedMessage
+			}
+			b = b[n:]
+		case 1, 5:
+			size := 8
+			if key&0x7 == 5 {
+				size = 4
+			}
+			if len(b) < size {
+				return ErrMalformedMessage
+			}
+			b = b[size:]
+		case 2:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return ErrMalformedMessage
+			}
+			value := b[n : n+int(length)]
+			b = b[n+int(length):]
+
+			err := fn(key>>3, value)
+			if err != nil {
+				return err
+			}
+		default:
+			return ErrMalformedMessage
+		}
+	}
+
+	return nil
+}
+
+// encodeCertificates encodes the concatenated DER of the certificates to PEM.
+func encodeCertificates(der []byte) ([]byte, error) {
+	certs, err := x509.ParseCertificates(der)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		err = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,209 @@
+package catalog
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/internal/testca"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/net/http2"
+)
+
+type testChecker struct{}
+
+func (testChecker) CheckContent([]byte) error {
+	return nil
+}
+
+// appendProtoBytes appends the length-delimited field of the protocol buffers.
+func appendProtoBytes(b []byte, num uint64, value []byte) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	b = append(b, buf[:binary.PutUvarint(buf, num<<3|2)]...)
+	b = append(b, buf[:binary.PutUvarint(buf, uint64(len(value)))]...)
+	return append(b, value...)
+}
+
+// testStatus is the gRPC status returned by the testWorkloadAPI.
+type testStatus struct {
+	code    string
+	message string
+}
+
+// testWorkloadAPI serves the FetchX509SVID with the response on the socket,
+// and keeps the stream open like the Workload API. If the status is set, it is
+// returned in the headers without the response if the response is nil, or in
+// the trailers after the response otherwise.
+func testWorkloadAPI(t *testing.T, sock string, response []byte, status *testStatus) {
+	t.Helper()
+
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("Unix domain socket is not available: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/SpiffeWorkloadAPI/FetchX509SVID" || r.Header.Get("workload.spiffe.io") != "true" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		w.Header().Set("content-type", "application/grpc")
+		if status != nil && response == nil {
+			w.Header().Set("grpc-status", status.code)
+			w.Header().Set("grpc-message", status.message)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if response != nil && len(response) > 0 {
+			msg := append([]byte{0, 0, 0, 0, 0}, response...)
+			binary.BigEndian.PutUint32(msg[1:5], uint32(len(response)))
+			_, _ = w.Write(msg)
+			w.(http.Flusher).Flush()
+		}
+
+		if status != nil {
+			w.Header().Set(http.TrailerPrefix+"grpc-status", status.code)
+			w.Header().Set(http.TrailerPrefix+"grpc-message", status.message)
+			return
+		}
+		if response != nil && len(response) == 0 {
+			w.Header().Set(http.TrailerPrefix+"grpc-status", "0")
+			return
+		}
+
+		<-r.Context().Done()
+	})
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		(&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+	}()
+}
+
+func TestWorkloadCatalog_Fetch(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestWorkloadCatalog_Fetch")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	testCA, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, svid := testCA.Root.Cert.Raw, testCA.Leaf.Cert.Raw
+	keyDER, err := x509.MarshalPKCS8PrivateKey(testCA.Leaf.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var svidMsg []byte
+	svidMsg = appendProtoBytes(svidMsg, 1, []byte("spiffe://example.org/cannect"))
+	svidMsg = appendProtoBytes(svidMsg, 2, svid)
+	svidMsg = appendProtoBytes(svidMsg, 3, keyDER)
+	svidMsg = appendProtoBytes(svidMsg, 4, ca)
+	response := appendProtoBytes(nil, 1, svidMsg)
+
+	denied := &testStatus{code: "7", message: "no identity issued"}
+	unavailable := &testStatus{code: "14", message: "agent is shutting down"}
+
+	data := []struct {
+		testCase string
+		asset    string
+		response []byte
+		status   *testStatus
+		// want
+		want []byte
+		err  error
+		msg  string
+	}{
+		{"OK:bundle", "", response, nil, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca}), nil, ""},
+		{"OK:svid", "?asset=svid", response, nil, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: svid}), nil, ""},
+		{"OK:key", "?asset=key", response, nil, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil, ""},
+		{"NG:no identity", "", nil, denied, nil, ErrWorkloadStatus, "PermissionDenied: no identity issued"},
+		{"NG:trailers", "", []byte{}, unavailable, nil, ErrWorkloadStatus, "Unavailable: agent is shutting down"},
+		{"NG:no svid", "", []byte{}, nil, nil, ErrNoSVID, ""},
+	}
+
+	for idx, d := range data {
+		d := d
+		sock := path.Join(dir, string(rune('a'+idx))+".sock")
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			testWorkloadAPI(t, sock, d.response, d.status)
+
+			uri, err := uriapi.NewWorkloadURI("workload://" + sock + d.asset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			got, err := NewWorkloadCatalog(uri, "spire", testChecker{}).Fetch(ctx)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), d.msg) {
+				t.Errorf("Expected %s in the error but got: %s", d.msg, err)
+			}
+			if diff := cmp.Diff(got, d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestWalkProto(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		msg      []byte
+		// want
+		values []string
+		err    error
+	}{
+		{"OK:skip", []byte{0x08, 0x96, 0x01, 0x12, 0x01, 'a', 0x1d, 0, 0, 0, 0}, []string{"a"}, nil},
+		{"NG:truncated", []byte{0x12, 0x05, 'a'}, nil, ErrMalformedMessage},
+		{"NG:wire type", []byte{0x0b}, nil, ErrMalformedMessage},
+	}
+
+	for _, d := range data {
+		var values []string
+		err := walkProto(d.msg, func(num uint64, value []byte) error {
+			values = append(values, string(value))
+			return nil
+		})
+		if !errors.Is(err, d.err) {
+			t.Errorf("%s: Expected %#v error but got: %#v", d.testCase, d.err, err)
+		}
+		if diff := cmp.Diff(values, d.values); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+}
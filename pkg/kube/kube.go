@@ -0,0 +1,167 @@
+// Package kube provides a minimal client of the Kubernetes API, enough for
+// applying Secrets and ConfigMaps and listing the CAnnect custom resources.
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	fieldManager      = "cannect"
+)
+
+var ErrNotInCluster = errors.New("not running in a Kubernetes cluster")
+
+// APIError is used to represent an error response of the Kubernetes API.
+type APIError struct {
+	Status  string
+	Message string
+	code    int
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Status, e.Message)
+}
+
+// NotFound reports whether the resource is not found.
+func (e APIError) NotFound() bool {
+	return e.code == http.StatusNotFound
+}
+
+// Throttled reports whether the request is refused by the priority and
+// fairness of the API server.
+func (e APIError) Throttled() bool {
+	return e.code == http.StatusTooManyRequests
+}
+
+// Client calls the Kubernetes API with a bearer token.
+type Client struct {
+	host   string
+	token  string
+	client *http.Client
+}
+
+// NewClient returns the Client of the API server at the host, like
+// "https://10.0.0.1:443".
+func NewClient(host, token string, client *http.Client) *Client {
+	return &Client{
+		host:   strings.TrimSuffix(host, "/"),
+		token:  token,
+		client: client,
+	}
+}
+
+// NewInClusterClient returns the Client authorized by the service account of
+// the pod.
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, ErrNotInCluster
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, err
+	}
+
+	caPEM, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("%s/ca.crt: no certificate found", serviceAccountDir)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+
+	return NewClient("https://"+net.JoinHostPort(host, port), strings.TrimSpace(string(token)), client), nil
+}
+
+// Namespace returns the namespace of the pod, or "default" if it is unknown.
+func Namespace() string {
+	ns, err := os.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil || len(ns) == 0 {
+		return "default"
+	}
+
+	return strings.TrimSpace(string(ns))
+}
+
+// Apply creates or updates the object at the path with the server-side apply.
+func (c *Client) Apply(ctx context.Context, path string, obj interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s%s?fieldManager=%s&force=true", c.host, path, fieldManager)
+
+	// The apply patch accepts JSON since it is a subset of YAML.
+	return c.do(ctx, http.MethodPatch, url, "application/apply-patch+yaml", body, nil)
+}
+
+// Get reads the object or the list of objects at the path into out.
+func (c *Client) Get(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, c.host+path, "", nil, out)
+}
+
+func (c *Client) do(ctx context.Context, method, url, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		apiErr := APIError{Status: resp.Status, code: resp.StatusCode}
+
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(b, &status) == nil {
+			apiErr.Message = status.Message
+		}
+
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(b, out)
+}
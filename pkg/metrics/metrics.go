@@ -0,0 +1,390 @@
+// Package metrics instruments the fetches of the catalogs and the orders, so
+// the failures of the distribution are alerted on. The Registry implements
+// the Instrument, and exposes the metrics in the text format of Prometheus,
+// through the HTTP handler or the textfile of the node exporter.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+// Instrument receives the events of the fetches and the orders. It is called
+// concurrently.
+type Instrument interface {
+	FetchStarted(alias, uri string)
+	FetchSucceeded(alias, uri string, bytes int, d time.Duration)
+	FetchFailed(alias, uri string, err error, d time.Duration)
+	OrderWritten(uri string, d time.Duration)
+	// OrderSkipped is called instead of the OrderWritten if the destination
+	// already has the contents.
+	OrderSkipped(uri string, d time.Duration)
+	OrderFailed(uri string, err error, d time.Duration)
+}
+
+// Catalog is the Catalog calling the Instrument around each fetch.
+type Catalog struct {
+	catalog orderapi.Catalog
+	alias   string
+	uri     string
+	inst    Instrument
+}
+
+func NewCatalog(catalog orderapi.Catalog, alias, uri string, inst Instrument) *Catalog {
+	return &Catalog{catalog: catalog, alias: alias, uri: uri, inst: inst}
+}
+
+func (c *Catalog) Fetch(ctx context.Context) ([]byte, error) {
+	c.inst.FetchStarted(c.alias, c.uri)
+
+	start := time.Now()
+	buf, err := c.catalog.Fetch(ctx)
+	if err != nil {
+		c.inst.FetchFailed(c.alias, c.uri, err, time.Since(start))
+		return nil, err
+	}
+
+	c.inst.FetchSucceeded(c.alias, c.uri, len(buf), time.Since(start))
+	return buf, nil
+}
+
+// Open calls the Instrument when the content is read to the end, or fails.
+func (c *Catalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	c.inst.FetchStarted(c.alias, c.uri)
+
+	start := time.Now()
+	r, err := orderapi.Open(ctx, c.catalog)
+	if err != nil {
+		c.inst.FetchFailed(c.alias, c.uri, err, time.Since(start))
+		return nil, err
+	}
+
+	return orderapi.OnReadEnd(r, func(n int, err error) error {
+		if err != nil {
+			c.inst.FetchFailed(c.alias, c.uri, err, time.Since(start))
+			return err
+		}
+
+		c.inst.FetchSucceeded(c.alias, c.uri, n, time.Since(start))
+		return nil
+	}), nil
+}
+
+func (c *Catalog) Streams() bool {
+	return orderapi.Streams(c.catalog)
+}
+
+// Order is the Order calling the Instrument after each order.
+type Order struct {
+	order orderapi.Order
+	uri   string
+	inst  Instrument
+}
+
+func NewOrder(order orderapi.Order, uri string, inst Instrument) *Order {
+	return &Order{order: order, uri: uri, inst: inst}
+}
+
+type skipKey struct{}
+
+// Order runs the order. The order skipping the write calls MarkSkipped with
+// the context.
+func (o *Order) Order(ctx context.Context) error {
+	skipped := new(int32)
+	ctx = context.WithValue(ctx, skipKey{}, skipped)
+
+	start := time.Now()
+	err := o.order.Order(ctx)
+	switch {
+	case err != nil:
+		o.inst.OrderFailed(o.uri, err, time.Since(start))
+	case atomic.LoadInt32(skipped) != 0:
+		o.inst.OrderSkipped(o.uri, time.Since(start))
+	default:
+		o.inst.OrderWritten(o.uri, time.Since(start))
+	}
+
+	return err
+}
+
+// MarkSkipped marks the order of the context as skipped, since the
+// destination already has the contents.
+func MarkSkipped(ctx context.Context) {
+	if skipped, ok := ctx.Value(skipKey{}).(*int32); ok {
+		atomic.StoreInt32(skipped, 1)
+	}
+}
+
+type fetchKey struct {
+	alias string
+	uri   string
+}
+
+type fetchStats struct {
+	inFlight    int
+	succeeded   int
+	failed      int
+	bytes       int64
+	seconds     float64
+	lastSuccess time.Time
+}
+
+type orderStats struct {
+	written     int
+	skipped     int
+	failed      int
+	seconds     float64
+	lastSuccess time.Time
+}
+
+// Registry is the Instrument keeping the metrics of the fetches by the alias
+// and the URI of the catalog, and the ones of the orders by the URI of the
+// destination.
+type Registry struct {
+	mu      sync.Mutex
+	fetches map[fetchKey]*fetchStats
+	orders  map[string]*orderStats
+	now     func() time.Time
+}
+
+func NewRegistry() *Registry {
+	registry := &Registry{
+		fetches: make(map[fetchKey]*fetchStats),
+		orders:  make(map[string]*orderStats),
+		now:     time.Now,
+	}
+
+	return registry
+}
+
+// fetch returns the stats of the fetch. The mu must be locked.
+func (r *Registry) fetch(alias, uri string) *fetchStats {
+	key := fetchKey{alias: alias, uri: uri}
+	stats, ok := r.fetches[key]
+	if !ok {
+		stats = &fetchStats{}
+		r.fetches[key] = stats
+	}
+
+	return stats
+}
+
+// order returns the stats of the order. The mu must be locked.
+func (r *Registry) order(uri string) *orderStats {
+	stats, ok := r.orders[uri]
+	if !ok {
+		stats = &orderStats{}
+		r.orders[uri] = stats
+	}
+
+	return stats
+}
+
+func (r *Registry) FetchStarted(alias, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fetch(alias, uri).inFlight++
+}
+
+func (r *Registry) FetchSucceeded(alias, uri string, bytes int, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.fetch(alias, uri)
+	stats.inFlight--
+	stats.succeeded++
+	stats.bytes += int64(bytes)
+	stats.seconds += d.Seconds()
+	stats.lastSuccess = r.now()
+}
+
+func (r *Registry) FetchFailed(alias, uri string, err error, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.fetch(alias, uri)
+	stats.inFlight--
+	stats.failed++
+	stats.seconds += d.Seconds()
+}
+
+func (r *Registry) OrderWritten(uri string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.order(uri)
+	stats.written++
+	stats.seconds += d.Seconds()
+	stats.lastSuccess = r.now()
+}
+
+func (r *Registry) OrderSkipped(uri string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.order(uri)
+	stats.skipped++
+	stats.seconds += d.Seconds()
+	stats.lastSuccess = r.now()
+}
+
+func (r *Registry) OrderFailed(uri string, err error, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.order(uri)
+	stats.failed++
+	stats.seconds += d.Seconds()
+}
+
+// metric is a metric family of the text format.
+type metric struct {
+	name    string
+	kind    string
+	help    string
+	samples []string
+}
+
+// WriteTo writes the metrics in the text format of Prometheus. The samples
+// are sorted by the labels, so the output is stable.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := r.families()
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	var n int64
+	for _, m := range families {
+		c, err := fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+
+		sort.Strings(m.samples)
+		for _, sample := range m.samples {
+			c, err := io.WriteString(bw, sample)
+			n += int64(c)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, bw.Flush()
+}
+
+// families returns the metric families of the stats. The mu must be locked.
+func (r *Registry) families() []*metric {
+	inFlight := &metric{name: "cannect_fetches_in_flight", kind: "gauge",
+		help: "Number of the fetches of the catalogs in progress."}
+	fetches := &metric{name: "cannect_fetches_total", kind: "counter",
+		help: "Number of the fetches of the catalogs by the result."}
+	fetchBytes := &metric{name: "cannect_fetch_bytes_total", kind: "counter",
+		help: "Size of the contents fetched from the catalogs."}
+	fetchDuration := &metric{name: "cannect_fetch_duration_seconds", kind: "summary",
+		help: "Duration of the fetches of the catalogs."}
+	fetchSuccess := &metric{name: "cannect_fetch_last_success_timestamp_seconds", kind: "gauge",
+		help: "Time of the last successful fetch of the catalog."}
+
+	for key, stats := range r.fetches {
+		labels := fmt.Sprintf(`alias="%s",uri="%s"`, escape(key.alias), escape(key.uri))
+		inFlight.add(labels, stats.inFlight)
+		fetches.add(labels+`,result="success"`, stats.succeeded)
+		fetches.add(labels+`,result="failure"`, stats.failed)
+		fetchBytes.add(labels, stats.bytes)
+		fetchDuration.addSummary(labels, stats.seconds, stats.succeeded+stats.failed)
+		if !stats.lastSuccess.IsZero() {
+			fetchSuccess.add(labels, stats.lastSuccess.Unix())
+		}
+	}
+
+	orders := &metric{name: "cannect_orders_total", kind: "counter",
+		help: "Number of the orders by the result."}
+	orderDuration := &metric{name: "cannect_order_duration_seconds", kind: "summary",
+		help: "Duration of the orders."}
+	orderSuccess := &metric{name: "cannect_order_last_success_timestamp_seconds", kind: "gauge",
+		help: "Time of the last order written or skipped for the unchanged destination."}
+
+	for uri, stats := range r.orders {
+		labels := fmt.Sprintf(`uri="%s"`, escape(uri))
+		orders.add(labels+`,result="written"`, stats.written)
+		orders.add(labels+`,result="skipped"`, stats.skipped)
+		orders.add(labels+`,result="failure"`, stats.failed)
+		orderDuration.addSummary(labels, stats.seconds, stats.written+stats.skipped+stats.failed)
+		if !stats.lastSuccess.IsZero() {
+			orderSuccess.add(labels, stats.lastSuccess.Unix())
+		}
+	}
+
+	return []*metric{inFlight, fetches, fetchBytes, fetchDuration, fetchSuccess, orders, orderDuration, orderSuccess}
+}
+
+func (m *metric) add(labels string, value interface{}) {
+	m.samples = append(m.samples, fmt.Sprintf("%s{%s} %v\n", m.name, labels, value))
+}
+
+func (m *metric) addSummary(labels string, sum float64, count int) {
+	m.samples = append(m.samples,
+		fmt.Sprintf("%s_sum{%s} %g\n", m.name, labels, sum),
+		fmt.Sprintf("%s_count{%s} %d\n", m.name, labels, count),
+	)
+}
+
+// escape escapes the value of the label.
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// ServeHTTP writes the metrics for the scrape of Prometheus.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+// WriteTextfile writes the metrics to the file for the textfile collector of
+// the node exporter. The file is replaced atomically, so the collector never
+// reads the partial metrics.
+func (r *Registry) WriteTextfile(name string) (err error) {
+	tmp, err := os.CreateTemp(path.Dir(name), "."+path.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = r.WriteTo(tmp)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Chmod(0o644)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
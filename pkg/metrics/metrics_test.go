@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+var errTest = errors.New("test failed")
+
+type testCatalog struct {
+	content string
+	err     error
+}
+
+func (t testCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	return []byte(t.content), t.err
+}
+
+type testOrder struct {
+	catalog orderapi.Catalog
+	skip    bool
+}
+
+func (t testOrder) Order(ctx context.Context) error {
+	if t.skip {
+		MarkSkipped(ctx)
+		return nil
+	}
+
+	_, err := t.catalog.Fetch(ctx)
+	return err
+}
+
+// testSamples returns the samples of the metrics, without the durations,
+// which differ in each run.
+func testSamples(t *testing.T, r *Registry) []string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var samples []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.HasPrefix(line, "#") || strings.Contains(line, "_duration_seconds_sum") {
+			continue
+		}
+		samples = append(samples, line)
+	}
+
+	return samples
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	root := NewCatalog(testCatalog{content: "root"}, "root", "file://root-ca.crt", r)
+	broken := NewCatalog(testCatalog{err: errTest}, "sub", `file://"sub".crt`, r)
+
+	orders := []orderapi.Order{
+		NewOrder(testOrder{catalog: root}, "file://ca-bundle.crt", r),
+		NewOrder(testOrder{skip: true}, "file://ca-bundle.crt", r),
+		NewOrder(testOrder{catalog: broken}, "s3://pki/ca-bundle.crt", r),
+	}
+	for _, o := range orders {
+		_ = o.Order(context.TODO())
+	}
+
+	want := []string{
+		`cannect_fetches_in_flight{alias="root",uri="file://root-ca.crt"} 0`,
+		`cannect_fetches_in_flight{alias="sub",uri="file://\"sub\".crt"} 0`,
+		`cannect_fetches_total{alias="root",uri="file://root-ca.crt",result="failure"} 0`,
+		`cannect_fetches_total{alias="root",uri="file://root-ca.crt",result="success"} 1`,
+		`cannect_fetches_total{alias="sub",uri="file://\"sub\".crt",result="failure"} 1`,
+		`cannect_fetches_total{alias="sub",uri="file://\"sub\".crt",result="success"} 0`,
+		`cannect_fetch_bytes_total{alias="root",uri="file://root-ca.crt"} 4`,
+		`cannect_fetch_bytes_total{alias="sub",uri="file://\"sub\".crt"} 0`,
+		`cannect_fetch_duration_seconds_count{alias="root",uri="file://root-ca.crt"} 1`,
+		`cannect_fetch_duration_seconds_count{alias="sub",uri="file://\"sub\".crt"} 1`,
+		`cannect_fetch_last_success_timestamp_seconds{alias="root",uri="file://root-ca.crt"} 1700000000`,
+		`cannect_orders_total{uri="file://ca-bundle.crt",result="failure"} 0`,
+		`cannect_orders_total{uri="file://ca-bundle.crt",result="skipped"} 1`,
+		`cannect_orders_total{uri="file://ca-bundle.crt",result="written"} 1`,
+		`cannect_orders_total{uri="s3://pki/ca-bundle.crt",result="failure"} 1`,
+		`cannect_orders_total{uri="s3://pki/ca-bundle.crt",result="skipped"} 0`,
+		`cannect_orders_total{uri="s3://pki/ca-bundle.crt",result="written"} 0`,
+		`cannect_order_duration_seconds_count{uri="file://ca-bundle.crt"} 2`,
+		`cannect_order_duration_seconds_count{uri="s3://pki/ca-bundle.crt"} 1`,
+		`cannect_order_last_success_timestamp_seconds{uri="file://ca-bundle.crt"} 1700000000`,
+	}
+	if diff := cmp.Diff(want, testSamples(t, r)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRegistry_Expose(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	_ = NewOrder(testOrder{skip: true}, "file://ca-bundle.crt", r).Order(context.TODO())
+
+	var want bytes.Buffer
+	_, err := r.WriteTo(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Expected the text format but got: %s", rec.Header().Get("Content-Type"))
+	}
+	if diff := cmp.Diff(want.String(), rec.Body.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	dir := path.Join("testdata", "TestRegistry_Expose")
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll("testdata") })
+
+	name := path.Join(dir, "cannect.prom")
+	err = r.WriteTextfile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want.String(), string(got)); diff != "" {
+		t.Error(diff)
+	}
+}
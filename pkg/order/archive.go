@@ -0,0 +1,147 @@
+package order
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// ArchiveModTime is the default modification time of the archive entries. It
+// is fixed, so the same contents always make the same archive. It is the
+// earliest time of the zip format.
+var ArchiveModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ArchiveOrder implements the Order interface. It is responsible for writing
+// the contents of each catalog as a separate entry inside a zip or tar
+// archive file, so that the whole set of CA assets can be distributed as one
+// artifact.
+type ArchiveOrder struct {
+	uri      uriapi.ArchiveURI
+	catalogs []Catalog
+	names    []string
+	modTime  time.Time
+	l        *slog.Logger
+}
+
+// NewArchiveOrder returns the ArchiveOrder. The names are the entry names of
+// the catalogs in the archive, in the same order as the catalogs.
+func NewArchiveOrder(uri uriapi.ArchiveURI, catalogs []Catalog, names []string) *ArchiveOrder {
+	order := &ArchiveOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		names:    names,
+		modTime:  ArchiveModTime,
+	}
+
+	return order
+}
+
+// Order fetches all catalogs before creating the archive file, so that no
+// incomplete archive is left when any fetch fails.
+func (a *ArchiveOrder) Order(ctx context.Context) error {
+	if a.l != nil {
+		a.l.InfoContext(ctx, "ordering", "scheme", a.uri.Scheme(), "uri", a.uri.Text())
+	}
+
+	contents, err := fetchEntries(ctx, a.catalogs, a.names)
+	if err != nil {
+		return fmt.Errorf("%s: %w", a.uri.Text(), err)
+	}
+
+	var archive bytes.Buffer
+
+	if a.uri.Scheme() == "zip" {
+		err = a.writeZip(&archive, contents)
+	} else {
+		err = a.writeTar(&archive, contents)
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(a.uri.Path(), archive.Bytes(), 0o600)
+}
+
+func (a *ArchiveOrder) writeZip(w io.Writer, contents [][]byte) error {
+	zw := zip.NewWriter(w)
+
+	for idx, content := range contents {
+		ew, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     a.names[idx],
+			Method:   zip.Deflate,
+			Modified: a.modTime,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = ew.Write(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (a *ArchiveOrder) writeTar(w io.Writer, contents [][]byte) error {
+	var gw *gzip.Writer
+
+	if strings.HasSuffix(a.uri.Path(), ".gz") || strings.HasSuffix(a.uri.Path(), ".tgz") {
+		gw = gzip.NewWriter(w)
+		w = gw
+	}
+
+	tw := tar.NewWriter(w)
+
+	for idx, content := range contents {
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     a.names[idx],
+			Mode:     0o600,
+			Size:     int64(len(content)),
+			ModTime:  a.modTime,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = tw.Write(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tw.Close()
+	if err != nil {
+		return err
+	}
+
+	if gw != nil {
+		return gw.Close()
+	}
+
+	return nil
+}
+
+func (a *ArchiveOrder) WithLogger(l *slog.Logger) *ArchiveOrder {
+	a.l = l
+	return a
+}
+
+// WithModTime sets the modification time of the entries, like the one of the
+// SOURCE_DATE_EPOCH.
+func (a *ArchiveOrder) WithModTime(t time.Time) *ArchiveOrder {
+	a.modTime = t.UTC().Truncate(time.Second)
+	return a
+}
@@ -0,0 +1,215 @@
+package order
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+var testArchiveNames = []string{"root-ca.crt", "sub-ca.crt", "server.crt"}
+
+func testReadWantEntries(t *testing.T) map[string]string {
+	t.Helper()
+
+	want := make(map[string]string, len(testArchiveNames))
+	for _, name := range testArchiveNames {
+		b, err := os.ReadFile(path.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[name] = string(b)
+	}
+
+	return want
+}
+
+func testOrderArchive(t *testing.T, uriText string) uriapi.ArchiveURI {
+	t.Helper()
+
+	uri, err := uriapi.NewArchiveURI(uriText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(uri.Path()) })
+
+	order := NewArchiveOrder(uri, testGenCatalogs(t), testArchiveNames)
+	err = order.Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return uri
+}
+
+func TestArchiveOrder_Order_Zip(t *testing.T) {
+	t.Parallel()
+
+	uri := testOrderArchive(t, "zip://testdata/TestArchiveOrder_Order_Zip.zip")
+
+	zr, err := zip.OpenReader(uri.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	result := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[f.Name] = string(b)
+	}
+
+	if diff := cmp.Diff(testReadWantEntries(t), result); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestArchiveOrder_Order_TarGz(t *testing.T) {
+	t.Parallel()
+
+	uri := testOrderArchive(t, "tar://testdata/TestArchiveOrder_Order_TarGz.tar.gz")
+
+	file, err := os.Open(uri.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(map[string]string)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[hdr.Name] = string(b)
+	}
+
+	if diff := cmp.Diff(testReadWantEntries(t), result); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestArchiveOrder_Order_Error(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testcase string
+		// input
+		names []string
+		// want
+		err error
+	}{
+		{"NG:names mismatch", []string{"root-ca.crt"}, ErrEntryNameMismatch},
+		{"NG:names duplicated", []string{"a.crt", "b.crt", "a.crt"}, ErrEntryNameDuplicated},
+	}
+
+	for idx, d := range data {
+		idx := idx
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := uriapi.NewArchiveURI(fmt.Sprintf("zip://testdata/TestArchiveOrder_Order_Error%d.zip", idx))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = NewArchiveOrder(uri, testGenCatalogs(t), d.names).Order(context.TODO())
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected error is %v but got: %v", d.err, err)
+			}
+
+			if _, err := os.Stat(uri.Path()); !os.IsNotExist(err) {
+				t.Errorf("Archive must not be created: %s", uri.Path())
+			}
+		})
+	}
+}
+
+func TestArchiveOrder_Order_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	for _, ext := range []string{"zip", "tar.gz"} {
+		scheme := "zip"
+		if ext != "zip" {
+			scheme = "tar"
+		}
+
+		var archives [][]byte
+		for idx := 0; idx < 2; idx++ {
+			uri := testOrderArchive(t, fmt.Sprintf("%s://testdata/TestArchiveOrder_Order_Deterministic_%d.%s", scheme, idx, ext))
+
+			b, err := os.ReadFile(uri.Path())
+			if err != nil {
+				t.Fatal(err)
+			}
+			archives = append(archives, b)
+		}
+
+		if !bytes.Equal(archives[0], archives[1]) {
+			t.Errorf("%s: Expected the same archives", ext)
+		}
+	}
+}
+
+func TestArchiveOrder_WithModTime(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewArchiveURI("zip://testdata/TestArchiveOrder_WithModTime.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(uri.Path()) })
+
+	modTime := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	err = NewArchiveOrder(uri, testGenCatalogs(t), testArchiveNames).WithModTime(modTime).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(uri.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if !f.Modified.Equal(modTime) {
+			t.Errorf("%s: Expected %v but got %v", f.Name, modTime, f.Modified)
+		}
+	}
+}
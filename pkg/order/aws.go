@@ -0,0 +1,63 @@
+package order
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/yuxki/cannect/internal/awsjson"
+)
+
+// signAWSRequest signs the request with the signature version 4 and the
+// default credentials of the AWS SDK, for the APIs using the REST protocols.
+func signAWSRequest(ctx context.Context, req *http.Request, body []byte, service, region string) error {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if cfg.Credentials == nil {
+		return awsjson.ErrNoCredentials
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	return v4.NewSigner().SignHTTP(
+		ctx, creds, req, hex.EncodeToString(sum[:]), service, region, time.Now(),
+	)
+}
+
+// awsGlobalEndpoint returns the endpoint of the global services of AWS, such
+// as CloudFront and Route53. The environment variable "AWS_ENDPOINT_URL"
+// overrides it.
+func awsGlobalEndpoint(endpoint string) string {
+	if ep := os.Getenv("AWS_ENDPOINT_URL"); ep != "" {
+		endpoint = ep
+	}
+
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+// newClientRequestToken returns a random UUID version 4, that is used as the
+// idempotency token of the AWS APIs.
+func newClientRequestToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
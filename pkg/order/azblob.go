@@ -0,0 +1,92 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const azureStorageVersion = "2021-08-06"
+
+// AzBlobOrder implements the Order interface. It is responsible for writing
+// the concatenated contents of the catalogs to a block blob in Azure Blob
+// Storage.
+type AzBlobOrder struct {
+	uri      uriapi.AzBlobURI
+	catalogs []Catalog
+	client   *http.Client
+	l        *slog.Logger
+}
+
+func NewAzBlobOrder(uri uriapi.AzBlobURI, catalogs []Catalog) *AzBlobOrder {
+	order := &AzBlobOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		client:   http.DefaultClient,
+	}
+
+	return order
+}
+
+// The Order function utilizes the Put Blob API in Azure Blob Storage. It
+// requires the usage of an environment variable "AZURE_STORAGE_SAS_TOKEN" to
+// authorize the request. The environment variable
+// "AZURE_STORAGE_BLOB_ENDPOINT" overrides the endpoint of the account, for
+// Azurite or sovereign clouds.
+func (a *AzBlobOrder) Order(ctx context.Context) error {
+	if a.l != nil {
+		a.l.InfoContext(ctx, "ordering", "scheme", a.uri.Scheme(), "uri", a.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, a.catalogs)
+	if err != nil {
+		return err
+	}
+
+	endpoint := os.Getenv("AZURE_STORAGE_BLOB_ENDPOINT")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", a.uri.Account())
+	}
+
+	segments := strings.Split(a.uri.Blob(), "/")
+	for idx := range segments {
+		segments[idx] = url.PathEscape(segments[idx])
+	}
+
+	target := fmt.Sprintf("%s/%s/%s",
+		strings.TrimSuffix(endpoint, "/"), a.uri.Container(), strings.Join(segments, "/"),
+	)
+	if sas := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"); sas != "" {
+		target = fmt.Sprintf("%s?%s", target, sas)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	req.Header.Set("X-Ms-Version", azureStorageVersion)
+
+	return send(a.client, req, a.uri.Text())
+}
+
+func (a *AzBlobOrder) WithLogger(l *slog.Logger) *AzBlobOrder {
+	a.l = l
+	return a
+}
+
+// WithHTTPClient makes the AzBlobOrder send the request with the client, like the
+// one with the proxy or the CA of the destination.
+func (a *AzBlobOrder) WithHTTPClient(c *http.Client) *AzBlobOrder {
+	a.client = c
+	return a
+}
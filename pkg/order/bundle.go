@@ -0,0 +1,146 @@
+package order
+
+import (
+	"bytes"
+	"context"
+
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+)
+
+// BundleChecker verifies the concatenated contents of the catalogs.
+type BundleChecker interface {
+	// Verify that the content in the bundle as expected.
+	CheckContent([]byte) error
+}
+
+// Transformer transforms the concatenated contents of the catalogs.
+type Transformer interface {
+	// Transform returns the transformed content.
+	Transform([]byte) ([]byte, error)
+}
+
+// FinalNewline is the handling of the newlines at the end of the concatenated
+// contents.
+type FinalNewline int
+
+const (
+	// KeepFinalNewline leaves the end of the contents as it is.
+	KeepFinalNewline FinalNewline = iota
+	// EnsureFinalNewline appends a newline if the contents do not end with it.
+	EnsureFinalNewline
+	// StripFinalNewline removes all of the newlines at the end of the contents.
+	StripFinalNewline
+)
+
+// Bundle implements the Catalog interface. It fetches the catalogs, and
+// returns the concatenated contents after transforming them with the
+// transformers and verifying them as a whole with the checkers. It is used in
+// the orders in place of the catalogs, so the contents are not written to the
+// destination when they are invalid.
+type Bundle struct {
+	catalogs     []Catalog
+	separator    []byte
+	assetNewline bool
+	finalNewline FinalNewline
+	transformers []Transformer
+	checkers     []BundleChecker
+}
+
+func NewBundle(catalogs []Catalog) *Bundle {
+	bundle := &Bundle{
+		catalogs: catalogs,
+	}
+
+	return bundle
+}
+
+func (b *Bundle) Fetch(ctx context.Context) ([]byte, error) {
+	buf, err := b.join(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range b.transformers {
+		buf, err = b.transformers[idx].Transform(buf)
+		if err != nil {
+			return nil, catalogapi.NewContentError("", "", err)
+		}
+	}
+
+	for idx := range b.checkers {
+		err := b.checkers[idx].CheckContent(buf)
+		if err != nil {
+			return nil, catalogapi.NewContentError("", "", err)
+		}
+	}
+
+	return buf, nil
+}
+
+// join fetches the catalogs, and concatenates the contents with the separator
+// and the newline handling.
+func (b *Bundle) join(ctx context.Context) ([]byte, error) {
+	contents, err := fetchContents(ctx, b.catalogs)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf []byte
+	for idx, content := range contents {
+		if idx > 0 {
+			buf = append(buf, b.separator...)
+		}
+
+		buf = append(buf, content...)
+		if b.assetNewline && len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+			buf = append(buf, '\n')
+		}
+	}
+
+	switch b.finalNewline {
+	case EnsureFinalNewline:
+		if len(buf) > 0 && !bytes.HasSuffix(buf, []byte("\n")) {
+			buf = append(buf, '\n')
+		}
+	case StripFinalNewline:
+		buf = bytes.TrimRight(buf, "\r\n")
+	}
+
+	return buf, nil
+}
+
+// WithSeparator makes the Bundle insert the separator between the contents of
+// the catalogs.
+func (b *Bundle) WithSeparator(sep string) *Bundle {
+	b.separator = []byte(sep)
+	return b
+}
+
+// WithAssetNewline makes the Bundle append a newline to the content of each
+// catalog that does not end with it, so the PEM blocks of the catalogs are not
+// glued like "-----END CERTIFICATE----------BEGIN CERTIFICATE-----".
+func (b *Bundle) WithAssetNewline() *Bundle {
+	b.assetNewline = true
+	return b
+}
+
+// WithFinalNewline sets the handling of the newlines at the end of the
+// concatenated contents.
+func (b *Bundle) WithFinalNewline(f FinalNewline) *Bundle {
+	b.finalNewline = f
+	return b
+}
+
+// WithChecker adds the checker that verifies the concatenated contents.
+func (b *Bundle) WithChecker(c BundleChecker) *Bundle {
+	b.checkers = append(b.checkers, c)
+	return b
+}
+
+// WithTransformer adds the transformer that transforms the concatenated
+// contents. The transformers are applied in the order they are added, before
+// the checkers.
+func (b *Bundle) WithTransformer(t Transformer) *Bundle {
+	b.transformers = append(b.transformers, t)
+	return b
+}
@@ -0,0 +1,79 @@
+package order
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	casAlgorithm = "sha256"
+	casLatest    = "latest"
+)
+
+// CASOrder implements the Order interface. It is responsible for writing the
+// concatenated contents of the catalogs under the path derived from their
+// digest, like "sha256/<hex>", in the content-addressable store, and pointing
+// the "latest" file to it. The written contents are never changed, so they can
+// be cached by downstream CDNs or proxies.
+type CASOrder struct {
+	uri      uriapi.CASURI
+	catalogs []Catalog
+	l        *slog.Logger
+}
+
+func NewCASOrder(uri uriapi.CASURI, catalogs []Catalog) *CASOrder {
+	order := &CASOrder{
+		uri:      uri,
+		catalogs: catalogs,
+	}
+
+	return order
+}
+
+// The Order function writes the "latest" file after the contents, and replaces
+// it by renaming, so readers never see the pointer to the missing contents.
+func (c *CASOrder) Order(ctx context.Context) error {
+	if c.l != nil {
+		c.l.InfoContext(ctx, "ordering", "scheme", c.uri.Scheme(), "uri", c.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, c.catalogs)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(buf)
+	rel := path.Join(casAlgorithm, hex.EncodeToString(digest[:]))
+	dst := path.Join(c.uri.Path(), rel)
+
+	err = os.MkdirAll(path.Dir(dst), 0o755)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(dst)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		err = writeFileAtomic(dst, buf, 0o644)
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	}
+
+	return writeFileAtomic(path.Join(c.uri.Path(), casLatest), []byte(rel+"\n"), 0o644)
+}
+
+func (c *CASOrder) WithLogger(l *slog.Logger) *CASOrder {
+	c.l = l
+	return c
+}
@@ -0,0 +1,63 @@
+package order
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestCASOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestCASOrder_Order")
+	uri, err := uriapi.NewCASURI("cas://" + dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(want)
+	wantRel := "sha256/" + hex.EncodeToString(digest[:])
+
+	// The second order must succeed with the existing contents.
+	for i := 0; i < 2; i++ {
+		err = NewCASOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	latest, err := os.ReadFile(path.Join(dir, "latest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(wantRel+"\n", string(latest)); diff != "" {
+		t.Error(diff)
+	}
+
+	result, err := os.ReadFile(path.Join(dir, wantRel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(result)); diff != "" {
+		t.Error(diff)
+	}
+
+	entries, err := os.ReadDir(path.Join(dir, "sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary file is left but got: %v", entries)
+	}
+}
@@ -0,0 +1,122 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+var ErrNoCommand = errors.New("command is not specified")
+
+// maxCommandStderr is the size of the end of the standard error kept for the
+// error of the command.
+const maxCommandStderr = 4096
+
+// CommandOrder implements the Order interface. It is responsible for piping
+// the concatenated contents of the catalogs to the standard input of the
+// command, like "vault kv put secret/ca value=-" or "kubectl apply -f -", so
+// that they are handed to the tools without the temporary files. The command
+// is run without the shell.
+type CommandOrder struct {
+	uri      uriapi.CommandURI
+	catalogs []Catalog
+	args     []string
+	timeout  time.Duration
+	out      io.Writer
+	l        *slog.Logger
+}
+
+func NewCommandOrder(uri uriapi.CommandURI, catalogs []Catalog, args []string) *CommandOrder {
+	order := &CommandOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		args:     args,
+		out:      io.Discard,
+	}
+
+	return order
+}
+
+// The Order function fetches all contents before starting the command, so the
+// command never reads the partial contents if any catalog fails. The error of
+// the command has the end of its standard error.
+func (c *CommandOrder) Order(ctx context.Context) error {
+	if c.l != nil {
+		c.l.InfoContext(ctx, "ordering", "scheme", c.uri.Scheme(), "uri", c.uri.Text())
+	}
+
+	if len(c.args) == 0 {
+		return fmt.Errorf("%s: %w", c.uri.Text(), ErrNoCommand)
+	}
+
+	buf, err := fetchAll(ctx, c.catalogs)
+	if err != nil {
+		return err
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// The standard output and error are copied concurrently.
+	out := &syncWriter{w: c.out}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdin = bytes.NewReader(buf)
+	cmd.Stdout = out
+	cmd.Stderr = io.MultiWriter(out, &stderr)
+
+	err = cmd.Run()
+	if err != nil {
+		msg := stderr.Bytes()
+		if len(msg) > maxCommandStderr {
+			msg = msg[len(msg)-maxCommandStderr:]
+		}
+
+		return fmt.Errorf("%s: %w: %s", strings.Join(c.args, " "), err, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
+// WithTimeout sets the timeout of the command. The command is killed when it
+// is exceeded.
+func (c *CommandOrder) WithTimeout(timeout time.Duration) *CommandOrder {
+	c.timeout = timeout
+	return c
+}
+
+// WithOutput sets the writer of the standard output and error of the command.
+// They are discarded by default.
+func (c *CommandOrder) WithOutput(w io.Writer) *CommandOrder {
+	c.out = w
+	return c
+}
+
+func (c *CommandOrder) WithLogger(l *slog.Logger) *CommandOrder {
+	c.l = l
+	return c
+}
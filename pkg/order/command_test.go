@@ -0,0 +1,106 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestCommandOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skip("sh is not available")
+	}
+
+	dir := path.Join("testdata", "TestCommandOrder_Order")
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uri, err := uriapi.NewCommandURI("cmd://tee")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := path.Join(dir, "chain.crt")
+	var buf bytes.Buffer
+	err = NewCommandOrder(uri, testGenCatalogs(t), []string{sh, "-c", "cat > " + out + " && echo done"}).
+		WithOutput(&buf).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(got), string(want)); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(buf.String(), "done\n"); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestCommandOrder_Order_Error(t *testing.T) {
+	t.Parallel()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skip("sh is not available")
+	}
+
+	uri, err := uriapi.NewCommandURI("cmd://fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase string
+		args     []string
+		timeout  time.Duration
+		err      error
+		stderr   string
+	}{
+		{"NG:exit", []string{sh, "-c", "echo permission denied >&2; exit 2"}, 0, nil, "permission denied"},
+		{"NG:timeout", []string{sh, "-c", "exec sleep 10"}, 100 * time.Millisecond, nil, ""},
+		{"NG:no command", nil, 0, ErrNoCommand, ""},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewCommandOrder(uri, testGenCatalogs(t), d.args).WithTimeout(d.timeout).Order(context.TODO())
+			if err == nil {
+				t.Fatal("Expected the error but got nil")
+			}
+			if d.err != nil && !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if !strings.Contains(err.Error(), d.stderr) {
+				t.Errorf("Expected the error with %q but got: %v", d.stderr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const defaultRoute53Endpoint = "https://route53.amazonaws.com"
+
+var (
+	ErrNoCertificate         = errors.New("certificate is not found")
+	ErrInvalidTLSAParameter  = errors.New("invalid TLSA parameter")
+	ErrUnsupportedRecordType = errors.New("record type is not supported by the provider")
+)
+
+const (
+	dnsTypeCERT uint16 = 37
+	dnsTypeTLSA uint16 = 52
+
+	// certTypePKIX is the X.509 certificate type of the CERT record.
+	certTypePKIX uint16 = 1
+)
+
+// dnsRecord is the record data in the wire format and the presentation format.
+type dnsRecord struct {
+	rdata []byte
+	value string
+}
+
+// DNSOrder implements the Order interface. It is responsible for publishing
+// the certificates in the concatenated contents of the catalogs as the TLSA or
+// CERT record set in DNS, so that DANE consumers stay in sync with the
+// distributed certificates. The record set is replaced with the records of the
+// certificates.
+type DNSOrder struct {
+	uri          uriapi.DNSURI
+	catalogs     []Catalog
+	usage        uint8
+	selector     uint8
+	matchingType uint8
+	ttl          uint32
+	endpoint     string
+	client       *http.Client
+	l            *slog.Logger
+}
+
+// NewDNSOrder returns the DNSOrder that publishes the TLSA records of "2 0 1"
+// (DANE-TA, the full certificate, SHA-256) with the TTL of 300 seconds.
+func NewDNSOrder(uri uriapi.DNSURI, catalogs []Catalog) *DNSOrder {
+	order := &DNSOrder{
+		uri:          uri,
+		catalogs:     catalogs,
+		usage:        2,
+		selector:     0,
+		matchingType: 1,
+		ttl:          300,
+		endpoint:     defaultRoute53Endpoint,
+		client:       http.DefaultClient,
+	}
+
+	return order
+}
+
+// The Order function publishes the records with the ChangeResourceRecordSets
+// API of AWS Route53 that requires the same credentials as the S3Order, or the
+// dynamic update of RFC2136. The update is sent to the server of the
+// environment variable "DNS_UPDATE_SERVER" ("host" or "host:port"), and signed
+// with TSIG of HMAC-SHA256 if "DNS_TSIG_KEY_NAME" and "DNS_TSIG_SECRET"
+// (base64) are set.
+func (d *DNSOrder) Order(ctx context.Context) error {
+	if d.l != nil {
+		d.l.InfoContext(ctx, "ordering", "scheme", d.uri.Scheme(), "uri", d.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, d.catalogs)
+	if err != nil {
+		return err
+	}
+
+	records, err := d.records(buf)
+	if err != nil {
+		return err
+	}
+
+	switch d.uri.Provider() {
+	case "route53":
+		return d.changeRoute53(ctx, records)
+	default:
+		return d.updateRFC2136(ctx, records)
+	}
+}
+
+func (d *DNSOrder) WithLogger(l *slog.Logger) *DNSOrder {
+	d.l = l
+	return d
+}
+
+// WithHTTPClient makes the DNSOrder send the request with the client, like the
+// one with the proxy or the CA of the destination.
+func (d *DNSOrder) WithHTTPClient(c *http.Client) *DNSOrder {
+	d.client = c
+	return d
+}
+
+// WithTLSA sets the certificate usage, the selector and the matching type of
+// the TLSA records.
+func (d *DNSOrder) WithTLSA(usage, selector, matchingType uint8) *DNSOrder {
+	d.usage = usage
+	d.selector = selector
+	d.matchingType = matchingType
+	return d
+}
+
+func (d *DNSOrder) WithTTL(ttl uint32) *DNSOrder {
+	d.ttl = ttl
+	return d
+}
+
+func (d *DNSOrder) rrtype() uint16 {
+	if d.uri.RecordType() == "CERT" {
+		return dnsTypeCERT
+	}
+
+	return dnsTypeTLSA
+}
+
+// records returns the records of the certificates in the content. The same
+// records are published once.
+func (d *DNSOrder) records(content []byte) ([]dnsRecord, error) {
+	var records []dnsRecord
+	seen := make(map[string]struct{})
+
+	rest := content
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		var record dnsRecord
+		var err error
+		if d.rrtype() == dnsTypeCERT {
+			record = certRecord(block.Bytes)
+		} else {
+			record, err = d.tlsaRecord(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if _, ok := seen[record.value]; ok {
+			continue
+		}
+		seen[record.value] = struct{}{}
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s: %w", d.uri.Text(), ErrNoCertificate)
+	}
+
+	return records, nil
+}
+
+func (d *DNSOrder) tlsaRecord(der []byte) (dnsRecord, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return dnsRecord{}, err
+	}
+
+	var selected []byte
+	switch d.selector {
+	case 0:
+		selected = cert.Raw
+	case 1:
+		selected = cert.RawSubjectPublicKeyInfo
+	default:
+		return dnsRecord{}, fmt.Errorf("selector %d: %w", d.selector, ErrInvalidTLSAParameter)
+	}
+
+	var data []byte
+	switch d.matchingType {
+	case 0:
+		data = selected
+	case 1:
+		sum := sha256.Sum256(selected)
+		data = sum[:]
+	case 2:
+		sum := sha512.Sum512(selected)
+		data = sum[:]
+	default:
+		return dnsRecord{}, fmt.Errorf("matching type %d: %w", d.matchingType, ErrInvalidTLSAParameter)
+	}
+
+	if d.usage > 3 {
+		return dnsRecord{}, fmt.Errorf("usage %d: %w", d.usage, ErrInvalidTLSAParameter)
+	}
+
+	return dnsRecord{
+		rdata: append([]byte{d.usage, d.selector, d.matchingType}, data...),
+		value: fmt.Sprintf("%d %d %d %x", d.usage, d.selector, d.matchingType, data),
+	}, nil
+}
+
+func certRecord(der []byte) dnsRecord {
+	rdata := appendUint16(nil, certTypePKIX)
+	// The key tag and the algorithm are zero for the X.509 certificates.
+	rdata = append(rdata, 0, 0, 0)
+	rdata = append(rdata, der...)
+
+	return dnsRecord{
+		rdata: rdata,
+		value: "PKIX 0 0 " + base64.StdEncoding.EncodeToString(der),
+	}
+}
+
+type route53ResourceRecord struct {
+	Value string `xml:"Value"`
+}
+
+type route53ChangeRequest struct {
+	XMLName xml.Name                `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	Comment string                  `xml:"ChangeBatch>Comment"`
+	Action  string                  `xml:"ChangeBatch>Changes>Change>Action"`
+	Name    string                  `xml:"ChangeBatch>Changes>Change>ResourceRecordSet>Name"`
+	Type    string                  `xml:"ChangeBatch>Changes>Change>ResourceRecordSet>Type"`
+	TTL     uint32                  `xml:"ChangeBatch>Changes>Change>ResourceRecordSet>TTL"`
+	Records []route53ResourceRecord `xml:"ChangeBatch>Changes>Change>ResourceRecordSet>ResourceRecords>ResourceRecord"`
+}
+
+func (d *DNSOrder) changeRoute53(ctx context.Context, records []dnsRecord) error {
+	// Route53 does not support the CERT record.
+	if d.rrtype() == dnsTypeCERT {
+		return fmt.Errorf("%s: %w", d.uri.Text(), ErrUnsupportedRecordType)
+	}
+
+	change := route53ChangeRequest{
+		Comment: "cannect",
+		Action:  "UPSERT",
+		Name:    d.uri.Name(),
+		Type:    d.uri.RecordType(),
+		TTL:     d.ttl,
+	}
+	for _, r := range records {
+		change.Records = append(change.Records, route53ResourceRecord{Value: r.value})
+	}
+
+	body, err := xml.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("%s/2013-04-01/hostedzone/%s/rrset/",
+			awsGlobalEndpoint(d.endpoint), url.PathEscape(d.uri.Zone()),
+		),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+
+	// Route53 is a global service signed in us-east-1.
+	err = signAWSRequest(ctx, req, body, "route53", "us-east-1")
+	if err != nil {
+		return err
+	}
+
+	return send(d.client, req, d.uri.Text())
+}
@@ -0,0 +1,219 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+// testSPKIRecords returns the TLSA records of "3 1 1" of the certificates in
+// the testdata/chain.crt.
+func testSPKIRecords(t *testing.T) ([]string, [][]byte) {
+	t.Helper()
+
+	chain, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values []string
+	var rdatas [][]byte
+	for {
+		var block *pem.Block
+		block, chain = pem.Decode(chain)
+		if block == nil {
+			break
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		values = append(values, fmt.Sprintf("3 1 1 %x", sum))
+		rdatas = append(rdatas, append([]byte{3, 1, 1}, sum[:]...))
+	}
+
+	return values, rdatas
+}
+
+func TestDNSOrder_Order_Route53(t *testing.T) {
+	var got testRequest
+	srv := testRecordServer(t, &got)
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_DEFAULT_REGION", "ap-northeast-1")
+
+	uri, err := uriapi.NewDNSURI("dns://route53/Z0123456789ABC/_443._tcp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewDNSOrder(uri, testGenCatalogs(t)).WithTLSA(3, 1, 1).WithTTL(60).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.uri != "/2013-04-01/hostedzone/Z0123456789ABC/rrset/" {
+		t.Errorf("Unexpected request URI: %s", got.uri)
+	}
+
+	var change route53ChangeRequest
+	err = xml.Unmarshal(got.body, &change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := testSPKIRecords(t)
+	var values []string
+	for _, r := range change.Records {
+		values = append(values, r.Value)
+	}
+
+	if change.Action != "UPSERT" || change.Name != "_443._tcp.example.com" || change.Type != "TLSA" || change.TTL != 60 {
+		t.Errorf("Unexpected change: %#v", change)
+	}
+	if diff := cmp.Diff(values, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDNSOrder_Order_Route53CERT(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewDNSURI("dns://route53/Z0123456789ABC/ca.example.com?type=CERT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewDNSOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+	if !errors.Is(err, ErrUnsupportedRecordType) {
+		t.Fatalf("Expected %#v error but got: %#v", ErrUnsupportedRecordType, err)
+	}
+}
+
+func TestDNSOrder_Order_NoCertificate(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewDNSURI("dns://route53/Z0123456789ABC/_443._tcp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalogs := []Catalog{testBytesCatalog("-----BEGIN X509 CRL-----\nMIIB\n-----END X509 CRL-----\n")}
+
+	err = NewDNSOrder(uri, catalogs).Order(context.TODO())
+	if !errors.Is(err, ErrNoCertificate) {
+		t.Fatalf("Expected %#v error but got: %#v", ErrNoCertificate, err)
+	}
+}
+
+// testDNSServer accepts a DNS message over TCP, and replies with the rcode.
+func testDNSServer(t *testing.T, rcode uint16, got chan<- []byte) net.Listener {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var msgLen [2]byte
+		if _, err := io.ReadFull(conn, msgLen[:]); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint16(msgLen[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		got <- msg
+
+		resp := append([]byte{}, msg[:12]...)
+		binary.BigEndian.PutUint16(resp[2:4], 0x8000|dnsOpcodeUpdate<<11|rcode)
+		_, _ = conn.Write(append(appendUint16(nil, uint16(len(resp))), resp...))
+	}()
+
+	return ln
+}
+
+func TestDNSOrder_Order_RFC2136(t *testing.T) {
+	msgs := make(chan []byte, 1)
+	ln := testDNSServer(t, 0, msgs)
+	defer ln.Close()
+
+	t.Setenv("DNS_UPDATE_SERVER", ln.Addr().String())
+	t.Setenv("DNS_TSIG_KEY_NAME", "cannect-key")
+	t.Setenv("DNS_TSIG_SECRET", "c2VjcmV0")
+
+	uri, err := uriapi.NewDNSURI("dns://rfc2136/example.com/_443._tcp.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewDNSOrder(uri, testGenCatalogs(t)).WithTLSA(3, 1, 1).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-msgs
+	if binary.BigEndian.Uint16(got[2:4])>>11 != dnsOpcodeUpdate {
+		t.Errorf("Expected opcode is UPDATE but got: %x", got[2:4])
+	}
+
+	// ZOCOUNT, PRCOUNT, UPCOUNT (deletion and 3 records), ADCOUNT (TSIG)
+	if diff := cmp.Diff(got[4:12], []byte{0, 1, 0, 0, 0, 4, 0, 1}); diff != "" {
+		t.Error(diff)
+	}
+
+	_, rdatas := testSPKIRecords(t)
+	for _, rdata := range rdatas {
+		if !bytes.Contains(got, rdata) {
+			t.Errorf("Expected record is not found: %x", rdata)
+		}
+	}
+	if !bytes.Contains(got, []byte("\x0bcannect-key\x00")) {
+		t.Error("Expected TSIG key name is not found")
+	}
+}
+
+func TestDNSOrder_Order_RFC2136Refused(t *testing.T) {
+	ln := testDNSServer(t, 5, make(chan []byte, 1))
+	defer ln.Close()
+
+	t.Setenv("DNS_UPDATE_SERVER", ln.Addr().String())
+
+	uri, err := uriapi.NewDNSURI("dns://rfc2136/example.com/ca.example.com?type=CERT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewDNSOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+
+	var wErr WriteError
+	if !errors.As(err, &wErr) {
+		t.Fatalf("Expected WriteError but got: %#v", err)
+	}
+}
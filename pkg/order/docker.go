@@ -0,0 +1,259 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	defaultDockerHost  = "unix:///var/run/docker.sock"
+	dockerAPIVersion   = "v1.41"
+	dockerSecretLabel  = "io.cannect.secret"
+	dockerDigestLength = 12
+)
+
+type dockerSecret struct {
+	ID   string `json:"ID"`
+	Spec struct {
+		Name string `json:"Name"`
+	} `json:"Spec"`
+}
+
+type dockerService struct {
+	ID      string `json:"ID"`
+	Version struct {
+		Index uint64 `json:"Index"`
+	} `json:"Version"`
+	Spec map[string]interface{} `json:"Spec"`
+}
+
+// dockerClient calls the Docker Engine API at the host of "DOCKER_HOST".
+type dockerClient struct {
+	base   string
+	client *http.Client
+}
+
+func newDockerClient(host string) (dockerClient, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return dockerClient{}, err
+	}
+
+	switch u.Scheme {
+	case "unix":
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", u.Path)
+			},
+		}
+
+		return dockerClient{base: "http://docker", client: &http.Client{Transport: transport}}, nil
+	case "tcp", "http":
+		return dockerClient{base: "http://" + u.Host, client: http.DefaultClient}, nil
+	default:
+		return dockerClient{}, fmt.Errorf("%s: unsupported DOCKER_HOST", host)
+	}
+}
+
+func (d dockerClient) call(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/%s%s", d.base, dockerAPIVersion, path), body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(b, out)
+}
+
+// DockerOrder implements the Order interface. It is responsible for creating
+// or rotating a Docker secret in a swarm with the concatenated contents of the
+// catalogs.
+type DockerOrder struct {
+	uri      uriapi.DockerURI
+	catalogs []Catalog
+	l        *slog.Logger
+}
+
+func NewDockerOrder(uri uriapi.DockerURI, catalogs []Catalog) *DockerOrder {
+	order := &DockerOrder{
+		uri:      uri,
+		catalogs: catalogs,
+	}
+
+	return order
+}
+
+// The Order function utilizes the Docker Engine API at "DOCKER_HOST", or the
+// local socket if it is not set. Since Docker secrets are immutable, the
+// secret is created with the name suffixed by the digest of the contents, and
+// the services using the former secrets of the same name are updated to use
+// the new one. The former secrets are kept for the running tasks.
+func (d *DockerOrder) Order(ctx context.Context) error {
+	if d.l != nil {
+		d.l.InfoContext(ctx, "ordering", "scheme", d.uri.Scheme(), "uri", d.uri.Text())
+	}
+
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = defaultDockerHost
+	}
+
+	client, err := newDockerClient(host)
+	if err != nil {
+		return err
+	}
+
+	buf, err := fetchAll(ctx, d.catalogs)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(buf)
+	name := fmt.Sprintf("%s-%s", d.uri.Path(), hex.EncodeToString(digest[:])[:dockerDigestLength])
+
+	filters, err := json.Marshal(map[string][]string{
+		"label": {fmt.Sprintf("%s=%s", dockerSecretLabel, d.uri.Path())},
+	})
+	if err != nil {
+		return err
+	}
+
+	var secrets []dockerSecret
+	err = client.call(ctx, http.MethodGet, "/secrets?filters="+url.QueryEscape(string(filters)), nil, &secrets)
+	if err != nil {
+		return WriteError{uri: d.uri.Text(), reason: err.Error()}
+	}
+
+	var id string
+	formers := make(map[string]struct{})
+	for _, secret := range secrets {
+		if secret.Spec.Name == name {
+			id = secret.ID
+			continue
+		}
+		formers[secret.ID] = struct{}{}
+	}
+
+	if id == "" {
+		var created struct {
+			ID string `json:"ID"`
+		}
+
+		err = client.call(ctx, http.MethodPost, "/secrets/create", map[string]interface{}{
+			"Name":   name,
+			"Labels": map[string]string{dockerSecretLabel: d.uri.Path()},
+			// The []byte is encoded in base64 as the API requires.
+			"Data": buf,
+		}, &created)
+		if err != nil {
+			return WriteError{uri: d.uri.Text(), reason: err.Error()}
+		}
+
+		id = created.ID
+	}
+
+	if len(formers) == 0 {
+		return nil
+	}
+
+	return d.rotate(ctx, client, formers, id, name)
+}
+
+// rotate updates the services using the former secrets to use the secret.
+func (d *DockerOrder) rotate(ctx context.Context, client dockerClient, formers map[string]struct{}, id, name string) error {
+	var services []dockerService
+	err := client.call(ctx, http.MethodGet, "/services", nil, &services)
+	if err != nil {
+		return WriteError{uri: d.uri.Text(), reason: err.Error()}
+	}
+
+	for _, service := range services {
+		if !replaceDockerSecret(service.Spec, formers, id, name) {
+			continue
+		}
+
+		path := fmt.Sprintf("/services/%s/update?version=%d", service.ID, service.Version.Index)
+		err := client.call(ctx, http.MethodPost, path, service.Spec, nil)
+		if err != nil {
+			return WriteError{uri: d.uri.Text(), reason: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// replaceDockerSecret replaces the references to the former secrets in the
+// service spec, and reports whether any reference is replaced.
+func replaceDockerSecret(spec map[string]interface{}, formers map[string]struct{}, id, name string) bool {
+	template, _ := spec["TaskTemplate"].(map[string]interface{})
+	container, _ := template["ContainerSpec"].(map[string]interface{})
+	refs, _ := container["Secrets"].([]interface{})
+
+	replaced := false
+	for _, r := range refs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		secretID, _ := ref["SecretID"].(string)
+		if _, ok := formers[secretID]; !ok {
+			continue
+		}
+
+		ref["SecretID"] = id
+		ref["SecretName"] = name
+		replaced = true
+	}
+
+	return replaced
+}
+
+func (d *DockerOrder) WithLogger(l *slog.Logger) *DockerOrder {
+	d.l = l
+	return d
+}
@@ -0,0 +1,104 @@
+package order
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestDockerOrder_Order(t *testing.T) {
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256(want)
+	wantName := "ca-bundle-" + hex.EncodeToString(digest[:])[:12]
+
+	var gotCreate struct {
+		Name   string            `json:"Name"`
+		Labels map[string]string `json:"Labels"`
+		Data   string            `json:"Data"`
+	}
+	var gotUpdatePath string
+	var gotUpdate map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.41/secrets":
+			w.Write([]byte(`[{"ID":"old-id","Spec":{"Name":"ca-bundle-000000000000"}}]`))
+		case "/v1.41/secrets/create":
+			if err := json.NewDecoder(r.Body).Decode(&gotCreate); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"ID":"new-id"}`))
+		case "/v1.41/services":
+			w.Write([]byte(`[
+				{"ID":"web","Version":{"Index":7},"Spec":{"Name":"web","TaskTemplate":{"ContainerSpec":{
+					"Image":"nginx","Secrets":[{"File":{"Name":"ca.crt"},"SecretID":"old-id","SecretName":"ca-bundle-000000000000"}]}}}},
+				{"ID":"db","Version":{"Index":3},"Spec":{"Name":"db","TaskTemplate":{"ContainerSpec":{"Image":"postgres"}}}}
+			]`))
+		default:
+			if strings.HasPrefix(r.URL.Path, "/v1.41/services/") {
+				gotUpdatePath = r.URL.Path + "?" + r.URL.RawQuery
+				if err := json.NewDecoder(r.Body).Decode(&gotUpdate); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(srv.URL, "http://"))
+
+	uri, err := uriapi.NewDockerURI("docker://ca-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewDockerOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotCreate.Name != wantName {
+		t.Errorf("Expected secret name is %s but got: %s", wantName, gotCreate.Name)
+	}
+	if gotCreate.Labels["io.cannect.secret"] != "ca-bundle" {
+		t.Errorf("Expected label is ca-bundle but got: %s", gotCreate.Labels["io.cannect.secret"])
+	}
+	if diff := cmp.Diff(base64.StdEncoding.EncodeToString(want), gotCreate.Data); diff != "" {
+		t.Error(diff)
+	}
+
+	if gotUpdatePath != "/v1.41/services/web/update?version=7" {
+		t.Errorf("Expected update path is /v1.41/services/web/update?version=7 but got: %s", gotUpdatePath)
+	}
+
+	wantRef := map[string]interface{}{
+		"File":       map[string]interface{}{"Name": "ca.crt"},
+		"SecretID":   "new-id",
+		"SecretName": wantName,
+	}
+	container := gotUpdate["TaskTemplate"].(map[string]interface{})["ContainerSpec"].(map[string]interface{})
+	if diff := cmp.Diff(wantRef, container["Secrets"].([]interface{})[0]); diff != "" {
+		t.Error(diff)
+	}
+	if container["Image"] != "nginx" {
+		t.Errorf("Expected the other fields are kept but got: %v", container)
+	}
+}
@@ -0,0 +1,156 @@
+package order
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvFormat is the format of the file written by the EnvOrders.
+type EnvFormat string
+
+const (
+	// ExportEnvFormat writes "export 'key'='value'" lines to be executed by the
+	// shell. The single quotes in the value are escaped, so the file is always
+	// safe to source.
+	ExportEnvFormat EnvFormat = "export"
+	// DotenvEnvFormat writes "key=value" lines. The value is double quoted with
+	// the escaped newlines if it is not a plain word.
+	DotenvEnvFormat EnvFormat = "dotenv"
+	// JSONEnvFormat writes a JSON object of the keys and values.
+	JSONEnvFormat EnvFormat = "json"
+	// YAMLEnvFormat writes a YAML mapping of the keys and double quoted values.
+	YAMLEnvFormat EnvFormat = "yaml"
+	// PowerShellEnvFormat writes "$env:key = 'value'" lines to be dot sourced
+	// by PowerShell.
+	PowerShellEnvFormat EnvFormat = "powershell"
+)
+
+// EnvWriter writes the variables of the EnvOrders to the writer in the format.
+// It is safe to share the EnvWriter among the orders writing to the same file.
+// The variables in the JSON format, and in all formats if it is sorted, are
+// written at Flush, and in the other formats as they are set.
+type EnvWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format EnvFormat
+	vars   map[string]string
+	sorted bool
+	lines  map[string]string
+}
+
+func NewEnvWriter(w io.Writer, format EnvFormat) *EnvWriter {
+	writer := &EnvWriter{
+		w:      w,
+		format: format,
+		vars:   make(map[string]string),
+		lines:  make(map[string]string),
+	}
+
+	return writer
+}
+
+// Set writes the variable of the key and the value.
+func (e *EnvWriter) Set(key, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	nl := "\n"
+	if runtime.GOOS == "windows" {
+		nl = "\r\n"
+	}
+
+	var line string
+	switch e.format {
+	case JSONEnvFormat:
+		e.vars[key] = value
+		return nil
+	case DotenvEnvFormat:
+		line = fmt.Sprintf("%s=%s%s", key, dotenvValue(value), nl)
+	case YAMLEnvFormat:
+		// JSON strings are also YAML double quoted scalars.
+		k, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		line = fmt.Sprintf("%s: %s%s", k, v, nl)
+	case PowerShellEnvFormat:
+		// Nothing is expanded in the single quotes, and a single quote is
+		// escaped by doubling it.
+		line = fmt.Sprintf("$env:%s = '%s'%s", key, strings.ReplaceAll(value, "'", "''"), nl)
+	default:
+		line = fmt.Sprintf("export %s=%s%s", shellQuote(key), shellQuote(value), nl)
+	}
+
+	if e.sorted {
+		e.lines[key] = line
+		return nil
+	}
+
+	_, err := io.WriteString(e.w, line)
+	return err
+}
+
+// WithSorted makes the EnvWriter write the variables sorted by the keys at
+// Flush, instead of in the order they are set. The orders sharing the
+// EnvWriter run concurrently, so the file is the same in every run only if it
+// is sorted.
+func (e *EnvWriter) WithSorted() *EnvWriter {
+	e.sorted = true
+	return e
+}
+
+// Flush writes the variables kept in the JSON format, or in all formats if it
+// is sorted. It does nothing in the other formats.
+func (e *EnvWriter) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.format != JSONEnvFormat {
+		keys := make([]string, 0, len(e.lines))
+		for key := range e.lines {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			_, err := io.WriteString(e.w, e.lines[key])
+			if err != nil {
+				return err
+			}
+		}
+		e.lines = make(map[string]string)
+
+		return nil
+	}
+
+	enc := json.NewEncoder(e.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e.vars)
+}
+
+// shellQuote returns the value single quoted for the POSIX shell. Nothing is
+// expanded in the single quotes, and each single quote in the value closes
+// the quotes, is escaped with a backslash and reopens them.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// dotenvValue returns the value as it is if it is a plain word, or double
+// quoted with the escaped backslashes, quotes and newlines.
+func dotenvValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n\"'`\\$#") {
+		return value
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\r", `\r`, "\n", `\n`)
+	return `"` + r.Replace(value) + `"`
+}
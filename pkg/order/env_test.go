@@ -0,0 +1,192 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestEnvWriter(t *testing.T) {
+	t.Parallel()
+
+	pem := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+	data := []struct {
+		testCase string
+		format   EnvFormat
+		want     string
+	}{
+		{
+			"Export",
+			ExportEnvFormat,
+			"export 'ROOT_CA'='" + pem + "'\nexport 'NAME'='root ca'\n",
+		},
+		{
+			"Dotenv",
+			DotenvEnvFormat,
+			`ROOT_CA="-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"` + "\nNAME=\"root ca\"\n",
+		},
+		{
+			"YAML",
+			YAMLEnvFormat,
+			`"ROOT_CA": "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"` + "\n\"NAME\": \"root ca\"\n",
+		},
+		{
+			"PowerShell",
+			PowerShellEnvFormat,
+			"$env:ROOT_CA = '" + pem + "'\n$env:NAME = 'root ca'\n",
+		},
+		{
+			"JSON",
+			JSONEnvFormat,
+			"{\n  \"NAME\": \"root ca\",\n" +
+				`  "ROOT_CA": "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"` + "\n}\n",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			w := NewEnvWriter(&buf, d.format)
+
+			err := w.Set("ROOT_CA", pem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = w.Set("NAME", "root ca")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = w.Flush()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := buf.String()
+			if runtime.GOOS == "windows" {
+				got = strings.ReplaceAll(got, "\r\n", "\n")
+			}
+
+			if diff := cmp.Diff(got, d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestEnvWriter_WithSorted(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []EnvFormat{ExportEnvFormat, DotenvEnvFormat, YAMLEnvFormat, PowerShellEnvFormat} {
+		var sorted bytes.Buffer
+		w := NewEnvWriter(&sorted, format).WithSorted()
+		for _, key := range []string{"SUB_CA", "ROOT_CA", "SERVER"} {
+			err := w.Set(key, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if sorted.Len() != 0 {
+			t.Fatalf("%s: Expected nothing written before Flush but got: %q", format, sorted.String())
+		}
+
+		err := w.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var want bytes.Buffer
+		w = NewEnvWriter(&want, format)
+		for _, key := range []string{"ROOT_CA", "SERVER", "SUB_CA"} {
+			err := w.Set(key, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if diff := cmp.Diff(sorted.String(), want.String()); diff != "" {
+			t.Errorf("%s: %s", format, diff)
+		}
+	}
+}
+
+func TestEnvWriter_Export_Source(t *testing.T) {
+	t.Parallel()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skip("sh is not available")
+	}
+
+	values := map[string]string{
+		"SINGLE_QUOTE": "-----BEGIN CERTIFICATE-----\nit's '$HOME'\n-----END CERTIFICATE-----\n",
+		"EXPANSION":    "$(echo injected) `echo injected` ${HOME} \\ \"\n",
+	}
+
+	dir := "testdata/TestEnvWriter_Export_Source"
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for key, value := range values {
+		var buf bytes.Buffer
+		err := NewEnvWriter(&buf, ExportEnvFormat).Set(key, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := path.Join(dir, key+".env")
+		err = os.WriteFile(p, buf.Bytes(), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Print the value with a trailing character, since the command
+		// substitution strips the trailing newlines.
+		out, err := exec.Command(sh, "-c", fmt.Sprintf(`. ./%s && printf '%%s.' "$%s"`, p, key)).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(string(out), value+"."); diff != "" {
+			t.Errorf("%s: %s", key, diff)
+		}
+	}
+}
+
+func TestEnvOrder_Order_Base64(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewEnvURI("env://ROOT_CA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := NewEnvWriter(&buf, DotenvEnvFormat)
+
+	catalogs := []Catalog{testBytesCatalog("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")}
+	err = NewEnvOrder(uri, catalogs, nil).WithEnvWriter(w).WithBase64().Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "ROOT_CA=LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUIKLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLQo="
+	if got := strings.TrimRight(buf.String(), "\r\n"); got != want {
+		t.Errorf("Expected %s but got: %s", want, got)
+	}
+}
@@ -0,0 +1,110 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const defaultGCSEndpoint = "https://storage.googleapis.com"
+
+// GCSOrder implements the Order interface. It is responsible for writing the
+// concatenated contents of the catalogs to an object in Google Cloud Storage.
+type GCSOrder struct {
+	uri      uriapi.GCSURI
+	catalogs []Catalog
+	inv      Invalidator
+	paths    []string
+	client   *http.Client
+	l        *slog.Logger
+}
+
+func NewGCSOrder(uri uriapi.GCSURI, catalogs []Catalog) *GCSOrder {
+	order := &GCSOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		client:   http.DefaultClient,
+	}
+
+	return order
+}
+
+// The Order function utilizes the simple upload of the Cloud Storage JSON
+// API. It requires the usage of an environment variable
+// "GOOGLE_OAUTH_ACCESS_TOKEN" to authorize the request, that can be issued by
+// "gcloud auth print-access-token". The environment variable
+// "STORAGE_EMULATOR_HOST" overrides the endpoint of the API.
+func (g *GCSOrder) Order(ctx context.Context) error {
+	if g.l != nil {
+		g.l.InfoContext(ctx, "ordering", "scheme", g.uri.Scheme(), "uri", g.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, g.catalogs)
+	if err != nil {
+		return err
+	}
+
+	endpoint := defaultGCSEndpoint
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		endpoint = host
+		if !strings.Contains(host, "://") {
+			endpoint = "http://" + host
+		}
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+			strings.TrimSuffix(endpoint, "/"), url.PathEscape(g.uri.Bucket()), url.QueryEscape(g.uri.Object()),
+		),
+		bytes.NewReader(buf),
+	)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	err = send(g.client, req, g.uri.Text())
+	if err != nil {
+		return err
+	}
+
+	if g.inv != nil {
+		return invalidate(ctx, g.inv, g.paths, "/"+g.uri.Object(), g.uri.Text())
+	}
+
+	return nil
+}
+
+func (g *GCSOrder) WithLogger(l *slog.Logger) *GCSOrder {
+	g.l = l
+	return g
+}
+
+// WithHTTPClient makes the GCSOrder send the request with the client, like the
+// one with the proxy or the CA of the destination.
+func (g *GCSOrder) WithHTTPClient(c *http.Client) *GCSOrder {
+	g.client = c
+	return g
+}
+
+// WithInvalidator makes the GCSOrder invalidate the cached contents in a CDN after
+// writing. The paths are invalidated, or the path of the object if they are
+// not specified.
+func (g *GCSOrder) WithInvalidator(inv Invalidator, paths ...string) *GCSOrder {
+	g.inv = inv
+	g.paths = paths
+	return g
+}
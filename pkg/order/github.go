@@ -0,0 +1,203 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/google/go-github/v55/github"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// GitHubOrder implements the Order interface. It is responsible for committing
+// the concatenated contents of the catalogs to a file in a GitHub repository,
+// and optionally opening a pull request of the commit.
+type GitHubOrder struct {
+	uri      uriapi.GitHubURI
+	catalogs []Catalog
+	message  string
+	prBase   string
+	client   *github.Client
+	http     *http.Client
+	l        *slog.Logger
+}
+
+func NewGitHubOrder(uri uriapi.GitHubURI, catalogs []Catalog) *GitHubOrder {
+	order := &GitHubOrder{
+		uri:      uri,
+		catalogs: catalogs,
+	}
+
+	return order
+}
+
+func isGitHubNotFound(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil &&
+		errResp.Response.StatusCode == http.StatusNotFound
+}
+
+// The Order function utilizes the Create or update file contents API in
+// GitHub. It requires the usage of an environment variable called
+// "GITHUB_TOKEN" to authorize the request. The contents are committed to the
+// branch of the "ref" query, or the default branch if it is not specified.
+// Nothing is committed if the file already has the contents.
+func (g *GitHubOrder) Order(ctx context.Context) error {
+	if g.l != nil {
+		g.l.InfoContext(ctx, "ordering", "scheme", g.uri.Scheme(), "uri", g.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, g.catalogs)
+	if err != nil {
+		return err
+	}
+
+	client := g.client
+	if client == nil {
+		client = github.NewClient(g.http).WithAuthToken(os.Getenv("GITHUB_TOKEN"))
+	}
+
+	if g.prBase != "" {
+		if g.uri.Ref() == "" {
+			return WriteError{uri: g.uri.Text(), reason: "ref is required to open a pull request."}
+		}
+
+		err = g.ensureBranch(ctx, client)
+		if err != nil {
+			return err
+		}
+	}
+
+	current, _, _, err := client.Repositories.GetContents(ctx,
+		g.uri.Owner(),
+		g.uri.Repo(),
+		g.uri.RepoPath(),
+		&github.RepositoryContentGetOptions{
+			Ref: g.uri.Ref(),
+		},
+	)
+	if err != nil && !isGitHubNotFound(err) {
+		return err
+	}
+
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.String(g.commitMessage()),
+		Content: buf,
+	}
+	if g.uri.Ref() != "" {
+		opts.Branch = github.String(g.uri.Ref())
+	}
+
+	if current != nil {
+		if current.GetType() != "file" {
+			return WriteError{uri: g.uri.Text(), reason: "Only support file type."}
+		}
+
+		content, err := current.GetContent()
+		if err != nil {
+			return err
+		}
+		if bytes.Equal([]byte(content), buf) {
+			return g.openPullRequest(ctx, client)
+		}
+
+		opts.SHA = current.SHA
+		_, _, err = client.Repositories.UpdateFile(ctx, g.uri.Owner(), g.uri.Repo(), g.uri.RepoPath(), opts)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, _, err = client.Repositories.CreateFile(ctx, g.uri.Owner(), g.uri.Repo(), g.uri.RepoPath(), opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return g.openPullRequest(ctx, client)
+}
+
+func (g *GitHubOrder) commitMessage() string {
+	if g.message != "" {
+		return g.message
+	}
+
+	return fmt.Sprintf("Update %s by cannect", g.uri.RepoPath())
+}
+
+// ensureBranch creates the branch of the "ref" query from the base branch of
+// the pull request, if it does not exist.
+func (g *GitHubOrder) ensureBranch(ctx context.Context, client *github.Client) error {
+	_, _, err := client.Git.GetRef(ctx, g.uri.Owner(), g.uri.Repo(), "heads/"+g.uri.Ref())
+	if err == nil {
+		return nil
+	}
+	if !isGitHubNotFound(err) {
+		return err
+	}
+
+	base, _, err := client.Git.GetRef(ctx, g.uri.Owner(), g.uri.Repo(), "heads/"+g.prBase)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Git.CreateRef(ctx, g.uri.Owner(), g.uri.Repo(), &github.Reference{
+		Ref:    github.String("refs/heads/" + g.uri.Ref()),
+		Object: &github.GitObject{SHA: base.Object.SHA},
+	})
+
+	return err
+}
+
+// openPullRequest opens the pull request from the branch of the "ref" query
+// to the base branch. It is not an error that the pull request already exists.
+func (g *GitHubOrder) openPullRequest(ctx context.Context, client *github.Client) error {
+	if g.prBase == "" {
+		return nil
+	}
+
+	_, _, err := client.PullRequests.Create(ctx, g.uri.Owner(), g.uri.Repo(), &github.NewPullRequest{
+		Title: github.String(g.commitMessage()),
+		Head:  github.String(g.uri.Ref()),
+		Base:  github.String(g.prBase),
+	})
+
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil &&
+		errResp.Response.StatusCode == http.StatusUnprocessableEntity {
+		// The pull request of the branch already exists.
+		return nil
+	}
+
+	return err
+}
+
+// WithMessage sets the commit message. The default is "Update <path> by
+// cannect".
+func (g *GitHubOrder) WithMessage(message string) *GitHubOrder {
+	g.message = message
+	return g
+}
+
+// WithPullRequest makes the GitHubOrder open a pull request from the branch
+// of the "ref" query to the base branch. The branch is created from the base
+// branch if it does not exist.
+func (g *GitHubOrder) WithPullRequest(base string) *GitHubOrder {
+	g.prBase = base
+	return g
+}
+
+func (g *GitHubOrder) WithLogger(l *slog.Logger) *GitHubOrder {
+	g.l = l
+	return g
+}
+
+// WithHTTPClient makes the GitHubOrder call the API with the client, like the
+// one with the proxy or the CA of the destination.
+func (g *GitHubOrder) WithHTTPClient(c *http.Client) *GitHubOrder {
+	g.http = c
+	return g
+}
@@ -0,0 +1,122 @@
+package order
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v55/github"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestGitHubOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotRequests []string
+	var gotRef, gotFile, gotPull map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/yuxki/pki/git/ref/heads/cannect", func(w http.ResponseWriter, r *http.Request) {
+		gotRequests = append(gotRequests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+	})
+	mux.HandleFunc("/repos/yuxki/pki/git/ref/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		gotRequests = append(gotRequests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{"ref":"refs/heads/main","object":{"type":"commit","sha":"main-sha"}}`))
+	})
+	mux.HandleFunc("/repos/yuxki/pki/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		gotRequests = append(gotRequests, r.Method+" "+r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&gotRef)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/repos/yuxki/pki/contents/dist/chain.crt", func(w http.ResponseWriter, r *http.Request) {
+		gotRequests = append(gotRequests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"type":"file","encoding":"base64","content":"` +
+				base64.StdEncoding.EncodeToString([]byte("old")) + `","sha":"old-sha"}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&gotFile)
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/repos/yuxki/pki/pulls", func(w http.ResponseWriter, r *http.Request) {
+		gotRequests = append(gotRequests, r.Method+" "+r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&gotPull)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, err = url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uri, err := uriapi.NewGitHubURI("github:///repos/yuxki/pki/contents/dist/chain.crt?ref=cannect")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	githubOrder := NewGitHubOrder(uri, testGenCatalogs(t)).WithMessage("Renew chain").WithPullRequest("main")
+	githubOrder.client = client
+
+	err = githubOrder.Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRequests := []string{
+		"GET /repos/yuxki/pki/git/ref/heads/cannect",
+		"GET /repos/yuxki/pki/git/ref/heads/main",
+		"POST /repos/yuxki/pki/git/refs",
+		"GET /repos/yuxki/pki/contents/dist/chain.crt",
+		"PUT /repos/yuxki/pki/contents/dist/chain.crt",
+		"POST /repos/yuxki/pki/pulls",
+	}
+	if diff := cmp.Diff(wantRequests, gotRequests); diff != "" {
+		t.Error(diff)
+	}
+
+	wantRef := map[string]interface{}{
+		"ref": "refs/heads/cannect",
+		"sha": "main-sha",
+	}
+	if diff := cmp.Diff(wantRef, gotRef); diff != "" {
+		t.Error(diff)
+	}
+
+	wantFile := map[string]interface{}{
+		"message": "Renew chain",
+		"content": base64.StdEncoding.EncodeToString(want),
+		"sha":     "old-sha",
+		"branch":  "cannect",
+	}
+	if diff := cmp.Diff(wantFile, gotFile); diff != "" {
+		t.Error(diff)
+	}
+
+	wantPull := map[string]interface{}{
+		"title": "Renew chain",
+		"head":  "cannect",
+		"base":  "main",
+	}
+	if diff := cmp.Diff(wantPull, gotPull); diff != "" {
+		t.Error(diff)
+	}
+}
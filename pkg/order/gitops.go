@@ -0,0 +1,192 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const kustomizationFile = "kustomization.yaml"
+
+var ErrInvalidEntryName = errors.New("entry name must be a file name")
+
+// writeYAMLKey writes the key quoted, so that keys like "on" or "1.0" are not
+// interpreted as other types.
+func writeYAMLKey(buf *bytes.Buffer, indent int, key string) {
+	quoted, _ := json.Marshal(key)
+	fmt.Fprintf(buf, "%s%s:", strings.Repeat("  ", indent), quoted)
+}
+
+// writeYAMLBlock writes the content as a literal block scalar, keeping the
+// trailing newlines as they are.
+func writeYAMLBlock(buf *bytes.Buffer, indent int, key string, content []byte) {
+	writeYAMLKey(buf, indent, key)
+
+	text := string(content)
+
+	indicator := "|"
+	if strings.HasPrefix(text, " ") {
+		indicator += "2"
+	}
+
+	switch {
+	case text == "" || !strings.HasSuffix(text, "\n"):
+		indicator += "-"
+	case strings.HasSuffix(text, "\n\n"):
+		indicator += "+"
+	}
+
+	buf.WriteString(" " + indicator + "\n")
+
+	prefix := strings.Repeat("  ", indent+1)
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(text, "\n"), "\n") {
+		if line == "\n" || line == "" {
+			buf.WriteString(line)
+			continue
+		}
+		buf.WriteString(prefix + line)
+	}
+	buf.WriteString("\n")
+}
+
+// HelmOrder implements the Order interface. It is responsible for rendering
+// the contents of each catalog into a Helm values file, under the dotted key
+// of the URI, so GitOps repositories can be updated by a bot workflow.
+type HelmOrder struct {
+	uri      uriapi.HelmURI
+	catalogs []Catalog
+	names    []string
+	l        *slog.Logger
+}
+
+// NewHelmOrder returns the HelmOrder. The names are the keys of the catalogs
+// in the values, in the same order as the catalogs.
+func NewHelmOrder(uri uriapi.HelmURI, catalogs []Catalog, names []string) *HelmOrder {
+	order := &HelmOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		names:    names,
+	}
+
+	return order
+}
+
+func (h *HelmOrder) Order(ctx context.Context) error {
+	if h.l != nil {
+		h.l.InfoContext(ctx, "ordering", "scheme", h.uri.Scheme(), "uri", h.uri.Text())
+	}
+
+	contents, err := fetchEntries(ctx, h.catalogs, h.names)
+	if err != nil {
+		return fmt.Errorf("%s: %w", h.uri.Text(), err)
+	}
+
+	var buf bytes.Buffer
+
+	indent := 0
+	if h.uri.Key() != "" {
+		for _, seg := range strings.Split(h.uri.Key(), ".") {
+			fmt.Fprintf(&buf, "%s%s:\n", strings.Repeat("  ", indent), seg)
+			indent++
+		}
+	}
+
+	for idx, content := range contents {
+		writeYAMLBlock(&buf, indent, h.names[idx], content)
+	}
+
+	return os.WriteFile(h.uri.Path(), buf.Bytes(), 0o600)
+}
+
+func (h *HelmOrder) WithLogger(l *slog.Logger) *HelmOrder {
+	h.l = l
+	return h
+}
+
+// KustomizeOrder implements the Order interface. It is responsible for
+// writing the contents of each catalog as a file in the directory, with the
+// kustomization.yaml that generates a Secret from the files.
+type KustomizeOrder struct {
+	uri      uriapi.KustomizeURI
+	catalogs []Catalog
+	names    []string
+	l        *slog.Logger
+}
+
+// NewKustomizeOrder returns the KustomizeOrder. The names are the file names
+// of the catalogs, in the same order as the catalogs.
+func NewKustomizeOrder(uri uriapi.KustomizeURI, catalogs []Catalog, names []string) *KustomizeOrder {
+	order := &KustomizeOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		names:    names,
+	}
+
+	return order
+}
+
+// The Order function overwrites the kustomization.yaml in the directory. The
+// name of the Secret is the name of the URI, or the base name of the directory
+// if it is not specified.
+func (k *KustomizeOrder) Order(ctx context.Context) error {
+	if k.l != nil {
+		k.l.InfoContext(ctx, "ordering", "scheme", k.uri.Scheme(), "uri", k.uri.Text())
+	}
+
+	for _, name := range k.names {
+		if name == "" || name == "." || name == ".." || name == kustomizationFile ||
+			strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("%s: %w", name, ErrInvalidEntryName)
+		}
+	}
+
+	contents, err := fetchEntries(ctx, k.catalogs, k.names)
+	if err != nil {
+		return fmt.Errorf("%s: %w", k.uri.Text(), err)
+	}
+
+	err = os.MkdirAll(k.uri.Path(), 0o755)
+	if err != nil {
+		return err
+	}
+
+	for idx, content := range contents {
+		err := os.WriteFile(path.Join(k.uri.Path(), k.names[idx]), content, 0o600)
+		if err != nil {
+			return err
+		}
+	}
+
+	name := k.uri.Name()
+	if name == "" {
+		name = path.Base(k.uri.Path())
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("apiVersion: kustomize.config.k8s.io/v1beta1\n")
+	buf.WriteString("kind: Kustomization\n")
+	buf.WriteString("secretGenerator:\n")
+	quoted, _ := json.Marshal(name)
+	fmt.Fprintf(&buf, "  - name: %s\n", quoted)
+	buf.WriteString("    files:\n")
+	for _, n := range k.names {
+		quoted, _ := json.Marshal(n)
+		fmt.Fprintf(&buf, "      - %s\n", quoted)
+	}
+
+	return os.WriteFile(path.Join(k.uri.Path(), kustomizationFile), buf.Bytes(), 0o600)
+}
+
+func (k *KustomizeOrder) WithLogger(l *slog.Logger) *KustomizeOrder {
+	k.l = l
+	return k
+}
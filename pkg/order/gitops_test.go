@@ -0,0 +1,118 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+type testBytesCatalog []byte
+
+func (t testBytesCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	return t, nil
+}
+
+func TestHelmOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewHelmURI("helm://testdata/TestHelmOrder_Order.yaml?key=ingress.tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(uri.Path()) })
+
+	catalogs := []Catalog{
+		testBytesCatalog("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"),
+		testBytesCatalog("no newline"),
+		testBytesCatalog("a\n\nb\n\n"),
+	}
+
+	err = NewHelmOrder(uri, catalogs, []string{"ca.crt", "on", "blank"}).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := os.ReadFile(uri.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `ingress:
+  tls:
+    "ca.crt": |
+      -----BEGIN CERTIFICATE-----
+      MIIB
+      -----END CERTIFICATE-----
+    "on": |-
+      no newline
+    "blank": |+
+      a
+
+      b
+
+`
+
+	if diff := cmp.Diff(want, string(result)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestKustomizeOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestKustomizeOrder_Order")
+	uri, err := uriapi.NewKustomizeURI("kustomize://" + dir + "?name=ca-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	catalogs := testGenCatalogs(t)
+
+	err = NewKustomizeOrder(uri, catalogs, testArchiveNames).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := os.ReadFile(path.Join(dir, "kustomization.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+secretGenerator:
+  - name: "ca-bundle"
+    files:
+      - "root-ca.crt"
+      - "sub-ca.crt"
+      - "server.crt"
+`
+
+	if diff := cmp.Diff(want, string(result)); diff != "" {
+		t.Error(diff)
+	}
+
+	entries := make(map[string]string)
+	for _, name := range testArchiveNames {
+		b, err := os.ReadFile(path.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[name] = string(b)
+	}
+
+	if diff := cmp.Diff(testReadWantEntries(t), entries); diff != "" {
+		t.Error(diff)
+	}
+
+	err = NewKustomizeOrder(uri, catalogs[:1], []string{"../root-ca.crt"}).Order(context.TODO())
+	if !errors.Is(err, ErrInvalidEntryName) {
+		t.Fatalf("Expected error is %v but got: %v", ErrInvalidEntryName, err)
+	}
+}
@@ -0,0 +1,42 @@
+package order
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// send sends the request to the destination of the URI, and returns WriteError
+// when the response status is not successful.
+func send(client *http.Client, req *http.Request, uriText string) error {
+	return receive(client, req, uriText, nil)
+}
+
+// receive sends the request like send, and decodes the response body in JSON to
+// the out if it is not nil.
+func receive(client *http.Client, req *http.Request, uriText string, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(resp.Body)
+		return WriteError{
+			uri:       uriText,
+			reason:    fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(msg))),
+			throttled: throttledStatus(resp.StatusCode),
+			status:    resp.StatusCode,
+		}
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
@@ -0,0 +1,174 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	defaultCloudFrontEndpoint = "https://cloudfront.amazonaws.com"
+	defaultComputeEndpoint    = "https://compute.googleapis.com"
+)
+
+// Invalidator invalidates the cached contents of the paths in a CDN, so that
+// the edge caches serve the written contents immediately.
+type Invalidator interface {
+	Invalidate(ctx context.Context, paths []string) error
+}
+
+// invalidate calls the Invalidator with the paths, or the default path if the
+// paths are not specified.
+func invalidate(ctx context.Context, inv Invalidator, paths []string, defaultPath, uriText string) error {
+	if len(paths) == 0 {
+		paths = []string{defaultPath}
+	}
+
+	err := inv.Invalidate(ctx, paths)
+	if err != nil {
+		return WriteError{uri: uriText, reason: fmt.Sprintf("invalidation failed: %v", err)}
+	}
+
+	return nil
+}
+
+type cloudFrontInvalidationBatch struct {
+	XMLName         xml.Name `xml:"http://cloudfront.amazonaws.com/doc/2020-05-31/ InvalidationBatch"`
+	CallerReference string   `xml:"CallerReference"`
+	Quantity        int      `xml:"Paths>Quantity"`
+	Items           []string `xml:"Paths>Items>Path"`
+}
+
+// CloudFrontInvalidator implements the Invalidator interface with the
+// CreateInvalidation API of AWS CloudFront.
+type CloudFrontInvalidator struct {
+	distributionID string
+	endpoint       string
+	client         *http.Client
+}
+
+func NewCloudFrontInvalidator(distributionID string) *CloudFrontInvalidator {
+	return &CloudFrontInvalidator{
+		distributionID: distributionID,
+		endpoint:       defaultCloudFrontEndpoint,
+		client:         http.DefaultClient,
+	}
+}
+
+// Invalidate requires the same credentials as the S3Order. The environment
+// variable "AWS_ENDPOINT_URL" overrides the endpoint of the API.
+func (c *CloudFrontInvalidator) Invalidate(ctx context.Context, paths []string) error {
+	ref, err := newClientRequestToken()
+	if err != nil {
+		return err
+	}
+
+	body, err := xml.Marshal(cloudFrontInvalidationBatch{
+		CallerReference: ref,
+		Quantity:        len(paths),
+		Items:           paths,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("%s/2020-05-31/distribution/%s/invalidation",
+			awsGlobalEndpoint(c.endpoint), url.PathEscape(c.distributionID),
+		),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+
+	// CloudFront is a global service signed in us-east-1.
+	err = signAWSRequest(ctx, req, body, "cloudfront", "us-east-1")
+	if err != nil {
+		return err
+	}
+
+	return doInvalidate(c.client, req)
+}
+
+// CloudCDNInvalidator implements the Invalidator interface with the
+// invalidateCache API of Google Cloud CDN.
+type CloudCDNInvalidator struct {
+	project  string
+	urlMap   string
+	endpoint string
+	client   *http.Client
+}
+
+func NewCloudCDNInvalidator(project, urlMap string) *CloudCDNInvalidator {
+	return &CloudCDNInvalidator{
+		project:  project,
+		urlMap:   urlMap,
+		endpoint: defaultComputeEndpoint,
+		client:   http.DefaultClient,
+	}
+}
+
+// Invalidate requires the same access token as the GCSOrder. The API accepts
+// one path in a request, so it is called for each path.
+func (c *CloudCDNInvalidator) Invalidate(ctx context.Context, paths []string) error {
+	for _, p := range paths {
+		body, err := json.Marshal(map[string]string{"path": p})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodPost,
+			fmt.Sprintf("%s/compute/v1/projects/%s/global/urlMaps/%s/invalidateCache",
+				strings.TrimSuffix(c.endpoint, "/"), url.PathEscape(c.project), url.PathEscape(c.urlMap),
+			),
+			bytes.NewReader(body),
+		)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		err = doInvalidate(c.client, req)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func doInvalidate(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	msg, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
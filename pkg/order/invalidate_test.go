@@ -0,0 +1,171 @@
+package order
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+type testInvalidator struct {
+	paths []string
+	err   error
+}
+
+func (i *testInvalidator) Invalidate(ctx context.Context, paths []string) error {
+	i.paths = paths
+	return i.err
+}
+
+func TestCloudFrontInvalidator_Invalidate(t *testing.T) {
+	var got testRequest
+	srv := testRecordServer(t, &got)
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_DEFAULT_REGION", "ap-northeast-1")
+
+	err := NewCloudFrontInvalidator("E2EXAMPLE").Invalidate(
+		context.TODO(), []string{"/pki/root.crl", "/pki/chain.crt"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.method != http.MethodPost {
+		t.Errorf("Expected method is POST but got: %s", got.method)
+	}
+	if got.uri != "/2020-05-31/distribution/E2EXAMPLE/invalidation" {
+		t.Errorf("Unexpected request URI: %s", got.uri)
+	}
+	if !strings.Contains(got.header.Get("Authorization"), "/us-east-1/cloudfront/aws4_request") {
+		t.Errorf("Unexpected authorization header: %s", got.header.Get("Authorization"))
+	}
+
+	var batch cloudFrontInvalidationBatch
+	err = xml.Unmarshal(got.body, &batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.CallerReference == "" {
+		t.Error("Expected caller reference is set")
+	}
+	if batch.Quantity != 2 {
+		t.Errorf("Expected quantity is 2 but got: %d", batch.Quantity)
+	}
+	if diff := cmp.Diff(batch.Items, []string{"/pki/root.crl", "/pki/chain.crt"}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestCloudCDNInvalidator_Invalidate(t *testing.T) {
+	var uris []string
+	var bodies []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		uris = append(uris, r.URL.RequestURI())
+		bodies = append(bodies, string(body))
+
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "test-token")
+
+	inv := NewCloudCDNInvalidator("foo-project", "foo-map")
+	inv.endpoint = srv.URL
+
+	err := inv.Invalidate(context.TODO(), []string{"/pki/root.crl", "/pki/*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantURI := "/compute/v1/projects/foo-project/global/urlMaps/foo-map/invalidateCache"
+	if diff := cmp.Diff(uris, []string{wantURI, wantURI}); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(bodies, []string{`{"path":"/pki/root.crl"}`, `{"path":"/pki/*"}`}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestGCSOrder_WithInvalidator(t *testing.T) {
+	var got testRequest
+	srv := testRecordServer(t, &got)
+	defer srv.Close()
+
+	t.Setenv("STORAGE_EMULATOR_HOST", srv.URL)
+
+	uri, err := uriapi.NewGCSURI("gcs://foo-bucket/pki/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase  string
+		paths     []string
+		err       error
+		wantPaths []string
+		wantErr   bool
+	}{
+		{
+			"Object Path",
+			nil,
+			nil,
+			[]string{"/pki/chain.crt"},
+			false,
+		},
+		{
+			"Specified Paths",
+			[]string{"/pki/*"},
+			nil,
+			[]string{"/pki/*"},
+			false,
+		},
+		{
+			"Invalidation Failed",
+			nil,
+			errors.New("test"),
+			[]string{"/pki/chain.crt"},
+			true,
+		},
+	}
+
+	for _, d := range data {
+		inv := &testInvalidator{err: d.err}
+
+		err := NewGCSOrder(uri, testGenCatalogs(t)).WithInvalidator(inv, d.paths...).Order(context.TODO())
+		if d.wantErr {
+			var wErr WriteError
+			if !errors.As(err, &wErr) {
+				t.Errorf("%s: Expected WriteError but got: %v", d.testCase, err)
+			}
+		} else if err != nil {
+			t.Fatalf("%s: %v", d.testCase, err)
+		}
+
+		if diff := cmp.Diff(inv.paths, d.wantPaths); diff != "" {
+			t.Errorf("%s: %s", d.testCase, diff)
+		}
+	}
+}
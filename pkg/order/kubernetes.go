@@ -0,0 +1,103 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yuxki/cannect/pkg/kube"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const defaultKubernetesKey = "content"
+
+// KubernetesOrder implements the Order interface. It is responsible for
+// writing the concatenated contents of the catalogs to a key of a Secret or a
+// ConfigMap in Kubernetes.
+type KubernetesOrder struct {
+	uri      uriapi.KubernetesURI
+	catalogs []Catalog
+	client   *kube.Client
+	l        *slog.Logger
+}
+
+func NewKubernetesOrder(uri uriapi.KubernetesURI, catalogs []Catalog) *KubernetesOrder {
+	order := &KubernetesOrder{
+		uri:      uri,
+		catalogs: catalogs,
+	}
+
+	return order
+}
+
+// The Order function applies the Secret or the ConfigMap with the server-side
+// apply, so the other keys managed by others are kept. It uses the service
+// account of the pod unless the client is specified by WithClient.
+func (k *KubernetesOrder) Order(ctx context.Context) error {
+	if k.l != nil {
+		k.l.InfoContext(ctx, "ordering", "scheme", k.uri.Scheme(), "uri", k.uri.Text())
+	}
+
+	client := k.client
+	if client == nil {
+		var err error
+
+		client, err = kube.NewInClusterClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	buf, err := fetchAll(ctx, k.catalogs)
+	if err != nil {
+		return err
+	}
+
+	key := k.uri.Key()
+	if key == "" {
+		key = defaultKubernetesKey
+	}
+
+	obj := map[string]interface{}{
+		"apiVersion": "v1",
+		"metadata": map[string]string{
+			"name":      k.uri.Name(),
+			"namespace": k.uri.Namespace(),
+		},
+	}
+
+	resource := "configmaps"
+	if k.uri.Kind() == "secret" {
+		resource = "secrets"
+		obj["kind"] = "Secret"
+		// The []byte is encoded in base64 as the Secret requires.
+		obj["data"] = map[string][]byte{key: buf}
+	} else {
+		obj["kind"] = "ConfigMap"
+		obj["data"] = map[string]string{key: string(buf)}
+	}
+
+	path := fmt.Sprintf("/api/v1/namespaces/%s/%s/%s", k.uri.Namespace(), resource, k.uri.Name())
+
+	err = client.Apply(ctx, path, obj)
+	if err != nil {
+		var apiErr kube.APIError
+		throttled := errors.As(err, &apiErr) && apiErr.Throttled()
+		return WriteError{uri: k.uri.Text(), reason: err.Error(), throttled: throttled}
+	}
+
+	return nil
+}
+
+// WithClient makes the KubernetesOrder use the client instead of the service
+// account of the pod.
+func (k *KubernetesOrder) WithClient(c *kube.Client) *KubernetesOrder {
+	k.client = c
+	return k
+}
+
+func (k *KubernetesOrder) WithLogger(l *slog.Logger) *KubernetesOrder {
+	k.l = l
+	return k
+}
@@ -0,0 +1,101 @@
+package order
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/pkg/kube"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestKubernetesOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testcase string
+		// input
+		uri string
+		// want
+		path  string
+		kind  string
+		value string
+	}{
+		{
+			"OK:secret",
+			"k8s://pki/secret/ca-bundle?key=ca.crt",
+			"/api/v1/namespaces/pki/secrets/ca-bundle",
+			"Secret",
+			base64.StdEncoding.EncodeToString(want),
+		},
+		{
+			"OK:configmap",
+			"k8s://pki/configmap/ca-bundle?key=ca.crt",
+			"/api/v1/namespaces/pki/configmaps/ca-bundle",
+			"ConfigMap",
+			string(want),
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			var gotPath, gotType, gotManager string
+			var gotBody struct {
+				Kind string            `json:"kind"`
+				Data map[string]string `json:"data"`
+			}
+
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				gotType = r.Header.Get("Content-Type")
+				gotManager = r.URL.Query().Get("fieldManager")
+				if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			uri, err := uriapi.NewKubernetesURI(d.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := kube.NewClient(srv.URL, "test-token", srv.Client())
+			err = NewKubernetesOrder(uri, testGenCatalogs(t)).WithClient(client).Order(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if gotPath != d.path {
+				t.Errorf("Expected path is %s but got: %s", d.path, gotPath)
+			}
+			if gotType != "application/apply-patch+yaml" {
+				t.Errorf("Expected content type is application/apply-patch+yaml but got: %s", gotType)
+			}
+			if gotManager != "cannect" {
+				t.Errorf("Expected field manager is cannect but got: %s", gotManager)
+			}
+			if gotBody.Kind != d.kind {
+				t.Errorf("Expected kind is %s but got: %s", d.kind, gotBody.Kind)
+			}
+			if diff := cmp.Diff(gotBody.Data["ca.crt"], d.value); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
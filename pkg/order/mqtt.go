@@ -0,0 +1,272 @@
+package order
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	mqttConnect    byte = 0x10
+	mqttConnAck    byte = 0x20
+	mqttPublish    byte = 0x30
+	mqttPubAck     byte = 0x40
+	mqttDisconnect byte = 0xe0
+
+	// mqttMaxRemaining is the maximum remaining length of the MQTT packet.
+	mqttMaxRemaining = 268435455
+	mqttKeepAlive    = 60
+)
+
+// MQTTOrder implements the Order interface. It is responsible for publishing
+// the concatenated contents of the catalogs, or the notification of them, to
+// the topic of an MQTT broker with MQTT 3.1.1, so that the IoT fleets receive
+// the new CA assets when they are changed.
+type MQTTOrder struct {
+	uri       uriapi.MQTTURI
+	catalogs  []Catalog
+	qos       byte
+	retain    bool
+	notify    bool
+	url       string
+	tlsConfig *tls.Config
+	l         *slog.Logger
+}
+
+// NewMQTTOrder returns the MQTTOrder that publishes the contents with QoS 0
+// and without the retain flag.
+func NewMQTTOrder(uri uriapi.MQTTURI, catalogs []Catalog) *MQTTOrder {
+	order := &MQTTOrder{
+		uri:       uri,
+		catalogs:  catalogs,
+		tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	return order
+}
+
+// The Order function connects to the broker with the clean session, and
+// authenticates with the environment variables "MQTT_USERNAME" and
+// "MQTT_PASSWORD" if they are set. The "mqtts" scheme connects with TLS to the
+// port 8883 by default, and "mqtt" without TLS to 1883.
+func (m *MQTTOrder) Order(ctx context.Context) error {
+	if m.l != nil {
+		m.l.InfoContext(ctx, "ordering", "scheme", m.uri.Scheme(), "uri", m.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, m.catalogs)
+	if err != nil {
+		return err
+	}
+
+	payload, err := publishPayload(buf, m.notify, m.url)
+	if err != nil {
+		return err
+	}
+
+	publish := m.publishPacket(payload)
+	if publish == nil {
+		return fmt.Errorf("%s: %w", m.uri.Text(), ErrContentTooLarge)
+	}
+
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	connect, err := m.connectPacket()
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(connect)
+	if err != nil {
+		return err
+	}
+
+	typ, body, err := readMQTTPacket(r)
+	if err != nil {
+		return err
+	}
+	if typ != mqttConnAck || len(body) != 2 {
+		return fmt.Errorf("%s: %w: 0x%02x", m.uri.Text(), ErrUnexpectedPacket, typ)
+	}
+	if body[1] != 0 {
+		return fmt.Errorf("%s: %w: return code %d", m.uri.Text(), ErrBrokerRefused, body[1])
+	}
+
+	_, err = conn.Write(publish)
+	if err != nil {
+		return err
+	}
+
+	if m.qos == 1 {
+		typ, body, err := readMQTTPacket(r)
+		if err != nil {
+			return err
+		}
+		if typ != mqttPubAck || len(body) != 2 || binary.BigEndian.Uint16(body) != 1 {
+			return fmt.Errorf("%s: %w: 0x%02x", m.uri.Text(), ErrUnexpectedPacket, typ)
+		}
+	}
+
+	_, err = conn.Write([]byte{mqttDisconnect, 0})
+	return err
+}
+
+func (m *MQTTOrder) dial(ctx context.Context) (net.Conn, error) {
+	if m.uri.Scheme() == "mqtts" {
+		return dialBroker(ctx, m.uri.Host(), "8883", m.tlsConfig)
+	}
+
+	return dialBroker(ctx, m.uri.Host(), "1883", nil)
+}
+
+// connectPacket returns the CONNECT packet with the random client identifier.
+func (m *MQTTOrder) connectPacket() ([]byte, error) {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The clean session flag.
+	var flags byte = 0x02
+	payload := mqttString("cannect-" + hex.EncodeToString(id))
+	if username, ok := os.LookupEnv("MQTT_USERNAME"); ok {
+		flags |= 0x80
+		payload = append(payload, mqttString(username)...)
+	}
+	if password, ok := os.LookupEnv("MQTT_PASSWORD"); ok {
+		flags |= 0x40
+		payload = append(payload, mqttString(password)...)
+	}
+
+	body := append(mqttString("MQTT"), 4, flags, 0, mqttKeepAlive)
+	body = append(body, payload...)
+
+	return mqttPacket(mqttConnect, body), nil
+}
+
+// publishPacket returns the PUBLISH packet with the packet identifier 1 for
+// QoS 1, or nil if the payload is too large.
+func (m *MQTTOrder) publishPacket(payload []byte) []byte {
+	header := mqttPublish | m.qos<<1
+	if m.retain {
+		header |= 0x01
+	}
+
+	body := mqttString(m.uri.Topic())
+	if m.qos == 1 {
+		body = append(body, 0, 1)
+	}
+	if len(body)+len(payload) > mqttMaxRemaining {
+		return nil
+	}
+
+	return mqttPacket(header, append(body, payload...))
+}
+
+// WithQoS sets the QoS level of the publication, 0 or 1. The order waits for
+// the acknowledgement of the broker with QoS 1.
+func (m *MQTTOrder) WithQoS(qos byte) *MQTTOrder {
+	m.qos = qos
+	return m
+}
+
+// WithRetain makes the broker keep the publication for the subscribers that
+// connect later.
+func (m *MQTTOrder) WithRetain(retain bool) *MQTTOrder {
+	m.retain = retain
+	return m
+}
+
+// WithNotification publishes the Notification with the URL instead of the
+// contents.
+func (m *MQTTOrder) WithNotification(url string) *MQTTOrder {
+	m.notify = true
+	m.url = url
+	return m
+}
+
+func (m *MQTTOrder) WithTLSConfig(config *tls.Config) *MQTTOrder {
+	m.tlsConfig = config
+	return m
+}
+
+func (m *MQTTOrder) WithLogger(l *slog.Logger) *MQTTOrder {
+	m.l = l
+	return m
+}
+
+// mqttString returns the string prefixed by its length in 2 bytes.
+func mqttString(s string) []byte {
+	b := make([]byte, 2, 2+len(s))
+	binary.BigEndian.PutUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// mqttPacket returns the packet of the fixed header and the body.
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if n == 0 {
+			break
+		}
+	}
+
+	return append(packet, body...)
+}
+
+// readMQTTPacket reads a packet, and returns the type of it and the body.
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	n, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if i == 3 && digit&0x80 != 0 {
+			return 0, nil, ErrUnexpectedPacket
+		}
+
+		n += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return header & 0xf0, body, nil
+}
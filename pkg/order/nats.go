@@ -0,0 +1,181 @@
+package order
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+var ErrServerError = errors.New("error is returned by the server")
+
+// natsInfo is the part of the INFO message of the NATS server.
+type natsInfo struct {
+	TLSRequired bool  `json:"tls_required"`
+	MaxPayload  int64 `json:"max_payload"`
+}
+
+// natsConnect is the options of the CONNECT message.
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	AuthToken   string `json:"auth_token,omitempty"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+}
+
+// NATSOrder implements the Order interface. It is responsible for publishing
+// the concatenated contents of the catalogs, or the notification of them, to
+// the subject of a NATS server, so that the IoT fleets receive the new CA
+// assets when they are changed.
+type NATSOrder struct {
+	uri       uriapi.NATSURI
+	catalogs  []Catalog
+	notify    bool
+	url       string
+	tlsConfig *tls.Config
+	l         *slog.Logger
+}
+
+func NewNATSOrder(uri uriapi.NATSURI, catalogs []Catalog) *NATSOrder {
+	order := &NATSOrder{
+		uri:       uri,
+		catalogs:  catalogs,
+		tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	return order
+}
+
+// The Order function connects to the port 4222 by default, and upgrades the
+// connection to TLS if the server requires it. It authenticates with the
+// environment variable "NATS_TOKEN", or "NATS_USER" and "NATS_PASSWORD" if
+// they are set. The publication is confirmed by the round trip of PING, so the
+// errors of the server, like the permission violation, are returned.
+func (n *NATSOrder) Order(ctx context.Context) error {
+	if n.l != nil {
+		n.l.InfoContext(ctx, "ordering", "scheme", n.uri.Scheme(), "uri", n.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, n.catalogs)
+	if err != nil {
+		return err
+	}
+
+	payload, err := publishPayload(buf, n.notify, n.url)
+	if err != nil {
+		return err
+	}
+
+	var conn net.Conn
+	conn, err = dialBroker(ctx, n.uri.Host(), "4222", nil)
+	if err != nil {
+		return err
+	}
+	// The connection may be upgraded to TLS.
+	defer func() { conn.Close() }()
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("%s: %w: %s", n.uri.Text(), ErrUnexpectedPacket, strings.TrimSpace(line))
+	}
+
+	var info natsInfo
+	err = json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+	if err != nil {
+		return err
+	}
+	if info.MaxPayload > 0 && int64(len(payload)) > info.MaxPayload {
+		return fmt.Errorf("%s: %w", n.uri.Text(), ErrContentTooLarge)
+	}
+
+	if info.TLSRequired {
+		config := n.tlsConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName = n.uri.Host()
+			if host, _, err := net.SplitHostPort(n.uri.Host()); err == nil {
+				config.ServerName = host
+			}
+		}
+
+		tlsConn := tls.Client(conn, config)
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			return err
+		}
+		conn = tlsConn
+		r = bufio.NewReader(conn)
+	}
+
+	options, err := json.Marshal(natsConnect{
+		TLSRequired: info.TLSRequired,
+		Name:        "cannect",
+		Lang:        "go",
+		AuthToken:   os.Getenv("NATS_TOKEN"),
+		User:        os.Getenv("NATS_USER"),
+		Pass:        os.Getenv("NATS_PASSWORD"),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("CONNECT %s\r\nPUB %s %d\r\n", options, n.uri.Subject(), len(payload))
+	_, err = conn.Write(append(append([]byte(msg), payload...), "\r\nPING\r\n"...))
+	if err != nil {
+		return err
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			_, err = conn.Write([]byte("PONG\r\n"))
+			if err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			reason := strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))
+			return fmt.Errorf("%s: %w: %s", n.uri.Text(), ErrServerError, reason)
+		}
+	}
+}
+
+// WithNotification publishes the Notification with the URL instead of the
+// contents.
+func (n *NATSOrder) WithNotification(url string) *NATSOrder {
+	n.notify = true
+	n.url = url
+	return n
+}
+
+func (n *NATSOrder) WithTLSConfig(config *tls.Config) *NATSOrder {
+	n.tlsConfig = config
+	return n
+}
+
+func (n *NATSOrder) WithLogger(l *slog.Logger) *NATSOrder {
+	n.l = l
+	return n
+}
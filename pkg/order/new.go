@@ -0,0 +1,469 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// ErrUnsupportedScheme is returned by New for the schemes it cannot build.
+var ErrUnsupportedScheme = errors.New("unsupported scheme")
+
+// Order writes the contents of the catalogs to the destination.
+type Order interface {
+	Order(context.Context) error
+}
+
+// Options are the options of the order built by New. The order elements of
+// the CLI config are converted to the same options, so the orders composed in
+// Go have the same features as the ones in the config.
+type Options struct {
+	Catalogs     []Catalog
+	Transformers []Transformer
+	Checkers     []BundleChecker
+	Separator    string
+	AssetNewline bool
+	FinalNewline FinalNewline
+	Logger       *slog.Logger
+	Sealer       Sealer
+	EnvWriter    *EnvWriter
+	EnvBase64    bool
+	Stdout       io.Writer
+	Timeout      time.Duration
+	Retry        catalogapi.Retry
+	HTTPClient   *http.Client
+	EntryNames   []string
+	Command      []string
+}
+
+// Option sets the Options.
+type Option func(*Options)
+
+// WithCatalogs adds the catalogs whose contents are written in the order they
+// are added.
+func WithCatalogs(catalogs ...Catalog) Option {
+	return func(o *Options) {
+		o.Catalogs = append(o.Catalogs, catalogs...)
+	}
+}
+
+// WithTransforms adds the transformers of the concatenated contents, like
+// transform.Normalize and transform.MergeCRL.
+func WithTransforms(transformers ...Transformer) Option {
+	return func(o *Options) {
+		o.Transformers = append(o.Transformers, transformers...)
+	}
+}
+
+// WithChecks adds the checkers of the concatenated contents, which are applied
+// after the transformers.
+func WithChecks(checkers ...BundleChecker) Option {
+	return func(o *Options) {
+		o.Checkers = append(o.Checkers, checkers...)
+	}
+}
+
+// WithJoin sets the concatenation of the contents. See the Bundle for details.
+func WithJoin(separator string, assetNewline bool, finalNewline FinalNewline) Option {
+	return func(o *Options) {
+		o.Separator = separator
+		o.AssetNewline = assetNewline
+		o.FinalNewline = finalNewline
+	}
+}
+
+// WithLogger sets the logger of the order, which logs each order at the info
+// level.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithSeal makes the order of the file scheme seal the contents with the
+// Sealer.
+func WithSeal(s Sealer) Option {
+	return func(o *Options) {
+		o.Sealer = s
+	}
+}
+
+// WithEnv sets the EnvWriter of the env scheme, and whether the values are
+// encoded in base64.
+func WithEnv(w *EnvWriter, base64 bool) Option {
+	return func(o *Options) {
+		o.EnvWriter = w
+		o.EnvBase64 = base64
+	}
+}
+
+// WithStdout sets the writer of the stdout scheme. The standard output is used
+// by default.
+func WithStdout(w io.Writer) Option {
+	return func(o *Options) {
+		o.Stdout = w
+	}
+}
+
+// WithTimeout limits the time of the order, including the fetches of its
+// catalogs. It is not limited if the timeout is 0.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// WithRetry retries the order failed with the transient error of the
+// destination, like the throttled write or the 5xx response, with the same
+// policy as the catalogs. The failures of the catalogs are not retried, since
+// the catalogs retry their own requests.
+func WithRetry(retry catalogapi.Retry) Option {
+	return func(o *Options) {
+		o.Retry = retry
+	}
+}
+
+// WithHTTPClient sets the client of the requests of the orders calling the
+// HTTP APIs, like the github, vault and s3 schemes.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *Options) {
+		o.HTTPClient = c
+	}
+}
+
+// WithEntryNames sets the names of the entries of the zip, tar, helm and
+// kustomize schemes, in the same order as the catalogs. The catalogs of these
+// schemes are not bundled.
+func WithEntryNames(names ...string) Option {
+	return func(o *Options) {
+		o.EntryNames = names
+	}
+}
+
+// WithCommand sets the command of the cmd scheme, which reads the contents
+// from its standard input.
+func WithCommand(args ...string) Option {
+	return func(o *Options) {
+		o.Command = args
+	}
+}
+
+// NewOptions returns the Options set by the opts in order.
+func NewOptions(opts ...Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// Bundled returns the catalogs as they are if no concatenation, transformer or
+// checker is set, or otherwise the Bundle of them.
+func (o Options) Bundled() []Catalog {
+	if len(o.Transformers) == 0 && len(o.Checkers) == 0 &&
+		o.Separator == "" && !o.AssetNewline && o.FinalNewline == KeepFinalNewline {
+		return o.Catalogs
+	}
+
+	bundle := NewBundle(o.Catalogs).WithSeparator(o.Separator).WithFinalNewline(o.FinalNewline)
+	if o.AssetNewline {
+		bundle = bundle.WithAssetNewline()
+	}
+	for _, t := range o.Transformers {
+		bundle = bundle.WithTransformer(t)
+	}
+	for _, c := range o.Checkers {
+		bundle = bundle.WithChecker(c)
+	}
+
+	return []Catalog{bundle}
+}
+
+// New returns the Order to the destination of the URI with the options. All
+// the schemes of the package are supported. The settings only some schemes
+// have, like the message of the github scheme or the headers of the https
+// scheme, are set by the methods of the orders created by their constructors.
+func New(uriText string, opts ...Option) (Order, error) {
+	o := NewOptions(opts...)
+
+	order, err := newOrder(uriText, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Retry.Attempts > 1 {
+		order = &retryOrder{order: order, retry: o.Retry}
+	}
+	if o.Timeout > 0 {
+		order = &timeoutOrder{order: order, timeout: o.Timeout}
+	}
+
+	return order, nil
+}
+
+// newOrder returns the Order of the scheme of the URI.
+func newOrder(uriText string, o Options) (Order, error) {
+	catalogs := o.Bundled()
+
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	scheme := uriText
+	if idx := strings.Index(uriText, "://"); idx >= 0 {
+		scheme = uriText[:idx]
+	}
+
+	switch scheme {
+	case "file":
+		uri, err := uriapi.NewFSURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order := NewFSOrder(uri, catalogs).WithLogger(o.Logger)
+		if o.Sealer != nil {
+			order = order.WithSealer(o.Sealer)
+		}
+
+		return order, nil
+	case "env":
+		uri, err := uriapi.NewEnvURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		w := o.EnvWriter
+		if w == nil {
+			w = NewEnvWriter(os.Stdout, ExportEnvFormat)
+		}
+
+		order := NewEnvOrder(uri, catalogs, nil).WithEnvWriter(w).WithLogger(o.Logger)
+		if o.EnvBase64 {
+			order = order.WithBase64()
+		}
+
+		return order, nil
+	case "stdout":
+		uri, err := uriapi.NewStdoutURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		w := o.Stdout
+		if w == nil {
+			w = os.Stdout
+		}
+
+		return NewStdoutOrder(uri, catalogs, w).WithLogger(o.Logger), nil
+	case "github":
+		uri, err := uriapi.NewGitHubURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewGitHubOrder(uri, catalogs).WithLogger(o.Logger).WithHTTPClient(client), nil
+	case "vault":
+		uri, err := uriapi.NewVaultURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewVaultOrder(uri, catalogs).WithLogger(o.Logger).WithHTTPClient(client), nil
+	case "secretsmanager":
+		uri, err := uriapi.NewSecretsManagerURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSecretsManagerOrder(uri, catalogs).WithLogger(o.Logger).WithHTTPClient(client), nil
+	case "ssm":
+		uri, err := uriapi.NewSSMURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSSMOrder(uri, catalogs).WithLogger(o.Logger).WithHTTPClient(client), nil
+	case "s3":
+		uri, err := uriapi.NewS3URI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		order := NewS3Order(uri, catalogs).WithLogger(o.Logger)
+		if o.HTTPClient != nil {
+			order = order.WithOptions(func(so *s3.Options) { so.HTTPClient = o.HTTPClient })
+		}
+
+		return order, nil
+	case "gcs":
+		uri, err := uriapi.NewGCSURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewGCSOrder(uri, catalogs).WithLogger(o.Logger).WithHTTPClient(client), nil
+	case "azblob":
+		uri, err := uriapi.NewAzBlobURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewAzBlobOrder(uri, catalogs).WithLogger(o.Logger).WithHTTPClient(client), nil
+	case "https":
+		uri, err := uriapi.NewWebhookURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewWebhookOrder(uri, catalogs).WithLogger(o.Logger).WithHTTPClient(client), nil
+	case "dns":
+		uri, err := uriapi.NewDNSURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewDNSOrder(uri, catalogs).WithLogger(o.Logger).WithHTTPClient(client), nil
+	case "k8s":
+		uri, err := uriapi.NewKubernetesURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewKubernetesOrder(uri, catalogs).WithLogger(o.Logger), nil
+	case "docker":
+		uri, err := uriapi.NewDockerURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewDockerOrder(uri, catalogs).WithLogger(o.Logger), nil
+	case "cas":
+		uri, err := uriapi.NewCASURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewCASOrder(uri, catalogs).WithLogger(o.Logger), nil
+	case "unix":
+		uri, err := uriapi.NewUnixURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewUnixOrder(uri, catalogs).WithLogger(o.Logger), nil
+	case "cmd":
+		uri, err := uriapi.NewCommandURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewCommandOrder(uri, catalogs, o.Command).WithLogger(o.Logger), nil
+	case "plugin":
+		uri, err := uriapi.NewPluginURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewPluginOrder(uri, catalogs).WithLogger(o.Logger), nil
+	case "mqtt", "mqtts":
+		uri, err := uriapi.NewMQTTURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewMQTTOrder(uri, catalogs).WithLogger(o.Logger), nil
+	case "nats":
+		uri, err := uriapi.NewNATSURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewNATSOrder(uri, catalogs).WithLogger(o.Logger), nil
+	case "zip", "tar":
+		uri, err := uriapi.NewArchiveURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewArchiveOrder(uri, o.Catalogs, o.EntryNames).WithLogger(o.Logger), nil
+	case "helm":
+		uri, err := uriapi.NewHelmURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewHelmOrder(uri, o.Catalogs, o.EntryNames).WithLogger(o.Logger), nil
+	case "kustomize":
+		uri, err := uriapi.NewKustomizeURI(uriText)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewKustomizeOrder(uri, o.Catalogs, o.EntryNames).WithLogger(o.Logger), nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", uriText, ErrUnsupportedScheme)
+}
+
+// retryOrder retries the order failed with the transient error.
+type retryOrder struct {
+	order Order
+	retry catalogapi.Retry
+}
+
+func (r *retryOrder) Order(ctx context.Context) error {
+	return r.retry.Do(ctx, r.order.Order, transientWrite)
+}
+
+// transientWrite reports whether the failed order may succeed if it is
+// retried. The throttled writes, the 5xx responses and the network errors are
+// transient, and the failures of the catalogs are not.
+func transientWrite(err error) (time.Duration, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, catalogapi.ErrFetch) || errors.Is(err, catalogapi.ErrContent) {
+		return 0, false
+	}
+	if Throttled(err) {
+		return 0, true
+	}
+
+	var writeErr WriteError
+	if errors.As(err, &writeErr) && writeErr.status >= http.StatusInternalServerError {
+		return 0, true
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return 0, statusErr.HTTPStatusCode() >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return 0, errors.As(err, &netErr)
+}
+
+// timeoutOrder limits the time of the order.
+type timeoutOrder struct {
+	order   Order
+	timeout time.Duration
+}
+
+func (t *timeoutOrder) Order(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.order.Order(ctx)
+}
@@ -0,0 +1,222 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	order, err := New("stdout://", WithCatalogs(testGenCatalogs(t)...), WithStdout(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = order.Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(buf.Bytes(), want); diff != "" {
+		t.Fatal(diff)
+	}
+
+	buf.Reset()
+	order, err = New("stdout://",
+		WithCatalogs(testGenCatalogs(t)...), WithChecks(testErrChecker{}), WithStdout(&buf),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = order.Order(context.TODO())
+	if !errors.Is(err, errTestCheck) {
+		t.Fatalf("Expected %#v error but got: %#v", errTestCheck, err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Expected nothing written but got: %q", buf.String())
+	}
+
+	_, err = New("ftp://ca/ca.crt", WithCatalogs(testGenCatalogs(t)...))
+	if !errors.Is(err, ErrUnsupportedScheme) {
+		t.Fatalf("Expected %#v error but got: %#v", ErrUnsupportedScheme, err)
+	}
+}
+
+func TestNew_Schemes(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriText string
+		// want
+		order string
+	}{
+		{"file://testdata/ca.crt", "*order.FSOrder"},
+		{"github:///repos/yuxki/pki/contents/ca.crt", "*order.GitHubOrder"},
+		{"vault://secret/data/ca", "*order.VaultOrder"},
+		{"secretsmanager://pki/ca", "*order.SecretsManagerOrder"},
+		{"s3://bucket/ca.crt", "*order.S3Order"},
+		{"https://example.com/ca", "*order.WebhookOrder"},
+		{"cmd://ca", "*order.CommandOrder"},
+		{"zip://dist/ca.zip", "*order.ArchiveOrder"},
+		{"kustomize://overlays/ca", "*order.KustomizeOrder"},
+	}
+
+	for _, d := range data {
+		order, err := New(d.uriText,
+			WithCatalogs(testGenCatalogs(t)...), WithEntryNames("ca.crt"), WithCommand("cat"),
+			WithHTTPClient(http.DefaultClient),
+		)
+		if err != nil {
+			t.Fatalf("%s: %v", d.uriText, err)
+		}
+		if got := fmt.Sprintf("%T", order); got != d.order {
+			t.Errorf("%s: Expected %s but got: %s", d.uriText, d.order, got)
+		}
+	}
+}
+
+func TestNew_WithRetry(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		status   int
+		// want
+		requests int
+		wantErr  bool
+	}{
+		{"OK:Unavailable", http.StatusServiceUnavailable, 2, false},
+		{"NG:Bad Request", http.StatusBadRequest, 1, true},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var requests int32
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					w.WriteHeader(d.status)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			order, err := New(srv.URL+"/ca", WithCatalogs(testGenCatalogs(t)...),
+				WithRetry(catalogapi.Retry{Attempts: 3, BaseDelay: time.Millisecond}),
+				WithHTTPClient(srv.Client()),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = order.Order(context.TODO())
+			if (err != nil) != d.wantErr {
+				t.Fatalf("Expected error %t but got: %v", d.wantErr, err)
+			}
+			if got := int(atomic.LoadInt32(&requests)); got != d.requests {
+				t.Errorf("Expected %d requests but got: %d", d.requests, got)
+			}
+		})
+	}
+}
+
+// testBlockCatalog blocks the fetch until the context is done.
+type testBlockCatalog struct{}
+
+func (testBlockCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestNew_WithTimeout(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	order, err := New("stdout://",
+		WithCatalogs(testBlockCatalog{}), WithStdout(&buf), WithTimeout(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = order.Order(context.TODO())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %#v error but got: %#v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestOptions_Bundled(t *testing.T) {
+	t.Parallel()
+
+	catalogs := []Catalog{
+		testBytesCatalog("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"),
+		testBytesCatalog("-----BEGIN CERTIFICATE-----\nMIIC\n-----END CERTIFICATE-----\n\n"),
+	}
+
+	data := []struct {
+		testCase string
+		opts     []Option
+		want     string
+	}{
+		{
+			"Raw",
+			[]Option{WithCatalogs(catalogs...)},
+			"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE----------BEGIN CERTIFICATE-----\n" +
+				"MIIC\n-----END CERTIFICATE-----\n\n",
+		},
+		{
+			"Join",
+			[]Option{WithCatalogs(catalogs...), WithJoin("\n", true, StripFinalNewline)},
+			"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n\n-----BEGIN CERTIFICATE-----\n" +
+				"MIIC\n-----END CERTIFICATE-----",
+		},
+		{
+			"Catalogs Added In Order",
+			[]Option{WithCatalogs(catalogs[1]), WithCatalogs(catalogs[0]), WithJoin("", false, EnsureFinalNewline)},
+			"-----BEGIN CERTIFICATE-----\nMIIC\n-----END CERTIFICATE-----\n\n" +
+				"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var got []byte
+			for _, catalog := range NewOptions(d.opts...).Bundled() {
+				buf, err := catalog.Fetch(context.TODO())
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, buf...)
+			}
+
+			if diff := cmp.Diff(string(got), d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package order
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+type testRequest struct {
+	method string
+	uri    string
+	header http.Header
+	body   []byte
+}
+
+func testRecordServer(t *testing.T, got *testRequest) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		*got = testRequest{method: r.Method, uri: r.URL.RequestURI(), header: r.Header, body: body}
+		w.WriteHeader(http.StatusCreated)
+	}))
+}
+
+func TestGCSOrder_Order(t *testing.T) {
+	var got testRequest
+	srv := testRecordServer(t, &got)
+	defer srv.Close()
+
+	t.Setenv("STORAGE_EMULATOR_HOST", srv.URL)
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "test-token")
+
+	uri, err := uriapi.NewGCSURI("gcs://foo-bucket/pki/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewGCSOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.uri != "/upload/storage/v1/b/foo-bucket/o?uploadType=media&name=pki%2Fchain.crt" {
+		t.Errorf("Unexpected request URI: %s", got.uri)
+	}
+	if got.header.Get("Authorization") != "Bearer test-token" {
+		t.Errorf("Unexpected authorization header: %s", got.header.Get("Authorization"))
+	}
+	if diff := cmp.Diff(got.body, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestAzBlobOrder_Order(t *testing.T) {
+	var got testRequest
+	srv := testRecordServer(t, &got)
+	defer srv.Close()
+
+	t.Setenv("AZURE_STORAGE_BLOB_ENDPOINT", srv.URL+"/fooaccount")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=2021-08-06&sig=test")
+
+	uri, err := uriapi.NewAzBlobURI("azblob://fooaccount/pki/ca/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewAzBlobOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.method != http.MethodPut {
+		t.Errorf("Expected method is PUT but got: %s", got.method)
+	}
+	if got.uri != "/fooaccount/pki/ca/chain.crt?sv=2021-08-06&sig=test" {
+		t.Errorf("Unexpected request URI: %s", got.uri)
+	}
+	if got.header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+		t.Errorf("Unexpected blob type header: %s", got.header.Get("X-Ms-Blob-Type"))
+	}
+	if diff := cmp.Diff(got.body, want); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -1,32 +1,107 @@
 package order
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"runtime"
 
 	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+	"golang.org/x/sync/errgroup"
 )
 
-type Logger interface {
-	// Log about provided URI.
-	Log(uriText string)
-}
-
 // Catalog represents catalog of assets held by Private CA.
 type Catalog interface {
 	// Fetch retrieves data based on the information of its own URI.
 	Fetch(context.Context) ([]byte, error)
 }
 
+// fetchAll fetches the contents of the catalogs, and returns the contents
+// concatenated in order.
+func fetchAll(ctx context.Context, catalogs []Catalog) ([]byte, error) {
+	contents, err := fetchContents(ctx, catalogs)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Join(contents, nil), nil
+}
+
+// fetchContents fetches the contents of the catalogs concurrently, so a chain
+// built from several remote sources takes the time of the slowest one, and
+// returns them in the order of the catalogs. The other fetches are canceled
+// when any of them fails, and the first error is returned.
+func fetchContents(ctx context.Context, catalogs []Catalog) ([][]byte, error) {
+	contents := make([][]byte, len(catalogs))
+	if len(catalogs) == 1 {
+		buf, err := catalogs[0].Fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		contents[0] = buf
+		return contents, nil
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	for idx := range catalogs {
+		idx := idx
+		g.Go(func() error {
+			buf, err := catalogs[idx].Fetch(gCtx)
+			if err != nil {
+				return err
+			}
+			contents[idx] = buf
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return contents, nil
+}
+
+var (
+	ErrEntryNameMismatch   = errors.New("number of entry names does not match catalogs")
+	ErrEntryNameDuplicated = errors.New("entry name is duplicated")
+)
+
+// fetchEntries fetches the contents of the catalogs as the entries named by
+// the names, for the orders writing each catalog separately.
+func fetchEntries(ctx context.Context, catalogs []Catalog, names []string) ([][]byte, error) {
+	if len(names) != len(catalogs) {
+		return nil, ErrEntryNameMismatch
+	}
+
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s: %w", name, ErrEntryNameDuplicated)
+		}
+		seen[name] = struct{}{}
+	}
+
+	return fetchContents(ctx, catalogs)
+}
+
 // FSOrder implements the Order interface. It is responsible for
 // placing a CAAsset object in a specific location within the local file system,
 // identified by its unique URI path.
 type FSOrder struct {
 	uri      uriapi.FSURI
 	catalogs []Catalog
-	l        Logger
+	sealer   Sealer
+	fsys     FS
+	l        *slog.Logger
 }
 
 func NewFSOrder(uri uriapi.FSURI, catalogs []Catalog) *FSOrder {
@@ -38,59 +113,236 @@ func NewFSOrder(uri uriapi.FSURI, catalogs []Catalog) *FSOrder {
 	return order
 }
 
-func (f *FSOrder) Order(ctx context.Context) (err error) {
+// The Order function streams the contents to the temporary file, and replaces
+// the file atomically, so that the readers never observe the partial contents
+// and the previous file is left as it is if any catalog fails. The contents of
+// the StreamCatalogs are not held in memory. The mode of the previous file is
+// kept.
+func (f *FSOrder) Order(ctx context.Context) error {
 	if f.l != nil {
-		f.l.Log(f.uri.Text())
+		f.l.InfoContext(ctx, "ordering", "scheme", f.uri.Scheme(), "uri", f.uri.Text())
 	}
 
-	file, err := os.Create(f.uri.Path())
+	if f.sealer != nil {
+		return f.orderSealed(ctx)
+	}
+
+	r, err := openAll(ctx, f.catalogs)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		closeErr := file.Close()
-		if err == nil {
-			err = closeErr
-		}
-	}()
+	defer r.Close()
 
-	for idx := range f.catalogs {
-		var buf []byte
+	fsys := f.fs()
+	err = fsys.WriteFile(f.uri.Path(), r, fileMode(fsys, f.uri.Path(), 0o644))
+	var rErr readError
+	if errors.As(err, &rErr) {
+		return rErr.err
+	}
+	if err != nil {
+		return NewWriteError(f.uri.Text(), err)
+	}
 
-		buf, err := f.catalogs[idx].Fetch(ctx)
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		_, err = file.Write(buf)
-		if err != nil {
-			return err
-		}
+// orderSealed seals the contents as a whole before writing the file.
+func (f *FSOrder) orderSealed(ctx context.Context) error {
+	buf, err := fetchAll(ctx, f.catalogs)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := f.sealer.Seal(ctx, buf)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.uri.Path(), err)
+	}
+
+	err = f.fs().WriteFile(f.uri.Path(), bytes.NewReader(sealed), 0o600)
+	if err != nil {
+		return NewWriteError(f.uri.Text(), err)
 	}
 
 	return nil
 }
 
-func (f *FSOrder) WithLogger(l Logger) *FSOrder {
+// Unchanged reports whether the file already has the contents of the
+// catalogs, so the write can be skipped and its modification time is kept. The
+// sealed file is never unchanged, since the contents differ in each sealing.
+func (f *FSOrder) Unchanged(ctx context.Context) (bool, error) {
+	if f.sealer != nil {
+		return false, nil
+	}
+
+	buf, err := fetchAll(ctx, f.catalogs)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := f.fs().ReadFile(f.uri.Path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(current, buf), nil
+}
+
+func (f *FSOrder) WithLogger(l *slog.Logger) *FSOrder {
 	f.l = l
 	return f
 }
 
+// WithSealer makes the FSOrder seal the contents with the Sealer before
+// writing them to the file.
+func (f *FSOrder) WithSealer(s Sealer) *FSOrder {
+	f.sealer = s
+	return f
+}
+
+// WithFS makes the FSOrder write the file to the FS instead of the OS file
+// system.
+func (f *FSOrder) WithFS(fsys FS) *FSOrder {
+	f.fsys = fsys
+	return f
+}
+
+// fs returns the FS of the FSOrder, or the OS file system.
+func (f *FSOrder) fs() FS {
+	if f.fsys == nil {
+		return osFS{}
+	}
+
+	return f.fsys
+}
+
+// FS is the file system the FSOrder writes the file to, like the one in memory
+// of the tests.
+type FS interface {
+	// ReadFile reads the file of the name.
+	ReadFile(name string) ([]byte, error)
+	// Stat returns the FileInfo of the file of the name.
+	Stat(name string) (fs.FileInfo, error)
+	// WriteFile replaces the file of the name with the content of the reader.
+	// The file of the name must be kept if reading the content fails.
+	WriteFile(name string, r io.Reader, perm fs.FileMode) error
+}
+
+// osFS is the FS of the OS file system, which replaces the files atomically.
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) WriteFile(name string, r io.Reader, perm fs.FileMode) error {
+	return copyFileAtomic(name, r, perm)
+}
+
+// fileMode returns the permission of the file, or the perm if it does not
+// exist.
+func fileMode(fsys FS, name string, perm fs.FileMode) fs.FileMode {
+	info, err := fsys.Stat(name)
+	if err != nil {
+		return perm
+	}
+
+	return info.Mode().Perm()
+}
+
+// writeFileAtomic writes the data to the temporary file in the same directory,
+// and renames it to the name after flushing it to the storage. The directory is
+// flushed too, so the renaming survives a crash. The file of the name is never
+// truncated, so it is kept if the writing fails.
+func writeFileAtomic(name string, data []byte, perm fs.FileMode) error {
+	return copyFileAtomic(name, bytes.NewReader(data), perm)
+}
+
+// copyFileAtomic is the writeFileAtomic copying the content from the reader, so
+// the content is not held in memory. The file of the name is kept if reading
+// the content fails.
+func copyFileAtomic(name string, r io.Reader, perm fs.FileMode) (err error) {
+	tmp, err := os.CreateTemp(path.Dir(name), "."+path.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Chmod(perm)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), name)
+	if err != nil {
+		return err
+	}
+
+	return syncDir(path.Dir(name))
+}
+
+// syncDir flushes the entries of the directory to the storage. It does nothing
+// on Windows, where the directories cannot be opened to be flushed.
+func syncDir(name string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	dir, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
 // EnvOrder implements the Order interface. This is responsible for writing values in
 // the format of "export 'key'='value'" to its own file descriptors. It is specifically
 // designed to write to environment variables by saving and executing the written file.
+// The values are escaped for the shell, and the other formats are written with the
+// EnvWriter.
 type EnvOrder struct {
 	uri      uriapi.EnvURI
-	file     *os.File
+	w        *EnvWriter
 	catalogs []Catalog
-	l        Logger
+	base64   bool
+	l        *slog.Logger
 }
 
 func NewEnvOrder(uri uriapi.EnvURI, catalogs []Catalog, file *os.File) *EnvOrder {
 	order := &EnvOrder{
 		uri:      uri,
 		catalogs: catalogs,
-		file:     file,
+		w:        NewEnvWriter(file, ExportEnvFormat),
 	}
 
 	return order
@@ -98,26 +350,75 @@ func NewEnvOrder(uri uriapi.EnvURI, catalogs []Catalog, file *os.File) *EnvOrder
 
 func (e *EnvOrder) Order(ctx context.Context) error {
 	if e.l != nil {
-		e.l.Log(e.uri.Text())
+		e.l.InfoContext(ctx, "ordering", "scheme", e.uri.Scheme(), "uri", e.uri.Text())
 	}
 
-	var buf []byte
+	buf, err := fetchAll(ctx, e.catalogs)
+	if err != nil {
+		return err
+	}
 
-	for idx := range e.catalogs {
-		b, err := e.catalogs[idx].Fetch(ctx)
-		if err != nil {
-			return err
-		}
+	value := string(buf)
+	if e.base64 {
+		value = base64.StdEncoding.EncodeToString(buf)
+	}
+
+	return e.w.Set(e.uri.Path(), value)
+}
+
+func (e *EnvOrder) WithLogger(l *slog.Logger) *EnvOrder {
+	e.l = l
+	return e
+}
 
-		buf = append(buf, b...)
+// WithEnvWriter makes the EnvOrder write with the EnvWriter instead of the
+// file, in the format of the EnvWriter. The EnvWriter should be shared among
+// the orders writing to the same file.
+func (e *EnvOrder) WithEnvWriter(w *EnvWriter) *EnvOrder {
+	e.w = w
+	return e
+}
+
+// WithBase64 makes the EnvOrder write the value encoded in base64, so the
+// multi-line PEM contents are kept in a line.
+func (e *EnvOrder) WithBase64() *EnvOrder {
+	e.base64 = true
+	return e
+}
+
+// StdoutOrder implements the Order interface. It is responsible for writing
+// the concatenated contents of the catalogs to the standard output, so that
+// the contents can be passed to another command through a pipe.
+type StdoutOrder struct {
+	uri      uriapi.StdoutURI
+	w        io.Writer
+	catalogs []Catalog
+	l        *slog.Logger
+}
+
+func NewStdoutOrder(uri uriapi.StdoutURI, catalogs []Catalog, w io.Writer) *StdoutOrder {
+	order := &StdoutOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		w:        w,
 	}
 
-	nl := "\n"
-	if runtime.GOOS == "windows" {
-		nl = "\r\n"
+	return order
+}
+
+// Order writes the contents after all catalogs are fetched, so that nothing
+// is written to the writer when any fetch fails.
+func (s *StdoutOrder) Order(ctx context.Context) error {
+	if s.l != nil {
+		s.l.InfoContext(ctx, "ordering", "scheme", s.uri.Scheme(), "uri", s.uri.Text())
 	}
 
-	_, err := e.file.WriteString(fmt.Sprintf("export '%s'='%s'%s", e.uri.Path(), string(buf), nl))
+	buf, err := fetchAll(ctx, s.catalogs)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.w.Write(buf)
 	if err != nil {
 		return err
 	}
@@ -125,7 +426,7 @@ func (e *EnvOrder) Order(ctx context.Context) error {
 	return nil
 }
 
-func (e *EnvOrder) WithLogger(l Logger) *EnvOrder {
-	e.l = l
-	return e
+func (s *StdoutOrder) WithLogger(l *slog.Logger) *StdoutOrder {
+	s.l = l
+	return s
 }
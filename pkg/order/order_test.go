@@ -1,13 +1,20 @@
 package order
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path"
 	"reflect"
 	"runtime"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/yuxki/cannect/pkg/asset"
@@ -75,6 +82,93 @@ func TestFSOrder_Order(t *testing.T) {
 	}
 }
 
+type testErrCatalog struct{}
+
+func (t testErrCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	return nil, errTestFetch
+}
+
+var errTestFetch = errors.New("test fetch error")
+
+func TestFSOrder_Order_Atomic(t *testing.T) {
+	t.Parallel()
+
+	dir := "testdata/TestFSOrder_Order_Atomic"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	dstP := path.Join(dir, "TestFSOrder_Order_Atomic.out")
+	uri, err := uriapi.NewFSURI(fmt.Sprintf("file://%s", dstP))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(dstP, []byte("previous"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalogs := []Catalog{testBytesCatalog("partial"), testErrCatalog{}}
+	err = NewFSOrder(uri, catalogs).Order(context.TODO())
+	if !errors.Is(err, errTestFetch) {
+		t.Fatalf("Expected %#v error but got: %#v", errTestFetch, err)
+	}
+
+	result, err := os.ReadFile(dstP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != "previous" {
+		t.Errorf("Expected previous content is kept but got: %s", result)
+	}
+
+	err = NewFSOrder(uri, []Catalog{testBytesCatalog("new")}).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = os.ReadFile(dstP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != "new" {
+		t.Errorf("Expected new content but got: %s", result)
+	}
+
+	info, err := os.Stat(dstP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode is kept but got: %s", info.Mode())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected temporary files are removed but got: %d files", len(entries))
+	}
+}
+
+func TestSyncDir(t *testing.T) {
+	t.Parallel()
+
+	err := syncDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = syncDir(path.Join(t.TempDir(), "missing"))
+	if runtime.GOOS != "windows" && !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected %#v error but got: %#v", fs.ErrNotExist, err)
+	}
+}
+
 func TestEnvOrder_Order(t *testing.T) {
 	t.Parallel()
 
@@ -118,3 +212,464 @@ func TestEnvOrder_Order(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+type testReverseSealer struct{}
+
+func (t testReverseSealer) Transform(content []byte) ([]byte, error) {
+	return t.Seal(context.TODO(), content)
+}
+
+func (t testReverseSealer) Seal(ctx context.Context, content []byte) ([]byte, error) {
+	sealed := make([]byte, len(content))
+	for i := range content {
+		sealed[len(content)-1-i] = content[i]
+	}
+	return sealed, nil
+}
+
+// testDirFS is the FS of the OS file system under the root directory, so the
+// tests write the files into their temporary directories.
+type testDirFS struct {
+	root string
+}
+
+func (t testDirFS) ReadFile(name string) ([]byte, error) {
+	return osFS{}.ReadFile(path.Join(t.root, name))
+}
+
+func (t testDirFS) Stat(name string) (fs.FileInfo, error) {
+	return osFS{}.Stat(path.Join(t.root, name))
+}
+
+func (t testDirFS) WriteFile(name string, r io.Reader, perm fs.FileMode) error {
+	return osFS{}.WriteFile(path.Join(t.root, name), r, perm)
+}
+
+func TestFSOrder_Order_WithSealer(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewFSURI("file://sealed/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	err = os.Mkdir(path.Join(dir, "sealed"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsOrder := NewFSOrder(uri, testGenCatalogs(t)).WithSealer(testReverseSealer{}).WithFS(testDirFS{root: dir})
+	err = fsOrder.Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := os.ReadFile(path.Join(dir, uri.Path()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := testReverseSealer{}.Seal(context.TODO(), chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(result, want); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestFSOrder_Unchanged(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		uriText  string
+		sealer   Sealer
+		// want
+		unchanged bool
+	}{
+		{"Unchanged", "file://testdata/chain.crt", nil, true},
+		{"Changed", "file://testdata/root-ca.crt", nil, false},
+		{"Missing", "file://testdata/TestFSOrder_Unchanged.out", nil, false},
+		{"Sealed", "file://testdata/chain.crt", testReverseSealer{}, false},
+	}
+
+	for _, d := range data {
+		uri, err := uriapi.NewFSURI(d.uriText)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fsOrder := NewFSOrder(uri, testGenCatalogs(t))
+		if d.sealer != nil {
+			fsOrder = fsOrder.WithSealer(d.sealer)
+		}
+
+		unchanged, err := fsOrder.Unchanged(context.TODO())
+		if err != nil {
+			t.Fatalf("%s: %v", d.testCase, err)
+		}
+		if unchanged != d.unchanged {
+			t.Errorf("%s: Expected unchanged %t but got: %t", d.testCase, d.unchanged, unchanged)
+		}
+	}
+}
+
+// testMapFS is the FS in memory. The file is written only when the content is
+// read completely.
+type testMapFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+func (t *testMapFS) ReadFile(name string) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return fs.ReadFile(t.files, name)
+}
+
+func (t *testMapFS) Stat(name string) (fs.FileInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return fs.Stat(t.files, name)
+}
+
+func (t *testMapFS) WriteFile(name string, r io.Reader, perm fs.FileMode) error {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.files[name] = &fstest.MapFile{Data: buf, Mode: perm}
+	return nil
+}
+
+func TestFSOrder_WithFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := &testMapFS{files: fstest.MapFS{
+		"pki/ca.crt": &fstest.MapFile{Data: []byte("previous"), Mode: 0o600},
+	}}
+
+	uri, err := uriapi.NewFSURI("file://pki/ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsOrder := NewFSOrder(uri, []Catalog{testBytesCatalog("root\n"), testBytesCatalog("sub\n")}).WithFS(fsys)
+	unchanged, err := fsOrder.Unchanged(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged {
+		t.Error("Expected changed but got unchanged")
+	}
+
+	err = fsOrder.Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := fsys.files["pki/ca.crt"]
+	if string(file.Data) != "root\nsub\n" {
+		t.Errorf("Expected concatenated contents but got: %q", file.Data)
+	}
+	if file.Mode != 0o600 {
+		t.Errorf("Expected mode is kept but got: %s", file.Mode)
+	}
+
+	unchanged, err = fsOrder.Unchanged(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !unchanged {
+		t.Error("Expected unchanged but got changed")
+	}
+
+	err = NewFSOrder(uri, []Catalog{testErrCatalog{}}).WithFS(fsys).Order(context.TODO())
+	if !errors.Is(err, errTestFetch) {
+		t.Fatalf("Expected %#v error but got: %#v", errTestFetch, err)
+	}
+	if string(fsys.files["pki/ca.crt"].Data) != "root\nsub\n" {
+		t.Errorf("Expected previous content is kept but got: %q", fsys.files["pki/ca.crt"].Data)
+	}
+}
+
+func TestStdoutOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewStdoutURI("stdout://")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	stdoutOrder := NewStdoutOrder(uri, testGenCatalogs(t), &buf)
+	err = stdoutOrder.Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(buf.Bytes(), want); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+type testErrChecker struct{}
+
+func (t testErrChecker) CheckContent(content []byte) error {
+	return errTestCheck
+}
+
+var errTestCheck = errors.New("test check error")
+
+func TestBundle_Fetch(t *testing.T) {
+	t.Parallel()
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := NewBundle(testGenCatalogs(t)).Fetch(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(buf, want); diff != "" {
+		t.Fatal(diff)
+	}
+
+	buf, err = NewBundle(testGenCatalogs(t)).WithTransformer(testReverseSealer{}).Fetch(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reversed, err := testReverseSealer{}.Seal(context.TODO(), want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(buf, reversed); diff != "" {
+		t.Fatal(diff)
+	}
+
+	_, err = NewBundle(testGenCatalogs(t)).WithChecker(testErrChecker{}).Fetch(context.TODO())
+	if !errors.Is(err, errTestCheck) {
+		t.Fatalf("Expected %#v error but got: %#v", errTestCheck, err)
+	}
+}
+
+func TestBundle_Join(t *testing.T) {
+	t.Parallel()
+
+	catalogs := []Catalog{
+		testBytesCatalog("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"),
+		testBytesCatalog("-----BEGIN CERTIFICATE-----\nMIIC\n-----END CERTIFICATE-----\n\n"),
+	}
+
+	data := []struct {
+		testCase string
+		bundle   *Bundle
+		want     string
+	}{
+		{
+			"Raw",
+			NewBundle(catalogs),
+			"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE----------BEGIN CERTIFICATE-----\n" +
+				"MIIC\n-----END CERTIFICATE-----\n\n",
+		},
+		{
+			"Asset Newline",
+			NewBundle(catalogs).WithAssetNewline(),
+			"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\n" +
+				"MIIC\n-----END CERTIFICATE-----\n\n",
+		},
+		{
+			"Separator And Strip Final Newline",
+			NewBundle(catalogs).WithAssetNewline().WithSeparator("\n").WithFinalNewline(StripFinalNewline),
+			"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n\n-----BEGIN CERTIFICATE-----\n" +
+				"MIIC\n-----END CERTIFICATE-----",
+		},
+		{
+			"Ensure Final Newline",
+			NewBundle(catalogs[:1]).WithFinalNewline(EnsureFinalNewline),
+			"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := d.bundle.Fetch(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(string(got), d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+type testCountCatalog struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (t *testCountCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.count++
+	return []byte("content"), nil
+}
+
+func TestSharedCatalog_Fetch(t *testing.T) {
+	t.Parallel()
+
+	counter := &testCountCatalog{}
+	shared := NewSharedCatalog(counter)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			buf, err := shared.Fetch(context.TODO())
+			if err != nil || string(buf) != "content" {
+				t.Errorf("Expected content but got: %s, %v", buf, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counter.count != 1 {
+		t.Errorf("Expected fetch count is 1 but got: %d", counter.count)
+	}
+}
+
+// testGateCatalog blocks the first fetch until its context is done.
+type testGateCatalog struct {
+	started chan struct{}
+	mu      sync.Mutex
+	count   int
+}
+
+func (t *testGateCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	t.mu.Lock()
+	t.count++
+	first := t.count == 1
+	t.mu.Unlock()
+
+	if first {
+		close(t.started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return []byte("content"), nil
+}
+
+func TestSharedCatalog_Fetch_Abandoned(t *testing.T) {
+	t.Parallel()
+
+	gate := &testGateCatalog{started: make(chan struct{})}
+	shared := NewSharedCatalog(gate)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := shared.Fetch(ctx)
+		first <- err
+	}()
+	<-gate.started
+
+	second := make(chan []byte, 1)
+	go func() {
+		buf, _ := shared.Fetch(context.Background())
+		second <- buf
+	}()
+	cancel()
+
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %#v error but got: %#v", context.Canceled, err)
+	}
+	if buf := <-second; string(buf) != "content" {
+		t.Errorf("Expected content fetched again but got: %s", buf)
+	}
+}
+
+// testBarrierCatalog returns the content after all catalogs of the barrier
+// start fetching, so the fetches never complete if they are sequential.
+type testBarrierCatalog struct {
+	content string
+	barrier *sync.WaitGroup
+}
+
+func (t testBarrierCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	t.barrier.Done()
+
+	done := make(chan struct{})
+	go func() {
+		t.barrier.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return []byte(t.content), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestFetchContents(t *testing.T) {
+	t.Parallel()
+
+	barrier := new(sync.WaitGroup)
+	barrier.Add(3)
+	catalogs := []Catalog{
+		testBarrierCatalog{"a", barrier}, testBarrierCatalog{"b", barrier}, testBarrierCatalog{"c", barrier},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	got, err := NewBundle(catalogs).Fetch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(got), "abc"); diff != "" {
+		t.Error(diff)
+	}
+
+	// The other fetches are canceled by the failure.
+	barrier = new(sync.WaitGroup)
+	barrier.Add(2)
+	_, err = fetchAll(ctx, []Catalog{testBarrierCatalog{"a", barrier}, testErrCatalog{}})
+	if !errors.Is(err, errTestFetch) {
+		t.Errorf("Expected %#v error but got: %#v", errTestFetch, err)
+	}
+}
@@ -0,0 +1,76 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/yuxki/cannect/pkg/plugin"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// PluginOrder implements the Order interface. It is responsible for handing
+// the concatenated contents of the catalogs to the external program of the
+// plugin, speaking the protocol of the plugin package.
+type PluginOrder struct {
+	uri      uriapi.PluginURI
+	catalogs []Catalog
+	program  string
+	stderr   io.Writer
+	l        *slog.Logger
+}
+
+func NewPluginOrder(uri uriapi.PluginURI, catalogs []Catalog) *PluginOrder {
+	order := &PluginOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		program:  plugin.Program(uri.Name()),
+	}
+
+	return order
+}
+
+// The Order function fetches all contents before starting the program, and
+// runs it with the order request.
+func (p *PluginOrder) Order(ctx context.Context) error {
+	if p.l != nil {
+		p.l.InfoContext(ctx, "ordering", "scheme", p.uri.Scheme(), "uri", p.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, p.catalogs)
+	if err != nil {
+		return err
+	}
+
+	_, err = plugin.Call(ctx, p.program, plugin.Request{
+		Operation: plugin.OrderOperation,
+		URI:       p.uri.Text(),
+		Path:      p.uri.Path(),
+		Content:   buf,
+	}, p.stderr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.uri.Text(), err)
+	}
+
+	return nil
+}
+
+// WithProgram makes the PluginOrder run the program, like the absolute path,
+// instead of the one of the plugin in PATH.
+func (p *PluginOrder) WithProgram(program string) *PluginOrder {
+	p.program = program
+	return p
+}
+
+// WithStderr sets the writer of the standard error of the program. It is
+// discarded by default.
+func (p *PluginOrder) WithStderr(w io.Writer) *PluginOrder {
+	p.stderr = w
+	return p
+}
+
+func (p *PluginOrder) WithLogger(l *slog.Logger) *PluginOrder {
+	p.l = l
+	return p
+}
@@ -0,0 +1,80 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/pkg/plugin"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestPluginOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("sh is not available")
+	}
+
+	dir := path.Join("testdata", "TestPluginOrder_Order")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := path.Join(dir, "request.json")
+	program := path.Join(dir, plugin.Program("portal"))
+	failing := path.Join(dir, plugin.Program("failing"))
+	for name, script := range map[string]string{
+		program: "cat > " + out + `; echo '{}'`,
+		failing: `cat > /dev/null; echo '{"error":"403 Forbidden"}'`,
+	} {
+		err = os.WriteFile(name, []byte("#!/bin/sh\n"+script+"\n"), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	uri, err := uriapi.NewPluginURI("plugin://portal/ca/chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewPluginOrder(uri, testGenCatalogs(t)).WithProgram("./" + program).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got plugin.Request
+	err = json.Unmarshal(b, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Operation != plugin.OrderOperation || got.Path != "ca/chain" {
+		t.Errorf("Expected the order of ca/chain but got: %s %s", got.Operation, got.Path)
+	}
+	if diff := cmp.Diff(string(got.Content), string(want)); diff != "" {
+		t.Error(diff)
+	}
+
+	err = NewPluginOrder(uri, testGenCatalogs(t)).WithProgram("./" + failing).Order(context.TODO())
+	if !errors.Is(err, plugin.ErrPlugin) {
+		t.Errorf("Expected %#v error but got: %#v", plugin.ErrPlugin, err)
+	}
+}
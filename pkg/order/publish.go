@@ -0,0 +1,68 @@
+package order
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+)
+
+var (
+	ErrBrokerRefused    = errors.New("connection is refused by the broker")
+	ErrUnexpectedPacket = errors.New("unexpected packet from the broker")
+)
+
+// Notification is published instead of the content in the notification mode
+// of the MQTTOrder and the NATSOrder, so that the devices are notified of the
+// change and pull the content from the URL by themselves.
+type Notification struct {
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+	URL    string `json:"url,omitempty"`
+}
+
+// publishPayload returns the content itself, or the notification of it if
+// notify is true.
+func publishPayload(buf []byte, notify bool, url string) ([]byte, error) {
+	if !notify {
+		return buf, nil
+	}
+
+	sum := sha256.Sum256(buf)
+	return json.Marshal(Notification{SHA256: hex.EncodeToString(sum[:]), Size: len(buf), URL: url})
+}
+
+// dialBroker connects to the "<host>[:<port>]" with the default port, and
+// with TLS if the config is not nil. The deadline of the connection is the
+// one of the context.
+func dialBroker(ctx context.Context, host, defaultPort string, config *tls.Config) (net.Conn, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, defaultPort)
+	}
+
+	var conn net.Conn
+	var err error
+	if config != nil {
+		d := tls.Dialer{Config: config}
+		conn, err = d.DialContext(ctx, "tcp", host)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,287 @@
+package order
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+// mqttPublication is the PUBLISH packet received by the test broker.
+type mqttPublication struct {
+	header  byte
+	topic   string
+	payload string
+}
+
+// testMQTTBroker accepts a connection, and returns the publication of it. The
+// CONNACK is returned with the return code.
+func testMQTTBroker(t *testing.T, rc byte) (string, <-chan mqttPublication) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	received := make(chan mqttPublication, 1)
+	go func() {
+		defer close(received)
+
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		typ, _, err := readMQTTPacket(r)
+		if err != nil || typ != mqttConnect {
+			return
+		}
+		_, err = conn.Write([]byte{mqttConnAck, 2, 0, rc})
+		if err != nil || rc != 0 {
+			return
+		}
+
+		header, err := r.Peek(1)
+		if err != nil {
+			return
+		}
+		pubHeader := header[0]
+
+		_, body, err := readMQTTPacket(r)
+		if err != nil || len(body) < 2 {
+			return
+		}
+		n := int(body[0])<<8 | int(body[1])
+		pub := mqttPublication{header: pubHeader, topic: string(body[2 : 2+n])}
+		body = body[2+n:]
+		if pubHeader&0x06 != 0 {
+			_, err = conn.Write(append([]byte{mqttPubAck, 2}, body[:2]...))
+			if err != nil {
+				return
+			}
+			body = body[2:]
+		}
+		pub.payload = string(body)
+
+		typ, _, err = readMQTTPacket(r)
+		if err == nil && typ == mqttDisconnect {
+			received <- pub
+		}
+	}()
+
+	return l.Addr().String(), received
+}
+
+func TestMQTTOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(want)
+
+	data := []struct {
+		testCase string
+		qos      byte
+		retain   bool
+		notify   bool
+		rc       byte
+		header   byte
+		payload  string
+		err      error
+	}{
+		{"OK:content", 0, false, false, 0, 0x30, string(want), nil},
+		{
+			"OK:notification",
+			1,
+			true,
+			true,
+			0,
+			0x33,
+			fmt.Sprintf(`{"sha256":"%s","size":%d,"url":"https://ca.example.com/chain.crt"}`, hex.EncodeToString(sum[:]), len(want)),
+			nil,
+		},
+		{"NG:refused", 0, false, false, 5, 0, "", ErrBrokerRefused},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			addr, received := testMQTTBroker(t, d.rc)
+			uri, err := uriapi.NewMQTTURI("mqtt://" + addr + "/fleet/ca")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			order := NewMQTTOrder(uri, testGenCatalogs(t)).WithQoS(d.qos).WithRetain(d.retain)
+			if d.notify {
+				order = order.WithNotification("https://ca.example.com/chain.crt")
+			}
+
+			err = order.Order(context.TODO())
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			got := <-received
+			if diff := cmp.Diff(
+				[]interface{}{got.header, got.topic, got.payload},
+				[]interface{}{d.header, "fleet/ca", d.payload},
+			); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// testNATSServer accepts a connection, and returns the payload published to
+// the subject. The PING after the publication is answered with the reply.
+func testNATSServer(t *testing.T, maxPayload int, reply string) (string, <-chan string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		defer close(received)
+
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, err = fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"max_payload\":%d}\r\n", maxPayload)
+		if err != nil {
+			return
+		}
+
+		r := bufio.NewReader(conn)
+		var payload string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 3 && fields[0] == "PUB":
+				n, err := strconv.Atoi(fields[2])
+				if err != nil {
+					return
+				}
+				buf := make([]byte, n+2)
+				_, err = io.ReadFull(r, buf)
+				if err != nil {
+					return
+				}
+				payload = fields[1] + " " + string(buf[:n])
+			case len(fields) == 1 && fields[0] == "PING":
+				_, err = conn.Write([]byte(reply + "\r\n"))
+				if err == nil {
+					received <- payload
+				}
+				return
+			}
+		}
+	}()
+
+	return l.Addr().String(), received
+}
+
+func TestNATSOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase   string
+		notify     bool
+		maxPayload int
+		reply      string
+		err        error
+	}{
+		{"OK:content", false, 1048576, "PONG", nil},
+		{"OK:notification", true, 1048576, "PONG", nil},
+		{"NG:too large", false, 10, "PONG", ErrContentTooLarge},
+		{"NG:server error", false, 1048576, "-ERR 'Permissions Violation for Publish to fleet.ca'", ErrServerError},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			addr, received := testNATSServer(t, d.maxPayload, d.reply)
+			uri, err := uriapi.NewNATSURI("nats://" + addr + "/fleet.ca")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			order := NewNATSOrder(uri, testGenCatalogs(t))
+			if d.notify {
+				order = order.WithNotification("")
+			}
+
+			err = order.Order(context.TODO())
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			subject, payload, _ := strings.Cut(<-received, " ")
+			if subject != "fleet.ca" {
+				t.Errorf("Expected the subject fleet.ca but got: %s", subject)
+			}
+
+			if !d.notify {
+				if diff := cmp.Diff(payload, string(want)); diff != "" {
+					t.Error(diff)
+				}
+				return
+			}
+
+			var notification Notification
+			err = json.Unmarshal([]byte(payload), &notification)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum := sha256.Sum256(want)
+			if diff := cmp.Diff(notification, Notification{SHA256: hex.EncodeToString(sum[:]), Size: len(want)}); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package order
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	errNoUpdateServer = errors.New("DNS_UPDATE_SERVER is not set")
+	errDNSMessage     = errors.New("invalid DNS message")
+)
+
+const (
+	dnsTypeSOA  uint16 = 6
+	dnsTypeTSIG uint16 = 250
+
+	dnsClassIN  uint16 = 1
+	dnsClassANY uint16 = 255
+
+	dnsOpcodeUpdate uint16 = 5
+
+	tsigAlgorithm = "hmac-sha256."
+	tsigFudge     = 300
+)
+
+var dnsRcodes = map[uint16]string{
+	1:  "FORMERR",
+	2:  "SERVFAIL",
+	3:  "NXDOMAIN",
+	4:  "NOTIMP",
+	5:  "REFUSED",
+	6:  "YXDOMAIN",
+	7:  "YXRRSET",
+	8:  "NXRRSET",
+	9:  "NOTAUTH",
+	10: "NOTZONE",
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// appendDNSName appends the name in the wire format. The name is always
+// treated as a fully qualified domain name.
+func appendDNSName(b []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if label == "" || len(label) > 63 {
+				return nil, fmt.Errorf("%s: %w", name, errDNSMessage)
+			}
+			b = append(b, byte(len(label)))
+			b = append(b, label...)
+		}
+	}
+
+	return append(b, 0), nil
+}
+
+// updateMessage returns the message that replaces the record set with the
+// records.
+func (d *DNSOrder) updateMessage(id uint16, records []dnsRecord) ([]byte, error) {
+	msg := appendUint16(nil, id)
+	msg = appendUint16(msg, dnsOpcodeUpdate<<11)
+	msg = appendUint16(msg, 1)                      // ZOCOUNT
+	msg = appendUint16(msg, 0)                      // PRCOUNT
+	msg = appendUint16(msg, uint16(len(records)+1)) // UPCOUNT
+	msg = appendUint16(msg, 0)                      // ADCOUNT
+
+	// Zone section
+	msg, err := appendDNSName(msg, d.uri.Zone())
+	if err != nil {
+		return nil, err
+	}
+	msg = appendUint16(msg, dnsTypeSOA)
+	msg = appendUint16(msg, dnsClassIN)
+
+	// Delete the record set
+	msg, err = appendDNSName(msg, d.uri.Name())
+	if err != nil {
+		return nil, err
+	}
+	msg = appendUint16(msg, d.rrtype())
+	msg = appendUint16(msg, dnsClassANY)
+	msg = appendUint32(msg, 0)
+	msg = appendUint16(msg, 0)
+
+	// Add the records
+	for _, r := range records {
+		msg, err = appendDNSName(msg, d.uri.Name())
+		if err != nil {
+			return nil, err
+		}
+		msg = appendUint16(msg, d.rrtype())
+		msg = appendUint16(msg, dnsClassIN)
+		msg = appendUint32(msg, d.ttl)
+		msg = appendUint16(msg, uint16(len(r.rdata)))
+		msg = append(msg, r.rdata...)
+	}
+
+	return msg, nil
+}
+
+// signTSIG appends the TSIG record to the message.
+func signTSIG(msg []byte, keyName string, secret []byte, now time.Time) ([]byte, error) {
+	name, err := appendDNSName(nil, strings.ToLower(keyName))
+	if err != nil {
+		return nil, err
+	}
+	alg, err := appendDNSName(nil, tsigAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := uint64(now.Unix())
+	timeSigned := []byte{
+		byte(signed >> 40), byte(signed >> 32), byte(signed >> 24),
+		byte(signed >> 16), byte(signed >> 8), byte(signed),
+	}
+
+	// TSIG variables
+	vars := append([]byte{}, name...)
+	vars = appendUint16(vars, dnsClassANY)
+	vars = appendUint32(vars, 0)
+	vars = append(vars, alg...)
+	vars = append(vars, timeSigned...)
+	vars = appendUint16(vars, tsigFudge)
+	vars = appendUint16(vars, 0) // Error
+	vars = appendUint16(vars, 0) // Other Len
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(msg)
+	mac.Write(vars)
+	sum := mac.Sum(nil)
+
+	rdata := append([]byte{}, alg...)
+	rdata = append(rdata, timeSigned...)
+	rdata = appendUint16(rdata, tsigFudge)
+	rdata = appendUint16(rdata, uint16(len(sum)))
+	rdata = append(rdata, sum...)
+	rdata = append(rdata, msg[0], msg[1]) // Original ID
+	rdata = appendUint16(rdata, 0)        // Error
+	rdata = appendUint16(rdata, 0)        // Other Len
+
+	signedMsg := append([]byte{}, msg...)
+	binary.BigEndian.PutUint16(signedMsg[10:12], binary.BigEndian.Uint16(msg[10:12])+1)
+	signedMsg = append(signedMsg, name...)
+	signedMsg = appendUint16(signedMsg, dnsTypeTSIG)
+	signedMsg = appendUint16(signedMsg, dnsClassANY)
+	signedMsg = appendUint32(signedMsg, 0)
+	signedMsg = appendUint16(signedMsg, uint16(len(rdata)))
+	signedMsg = append(signedMsg, rdata...)
+
+	return signedMsg, nil
+}
+
+// updateRFC2136 sends the dynamic update to the server over TCP.
+func (d *DNSOrder) updateRFC2136(ctx context.Context, records []dnsRecord) error {
+	server := os.Getenv("DNS_UPDATE_SERVER")
+	if server == "" {
+		return errNoUpdateServer
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	var idBuf [2]byte
+	if _, err := rand.Read(idBuf[:]); err != nil {
+		return err
+	}
+	id := binary.BigEndian.Uint16(idBuf[:])
+
+	msg, err := d.updateMessage(id, records)
+	if err != nil {
+		return err
+	}
+
+	if keyName := os.Getenv("DNS_TSIG_KEY_NAME"); keyName != "" {
+		secret, err := base64.StdEncoding.DecodeString(os.Getenv("DNS_TSIG_SECRET"))
+		if err != nil {
+			return err
+		}
+
+		msg, err = signTSIG(msg, keyName, secret, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = conn.Write(append(appendUint16(nil, uint16(len(msg))), msg...))
+	if err != nil {
+		return err
+	}
+
+	var respLen [2]byte
+	_, err = io.ReadFull(conn, respLen[:])
+	if err != nil {
+		return err
+	}
+
+	resp := make([]byte, binary.BigEndian.Uint16(respLen[:]))
+	_, err = io.ReadFull(conn, resp)
+	if err != nil {
+		return err
+	}
+
+	if len(resp) < 12 || binary.BigEndian.Uint16(resp[0:2]) != id {
+		return errDNSMessage
+	}
+
+	rcode := binary.BigEndian.Uint16(resp[2:4]) & 0x000f
+	if rcode != 0 {
+		reason, ok := dnsRcodes[rcode]
+		if !ok {
+			reason = fmt.Sprintf("RCODE%d", rcode)
+		}
+		return WriteError{uri: d.uri.Text(), reason: reason}
+	}
+
+	return nil
+}
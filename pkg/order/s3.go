@@ -0,0 +1,123 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // The ETag of the object is the MD5.
+	"encoding/hex"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// S3Order implements the Order interface. It is responsible for writing the
+// concatenated contents of the catalogs to an object in AWS S3.
+type S3Order struct {
+	uri      uriapi.S3URI
+	catalogs []Catalog
+	inv      Invalidator
+	paths    []string
+	l        *slog.Logger
+	opts     []func(*s3.Options)
+}
+
+func NewS3Order(uri uriapi.S3URI, catalogs []Catalog) *S3Order {
+	order := &S3Order{
+		uri:      uri,
+		catalogs: catalogs,
+	}
+
+	return order
+}
+
+// The Order function utilizes the PutObject API in AWS S3. It requires the
+// usage of an environment variable "AWS_ACCESS_KEY_ID" and
+// "AWS_SECRET_ACCESS_KEY", "AWS_DEFAULT_REGION", to authorize the request.
+func (s *S3Order) Order(ctx context.Context) error {
+	if s.l != nil {
+		s.l.InfoContext(ctx, "ordering", "scheme", s.uri.Scheme(), "uri", s.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, s.catalogs)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	client := s3.NewFromConfig(cfg, s.opts...)
+
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.uri.Bucket()),
+		Key:    aws.String(s.uri.Key()),
+		Body:   bytes.NewReader(buf),
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.inv != nil {
+		return invalidate(ctx, s.inv, s.paths, "/"+s.uri.Key(), s.uri.Text())
+	}
+
+	return nil
+}
+
+// Unchanged reports whether the object already has the contents of the
+// catalogs, by comparing its ETag with the MD5 of the contents, so the write
+// can be skipped. The ETag of the object uploaded in parts, or encrypted with
+// KMS, is not the MD5, so the object is written. It requires the permission of
+// s3:GetObject.
+func (s *S3Order) Unchanged(ctx context.Context) (bool, error) {
+	buf, err := fetchAll(ctx, s.catalogs)
+	if err != nil {
+		return false, err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	client := s3.NewFromConfig(cfg, s.opts...)
+
+	output, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.uri.Bucket()),
+		Key:    aws.String(s.uri.Key()),
+	})
+	if err != nil {
+		// The missing object, and the one not allowed to read, are written.
+		return false, nil
+	}
+
+	sum := md5.Sum(buf)
+	return aws.ToString(output.ETag) == `"`+hex.EncodeToString(sum[:])+`"`, nil
+}
+
+func (s *S3Order) WithLogger(l *slog.Logger) *S3Order {
+	s.l = l
+	return s
+}
+
+// WithOptions makes the S3Order create the client with the options of the AWS
+// SDK, like the endpoint and the path-style addressing of the S3-compatible
+// object stores.
+func (s *S3Order) WithOptions(optFns ...func(*s3.Options)) *S3Order {
+	s.opts = optFns
+	return s
+}
+
+// WithInvalidator makes the S3Order invalidate the cached contents in a CDN after
+// writing. The paths are invalidated, or the path of the object if they are
+// not specified.
+func (s *S3Order) WithInvalidator(inv Invalidator, paths ...string) *S3Order {
+	s.inv = inv
+	s.paths = paths
+	return s
+}
@@ -0,0 +1,17 @@
+package order
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSealNotSupported is an error that should be used when the platform does
+// not support sealing the contents.
+var ErrSealNotSupported = errors.New("sealing is not supported on this platform")
+
+// Sealer encrypts the contents so that they can be decrypted only on the host
+// that sealed them.
+type Sealer interface {
+	// Seal returns the encrypted contents.
+	Seal(ctx context.Context, content []byte) ([]byte, error)
+}
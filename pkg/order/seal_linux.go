@@ -0,0 +1,55 @@
+//go:build linux
+
+package order
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// TPMSealer implements the Sealer interface. It encrypts the contents with a
+// key resident in the TPM2 of the host using "systemd-creds", so the contents
+// at rest are bound to the host. The sealed file can be decrypted by
+// "systemd-creds decrypt --name=<name>" or "LoadCredentialEncrypted=" of systemd.
+type TPMSealer struct {
+	name string
+	// exec runs the command of the argv with the stdin, and returns its stdout.
+	exec func(ctx context.Context, argv []string, stdin []byte) ([]byte, error)
+}
+
+func NewTPMSealer(name string) TPMSealer {
+	return TPMSealer{name: name, exec: execCommand}
+}
+
+func (t TPMSealer) Seal(ctx context.Context, content []byte) ([]byte, error) {
+	return t.exec(ctx, []string{
+		"systemd-creds", "encrypt", "--with-key=tpm2", fmt.Sprintf("--name=%s", t.name), "-", "-",
+	}, content)
+}
+
+// execCommand runs the command of the argv found in the PATH, and returns its
+// stdout. The stderr is added to the error if the command fails.
+func execCommand(ctx context.Context, argv []string, stdin []byte) ([]byte, error) {
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", argv[0], ErrSealNotSupported)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	// #nosec G204 -- the arguments are passed as they are, not through a shell.
+	cmd := exec.CommandContext(ctx, path, argv[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,49 @@
+//go:build linux
+
+package order
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTPMSealer_Seal(t *testing.T) {
+	t.Parallel()
+
+	var gotArgv []string
+	var gotStdin []byte
+
+	sealer := NewTPMSealer("server.key")
+	sealer.exec = func(ctx context.Context, argv []string, stdin []byte) ([]byte, error) {
+		gotArgv, gotStdin = argv, stdin
+		return []byte("sealed"), nil
+	}
+
+	sealed, err := sealer.Seal(context.TODO(), []byte("content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sealed) != "sealed" {
+		t.Errorf("Expected the stdout of the command but got: %q", sealed)
+	}
+
+	wantArgv := []string{"systemd-creds", "encrypt", "--with-key=tpm2", "--name=server.key", "-", "-"}
+	if diff := cmp.Diff(gotArgv, wantArgv); diff != "" {
+		t.Error(diff)
+	}
+	if string(gotStdin) != "content" {
+		t.Errorf("Expected the content in the stdin but got: %q", gotStdin)
+	}
+}
+
+func TestTPMSealer_Seal_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := execCommand(context.TODO(), []string{"cannect-missing-command"}, nil)
+	if !errors.Is(err, ErrSealNotSupported) {
+		t.Fatalf("Expected %#v error but got: %#v", ErrSealNotSupported, err)
+	}
+}
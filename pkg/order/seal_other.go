@@ -0,0 +1,20 @@
+//go:build !linux
+
+package order
+
+import (
+	"context"
+)
+
+// TPMSealer implements the Sealer interface. It is supported only on Linux.
+type TPMSealer struct {
+	name string
+}
+
+func NewTPMSealer(name string) TPMSealer {
+	return TPMSealer{name: name}
+}
+
+func (t TPMSealer) Seal(ctx context.Context, content []byte) ([]byte, error) {
+	return nil, ErrSealNotSupported
+}
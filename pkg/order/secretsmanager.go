@@ -0,0 +1,84 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/yuxki/cannect/internal/awsjson"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// SecretsManagerOrder implements the Order interface. It is responsible for
+// writing the concatenated contents of the catalogs to a secret in AWS
+// Secrets Manager. The secret is created if it does not exist.
+type SecretsManagerOrder struct {
+	uri      uriapi.SecretsManagerURI
+	catalogs []Catalog
+	http     *http.Client
+	l        *slog.Logger
+}
+
+func NewSecretsManagerOrder(uri uriapi.SecretsManagerURI, catalogs []Catalog) *SecretsManagerOrder {
+	order := &SecretsManagerOrder{
+		uri:      uri,
+		catalogs: catalogs,
+	}
+
+	return order
+}
+
+// The Order function utilizes the PutSecretValue API in AWS Secrets Manager,
+// and the CreateSecret API when the secret is not found. It requires the
+// usage of an environment variable "AWS_ACCESS_KEY_ID" and
+// "AWS_SECRET_ACCESS_KEY", "AWS_DEFAULT_REGION", to authorize the request.
+func (s *SecretsManagerOrder) Order(ctx context.Context) error {
+	if s.l != nil {
+		s.l.InfoContext(ctx, "ordering", "scheme", s.uri.Scheme(), "uri", s.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, s.catalogs)
+	if err != nil {
+		return err
+	}
+
+	client, err := awsjson.NewClient(ctx, "secretsmanager", "secretsmanager", s.http)
+	if err != nil {
+		return err
+	}
+
+	token, err := newClientRequestToken()
+	if err != nil {
+		return err
+	}
+
+	err = client.Call(ctx, "PutSecretValue", map[string]string{
+		"SecretId":           s.uri.Path(),
+		"SecretString":       string(buf),
+		"ClientRequestToken": token,
+	}, nil)
+
+	var apiErr awsjson.APIError
+	if !errors.As(err, &apiErr) || !apiErr.HasCode("ResourceNotFoundException") {
+		return err
+	}
+
+	return client.Call(ctx, "CreateSecret", map[string]string{
+		"Name":               s.uri.Path(),
+		"SecretString":       string(buf),
+		"ClientRequestToken": token,
+	}, nil)
+}
+
+func (s *SecretsManagerOrder) WithLogger(l *slog.Logger) *SecretsManagerOrder {
+	s.l = l
+	return s
+}
+
+// WithHTTPClient makes the SecretsManagerOrder call the API with the client, like the
+// one with the proxy or the CA of the destination.
+func (s *SecretsManagerOrder) WithHTTPClient(c *http.Client) *SecretsManagerOrder {
+	s.http = c
+	return s
+}
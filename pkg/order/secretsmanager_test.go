@@ -0,0 +1,71 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestSecretsManagerOrder_Order(t *testing.T) {
+	var targets []string
+	var created map[string]string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")
+		targets = append(targets, target)
+
+		switch target {
+		case "secretsmanager.PutSecretValue":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+		case "secretsmanager.CreateSecret":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_DEFAULT_REGION", "us-east-1")
+
+	uri, err := uriapi.NewSecretsManagerURI("secretsmanager://prod/tls/chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewSecretsManagerOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(targets, []string{
+		"secretsmanager.PutSecretValue", "secretsmanager.CreateSecret",
+	}); diff != "" {
+		t.Error(diff)
+	}
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created["Name"] != "prod/tls/chain" {
+		t.Errorf("Expected name is prod/tls/chain but got: %s", created["Name"])
+	}
+	if diff := cmp.Diff(created["SecretString"], string(want)); diff != "" {
+		t.Error(diff)
+	}
+}
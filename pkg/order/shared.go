@@ -0,0 +1,126 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// SharedCatalog implements the Catalog interface. It fetches the contents of
+// the catalog only once, and shares them with all the orders, so the contents
+// can be fanned out to several destinations, or reused by the orders of the
+// same catalog, without fetching again.
+type SharedCatalog struct {
+	catalog Catalog
+	mu      sync.Mutex
+	fetch   *sharedFetch
+	stream  bool
+	size    int
+	sized   bool
+}
+
+// sharedFetch is the fetch in progress or done. The abandoned is set if the
+// fetch is stopped by the context of its caller.
+type sharedFetch struct {
+	done      chan struct{}
+	buf       []byte
+	err       error
+	abandoned bool
+}
+
+func NewSharedCatalog(catalog Catalog) *SharedCatalog {
+	return &SharedCatalog{catalog: catalog}
+}
+
+// Fetch fetches the contents with the context of the first call. The other
+// calls wait for it and get the same contents or error. If the context of the
+// first call is done, like the timeout of its order, the waiting call fetches
+// again with its own context instead of getting the error of the other.
+func (s *SharedCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	for {
+		s.mu.Lock()
+		f := s.fetch
+		if f == nil {
+			f = &sharedFetch{done: make(chan struct{})}
+			s.fetch = f
+			s.mu.Unlock()
+
+			f.buf, f.err = s.catalog.Fetch(ctx)
+			if f.err != nil && ctx.Err() != nil {
+				f.abandoned = true
+				s.mu.Lock()
+				s.fetch = nil
+				s.mu.Unlock()
+			}
+			close(f.done)
+
+			return f.buf, f.err
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-f.done:
+		}
+
+		if !f.abandoned {
+			return f.buf, f.err
+		}
+	}
+}
+
+// WithStream makes the SharedCatalog stream the content of the streaming
+// catalog on Open, instead of fetching and sharing it. It is for the catalog
+// read by a single order, since the streamed content is not held to share it,
+// and every Open reads it again.
+func (s *SharedCatalog) WithStream() *SharedCatalog {
+	s.stream = true
+	return s
+}
+
+// Open streams the content if the SharedCatalog streams, and the content is
+// not fetched yet, like the contents compared with the destination before the
+// order. The size of the content read to the end is kept for Size. Otherwise,
+// the content is fetched once, like Fetch.
+func (s *SharedCatalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	s.mu.Lock()
+	fetched := s.fetch != nil
+	s.mu.Unlock()
+
+	if !s.Streams() || fetched {
+		buf, err := s.Fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}
+
+	r, err := Open(ctx, s.catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	return OnReadEnd(r, func(n int, err error) error {
+		if err == nil {
+			s.mu.Lock()
+			s.size, s.sized = n, true
+			s.mu.Unlock()
+		}
+		return err
+	}), nil
+}
+
+func (s *SharedCatalog) Streams() bool {
+	return s.stream && Streams(s.catalog)
+}
+
+// streamedSize returns the size of the content streamed to the end.
+func (s *SharedCatalog) streamedSize() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size, s.sized
+}
@@ -0,0 +1,77 @@
+package order
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/yuxki/cannect/internal/awsjson"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+// SSMOrder implements the Order interface. It is responsible for writing the
+// concatenated contents of the catalogs to a SecureString parameter in AWS
+// Systems Manager Parameter Store.
+type SSMOrder struct {
+	uri      uriapi.SSMURI
+	catalogs []Catalog
+	http     *http.Client
+	l        *slog.Logger
+}
+
+func NewSSMOrder(uri uriapi.SSMURI, catalogs []Catalog) *SSMOrder {
+	order := &SSMOrder{
+		uri:      uri,
+		catalogs: catalogs,
+	}
+
+	return order
+}
+
+// The Order function utilizes the PutParameter API in AWS Systems Manager.
+// The parameter is overwritten if it exists, and encrypted with the KMS key
+// of the URI, or the AWS managed key if it is not specified. The
+// "Intelligent-Tiering" tier is used, since certificate chains may exceed the
+// size limit of the standard tier. It requires the usage of an environment
+// variable "AWS_ACCESS_KEY_ID" and "AWS_SECRET_ACCESS_KEY",
+// "AWS_DEFAULT_REGION", to authorize the request.
+func (s *SSMOrder) Order(ctx context.Context) error {
+	if s.l != nil {
+		s.l.InfoContext(ctx, "ordering", "scheme", s.uri.Scheme(), "uri", s.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, s.catalogs)
+	if err != nil {
+		return err
+	}
+
+	client, err := awsjson.NewClient(ctx, "ssm", "AmazonSSM", s.http)
+	if err != nil {
+		return err
+	}
+
+	input := map[string]interface{}{
+		"Name":      s.uri.Path(),
+		"Value":     string(buf),
+		"Type":      "SecureString",
+		"Tier":      "Intelligent-Tiering",
+		"Overwrite": true,
+	}
+	if s.uri.KMSKeyID() != "" {
+		input["KeyId"] = s.uri.KMSKeyID()
+	}
+
+	return client.Call(ctx, "PutParameter", input, nil)
+}
+
+func (s *SSMOrder) WithLogger(l *slog.Logger) *SSMOrder {
+	s.l = l
+	return s
+}
+
+// WithHTTPClient makes the SSMOrder call the API with the client, like the
+// one with the proxy or the CA of the destination.
+func (s *SSMOrder) WithHTTPClient(c *http.Client) *SSMOrder {
+	s.http = c
+	return s
+}
@@ -0,0 +1,64 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestSSMOrder_Order(t *testing.T) {
+	var target string
+	var input map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.Header.Get("X-Amz-Target")
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Version":1}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_DEFAULT_REGION", "us-east-1")
+
+	uri, err := uriapi.NewSSMURI("ssm:///prod/tls/chain?kmsKeyId=alias/cannect")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewSSMOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if target != "AmazonSSM.PutParameter" {
+		t.Errorf("Expected target is AmazonSSM.PutParameter but got: %s", target)
+	}
+
+	want := map[string]interface{}{
+		"Name":      "/prod/tls/chain",
+		"Value":     string(chain),
+		"Type":      "SecureString",
+		"Tier":      "Intelligent-Tiering",
+		"Overwrite": true,
+		"KeyId":     "alias/cannect",
+	}
+	if diff := cmp.Diff(input, want); diff != "" {
+		t.Error(diff)
+	}
+}
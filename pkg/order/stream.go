@@ -0,0 +1,257 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+// StreamCatalog is the Catalog whose content can be read as a stream, so the
+// large contents, like the CRLs and the concatenated bundles, are written
+// without holding them in memory.
+type StreamCatalog interface {
+	Catalog
+	// Open opens the content based on the information of its own URI. The
+	// reader fails instead of returning io.EOF if the content read is not the
+	// one checked.
+	Open(context.Context) (io.ReadCloser, error)
+}
+
+// Streamer is implemented by the StreamCatalog wrapping another catalog, like
+// the ones limiting the time or logging the fetches. The wrapper forwards Open
+// to the wrapped catalog, and streams only if the wrapped catalog streams.
+type Streamer interface {
+	Streams() bool
+}
+
+// Streams reports whether the content of the catalog is read as a stream.
+func Streams(c Catalog) bool {
+	if _, ok := c.(StreamCatalog); !ok {
+		return false
+	}
+
+	if s, ok := c.(Streamer); ok {
+		return s.Streams()
+	}
+
+	return true
+}
+
+// Open opens the content of the catalog. The content of the catalog not
+// streaming is fetched, and read from the memory.
+func Open(ctx context.Context, c Catalog) (io.ReadCloser, error) {
+	if s, ok := c.(StreamCatalog); ok && Streams(c) {
+		return s.Open(ctx)
+	}
+
+	buf, err := c.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(buf)), nil
+}
+
+// openAll returns the reader of the contents of the catalogs concatenated in
+// order. The contents of the other catalogs than the StreamCatalogs are
+// fetched concurrently beforehand, and each StreamCatalog is opened when the
+// contents before it are read, so at most one of them is open at a time.
+func openAll(ctx context.Context, catalogs []Catalog) (io.ReadCloser, error) {
+	var fetched []Catalog
+	for _, c := range catalogs {
+		if !Streams(c) {
+			fetched = append(fetched, c)
+		}
+	}
+
+	contents, err := fetchContents(ctx, fetched)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &multiReader{ctx: ctx}
+	for _, c := range catalogs {
+		if Streams(c) {
+			r.sources = append(r.sources, source{catalog: c.(StreamCatalog)})
+			continue
+		}
+
+		r.sources = append(r.sources, source{content: contents[0]})
+		contents = contents[1:]
+	}
+
+	return r, nil
+}
+
+// readError is the failure of reading the contents, which the orders tell
+// from the one of writing them.
+type readError struct {
+	err error
+}
+
+func (r readError) Error() string {
+	return r.err.Error()
+}
+
+func (r readError) Unwrap() error {
+	return r.err
+}
+
+// source is either the fetched content or the StreamCatalog to open.
+type source struct {
+	content []byte
+	catalog StreamCatalog
+}
+
+// multiReader reads the sources in turn, opening the StreamCatalogs lazily.
+type multiReader struct {
+	ctx     context.Context
+	sources []source
+	current io.ReadCloser
+}
+
+func (m *multiReader) Read(p []byte) (int, error) {
+	for {
+		if m.current == nil {
+			if len(m.sources) == 0 {
+				return 0, io.EOF
+			}
+
+			err := m.next()
+			if err != nil {
+				return 0, readError{err}
+			}
+		}
+
+		n, err := m.current.Read(p)
+		if err == io.EOF {
+			err = m.current.Close()
+			m.current = nil
+			if err != nil {
+				return n, readError{err}
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		if err != nil {
+			return n, readError{err}
+		}
+
+		return n, nil
+	}
+}
+
+// next opens the first of the remaining sources.
+func (m *multiReader) next() error {
+	src := m.sources[0]
+	m.sources[0] = source{}
+	m.sources = m.sources[1:]
+
+	if src.catalog == nil {
+		m.current = io.NopCloser(bytes.NewReader(src.content))
+		return nil
+	}
+
+	r, err := src.catalog.Open(m.ctx)
+	if err != nil {
+		return err
+	}
+	m.current = r
+
+	return nil
+}
+
+// Close closes the source being read, and drops the remaining ones.
+func (m *multiReader) Close() error {
+	m.sources = nil
+	if m.current == nil {
+		return nil
+	}
+
+	err := m.current.Close()
+	m.current = nil
+
+	return err
+}
+
+// Size returns the size of the content of the catalog. The content streamed by
+// the SharedCatalog to the end is not read again, and the content of the other
+// streaming catalogs is read to the end without holding it in memory.
+func Size(ctx context.Context, c Catalog) (int, error) {
+	if s, ok := c.(*SharedCatalog); ok {
+		if size, ok := s.streamedSize(); ok {
+			return size, nil
+		}
+	}
+
+	if !Streams(c) {
+		buf, err := c.Fetch(ctx)
+		return len(buf), err
+	}
+
+	r, err := Open(ctx, c)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	n, err := io.Copy(io.Discard, r)
+
+	return int(n), err
+}
+
+// ErrReadAborted means the reader is closed before reading the content to the
+// end.
+var ErrReadAborted = errors.New("content is not read to the end")
+
+// OnReadEnd returns the reader calling the end once, when the r returns an
+// error or io.EOF, or is closed before them with ErrReadAborted. The n is the
+// number of the bytes read. The error returned by the end replaces the one of
+// the r, so the wrapper of the StreamCatalog can fail the read, like Fetch.
+func OnReadEnd(r io.ReadCloser, end func(n int, err error) error) io.ReadCloser {
+	return &endReader{r: r, end: end}
+}
+
+type endReader struct {
+	r     io.ReadCloser
+	end   func(n int, err error) error
+	n     int
+	ended bool
+	err   error
+}
+
+func (e *endReader) Read(p []byte) (int, error) {
+	if e.ended {
+		return 0, e.err
+	}
+
+	n, err := e.r.Read(p)
+	e.n += n
+	if err == nil {
+		return n, nil
+	}
+
+	if err == io.EOF {
+		err = nil
+	}
+
+	e.ended = true
+	e.err = e.end(e.n, err)
+	if e.err == nil {
+		e.err = io.EOF
+	}
+
+	return n, e.err
+}
+
+func (e *endReader) Close() error {
+	if !e.ended {
+		e.ended = true
+		e.end(e.n, ErrReadAborted)
+	}
+
+	return e.r.Close()
+}
@@ -0,0 +1,308 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+var errTestRead = errors.New("test read error")
+
+// testStreamCatalog is read only through Open, and fails at the end of the
+// content if err is set. It records the number of the open readers.
+type testStreamCatalog struct {
+	content string
+	err     error
+	mu      *sync.Mutex
+	open    *int
+	maxOpen *int
+}
+
+func (t testStreamCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	return nil, errors.New("unexpected fetch")
+}
+
+func (t testStreamCatalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*t.open++
+	if *t.open > *t.maxOpen {
+		*t.maxOpen = *t.open
+	}
+
+	var r io.Reader = strings.NewReader(t.content)
+	if t.err != nil {
+		r = io.MultiReader(r, testErrReader{t.err})
+	}
+
+	return testStreamReader{Reader: r, catalog: t}, nil
+}
+
+type testErrReader struct{ err error }
+
+func (t testErrReader) Read(p []byte) (int, error) {
+	return 0, t.err
+}
+
+type testStreamReader struct {
+	io.Reader
+	catalog testStreamCatalog
+}
+
+func (t testStreamReader) Close() error {
+	t.catalog.mu.Lock()
+	defer t.catalog.mu.Unlock()
+
+	*t.catalog.open--
+	return nil
+}
+
+func TestFSOrder_Order_Stream(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		err      error
+		// want
+		content string
+	}{
+		{"Streamed", nil, "header\nfirst\nmiddle\nsecond\n"},
+		{"Read Failure", errTestRead, "previous"},
+	}
+
+	dir := "testdata/TestFSOrder_Order_Stream"
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for idx, d := range data {
+		idx, d := idx, d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			dstP := path.Join(dir, fmt.Sprintf("%d.out", idx))
+			uri, err := uriapi.NewFSURI(fmt.Sprintf("file://%s", dstP))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = os.WriteFile(dstP, []byte("previous"), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var mu sync.Mutex
+			var open, maxOpen int
+			stream := func(content string, err error) testStreamCatalog {
+				return testStreamCatalog{content: content, err: err, mu: &mu, open: &open, maxOpen: &maxOpen}
+			}
+
+			catalogs := []Catalog{
+				testBytesCatalog("header\n"),
+				stream("first\n", nil),
+				testBytesCatalog("middle\n"),
+				stream("second\n", d.err),
+			}
+			err = NewFSOrder(uri, catalogs).Order(context.TODO())
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if errors.Is(err, ErrWrite) {
+				t.Errorf("Expected the read failure is not the write one but got: %v", err)
+			}
+
+			result, err := os.ReadFile(dstP)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result) != d.content {
+				t.Errorf("Expected %q but got: %q", d.content, result)
+			}
+			if maxOpen != 1 {
+				t.Errorf("Expected one stream is open at a time but got: %d", maxOpen)
+			}
+			if open != 0 {
+				t.Errorf("Expected all streams are closed but got: %d open", open)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	r, err := Open(context.TODO(), testBytesCatalog("content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "content" {
+		t.Errorf("Expected fetched content but got: %q", buf)
+	}
+
+	_, err = Open(context.TODO(), testErrCatalog{})
+	if !errors.Is(err, errTestFetch) {
+		t.Fatalf("Expected %#v error but got: %#v", errTestFetch, err)
+	}
+}
+
+func TestFSOrder_Order_WriteError(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewFSURI("file://testdata/TestFSOrder_Order_WriteError/missing/ca.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewFSOrder(uri, []Catalog{testBytesCatalog("content")}).Order(context.TODO())
+	var wErr WriteError
+	if !errors.As(err, &wErr) || !errors.Is(err, ErrWrite) {
+		t.Fatalf("Expected WriteError but got: %#v", err)
+	}
+	if wErr.URI() != uri.Text() {
+		t.Errorf("Expected %s but got: %s", uri.Text(), wErr.URI())
+	}
+}
+
+func TestStreams(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var open, maxOpen int
+	stream := testStreamCatalog{content: "content", mu: &mu, open: &open, maxOpen: &maxOpen}
+
+	data := []struct {
+		testCase string
+		catalog  Catalog
+		// want
+		streams bool
+	}{
+		{"Fetched", testBytesCatalog("content"), false},
+		{"Streamed", stream, true},
+		{"Shared", NewSharedCatalog(stream), false},
+		{"Shared Stream", NewSharedCatalog(stream).WithStream(), true},
+		{"Shared Fetched Stream", NewSharedCatalog(testBytesCatalog("content")).WithStream(), false},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Streams(d.catalog); got != d.streams {
+				t.Errorf("Expected %v but got: %v", d.streams, got)
+			}
+		})
+	}
+}
+
+func TestSharedCatalog_Open(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var open, maxOpen int
+	stream := testStreamCatalog{content: "content", mu: &mu, open: &open, maxOpen: &maxOpen}
+	shared := NewSharedCatalog(stream).WithStream()
+
+	r, err := Open(context.TODO(), shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	if string(buf) != "content" {
+		t.Errorf("Expected streamed content but got: %q", buf)
+	}
+
+	size, err := Size(context.TODO(), shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != len("content") {
+		t.Errorf("Expected the streamed size but got: %d", size)
+	}
+	if open != 0 {
+		t.Errorf("Expected all streams are closed but got: %d open", open)
+	}
+}
+
+func TestOnReadEnd(t *testing.T) {
+	t.Parallel()
+
+	errEnd := errors.New("end error")
+
+	data := []struct {
+		testCase string
+		readErr  error
+		endErr   error
+		close    bool
+		// want
+		n   int
+		err error
+		got error
+	}{
+		{"EOF", nil, nil, false, 7, nil, nil},
+		{"Read Failure", errTestRead, errTestRead, false, 7, errTestRead, errTestRead},
+		{"End Failure", nil, errEnd, false, 7, errEnd, nil},
+		{"Aborted", nil, nil, true, 0, nil, ErrReadAborted},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var r io.Reader = strings.NewReader("content")
+			if d.readErr != nil {
+				r = io.MultiReader(r, testErrReader{d.readErr})
+			}
+
+			calls := 0
+			var n int
+			var got error
+			rc := OnReadEnd(io.NopCloser(r), func(size int, err error) error {
+				calls++
+				n, got = size, err
+				return d.endErr
+			})
+
+			if d.close {
+				rc.Close()
+			} else {
+				_, err := io.ReadAll(rc)
+				if !errors.Is(err, d.err) {
+					t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+				}
+				rc.Close()
+			}
+
+			if calls != 1 {
+				t.Errorf("Expected the end is called once but got: %d", calls)
+			}
+			if n != d.n || !errors.Is(got, d.got) {
+				t.Errorf("Expected %d, %v but got: %d, %v", d.n, d.got, n, got)
+			}
+		})
+	}
+}
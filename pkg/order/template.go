@@ -0,0 +1,103 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available in the template in addition to
+// the builtin functions of text/template.
+var templateFuncs = template.FuncMap{
+	"base64": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	},
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+}
+
+// TemplateAsset is the content of a catalog passed to the template.
+type TemplateAsset struct {
+	Name    string
+	Content string
+}
+
+// TemplateData is the data passed to the template. The Assets are in the
+// order of the catalogs, and the Content is the concatenated contents of them.
+type TemplateData struct {
+	Assets  []TemplateAsset
+	Content string
+}
+
+// Asset returns the content of the asset named by the name, like
+// {{ .Asset "root-ca.crt" }}.
+func (d TemplateData) Asset(name string) (string, error) {
+	for _, a := range d.Assets {
+		if a.Name == name {
+			return a.Content, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: asset is not found", name)
+}
+
+// Template implements the Catalog interface. It fetches the catalogs as the
+// assets named by the names, and returns the output of the text/template
+// rendered with them in place of the concatenated contents. The functions
+// "base64", "indent" and "json" are available in the template.
+type Template struct {
+	tmpl     *template.Template
+	catalogs []Catalog
+	names    []string
+}
+
+func NewTemplate(text string, catalogs []Catalog, names []string) (*Template, error) {
+	tmpl, err := template.New("order").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Template{
+		tmpl:     tmpl,
+		catalogs: catalogs,
+		names:    names,
+	}
+
+	return t, nil
+}
+
+func (t *Template) Fetch(ctx context.Context) ([]byte, error) {
+	contents, err := fetchEntries(ctx, t.catalogs, t.names)
+	if err != nil {
+		return nil, err
+	}
+
+	var data TemplateData
+	var content strings.Builder
+	for idx := range contents {
+		data.Assets = append(data.Assets, TemplateAsset{Name: t.names[idx], Content: string(contents[idx])})
+		content.Write(contents[idx])
+	}
+	data.Content = content.String()
+
+	var buf bytes.Buffer
+	err = t.tmpl.Execute(&buf, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,41 @@
+package order
+
+import (
+	"errors"
+	"net/http"
+)
+
+// throttleCodes are the error codes of the AWS APIs refusing the requests
+// because of their rate, like "SlowDown" of S3.
+var throttleCodes = map[string]bool{
+	"SlowDown":                 true,
+	"Throttling":               true,
+	"ThrottlingException":      true,
+	"RequestLimitExceeded":     true,
+	"TooManyRequests":          true,
+	"TooManyRequestsException": true,
+}
+
+// Throttled reports whether the error is the response of the destination
+// refusing the write because of too many requests, so that it may succeed if
+// it is written later with fewer requests in parallel.
+func Throttled(err error) bool {
+	var throttledErr interface{ Throttled() bool }
+	if errors.As(err, &throttledErr) && throttledErr.Throttled() {
+		return true
+	}
+
+	// The errors of the AWS SDK.
+	var codeErr interface{ ErrorCode() string }
+	if errors.As(err, &codeErr) && throttleCodes[codeErr.ErrorCode()] {
+		return true
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	return errors.As(err, &statusErr) && throttledStatus(statusErr.HTTPStatusCode())
+}
+
+// throttledStatus reports whether the status is of the throttling response.
+func throttledStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
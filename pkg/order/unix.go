@@ -0,0 +1,89 @@
+package order
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+var ErrContentTooLarge = errors.New("content is too large for the length prefix")
+
+// UnixOrder implements the Order interface. It is responsible for sending the
+// concatenated contents of the catalogs to the Unix domain socket, so that a
+// local agent, like a key broker daemon, receives them without the files. The
+// content is prefixed by its length in 4 bytes big endian, or sent as it is
+// until the end of the stream in the "raw" frame.
+type UnixOrder struct {
+	uri      uriapi.UnixURI
+	catalogs []Catalog
+	l        *slog.Logger
+}
+
+func NewUnixOrder(uri uriapi.UnixURI, catalogs []Catalog) *UnixOrder {
+	order := &UnixOrder{
+		uri:      uri,
+		catalogs: catalogs,
+	}
+
+	return order
+}
+
+// The Order function fetches all contents before connecting, so the agent
+// never receives the partial contents if any catalog fails.
+func (u *UnixOrder) Order(ctx context.Context) error {
+	if u.l != nil {
+		u.l.InfoContext(ctx, "ordering", "scheme", u.uri.Scheme(), "uri", u.uri.Text())
+	}
+
+	buf, err := fetchAll(ctx, u.catalogs)
+	if err != nil {
+		return err
+	}
+
+	if u.uri.Frame() == "length" {
+		if uint64(len(buf)) > math.MaxUint32 {
+			return fmt.Errorf("%s: %w", u.uri.Text(), ErrContentTooLarge)
+		}
+
+		header := make([]byte, 4)
+		binary.BigEndian.PutUint32(header, uint32(len(buf)))
+		buf = append(header, buf...)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", u.uri.Path())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = conn.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	// Tell the end of the content to the agent reading until EOF.
+	if uConn, ok := conn.(*net.UnixConn); ok {
+		return uConn.CloseWrite()
+	}
+
+	return nil
+}
+
+func (u *UnixOrder) WithLogger(l *slog.Logger) *UnixOrder {
+	u.l = l
+	return u
+}
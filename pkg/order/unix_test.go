@@ -0,0 +1,97 @@
+package order
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestUnixOrder_Order(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestUnixOrder_Order")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(want)))
+
+	data := []struct {
+		testCase string
+		frame    string
+		want     []byte
+	}{
+		{"OK:length", "", append(header, want...)},
+		{"OK:raw", "?frame=raw", want},
+	}
+
+	for idx, d := range data {
+		d := d
+		sock := path.Join(dir, string(rune('a'+idx))+".sock")
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			l, err := net.Listen("unix", sock)
+			if err != nil {
+				t.Skipf("Unix domain socket is not available: %v", err)
+			}
+			defer l.Close()
+
+			received := make(chan []byte, 1)
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					received <- nil
+					return
+				}
+				defer conn.Close()
+
+				buf, _ := io.ReadAll(conn)
+				received <- buf
+			}()
+
+			uri, err := uriapi.NewUnixURI("unix://" + sock + d.frame)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = NewUnixOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(string(<-received), string(d.want)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestUnixOrder_Order_NoListener(t *testing.T) {
+	t.Parallel()
+
+	uri, err := uriapi.NewUnixURI("unix://testdata/TestUnixOrder_Order_NoListener.sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewUnixOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+	if err == nil {
+		t.Fatal("Expected the error of dialing but got nil")
+	}
+}
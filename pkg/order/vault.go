@@ -0,0 +1,209 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const defaultVaultKey = "content"
+
+// ErrWrite is matched by the WriteError, whose destination is unwritable.
+var ErrWrite = errors.New("write failed")
+
+// WriteError is used to represent an error that occurs when writing the
+// contents to the destination fails.
+type WriteError struct {
+	uri       string
+	reason    string
+	throttled bool
+	status    int
+	err       error
+}
+
+// NewWriteError returns the WriteError of the err, for the orders implemented
+// outside the package.
+func NewWriteError(uri string, err error) error {
+	return WriteError{uri: uri, reason: err.Error(), err: err}
+}
+
+func (e WriteError) Error() string {
+	return fmt.Sprintf("write failed at %s: %s", e.uri, e.reason)
+}
+
+func (e WriteError) Unwrap() error {
+	return e.err
+}
+
+func (e WriteError) Is(target error) bool {
+	return target == ErrWrite
+}
+
+// URI returns the URI of the order.
+func (e WriteError) URI() string {
+	return e.uri
+}
+
+// Throttled reports whether the destination refused the write because of too
+// many requests.
+func (e WriteError) Throttled() bool {
+	return e.throttled
+}
+
+// VaultOrder implements the Order interface. It is responsible for writing
+// the concatenated contents of the catalogs to a secret in the Vault KV
+// version 2 secrets engine.
+type VaultOrder struct {
+	uri      uriapi.VaultURI
+	catalogs []Catalog
+	client   *http.Client
+	l        *slog.Logger
+}
+
+func NewVaultOrder(uri uriapi.VaultURI, catalogs []Catalog) *VaultOrder {
+	order := &VaultOrder{
+		uri:      uri,
+		catalogs: catalogs,
+		client:   http.DefaultClient,
+	}
+
+	return order
+}
+
+// The Order function utilizes the Patch Secret API of the KV version 2 secrets
+// engine, so the other keys of the secret are kept. The secret is created by
+// the Create/Update Secret API with "cas" of the current version in its
+// metadata if it does not exist, or its latest version is deleted, so the
+// secret written concurrently is never overwritten. It requires the usage of
+// environment variables "VAULT_ADDR" and "VAULT_TOKEN", and optionally
+// "VAULT_NAMESPACE", to authorize the request. The contents are stored under
+// the key of the URI, or "content" if the key is not specified.
+func (v *VaultOrder) Order(ctx context.Context) error {
+	if v.l != nil {
+		v.l.InfoContext(ctx, "ordering", "scheme", v.uri.Scheme(), "uri", v.uri.Text())
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return WriteError{uri: v.uri.Text(), reason: "VAULT_ADDR is not set."}
+	}
+
+	buf, err := fetchAll(ctx, v.catalogs)
+	if err != nil {
+		return err
+	}
+
+	key := v.uri.Key()
+	if key == "" {
+		key = defaultVaultKey
+	}
+
+	data := map[string]string{key: string(buf)}
+
+	err = v.send(ctx, addr, http.MethodPatch, "application/merge-patch+json", map[string]interface{}{
+		"data": data,
+	})
+
+	var wErr WriteError
+	if !errors.As(err, &wErr) || wErr.status != http.StatusNotFound {
+		return err
+	}
+
+	// The Patch Secret API fails for the deleted secret too, whose metadata
+	// still has the current version.
+	version, err := v.currentVersion(ctx, addr)
+	if err != nil {
+		return err
+	}
+
+	return v.send(ctx, addr, http.MethodPost, "application/json", map[string]interface{}{
+		"options": map[string]int{"cas": version},
+		"data":    data,
+	})
+}
+
+// currentVersion returns the current version in the metadata of the secret, or
+// 0 if the secret has no metadata. It requires the permission to read the
+// metadata.
+func (v *VaultOrder) currentVersion(ctx context.Context, addr string) (int, error) {
+	req, err := v.request(ctx, addr, http.MethodGet, fmt.Sprintf("%s/metadata/%s", v.uri.Mount(), v.uri.SecretPath()), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var metadata struct {
+		Data struct {
+			CurrentVersion int `json:"current_version"`
+		} `json:"data"`
+	}
+	err = receive(v.client, req, v.uri.Text(), &metadata)
+
+	var wErr WriteError
+	if errors.As(err, &wErr) && wErr.status == http.StatusNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return metadata.Data.CurrentVersion, nil
+}
+
+// send sends the request of the method with the body in JSON to the path of
+// the URI.
+func (v *VaultOrder) send(ctx context.Context, addr, method, contentType string, body interface{}) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := v.request(ctx, addr, method, v.uri.Path(), bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	return send(v.client, req, v.uri.Text())
+}
+
+// request returns the request of the method to the path of the API, with the
+// token and the namespace.
+func (v *VaultOrder) request(ctx context.Context, addr, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		method,
+		fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(addr, "/"), path),
+		body,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	return req, nil
+}
+
+func (v *VaultOrder) WithLogger(l *slog.Logger) *VaultOrder {
+	v.l = l
+	return v
+}
+
+// WithHTTPClient makes the VaultOrder send the request with the client, like the
+// one with the proxy or the CA of the destination.
+func (v *VaultOrder) WithHTTPClient(c *http.Client) *VaultOrder {
+	v.client = c
+	return v
+}
@@ -0,0 +1,178 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+// testVaultKV is the secret of the KV version 2 secrets engine. It is patched
+// only if its latest version exists, and created only if the cas is the
+// current version and the latest version does not exist. The data is nil and
+// the version is not 0 if the latest version is deleted.
+type testVaultKV struct {
+	mu      sync.Mutex
+	data    map[string]string
+	version int
+	methods []string
+}
+
+func (kv *testVaultKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	kv.methods = append(kv.methods, r.Method)
+	if r.Method == http.MethodGet {
+		if r.URL.Path != "/v1/secret/metadata/pki/chain" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if kv.version == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]int{"current_version": kv.version},
+		})
+		return
+	}
+
+	var body struct {
+		Options map[string]int    `json:"options"`
+		Data    map[string]string `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPatch && r.Header.Get("Content-Type") == "application/merge-patch+json":
+		if kv.data == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for key, value := range body.Data {
+			kv.data[key] = value
+		}
+	case r.Method == http.MethodPost:
+		if cas, ok := body.Options["cas"]; !ok || cas != kv.version || kv.data != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		kv.data = body.Data
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	kv.version++
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestVaultOrder_Order(t *testing.T) {
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		testCase    string
+		data        map[string]string
+		version     int
+		wantData    map[string]string
+		wantMethods []string
+	}{
+		{
+			"Patch",
+			map[string]string{"chain": "previous", "key": "private key"},
+			1,
+			map[string]string{"chain": string(want), "key": "private key"},
+			[]string{http.MethodPatch},
+		},
+		{
+			"Create",
+			nil,
+			0,
+			map[string]string{"chain": string(want)},
+			[]string{http.MethodPatch, http.MethodGet, http.MethodPost},
+		},
+		{
+			"Create:deleted",
+			nil,
+			3,
+			map[string]string{"chain": string(want)},
+			[]string{http.MethodPatch, http.MethodGet, http.MethodPost},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			var gotPath, gotToken string
+
+			kv := &testVaultKV{data: d.data, version: d.version}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				gotToken = r.Header.Get("X-Vault-Token")
+				kv.ServeHTTP(w, r)
+			}))
+			defer srv.Close()
+
+			t.Setenv("VAULT_ADDR", srv.URL)
+			t.Setenv("VAULT_TOKEN", "test-token")
+
+			uri, err := uriapi.NewVaultURI("vault://secret/data/pki/chain?key=chain")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = NewVaultOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if gotPath != "/v1/secret/data/pki/chain" {
+				t.Errorf("Expected path is /v1/secret/data/pki/chain but got: %s", gotPath)
+			}
+			if gotToken != "test-token" {
+				t.Errorf("Expected token is test-token but got: %s", gotToken)
+			}
+			if diff := cmp.Diff(kv.data, d.wantData); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(kv.methods, d.wantMethods); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestVaultOrder_Order_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "invalid-token")
+
+	uri, err := uriapi.NewVaultURI("vault://secret/data/pki/chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewVaultOrder(uri, testGenCatalogs(t)).Order(context.TODO())
+	var wErr WriteError
+	if !errors.As(err, &wErr) {
+		t.Fatalf("Expected WriteError but got: %#v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+	"golang.org/x/exp/slog"
+)
+
+const defaultWebhookContentType = "application/x-pem-file"
+
+// WebhookOrder implements the Order interface. It is responsible for sending
+// the concatenated contents of the catalogs to an HTTPS endpoint, such as an
+// internal inventory service or a load balancer API.
+type WebhookOrder struct {
+	uri         uriapi.WebhookURI
+	catalogs    []Catalog
+	method      string
+	contentType string
+	headerEnvs  map[string]string
+	client      *http.Client
+	l           *slog.Logger
+}
+
+func NewWebhookOrder(uri uriapi.WebhookURI, catalogs []Catalog) *WebhookOrder {
+	order := &WebhookOrder{
+		uri:         uri,
+		catalogs:    catalogs,
+		method:      http.MethodPost,
+		contentType: defaultWebhookContentType,
+		headerEnvs:  make(map[string]string),
+		client:      http.DefaultClient,
+	}
+
+	return order
+}
+
+// The Order function sends the contents as the request body. The values of the
+// headers are read from the environment variables when ordering, so that
+// secrets like tokens are not written in the config files.
+func (w *WebhookOrder) Order(ctx context.Context) error {
+	if w.l != nil {
+		w.l.InfoContext(ctx, "ordering", "scheme", w.uri.Scheme(), "uri", w.uri.Text())
+	}
+
+	header := make(http.Header, len(w.headerEnvs))
+	for name, env := range w.headerEnvs {
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return WriteError{uri: w.uri.Text(), reason: fmt.Sprintf("%s is not set.", env)}
+		}
+		header.Set(name, value)
+	}
+
+	buf, err := fetchAll(ctx, w.catalogs)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.method, w.uri.Text(), bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+
+	req.Header = header
+	req.Header.Set("Content-Type", w.contentType)
+
+	return send(w.client, req, w.uri.Text())
+}
+
+// WithMethod sets the HTTP method of the request. The default is POST.
+func (w *WebhookOrder) WithMethod(method string) *WebhookOrder {
+	w.method = method
+	return w
+}
+
+// WithContentType sets the Content-Type header of the request. The default is
+// "application/x-pem-file".
+func (w *WebhookOrder) WithContentType(contentType string) *WebhookOrder {
+	w.contentType = contentType
+	return w
+}
+
+// WithHeaderEnv sets the header of the request to the value of the
+// environment variable.
+func (w *WebhookOrder) WithHeaderEnv(name, env string) *WebhookOrder {
+	w.headerEnvs[name] = env
+	return w
+}
+
+func (w *WebhookOrder) WithLogger(l *slog.Logger) *WebhookOrder {
+	w.l = l
+	return w
+}
+
+// WithHTTPClient makes the WebhookOrder send the request with the client, like the
+// one with the proxy or the CA of the destination.
+func (w *WebhookOrder) WithHTTPClient(c *http.Client) *WebhookOrder {
+	w.client = c
+	return w
+}
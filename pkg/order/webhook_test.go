@@ -0,0 +1,138 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+func TestWebhookOrder_Order(t *testing.T) {
+	var gotMethod, gotPath, gotQuery, gotToken, gotType string
+	var gotBody []byte
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotQuery = r.URL.RawQuery
+		gotToken = r.Header.Get("Authorization")
+		gotType = r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TEST_WEBHOOK_TOKEN", "Bearer test-token")
+
+	uri, err := uriapi.NewWebhookURI(srv.URL + "/api/v1/bundles?env=prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhookOrder := NewWebhookOrder(uri, testGenCatalogs(t)).
+		WithMethod(http.MethodPut).
+		WithContentType("text/plain").
+		WithHeaderEnv("Authorization", "TEST_WEBHOOK_TOKEN")
+	webhookOrder.client = srv.Client()
+
+	err = webhookOrder.Order(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("testdata/chain.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotMethod != http.MethodPut {
+		t.Errorf("Expected method is %s but got: %s", http.MethodPut, gotMethod)
+	}
+	if gotPath != "/api/v1/bundles" || gotQuery != "env=prod" {
+		t.Errorf("Expected path is /api/v1/bundles?env=prod but got: %s?%s", gotPath, gotQuery)
+	}
+	if gotToken != "Bearer test-token" {
+		t.Errorf("Expected token is Bearer test-token but got: %s", gotToken)
+	}
+	if gotType != "text/plain" {
+		t.Errorf("Expected content type is text/plain but got: %s", gotType)
+	}
+	if diff := cmp.Diff(string(gotBody), string(want)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWebhookOrder_Order_Error(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	uri, err := uriapi.NewWebhookURI(srv.URL + "/api/v1/bundles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhookOrder := NewWebhookOrder(uri, testGenCatalogs(t))
+	webhookOrder.client = srv.Client()
+
+	var wErr WriteError
+
+	err = webhookOrder.Order(context.TODO())
+	if !errors.As(err, &wErr) {
+		t.Fatalf("Expected WriteError but got: %v", err)
+	}
+
+	webhookOrder = webhookOrder.WithHeaderEnv("Authorization", "TEST_WEBHOOK_TOKEN_UNSET")
+	err = webhookOrder.Order(context.TODO())
+	if !errors.As(err, &wErr) {
+		t.Fatalf("Expected WriteError but got: %v", err)
+	}
+}
+
+func TestWebhookOrder_Order_Throttled(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		status   int
+		// want
+		throttled bool
+	}{
+		{"Too Many Requests", http.StatusTooManyRequests, true},
+		{"Service Unavailable", http.StatusServiceUnavailable, true},
+		{"Forbidden", http.StatusForbidden, false},
+	}
+
+	for _, d := range data {
+		status := d.status
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		uri, err := uriapi.NewWebhookURI(srv.URL + "/api/v1/bundles")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		webhookOrder := NewWebhookOrder(uri, testGenCatalogs(t))
+		webhookOrder.client = srv.Client()
+
+		err = webhookOrder.Order(context.TODO())
+		srv.Close()
+		if err == nil {
+			t.Fatalf("%s: Expected the error but got nil", d.testCase)
+		}
+		if Throttled(err) != d.throttled {
+			t.Errorf("%s: Expected throttled %t but got: %v", d.testCase, d.throttled, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package plugin is the protocol of the external programs fetching and writing
+// the contents of the plugin scheme, like the legacy CA portals and the
+// proprietary secret stores. The program of the plugin "portal" is
+// "cannect-plugin-portal", looked up in PATH. It is run for each fetch and
+// each write, reads a Request in JSON from its standard input, and writes a
+// Response in JSON to its standard output. Its standard error is written to
+// the logs.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+var (
+	ErrPlugin          = errors.New("plugin failed")
+	ErrInvalidResponse = errors.New("invalid response of plugin")
+)
+
+// Version is the version of the protocol in the Request.
+const Version = 1
+
+// ProgramPrefix is the prefix of the names of the programs of the plugins.
+const ProgramPrefix = "cannect-plugin-"
+
+// The operations of the Request.
+const (
+	FetchOperation = "fetch"
+	OrderOperation = "order"
+)
+
+// maxStderr is the size of the end of the standard error kept for the error
+// of the program.
+const maxStderr = 4096
+
+// Request is the request written to the standard input of the program.
+type Request struct {
+	Version int `json:"version"`
+	// Operation is "fetch" for the catalogs, or "order" for the orders.
+	Operation string `json:"operation"`
+	// URI is the full URI, and Path is the part after the name of the plugin.
+	URI  string `json:"uri"`
+	Path string `json:"path"`
+	// Alias is the alias of the catalog of the fetch.
+	Alias string `json:"alias,omitempty"`
+	// Content is the content the order writes, encoded in base64.
+	Content []byte `json:"content,omitempty"`
+}
+
+// Response is the response read from the standard output of the program. The
+// program reports the failure with the Error, or with the exit status. The
+// order writes nothing, or "{}", when it succeeds.
+type Response struct {
+	// Content is the fetched content, encoded in base64.
+	Content []byte `json:"content,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Program returns the name of the program of the plugin.
+func Program(name string) string {
+	return ProgramPrefix + name
+}
+
+// Call runs the program with the request, and returns its response. The
+// standard error of the program is written to the stderr if it is not nil.
+func Call(ctx context.Context, program string, req Request, stderr io.Writer) (Response, error) {
+	var resp Response
+
+	req.Version = Version
+	in, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	var out, tail bytes.Buffer
+	cmd := exec.CommandContext(ctx, program)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &out
+	cmd.Stderr = io.MultiWriter(stderr, &tail)
+
+	runErr := cmd.Run()
+
+	// The error in the response is preferred to the exit status. The empty
+	// output is the empty response.
+	var decodeErr error
+	if len(bytes.TrimSpace(out.Bytes())) > 0 {
+		decodeErr = json.Unmarshal(out.Bytes(), &resp)
+	}
+	if decodeErr == nil && resp.Error != "" {
+		return Response{}, fmt.Errorf("%s: %w: %s", program, ErrPlugin, resp.Error)
+	}
+
+	if runErr != nil {
+		msg := tail.Bytes()
+		if len(msg) > maxStderr {
+			msg = msg[len(msg)-maxStderr:]
+		}
+
+		return Response{}, fmt.Errorf("%s: %w: %s", program, runErr, bytes.TrimSpace(msg))
+	}
+
+	if decodeErr != nil {
+		return Response{}, fmt.Errorf("%s: %w: %s", program, ErrInvalidResponse, decodeErr.Error())
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testProgram writes the shell script of the program to the dir.
+func testProgram(t *testing.T, dir, name, script string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("sh"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("sh is not available")
+	}
+
+	program := path.Join(dir, name)
+	err := os.WriteFile(program, []byte("#!/bin/sh\n"+script+"\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The path without a slash is looked up in PATH.
+	return "./" + program
+}
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestCall")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	data := []struct {
+		testCase string
+		script   string
+		// want
+		want   Response
+		stderr string
+		err    error
+	}{
+		{
+			"OK:Content",
+			`cat > /dev/null; echo '{"content":"LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCg=="}'`,
+			Response{Content: []byte("-----BEGIN CERTIFICATE-----\n")},
+			"",
+			nil,
+		},
+		{"OK:Empty", "cat > /dev/null", Response{}, "", nil},
+		{
+			"NG:Error",
+			`cat > /dev/null; echo 'portal is down' >&2; echo '{"error":"503 Service Unavailable"}'; exit 1`,
+			Response{},
+			"portal is down\n",
+			ErrPlugin,
+		},
+		{"NG:Exit", "cat > /dev/null; echo 'no such path' >&2; exit 2", Response{}, "no such path\n", nil},
+		{"NG:Invalid", "cat > /dev/null; echo 'content'", Response{}, "", ErrInvalidResponse},
+	}
+
+	// The programs are written before running any of them.
+	programs := make([]string, len(data))
+	for idx, d := range data {
+		programs[idx] = testProgram(t, dir, Program(strings.Repeat("x", idx+1)), d.script)
+	}
+
+	for idx, d := range data {
+		d, program := d, programs[idx]
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			var stderr bytes.Buffer
+			got, err := Call(context.Background(), program, Request{Operation: FetchOperation, URI: "plugin://portal/ca/root", Path: "ca/root"}, &stderr)
+			if d.testCase == "NG:Exit" {
+				var exitErr *exec.ExitError
+				if !errors.As(err, &exitErr) || !strings.HasSuffix(err.Error(), "no such path") {
+					t.Fatalf("Expected the exit error with the standard error but got: %#v", err)
+				}
+			} else if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if diff := cmp.Diff(d.want, got); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(d.stderr, stderr.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestCall_Request(t *testing.T) {
+	t.Parallel()
+
+	dir := path.Join("testdata", "TestCall_Request")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	out := path.Join(dir, "request.json")
+	program := testProgram(t, dir, Program("portal"), "cat > "+out)
+
+	want := Request{
+		Version:   Version,
+		Operation: OrderOperation,
+		URI:       "plugin://portal/ca/root?env=prod",
+		Path:      "ca/root?env=prod",
+		Content:   []byte("-----BEGIN CERTIFICATE-----\n"),
+	}
+	_, err = Call(context.Background(), program, Request{
+		Operation: want.Operation, URI: want.URI, Path: want.Path, Content: want.Content,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Request
+	err = json.Unmarshal(b, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,114 @@
+// Package scheme is the extension point for the custom URI schemes. A custom
+// scheme registers its URI parser, catalog factory and order factory together,
+// usually in the init function of the package that implements the backend,
+// and the CLI includes the registered schemes in the help text, the validation
+// and the execution.
+package scheme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+var (
+	ErrInvalidScheme    = errors.New("invalid scheme")
+	ErrSchemeRegistered = errors.New("scheme is already registered")
+)
+
+var nameReg = regexp.MustCompile("^[a-z][a-z0-9+.-]*$")
+
+// URI is the parsed URI of a custom scheme.
+type URI interface {
+	Text() string
+	Scheme() string
+	Path() string
+}
+
+// Order writes the contents of the catalogs to the destination.
+type Order interface {
+	Order(context.Context) error
+}
+
+// Scheme is a custom URI scheme. The NewCatalog or the NewOrder is nil if the
+// scheme is not supported in the catalogs or the orders.
+type Scheme struct {
+	// Name is the scheme of the URIs, like "vendor" of "vendor://path".
+	Name string
+	// Usage is the one line description printed in the help text.
+	Usage string
+	// ParseURI parses the URI of the scheme.
+	ParseURI func(uri string) (URI, error)
+	// NewCatalog returns the catalog fetching from the URI, that checks the
+	// fetched contents with the checker.
+	NewCatalog func(uri URI, alias string, checker catalogapi.AssetChecker) (orderapi.Catalog, error)
+	// NewOrder returns the order writing the contents of the catalogs to the
+	// URI.
+	NewOrder func(uri URI, catalogs []orderapi.Catalog) (Order, error)
+}
+
+var (
+	mu      sync.RWMutex
+	schemes = make(map[string]Scheme)
+)
+
+// Register registers the scheme. The name must be a valid URI scheme, and the
+// ParseURI and either of the NewCatalog or the NewOrder must be set. The
+// built-in schemes of the CLI take precedence over the registered ones.
+func Register(s Scheme) error {
+	if !nameReg.MatchString(s.Name) || s.ParseURI == nil || (s.NewCatalog == nil && s.NewOrder == nil) {
+		return fmt.Errorf("%s: %w", s.Name, ErrInvalidScheme)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := schemes[s.Name]; ok {
+		return fmt.Errorf("%s: %w", s.Name, ErrSchemeRegistered)
+	}
+	schemes[s.Name] = s
+
+	return nil
+}
+
+// Lookup returns the registered scheme of the name.
+func Lookup(name string) (Scheme, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	s, ok := schemes[name]
+	return s, ok
+}
+
+// Schemes returns the registered schemes in the order of the names.
+func Schemes() []Scheme {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Scheme, 0, len(schemes))
+	for _, s := range schemes {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// Of returns the scheme part of the URI, like "vendor" of "vendor://path".
+func Of(uri string) string {
+	name, _, ok := strings.Cut(uri, ":")
+	if !ok {
+		return ""
+	}
+
+	return name
+}
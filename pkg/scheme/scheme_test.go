@@ -0,0 +1,123 @@
+package scheme
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	catalogapi "github.com/yuxki/cannect/pkg/catalog"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+)
+
+type testURI string
+
+func (u testURI) Text() string   { return string(u) }
+func (u testURI) Scheme() string { return Of(string(u)) }
+func (u testURI) Path() string   { return string(u)[len(u.Scheme())+3:] }
+
+type testCatalog []byte
+
+func (t testCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	return t, nil
+}
+
+func testScheme(name string) Scheme {
+	return Scheme{
+		Name:  name,
+		Usage: "test scheme",
+		ParseURI: func(uri string) (URI, error) {
+			return testURI(uri), nil
+		},
+		NewCatalog: func(uri URI, alias string, checker catalogapi.AssetChecker) (orderapi.Catalog, error) {
+			return testCatalog(uri.Path()), nil
+		},
+	}
+}
+
+func TestRegister(t *testing.T) {
+	data := []struct {
+		testcase string
+		// input
+		scheme Scheme
+		// want
+		err error
+	}{
+		{
+			"OK:register",
+			testScheme("test-b"),
+			nil,
+		},
+		{
+			"OK:register another",
+			testScheme("test-a"),
+			nil,
+		},
+		{
+			"NG:registered",
+			testScheme("test-b"),
+			ErrSchemeRegistered,
+		},
+		{
+			"NG:invalid name",
+			testScheme("Test_C"),
+			ErrInvalidScheme,
+		},
+		{
+			"NG:no factory",
+			Scheme{Name: "test-d", ParseURI: testScheme("test-d").ParseURI},
+			ErrInvalidScheme,
+		},
+	}
+
+	// The registry is global, so the cases are run in order.
+	for _, d := range data {
+		err := Register(d.scheme)
+		if !errors.Is(err, d.err) {
+			t.Fatalf("%s: Expected %#v error but got: %#v", d.testcase, d.err, err)
+		}
+	}
+
+	var names []string
+	for _, s := range Schemes() {
+		names = append(names, s.Name)
+	}
+	if diff := cmp.Diff(names, []string{"test-a", "test-b"}); diff != "" {
+		t.Error(diff)
+	}
+
+	s, ok := Lookup("test-a")
+	if !ok {
+		t.Fatal("Expected scheme is registered")
+	}
+
+	uri, err := s.ParseURI("test-a://foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := s.NewCatalog(uri, "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := catalog.Fetch(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "foo" {
+		t.Errorf("Expected content is foo but got: %s", buf)
+	}
+}
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+
+	for uri, want := range map[string]string{
+		"vendor://path": "vendor",
+		"stdout:":       "stdout",
+		"no-scheme":     "",
+	} {
+		if got := Of(uri); got != want {
+			t.Errorf("%s: Expected scheme is %s but got: %s", uri, want, got)
+		}
+	}
+}
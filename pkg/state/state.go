@@ -0,0 +1,240 @@
+// Package state persists the state of the runs, like the digests of the
+// contents written to the destinations, so the runs in the stateless
+// containers and the Lambda functions share it. The state is saved to a local
+// file, an object in AWS S3 or an item in AWS DynamoDB.
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/yuxki/cannect/internal/awsjson"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+// ErrUnsupportedScheme is returned by New when the scheme of the URI has no
+// Store.
+var ErrUnsupportedScheme = errors.New("unsupported scheme of the state")
+
+// Store loads and saves the state. It is not called concurrently.
+type Store interface {
+	// Load returns the state saved last, or nil if nothing is saved yet.
+	Load(ctx context.Context) ([]byte, error)
+	// Save replaces the state.
+	Save(ctx context.Context, state []byte) error
+}
+
+// New returns the Store of the URI. The path without the scheme and the
+// "file" scheme are the local file, and the "s3" and the "dynamodb" schemes
+// are the object and the item.
+func New(uri string) (Store, error) {
+	if !strings.Contains(uri, "://") {
+		return NewFileStore(uri), nil
+	}
+
+	switch schemeapi.Of(uri) {
+	case "file":
+		fsURI, err := uriapi.NewFSURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		return NewFileStore(fsURI.Path()), nil
+	case "s3":
+		s3URI, err := uriapi.NewS3URI(uri)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3Store(s3URI), nil
+	case "dynamodb":
+		dURI, err := uriapi.NewDynamoDBURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		return NewDynamoDBStore(dURI), nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", uri, ErrUnsupportedScheme)
+}
+
+// FileStore saves the state to the local file.
+type FileStore struct {
+	name string
+}
+
+func NewFileStore(name string) *FileStore {
+	return &FileStore{name: name}
+}
+
+func (f *FileStore) Load(ctx context.Context) ([]byte, error) {
+	buf, err := os.ReadFile(f.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	return buf, err
+}
+
+func (f *FileStore) Save(ctx context.Context, state []byte) error {
+	return os.WriteFile(f.name, state, 0o666)
+}
+
+// S3Store saves the state to the object in AWS S3. It requires the permissions
+// of s3:GetObject and s3:PutObject.
+type S3Store struct {
+	uri  uriapi.S3URI
+	opts []func(*s3.Options)
+}
+
+func NewS3Store(uri uriapi.S3URI) *S3Store {
+	return &S3Store{uri: uri}
+}
+
+// WithOptions makes the S3Store create the client with the options of the AWS
+// SDK, like the endpoint and the path-style addressing of the S3-compatible
+// object stores.
+func (s *S3Store) WithOptions(optFns ...func(*s3.Options)) *S3Store {
+	s.opts = optFns
+	return s
+}
+
+func (s *S3Store) client(ctx context.Context) (*s3.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.NewFromConfig(cfg, s.opts...), nil
+}
+
+func (s *S3Store) Load(ctx context.Context) ([]byte, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.uri.Bucket()),
+		Key:    aws.String(s.uri.Key()),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	return io.ReadAll(output.Body)
+}
+
+func (s *S3Store) Save(ctx context.Context, state []byte) error {
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.uri.Bucket()),
+		Key:    aws.String(s.uri.Key()),
+		Body:   bytes.NewReader(state),
+	})
+
+	return err
+}
+
+// The attributes of the item of the DynamoDBStore.
+const (
+	dynamoDBKeyAttribute   = "id"
+	dynamoDBStateAttribute = "state"
+)
+
+// DynamoDBStore saves the state to the item in AWS DynamoDB, as the binary
+// attribute "state". The table has the partition key "id" of the string type.
+// It requires the permissions of dynamodb:GetItem and dynamodb:PutItem.
+type DynamoDBStore struct {
+	uri  uriapi.DynamoDBURI
+	http *http.Client
+}
+
+func NewDynamoDBStore(uri uriapi.DynamoDBURI) *DynamoDBStore {
+	return &DynamoDBStore{uri: uri}
+}
+
+// WithHTTPClient makes the DynamoDBStore call the API with the client, like
+// the one with the proxy.
+func (d *DynamoDBStore) WithHTTPClient(c *http.Client) *DynamoDBStore {
+	d.http = c
+	return d
+}
+
+// dynamoDBValue is the attribute value of DynamoDB. Only the string and the
+// binary types are used.
+type dynamoDBValue struct {
+	S string `json:"S,omitempty"`
+	B []byte `json:"B,omitempty"`
+}
+
+func (d *DynamoDBStore) key() map[string]dynamoDBValue {
+	return map[string]dynamoDBValue{dynamoDBKeyAttribute: {S: d.uri.Key()}}
+}
+
+func (d *DynamoDBStore) client(ctx context.Context) (awsjson.Client, error) {
+	client, err := awsjson.NewClient(ctx, "dynamodb", "DynamoDB_20120810", d.http)
+	if err != nil {
+		return client, err
+	}
+
+	return client.WithVersion("1.0"), nil
+}
+
+// Load reads the item with the strongly consistent read, so the state saved by
+// the previous run is always read.
+func (d *DynamoDBStore) Load(ctx context.Context) ([]byte, error) {
+	client, err := d.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var output struct {
+		Item map[string]dynamoDBValue `json:"Item"`
+	}
+	err = client.Call(ctx, "GetItem", map[string]interface{}{
+		"TableName":      d.uri.Table(),
+		"Key":            d.key(),
+		"ConsistentRead": true,
+	}, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	// The item is missing if the output has no item.
+	return output.Item[dynamoDBStateAttribute].B, nil
+}
+
+func (d *DynamoDBStore) Save(ctx context.Context, state []byte) error {
+	client, err := d.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	item := d.key()
+	item[dynamoDBStateAttribute] = dynamoDBValue{B: state}
+
+	return client.Call(ctx, "PutItem", map[string]interface{}{
+		"TableName": d.uri.Table(),
+		"Item":      item,
+	}, nil)
+}
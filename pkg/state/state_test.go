@@ -0,0 +1,205 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/go-cmp/cmp"
+	uriapi "github.com/yuxki/cannect/pkg/uri"
+)
+
+// testRoundTrip saves the state to the store after checking that nothing is
+// loaded, and loads it back.
+func testRoundTrip(t *testing.T, store Store) {
+	t.Helper()
+
+	got, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("Expected no state but got: %s", got)
+	}
+
+	want := []byte(`{"digests":{"file://ca.crt":"e3b0c442"}}`)
+	err = store.Save(context.Background(), want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testCase string
+		uri      string
+		// want
+		store string
+		err   error
+	}{
+		{"OK:path", "state/cannect.json", "*state.FileStore", nil},
+		{"OK:scheme:file", "file://state/cannect.json", "*state.FileStore", nil},
+		{"OK:scheme:s3", "s3://pki/state/cannect.json", "*state.S3Store", nil},
+		{"OK:scheme:dynamodb", "dynamodb://cannect-state/web", "*state.DynamoDBStore", nil},
+		{"NG:scheme:undefined", "vault://kv/data/state", "", ErrUnsupportedScheme},
+		{"NG:invalid", "dynamodb://cannect-state/", "", uriapi.ErrInvalidURI},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := New(d.uri)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := fmt.Sprintf("%T", store); got != d.store {
+				t.Errorf("Expected %s but got: %s", d.store, got)
+			}
+		})
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("testdata", t.Name())
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	testRoundTrip(t, NewFileStore(filepath.Join(dir, "cannect.json")))
+}
+
+func TestS3Store(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			buf, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+				return
+			}
+			_, _ = w.Write(buf)
+		case http.MethodPut:
+			buf, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = buf
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_DEFAULT_REGION", "us-east-1")
+
+	uri, err := uriapi.NewS3URI("s3://pki/state/cannect.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewS3Store(uri).WithOptions(func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(srv.URL)
+		o.UsePathStyle = true
+	})
+	testRoundTrip(t, store)
+
+	if _, ok := objects["/pki/state/cannect.json"]; !ok {
+		t.Errorf("Expected the object is written but got: %v", objects)
+	}
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	var mu sync.Mutex
+	items := make(map[string]map[string]dynamoDBValue)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var input struct {
+			TableName string                   `json:"TableName"`
+			Key       map[string]dynamoDBValue `json:"Key"`
+			Item      map[string]dynamoDBValue `json:"Item"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil || input.TableName != "cannect-state" ||
+			r.Header.Get("Content-Type") != "application/x-amz-json-1.0" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "DynamoDB_20120810.GetItem":
+			item, ok := items[input.Key["id"].S]
+			if !ok {
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"Item": item})
+		case "DynamoDB_20120810.PutItem":
+			items[input.Item["id"].S] = input.Item
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_DEFAULT_REGION", "us-east-1")
+
+	uri, err := uriapi.NewDynamoDBURI("dynamodb://cannect-state/fleet/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testRoundTrip(t, NewDynamoDBStore(uri))
+
+	if _, ok := items["fleet/web"]; !ok {
+		t.Errorf("Expected the item is written but got: %v", items)
+	}
+}
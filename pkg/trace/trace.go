@@ -0,0 +1,119 @@
+// Package trace emits the OpenTelemetry spans of the fetches of the catalogs
+// and the orders, with the tracers of the trace.TracerProvider configured by
+// the program, so the runs inside the CI/CD pipelines show up in their traces.
+package trace
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	schemeapi "github.com/yuxki/cannect/pkg/scheme"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracers of cannect.
+const InstrumentationName = "github.com/yuxki/cannect"
+
+// ContextFromEnv returns the context continuing the trace of the TRACEPARENT
+// and the TRACESTATE environment variables, set by the CI/CD pipelines. The
+// context is returned as it is if TRACEPARENT is not set or invalid.
+func ContextFromEnv(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{
+		"traceparent": os.Getenv("TRACEPARENT"),
+		"tracestate":  os.Getenv("TRACESTATE"),
+	}
+
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// end ends the span, setting its status to the error if it is not nil.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Catalog is the Catalog emitting the span of each fetch.
+type Catalog struct {
+	catalog orderapi.Catalog
+	alias   string
+	uri     string
+	tracer  trace.Tracer
+}
+
+func NewCatalog(catalog orderapi.Catalog, alias, uri string, provider trace.TracerProvider) *Catalog {
+	return &Catalog{catalog: catalog, alias: alias, uri: uri, tracer: provider.Tracer(InstrumentationName)}
+}
+
+func (c *Catalog) start(ctx context.Context) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "cannect.fetch", trace.WithAttributes(
+		attribute.String("cannect.alias", c.alias),
+		attribute.String("cannect.scheme", schemeapi.Of(c.uri)),
+		attribute.String("cannect.source", c.uri),
+	))
+}
+
+func (c *Catalog) Fetch(ctx context.Context) ([]byte, error) {
+	ctx, span := c.start(ctx)
+
+	buf, err := c.catalog.Fetch(ctx)
+	end(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// Open emits the span ending when the content is read to the end, or fails.
+func (c *Catalog) Open(ctx context.Context) (io.ReadCloser, error) {
+	ctx, span := c.start(ctx)
+
+	r, err := orderapi.Open(ctx, c.catalog)
+	if err != nil {
+		end(span, err)
+		return nil, err
+	}
+
+	return orderapi.OnReadEnd(r, func(_ int, err error) error {
+		end(span, err)
+		return err
+	}), nil
+}
+
+func (c *Catalog) Streams() bool {
+	return orderapi.Streams(c.catalog)
+}
+
+// Order is the Order emitting the span of each order.
+type Order struct {
+	order   orderapi.Order
+	uri     string
+	aliases []string
+	tracer  trace.Tracer
+}
+
+func NewOrder(order orderapi.Order, uri string, aliases []string, provider trace.TracerProvider) *Order {
+	return &Order{order: order, uri: uri, aliases: aliases, tracer: provider.Tracer(InstrumentationName)}
+}
+
+func (o *Order) Order(ctx context.Context) error {
+	ctx, span := o.tracer.Start(ctx, "cannect.order", trace.WithAttributes(
+		attribute.String("cannect.aliases", strings.Join(o.aliases, ",")),
+		attribute.String("cannect.scheme", schemeapi.Of(o.uri)),
+		attribute.String("cannect.destination", o.uri),
+	))
+
+	err := o.order.Order(ctx)
+	end(span, err)
+
+	return err
+}
@@ -0,0 +1,116 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	orderapi "github.com/yuxki/cannect/pkg/order"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errTest = errors.New("test failed")
+
+type testCatalog struct {
+	err error
+}
+
+func (t testCatalog) Fetch(ctx context.Context) ([]byte, error) {
+	return []byte("content"), t.err
+}
+
+type testOrder struct {
+	catalogs []orderapi.Catalog
+}
+
+func (t testOrder) Order(ctx context.Context) error {
+	for _, c := range t.catalogs {
+		_, err := c.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestOrder_Spans(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled, Remote: true,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+
+	broken := NewCatalog(testCatalog{err: errTest}, "sub", "github://pki/sub-ca.crt", provider)
+	order := NewOrder(testOrder{catalogs: []orderapi.Catalog{broken}}, "s3://pki/ca-bundle.crt", []string{"sub"}, provider)
+	err := order.Order(ctx)
+	if !errors.Is(err, errTest) {
+		t.Fatalf("Expected %#v error but got: %#v", errTest, err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans but got: %d", len(spans))
+	}
+	fetch, ord := spans[0], spans[1]
+
+	for _, s := range spans {
+		if s.SpanContext().TraceID() != traceID {
+			t.Errorf("Expected the trace of the parent but got: %s", s.SpanContext().TraceID())
+		}
+		if s.Status().Code != codes.Error || s.Status().Description != errTest.Error() {
+			t.Errorf("Expected the error status but got: %#v", s.Status())
+		}
+	}
+	if ord.Parent().SpanID() != spanID || fetch.Parent().SpanID() != ord.SpanContext().SpanID() {
+		t.Errorf("Expected the fetch in the order in the parent but got: %s, %s", ord.Parent().SpanID(), fetch.Parent().SpanID())
+	}
+	if ord.Name() != "cannect.order" || fetch.Name() != "cannect.fetch" {
+		t.Errorf("Expected the names of the spans but got: %s, %s", ord.Name(), fetch.Name())
+	}
+
+	want := []attribute.KeyValue{
+		attribute.String("cannect.aliases", "sub"),
+		attribute.String("cannect.scheme", "s3"),
+		attribute.String("cannect.destination", "s3://pki/ca-bundle.crt"),
+	}
+	if diff := cmp.Diff(want, ord.Attributes(), cmp.AllowUnexported(attribute.Value{})); diff != "" {
+		t.Error(diff)
+	}
+
+	want = []attribute.KeyValue{
+		attribute.String("cannect.alias", "sub"),
+		attribute.String("cannect.scheme", "github"),
+		attribute.String("cannect.source", "github://pki/sub-ca.crt"),
+	}
+	if diff := cmp.Diff(want, fetch.Attributes(), cmp.AllowUnexported(attribute.Value{})); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestContextFromEnv(t *testing.T) {
+	t.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	sc := trace.SpanContextFromContext(ContextFromEnv(context.Background()))
+	if sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID().String() != "00f067aa0ba902b7" ||
+		!sc.IsSampled() || !sc.IsRemote() {
+		t.Errorf("Expected the span of TRACEPARENT but got: %v", sc)
+	}
+
+	t.Setenv("TRACEPARENT", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	sc = trace.SpanContextFromContext(ContextFromEnv(context.Background()))
+	if sc.IsValid() {
+		t.Errorf("Expected no span but got: %v", sc)
+	}
+}
@@ -0,0 +1,259 @@
+package transform
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var (
+	ErrNoBaseCRL     = errors.New("exactly one base CRL is required")
+	ErrNoCRLSigner   = errors.New("issuer certificate and private key of CRLs are not found")
+	ErrCRLMismatch   = errors.New("delta CRL does not match base CRL")
+	ErrNotCRLSigner  = errors.New("private key does not match issuer certificate")
+	errNotSignerType = errors.New("private key is not a signer")
+)
+
+var (
+	oidDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+	oidCRLReason         = asn1.ObjectIdentifier{2, 5, 29, 21}
+)
+
+// removeFromCRL is the reason code of the delta CRL entry, that means the
+// certificate is no longer revoked, like the release of the certificateHold.
+const removeFromCRL = 8
+
+// MergeCRL merges a base CRL and the delta CRLs into a complete CRL, for the
+// consumers that cannot process the delta CRLs. The content must have the base
+// CRL, the delta CRLs, and the issuer certificate and the private key to sign
+// the merged CRL. The delta CRLs are applied in the order of the CRL numbers,
+// and the ones that are older than the base CRL are ignored. The merged CRL
+// has the CRL number and the validity of the latest CRL. Only the merged CRL
+// is returned, so the private key is never written to the destination.
+type MergeCRL struct{}
+
+func NewMergeCRL() MergeCRL {
+	return MergeCRL{}
+}
+
+func (m MergeCRL) Transform(content []byte) ([]byte, error) {
+	var base *x509.RevocationList
+	var deltas []*x509.RevocationList
+	var certs []*x509.Certificate
+	var signer crypto.Signer
+
+	rest := gluedEndReg.ReplaceAll(content, []byte("$1\n$2"))
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "X509 CRL":
+			crl, err := x509.ParseRevocationList(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+
+			if isDeltaCRL(crl) {
+				deltas = append(deltas, crl)
+				continue
+			}
+			if base != nil {
+				return nil, ErrNoBaseCRL
+			}
+			base = crl
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		case "PRIVATE KEY", "EC PRIVATE KEY", "RSA PRIVATE KEY":
+			key, err := parseSigner(block)
+			if err != nil {
+				return nil, err
+			}
+			signer = key
+		}
+	}
+
+	if base == nil {
+		return nil, ErrNoBaseCRL
+	}
+
+	issuer := crlIssuer(base, certs)
+	if issuer == nil || signer == nil {
+		return nil, ErrNoCRLSigner
+	}
+	if !publicKeyEqual(issuer.PublicKey, signer.Public()) {
+		return nil, ErrNotCRLSigner
+	}
+
+	revoked, latest, err := applyDeltas(base, deltas, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificates: revoked,
+		Number:              latest.Number,
+		ThisUpdate:          latest.ThisUpdate,
+		NextUpdate:          latest.NextUpdate,
+	}, issuer, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+// applyDeltas returns the revoked certificates of the base CRL updated by the
+// delta CRLs, and the latest CRL.
+func applyDeltas(
+	base *x509.RevocationList, deltas []*x509.RevocationList, issuer *x509.Certificate,
+) ([]pkix.RevokedCertificate, *x509.RevocationList, error) {
+	err := base.CheckSignatureFrom(issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].Number.Cmp(deltas[j].Number) < 0
+	})
+
+	entries := make(map[string]pkix.RevokedCertificate)
+	for _, rc := range base.RevokedCertificates {
+		entries[rc.SerialNumber.String()] = rc
+	}
+
+	latest := base
+	for _, delta := range deltas {
+		if delta.Number.Cmp(base.Number) <= 0 {
+			// The changes are already in the base CRL.
+			continue
+		}
+
+		baseNumber, err := deltaBaseNumber(delta)
+		if err != nil || baseNumber.Cmp(base.Number) > 0 || !bytes.Equal(delta.RawIssuer, base.RawIssuer) {
+			return nil, nil, fmt.Errorf("CRL number %s: %w", delta.Number, ErrCRLMismatch)
+		}
+
+		err = delta.CheckSignatureFrom(issuer)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, rc := range delta.RevokedCertificates {
+			if crlReason(rc) == removeFromCRL {
+				delete(entries, rc.SerialNumber.String())
+				continue
+			}
+			entries[rc.SerialNumber.String()] = rc
+		}
+
+		latest = delta
+	}
+
+	revoked := make([]pkix.RevokedCertificate, 0, len(entries))
+	for _, rc := range entries {
+		revoked = append(revoked, rc)
+	}
+	sort.Slice(revoked, func(i, j int) bool {
+		return revoked[i].SerialNumber.Cmp(revoked[j].SerialNumber) < 0
+	})
+
+	return revoked, latest, nil
+}
+
+func isDeltaCRL(crl *x509.RevocationList) bool {
+	for _, ext := range crl.Extensions {
+		if ext.Id.Equal(oidDeltaCRLIndicator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deltaBaseNumber returns the base CRL number of the delta CRL indicator
+// extension.
+func deltaBaseNumber(crl *x509.RevocationList) (*big.Int, error) {
+	number := new(big.Int)
+	for _, ext := range crl.Extensions {
+		if ext.Id.Equal(oidDeltaCRLIndicator) {
+			_, err := asn1.Unmarshal(ext.Value, &number)
+			return number, err
+		}
+	}
+
+	return nil, ErrCRLMismatch
+}
+
+func crlReason(rc pkix.RevokedCertificate) asn1.Enumerated {
+	for _, ext := range rc.Extensions {
+		if !ext.Id.Equal(oidCRLReason) {
+			continue
+		}
+
+		var reason asn1.Enumerated
+		_, err := asn1.Unmarshal(ext.Value, &reason)
+		if err != nil {
+			return -1
+		}
+		return reason
+	}
+
+	return -1
+}
+
+// crlIssuer returns the certificate that signed the CRL.
+func crlIssuer(crl *x509.RevocationList, certs []*x509.Certificate) *x509.Certificate {
+	for _, cert := range certs {
+		if crl.CheckSignatureFrom(cert) == nil {
+			return cert
+		}
+	}
+
+	return nil
+}
+
+func parseSigner(block *pem.Block) (crypto.Signer, error) {
+	var key interface{}
+	var err error
+
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errNotSignerType
+	}
+
+	return signer, nil
+}
+
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	k, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && k.Equal(b)
+}
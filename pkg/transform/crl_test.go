@@ -0,0 +1,210 @@
+package transform
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+func testRevoked(t *testing.T, serial int64, reason int) pkix.RevokedCertificate {
+	t.Helper()
+
+	rc := pkix.RevokedCertificate{SerialNumber: big.NewInt(serial), RevocationTime: time.Now().UTC()}
+	if reason != 0 {
+		value, err := asn1.Marshal(asn1.Enumerated(reason))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc.Extensions = []pkix.Extension{{Id: oidCRLReason, Value: value}}
+	}
+
+	return rc
+}
+
+// testGenCRL returns the CRL of the number. It is a delta CRL if baseNumber is
+// not zero.
+func testGenCRL(
+	t *testing.T, issuer *testca.Cert, number, baseNumber int64, revoked []pkix.RevokedCertificate,
+) []byte {
+	t.Helper()
+
+	tmpl := &x509.RevocationList{
+		RevokedCertificates: revoked,
+		Number:              big.NewInt(number),
+		ThisUpdate:          time.Now().Add(time.Duration(number) * time.Minute),
+		NextUpdate:          time.Now().Add(time.Duration(number) * time.Hour),
+	}
+	if baseNumber != 0 {
+		value, err := asn1.Marshal(big.NewInt(baseNumber))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl.ExtraExtensions = []pkix.Extension{{Id: oidDeltaCRLIndicator, Critical: true, Value: value}}
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, issuer.Cert, issuer.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func TestMergeCRL(t *testing.T) {
+	t.Parallel()
+
+	ca, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := ca.Intermediate
+	issuerPEM := issuer.CertPEM()
+	issuerKeyPEM, err := issuer.KeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeyPEM, err := other.Intermediate.KeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// certificateHold is 6
+	base := testGenCRL(t, issuer, 10, 0, []pkix.RevokedCertificate{
+		testRevoked(t, 1, 0), testRevoked(t, 2, 6),
+	})
+	delta11 := testGenCRL(t, issuer, 11, 10, []pkix.RevokedCertificate{
+		testRevoked(t, 2, removeFromCRL), testRevoked(t, 3, 0),
+	})
+	delta12 := testGenCRL(t, issuer, 12, 10, []pkix.RevokedCertificate{
+		testRevoked(t, 3, 0), testRevoked(t, 4, 0),
+	})
+	delta9 := testGenCRL(t, issuer, 9, 8, []pkix.RevokedCertificate{
+		testRevoked(t, 5, 0),
+	})
+	deltaNewerBase := testGenCRL(t, issuer, 13, 11, []pkix.RevokedCertificate{
+		testRevoked(t, 6, 0),
+	})
+
+	join := func(contents ...[]byte) []byte {
+		var buf []byte
+		for _, c := range contents {
+			buf = append(buf, c...)
+		}
+		return buf
+	}
+
+	data := []struct {
+		testcase string
+		// input
+		content []byte
+		// want
+		serials []int64
+		number  int64
+		err     error
+	}{
+		{
+			"OK:apply deltas in order of numbers",
+			join(issuerPEM, delta12, base, delta11, delta9, issuerKeyPEM),
+			[]int64{1, 3, 4},
+			12,
+			nil,
+		},
+		{
+			"OK:no delta",
+			join(base, issuerPEM, issuerKeyPEM),
+			[]int64{1, 2},
+			10,
+			nil,
+		},
+		{
+			"NG:delta of newer base",
+			join(base, deltaNewerBase, issuerPEM, issuerKeyPEM),
+			nil,
+			0,
+			ErrCRLMismatch,
+		},
+		{
+			"NG:no base",
+			join(delta11, issuerPEM, issuerKeyPEM),
+			nil,
+			0,
+			ErrNoBaseCRL,
+		},
+		{
+			"NG:two bases",
+			join(base, base, issuerPEM, issuerKeyPEM),
+			nil,
+			0,
+			ErrNoBaseCRL,
+		},
+		{
+			"NG:no key",
+			join(base, issuerPEM),
+			nil,
+			0,
+			ErrNoCRLSigner,
+		},
+		{
+			"NG:key of other issuer",
+			join(base, issuerPEM, otherKeyPEM),
+			nil,
+			0,
+			ErrNotCRLSigner,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewMergeCRL().Transform(d.content)
+			if d.err != nil {
+				if !errors.Is(err, d.err) {
+					t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			block, rest := pem.Decode(got)
+			if block == nil || block.Type != "X509 CRL" || len(rest) != 0 {
+				t.Fatalf("Expected only one CRL but got: %s", got)
+			}
+
+			crl, err := x509.ParseRevocationList(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := crl.CheckSignatureFrom(issuer.Cert); err != nil {
+				t.Fatal(err)
+			}
+
+			var serials []int64
+			for _, rc := range crl.RevokedCertificates {
+				serials = append(serials, rc.SerialNumber.Int64())
+			}
+			if diff := cmp.Diff(serials, d.serials); diff != "" {
+				t.Error(diff)
+			}
+			if crl.Number.Int64() != d.number {
+				t.Errorf("Expected CRL number is %d but got: %s", d.number, crl.Number)
+			}
+		})
+	}
+}
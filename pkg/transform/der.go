@@ -0,0 +1,140 @@
+package transform
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/yuxki/cannect/internal/pkcs7"
+)
+
+// ErrUnknownDER means the DER in the content is not a certificate, a CRL or a
+// PKCS #7 bundle.
+var ErrUnknownDER = errors.New("DER is not a certificate, a CRL or a PKCS #7 bundle")
+
+var pemBegin = []byte("-----BEGIN ")
+
+// ToDER converts the PEM blocks in the content to DER, for the appliances
+// requiring the .cer and .crl files in DER. The DER of the blocks are
+// concatenated without separators, and the DER in the content is kept as it is,
+// so the catalogs of PEM and DER can be concatenated.
+type ToDER struct{}
+
+func NewToDER() ToDER {
+	return ToDER{}
+}
+
+func (t ToDER) Transform(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := scanAssets(content, func(block *pem.Block) error {
+		buf.Write(block.Bytes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ToPEM converts the DER of the certificates, the CRLs and the PKCS #7 bundles
+// in the content to PEM, so the catalogs in DER can be served to the consumers
+// of PEM. The PEM blocks in the content are kept, and the texts outside them
+// are removed.
+type ToPEM struct{}
+
+func NewToPEM() ToPEM {
+	return ToPEM{}
+}
+
+func (t ToPEM) Transform(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := scanAssets(content, func(block *pem.Block) error {
+		return pem.Encode(&buf, block)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// scanAssets calls the function with each PEM block and each DER in the
+// content. The type of the DER is detected by parsing it.
+func scanAssets(content []byte, f func(*pem.Block) error) error {
+	var found bool
+
+	rest := content
+	for len(rest) > 0 {
+		block, next, err := nextAsset(rest)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			break
+		}
+		found = true
+		rest = next
+
+		err = f(block)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !found {
+		return ErrNoPEMBlock
+	}
+
+	return nil
+}
+
+// nextAsset returns the first PEM block or DER in the content, and the rest.
+// The texts and the newlines before the PEM block are skipped. The block is
+// nil if no asset is found.
+func nextAsset(content []byte) (*pem.Block, []byte, error) {
+	idx := bytes.Index(content, pemBegin)
+
+	// The DER starts with the tag of SEQUENCE
+	if content[0] == 0x30 && idx != 0 {
+		var raw asn1.RawValue
+		rest, err := asn1.Unmarshal(content, &raw)
+		if err == nil {
+			typ, err := derType(raw.FullBytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &pem.Block{Type: typ, Bytes: raw.FullBytes}, rest, nil
+		}
+		if idx < 0 {
+			return nil, nil, fmt.Errorf("%s: %w", err, ErrUnknownDER)
+		}
+	}
+
+	if idx < 0 {
+		return nil, nil, nil
+	}
+	block, rest := pem.Decode(content[idx:])
+
+	return block, rest, nil
+}
+
+// derType returns the type of the PEM block of the DER.
+func derType(der []byte) (string, error) {
+	if _, err := x509.ParseCertificate(der); err == nil {
+		return "CERTIFICATE", nil
+	}
+	if _, err := x509.ParseRevocationList(der); err == nil {
+		return "X509 CRL", nil
+	}
+	if _, err := pkcs7.Parse(der); err == nil {
+		return "PKCS7", nil
+	}
+
+	return "", ErrUnknownDER
+}
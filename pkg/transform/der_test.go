@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+func TestToDER_ToPEM(t *testing.T) {
+	t.Parallel()
+
+	ca, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlPEM, err := ca.Intermediate.CRLPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(crlPEM)
+
+	rootPEM, rootDER := string(ca.Root.CertPEM()), string(ca.Root.Cert.Raw)
+	subPEM, subDER := string(ca.Intermediate.CertPEM()), string(ca.Intermediate.Cert.Raw)
+	crlDER := string(block.Bytes)
+
+	data := []struct {
+		testCase string
+		content  string
+		// want
+		der string
+		pem string
+		err error
+	}{
+		{"PEM", "# root\n" + rootPEM + subPEM, rootDER + subDER, rootPEM + subPEM, nil},
+		{"DER", rootDER + crlDER, rootDER + crlDER, rootPEM + string(crlPEM), nil},
+		{"PEM And DER", rootDER + "\n" + subPEM + crlDER, rootDER + subDER + crlDER, rootPEM + subPEM + string(crlPEM), nil},
+		{"Empty", "", "", "", ErrNoPEMBlock},
+		{"Text", "root certificate\n", "", "", ErrNoPEMBlock},
+		{"Not Certificate", "\x30\x03\x02\x01\x01", "", "", ErrUnknownDER},
+		{"Broken DER", "\x30\x82\xff", "", "", ErrUnknownDER},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			der, err := NewToDER().Transform([]byte(d.content))
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if diff := cmp.Diff(string(der), d.der); diff != "" {
+				t.Error(diff)
+			}
+
+			got, err := NewToPEM().Transform([]byte(d.content))
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if diff := cmp.Diff(string(got), d.pem); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
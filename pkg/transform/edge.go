@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoRootCertificate = errors.New("self-signed root certificate is not found")
+	ErrOverBudget        = errors.New("content exceeds the size budget")
+)
+
+// Edge minimizes the PEM content for the constrained devices, like the
+// microcontrollers with a few kilobytes for the trust store. The texts outside
+// the blocks and the headers in the blocks are removed, and the blocks are
+// converted to DER, which is about three quarters of the size of PEM, if it
+// is enabled. The DER of the blocks are concatenated without separators.
+type Edge struct {
+	der      bool
+	rootOnly bool
+	budget   int
+}
+
+func NewEdge() Edge {
+	return Edge{}
+}
+
+// WithDER makes Edge convert the blocks to DER.
+func (e Edge) WithDER() Edge {
+	e.der = true
+	return e
+}
+
+// WithRootOnly makes Edge keep only the self-signed root certificates, for
+// the devices that verify the chains sent by the servers.
+func (e Edge) WithRootOnly() Edge {
+	e.rootOnly = true
+	return e
+}
+
+// WithBudget makes Edge fail if the size of the minimized content exceeds
+// the budget in bytes, so the content that does not fit in the devices is
+// never distributed.
+func (e Edge) WithBudget(budget int) Edge {
+	e.budget = budget
+	return e
+}
+
+func (e Edge) Transform(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var found bool
+
+	rest := gluedEndReg.ReplaceAll(content, []byte("$1\n$2"))
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		found = true
+
+		if e.rootOnly && !isRootCertificate(block) {
+			continue
+		}
+
+		if e.der {
+			buf.Write(block.Bytes)
+			continue
+		}
+
+		err := pem.Encode(&buf, &pem.Block{Type: block.Type, Bytes: block.Bytes})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !found {
+		return nil, ErrNoPEMBlock
+	}
+	if buf.Len() == 0 {
+		return nil, ErrNoRootCertificate
+	}
+	if e.budget > 0 && buf.Len() > e.budget {
+		return nil, fmt.Errorf("%d bytes for %d bytes budget: %w", buf.Len(), e.budget, ErrOverBudget)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isRootCertificate reports whether the block is a certificate signed by
+// itself.
+func isRootCertificate(block *pem.Block) bool {
+	if block.Type != "CERTIFICATE" {
+		return false
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
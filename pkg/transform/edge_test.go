@@ -0,0 +1,60 @@
+package transform
+
+import (
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+func TestEdge(t *testing.T) {
+	t.Parallel()
+
+	ca, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, sub := ca.Root.Cert.Raw, ca.Intermediate.Cert.Raw
+	rootPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root})
+	subPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: sub})
+	withHeaders := pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Headers: map[string]string{"Comment": "sub"}, Bytes: sub,
+	})
+	content := "subject=CN = cannect Test Intermediate CA\n" + string(withHeaders) + "Bag Attributes\n" + string(rootPEM)
+
+	data := []struct {
+		testcase string
+		// input
+		edge    Edge
+		content string
+		// want
+		want string
+		err  error
+	}{
+		{"OK:strip", NewEdge(), content, string(subPEM) + string(rootPEM), nil},
+		{"OK:der", NewEdge().WithDER(), content, string(sub) + string(root), nil},
+		{"OK:root only", NewEdge().WithRootOnly().WithDER(), content, string(root), nil},
+		{"OK:within budget", NewEdge().WithRootOnly().WithDER().WithBudget(len(root)), content, string(root), nil},
+		{"NG:over budget", NewEdge().WithDER().WithBudget(len(root)), content, "", ErrOverBudget},
+		{"NG:no root", NewEdge().WithRootOnly(), string(subPEM), "", ErrNoRootCertificate},
+		{"NG:no block", NewEdge(), "garbage", "", ErrNoPEMBlock},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := d.edge.Transform([]byte(d.content))
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if diff := cmp.Diff(string(got), d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package transform
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"regexp"
+	"time"
+)
+
+// PEMFilter filters the PEM blocks in the content fetched by a catalog, for
+// the sources that are large combined bundles of which only a part should be
+// distributed. The texts outside the blocks are removed. The conditions of the
+// certificates are applied only to the "CERTIFICATE" blocks, and the other
+// blocks are kept unless they are excluded by the types.
+type PEMFilter struct {
+	types          map[string]struct{}
+	excludeExpired bool
+	subject        *regexp.Regexp
+	now            func() time.Time
+}
+
+func NewPEMFilter() PEMFilter {
+	return PEMFilter{
+		now: time.Now,
+	}
+}
+
+// WithTypes makes the PEMFilter keep only the blocks of the types, like
+// "CERTIFICATE".
+func (p PEMFilter) WithTypes(types ...string) PEMFilter {
+	p.types = make(map[string]struct{}, len(types))
+	for _, t := range types {
+		p.types[t] = struct{}{}
+	}
+	return p
+}
+
+// WithExcludeExpired makes the PEMFilter remove the expired certificates.
+func (p PEMFilter) WithExcludeExpired() PEMFilter {
+	p.excludeExpired = true
+	return p
+}
+
+// WithClock makes the PEMFilter decide whether the certificates are expired at
+// the time returned by the now instead of the current time, like a fixed time
+// of the tests.
+func (p PEMFilter) WithClock(now func() time.Time) PEMFilter {
+	p.now = now
+	return p
+}
+
+// WithSubject makes the PEMFilter keep only the certificates whose subject in
+// the RFC2253 form, like "CN=Sub CA,O=Example", matches the pattern.
+func (p PEMFilter) WithSubject(pattern *regexp.Regexp) PEMFilter {
+	p.subject = pattern
+	return p
+}
+
+func (p PEMFilter) Filter(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	rest := gluedEndReg.ReplaceAll(content, []byte("$1\n$2"))
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		ok, err := p.match(block)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		err = pem.Encode(&buf, block)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (p PEMFilter) match(block *pem.Block) (bool, error) {
+	if p.types != nil {
+		if _, ok := p.types[block.Type]; !ok {
+			return false, nil
+		}
+	}
+
+	if block.Type != "CERTIFICATE" || (!p.excludeExpired && p.subject == nil) {
+		return true, nil
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false, err
+	}
+
+	if p.excludeExpired && p.now().After(cert.NotAfter) {
+		return false, nil
+	}
+
+	if p.subject != nil && !p.subject.MatchString(cert.Subject.String()) {
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,97 @@
+package transform
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+func TestPEMFilter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// gen returns the certificate valid until an hour after the time.
+	gen := func(cn string, at time.Time) []byte {
+		tmpl := testca.Template(1, cn, at)
+		tmpl.Subject.Organization = []string{"Example"}
+		cert, err := testca.Issue(tmpl, nil, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert.CertPEM()
+	}
+	root := gen("Root CA", now.Add(23*time.Hour))
+	sub := gen("Sub CA", now.Add(23*time.Hour))
+	expired := gen("Old Sub CA", now.Add(-2*time.Hour))
+	crl := []byte("-----BEGIN X509 CRL-----\nQUJD\n-----END X509 CRL-----\n")
+
+	join := func(contents ...[]byte) string {
+		var buf []byte
+		for _, c := range contents {
+			buf = append(buf, c...)
+		}
+		return string(buf)
+	}
+	content := []byte("Bag Attributes\n" + join(root, crl, sub, expired))
+
+	data := []struct {
+		testcase string
+		// input
+		filter PEMFilter
+		// want
+		want string
+	}{
+		{
+			"OK:no condition",
+			NewPEMFilter(),
+			join(root, crl, sub, expired),
+		},
+		{
+			"OK:types",
+			NewPEMFilter().WithTypes("CERTIFICATE"),
+			join(root, sub, expired),
+		},
+		{
+			"OK:exclude expired",
+			NewPEMFilter().WithExcludeExpired(),
+			join(root, crl, sub),
+		},
+		{
+			"OK:subject",
+			NewPEMFilter().WithTypes("CERTIFICATE").WithSubject(regexp.MustCompile("^CN=(Old )?Sub CA,")),
+			join(sub, expired),
+		},
+		{
+			"OK:all conditions",
+			NewPEMFilter().WithTypes("CERTIFICATE").WithExcludeExpired().WithSubject(regexp.MustCompile("Sub CA")),
+			join(sub),
+		},
+		{
+			"OK:nothing matched",
+			NewPEMFilter().WithTypes("PRIVATE KEY"),
+			"",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			filter := d.filter.WithClock(func() time.Time { return now })
+
+			got, err := filter.Filter(content)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(string(got), d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
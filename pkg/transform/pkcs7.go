@@ -0,0 +1,62 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/yuxki/cannect/internal/pkcs7"
+)
+
+var pkcs7Begin = []byte("-----BEGIN PKCS7-----")
+
+// UnwrapPKCS7 replaces each PKCS #7 bundle in the content with the PEM blocks of
+// its certificates and CRLs, in the order of the bundle, like
+// "openssl pkcs7 -print_certs". The rest of the content is kept as it is, so
+// the bundles can be concatenated with the other assets.
+type UnwrapPKCS7 struct{}
+
+func NewUnwrapPKCS7() UnwrapPKCS7 {
+	return UnwrapPKCS7{}
+}
+
+func (u UnwrapPKCS7) Transform(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	rest := content
+	for {
+		idx := bytes.Index(rest, pkcs7Begin)
+		if idx < 0 {
+			break
+		}
+		buf.Write(rest[:idx])
+
+		var block *pem.Block
+		block, rest = pem.Decode(rest[idx:])
+		if block == nil || block.Type != "PKCS7" {
+			// The bundle is broken, and the next block is decoded
+			return nil, fmt.Errorf("%s: %w", pkcs7Begin, ErrNoPEMBlock)
+		}
+
+		bundle, err := pkcs7.Parse(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cert := range bundle.Certificates {
+			err = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, crl := range bundle.CRLs {
+			err = pem.Encode(&buf, &pem.Block{Type: "X509 CRL", Bytes: crl.Raw})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	buf.Write(rest)
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,81 @@
+package transform
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuxki/cannect/internal/pkcs7"
+	"github.com/yuxki/cannect/internal/testca"
+)
+
+func TestUnwrapPKCS7(t *testing.T) {
+	t.Parallel()
+
+	ca, err := testca.New(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlPEM, err := ca.Intermediate.CRLPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(crlPEM)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := pkcs7.Marshal(pkcs7.Bundle{
+		Certificates: []*x509.Certificate{ca.Leaf.Cert, ca.Intermediate.Cert},
+		CRLs:         []*x509.RevocationList{crl},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p7bPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: der}))
+	rootPEM := string(ca.Root.CertPEM())
+	unwrapped := string(ca.Leaf.CertPEM()) + string(ca.Intermediate.CertPEM()) + string(crlPEM)
+
+	data := []struct {
+		testCase string
+		content  string
+		// want
+		want string
+		err  error
+	}{
+		{"Bundle", p7bPEM, unwrapped, nil},
+		{"Bundle And Certificate", "# chain\n" + p7bPEM + rootPEM, "# chain\n" + unwrapped + rootPEM, nil},
+		{"No Bundle", rootPEM, rootPEM, nil},
+		{"Broken Bundle", "-----BEGIN PKCS7-----\n" + rootPEM, "", ErrNoPEMBlock},
+		{
+			"Not SignedData", string(pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: ca.Root.Cert.Raw})),
+			"", pkcs7.ErrNotSignedData,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testCase, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewUnwrapPKCS7().Transform([]byte(d.content))
+			if d.err != nil {
+				if !errors.Is(err, d.err) {
+					t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(string(got), d.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
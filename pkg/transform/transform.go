@@ -0,0 +1,65 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"regexp"
+)
+
+// ErrNoPEMBlock means the content does not have any PEM block.
+var ErrNoPEMBlock = errors.New("PEM block is not found")
+
+// gluedEndReg matches the end line of a PEM block that is not followed by a
+// newline, like "-----END CERTIFICATE----------BEGIN CERTIFICATE-----".
+var gluedEndReg = regexp.MustCompile(`(-----END [^-\r\n]+-----)([^\r\n])`)
+
+// Normalize normalizes the PEM content. It re-encodes every PEM block, so
+// the base64 lines are wrapped at 64 columns and every block ends with a
+// newline, even if the blocks are glued without newlines. The texts before, between and after the blocks, such as
+// "Bag Attributes" or the text form of the certificate that openssl prints,
+// are removed.
+type Normalize struct {
+	stripHeaders bool
+}
+
+func NewNormalize() Normalize {
+	return Normalize{}
+}
+
+// WithStripHeaders makes Normalize remove the headers in the PEM blocks,
+// such as "Proc-Type" and "DEK-Info". Note that the headers are required to
+// decrypt the legacy encrypted private keys.
+func (n Normalize) WithStripHeaders() Normalize {
+	n.stripHeaders = true
+	return n
+}
+
+func (n Normalize) Transform(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	rest := gluedEndReg.ReplaceAll(content, []byte("$1\n$2"))
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if n.stripHeaders {
+			block.Headers = nil
+		}
+
+		err := pem.Encode(&buf, block)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if buf.Len() == 0 {
+		return nil, ErrNoPEMBlock
+	}
+
+	return buf.Bytes(), nil
+}
@@ -3,13 +3,19 @@ package uri
 import (
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
+	"strings"
 )
 
 // ErrInvalidURI is an error that should be used when attempting to use an
 // invalid URI.
 var ErrInvalidURI = errors.New("invalid uri")
 
+// ErrOutsideRoot is returned when the path of the URI escapes from the root
+// directory.
+var ErrOutsideRoot = errors.New("path is outside the root directory")
+
 // FSURI represents a URI to a local file.
 type FSURI struct {
 	text   string
@@ -17,21 +23,29 @@ type FSURI struct {
 	path   string
 }
 
+// NewFSURI returns the FSURI of the URI. The Windows absolute paths with the drive
+// letters are also accepted, like "file://C:/certs/out.pem" and
+// "file://C:\certs\out.pem", and the backslashes in the path are replaced with
+// the slashes.
 func NewFSURI(uri string) (FSURI, error) {
 	var fsURI FSURI
 
 	reg := regexp.MustCompile("^(file):///?((?:[-_a-z0-9A-Z]+)(?:/[-_a-z0-9A-Z.]+)*)$")
-	mt := reg.MatchString(uri)
-	if !mt {
+	winReg := regexp.MustCompile(`^(file):///?([a-zA-Z]:(?:[/\\][-_a-z0-9A-Z.]+)+)$`)
+
+	submt := reg.FindStringSubmatch(uri)
+	if submt == nil {
+		submt = winReg.FindStringSubmatch(uri)
+	}
+	if submt == nil {
 		return fsURI, fmt.Errorf(
 			"could not match collect File System URI pattern with %s: %w", uri, ErrInvalidURI,
 		)
 	}
 
-	submt := reg.FindAllStringSubmatch(uri, -1)
-	fsURI.text = submt[0][0]
-	fsURI.scheme = submt[0][1]
-	fsURI.path = submt[0][2]
+	fsURI.text = submt[0]
+	fsURI.scheme = submt[1]
+	fsURI.path = strings.ReplaceAll(submt[2], `\`, "/")
 
 	return fsURI, nil
 }
@@ -51,6 +65,19 @@ func (u FSURI) Path() string {
 	return u.path
 }
 
+// Under returns the FSURI whose path is in the root directory, like the -C
+// option of tar. The Text is not changed. The path escaping from the root
+// with "..", and the Windows absolute path, are rejected.
+func (u FSURI) Under(root string) (FSURI, error) {
+	p := path.Clean(u.path)
+	if p == ".." || strings.HasPrefix(p, "../") || regexp.MustCompile(`^[a-zA-Z]:`).MatchString(p) {
+		return u, fmt.Errorf("%s: %w", u.text, ErrOutsideRoot)
+	}
+
+	u.path = path.Join(strings.ReplaceAll(root, `\`, "/"), p)
+	return u, nil
+}
+
 type EnvURI struct {
 	text   string
 	scheme string
@@ -92,6 +119,45 @@ func (u EnvURI) Path() string {
 	return u.path
 }
 
+type StdoutURI struct {
+	text   string
+	scheme string
+}
+
+// NewStdoutURI represents a URI for the standard output. It has no path.
+func NewStdoutURI(uri string) (StdoutURI, error) {
+	var sURI StdoutURI
+
+	reg := regexp.MustCompile("^(stdout)://$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return sURI, fmt.Errorf(
+			"could not match collect Stdout URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	sURI.text = submt[0][0]
+	sURI.scheme = submt[0][1]
+
+	return sURI, nil
+}
+
+// Text returns the full URI as a string.
+func (u StdoutURI) Text() string {
+	return u.text
+}
+
+// Scheme returns the part of scheme in URI.
+func (u StdoutURI) Scheme() string {
+	return u.scheme
+}
+
+// Path returns the part of path in URI. It is always empty.
+func (u StdoutURI) Path() string {
+	return ""
+}
+
 type GitHubURI struct {
 	text     string
 	scheme   string
@@ -160,18 +226,19 @@ func (u GitHubURI) Ref() string {
 }
 
 type S3URI struct {
-	text   string
-	scheme string
-	path   string
-	bucket string
-	key    string
+	text      string
+	scheme    string
+	path      string
+	bucket    string
+	key       string
+	versionID string
 }
 
 // FSURI represents a URI for an AWS S3 GetObject API.
 func NewS3URI(uri string) (S3URI, error) {
 	var s3URI S3URI
 
-	reg := regexp.MustCompile("^(s3)://(([^/]+)/(.*))$")
+	reg := regexp.MustCompile(`^(s3)://(([^/]+)/(.*?)(?:\?versionId=([^&/]+))?)$`)
 	mt := reg.MatchString(uri)
 	if !mt {
 		return s3URI, fmt.Errorf(
@@ -185,6 +252,7 @@ func NewS3URI(uri string) (S3URI, error) {
 	s3URI.path = submt[0][2]
 	s3URI.bucket = submt[0][3]
 	s3URI.key = submt[0][4]
+	s3URI.versionID = submt[0][5]
 
 	return s3URI, nil
 }
@@ -208,3 +276,1022 @@ func (s S3URI) Bucket() string {
 func (s S3URI) Key() string {
 	return s.key
 }
+
+// VersionID returns the version of the object pinned with the versionId
+// query. It is empty for the latest version.
+func (s S3URI) VersionID() string {
+	return s.versionID
+}
+
+type VaultURI struct {
+	text       string
+	scheme     string
+	path       string
+	mount      string
+	secretPath string
+	key        string
+}
+
+// NewVaultURI represents a URI for a secret in the Vault KV version 2 secrets engine.
+// The path is in the "<mount>/data/<secret path>" format of the KV v2 API, and
+// the optional "key" query selects the field the content is written to.
+func NewVaultURI(uri string) (VaultURI, error) {
+	var vURI VaultURI
+
+	word := "[-_a-zA-Z0-9.]"
+	reg := regexp.MustCompile(
+		fmt.Sprintf(`^(vault)://((%s+)/data/(%s+(?:/%s+)*))(?:\?key=(%s+))?$`,
+			word, word, word, word,
+		),
+	)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return vURI, fmt.Errorf(
+			"could not match collect Vault URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	vURI.text = submt[0][0]
+	vURI.scheme = submt[0][1]
+	vURI.path = submt[0][2]
+	vURI.mount = submt[0][3]
+	vURI.secretPath = submt[0][4]
+	vURI.key = submt[0][5]
+
+	return vURI, nil
+}
+
+func (v VaultURI) Text() string {
+	return v.text
+}
+
+func (v VaultURI) Scheme() string {
+	return v.scheme
+}
+
+func (v VaultURI) Path() string {
+	return v.path
+}
+
+func (v VaultURI) Mount() string {
+	return v.mount
+}
+
+func (v VaultURI) SecretPath() string {
+	return v.secretPath
+}
+
+// Key returns the field name in the secret data. It is empty if the URI
+// does not have the "key" query.
+func (v VaultURI) Key() string {
+	return v.key
+}
+
+type SecretsManagerURI struct {
+	text   string
+	scheme string
+	path   string
+}
+
+// NewSecretsManagerURI represents a URI for a secret in AWS Secrets Manager.
+// The path is the name of the secret.
+func NewSecretsManagerURI(uri string) (SecretsManagerURI, error) {
+	var smURI SecretsManagerURI
+
+	reg := regexp.MustCompile("^(secretsmanager)://([-/_+=.@a-zA-Z0-9]+)$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return smURI, fmt.Errorf(
+			"could not match collect Secrets Manager URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	smURI.text = submt[0][0]
+	smURI.scheme = submt[0][1]
+	smURI.path = submt[0][2]
+
+	return smURI, nil
+}
+
+func (s SecretsManagerURI) Text() string {
+	return s.text
+}
+
+func (s SecretsManagerURI) Scheme() string {
+	return s.scheme
+}
+
+func (s SecretsManagerURI) Path() string {
+	return s.path
+}
+
+type SSMURI struct {
+	text     string
+	scheme   string
+	path     string
+	kmsKeyID string
+}
+
+// NewSSMURI represents a URI for a parameter in AWS Systems Manager Parameter
+// Store. The path is the name of the parameter, and the optional "kmsKeyId"
+// query selects the KMS key to encrypt the parameter.
+func NewSSMURI(uri string) (SSMURI, error) {
+	var ssmURI SSMURI
+
+	word := "[-_.a-zA-Z0-9]"
+	reg := regexp.MustCompile(
+		fmt.Sprintf(`^(ssm)://(/?%s+(?:/%s+)*)(?:\?kmsKeyId=([-_/:.a-zA-Z0-9]+))?$`, word, word),
+	)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return ssmURI, fmt.Errorf(
+			"could not match collect SSM URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	ssmURI.text = submt[0][0]
+	ssmURI.scheme = submt[0][1]
+	ssmURI.path = submt[0][2]
+	ssmURI.kmsKeyID = submt[0][3]
+
+	return ssmURI, nil
+}
+
+func (s SSMURI) Text() string {
+	return s.text
+}
+
+func (s SSMURI) Scheme() string {
+	return s.scheme
+}
+
+func (s SSMURI) Path() string {
+	return s.path
+}
+
+// KMSKeyID returns the ID, ARN or alias of the KMS key. It is empty if the
+// URI does not have the "kmsKeyId" query.
+func (s SSMURI) KMSKeyID() string {
+	return s.kmsKeyID
+}
+
+type GCSURI struct {
+	text   string
+	scheme string
+	path   string
+	bucket string
+	object string
+}
+
+// NewGCSURI represents a URI for an object in Google Cloud Storage.
+func NewGCSURI(uri string) (GCSURI, error) {
+	var gcsURI GCSURI
+
+	reg := regexp.MustCompile("^(gcs)://(([^/]+)/(.+))$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return gcsURI, fmt.Errorf(
+			"could not match collect GCS URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	gcsURI.text = submt[0][0]
+	gcsURI.scheme = submt[0][1]
+	gcsURI.path = submt[0][2]
+	gcsURI.bucket = submt[0][3]
+	gcsURI.object = submt[0][4]
+
+	return gcsURI, nil
+}
+
+func (g GCSURI) Text() string {
+	return g.text
+}
+
+func (g GCSURI) Scheme() string {
+	return g.scheme
+}
+
+func (g GCSURI) Path() string {
+	return g.path
+}
+
+func (g GCSURI) Bucket() string {
+	return g.bucket
+}
+
+func (g GCSURI) Object() string {
+	return g.object
+}
+
+type AzBlobURI struct {
+	text      string
+	scheme    string
+	path      string
+	account   string
+	container string
+	blob      string
+}
+
+// NewAzBlobURI represents a URI for a blob in Azure Blob Storage. The path is
+// in the "<storage account>/<container>/<blob>" format.
+func NewAzBlobURI(uri string) (AzBlobURI, error) {
+	var azURI AzBlobURI
+
+	reg := regexp.MustCompile("^(azblob)://(([a-z0-9]+)/([-a-z0-9]+)/(.+))$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return azURI, fmt.Errorf(
+			"could not match collect Azure Blob URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	azURI.text = submt[0][0]
+	azURI.scheme = submt[0][1]
+	azURI.path = submt[0][2]
+	azURI.account = submt[0][3]
+	azURI.container = submt[0][4]
+	azURI.blob = submt[0][5]
+
+	return azURI, nil
+}
+
+func (a AzBlobURI) Text() string {
+	return a.text
+}
+
+func (a AzBlobURI) Scheme() string {
+	return a.scheme
+}
+
+func (a AzBlobURI) Path() string {
+	return a.path
+}
+
+func (a AzBlobURI) Account() string {
+	return a.account
+}
+
+func (a AzBlobURI) Container() string {
+	return a.container
+}
+
+func (a AzBlobURI) Blob() string {
+	return a.blob
+}
+
+type ArchiveURI struct {
+	text   string
+	scheme string
+	path   string
+}
+
+// NewArchiveURI represents a URI for a local zip or tar archive file. The tar
+// archive is compressed with gzip if the path ends with ".gz" or ".tgz".
+func NewArchiveURI(uri string) (ArchiveURI, error) {
+	var aURI ArchiveURI
+
+	reg := regexp.MustCompile("^(zip|tar):///?((?:[-_a-z0-9A-Z]+)(?:/[-_a-z0-9A-Z.]+)*)$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return aURI, fmt.Errorf(
+			"could not match collect Archive URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	aURI.text = submt[0][0]
+	aURI.scheme = submt[0][1]
+	aURI.path = submt[0][2]
+
+	return aURI, nil
+}
+
+func (a ArchiveURI) Text() string {
+	return a.text
+}
+
+func (a ArchiveURI) Scheme() string {
+	return a.scheme
+}
+
+func (a ArchiveURI) Path() string {
+	return a.path
+}
+
+type WebhookURI struct {
+	text   string
+	scheme string
+	path   string
+	host   string
+}
+
+// NewWebhookURI represents a URI for an HTTPS endpoint. The path is in the
+// "<host>[:<port>]/<path>" format, and the query is kept in the text.
+func NewWebhookURI(uri string) (WebhookURI, error) {
+	var wURI WebhookURI
+
+	reg := regexp.MustCompile(`^(https)://(([-a-zA-Z0-9.]+(?::[0-9]+)?)(?:/[^?#\s]*)?)(?:\?[^#\s]*)?$`)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return wURI, fmt.Errorf(
+			"could not match collect Webhook URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	wURI.text = submt[0][0]
+	wURI.scheme = submt[0][1]
+	wURI.path = submt[0][2]
+	wURI.host = submt[0][3]
+
+	return wURI, nil
+}
+
+// Text returns the full URI as a string. It is the URL of the endpoint.
+func (w WebhookURI) Text() string {
+	return w.text
+}
+
+func (w WebhookURI) Scheme() string {
+	return w.scheme
+}
+
+func (w WebhookURI) Path() string {
+	return w.path
+}
+
+func (w WebhookURI) Host() string {
+	return w.host
+}
+
+type KubernetesURI struct {
+	text      string
+	scheme    string
+	path      string
+	namespace string
+	kind      string
+	name      string
+	key       string
+}
+
+// NewKubernetesURI represents a URI for a key of a Secret or a ConfigMap in
+// Kubernetes. The path is in the "<namespace>/<secret|configmap>/<name>"
+// format, and the key is specified by the query "key".
+func NewKubernetesURI(uri string) (KubernetesURI, error) {
+	var kURI KubernetesURI
+
+	reg := regexp.MustCompile(
+		`^(k8s)://(([a-z0-9][-a-z0-9]*)/(secret|configmap)/([a-z0-9][-a-z0-9.]*))(?:\?key=([-._a-zA-Z0-9]+))?$`,
+	)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return kURI, fmt.Errorf(
+			"could not match collect Kubernetes URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	kURI.text = submt[0][0]
+	kURI.scheme = submt[0][1]
+	kURI.path = submt[0][2]
+	kURI.namespace = submt[0][3]
+	kURI.kind = submt[0][4]
+	kURI.name = submt[0][5]
+	kURI.key = submt[0][6]
+
+	return kURI, nil
+}
+
+func (k KubernetesURI) Text() string {
+	return k.text
+}
+
+func (k KubernetesURI) Scheme() string {
+	return k.scheme
+}
+
+func (k KubernetesURI) Path() string {
+	return k.path
+}
+
+func (k KubernetesURI) Namespace() string {
+	return k.namespace
+}
+
+// Kind returns "secret" or "configmap".
+func (k KubernetesURI) Kind() string {
+	return k.kind
+}
+
+func (k KubernetesURI) Name() string {
+	return k.name
+}
+
+// Key returns the key in the data. It is empty if not specified.
+func (k KubernetesURI) Key() string {
+	return k.key
+}
+
+type DockerURI struct {
+	text   string
+	scheme string
+	path   string
+}
+
+// NewDockerURI represents a URI for a Docker secret in a swarm. The path is
+// the name of the secret.
+func NewDockerURI(uri string) (DockerURI, error) {
+	var dURI DockerURI
+
+	reg := regexp.MustCompile("^(docker)://([a-zA-Z0-9][-_.a-zA-Z0-9]*)$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return dURI, fmt.Errorf(
+			"could not match collect Docker URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	dURI.text = submt[0][0]
+	dURI.scheme = submt[0][1]
+	dURI.path = submt[0][2]
+
+	return dURI, nil
+}
+
+func (d DockerURI) Text() string {
+	return d.text
+}
+
+func (d DockerURI) Scheme() string {
+	return d.scheme
+}
+
+func (d DockerURI) Path() string {
+	return d.path
+}
+
+type HelmURI struct {
+	text   string
+	scheme string
+	path   string
+	key    string
+}
+
+// NewHelmURI represents a URI for a local Helm values file. The key is the
+// dotted path of the values the assets are put under, specified by the query
+// "key".
+func NewHelmURI(uri string) (HelmURI, error) {
+	var hURI HelmURI
+
+	reg := regexp.MustCompile(
+		`^(helm):///?((?:[-_a-z0-9A-Z]+)(?:/[-_a-z0-9A-Z.]+)*)(?:\?key=([-_a-zA-Z0-9]+(?:\.[-_a-zA-Z0-9]+)*))?$`,
+	)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return hURI, fmt.Errorf(
+			"could not match collect Helm URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	hURI.text = submt[0][0]
+	hURI.scheme = submt[0][1]
+	hURI.path = submt[0][2]
+	hURI.key = submt[0][3]
+
+	return hURI, nil
+}
+
+func (h HelmURI) Text() string {
+	return h.text
+}
+
+func (h HelmURI) Scheme() string {
+	return h.scheme
+}
+
+func (h HelmURI) Path() string {
+	return h.path
+}
+
+// Key returns the dotted path of the values. It is empty if not specified.
+func (h HelmURI) Key() string {
+	return h.key
+}
+
+type KustomizeURI struct {
+	text   string
+	scheme string
+	path   string
+	name   string
+}
+
+// NewKustomizeURI represents a URI for a local kustomize directory. The name
+// of the generated Secret is specified by the query "name".
+func NewKustomizeURI(uri string) (KustomizeURI, error) {
+	var kURI KustomizeURI
+
+	reg := regexp.MustCompile(
+		`^(kustomize):///?((?:[-_a-z0-9A-Z]+)(?:/[-_a-z0-9A-Z.]+)*)(?:\?name=([a-z0-9][-a-z0-9.]*))?$`,
+	)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return kURI, fmt.Errorf(
+			"could not match collect Kustomize URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	kURI.text = submt[0][0]
+	kURI.scheme = submt[0][1]
+	kURI.path = submt[0][2]
+	kURI.name = submt[0][3]
+
+	return kURI, nil
+}
+
+func (k KustomizeURI) Text() string {
+	return k.text
+}
+
+func (k KustomizeURI) Scheme() string {
+	return k.scheme
+}
+
+func (k KustomizeURI) Path() string {
+	return k.path
+}
+
+// Name returns the name of the generated Secret. It is empty if not specified.
+func (k KustomizeURI) Name() string {
+	return k.name
+}
+
+type CASURI struct {
+	text   string
+	scheme string
+	path   string
+}
+
+// NewCASURI represents a URI for a local content-addressable store. The path
+// is the root directory of the store.
+func NewCASURI(uri string) (CASURI, error) {
+	var cURI CASURI
+
+	reg := regexp.MustCompile("^(cas):///?((?:[-_a-z0-9A-Z]+)(?:/[-_a-z0-9A-Z.]+)*)$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return cURI, fmt.Errorf(
+			"could not match collect CAS URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	cURI.text = submt[0][0]
+	cURI.scheme = submt[0][1]
+	cURI.path = submt[0][2]
+
+	return cURI, nil
+}
+
+func (c CASURI) Text() string {
+	return c.text
+}
+
+func (c CASURI) Scheme() string {
+	return c.scheme
+}
+
+func (c CASURI) Path() string {
+	return c.path
+}
+
+type DNSURI struct {
+	text       string
+	scheme     string
+	path       string
+	provider   string
+	zone       string
+	name       string
+	recordType string
+}
+
+// NewDNSURI represents a URI for a DNS record set. The path is in the
+// "<route53|rfc2136>/<zone>/<record name>" format. The zone is the hosted zone
+// ID of Route53, or the zone name of RFC2136. The record type is specified by
+// the query "type", "TLSA" or "CERT", and the default is "TLSA".
+func NewDNSURI(uri string) (DNSURI, error) {
+	var dURI DNSURI
+
+	reg := regexp.MustCompile(
+		`^(dns)://((route53|rfc2136)/([-_.a-zA-Z0-9]+)/([-_*a-zA-Z0-9]+(?:\.[-_a-zA-Z0-9]+)*\.?))(?:\?type=(TLSA|CERT))?$`,
+	)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return dURI, fmt.Errorf(
+			"could not match collect DNS URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	dURI.text = submt[0][0]
+	dURI.scheme = submt[0][1]
+	dURI.path = submt[0][2]
+	dURI.provider = submt[0][3]
+	dURI.zone = submt[0][4]
+	dURI.name = submt[0][5]
+	dURI.recordType = submt[0][6]
+	if dURI.recordType == "" {
+		dURI.recordType = "TLSA"
+	}
+
+	return dURI, nil
+}
+
+func (d DNSURI) Text() string {
+	return d.text
+}
+
+func (d DNSURI) Scheme() string {
+	return d.scheme
+}
+
+func (d DNSURI) Path() string {
+	return d.path
+}
+
+// Provider returns "route53" or "rfc2136".
+func (d DNSURI) Provider() string {
+	return d.provider
+}
+
+func (d DNSURI) Zone() string {
+	return d.zone
+}
+
+// Name returns the owner name of the record set, like "_443._tcp.example.com".
+func (d DNSURI) Name() string {
+	return d.name
+}
+
+// RecordType returns "TLSA" or "CERT".
+func (d DNSURI) RecordType() string {
+	return d.recordType
+}
+
+type UnixURI struct {
+	text   string
+	scheme string
+	path   string
+	frame  string
+}
+
+// NewUnixURI represents a URI for a Unix domain socket. Unlike the file scheme,
+// the leading slash of the path is kept, so "unix:///run/agent.sock" is the
+// absolute path. The framing of the content is specified by the query "frame",
+// "length" or "raw", and the default is "length".
+func NewUnixURI(uri string) (UnixURI, error) {
+	var uURI UnixURI
+
+	reg := regexp.MustCompile(`^(unix)://(/?[-_a-z0-9A-Z.]+(?:/[-_a-z0-9A-Z.]+)*)(?:\?frame=(length|raw))?$`)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return uURI, fmt.Errorf(
+			"could not match collect Unix URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	uURI.text = submt[0][0]
+	uURI.scheme = submt[0][1]
+	uURI.path = submt[0][2]
+	uURI.frame = submt[0][3]
+	if uURI.frame == "" {
+		uURI.frame = "length"
+	}
+
+	return uURI, nil
+}
+
+func (u UnixURI) Text() string {
+	return u.text
+}
+
+func (u UnixURI) Scheme() string {
+	return u.scheme
+}
+
+func (u UnixURI) Path() string {
+	return u.path
+}
+
+// Frame returns "length" or "raw".
+func (u UnixURI) Frame() string {
+	return u.frame
+}
+
+type CommandURI struct {
+	text   string
+	scheme string
+	name   string
+}
+
+// NewCommandURI represents a URI for a command reading the content from its
+// standard input. The name only identifies the destination, and the command
+// is configured apart from the URI.
+func NewCommandURI(uri string) (CommandURI, error) {
+	var cURI CommandURI
+
+	reg := regexp.MustCompile("^(cmd)://([-_.a-zA-Z0-9]+)$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return cURI, fmt.Errorf(
+			"could not match collect Command URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	cURI.text = submt[0][0]
+	cURI.scheme = submt[0][1]
+	cURI.name = submt[0][2]
+
+	return cURI, nil
+}
+
+func (c CommandURI) Text() string {
+	return c.text
+}
+
+func (c CommandURI) Scheme() string {
+	return c.scheme
+}
+
+func (c CommandURI) Name() string {
+	return c.name
+}
+
+type PluginURI struct {
+	text   string
+	scheme string
+	name   string
+	path   string
+}
+
+// NewPluginURI represents a URI for the external program of a plugin, like
+// "plugin://portal/ca/root?env=prod". The name is of the plugin, and the rest
+// is the path handed to the program as it is.
+func NewPluginURI(uri string) (PluginURI, error) {
+	var pURI PluginURI
+
+	reg := regexp.MustCompile("^(plugin)://([a-z0-9][-_a-z0-9]*)(?:/(.*))?$")
+	mt := reg.MatchString(uri)
+	if !mt {
+		return pURI, fmt.Errorf(
+			"could not match collect Plugin URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	pURI.text = submt[0][0]
+	pURI.scheme = submt[0][1]
+	pURI.name = submt[0][2]
+	pURI.path = submt[0][3]
+
+	return pURI, nil
+}
+
+func (p PluginURI) Text() string {
+	return p.text
+}
+
+func (p PluginURI) Scheme() string {
+	return p.scheme
+}
+
+// Path returns the part after the name of the plugin, including the query.
+func (p PluginURI) Path() string {
+	return p.path
+}
+
+func (p PluginURI) Name() string {
+	return p.name
+}
+
+type MQTTURI struct {
+	text   string
+	scheme string
+	path   string
+	host   string
+	topic  string
+}
+
+// NewMQTTURI represents a URI for a topic of an MQTT broker. The path is in
+// the "<host>[:<port>]/<topic>" format, and the topic must not contain the
+// wildcards. The "mqtts" scheme connects to the broker with TLS.
+func NewMQTTURI(uri string) (MQTTURI, error) {
+	var mURI MQTTURI
+
+	reg := regexp.MustCompile(`^(mqtts?)://(([-a-zA-Z0-9.]+(?::[0-9]+)?)/([-_.a-zA-Z0-9]+(?:/[-_.a-zA-Z0-9]+)*))$`)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return mURI, fmt.Errorf(
+			"could not match collect MQTT URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	mURI.text = submt[0][0]
+	mURI.scheme = submt[0][1]
+	mURI.path = submt[0][2]
+	mURI.host = submt[0][3]
+	mURI.topic = submt[0][4]
+
+	return mURI, nil
+}
+
+func (m MQTTURI) Text() string {
+	return m.text
+}
+
+func (m MQTTURI) Scheme() string {
+	return m.scheme
+}
+
+func (m MQTTURI) Path() string {
+	return m.path
+}
+
+// Host returns the "<host>[:<port>]" of the broker.
+func (m MQTTURI) Host() string {
+	return m.host
+}
+
+func (m MQTTURI) Topic() string {
+	return m.topic
+}
+
+type NATSURI struct {
+	text    string
+	scheme  string
+	path    string
+	host    string
+	subject string
+}
+
+// NewNATSURI represents a URI for a subject of a NATS server. The path is in
+// the "<host>[:<port>]/<subject>" format, and the subject is the tokens
+// separated by dots without the wildcards.
+func NewNATSURI(uri string) (NATSURI, error) {
+	var nURI NATSURI
+
+	reg := regexp.MustCompile(`^(nats)://(([-a-zA-Z0-9.]+(?::[0-9]+)?)/([-_a-zA-Z0-9]+(?:\.[-_a-zA-Z0-9]+)*))$`)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return nURI, fmt.Errorf(
+			"could not match collect NATS URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	nURI.text = submt[0][0]
+	nURI.scheme = submt[0][1]
+	nURI.path = submt[0][2]
+	nURI.host = submt[0][3]
+	nURI.subject = submt[0][4]
+
+	return nURI, nil
+}
+
+func (n NATSURI) Text() string {
+	return n.text
+}
+
+func (n NATSURI) Scheme() string {
+	return n.scheme
+}
+
+func (n NATSURI) Path() string {
+	return n.path
+}
+
+// Host returns the "<host>[:<port>]" of the server.
+func (n NATSURI) Host() string {
+	return n.host
+}
+
+func (n NATSURI) Subject() string {
+	return n.subject
+}
+
+type WorkloadURI struct {
+	text   string
+	scheme string
+	path   string
+	asset  string
+}
+
+// NewWorkloadURI represents a URI for the SPIFFE Workload API, like the SPIRE
+// agent. The path is the Unix domain socket of the API, and it is empty if the
+// socket is given by the environment variable "SPIFFE_ENDPOINT_SOCKET". The
+// asset is specified by the query "asset", "bundle", "svid" or "key", and the
+// default is "bundle".
+func NewWorkloadURI(uri string) (WorkloadURI, error) {
+	var wURI WorkloadURI
+
+	reg := regexp.MustCompile(`^(workload)://(/?[-_a-z0-9A-Z.]+(?:/[-_a-z0-9A-Z.]+)*)?(?:\?asset=(bundle|svid|key))?$`)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return wURI, fmt.Errorf(
+			"could not match collect Workload URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	wURI.text = submt[0][0]
+	wURI.scheme = submt[0][1]
+	wURI.path = submt[0][2]
+	wURI.asset = submt[0][3]
+	if wURI.asset == "" {
+		wURI.asset = "bundle"
+	}
+
+	return wURI, nil
+}
+
+func (w WorkloadURI) Text() string {
+	return w.text
+}
+
+func (w WorkloadURI) Scheme() string {
+	return w.scheme
+}
+
+func (w WorkloadURI) Path() string {
+	return w.path
+}
+
+// Asset returns "bundle", "svid" or "key".
+func (w WorkloadURI) Asset() string {
+	return w.asset
+}
+
+type DynamoDBURI struct {
+	text   string
+	scheme string
+	path   string
+	table  string
+	key    string
+}
+
+// NewDynamoDBURI represents a URI for an item in an AWS DynamoDB table. The
+// path is in the "<table>/<key>" format, and the key is the value of the
+// partition key of the item.
+func NewDynamoDBURI(uri string) (DynamoDBURI, error) {
+	var dURI DynamoDBURI
+
+	reg := regexp.MustCompile(`^(dynamodb)://(([-_.a-zA-Z0-9]{3,255})/([^?]+))$`)
+	mt := reg.MatchString(uri)
+	if !mt {
+		return dURI, fmt.Errorf(
+			"could not match collect DynamoDB URI pattern with %s: %w", uri, ErrInvalidURI,
+		)
+	}
+
+	submt := reg.FindAllStringSubmatch(uri, -1)
+	dURI.text = submt[0][0]
+	dURI.scheme = submt[0][1]
+	dURI.path = submt[0][2]
+	dURI.table = submt[0][3]
+	dURI.key = submt[0][4]
+
+	return dURI, nil
+}
+
+func (d DynamoDBURI) Text() string {
+	return d.text
+}
+
+func (d DynamoDBURI) Scheme() string {
+	return d.scheme
+}
+
+func (d DynamoDBURI) Path() string {
+	return d.path
+}
+
+// Table returns the name of the table.
+func (d DynamoDBURI) Table() string {
+	return d.table
+}
+
+// Key returns the value of the partition key of the item.
+func (d DynamoDBURI) Key() string {
+	return d.key
+}
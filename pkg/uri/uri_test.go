@@ -57,6 +57,27 @@ func Test_NewFSURI(t *testing.T) {
 			"a-bc/d_efg/hi222j.test",
 			nil,
 		},
+		{
+			"OK:windows drive",
+			"file://C:/certs/out.pem",
+			"file",
+			"C:/certs/out.pem",
+			nil,
+		},
+		{
+			"OK:windows backslashes",
+			`file:///c:\certs\out.pem`,
+			"file",
+			"c:/certs/out.pem",
+			nil,
+		},
+		{
+			"NG:windows drive only",
+			"file://C:",
+			"",
+			"",
+			ErrInvalidURI,
+		},
 		{
 			"NG:scheme:undefined",
 			"ng://ng",
@@ -77,6 +98,52 @@ func Test_NewFSURI(t *testing.T) {
 	}
 }
 
+func TestFSURI_Under(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		testcase string
+		uri      string
+		root     string
+		path     string
+		err      error
+	}{
+		{"OK:relative root", "file://ca/root-ca.crt", "mnt", "mnt/ca/root-ca.crt", nil},
+		{"OK:absolute root", "file:///ca/root-ca.crt", "/mnt/pki", "/mnt/pki/ca/root-ca.crt", nil},
+		{"OK:windows root", "file://ca/root-ca.crt", `C:\pki`, "C:/pki/ca/root-ca.crt", nil},
+		{"OK:parent in root", "file://ca/../root-ca.crt", "/mnt", "/mnt/root-ca.crt", nil},
+		{"NG:parent of root", "file://ca/../../root-ca.crt", "/mnt", "", ErrOutsideRoot},
+		{"NG:windows drive", "file://C:/ca/root-ca.crt", "/mnt", "", ErrOutsideRoot},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewFSURI(d.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			uri, err = uri.Under(d.root)
+			if !errors.Is(err, d.err) {
+				t.Fatalf("Expected %#v error but got: %#v", d.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if uri.Path() != d.path {
+				t.Errorf("Expected path is %s but got: %s", d.path, uri.Path())
+			}
+			if uri.Text() != d.uri {
+				t.Errorf("Expected uri text is %s but got: %s", d.uri, uri.Text())
+			}
+		})
+	}
+}
+
 func Test_NewEnvURI(t *testing.T) {
 	t.Parallel()
 
@@ -122,6 +189,44 @@ func Test_NewEnvURI(t *testing.T) {
 	}
 }
 
+func Test_NewStdoutURI(t *testing.T) {
+	t.Parallel()
+
+	data := []uriCommonTestData{
+		{
+			"OK:scheme:stdout",
+			"stdout://",
+			"stdout",
+			"",
+			nil,
+		},
+		{
+			"NG:path:not empty",
+			"stdout://abc",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+		{
+			"NG:scheme:undefined",
+			"ng://",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewStdoutURI(d.uri)
+			testCommonTestData(t, d, uri.Text(), uri.Scheme(), uri.Path(), err)
+		})
+	}
+}
+
 func Test_NewGitHubURI(t *testing.T) {
 	t.Parallel()
 
@@ -191,9 +296,10 @@ func Test_NewS3URI(t *testing.T) {
 	data := []struct {
 		uriCommonTestData
 		// want
-		bucket string
-		key    string
-		err    error
+		bucket    string
+		key       string
+		versionID string
+		err       error
 	}{
 		{
 			uriCommonTestData: uriCommonTestData{
@@ -219,6 +325,19 @@ func Test_NewS3URI(t *testing.T) {
 			key:    "fooKey/barKey/bazKey",
 			err:    nil,
 		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:with versionId",
+				"s3://fooBucket/fooKey/barKey?versionId=3HL4kqtJlcpXroDTDmJ.rmSpXd3dIbrHY",
+				"s3",
+				"fooBucket/fooKey/barKey?versionId=3HL4kqtJlcpXroDTDmJ.rmSpXd3dIbrHY",
+				nil,
+			},
+			bucket:    "fooBucket",
+			key:       "fooKey/barKey",
+			versionID: "3HL4kqtJlcpXroDTDmJ.rmSpXd3dIbrHY",
+			err:       nil,
+		},
 		{
 			uriCommonTestData: uriCommonTestData{
 				"NG:invalid URI",
@@ -258,6 +377,993 @@ func Test_NewS3URI(t *testing.T) {
 			if uri.Key() != d.key {
 				t.Errorf("Expected key is %s but got: %s", d.key, uri.Key())
 			}
+			if uri.VersionID() != d.versionID {
+				t.Errorf("Expected versionId is %s but got: %s", d.versionID, uri.VersionID())
+			}
+		})
+	}
+}
+
+func Test_NewVaultURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		mount      string
+		secretPath string
+		key        string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:without key",
+				"vault://secret/data/pki/server-chain",
+				"vault",
+				"secret/data/pki/server-chain",
+				nil,
+			},
+			mount:      "secret",
+			secretPath: "pki/server-chain",
+			key:        "",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:with key",
+				"vault://kv/data/server.crt?key=chain",
+				"vault",
+				"kv/data/server.crt",
+				nil,
+			},
+			mount:      "kv",
+			secretPath: "server.crt",
+			key:        "chain",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:not KV v2 data path",
+				"vault://secret/pki/server-chain",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:scheme:undefined",
+				"ng://secret/data/pki",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewVaultURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+			if err != nil {
+				return
+			}
+
+			if uri.Mount() != d.mount {
+				t.Errorf("Expected mount is %s but got: %s", d.mount, uri.Mount())
+			}
+			if uri.SecretPath() != d.secretPath {
+				t.Errorf("Expected secret path is %s but got: %s", d.secretPath, uri.SecretPath())
+			}
+			if uri.Key() != d.key {
+				t.Errorf("Expected key is %s but got: %s", d.key, uri.Key())
+			}
+		})
+	}
+}
+
+func Test_NewSecretsManagerURI(t *testing.T) {
+	t.Parallel()
+
+	data := []uriCommonTestData{
+		{
+			"OK:scheme:secretsmanager",
+			"secretsmanager://prod/tls/server-chain",
+			"secretsmanager",
+			"prod/tls/server-chain",
+			nil,
+		},
+		{
+			"NG:path:invalid character",
+			"secretsmanager://prod/tls/server chain",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+		{
+			"NG:scheme:undefined",
+			"ng://prod/tls/server-chain",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewSecretsManagerURI(d.uri)
+			testCommonTestData(t, d, uri.Text(), uri.Scheme(), uri.Path(), err)
+		})
+	}
+}
+
+func Test_NewSSMURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		kmsKeyID string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:hierarchy without kmsKeyId",
+				"ssm:///prod/tls/server-chain",
+				"ssm",
+				"/prod/tls/server-chain",
+				nil,
+			},
+			kmsKeyID: "",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:with kmsKeyId",
+				"ssm://server-chain?kmsKeyId=alias/cannect",
+				"ssm",
+				"server-chain",
+				nil,
+			},
+			kmsKeyID: "alias/cannect",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:scheme:undefined",
+				"ng:///prod/tls/server-chain",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewSSMURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+
+			if uri.KMSKeyID() != d.kmsKeyID {
+				t.Errorf("Expected KMS key ID is %s but got: %s", d.kmsKeyID, uri.KMSKeyID())
+			}
+		})
+	}
+}
+
+func Test_NewGCSURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		bucket string
+		object string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:with logical hierarchy",
+				"gcs://foo-bucket/pki/chain.crt",
+				"gcs",
+				"foo-bucket/pki/chain.crt",
+				nil,
+			},
+			bucket: "foo-bucket",
+			object: "pki/chain.crt",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:object is missing",
+				"gcs://foo-bucket/",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewGCSURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+
+			if uri.Bucket() != d.bucket {
+				t.Errorf("Expected bucket is %s but got: %s", d.bucket, uri.Bucket())
+			}
+			if uri.Object() != d.object {
+				t.Errorf("Expected object is %s but got: %s", d.object, uri.Object())
+			}
+		})
+	}
+}
+
+func Test_NewAzBlobURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		account   string
+		container string
+		blob      string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:with virtual directory",
+				"azblob://fooaccount/pki-container/ca/chain.crt",
+				"azblob",
+				"fooaccount/pki-container/ca/chain.crt",
+				nil,
+			},
+			account:   "fooaccount",
+			container: "pki-container",
+			blob:      "ca/chain.crt",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:container is missing",
+				"azblob://fooaccount/chain.crt",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewAzBlobURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+
+			if uri.Account() != d.account {
+				t.Errorf("Expected account is %s but got: %s", d.account, uri.Account())
+			}
+			if uri.Container() != d.container {
+				t.Errorf("Expected container is %s but got: %s", d.container, uri.Container())
+			}
+			if uri.Blob() != d.blob {
+				t.Errorf("Expected blob is %s but got: %s", d.blob, uri.Blob())
+			}
+		})
+	}
+}
+
+func Test_NewArchiveURI(t *testing.T) {
+	t.Parallel()
+
+	data := []uriCommonTestData{
+		{
+			"OK:scheme:zip",
+			"zip://dist/ca-bundle.zip",
+			"zip",
+			"dist/ca-bundle.zip",
+			nil,
+		},
+		{
+			"OK:scheme:tar",
+			"tar://dist/ca-bundle.tar.gz",
+			"tar",
+			"dist/ca-bundle.tar.gz",
+			nil,
+		},
+		{
+			"NG:scheme:undefined",
+			"rar://dist/ca-bundle.rar",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewArchiveURI(d.uri)
+			testCommonTestData(t, d, uri.Text(), uri.Scheme(), uri.Path(), err)
+		})
+	}
+}
+
+func Test_NewWebhookURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		host string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:with port and query",
+				"https://inventory.example.com:8443/api/v1/bundles?env=prod",
+				"https",
+				"inventory.example.com:8443/api/v1/bundles",
+				nil,
+			},
+			host: "inventory.example.com:8443",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:host only",
+				"https://inventory.example.com",
+				"https",
+				"inventory.example.com",
+				nil,
+			},
+			host: "inventory.example.com",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:plain http",
+				"http://inventory.example.com/api/v1/bundles",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewWebhookURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+
+			if uri.Host() != d.host {
+				t.Errorf("Expected host is %s but got: %s", d.host, uri.Host())
+			}
+		})
+	}
+}
+
+func Test_NewKubernetesURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		namespace string
+		kind      string
+		name      string
+		key       string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:secret with key",
+				"k8s://pki/secret/ca-bundle?key=ca.crt",
+				"k8s",
+				"pki/secret/ca-bundle",
+				nil,
+			},
+			namespace: "pki",
+			kind:      "secret",
+			name:      "ca-bundle",
+			key:       "ca.crt",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:configmap without key",
+				"k8s://default/configmap/ca-bundle",
+				"k8s",
+				"default/configmap/ca-bundle",
+				nil,
+			},
+			namespace: "default",
+			kind:      "configmap",
+			name:      "ca-bundle",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:undefined kind",
+				"k8s://default/pod/ca-bundle",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewKubernetesURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+
+			if uri.Namespace() != d.namespace {
+				t.Errorf("Expected namespace is %s but got: %s", d.namespace, uri.Namespace())
+			}
+			if uri.Kind() != d.kind {
+				t.Errorf("Expected kind is %s but got: %s", d.kind, uri.Kind())
+			}
+			if uri.Name() != d.name {
+				t.Errorf("Expected name is %s but got: %s", d.name, uri.Name())
+			}
+			if uri.Key() != d.key {
+				t.Errorf("Expected key is %s but got: %s", d.key, uri.Key())
+			}
+		})
+	}
+}
+
+func Test_NewDockerURI(t *testing.T) {
+	t.Parallel()
+
+	data := []uriCommonTestData{
+		{
+			"OK:scheme:docker",
+			"docker://web_ca-bundle.crt",
+			"docker",
+			"web_ca-bundle.crt",
+			nil,
+		},
+		{
+			"NG:name with slash",
+			"docker://web/ca-bundle",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewDockerURI(d.uri)
+			testCommonTestData(t, d, uri.Text(), uri.Scheme(), uri.Path(), err)
+		})
+	}
+}
+
+func Test_NewHelmURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		key string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:with key",
+				"helm://charts/web/values-ca.yaml?key=ingress.tls.ca",
+				"helm",
+				"charts/web/values-ca.yaml",
+				nil,
+			},
+			key: "ingress.tls.ca",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:empty key segment",
+				"helm://charts/web/values-ca.yaml?key=ingress..ca",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewHelmURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+
+			if uri.Key() != d.key {
+				t.Errorf("Expected key is %s but got: %s", d.key, uri.Key())
+			}
+		})
+	}
+}
+
+func Test_NewKustomizeURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		name string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:with name",
+				"kustomize://overlays/prod/ca?name=ca-bundle",
+				"kustomize",
+				"overlays/prod/ca",
+				nil,
+			},
+			name: "ca-bundle",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:upper case name",
+				"kustomize://overlays/prod/ca?name=CA",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewKustomizeURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+
+			if uri.Name() != d.name {
+				t.Errorf("Expected name is %s but got: %s", d.name, uri.Name())
+			}
+		})
+	}
+}
+
+func Test_NewCASURI(t *testing.T) {
+	t.Parallel()
+
+	data := []uriCommonTestData{
+		{
+			"OK:scheme:cas",
+			"cas://var/lib/cannect/store",
+			"cas",
+			"var/lib/cannect/store",
+			nil,
+		},
+		{
+			"NG:scheme:undefined",
+			"ng://var/lib/cannect/store",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewCASURI(d.uri)
+			testCommonTestData(t, d, uri.Text(), uri.Scheme(), uri.Path(), err)
+		})
+	}
+}
+
+func Test_NewDNSURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		provider   string
+		zone       string
+		name       string
+		recordType string
+	}{
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:route53 tlsa",
+				"dns://route53/Z0123456789ABC/_443._tcp.example.com",
+				"dns",
+				"route53/Z0123456789ABC/_443._tcp.example.com",
+				nil,
+			},
+			provider:   "route53",
+			zone:       "Z0123456789ABC",
+			name:       "_443._tcp.example.com",
+			recordType: "TLSA",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"OK:rfc2136 cert",
+				"dns://rfc2136/example.com/ca.example.com.?type=CERT",
+				"dns",
+				"rfc2136/example.com/ca.example.com.",
+				nil,
+			},
+			provider:   "rfc2136",
+			zone:       "example.com",
+			name:       "ca.example.com.",
+			recordType: "CERT",
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:undefined provider",
+				"dns://cloudflare/example.com/_443._tcp.example.com",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+		{
+			uriCommonTestData: uriCommonTestData{
+				"NG:undefined type",
+				"dns://route53/Z0123456789ABC/_443._tcp.example.com?type=TXT",
+				"",
+				"",
+				ErrInvalidURI,
+			},
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewDNSURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+
+			if uri.Provider() != d.provider {
+				t.Errorf("Expected provider is %s but got: %s", d.provider, uri.Provider())
+			}
+			if uri.Zone() != d.zone {
+				t.Errorf("Expected zone is %s but got: %s", d.zone, uri.Zone())
+			}
+			if uri.Name() != d.name {
+				t.Errorf("Expected name is %s but got: %s", d.name, uri.Name())
+			}
+			if uri.RecordType() != d.recordType {
+				t.Errorf("Expected record type is %s but got: %s", d.recordType, uri.RecordType())
+			}
+		})
+	}
+}
+
+func Test_NewUnixURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		frame string
+	}{
+		{
+			uriCommonTestData{"OK:absolute", "unix:///run/agent.sock", "unix", "/run/agent.sock", nil},
+			"length",
+		},
+		{
+			uriCommonTestData{"OK:relative", "unix://agent.sock?frame=raw", "unix", "agent.sock", nil},
+			"raw",
+		},
+		{
+			uriCommonTestData{"NG:frame:undefined", "unix:///run/agent.sock?frame=http", "", "", ErrInvalidURI},
+			"",
+		},
+		{
+			uriCommonTestData{"NG:scheme:undefined", "ng:///run/agent.sock", "", "", ErrInvalidURI},
+			"",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewUnixURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+			if err == nil && uri.Frame() != d.frame {
+				t.Errorf("Expected frame is %s but got: %s", d.frame, uri.Frame())
+			}
+		})
+	}
+}
+
+func Test_NewCommandURI(t *testing.T) {
+	t.Parallel()
+
+	data := []uriCommonTestData{
+		{
+			"OK:scheme:cmd",
+			"cmd://vault-kv",
+			"cmd",
+			"vault-kv",
+			nil,
+		},
+		{
+			"NG:path",
+			"cmd://vault/kv",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+		{
+			"NG:scheme:undefined",
+			"ng://vault-kv",
+			"",
+			"",
+			ErrInvalidURI,
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewCommandURI(d.uri)
+			testCommonTestData(t, d, uri.Text(), uri.Scheme(), uri.Name(), err)
+		})
+	}
+}
+
+func Test_NewPluginURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		name string
+	}{
+		{
+			uriCommonTestData{"OK:scheme:plugin", "plugin://portal/ca/root?env=prod", "plugin", "ca/root?env=prod", nil},
+			"portal",
+		},
+		{
+			uriCommonTestData{"OK:no path", "plugin://legacy-ca", "plugin", "", nil},
+			"legacy-ca",
+		},
+		{
+			uriCommonTestData{"NG:name", "plugin://Portal/ca/root", "", "", ErrInvalidURI},
+			"",
+		},
+		{
+			uriCommonTestData{"NG:scheme:undefined", "ng://portal/ca/root", "", "", ErrInvalidURI},
+			"",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewPluginURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+			if uri.Name() != d.name {
+				t.Errorf("Expected name is %s but got: %s", d.name, uri.Name())
+			}
+		})
+	}
+}
+
+func Test_NewMQTTURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		host  string
+		topic string
+	}{
+		{
+			uriCommonTestData{
+				"OK:scheme:mqtt", "mqtt://broker.example.com/fleet/ca/bundle", "mqtt",
+				"broker.example.com/fleet/ca/bundle", nil,
+			},
+			"broker.example.com",
+			"fleet/ca/bundle",
+		},
+		{
+			uriCommonTestData{
+				"OK:scheme:mqtts", "mqtts://broker.example.com:8883/ca", "mqtts",
+				"broker.example.com:8883/ca", nil,
+			},
+			"broker.example.com:8883",
+			"ca",
+		},
+		{
+			uriCommonTestData{"NG:wildcard", "mqtt://broker.example.com/fleet/+/ca", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+		{
+			uriCommonTestData{"NG:no topic", "mqtt://broker.example.com/", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+		{
+			uriCommonTestData{"NG:scheme:undefined", "ng://broker.example.com/ca", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewMQTTURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+			if err == nil && (uri.Host() != d.host || uri.Topic() != d.topic) {
+				t.Errorf("Expected host and topic are %s and %s but got: %s and %s", d.host, d.topic, uri.Host(), uri.Topic())
+			}
+		})
+	}
+}
+
+func Test_NewNATSURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		host    string
+		subject string
+	}{
+		{
+			uriCommonTestData{
+				"OK:scheme:nats", "nats://nats.example.com:4222/fleet.ca.bundle", "nats",
+				"nats.example.com:4222/fleet.ca.bundle", nil,
+			},
+			"nats.example.com:4222",
+			"fleet.ca.bundle",
+		},
+		{
+			uriCommonTestData{"NG:wildcard", "nats://nats.example.com/fleet.*", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+		{
+			uriCommonTestData{"NG:slash", "nats://nats.example.com/fleet/ca", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+		{
+			uriCommonTestData{"NG:scheme:undefined", "ng://nats.example.com/ca", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewNATSURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+			if err == nil && (uri.Host() != d.host || uri.Subject() != d.subject) {
+				t.Errorf("Expected host and subject are %s and %s but got: %s and %s", d.host, d.subject, uri.Host(), uri.Subject())
+			}
+		})
+	}
+}
+
+func Test_NewWorkloadURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		asset string
+	}{
+		{
+			uriCommonTestData{
+				"OK:absolute", "workload:///run/spire/sockets/agent.sock", "workload",
+				"/run/spire/sockets/agent.sock", nil,
+			},
+			"bundle",
+		},
+		{
+			uriCommonTestData{"OK:environment", "workload://?asset=svid", "workload", "", nil},
+			"svid",
+		},
+		{
+			uriCommonTestData{"OK:key", "workload://agent.sock?asset=key", "workload", "agent.sock", nil},
+			"key",
+		},
+		{
+			uriCommonTestData{"NG:asset:undefined", "workload:///run/agent.sock?asset=jwt", "", "", ErrInvalidURI},
+			"",
+		},
+		{
+			uriCommonTestData{"NG:scheme:undefined", "ng:///run/agent.sock", "", "", ErrInvalidURI},
+			"",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewWorkloadURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+			if err == nil && uri.Asset() != d.asset {
+				t.Errorf("Expected asset is %s but got: %s", d.asset, uri.Asset())
+			}
+		})
+	}
+}
+
+func Test_NewDynamoDBURI(t *testing.T) {
+	t.Parallel()
+
+	data := []struct {
+		uriCommonTestData
+		// want
+		table string
+		key   string
+	}{
+		{
+			uriCommonTestData{
+				"OK:scheme:dynamodb", "dynamodb://cannect-state/fleet/web", "dynamodb",
+				"cannect-state/fleet/web", nil,
+			},
+			"cannect-state",
+			"fleet/web",
+		},
+		{
+			uriCommonTestData{"NG:key:empty", "dynamodb://cannect-state/", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+		{
+			uriCommonTestData{"NG:table:short", "dynamodb://ca/web", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+		{
+			uriCommonTestData{"NG:scheme:undefined", "ng://cannect-state/web", "", "", ErrInvalidURI},
+			"",
+			"",
+		},
+	}
+
+	for _, d := range data {
+		d := d
+		t.Run(d.testcase, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := NewDynamoDBURI(d.uri)
+			testCommonTestData(t, d.uriCommonTestData, uri.Text(), uri.Scheme(), uri.Path(), err)
+			if err == nil && (uri.Table() != d.table || uri.Key() != d.key) {
+				t.Errorf("Expected table and key are %s and %s but got: %s and %s", d.table, d.key, uri.Table(), uri.Key())
+			}
 		})
 	}
 }